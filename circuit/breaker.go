@@ -53,6 +53,7 @@ type BreakerSettings struct {
 
 type breakerImplementation interface {
 	Allow() (func(bool), bool)
+	closed() bool
 }
 
 type voidBreaker struct{}
@@ -143,6 +144,8 @@ func (b voidBreaker) Allow() (func(bool), bool) {
 	return func(bool) {}, true
 }
 
+func (b voidBreaker) closed() bool { return true }
+
 func newBreaker(s BreakerSettings) *Breaker {
 	var impl breakerImplementation
 	switch s.Type {
@@ -167,6 +170,12 @@ func (b *Breaker) Allow() (func(bool), bool) {
 	return b.impl.Allow()
 }
 
+// Closed returns true if the breaker is in the closed state. Unlike Allow, it doesn't count as a request in the
+// half-open state.
+func (b *Breaker) Closed() bool {
+	return b.impl.closed()
+}
+
 func (b *Breaker) idle(now time.Time) bool {
 	return now.Sub(b.ts) > b.settings.IdleTTL
 }
@@ -43,3 +43,7 @@ func (b *consecutiveBreaker) Allow() (func(bool), bool) {
 	}
 	return done, true
 }
+
+func (b *consecutiveBreaker) closed() bool {
+	return b.gb.State() == gobreaker.StateClosed
+}
@@ -76,3 +76,7 @@ func (b *rateBreaker) Allow() (func(bool), bool) {
 		done(success)
 	}, true
 }
+
+func (b *rateBreaker) closed() bool {
+	return b.gb.State() == gobreaker.StateClosed
+}
@@ -113,3 +113,29 @@ func (r *Registry) Get(s BreakerSettings) *Breaker {
 
 	return r.get(s)
 }
+
+// HasHost returns true if the registry was initialized with settings for the provided host.
+func (r *Registry) HasHost(host string) bool {
+	_, ok := r.hostSettings[host]
+	return ok
+}
+
+// Closed returns true if the circuit breaker of the host, with the settings of the host, is closed. It doesn't
+// create a new breaker, and when there is no active breaker for the host, e.g. because it was not used yet or it
+// was idle, the host is considered closed. The breakers created with route specific settings are not checked.
+func (r *Registry) Closed(host string) bool {
+	s := r.mergeDefaults(BreakerSettings{Host: host})
+	if s.Type == BreakerNone || s.Type == BreakerDisabled {
+		return true
+	}
+
+	r.mx.Lock()
+	b, ok := r.lookup[s]
+	if ok && b.idle(time.Now()) {
+		ok = false
+	}
+
+	r.mx.Unlock()
+
+	return !ok || b.Closed()
+}
@@ -32,6 +32,8 @@ type Config struct {
 	// generic:
 	Address                         string         `yaml:"address"`
 	InsecureAddress                 string         `yaml:"insecure-address"`
+	UnixSocketAddress               string         `yaml:"unix-socket-address"`
+	AcceptProxyProtocol             bool           `yaml:"accept-proxy-protocol"`
 	EnableTCPQueue                  bool           `yaml:"enable-tcp-queue"`
 	ExpectedBytesPerRequest         int            `yaml:"expected-bytes-per-request"`
 	MaxTCPListenerConcurrency       int            `yaml:"max-tcp-listener-concurrency"`
@@ -51,6 +53,7 @@ type Config struct {
 	PluginDir                       string         `yaml:"plugindir"`
 	LoadBalancerHealthCheckInterval time.Duration  `yaml:"lb-healthcheck-interval"`
 	ReverseSourcePredicate          bool           `yaml:"reverse-source-predicate"`
+	ClientASNDatabase               string         `yaml:"client-asn-database"`
 	RemoveHopHeaders                bool           `yaml:"remove-hop-headers"`
 	RfcPatchPath                    bool           `yaml:"rfc-patch-path"`
 	MaxAuditBody                    int            `yaml:"max-audit-body"`
@@ -61,12 +64,18 @@ type Config struct {
 	Ratelimits                      ratelimitFlags `yaml:"ratelimits"`
 	EnableRouteFIFOMetrics          bool           `yaml:"enable-route-fifo-metrics"`
 	EnableRouteLIFOMetrics          bool           `yaml:"enable-route-lifo-metrics"`
+	EnablePredicateMetrics          bool           `yaml:"enable-predicate-metrics"`
+	PredicateMetricsMaxRoutes       int            `yaml:"predicate-metrics-max-routes"`
 	MetricsFlavour                  *listFlag      `yaml:"metrics-flavour"`
 	FilterPlugins                   *pluginFlag    `yaml:"filter-plugin"`
 	PredicatePlugins                *pluginFlag    `yaml:"predicate-plugin"`
 	DataclientPlugins               *pluginFlag    `yaml:"dataclient-plugin"`
 	MultiPlugins                    *pluginFlag    `yaml:"multi-plugin"`
 	CompressEncodings               *listFlag      `yaml:"compress-encodings"`
+	EnableErrorEnrich               bool           `yaml:"enable-error-enrich"`
+	TeeKafkaBrokers                 *listFlag      `yaml:"tee-kafka-brokers"`
+	CohortWALFile                   string         `yaml:"cohort-wal-file"`
+	CohortWALBufferSize             int            `yaml:"cohort-wal-buffer-size"`
 
 	// logging, metrics, profiling, tracing:
 	EnablePrometheusMetrics             bool      `yaml:"enable-prometheus-metrics"`
@@ -113,25 +122,30 @@ type Config struct {
 	AccessLogJSONEnabled                bool      `yaml:"access-log-json-enabled"`
 	AccessLogStripQuery                 bool      `yaml:"access-log-strip-query"`
 	SuppressRouteUpdateLogs             bool      `yaml:"suppress-route-update-logs"`
+	SegmentGapBackend                   string    `yaml:"segment-gap-backend"`
 
 	// route sources:
-	EtcdUrls           string               `yaml:"etcd-urls"`
-	EtcdPrefix         string               `yaml:"etcd-prefix"`
-	EtcdTimeout        time.Duration        `yaml:"etcd-timeout"`
-	EtcdInsecure       bool                 `yaml:"etcd-insecure"`
-	EtcdOAuthToken     string               `yaml:"etcd-oauth-token"`
-	EtcdUsername       string               `yaml:"etcd-username"`
-	EtcdPassword       string               `yaml:"etcd-password"`
-	RoutesFile         string               `yaml:"routes-file"`
-	RoutesURLs         *listFlag            `yaml:"routes-urls"`
-	InlineRoutes       string               `yaml:"inline-routes"`
-	AppendFilters      *defaultFiltersFlags `yaml:"default-filters-append"`
-	PrependFilters     *defaultFiltersFlags `yaml:"default-filters-prepend"`
-	DisabledFilters    *listFlag            `yaml:"disabled-filters"`
-	EditRoute          routeChangerConfig   `yaml:"edit-route"`
-	CloneRoute         routeChangerConfig   `yaml:"clone-route"`
-	SourcePollTimeout  int64                `yaml:"source-poll-timeout"`
-	WaitFirstRouteLoad bool                 `yaml:"wait-first-route-load"`
+	EtcdUrls             string               `yaml:"etcd-urls"`
+	EtcdPrefix           string               `yaml:"etcd-prefix"`
+	EtcdTimeout          time.Duration        `yaml:"etcd-timeout"`
+	EtcdInsecure         bool                 `yaml:"etcd-insecure"`
+	EtcdOAuthToken       string               `yaml:"etcd-oauth-token"`
+	EtcdUsername         string               `yaml:"etcd-username"`
+	EtcdPassword         string               `yaml:"etcd-password"`
+	RoutesFile           string               `yaml:"routes-file"`
+	RoutesURLs           *listFlag            `yaml:"routes-urls"`
+	InlineRoutes         string               `yaml:"inline-routes"`
+	ConsulAddress        string               `yaml:"consul-address"`
+	ConsulServiceTag     string               `yaml:"consul-service-tag"`
+	RoutesObjectURL      string               `yaml:"routes-object-url"`
+	RoutesObjectEndpoint string               `yaml:"routes-object-endpoint"`
+	AppendFilters        *defaultFiltersFlags `yaml:"default-filters-append"`
+	PrependFilters       *defaultFiltersFlags `yaml:"default-filters-prepend"`
+	DisabledFilters      *listFlag            `yaml:"disabled-filters"`
+	EditRoute            routeChangerConfig   `yaml:"edit-route"`
+	CloneRoute           routeChangerConfig   `yaml:"clone-route"`
+	SourcePollTimeout    int64                `yaml:"source-poll-timeout"`
+	WaitFirstRouteLoad   bool                 `yaml:"wait-first-route-load"`
 
 	// Forwarded headers
 	ForwardedHeadersList            *listFlag            `yaml:"forwarded-headers"`
@@ -241,6 +255,9 @@ type Config struct {
 	ResponseHeaderTimeoutBackend time.Duration `yaml:"response-header-timeout-backend"`
 	ExpectContinueTimeoutBackend time.Duration `yaml:"expect-continue-timeout-backend"`
 	MaxIdleConnsBackend          int           `yaml:"max-idle-connection-backend"`
+	MaxConnsPerHostBackend       int           `yaml:"max-conns-per-host-backend"`
+	FailFastCohorts              *listFlag     `yaml:"fail-fast-cohorts"`
+	DeploymentSlots              *listFlag     `yaml:"deployment-slots"`
 	DisableHTTPKeepalives        bool          `yaml:"disable-http-keepalives"`
 
 	// swarm:
@@ -293,6 +310,8 @@ func NewConfig() *Config {
 	cfg.AppendFilters = &defaultFiltersFlags{}
 	cfg.PrependFilters = &defaultFiltersFlags{}
 	cfg.DisabledFilters = commaListFlag()
+	cfg.FailFastCohorts = commaListFlag()
+	cfg.DeploymentSlots = commaListFlag()
 	cfg.CloneRoute = routeChangerConfig{}
 	cfg.EditRoute = routeChangerConfig{}
 	cfg.KubernetesEastWestRangeDomains = commaListFlag()
@@ -300,6 +319,7 @@ func NewConfig() *Config {
 	cfg.ForwardedHeadersList = commaListFlag()
 	cfg.ForwardedHeadersExcludeCIDRList = commaListFlag()
 	cfg.CompressEncodings = commaListFlag("gzip", "deflate", "br")
+	cfg.TeeKafkaBrokers = commaListFlag()
 	cfg.LuaModules = commaListFlag()
 	cfg.LuaSources = commaListFlag()
 	cfg.Oauth2GrantTokeninfoKeys = commaListFlag()
@@ -310,6 +330,8 @@ func NewConfig() *Config {
 	// generic:
 	flag.StringVar(&cfg.Address, "address", ":9090", "network address that skipper should listen on")
 	flag.StringVar(&cfg.InsecureAddress, "insecure-address", "", "insecure network address that skipper should listen on when TLS is enabled")
+	flag.StringVar(&cfg.UnixSocketAddress, "unix-socket-address", "", "path of an additional unix domain socket that skipper should listen on, serving plain HTTP")
+	flag.BoolVar(&cfg.AcceptProxyProtocol, "accept-proxy-protocol", false, "accept the PROXY protocol header, v1 or v2, on the incoming connections of the main and the insecure listener, and reject the connections without it")
 	flag.BoolVar(&cfg.EnableTCPQueue, "enable-tcp-queue", false, "enable the TCP listener queue")
 	flag.IntVar(&cfg.ExpectedBytesPerRequest, "expected-bytes-per-request", 50*1024, "bytes per request, that is used to calculate concurrency limits to buffer connection spikes")
 	flag.IntVar(&cfg.MaxTCPListenerConcurrency, "max-tcp-listener-concurrency", 0, "sets hardcoded max for TCP listener concurrency, normally calculated based on available memory cgroups with max TODO")
@@ -329,6 +351,7 @@ func NewConfig() *Config {
 	flag.StringVar(&cfg.PluginDir, "plugindir", "", "set the directory to load plugins from, default is ./")
 	flag.DurationVar(&cfg.LoadBalancerHealthCheckInterval, "lb-healthcheck-interval", 0, "use to set the health checker interval to check healthiness of former dead or unhealthy routes")
 	flag.BoolVar(&cfg.ReverseSourcePredicate, "reverse-source-predicate", false, "reverse the order of finding the client IP from X-Forwarded-For header")
+	flag.StringVar(&cfg.ClientASNDatabase, "client-asn-database", "", "path of a MaxMind ASN database, enables the ClientASN predicate")
 	flag.BoolVar(&cfg.RemoveHopHeaders, "remove-hop-headers", false, "enables removal of Hop-Headers according to RFC-2616")
 	flag.BoolVar(&cfg.RfcPatchPath, "rfc-patch-path", false, "patches the incoming request path to preserve uncoded reserved characters according to RFC 2616 and RFC 3986")
 	flag.IntVar(&cfg.MaxAuditBody, "max-audit-body", 1024, "sets the max body to read to log in the audit log body")
@@ -339,12 +362,18 @@ func NewConfig() *Config {
 	flag.Var(&cfg.Ratelimits, "ratelimits", ratelimitsUsage)
 	flag.BoolVar(&cfg.EnableRouteFIFOMetrics, "enable-route-fifo-metrics", false, "enable metrics for the individual route FIFO queues")
 	flag.BoolVar(&cfg.EnableRouteLIFOMetrics, "enable-route-lifo-metrics", false, "enable metrics for the individual route LIFO queues")
+	flag.BoolVar(&cfg.EnablePredicateMetrics, "enable-predicate-metrics", false, "enable counting the matches and no-matches of the custom predicates")
+	flag.IntVar(&cfg.PredicateMetricsMaxRoutes, "predicate-metrics-max-routes", 100, "maximum number of routes whose predicate metrics contain the route id")
 	flag.Var(cfg.MetricsFlavour, "metrics-flavour", "Metrics flavour is used to change the exposed metrics format. Supported metric formats: 'codahale' and 'prometheus', you can select both of them")
 	flag.Var(cfg.FilterPlugins, "filter-plugin", "set a custom filter plugins to load, a comma separated list of name and arguments")
 	flag.Var(cfg.PredicatePlugins, "predicate-plugin", "set a custom predicate plugins to load, a comma separated list of name and arguments")
 	flag.Var(cfg.DataclientPlugins, "dataclient-plugin", "set a custom dataclient plugins to load, a comma separated list of name and arguments")
 	flag.Var(cfg.MultiPlugins, "multi-plugin", "set a custom multitype plugins to load, a comma separated list of name and arguments")
+	flag.BoolVar(&cfg.EnableErrorEnrich, "enable-error-enrich", false, "enables the errorEnrich filter, exposing the routing details of the cohort traffic in the error responses, meant only for test environments")
 	flag.Var(cfg.CompressEncodings, "compress-encodings", "set encodings supported for compression, the order defines priority when Accept-Header has equal quality values, see RFC 7231 section 5.3.1")
+	flag.Var(cfg.TeeKafkaBrokers, "tee-kafka-brokers", "comma separated list of Kafka brokers of the default producer of the teeToKafka filter")
+	flag.StringVar(&cfg.CohortWALFile, "cohort-wal-file", "", "file of the default sink of the cohortWAL filter, the cohort assignment records are appended to it")
+	flag.IntVar(&cfg.CohortWALBufferSize, "cohort-wal-buffer-size", 1024, "number of the cohort assignment records buffered per sink of the cohortWAL filter, the records exceeding it are dropped")
 
 	// logging, metrics, tracing:
 	flag.BoolVar(&cfg.EnablePrometheusMetrics, "enable-prometheus-metrics", false, "*Deprecated*: use metrics-flavour. Switch to Prometheus metrics format to expose metrics")
@@ -389,6 +418,7 @@ func NewConfig() *Config {
 	flag.BoolVar(&cfg.AccessLogJSONEnabled, "access-log-json-enabled", false, "when this flag is set, log in JSON format is used")
 	flag.BoolVar(&cfg.AccessLogStripQuery, "access-log-strip-query", false, "when this flag is set, the access log strips the query strings from the access log")
 	flag.BoolVar(&cfg.SuppressRouteUpdateLogs, "suppress-route-update-logs", false, "print only summaries on route updates/deletes")
+	flag.StringVar(&cfg.SegmentGapBackend, "segment-gap-backend", "", "when set, the requests falling into the intervals of the traffic splits not covered by the TrafficSegment predicates are routed to this backend")
 
 	// route sources:
 	flag.StringVar(&cfg.EtcdUrls, "etcd-urls", "", "urls of nodes in an etcd cluster, storing route definitions")
@@ -401,6 +431,10 @@ func NewConfig() *Config {
 	flag.StringVar(&cfg.RoutesFile, "routes-file", "", "file containing route definitions")
 	flag.Var(cfg.RoutesURLs, "routes-urls", "comma separated URLs to route definitions in eskip format")
 	flag.StringVar(&cfg.InlineRoutes, "inline-routes", "", "inline routes in eskip format")
+	flag.StringVar(&cfg.ConsulAddress, "consul-address", "", "address of the Consul HTTP API, enables the routes generated from the Consul service catalog, e.g. http://127.0.0.1:8500")
+	flag.StringVar(&cfg.ConsulServiceTag, "consul-service-tag", "skipper", "tag of the Consul services to generate routes for")
+	flag.StringVar(&cfg.RoutesObjectURL, "routes-object-url", "", "URL of an object in eskip format in an S3 or GCS bucket, polled for the routes, e.g. s3://my-bucket/routes.eskip")
+	flag.StringVar(&cfg.RoutesObjectEndpoint, "routes-object-endpoint", "", "overrides the address of the object store API of the -routes-object-url, e.g. of an S3 compatible store")
 	flag.Int64Var(&cfg.SourcePollTimeout, "source-poll-timeout", int64(3000), "polling timeout of the routing data sources, in milliseconds")
 	flag.Var(cfg.AppendFilters, "default-filters-append", "set of default filters to apply to append to all filters of all routes")
 	flag.Var(cfg.PrependFilters, "default-filters-prepend", "set of default filters to apply to prepend to all filters of all routes")
@@ -515,6 +549,9 @@ func NewConfig() *Config {
 	flag.DurationVar(&cfg.ResponseHeaderTimeoutBackend, "response-header-timeout-backend", 60*time.Second, "sets the HTTP response header timeout for backend connections")
 	flag.DurationVar(&cfg.ExpectContinueTimeoutBackend, "expect-continue-timeout-backend", 30*time.Second, "sets the HTTP expect continue timeout for backend connections")
 	flag.IntVar(&cfg.MaxIdleConnsBackend, "max-idle-connection-backend", 0, "sets the maximum idle connections for all backend connections")
+	flag.IntVar(&cfg.MaxConnsPerHostBackend, "max-conns-per-host-backend", 0, "sets the maximum connections per backend host, 0 means no limit")
+	flag.Var(cfg.FailFastCohorts, "fail-fast-cohorts", "comma separated list of TrafficSegment cohorts, whose requests fail fast with 503 when the backend host connections are saturated, see -max-conns-per-host-backend")
+	flag.Var(cfg.DeploymentSlots, "deployment-slots", "comma separated list of the deployment slots, that the cohorts can be rolled back to with the rollbackTo filter, using the /rollbacks endpoint of the support listener")
 	flag.BoolVar(&cfg.DisableHTTPKeepalives, "disable-http-keepalives", false, "forces backend to always create a new connection")
 	flag.BoolVar(&cfg.KubernetesEnableTLS, "kubernetes-enable-tls", false, "enable using kubnernetes resources to terminate tls")
 
@@ -715,7 +752,9 @@ func (c *Config) ToOptions() skipper.Options {
 		// generic:
 		Address:                         c.Address,
 		InsecureAddress:                 c.InsecureAddress,
+		UnixSocketAddress:               c.UnixSocketAddress,
 		StatusChecks:                    c.StatusChecks.values,
+		AcceptProxyProtocol:             c.AcceptProxyProtocol,
 		EnableTCPQueue:                  c.EnableTCPQueue,
 		ExpectedBytesPerRequest:         c.ExpectedBytesPerRequest,
 		MaxTCPListenerConcurrency:       c.MaxTCPListenerConcurrency,
@@ -730,6 +769,7 @@ func (c *Config) ToOptions() skipper.Options {
 		DefaultHTTPStatus:               c.DefaultHTTPStatus,
 		LoadBalancerHealthCheckInterval: c.LoadBalancerHealthCheckInterval,
 		ReverseSourcePredicate:          c.ReverseSourcePredicate,
+		ClientASNDatabase:               c.ClientASNDatabase,
 		MaxAuditBody:                    c.MaxAuditBody,
 		MaxMatcherBufferSize:            c.MaxMatcherBufferSize,
 		EnableBreakers:                  c.EnableBreakers,
@@ -738,6 +778,8 @@ func (c *Config) ToOptions() skipper.Options {
 		RatelimitSettings:               c.Ratelimits,
 		EnableRouteFIFOMetrics:          c.EnableRouteFIFOMetrics,
 		EnableRouteLIFOMetrics:          c.EnableRouteLIFOMetrics,
+		EnablePredicateMetrics:          c.EnablePredicateMetrics,
+		PredicateMetricsMaxRoutes:       c.PredicateMetricsMaxRoutes,
 		MetricsFlavours:                 c.MetricsFlavour.values,
 		FilterPlugins:                   c.FilterPlugins.values,
 		PredicatePlugins:                c.PredicatePlugins.values,
@@ -745,6 +787,10 @@ func (c *Config) ToOptions() skipper.Options {
 		Plugins:                         c.MultiPlugins.values,
 		PluginDirs:                      []string{skipper.DefaultPluginDir},
 		CompressEncodings:               c.CompressEncodings.values,
+		EnableErrorEnrich:               c.EnableErrorEnrich,
+		TeeKafkaBrokers:                 c.TeeKafkaBrokers.values,
+		CohortWALFile:                   c.CohortWALFile,
+		CohortWALBufferSize:             c.CohortWALBufferSize,
 
 		// logging, metrics, profiling, tracing:
 		EnablePrometheusMetrics:             c.EnablePrometheusMetrics,
@@ -786,18 +832,23 @@ func (c *Config) ToOptions() skipper.Options {
 		AccessLogJSONEnabled:                c.AccessLogJSONEnabled,
 		AccessLogStripQuery:                 c.AccessLogStripQuery,
 		SuppressRouteUpdateLogs:             c.SuppressRouteUpdateLogs,
+		SegmentGapBackend:                   c.SegmentGapBackend,
 
 		// route sources:
-		EtcdUrls:        eus,
-		EtcdPrefix:      c.EtcdPrefix,
-		EtcdWaitTimeout: c.EtcdTimeout,
-		EtcdInsecure:    c.EtcdInsecure,
-		EtcdOAuthToken:  c.EtcdOAuthToken,
-		EtcdUsername:    c.EtcdUsername,
-		EtcdPassword:    c.EtcdPassword,
-		WatchRoutesFile: c.RoutesFile,
-		RoutesURLs:      c.RoutesURLs.values,
-		InlineRoutes:    c.InlineRoutes,
+		EtcdUrls:             eus,
+		EtcdPrefix:           c.EtcdPrefix,
+		EtcdWaitTimeout:      c.EtcdTimeout,
+		EtcdInsecure:         c.EtcdInsecure,
+		EtcdOAuthToken:       c.EtcdOAuthToken,
+		EtcdUsername:         c.EtcdUsername,
+		EtcdPassword:         c.EtcdPassword,
+		WatchRoutesFile:      c.RoutesFile,
+		RoutesURLs:           c.RoutesURLs.values,
+		InlineRoutes:         c.InlineRoutes,
+		ConsulAddress:        c.ConsulAddress,
+		ConsulServiceTag:     c.ConsulServiceTag,
+		RoutesObjectURL:      c.RoutesObjectURL,
+		RoutesObjectEndpoint: c.RoutesObjectEndpoint,
 		DefaultFilters: &eskip.DefaultFilters{
 			Prepend: c.PrependFilters.filters,
 			Append:  c.AppendFilters.filters,
@@ -886,6 +937,9 @@ func (c *Config) ToOptions() skipper.Options {
 		ResponseHeaderTimeoutBackend: c.ResponseHeaderTimeoutBackend,
 		ExpectContinueTimeoutBackend: c.ExpectContinueTimeoutBackend,
 		MaxIdleConnsBackend:          c.MaxIdleConnsBackend,
+		MaxConnsPerHostBackend:       c.MaxConnsPerHostBackend,
+		FailFastCohorts:              c.FailFastCohorts.values,
+		DeploymentSlots:              c.DeploymentSlots.values,
 		DisableHTTPKeepalives:        c.DisableHTTPKeepalives,
 		KubernetesEnableTLS:          c.KubernetesEnableTLS,
 
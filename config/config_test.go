@@ -79,6 +79,8 @@ func defaultConfig() *Config {
 		DataclientPlugins:                       newPluginFlag(),
 		MultiPlugins:                            newPluginFlag(),
 		CompressEncodings:                       commaListFlag("gzip", "deflate", "br"),
+		TeeKafkaBrokers:                         commaListFlag(),
+		CohortWALBufferSize:                     1024,
 		OpenTracing:                             "noop",
 		OpenTracingInitialSpan:                  "ingress",
 		OpentracingLogFilterLifecycleEvents:     true,
@@ -95,6 +97,8 @@ func defaultConfig() *Config {
 		AppendFilters:                           &defaultFiltersFlags{},
 		PrependFilters:                          &defaultFiltersFlags{},
 		DisabledFilters:                         commaListFlag(),
+		FailFastCohorts:                         commaListFlag(),
+		DeploymentSlots:                         commaListFlag(),
 		CloneRoute:                              routeChangerConfig{},
 		EditRoute:                               routeChangerConfig{},
 		SourcePollTimeout:                       3000,
@@ -150,6 +154,8 @@ func defaultConfig() *Config {
 		SwarmLeaveTimeout:                       5 * time.Second,
 		TLSMinVersion:                           defaultMinTLSVersion,
 		RoutesURLs:                              commaListFlag(),
+		ConsulServiceTag:                        "skipper",
+		PredicateMetricsMaxRoutes:               100,
 		ForwardedHeadersList:                    commaListFlag(),
 		ForwardedHeadersExcludeCIDRList:         commaListFlag(),
 		ClusterRatelimitMaxGroupShards:          1,
@@ -0,0 +1,318 @@
+/*
+Package consul implements a DataClient that generates routes from the
+services of a Consul service catalog.
+
+The services tagged with the expose tag, "skipper" by default, are routed
+with a PathSubtree predicate to the load balanced endpoints of their
+healthy instances. The path prefix of a service defaults to /<service>,
+and it can be set with the skipper-path=<prefix> tag of the service, or
+with the skipper-path service metadata of its instances.
+
+The catalog is polled by the routing on every LoadUpdate call, and only
+the changed and deleted routes are returned.
+*/
+package consul
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/zalando/skipper/eskip"
+	"github.com/zalando/skipper/predicates"
+)
+
+const (
+	// DefaultAddress is the address of the local Consul agent.
+	DefaultAddress = "http://127.0.0.1:8500"
+
+	// DefaultTag is the tag of the services exposed by skipper.
+	DefaultTag = "skipper"
+
+	// PathTagPrefix is the prefix of the tag setting the path prefix
+	// of a service, e.g. skipper-path=/api.
+	PathTagPrefix = "skipper-path="
+
+	// PathMetaKey is the service metadata key setting the path prefix
+	// of a service.
+	PathMetaKey = "skipper-path"
+
+	defaultTimeout     = 3 * time.Second
+	defaultLBAlgorithm = "roundRobin"
+	routeIDPrefix      = "consul_"
+	tokenHeader        = "X-Consul-Token"
+	tokenEnv           = "CONSUL_HTTP_TOKEN"
+)
+
+var invalidRouteIDChars = regexp.MustCompile(`[^a-zA-Z0-9_]`)
+
+// Options for the Consul DataClient.
+type Options struct {
+	// Address of the Consul HTTP API, defaults to http://127.0.0.1:8500.
+	Address string
+
+	// Token is the ACL token used for the Consul API. Defaults to the
+	// value of the CONSUL_HTTP_TOKEN environment variable.
+	Token string
+
+	// Datacenter to query, defaults to the datacenter of the agent.
+	Datacenter string
+
+	// Tag of the services to expose, defaults to "skipper".
+	Tag string
+
+	// LBAlgorithm of the generated routes, defaults to roundRobin.
+	LBAlgorithm string
+
+	// Timeout of the requests to the Consul API, defaults to 3s.
+	Timeout time.Duration
+}
+
+// Client is the Consul DataClient.
+type Client struct {
+	options Options
+	address *url.URL
+	client  *http.Client
+	routes  map[string]*eskip.Route
+}
+
+type serviceEntry struct {
+	Node struct {
+		Address string
+	}
+	Service struct {
+		Address string
+		Port    int
+		Meta    map[string]string
+	}
+}
+
+// New creates a Consul DataClient.
+func New(o Options) (*Client, error) {
+	if o.Address == "" {
+		o.Address = DefaultAddress
+	}
+
+	if o.Token == "" {
+		o.Token = os.Getenv(tokenEnv)
+	}
+
+	if o.Tag == "" {
+		o.Tag = DefaultTag
+	}
+
+	if o.LBAlgorithm == "" {
+		o.LBAlgorithm = defaultLBAlgorithm
+	}
+
+	if o.Timeout <= 0 {
+		o.Timeout = defaultTimeout
+	}
+
+	u, err := url.Parse(o.Address)
+	if err != nil {
+		return nil, fmt.Errorf("invalid consul address %s: %w", o.Address, err)
+	}
+
+	if u.Scheme != "http" && u.Scheme != "https" || u.Host == "" {
+		return nil, fmt.Errorf("invalid consul address %s", o.Address)
+	}
+
+	return &Client{
+		options: o,
+		address: u,
+		client:  &http.Client{Timeout: o.Timeout},
+	}, nil
+}
+
+func (c *Client) get(path string, query url.Values, v interface{}) error {
+	u := *c.address
+	u.Path = strings.TrimSuffix(u.Path, "/") + path
+	if c.options.Datacenter != "" {
+		query.Set("dc", c.options.Datacenter)
+	}
+
+	u.RawQuery = query.Encode()
+
+	req, err := http.NewRequest("GET", u.String(), nil)
+	if err != nil {
+		return err
+	}
+
+	if c.options.Token != "" {
+		req.Header.Set(tokenHeader, c.options.Token)
+	}
+
+	rsp, err := c.client.Do(req)
+	if err != nil {
+		return err
+	}
+
+	defer rsp.Body.Close()
+
+	if rsp.StatusCode != http.StatusOK {
+		return fmt.Errorf("failed to get %s from consul: %s", path, rsp.Status)
+	}
+
+	return json.NewDecoder(rsp.Body).Decode(v)
+}
+
+func hasTag(tags []string, tag string) bool {
+	for _, t := range tags {
+		if t == tag {
+			return true
+		}
+	}
+
+	return false
+}
+
+func pathPrefix(service string, tags []string, entries []serviceEntry) string {
+	for _, e := range entries {
+		if p := e.Service.Meta[PathMetaKey]; p != "" {
+			return p
+		}
+	}
+
+	for _, t := range tags {
+		if strings.HasPrefix(t, PathTagPrefix) {
+			return strings.TrimPrefix(t, PathTagPrefix)
+		}
+	}
+
+	return "/" + service
+}
+
+func (c *Client) serviceRoute(service string, tags []string) (*eskip.Route, error) {
+	var entries []serviceEntry
+	if err := c.get("/v1/health/service/"+url.PathEscape(service), url.Values{"passing": []string{"1"}}, &entries); err != nil {
+		return nil, err
+	}
+
+	var endpoints []string
+	for _, e := range entries {
+		host := e.Service.Address
+		if host == "" {
+			host = e.Node.Address
+		}
+
+		if host == "" || e.Service.Port <= 0 {
+			continue
+		}
+
+		endpoints = append(endpoints, "http://"+net.JoinHostPort(host, strconv.Itoa(e.Service.Port)))
+	}
+
+	if len(endpoints) == 0 {
+		return nil, nil
+	}
+
+	sort.Strings(endpoints)
+
+	return &eskip.Route{
+		Id: routeIDPrefix + invalidRouteIDChars.ReplaceAllString(service, "_"),
+		Predicates: []*eskip.Predicate{{
+			Name: predicates.PathSubtreeName,
+			Args: []interface{}{pathPrefix(service, tags, entries)},
+		}},
+		BackendType: eskip.LBBackend,
+		LBAlgorithm: c.options.LBAlgorithm,
+		LBEndpoints: endpoints,
+	}, nil
+}
+
+func (c *Client) loadRoutes() ([]*eskip.Route, error) {
+	var services map[string][]string
+	if err := c.get("/v1/catalog/services", url.Values{}, &services); err != nil {
+		return nil, err
+	}
+
+	names := make([]string, 0, len(services))
+	for name, tags := range services {
+		if hasTag(tags, c.options.Tag) {
+			names = append(names, name)
+		}
+	}
+
+	sort.Strings(names)
+
+	var routes []*eskip.Route
+	for _, name := range names {
+		r, err := c.serviceRoute(name, services[name])
+		if err != nil {
+			return nil, err
+		}
+
+		if r != nil {
+			routes = append(routes, r)
+		}
+	}
+
+	return routes, nil
+}
+
+func mapRoutes(r []*eskip.Route) map[string]*eskip.Route {
+	m := make(map[string]*eskip.Route)
+	for _, ri := range r {
+		m[ri.Id] = ri
+	}
+
+	return m
+}
+
+// LoadAll returns the routes of all exposed services.
+func (c *Client) LoadAll() ([]*eskip.Route, error) {
+	routes, err := c.loadRoutes()
+	if err != nil {
+		return nil, err
+	}
+
+	c.routes = mapRoutes(routes)
+	return eskip.CopyRoutes(routes), nil
+}
+
+// LoadUpdate returns the routes of the services that changed since the
+// previous call, and the ids of the routes of the services that were
+// removed or have no healthy instances anymore.
+func (c *Client) LoadUpdate() ([]*eskip.Route, []string, error) {
+	routes, err := c.loadRoutes()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var (
+		upsert  []*eskip.Route
+		deleted []string
+	)
+
+	for _, r := range routes {
+		if !reflect.DeepEqual(r, c.routes[r.Id]) {
+			upsert = append(upsert, r)
+		}
+	}
+
+	m := mapRoutes(routes)
+	for id := range c.routes {
+		if _, keep := m[id]; !keep {
+			deleted = append(deleted, id)
+		}
+	}
+
+	sort.Strings(deleted)
+	c.routes = m
+	return eskip.CopyRoutes(upsert), deleted, nil
+}
+
+// Close releases the idle connections of the client.
+func (c *Client) Close() {
+	c.client.CloseIdleConnections()
+}
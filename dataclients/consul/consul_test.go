@@ -0,0 +1,187 @@
+package consul
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/zalando/skipper/eskip"
+)
+
+type stubConsul struct {
+	mu       sync.Mutex
+	services map[string][]string
+	health   map[string][]serviceEntry
+	tokens   []string
+	dcs      []string
+}
+
+func entry(address string, port int, meta map[string]string) serviceEntry {
+	var e serviceEntry
+	e.Service.Address = address
+	e.Service.Port = port
+	e.Service.Meta = meta
+	return e
+}
+
+func (s *stubConsul) set(services map[string][]string, health map[string][]serviceEntry) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.services, s.health = services, health
+}
+
+func (s *stubConsul) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.tokens = append(s.tokens, r.Header.Get(tokenHeader))
+	s.dcs = append(s.dcs, r.URL.Query().Get("dc"))
+
+	switch {
+	case r.URL.Path == "/v1/catalog/services":
+		json.NewEncoder(w).Encode(s.services)
+	case strings.HasPrefix(r.URL.Path, "/v1/health/service/"):
+		if r.URL.Query().Get("passing") != "1" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		entries := s.health[strings.TrimPrefix(r.URL.Path, "/v1/health/service/")]
+		if entries == nil {
+			entries = []serviceEntry{}
+		}
+
+		json.NewEncoder(w).Encode(entries)
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+func TestNewInvalidAddress(t *testing.T) {
+	for _, address := range []string{"::", "ftp://consul:8500", "http://"} {
+		_, err := New(Options{Address: address})
+		assert.Error(t, err, address)
+	}
+}
+
+func TestLoadAll(t *testing.T) {
+	stub := &stubConsul{}
+	stub.set(map[string][]string{
+		"api":      {"skipper"},
+		"web.app":  {"skipper", "skipper-path=/web"},
+		"internal": {"private"},
+		"orders":   {"skipper"},
+		"empty":    {"skipper"},
+		"consul":   nil,
+	}, map[string][]serviceEntry{
+		"api": {
+			entry("10.0.0.2", 8080, nil),
+			entry("10.0.0.1", 8080, nil),
+		},
+		"web.app":  {entry("10.0.1.1", 9090, nil)},
+		"internal": {entry("10.0.2.1", 8080, nil)},
+		"orders":   {entry("10.0.3.1", 8080, map[string]string{PathMetaKey: "/v2/orders"})},
+	})
+
+	s := httptest.NewServer(stub)
+	defer s.Close()
+
+	c, err := New(Options{Address: s.URL, Token: "secret", Datacenter: "eu"})
+	require.NoError(t, err)
+	defer c.Close()
+
+	routes, err := c.LoadAll()
+	require.NoError(t, err)
+
+	expected, err := eskip.Parse(`
+		consul_api: PathSubtree("/api") -> <roundRobin, "http://10.0.0.1:8080", "http://10.0.0.2:8080">;
+		consul_orders: PathSubtree("/v2/orders") -> <roundRobin, "http://10.0.3.1:8080">;
+		consul_web_app: PathSubtree("/web") -> <roundRobin, "http://10.0.1.1:9090">;
+	`)
+	require.NoError(t, err)
+
+	assert.Equal(t, eskip.String(expected...), eskip.String(routes...))
+
+	for _, token := range stub.tokens {
+		assert.Equal(t, "secret", token)
+	}
+
+	for _, dc := range stub.dcs {
+		assert.Equal(t, "eu", dc)
+	}
+}
+
+func TestLoadUpdate(t *testing.T) {
+	stub := &stubConsul{}
+	stub.set(map[string][]string{
+		"api":    {"skipper"},
+		"orders": {"skipper"},
+		"users":  {"skipper"},
+	}, map[string][]serviceEntry{
+		"api":    {entry("10.0.0.1", 8080, nil)},
+		"orders": {entry("10.0.1.1", 8080, nil)},
+		"users":  {entry("10.0.2.1", 8080, nil)},
+	})
+
+	s := httptest.NewServer(stub)
+	defer s.Close()
+
+	c, err := New(Options{Address: s.URL, LBAlgorithm: "powerOfRandomNChoices"})
+	require.NoError(t, err)
+	defer c.Close()
+
+	routes, err := c.LoadAll()
+	require.NoError(t, err)
+	require.Len(t, routes, 3)
+	assert.Equal(t, "powerOfRandomNChoices", routes[0].LBAlgorithm)
+
+	upsert, deleted, err := c.LoadUpdate()
+	require.NoError(t, err)
+	assert.Empty(t, upsert)
+	assert.Empty(t, deleted)
+
+	// api scales up, orders becomes unhealthy, users is removed, payments is added
+	stub.set(map[string][]string{
+		"api":      {"skipper"},
+		"orders":   {"skipper"},
+		"payments": {"skipper"},
+	}, map[string][]serviceEntry{
+		"api":      {entry("10.0.0.1", 8080, nil), entry("10.0.0.2", 8080, nil)},
+		"payments": {entry("10.0.3.1", 8080, nil)},
+	})
+
+	upsert, deleted, err = c.LoadUpdate()
+	require.NoError(t, err)
+
+	expected, err := eskip.Parse(`
+		consul_api: PathSubtree("/api") -> <powerOfRandomNChoices, "http://10.0.0.1:8080", "http://10.0.0.2:8080">;
+		consul_payments: PathSubtree("/payments") -> <powerOfRandomNChoices, "http://10.0.3.1:8080">;
+	`)
+	require.NoError(t, err)
+
+	assert.Equal(t, eskip.String(expected...), eskip.String(upsert...))
+	assert.Equal(t, []string{"consul_orders", "consul_users"}, deleted)
+}
+
+func TestLoadFailure(t *testing.T) {
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusForbidden)
+	}))
+	defer s.Close()
+
+	c, err := New(Options{Address: s.URL})
+	require.NoError(t, err)
+	defer c.Close()
+
+	_, err = c.LoadAll()
+	assert.Error(t, err)
+
+	_, _, err = c.LoadUpdate()
+	assert.Error(t, err)
+}
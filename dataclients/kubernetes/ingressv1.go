@@ -198,6 +198,7 @@ func (ing *ingress) addEndpointsRuleV1(ic ingressContext, host string, prule *de
 	if err != nil {
 		ic.logger.Errorf("failed to apply annotation predicates: %v", err)
 	}
+	setRouteVersion(meta, endpointsRoute)
 	ic.addHostRoute(host, endpointsRoute)
 
 	redirect := ic.redirect
@@ -12,7 +12,10 @@ import (
 	"github.com/zalando/skipper/loadbalancer"
 )
 
-const backendNameTracingTagName = "skipper.backend_name"
+const (
+	backendNameTracingTagName        = "skipper.backend_name"
+	trafficFromReplicasAnnotationKey = "zalando.org/traffic-from-replicas"
+)
 
 // TODO:
 // - consider catchall for east-west routes
@@ -51,6 +54,12 @@ type routeContext struct {
 type calculatedTraffic struct {
 	value   float64
 	balance int
+
+	// segment is set when the traffic is calculated from the replica
+	// counts, and then the [min, max) interval is used with the
+	// TrafficSegment predicate
+	segment  bool
+	min, max float64
 }
 
 func eskipError(typ, e string, err error) error {
@@ -199,6 +208,67 @@ func calculateTraffic(b []*definitions.BackendReference) map[string]*calculatedT
 	return t
 }
 
+// replicaTraffic calculates the TrafficSegment intervals of the backends
+// based on their replica counts, i.e. the number of their ready endpoints.
+// The intervals follow the order of the backend references, so that when
+// the replicas scale, only the boundaries between the intervals move. When
+// a backend is not a service backend, or there are no replicas at all, it
+// returns false.
+func replicaTraffic(ctx *routeGroupContext, b []*definitions.BackendReference) (map[string]*calculatedTraffic, bool) {
+	var sum int
+	replicas := make([]int, len(b))
+	for i, bi := range b {
+		be, ok := ctx.backendsByName[bi.BackendName]
+		if !ok || be.Type != definitions.ServiceBackend {
+			return nil, false
+		}
+
+		eps, err := serviceEndpoints(ctx, be)
+		if err != nil {
+			return nil, false
+		}
+
+		replicas[i] = len(eps)
+		sum += replicas[i]
+	}
+
+	if sum == 0 {
+		return nil, false
+	}
+
+	var count int
+	t := make(map[string]*calculatedTraffic)
+	for i, bi := range b {
+		ct := &calculatedTraffic{segment: true, min: float64(count) / float64(sum)}
+		count += replicas[i]
+		ct.max = float64(count) / float64(sum)
+		t[bi.BackendName] = ct
+	}
+
+	return t, true
+}
+
+// calculateBackendTraffic calculates the traffic of the backends based on the
+// weights, or based on the replica counts, when enabled with the
+// zalando.org/traffic-from-replicas annotation.
+func calculateBackendTraffic(ctx *routeGroupContext, b []*definitions.BackendReference) map[string]*calculatedTraffic {
+	if ctx.routeGroup.Metadata.Annotations[trafficFromReplicasAnnotationKey] != "true" || len(b) < 2 {
+		return calculateTraffic(b)
+	}
+
+	if t, ok := replicaTraffic(ctx, b); ok {
+		return t
+	}
+
+	log.Warnf(
+		"[routegroup] Invalid replica counts for %s/%s, using the backend weights.",
+		namespaceString(ctx.routeGroup.Metadata.Namespace),
+		ctx.routeGroup.Metadata.Name,
+	)
+
+	return calculateTraffic(b)
+}
+
 func trafficBalance(t *calculatedTraffic) []*eskip.Predicate {
 	if t.balance <= 0 {
 		return nil
@@ -214,6 +284,11 @@ func trafficBalance(t *calculatedTraffic) []*eskip.Predicate {
 }
 
 func configureTraffic(r *eskip.Route, t *calculatedTraffic) {
+	if t.segment {
+		r.Predicates = appendPredicate(r.Predicates, "TrafficSegment", t.min, t.max)
+		return
+	}
+
 	if t.value == 1 {
 		return
 	}
@@ -238,7 +313,7 @@ func getBackendService(ctx *routeGroupContext, backend *definitions.SkipperBacke
 	return s, nil
 }
 
-func applyServiceBackend(ctx *routeGroupContext, backend *definitions.SkipperBackend, r *eskip.Route) error {
+func serviceEndpoints(ctx *routeGroupContext, backend *definitions.SkipperBackend) ([]string, error) {
 	protocol := "http"
 	if p, ok := ctx.routeGroup.Metadata.Annotations[skipperBackendProtocolAnnotationKey]; ok {
 		protocol = p
@@ -246,20 +321,27 @@ func applyServiceBackend(ctx *routeGroupContext, backend *definitions.SkipperBac
 
 	s, err := getBackendService(ctx, backend)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	targetPort, ok := s.getTargetPortByValue(backend.ServicePort)
 	if !ok {
-		return targetPortNotFound(backend.ServiceName, backend.ServicePort)
+		return nil, targetPortNotFound(backend.ServiceName, backend.ServicePort)
 	}
 
-	eps := ctx.clusterState.GetEndpointsByTarget(
+	return ctx.clusterState.GetEndpointsByTarget(
 		namespaceString(ctx.routeGroup.Metadata.Namespace),
 		s.Meta.Name,
 		protocol,
 		targetPort,
-	)
+	), nil
+}
+
+func applyServiceBackend(ctx *routeGroupContext, backend *definitions.SkipperBackend, r *eskip.Route) error {
+	eps, err := serviceEndpoints(ctx, backend)
+	if err != nil {
+		return err
+	}
 
 	if len(eps) == 0 {
 		log.Debugf(
@@ -493,7 +575,7 @@ func explicitGroupRoutes(ctx *routeGroupContext) ([]*eskip.Route, error) {
 		backendTraffic := ctx.defaultBackendTraffic
 		if len(rgr.Backends) != 0 {
 			backendRefs = rgr.Backends
-			backendTraffic = calculateTraffic(rgr.Backends)
+			backendTraffic = calculateBackendTraffic(ctx, rgr.Backends)
 		}
 
 		for _, method := range rgr.UniqueMethods() {
@@ -528,12 +610,25 @@ func explicitGroupRoutes(ctx *routeGroupContext) ([]*eskip.Route, error) {
 }
 
 func transformRouteGroup(ctx *routeGroupContext) ([]*eskip.Route, error) {
-	ctx.defaultBackendTraffic = calculateTraffic(ctx.routeGroup.Spec.DefaultBackends)
+	ctx.defaultBackendTraffic = calculateBackendTraffic(ctx, ctx.routeGroup.Spec.DefaultBackends)
+
+	var (
+		routes []*eskip.Route
+		err    error
+	)
+
 	if len(ctx.routeGroup.Spec.Routes) == 0 {
-		return implicitGroupRoutes(ctx)
+		routes, err = implicitGroupRoutes(ctx)
+	} else {
+		routes, err = explicitGroupRoutes(ctx)
+	}
+
+	if err != nil {
+		return nil, err
 	}
 
-	return explicitGroupRoutes(ctx)
+	setRouteVersion(ctx.routeGroup.Metadata, routes...)
+	return routes, nil
 }
 
 func splitHosts(hosts []string, domains []string) ([]string, []string) {
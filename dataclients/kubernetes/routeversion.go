@@ -0,0 +1,29 @@
+package kubernetes
+
+import (
+	"github.com/zalando/skipper/dataclients/kubernetes/definitions"
+	"github.com/zalando/skipper/eskip"
+)
+
+// the deployment version of the routes generated from an ingress or a route
+// group, passed to the filters in the route metadata, e.g. to
+// stampRouteVersion()
+const (
+	routeVersionAnnotationKey = "zalando.org/skipper-route-version"
+	routeVersionMetadataKey   = "version"
+)
+
+func setRouteVersion(m *definitions.Metadata, routes ...*eskip.Route) {
+	v := m.Annotations[routeVersionAnnotationKey]
+	if v == "" {
+		return
+	}
+
+	for _, r := range routes {
+		if r.Metadata == nil {
+			r.Metadata = make(map[string]string)
+		}
+
+		r.Metadata[routeVersionMetadataKey] = v
+	}
+}
@@ -0,0 +1,142 @@
+package kubernetes_test
+
+import (
+	"bytes"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/zalando/skipper/dataclients/kubernetes"
+	"github.com/zalando/skipper/dataclients/kubernetes/kubernetestest"
+)
+
+const routeVersionSpec = `
+apiVersion: networking.k8s.io/v1
+kind: Ingress
+metadata:
+  namespace: default
+  name: checkout
+  annotations:
+    zalando.org/skipper-route-version: v1.4.2
+spec:
+  rules:
+  - host: checkout.example.org
+    http:
+      paths:
+      - path: /
+        pathType: Prefix
+        backend:
+          service:
+            name: myapp
+            port:
+              number: 80
+---
+apiVersion: networking.k8s.io/v1
+kind: Ingress
+metadata:
+  namespace: default
+  name: unversioned
+spec:
+  rules:
+  - host: unversioned.example.org
+    http:
+      paths:
+      - path: /
+        pathType: Prefix
+        backend:
+          service:
+            name: myapp
+            port:
+              number: 80
+---
+apiVersion: zalando.org/v1
+kind: RouteGroup
+metadata:
+  namespace: default
+  name: orders
+  annotations:
+    zalando.org/skipper-route-version: v2.0.0-canary
+spec:
+  hosts:
+  - orders.example.org
+  backends:
+  - name: app
+    type: service
+    serviceName: myapp
+    servicePort: 80
+  routes:
+  - pathSubtree: /
+    backends:
+    - backendName: app
+---
+apiVersion: v1
+kind: Service
+metadata:
+  namespace: default
+  name: myapp
+spec:
+  clusterIP: 10.3.190.97
+  ports:
+  - port: 80
+    protocol: TCP
+    targetPort: 8080
+  type: ClusterIP
+---
+apiVersion: v1
+kind: Endpoints
+metadata:
+  namespace: default
+  name: myapp
+subsets:
+- addresses:
+  - ip: 10.2.9.103
+  ports:
+  - port: 8080
+    protocol: TCP
+`
+
+func TestRouteVersionAnnotation(t *testing.T) {
+	a, err := kubernetestest.NewAPI(kubernetestest.TestAPIOptions{}, bytes.NewBufferString(routeVersionSpec))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	s := httptest.NewServer(a)
+	defer s.Close()
+
+	c, err := kubernetes.New(kubernetes.Options{KubernetesURL: s.URL})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+
+	routes, err := c.LoadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	found := make(map[string]bool)
+	for _, r := range routes {
+		var expected string
+		switch {
+		case strings.HasPrefix(r.Id, "kube_default__checkout__"):
+			expected = "v1.4.2"
+		case strings.HasPrefix(r.Id, "kube_rg__default__orders__"):
+			expected = "v2.0.0-canary"
+		case strings.HasPrefix(r.Id, "kube_default__unversioned__"):
+		default:
+			continue
+		}
+
+		found[expected] = true
+		if v := r.Metadata["version"]; v != expected {
+			t.Errorf("%s: expected version %q, got %q", r.Id, expected, v)
+		}
+	}
+
+	for _, v := range []string{"v1.4.2", "v2.0.0-canary", ""} {
+		if !found[v] {
+			t.Errorf("route with version %q not found", v)
+		}
+	}
+}
@@ -0,0 +1,130 @@
+package objectstore
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	defaultGCSEndpoint  = "https://storage.googleapis.com"
+	defaultMetadataHost = "metadata.google.internal"
+	metadataTokenPath   = "/computeMetadata/v1/instance/service-accounts/default/token"
+)
+
+type gcsToken struct {
+	AccessToken string `json:"access_token"`
+	ExpiresIn   int    `json:"expires_in"`
+}
+
+type gcsStore struct {
+	url    string
+	client *http.Client
+	now    func() time.Time
+
+	mu      sync.Mutex
+	token   string
+	expires time.Time
+}
+
+func newGCS(o Options, bucket, key string, client *http.Client) *gcsStore {
+	endpoint := defaultGCSEndpoint
+	if o.Endpoint != "" {
+		endpoint = strings.TrimSuffix(o.Endpoint, "/")
+	}
+
+	return &gcsStore{
+		url:    endpoint + "/" + escapePath(bucket) + "/" + escapePath(key),
+		client: client,
+		now:    time.Now,
+	}
+}
+
+// metadataToken loads the access token of the service account from the
+// metadata server.
+func (s *gcsStore) metadataToken() (*gcsToken, error) {
+	host := os.Getenv("GCE_METADATA_HOST")
+	if host == "" {
+		host = defaultMetadataHost
+	}
+
+	req, err := http.NewRequest("GET", "http://"+host+metadataTokenPath, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Set("Metadata-Flavor", "Google")
+	rsp, err := s.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rsp.Body.Close()
+
+	if rsp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to get access token from the metadata server: %s", rsp.Status)
+	}
+
+	var t gcsToken
+	if err := json.NewDecoder(rsp.Body).Decode(&t); err != nil {
+		return nil, err
+	}
+
+	if t.AccessToken == "" {
+		return nil, fmt.Errorf("invalid access token from the metadata server")
+	}
+
+	return &t, nil
+}
+
+// getToken returns the access token from the environment, or the cached
+// token of the service account, renewed before it expires.
+func (s *gcsStore) getToken() (string, error) {
+	if t := os.Getenv("GOOGLE_OAUTH_ACCESS_TOKEN"); t != "" {
+		return t, nil
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	if s.token != "" && now.Add(credentialsExpiryWindow).Before(s.expires) {
+		return s.token, nil
+	}
+
+	t, err := s.metadataToken()
+	if err != nil {
+		return "", err
+	}
+
+	s.token = t.AccessToken
+	s.expires = now.Add(time.Duration(t.ExpiresIn) * time.Second)
+	return s.token, nil
+}
+
+func (s *gcsStore) newRequest(version string) (*http.Request, error) {
+	req, err := http.NewRequest("GET", s.url, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	if version != "" {
+		req.Header.Set("X-Goog-If-Generation-Not-Match", version)
+	}
+
+	token, err := s.getToken()
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Set("Authorization", "Bearer "+token)
+	return req, nil
+}
+
+func (*gcsStore) version(rsp *http.Response) string {
+	return rsp.Header.Get("X-Goog-Generation")
+}
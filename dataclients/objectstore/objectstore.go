@@ -0,0 +1,261 @@
+/*
+Package objectstore implements a DataClient that reads the routes, in eskip
+format, from an object of an S3 bucket or a Google Cloud Storage bucket.
+
+The object is identified by a URL, e.g. s3://my-bucket/routes.eskip or
+gs://my-bucket/routes.eskip. The routing polls the object on every
+LoadUpdate call, with conditional requests: by ETag in case of S3, and by
+object generation in case of GCS, so an unchanged object is not downloaded
+again. Only the changed and deleted routes are returned.
+
+When the object can't be loaded or parsed, the client keeps the last good
+set of routes, and logs the error.
+
+The S3 requests are signed with the credentials taken from the
+AWS_ACCESS_KEY_ID, AWS_SECRET_ACCESS_KEY and AWS_SESSION_TOKEN environment
+variables, or from the container credentials endpoint of the role, e.g. in
+ECS or with EKS Pod Identity, set by AWS_CONTAINER_CREDENTIALS_FULL_URI or
+AWS_CONTAINER_CREDENTIALS_RELATIVE_URI. Without credentials, the requests
+are sent unsigned. The region is taken from the AWS_REGION or the
+AWS_DEFAULT_REGION environment variable, and defaults to us-east-1.
+
+The GCS requests use the access token taken from the
+GOOGLE_OAUTH_ACCESS_TOKEN environment variable, or from the metadata
+server of the service account, e.g. on GCE or with GKE Workload Identity.
+*/
+package objectstore
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/zalando/skipper/eskip"
+)
+
+const defaultTimeout = 3 * time.Second
+
+var errNotModified = errors.New("object not modified")
+
+// Options for the object store DataClient.
+type Options struct {
+	// URL of the object, e.g. s3://my-bucket/routes.eskip or
+	// gs://my-bucket/routes.eskip.
+	URL string
+
+	// Endpoint overrides the address of the object store API, e.g. of
+	// an S3 compatible store. With S3, the object is addressed in path
+	// style under the endpoint.
+	Endpoint string
+
+	// Region of the S3 bucket, defaults to the value of the AWS_REGION
+	// or the AWS_DEFAULT_REGION environment variable, or us-east-1.
+	Region string
+
+	// Timeout of the requests to the object store, defaults to 3s.
+	Timeout time.Duration
+}
+
+// store creates the requests specific to the object store.
+type store interface {
+
+	// newRequest creates the request of the object, conditional on the
+	// version of the object, when not empty.
+	newRequest(version string) (*http.Request, error)
+
+	// version returns the version of the object from the response.
+	version(*http.Response) string
+}
+
+// Client is the object store DataClient.
+type Client struct {
+	url     string
+	store   store
+	client  *http.Client
+	version string
+	routes  map[string]*eskip.Route
+}
+
+// New creates an object store DataClient.
+func New(o Options) (*Client, error) {
+	if o.Timeout <= 0 {
+		o.Timeout = defaultTimeout
+	}
+
+	u, err := url.Parse(o.URL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid object url %s: %w", o.URL, err)
+	}
+
+	bucket, key := u.Host, strings.TrimPrefix(u.Path, "/")
+	if bucket == "" || key == "" {
+		return nil, fmt.Errorf("invalid object url %s", o.URL)
+	}
+
+	if o.Endpoint != "" {
+		e, err := url.Parse(o.Endpoint)
+		if err != nil || e.Scheme != "http" && e.Scheme != "https" || e.Host == "" {
+			return nil, fmt.Errorf("invalid object store endpoint %s", o.Endpoint)
+		}
+	}
+
+	client := &http.Client{Timeout: o.Timeout}
+
+	var s store
+	switch u.Scheme {
+	case "s3":
+		s = newS3(o, bucket, key, client)
+	case "gs":
+		s = newGCS(o, bucket, key, client)
+	default:
+		return nil, fmt.Errorf("unsupported object url scheme %s", u.Scheme)
+	}
+
+	return &Client{url: o.URL, store: s, client: client}, nil
+}
+
+// escapePath escapes the segments of an object key, leaving only the
+// unreserved characters unescaped, as required by the request signing.
+func escapePath(key string) string {
+	segments := strings.Split(key, "/")
+	for i, s := range segments {
+		var b strings.Builder
+		for _, c := range []byte(s) {
+			if c >= 'A' && c <= 'Z' || c >= 'a' && c <= 'z' || c >= '0' && c <= '9' ||
+				c == '-' || c == '.' || c == '_' || c == '~' {
+				b.WriteByte(c)
+			} else {
+				fmt.Fprintf(&b, "%%%02X", c)
+			}
+		}
+
+		segments[i] = b.String()
+	}
+
+	return strings.Join(segments, "/")
+}
+
+// load returns the routes of the object, or errNotModified, when the
+// object didn't change since the last successful load.
+func (c *Client) load() ([]*eskip.Route, string, error) {
+	req, err := c.store.newRequest(c.version)
+	if err != nil {
+		return nil, "", err
+	}
+
+	rsp, err := c.client.Do(req)
+	if err != nil {
+		return nil, "", err
+	}
+
+	defer rsp.Body.Close()
+
+	switch rsp.StatusCode {
+	case http.StatusOK:
+	case http.StatusNotModified:
+		return nil, "", errNotModified
+	default:
+		return nil, "", fmt.Errorf("failed to get object %s: %s", c.url, rsp.Status)
+	}
+
+	b, err := io.ReadAll(rsp.Body)
+	if err != nil {
+		return nil, "", err
+	}
+
+	routes, err := eskip.Parse(string(b))
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to parse object %s: %w", c.url, err)
+	}
+
+	return routes, c.store.version(rsp), nil
+}
+
+func mapRoutes(r []*eskip.Route) map[string]*eskip.Route {
+	m := make(map[string]*eskip.Route)
+	for _, ri := range r {
+		m[ri.Id] = ri
+	}
+
+	return m
+}
+
+func (c *Client) lastRoutes() []*eskip.Route {
+	routes := make([]*eskip.Route, 0, len(c.routes))
+	for _, r := range c.routes {
+		routes = append(routes, r)
+	}
+
+	sort.Slice(routes, func(i, j int) bool { return routes[i].Id < routes[j].Id })
+	return eskip.CopyRoutes(routes)
+}
+
+// LoadAll returns all the routes of the object. When the object can't be
+// loaded, it returns the last good set of routes, if any.
+func (c *Client) LoadAll() ([]*eskip.Route, error) {
+	routes, version, err := c.load()
+	switch {
+	case err == errNotModified:
+		return c.lastRoutes(), nil
+	case err != nil && c.routes == nil:
+		return nil, err
+	case err != nil:
+		log.Errorf("Failed to load routes, keeping the last good set: %v", err)
+		return c.lastRoutes(), nil
+	}
+
+	c.routes = mapRoutes(routes)
+	c.version = version
+	return eskip.CopyRoutes(routes), nil
+}
+
+// LoadUpdate returns the routes that changed since the previous call, and
+// the ids of the deleted routes. When the object can't be loaded, it keeps
+// the last good set of routes, and returns no changes.
+func (c *Client) LoadUpdate() ([]*eskip.Route, []string, error) {
+	routes, version, err := c.load()
+	switch {
+	case err == errNotModified:
+		return nil, nil, nil
+	case err != nil:
+		log.Errorf("Failed to load route updates, keeping the last good set: %v", err)
+		return nil, nil, nil
+	}
+
+	var (
+		upsert  []*eskip.Route
+		deleted []string
+	)
+
+	m := mapRoutes(routes)
+	for _, r := range m {
+		if !reflect.DeepEqual(r, c.routes[r.Id]) {
+			upsert = append(upsert, r)
+		}
+	}
+
+	for id := range c.routes {
+		if _, keep := m[id]; !keep {
+			deleted = append(deleted, id)
+		}
+	}
+
+	sort.Slice(upsert, func(i, j int) bool { return upsert[i].Id < upsert[j].Id })
+	sort.Strings(deleted)
+	c.routes = m
+	c.version = version
+	return eskip.CopyRoutes(upsert), deleted, nil
+}
+
+// Close releases the idle connections of the client.
+func (c *Client) Close() {
+	c.client.CloseIdleConnections()
+}
@@ -0,0 +1,298 @@
+package objectstore
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/zalando/skipper/eskip"
+)
+
+// stubStore serves a single object, versioned both by ETag and by
+// generation, and answers the conditional requests of either kind.
+type stubStore struct {
+	mu         sync.Mutex
+	path       string
+	content    string
+	status     int
+	generation int
+	requests   []*http.Request
+}
+
+func (s *stubStore) set(content string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.content = content
+	s.generation++
+}
+
+func (s *stubStore) fail(status int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.status = status
+}
+
+func (s *stubStore) last() *http.Request {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.requests[len(s.requests)-1]
+}
+
+func (s *stubStore) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.requests = append(s.requests, r)
+	if s.status != 0 {
+		w.WriteHeader(s.status)
+		return
+	}
+
+	if r.URL.EscapedPath() != s.path {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	etag := fmt.Sprintf(`"etag-%d"`, s.generation)
+	generation := strconv.Itoa(s.generation)
+	if r.Header.Get("If-None-Match") == etag || r.Header.Get("X-Goog-If-Generation-Not-Match") == generation {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+
+	w.Header().Set("ETag", etag)
+	w.Header().Set("X-Goog-Generation", generation)
+	w.Write([]byte(s.content))
+}
+
+func clearCredentials(t *testing.T) {
+	for _, name := range []string{
+		"AWS_ACCESS_KEY_ID",
+		"AWS_SECRET_ACCESS_KEY",
+		"AWS_SESSION_TOKEN",
+		"AWS_REGION",
+		"AWS_DEFAULT_REGION",
+		"AWS_CONTAINER_CREDENTIALS_FULL_URI",
+		"AWS_CONTAINER_CREDENTIALS_RELATIVE_URI",
+		"AWS_CONTAINER_AUTHORIZATION_TOKEN",
+		"GOOGLE_OAUTH_ACCESS_TOKEN",
+		"GCE_METADATA_HOST",
+	} {
+		t.Setenv(name, "")
+	}
+}
+
+func routeIds(routes []*eskip.Route) []string {
+	var ids []string
+	for _, r := range routes {
+		ids = append(ids, r.Id)
+	}
+
+	return ids
+}
+
+func TestNewInvalid(t *testing.T) {
+	for _, o := range []Options{
+		{URL: "::"},
+		{URL: "s3://bucket"},
+		{URL: "s3:///routes.eskip"},
+		{URL: "ftp://bucket/routes.eskip"},
+		{URL: "s3://bucket/routes.eskip", Endpoint: "ftp://minio:9000"},
+	} {
+		_, err := New(o)
+		assert.Error(t, err, o.URL)
+	}
+}
+
+func TestS3Polling(t *testing.T) {
+	clearCredentials(t)
+	t.Setenv("AWS_ACCESS_KEY_ID", "AKIDEXAMPLE")
+	t.Setenv("AWS_SECRET_ACCESS_KEY", "secret")
+	t.Setenv("AWS_SESSION_TOKEN", "session")
+
+	stub := &stubStore{path: "/routes/config/routes%2Bv1.eskip"}
+	stub.set(`
+		a: Path("/a") -> "https://a.example.org";
+		b: Path("/b") -> "https://b.example.org";
+	`)
+
+	server := httptest.NewServer(stub)
+	defer server.Close()
+
+	c, err := New(Options{URL: "s3://routes/config/routes+v1.eskip", Endpoint: server.URL, Region: "eu-central-1"})
+	require.NoError(t, err)
+	defer c.Close()
+
+	routes, err := c.LoadAll()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"a", "b"}, routeIds(routes))
+
+	req := stub.last()
+	assert.Regexp(t,
+		`^AWS4-HMAC-SHA256 Credential=AKIDEXAMPLE/\d{8}/eu-central-1/s3/aws4_request, `+
+			`SignedHeaders=host;x-amz-content-sha256;x-amz-date;x-amz-security-token, Signature=[0-9a-f]{64}$`,
+		req.Header.Get("Authorization"),
+	)
+	assert.Equal(t, "session", req.Header.Get("X-Amz-Security-Token"))
+	assert.Empty(t, req.Header.Get("If-None-Match"))
+
+	// unchanged
+	upsert, deleted, err := c.LoadUpdate()
+	require.NoError(t, err)
+	assert.Empty(t, upsert)
+	assert.Empty(t, deleted)
+	assert.Equal(t, `"etag-1"`, stub.last().Header.Get("If-None-Match"))
+
+	stub.set(`
+		a: Path("/a") -> "https://a.example.org";
+		b: Path("/b") -> "https://b2.example.org";
+		c: Path("/c") -> "https://c.example.org";
+	`)
+
+	upsert, deleted, err = c.LoadUpdate()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"b", "c"}, routeIds(upsert))
+	assert.Empty(t, deleted)
+
+	stub.set(`c: Path("/c") -> "https://c.example.org";`)
+	upsert, deleted, err = c.LoadUpdate()
+	require.NoError(t, err)
+	assert.Empty(t, upsert)
+	assert.Equal(t, []string{"a", "b"}, deleted)
+}
+
+func TestKeepsLastGoodRoutes(t *testing.T) {
+	clearCredentials(t)
+	t.Setenv("GOOGLE_OAUTH_ACCESS_TOKEN", "token")
+
+	stub := &stubStore{path: "/routes/routes.eskip"}
+	stub.set(`a: Path("/a") -> "https://a.example.org";`)
+
+	server := httptest.NewServer(stub)
+	defer server.Close()
+
+	c, err := New(Options{URL: "gs://routes/routes.eskip", Endpoint: server.URL})
+	require.NoError(t, err)
+	defer c.Close()
+
+	routes, err := c.LoadAll()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"a"}, routeIds(routes))
+	assert.Equal(t, "Bearer token", stub.last().Header.Get("Authorization"))
+
+	// invalid content
+	stub.set(`a: Path("/a") -> `)
+	upsert, deleted, err := c.LoadUpdate()
+	require.NoError(t, err)
+	assert.Empty(t, upsert)
+	assert.Empty(t, deleted)
+	assert.Equal(t, "1", stub.last().Header.Get("X-Goog-If-Generation-Not-Match"))
+
+	routes, err = c.LoadAll()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"a"}, routeIds(routes))
+
+	// failing store
+	stub.fail(http.StatusInternalServerError)
+	upsert, deleted, err = c.LoadUpdate()
+	require.NoError(t, err)
+	assert.Empty(t, upsert)
+	assert.Empty(t, deleted)
+
+	routes, err = c.LoadAll()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"a"}, routeIds(routes))
+
+	// recovered
+	stub.fail(0)
+	stub.set(`b: Path("/b") -> "https://b.example.org";`)
+	upsert, deleted, err = c.LoadUpdate()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"b"}, routeIds(upsert))
+	assert.Equal(t, []string{"a"}, deleted)
+
+	// the version of the failed content is not stored
+	assert.Equal(t, "1", stub.last().Header.Get("X-Goog-If-Generation-Not-Match"))
+
+	upsert, deleted, err = c.LoadUpdate()
+	require.NoError(t, err)
+	assert.Empty(t, upsert)
+	assert.Empty(t, deleted)
+	assert.Equal(t, "3", stub.last().Header.Get("X-Goog-If-Generation-Not-Match"))
+}
+
+func TestInitialLoadFails(t *testing.T) {
+	clearCredentials(t)
+
+	stub := &stubStore{path: "/routes/routes.eskip", status: http.StatusForbidden}
+	server := httptest.NewServer(stub)
+	defer server.Close()
+
+	c, err := New(Options{URL: "s3://routes/routes.eskip", Endpoint: server.URL})
+	require.NoError(t, err)
+	defer c.Close()
+
+	_, err = c.LoadAll()
+	assert.Error(t, err)
+
+	// unsigned without credentials
+	assert.Empty(t, stub.last().Header.Get("Authorization"))
+}
+
+func TestRoleCredentials(t *testing.T) {
+	clearCredentials(t)
+
+	var credentialRequests int
+	credentials := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		credentialRequests++
+		switch {
+		case r.URL.Path == "/v2/credentials" && r.Header.Get("Authorization") == "container-token":
+			fmt.Fprint(w, `{"AccessKeyId": "AKIDROLE", "SecretAccessKey": "secret", "Token": "role-session", "Expiration": "2099-01-01T00:00:00Z"}`)
+		case strings.HasSuffix(r.URL.Path, metadataTokenPath) && r.Header.Get("Metadata-Flavor") == "Google":
+			fmt.Fprint(w, `{"access_token": "metadata-token", "expires_in": 3600}`)
+		default:
+			w.WriteHeader(http.StatusUnauthorized)
+		}
+	}))
+	defer credentials.Close()
+
+	t.Setenv("AWS_CONTAINER_CREDENTIALS_FULL_URI", credentials.URL+"/v2/credentials")
+	t.Setenv("AWS_CONTAINER_AUTHORIZATION_TOKEN", "container-token")
+	t.Setenv("GCE_METADATA_HOST", strings.TrimPrefix(credentials.URL, "http://"))
+
+	stub := &stubStore{path: "/routes/routes.eskip"}
+	stub.set(`a: Path("/a") -> "https://a.example.org";`)
+	server := httptest.NewServer(stub)
+	defer server.Close()
+
+	s3, err := New(Options{URL: "s3://routes/routes.eskip", Endpoint: server.URL})
+	require.NoError(t, err)
+	defer s3.Close()
+
+	for i := 0; i < 2; i++ {
+		_, err = s3.LoadAll()
+		require.NoError(t, err)
+		assert.Contains(t, stub.last().Header.Get("Authorization"), "Credential=AKIDROLE/")
+		assert.Equal(t, "role-session", stub.last().Header.Get("X-Amz-Security-Token"))
+	}
+
+	gcs, err := New(Options{URL: "gs://routes/routes.eskip", Endpoint: server.URL})
+	require.NoError(t, err)
+	defer gcs.Close()
+
+	for i := 0; i < 2; i++ {
+		_, err = gcs.LoadAll()
+		require.NoError(t, err)
+		assert.Equal(t, "Bearer metadata-token", stub.last().Header.Get("Authorization"))
+	}
+
+	// the credentials are cached
+	assert.Equal(t, 2, credentialRequests)
+}
@@ -0,0 +1,226 @@
+package objectstore
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	defaultS3Region = "us-east-1"
+
+	// the address of the container credentials endpoint, when set by
+	// the relative uri
+	containerCredentialsHost = "http://169.254.170.2"
+
+	// the hex encoded SHA-256 hash of the empty payload
+	emptyPayloadHash = "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855"
+
+	// the credentials are renewed before they expire
+	credentialsExpiryWindow = 5 * time.Minute
+)
+
+type s3Credentials struct {
+	AccessKeyID     string `json:"AccessKeyId"`
+	SecretAccessKey string
+	Token           string
+	Expiration      time.Time
+}
+
+type s3Store struct {
+	url    string
+	path   string
+	host   string
+	region string
+	client *http.Client
+	now    func() time.Time
+
+	mu          sync.Mutex
+	credentials *s3Credentials
+}
+
+func newS3(o Options, bucket, key string, client *http.Client) *s3Store {
+	region := o.Region
+	if region == "" {
+		region = os.Getenv("AWS_REGION")
+	}
+
+	if region == "" {
+		region = os.Getenv("AWS_DEFAULT_REGION")
+	}
+
+	if region == "" {
+		region = defaultS3Region
+	}
+
+	s := &s3Store{region: region, client: client, now: time.Now}
+	if o.Endpoint != "" {
+		s.path = "/" + escapePath(bucket) + "/" + escapePath(key)
+		s.url = strings.TrimSuffix(o.Endpoint, "/") + s.path
+	} else {
+		s.path = "/" + escapePath(key)
+		s.url = fmt.Sprintf("https://%s.s3.%s.amazonaws.com%s", bucket, region, s.path)
+	}
+
+	return s
+}
+
+// containerCredentials loads the credentials of the role from the container
+// credentials endpoint, or returns nil, when the endpoint is not set.
+func (s *s3Store) containerCredentials() (*s3Credentials, error) {
+	u := os.Getenv("AWS_CONTAINER_CREDENTIALS_FULL_URI")
+	if u == "" {
+		if rel := os.Getenv("AWS_CONTAINER_CREDENTIALS_RELATIVE_URI"); rel != "" {
+			u = containerCredentialsHost + rel
+		}
+	}
+
+	if u == "" {
+		return nil, nil
+	}
+
+	req, err := http.NewRequest("GET", u, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	if token := os.Getenv("AWS_CONTAINER_AUTHORIZATION_TOKEN"); token != "" {
+		req.Header.Set("Authorization", token)
+	}
+
+	rsp, err := s.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rsp.Body.Close()
+
+	if rsp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to get container credentials: %s", rsp.Status)
+	}
+
+	var c s3Credentials
+	if err := json.NewDecoder(rsp.Body).Decode(&c); err != nil {
+		return nil, err
+	}
+
+	if c.AccessKeyID == "" || c.SecretAccessKey == "" {
+		return nil, fmt.Errorf("invalid container credentials")
+	}
+
+	return &c, nil
+}
+
+// getCredentials returns the credentials from the environment, or the
+// cached credentials of the role, renewed before they expire. It returns
+// nil, when no credentials are configured.
+func (s *s3Store) getCredentials() (*s3Credentials, error) {
+	if id, secret := os.Getenv("AWS_ACCESS_KEY_ID"), os.Getenv("AWS_SECRET_ACCESS_KEY"); id != "" && secret != "" {
+		return &s3Credentials{
+			AccessKeyID:     id,
+			SecretAccessKey: secret,
+			Token:           os.Getenv("AWS_SESSION_TOKEN"),
+		}, nil
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.credentials != nil && s.now().Add(credentialsExpiryWindow).Before(s.credentials.Expiration) {
+		return s.credentials, nil
+	}
+
+	c, err := s.containerCredentials()
+	if err != nil {
+		return nil, err
+	}
+
+	s.credentials = c
+	return c, nil
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	h := hmac.New(sha256.New, key)
+	h.Write([]byte(data))
+	return h.Sum(nil)
+}
+
+// sign signs a GET request with AWS Signature Version 4.
+func (s *s3Store) sign(req *http.Request, c *s3Credentials) {
+	now := s.now().UTC()
+	amzDate := now.Format("20060102T150405Z")
+	date := now.Format("20060102")
+
+	req.Header.Set("X-Amz-Date", amzDate)
+	req.Header.Set("X-Amz-Content-Sha256", emptyPayloadHash)
+	signedHeaders := "host;x-amz-content-sha256;x-amz-date"
+	canonicalHeaders := "host:" + req.URL.Host + "\n" +
+		"x-amz-content-sha256:" + emptyPayloadHash + "\n" +
+		"x-amz-date:" + amzDate + "\n"
+
+	if c.Token != "" {
+		req.Header.Set("X-Amz-Security-Token", c.Token)
+		signedHeaders += ";x-amz-security-token"
+		canonicalHeaders += "x-amz-security-token:" + c.Token + "\n"
+	}
+
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		s.path,
+		"",
+		canonicalHeaders,
+		signedHeaders,
+		emptyPayloadHash,
+	}, "\n")
+
+	scope := date + "/" + s.region + "/s3/aws4_request"
+	hash := sha256.Sum256([]byte(canonicalRequest))
+	stringToSign := "AWS4-HMAC-SHA256\n" + amzDate + "\n" + scope + "\n" + hex.EncodeToString(hash[:])
+
+	key := hmacSHA256([]byte("AWS4"+c.SecretAccessKey), date)
+	key = hmacSHA256(key, s.region)
+	key = hmacSHA256(key, "s3")
+	key = hmacSHA256(key, "aws4_request")
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+
+	req.Header.Set("Authorization", fmt.Sprintf(
+		"AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		c.AccessKeyID,
+		scope,
+		signedHeaders,
+		signature,
+	))
+}
+
+func (s *s3Store) newRequest(version string) (*http.Request, error) {
+	req, err := http.NewRequest("GET", s.url, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	if version != "" {
+		req.Header.Set("If-None-Match", version)
+	}
+
+	c, err := s.getCredentials()
+	if err != nil {
+		return nil, err
+	}
+
+	if c != nil {
+		s.sign(req, c)
+	}
+
+	return req, nil
+}
+
+func (*s3Store) version(rsp *http.Response) string {
+	return rsp.Header.Get("ETag")
+}
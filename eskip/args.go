@@ -0,0 +1,176 @@
+package eskip
+
+import (
+	"fmt"
+	"math"
+	"net"
+	"regexp"
+	"time"
+)
+
+// ArgType converts an argument of a route definition, as parsed from eskip,
+// e.g. a float64 or a string, into a typed value. Custom types can be
+// created with NewArgType.
+type ArgType interface {
+	// Name of the type, used in the error messages.
+	Name() string
+
+	// Parse converts the raw argument into the typed value.
+	Parse(arg interface{}) (interface{}, error)
+}
+
+type argType struct {
+	name  string
+	parse func(interface{}) (interface{}, error)
+}
+
+func (t argType) Name() string { return t.name }
+
+func (t argType) Parse(arg interface{}) (interface{}, error) { return t.parse(arg) }
+
+// NewArgType creates an argument type with a custom conversion.
+func NewArgType(name string, parse func(arg interface{}) (interface{}, error)) ArgType {
+	return argType{name: name, parse: parse}
+}
+
+var (
+	// ArgAny accepts any argument unchanged.
+	ArgAny = NewArgType("any", func(arg interface{}) (interface{}, error) {
+		return arg, nil
+	})
+
+	// ArgString accepts a string.
+	ArgString = NewArgType("string", func(arg interface{}) (interface{}, error) {
+		s, ok := arg.(string)
+		if !ok {
+			return nil, fmt.Errorf("expected string, got %v", arg)
+		}
+
+		return s, nil
+	})
+
+	// ArgNumber accepts a number, converted to float64.
+	ArgNumber = NewArgType("number", func(arg interface{}) (interface{}, error) {
+		switch v := arg.(type) {
+		case float64:
+			return v, nil
+		case int:
+			return float64(v), nil
+		default:
+			return nil, fmt.Errorf("expected number, got %v", arg)
+		}
+	})
+
+	// ArgInt accepts a whole number, converted to int.
+	ArgInt = NewArgType("int", func(arg interface{}) (interface{}, error) {
+		switch v := arg.(type) {
+		case int:
+			return v, nil
+		case float64:
+			if v != math.Trunc(v) || v > math.MaxInt32 || v < math.MinInt32 {
+				return nil, fmt.Errorf("expected integer, got %v", v)
+			}
+
+			return int(v), nil
+		default:
+			return nil, fmt.Errorf("expected integer, got %v", arg)
+		}
+	})
+
+	// ArgDuration accepts a duration string, e.g. "1m30s", converted to
+	// time.Duration.
+	ArgDuration = NewArgType("duration", func(arg interface{}) (interface{}, error) {
+		switch v := arg.(type) {
+		case time.Duration:
+			return v, nil
+		case string:
+			d, err := time.ParseDuration(v)
+			if err != nil {
+				return nil, fmt.Errorf("invalid duration %q: %w", v, err)
+			}
+
+			return d, nil
+		default:
+			return nil, fmt.Errorf("expected duration, got %v", arg)
+		}
+	})
+
+	// ArgCIDR accepts a network in CIDR notation, e.g. "10.0.0.0/8",
+	// converted to *net.IPNet.
+	ArgCIDR = NewArgType("cidr", func(arg interface{}) (interface{}, error) {
+		s, ok := arg.(string)
+		if !ok {
+			return nil, fmt.Errorf("expected CIDR, got %v", arg)
+		}
+
+		_, n, err := net.ParseCIDR(s)
+		if err != nil {
+			return nil, fmt.Errorf("invalid CIDR %q: %w", s, err)
+		}
+
+		return n, nil
+	})
+
+	// ArgRegexp accepts a regular expression, converted to *regexp.Regexp.
+	ArgRegexp = NewArgType("regexp", func(arg interface{}) (interface{}, error) {
+		s, ok := arg.(string)
+		if !ok {
+			return nil, fmt.Errorf("expected regular expression, got %v", arg)
+		}
+
+		rx, err := regexp.Compile(s)
+		if err != nil {
+			return nil, fmt.Errorf("invalid regular expression %q: %w", s, err)
+		}
+
+		return rx, nil
+	})
+)
+
+// ArgsSchema declares the types of the arguments of a predicate or a
+// filter.
+type ArgsSchema struct {
+	// Required arguments.
+	Required []ArgType
+
+	// Optional arguments, following the required ones.
+	Optional []ArgType
+
+	// Variadic, when set, is the type of any number of arguments
+	// following the optional ones.
+	Variadic ArgType
+}
+
+// Parse checks the number of the arguments and converts them into the
+// typed values.
+func (s ArgsSchema) Parse(args []interface{}) ([]interface{}, error) {
+	if len(args) < len(s.Required) {
+		return nil, fmt.Errorf("expected at least %d arguments, got %d", len(s.Required), len(args))
+	}
+
+	if s.Variadic == nil && len(args) > len(s.Required)+len(s.Optional) {
+		return nil, fmt.Errorf("expected at most %d arguments, got %d", len(s.Required)+len(s.Optional), len(args))
+	}
+
+	typed := make([]interface{}, len(args))
+	for i, a := range args {
+		var t ArgType
+		switch {
+		case i < len(s.Required):
+			t = s.Required[i]
+		case i < len(s.Required)+len(s.Optional):
+			t = s.Optional[i-len(s.Required)]
+		default:
+			t = s.Variadic
+		}
+
+		v, err := t.Parse(a)
+		if err != nil {
+			return nil, fmt.Errorf("invalid argument %d (%s): %w", i+1, t.Name(), err)
+		}
+
+		typed[i] = v
+	}
+
+	return typed, nil
+}
@@ -0,0 +1,158 @@
+package eskip
+
+import (
+	"errors"
+	"net"
+	"reflect"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestArgTypes(t *testing.T) {
+	_, network, _ := net.ParseCIDR("192.168.0.0/16")
+	for _, tt := range []struct {
+		typ      ArgType
+		arg      interface{}
+		expected interface{}
+		err      bool
+	}{
+		{typ: ArgAny, arg: 42.0, expected: 42.0},
+		{typ: ArgString, arg: "foo", expected: "foo"},
+		{typ: ArgString, arg: 42.0, err: true},
+		{typ: ArgNumber, arg: 0.5, expected: 0.5},
+		{typ: ArgNumber, arg: 2, expected: 2.0},
+		{typ: ArgNumber, arg: "0.5", err: true},
+		{typ: ArgInt, arg: 3.0, expected: 3},
+		{typ: ArgInt, arg: 3, expected: 3},
+		{typ: ArgInt, arg: 3.5, err: true},
+		{typ: ArgInt, arg: 1e20, err: true},
+		{typ: ArgInt, arg: "3", err: true},
+		{typ: ArgDuration, arg: "1m30s", expected: 90 * time.Second},
+		{typ: ArgDuration, arg: time.Second, expected: time.Second},
+		{typ: ArgDuration, arg: "90", err: true},
+		{typ: ArgDuration, arg: 90.0, err: true},
+		{typ: ArgCIDR, arg: "192.168.1.1/16", expected: network},
+		{typ: ArgCIDR, arg: "192.168.1.1", err: true},
+		{typ: ArgCIDR, arg: 42.0, err: true},
+		{typ: ArgRegexp, arg: "^/api", expected: regexp.MustCompile("^/api")},
+		{typ: ArgRegexp, arg: "(", err: true},
+		{typ: ArgRegexp, arg: 42.0, err: true},
+	} {
+		t.Run(tt.typ.Name(), func(t *testing.T) {
+			v, err := tt.typ.Parse(tt.arg)
+			if tt.err {
+				if err == nil {
+					t.Errorf("failed to fail for %v", tt.arg)
+				}
+
+				return
+			}
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if !reflect.DeepEqual(v, tt.expected) {
+				t.Errorf("expected %v, got %v", tt.expected, v)
+			}
+		})
+	}
+}
+
+func TestArgsSchema(t *testing.T) {
+	errOdd := errors.New("odd number")
+	even := NewArgType("even", func(arg interface{}) (interface{}, error) {
+		v, err := ArgInt.Parse(arg)
+		if err != nil {
+			return nil, err
+		}
+
+		if v.(int)%2 != 0 {
+			return nil, errOdd
+		}
+
+		return v, nil
+	})
+
+	for _, tt := range []struct {
+		msg      string
+		schema   ArgsSchema
+		args     []interface{}
+		expected []interface{}
+		err      string
+	}{{
+		msg:      "no args",
+		expected: []interface{}{},
+	}, {
+		msg:    "unexpected args",
+		args:   []interface{}{"foo"},
+		err:    "expected at most 0 arguments, got 1",
+		schema: ArgsSchema{},
+	}, {
+		msg:      "required",
+		schema:   ArgsSchema{Required: []ArgType{ArgString, ArgDuration}},
+		args:     []interface{}{"foo", "1s"},
+		expected: []interface{}{"foo", time.Second},
+	}, {
+		msg:    "missing required",
+		schema: ArgsSchema{Required: []ArgType{ArgString, ArgDuration}},
+		args:   []interface{}{"foo"},
+		err:    "expected at least 2 arguments, got 1",
+	}, {
+		msg:      "optional omitted",
+		schema:   ArgsSchema{Required: []ArgType{ArgString}, Optional: []ArgType{ArgInt}},
+		args:     []interface{}{"foo"},
+		expected: []interface{}{"foo"},
+	}, {
+		msg:      "optional",
+		schema:   ArgsSchema{Required: []ArgType{ArgString}, Optional: []ArgType{ArgInt}},
+		args:     []interface{}{"foo", 2.0},
+		expected: []interface{}{"foo", 2},
+	}, {
+		msg:    "too many",
+		schema: ArgsSchema{Required: []ArgType{ArgString}, Optional: []ArgType{ArgInt}},
+		args:   []interface{}{"foo", 2.0, 3.0},
+		err:    "expected at most 2 arguments, got 3",
+	}, {
+		msg:      "variadic",
+		schema:   ArgsSchema{Required: []ArgType{ArgString}, Variadic: ArgDuration},
+		args:     []interface{}{"foo", "1s", "2s"},
+		expected: []interface{}{"foo", time.Second, 2 * time.Second},
+	}, {
+		msg:    "invalid variadic",
+		schema: ArgsSchema{Required: []ArgType{ArgString}, Variadic: ArgDuration},
+		args:   []interface{}{"foo", "1s", "2x"},
+		err:    `invalid argument 3 (duration): invalid duration "2x"`,
+	}, {
+		msg:      "custom type",
+		schema:   ArgsSchema{Required: []ArgType{even}},
+		args:     []interface{}{4.0},
+		expected: []interface{}{4},
+	}, {
+		msg:    "invalid custom type",
+		schema: ArgsSchema{Required: []ArgType{even}},
+		args:   []interface{}{3.0},
+		err:    "invalid argument 1 (even): odd number",
+	}} {
+		t.Run(tt.msg, func(t *testing.T) {
+			args, err := tt.schema.Parse(tt.args)
+			if tt.err != "" {
+				if err == nil || !strings.HasPrefix(err.Error(), tt.err) {
+					t.Errorf("expected error %q, got: %v", tt.err, err)
+				}
+
+				return
+			}
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if !reflect.DeepEqual(args, tt.expected) {
+				t.Errorf("expected %v, got %v", tt.expected, args)
+			}
+		})
+	}
+}
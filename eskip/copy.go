@@ -7,6 +7,19 @@ func copyArgs(a []interface{}) []interface{} {
 	return c
 }
 
+func copyMetadata(m map[string]string) map[string]string {
+	if m == nil {
+		return nil
+	}
+
+	c := make(map[string]string, len(m))
+	for k, v := range m {
+		c[k] = v
+	}
+
+	return c
+}
+
 // CopyPredicate creates a copy of the input predicate.
 func CopyPredicate(p *Predicate) *Predicate {
 	if p == nil {
@@ -67,6 +80,7 @@ func Copy(r *Route) *Route {
 	c.LBAlgorithm = r.LBAlgorithm
 	c.LBEndpoints = make([]string, len(r.LBEndpoints))
 	copy(c.LBEndpoints, r.LBEndpoints)
+	c.Metadata = copyMetadata(r.Metadata)
 	return c
 }
 
@@ -54,6 +54,20 @@ func eqStrings(left, right []string) bool {
 	return true
 }
 
+func eqMetadata(left, right map[string]string) bool {
+	if len(left) != len(right) {
+		return false
+	}
+
+	for k, v := range left {
+		if rv, ok := right[k]; !ok || rv != v {
+			return false
+		}
+	}
+
+	return true
+}
+
 func eq2(left, right *Route) bool {
 	lc, rc := Canonical(left), Canonical(right)
 
@@ -107,6 +121,10 @@ func eq2(left, right *Route) bool {
 		return false
 	}
 
+	if !eqMetadata(lc.Metadata, rc.Metadata) {
+		return false
+	}
+
 	return true
 }
 
@@ -260,6 +278,10 @@ func Canonical(r *Route) *Route {
 		sort.Strings(c.LBEndpoints)
 	}
 
+	if len(r.Metadata) > 0 {
+		c.Metadata = r.Metadata
+	}
+
 	// Name and Namespace stripped
 
 	return c
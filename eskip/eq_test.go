@@ -36,6 +36,23 @@ func TestEq(t *testing.T) {
 	}, {
 		title:  "non-eq id",
 		routes: []*Route{{Id: "foo"}, {Id: "bar"}},
+	}, {
+		title: "eq metadata",
+		routes: []*Route{
+			{Id: "foo", Metadata: map[string]string{"version": "v1"}},
+			{Id: "foo", Metadata: map[string]string{"version": "v1"}},
+		},
+		expect: true,
+	}, {
+		title:  "eq empty metadata",
+		routes: []*Route{{Id: "foo", Metadata: map[string]string{}}, {Id: "foo"}},
+		expect: true,
+	}, {
+		title: "non-eq metadata",
+		routes: []*Route{
+			{Id: "foo", Metadata: map[string]string{"version": "v1"}},
+			{Id: "foo", Metadata: map[string]string{"version": "v2"}},
+		},
 	}, {
 		title:  "non-eq predicate count",
 		routes: []*Route{{Predicates: []*Predicate{{}, {}}}, {Predicates: []*Predicate{{}}}},
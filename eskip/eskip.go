@@ -332,6 +332,12 @@ type Route struct {
 	// load balancing backends.
 	LBEndpoints []string
 
+	// Metadata contains arbitrary attributes of the route, set by the
+	// data clients, e.g. the deployment version from a Kubernetes
+	// annotation. It has no eskip syntax, and it is passed to the
+	// filters in the state bag, see filters.RouteMetadataKey.
+	Metadata map[string]string
+
 	// Name is deprecated and not used.
 	Name string
 
@@ -415,6 +421,10 @@ func (r *Route) Copy() *Route {
 		copy(c.LBEndpoints, r.LBEndpoints)
 	}
 
+	if len(r.Metadata) > 0 {
+		c.Metadata = copyMetadata(r.Metadata)
+	}
+
 	return &c
 }
 
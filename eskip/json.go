@@ -18,10 +18,11 @@ type jsonBackend struct {
 }
 
 type jsonRoute struct {
-	ID         string       `json:"id,omitempty"`
-	Backend    *jsonBackend `json:"backend,omitempty"`
-	Predicates []*Predicate `json:"predicates,omitempty"`
-	Filters    []*Filter    `json:"filters,omitempty"`
+	ID         string            `json:"id,omitempty"`
+	Backend    *jsonBackend      `json:"backend,omitempty"`
+	Predicates []*Predicate      `json:"predicates,omitempty"`
+	Filters    []*Filter         `json:"filters,omitempty"`
+	Metadata   map[string]string `json:"metadata,omitempty"`
 }
 
 func newJSONRoute(r *Route) *jsonRoute {
@@ -30,6 +31,7 @@ func newJSONRoute(r *Route) *jsonRoute {
 		ID:         cr.Id,
 		Predicates: cr.Predicates,
 		Filters:    cr.Filters,
+		Metadata:   cr.Metadata,
 	}
 
 	if cr.BackendType != NetworkBackend || cr.Backend != "" {
@@ -110,5 +112,9 @@ func (r *Route) UnmarshalJSON(b []byte) error {
 		r.Predicates = nil
 	}
 
+	if len(jr.Metadata) > 0 {
+		r.Metadata = jr.Metadata
+	}
+
 	return nil
 }
@@ -96,6 +96,11 @@ func TestMarshalUnmarshalJSON(t *testing.T) {
 			[]*Route{{Id: "shunty", BackendType: ShuntBackend}},
 			`[{"id":"shunty","backend":{"type":"shunt"}}]`,
 		},
+		{
+			"metadata",
+			[]*Route{{Id: "versioned", BackendType: ShuntBackend, Metadata: map[string]string{"version": "v1"}}},
+			`[{"id":"versioned","backend":{"type":"shunt"},"metadata":{"version":"v1"}}]`,
+		},
 		{
 			"predicates and filters",
 			[]*Route{
@@ -47,6 +47,11 @@ func NewTemplate(template string) *Template {
 	return &Template{template: template, placeholders: placeholders}
 }
 
+// HasPlaceholders tells whether the template contains any placeholders.
+func (t *Template) HasPlaceholders() bool {
+	return len(t.placeholders) > 0
+}
+
 // Apply evaluates the template using a TemplateGetter function to resolve the
 // placeholders.
 func (t *Template) Apply(get TemplateGetter) string {
@@ -64,6 +64,20 @@ func TestTemplateGetter(t *testing.T) {
 	}})
 }
 
+func TestTemplateHasPlaceholders(t *testing.T) {
+	for template, expected := range map[string]bool{
+		"":                 false,
+		"418":              false,
+		"${":               false,
+		"${request.path}":  true,
+		"/api/${id}/items": true,
+	} {
+		if got := NewTemplate(template).HasPlaceholders(); got != expected {
+			t.Errorf("%q: expected %v, got %v", template, expected, got)
+		}
+	}
+}
+
 func TestTemplateApplyContext(t *testing.T) {
 	parseUrl := func(s string) *url.URL {
 		u, err := url.Parse(s)
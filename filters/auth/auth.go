@@ -45,6 +45,8 @@ const (
 	invalidClaim       rejectReason = "invalid-claim"
 	invalidFilter      rejectReason = "invalid-filter"
 	invalidAccess      rejectReason = "invalid-access"
+	missingSignature   rejectReason = "missing-signature"
+	invalidSignature   rejectReason = "invalid-signature"
 )
 
 const (
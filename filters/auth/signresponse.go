@@ -0,0 +1,296 @@
+package auth
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+
+	log "github.com/sirupsen/logrus"
+	"golang.org/x/net/http/httpguts"
+
+	"github.com/zalando/skipper/filters"
+	"github.com/zalando/skipper/net"
+	"github.com/zalando/skipper/secrets"
+)
+
+const defaultSignResponseMaxBody = net.DefaultMaxBodySize
+
+var errUnsupportedSigningKey = errors.New("unsupported signing key")
+
+type (
+	signResponseSpec struct {
+		secretsReader secrets.SecretsReader
+	}
+
+	signResponseFilter struct {
+		keyRef        string
+		header        string
+		maxBody       int64
+		secretsReader secrets.SecretsReader
+
+		mu  sync.Mutex
+		raw []byte
+		key *responseSigningKey
+	}
+
+	responseSigningKey struct {
+		signer crypto.Signer
+		alg    string
+		kid    string
+	}
+)
+
+// NewSignResponse creates a filter specification whose instances sign the
+// response bodies with a detached JWS, RFC 7515, Appendix F, so that the
+// clients can verify the integrity of the responses. The JWS is set in the
+// response header given as the second argument, in the format of
+// <header>..<signature>, where the payload is the response body.
+//
+// The first argument references the private key in the secrets reader, e.g.
+// the path of a file in the credentials paths. The key is in PEM format,
+// either PKCS #8, PKCS #1 or SEC 1, and it can be an ECDSA P-256, P-384 or
+// P-521, an RSA or an Ed25519 key, signed with ES256, ES384, ES512, RS256 or
+// EdDSA, respectively. When the secret changes, e.g. during key rotation,
+// the new key is used. The JWS header contains the key id, kid, the hex
+// encoded first 8 bytes of the SHA-256 hash of the public key, in PKIX
+// format.
+//
+// The responses with a body larger than the optional maximum size, by
+// default 1MB, are not signed.
+//
+// The filter needs to be the first one in the route, so that it signs the
+// body as changed by the other filters.
+//
+// Example:
+//
+//	signResponse("/meta/credentials/signing-key.pem", "X-Signature")
+func NewSignResponse(sr secrets.SecretsReader) filters.Spec {
+	return &signResponseSpec{secretsReader: sr}
+}
+
+func (*signResponseSpec) Name() string { return filters.SignResponseName }
+
+func (s *signResponseSpec) CreateFilter(args []interface{}) (filters.Filter, error) {
+	if len(args) != 2 && len(args) != 3 {
+		return nil, filters.ErrInvalidFilterParameters
+	}
+
+	keyRef, ok := args[0].(string)
+	if !ok || keyRef == "" {
+		return nil, filters.ErrInvalidFilterParameters
+	}
+
+	header, ok := args[1].(string)
+	if !ok || !httpguts.ValidHeaderFieldName(header) {
+		return nil, filters.ErrInvalidFilterParameters
+	}
+
+	f := &signResponseFilter{
+		keyRef:        keyRef,
+		header:        header,
+		maxBody:       defaultSignResponseMaxBody,
+		secretsReader: s.secretsReader,
+	}
+
+	if len(args) == 3 {
+		switch v := args[2].(type) {
+		case int:
+			f.maxBody = int64(v)
+		case float64:
+			f.maxBody = int64(v)
+		default:
+			return nil, filters.ErrInvalidFilterParameters
+		}
+
+		if f.maxBody <= 0 {
+			return nil, filters.ErrInvalidFilterParameters
+		}
+	}
+
+	return f, nil
+}
+
+func parseSigningKey(data []byte) (*responseSigningKey, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errUnsupportedSigningKey
+	}
+
+	var (
+		key interface{}
+		err error
+	)
+
+	switch block.Type {
+	case "RSA PRIVATE KEY":
+		key, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+	case "EC PRIVATE KEY":
+		key, err = x509.ParseECPrivateKey(block.Bytes)
+	default:
+		key, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	k := &responseSigningKey{}
+	switch kt := key.(type) {
+	case *rsa.PrivateKey:
+		k.signer, k.alg = kt, "RS256"
+	case *ecdsa.PrivateKey:
+		k.signer = kt
+		switch kt.Curve.Params().BitSize {
+		case 256:
+			k.alg = "ES256"
+		case 384:
+			k.alg = "ES384"
+		case 521:
+			k.alg = "ES512"
+		default:
+			return nil, errUnsupportedSigningKey
+		}
+	case ed25519.PrivateKey:
+		k.signer, k.alg = kt, "EdDSA"
+	default:
+		return nil, errUnsupportedSigningKey
+	}
+
+	pub, err := x509.MarshalPKIXPublicKey(k.signer.Public())
+	if err != nil {
+		return nil, err
+	}
+
+	sum := sha256.Sum256(pub)
+	k.kid = hex.EncodeToString(sum[:8])
+	return k, nil
+}
+
+// signingKey returns the current key, parsed again only when the secret
+// has changed.
+func (f *signResponseFilter) signingKey() (*responseSigningKey, error) {
+	raw, ok := f.secretsReader.GetSecret(f.keyRef)
+	if !ok {
+		return nil, fmt.Errorf("signing key not found: %s", f.keyRef)
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.key != nil && bytes.Equal(raw, f.raw) {
+		return f.key, nil
+	}
+
+	k, err := parseSigningKey(raw)
+	if err != nil {
+		return nil, err
+	}
+
+	f.raw, f.key = raw, k
+	return k, nil
+}
+
+func (k *responseSigningKey) sign(payload []byte) (string, error) {
+	header, err := json.Marshal(map[string]string{"alg": k.alg, "kid": k.kid})
+	if err != nil {
+		return "", err
+	}
+
+	h := base64.RawURLEncoding.EncodeToString(header)
+	input := h + "." + base64.RawURLEncoding.EncodeToString(payload)
+
+	var sig []byte
+	switch k.alg {
+	case "EdDSA":
+		sig, err = k.signer.Sign(rand.Reader, []byte(input), crypto.Hash(0))
+	case "RS256":
+		sum := sha256.Sum256([]byte(input))
+		sig, err = k.signer.Sign(rand.Reader, sum[:], crypto.SHA256)
+	default:
+		sig, err = signECDSA(k.signer.(*ecdsa.PrivateKey), k.alg, []byte(input))
+	}
+
+	if err != nil {
+		return "", err
+	}
+
+	return h + ".." + base64.RawURLEncoding.EncodeToString(sig), nil
+}
+
+// signECDSA returns the signature as the concatenated R and S values, as
+// required by JWS, RFC 7518, 3.4.
+func signECDSA(key *ecdsa.PrivateKey, alg string, input []byte) ([]byte, error) {
+	var hash crypto.Hash
+	switch alg {
+	case "ES256":
+		hash = crypto.SHA256
+	case "ES384":
+		hash = crypto.SHA384
+	default:
+		hash = crypto.SHA512
+	}
+
+	h := hash.New()
+	h.Write(input)
+	r, s, err := ecdsa.Sign(rand.Reader, key, h.Sum(nil))
+	if err != nil {
+		return nil, err
+	}
+
+	size := (key.Curve.Params().BitSize + 7) / 8
+	sig := make([]byte, 2*size)
+	r.FillBytes(sig[:size])
+	s.FillBytes(sig[size:])
+	return sig, nil
+}
+
+func (*signResponseFilter) Request(filters.FilterContext) {}
+
+func (f *signResponseFilter) Response(ctx filters.FilterContext) {
+	rsp := ctx.Response()
+	if rsp.ContentLength > f.maxBody {
+		return
+	}
+
+	var body []byte
+	if rsp.Body != nil {
+		var (
+			original io.ReadCloser
+			ok       bool
+		)
+
+		if body, original, ok = net.BufferBody(rsp.Body, f.maxBody); !ok {
+			rsp.Body = original
+			return
+		}
+
+		rsp.Body = io.NopCloser(bytes.NewReader(body))
+	}
+
+	k, err := f.signingKey()
+	if err != nil {
+		log.Errorf("%s: %v", filters.SignResponseName, err)
+		return
+	}
+
+	jws, err := k.sign(body)
+	if err != nil {
+		log.Errorf("%s: failed to sign the response: %v", filters.SignResponseName, err)
+		return
+	}
+
+	rsp.Header.Set(f.header, jws)
+}
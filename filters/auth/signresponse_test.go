@@ -0,0 +1,303 @@
+package auth
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/sha512"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"io"
+	"math/big"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/zalando/skipper/filters"
+	"github.com/zalando/skipper/filters/filtertest"
+)
+
+type testSecrets struct {
+	mu      sync.Mutex
+	secrets map[string][]byte
+}
+
+func (s *testSecrets) GetSecret(name string) ([]byte, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	b, ok := s.secrets[name]
+	return b, ok
+}
+
+func (s *testSecrets) set(name string, b []byte) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.secrets[name] = b
+}
+
+func (*testSecrets) Close() {}
+
+func marshalTestKey(t *testing.T, key crypto.Signer) []byte {
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der})
+}
+
+// verifyDetachedJWS verifies the detached JWS of the payload with the public
+// key, and returns its header.
+func verifyDetachedJWS(t *testing.T, jws string, payload []byte, pub crypto.PublicKey) map[string]string {
+	parts := strings.Split(jws, ".")
+	if len(parts) != 3 || parts[1] != "" {
+		t.Fatalf("invalid detached JWS: %s", jws)
+	}
+
+	hb, err := base64.RawURLEncoding.DecodeString(parts[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var header map[string]string
+	if err := json.Unmarshal(hb, &header); err != nil {
+		t.Fatal(err)
+	}
+
+	sig, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	input := []byte(parts[0] + "." + base64.RawURLEncoding.EncodeToString(payload))
+	var valid bool
+	switch k := pub.(type) {
+	case ed25519.PublicKey:
+		valid = ed25519.Verify(k, input, sig)
+	case *rsa.PublicKey:
+		sum := sha256.Sum256(input)
+		valid = rsa.VerifyPKCS1v15(k, crypto.SHA256, sum[:], sig) == nil
+	case *ecdsa.PublicKey:
+		var digest []byte
+		switch header["alg"] {
+		case "ES256":
+			sum := sha256.Sum256(input)
+			digest = sum[:]
+		case "ES384":
+			sum := sha512.Sum384(input)
+			digest = sum[:]
+		}
+
+		size := len(sig) / 2
+		r, s := new(big.Int).SetBytes(sig[:size]), new(big.Int).SetBytes(sig[size:])
+		valid = ecdsa.Verify(k, digest, r, s)
+	}
+
+	if !valid {
+		t.Errorf("invalid signature: %s", jws)
+	}
+
+	return header
+}
+
+func TestSignResponseCreateFilter(t *testing.T) {
+	spec := NewSignResponse(&testSecrets{})
+	if spec.Name() != filters.SignResponseName {
+		t.Errorf("invalid name: %s", spec.Name())
+	}
+
+	for _, tt := range []struct {
+		name    string
+		args    []interface{}
+		wantErr bool
+	}{{
+		name:    "no args",
+		wantErr: true,
+	}, {
+		name:    "missing header",
+		args:    []interface{}{"key"},
+		wantErr: true,
+	}, {
+		name:    "invalid header",
+		args:    []interface{}{"key", "X Signature"},
+		wantErr: true,
+	}, {
+		name:    "invalid max body",
+		args:    []interface{}{"key", "X-Signature", 0},
+		wantErr: true,
+	}, {
+		name:    "max body not a number",
+		args:    []interface{}{"key", "X-Signature", "1MB"},
+		wantErr: true,
+	}, {
+		name: "valid",
+		args: []interface{}{"key", "X-Signature"},
+	}, {
+		name: "valid with max body",
+		args: []interface{}{"key", "X-Signature", 1024},
+	}} {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := spec.CreateFilter(tt.args)
+			if tt.wantErr && err == nil {
+				t.Error("failed to fail")
+			} else if !tt.wantErr && err != nil {
+				t.Error(err)
+			}
+		})
+	}
+}
+
+func signTestResponse(t *testing.T, f filters.Filter, body string) (*http.Response, []byte) {
+	ctx := &filtertest.Context{
+		FRequest: &http.Request{},
+		FResponse: &http.Response{
+			Header: http.Header{},
+			Body:   io.NopCloser(strings.NewReader(body)),
+		},
+	}
+
+	f.Response(ctx)
+	b, err := io.ReadAll(ctx.FResponse.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return ctx.FResponse, b
+}
+
+func TestSignResponse(t *testing.T) {
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ec384Key, err := ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, edKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tt := range []struct {
+		name string
+		key  crypto.Signer
+		alg  string
+	}{
+		{name: "ECDSA P-256", key: ecKey, alg: "ES256"},
+		{name: "ECDSA P-384", key: ec384Key, alg: "ES384"},
+		{name: "RSA", key: rsaKey, alg: "RS256"},
+		{name: "Ed25519", key: edKey, alg: "EdDSA"},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			sr := &testSecrets{secrets: map[string][]byte{"key": marshalTestKey(t, tt.key)}}
+			f, err := NewSignResponse(sr).CreateFilter([]interface{}{"key", "X-Signature"})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			const body = `{"hello": "world"}`
+			rsp, b := signTestResponse(t, f, body)
+			if string(b) != body {
+				t.Errorf("unexpected body: %s", b)
+			}
+
+			header := verifyDetachedJWS(t, rsp.Header.Get("X-Signature"), b, tt.key.Public())
+			if header["alg"] != tt.alg || header["kid"] == "" {
+				t.Errorf("unexpected JWS header: %v", header)
+			}
+		})
+	}
+}
+
+func TestSignResponseKeyRotation(t *testing.T) {
+	key1, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	key2, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sr := &testSecrets{secrets: map[string][]byte{"key": marshalTestKey(t, key1)}}
+	f, err := NewSignResponse(sr).CreateFilter([]interface{}{"key", "X-Signature"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rsp, b := signTestResponse(t, f, "first")
+	kid1 := verifyDetachedJWS(t, rsp.Header.Get("X-Signature"), b, key1.Public())["kid"]
+
+	sr.set("key", marshalTestKey(t, key2))
+	rsp, b = signTestResponse(t, f, "second")
+	kid2 := verifyDetachedJWS(t, rsp.Header.Get("X-Signature"), b, key2.Public())["kid"]
+
+	if kid1 == kid2 {
+		t.Errorf("the key id didn't change after the rotation: %s", kid1)
+	}
+}
+
+func TestSignResponseSkipped(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("oversize body", func(t *testing.T) {
+		sr := &testSecrets{secrets: map[string][]byte{"key": marshalTestKey(t, key)}}
+		f, err := NewSignResponse(sr).CreateFilter([]interface{}{"key", "X-Signature", 4})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		rsp, b := signTestResponse(t, f, "too large")
+		if string(b) != "too large" {
+			t.Errorf("unexpected body: %s", b)
+		}
+
+		if s := rsp.Header.Get("X-Signature"); s != "" {
+			t.Errorf("unexpected signature: %s", s)
+		}
+	})
+
+	t.Run("missing key", func(t *testing.T) {
+		f, err := NewSignResponse(&testSecrets{}).CreateFilter([]interface{}{"key", "X-Signature"})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		rsp, b := signTestResponse(t, f, "hello")
+		if string(b) != "hello" || rsp.Header.Get("X-Signature") != "" {
+			t.Errorf("unexpected response: %s, %v", b, rsp.Header)
+		}
+	})
+
+	t.Run("invalid key", func(t *testing.T) {
+		sr := &testSecrets{secrets: map[string][]byte{"key": []byte("not a key")}}
+		f, err := NewSignResponse(sr).CreateFilter([]interface{}{"key", "X-Signature"})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		rsp, b := signTestResponse(t, f, "hello")
+		if string(b) != "hello" || rsp.Header.Get("X-Signature") != "" {
+			t.Errorf("unexpected response: %s, %v", b, rsp.Header)
+		}
+	})
+}
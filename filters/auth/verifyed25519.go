@@ -0,0 +1,228 @@
+package auth
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+
+	"golang.org/x/net/http/httpguts"
+
+	"github.com/zalando/skipper/filters"
+	"github.com/zalando/skipper/net"
+	"github.com/zalando/skipper/secrets"
+)
+
+const defaultVerifyEd25519MaxBody = net.DefaultMaxBodySize
+
+var errNoEd25519PublicKey = errors.New("no Ed25519 public key")
+
+type (
+	verifyEd25519Spec struct {
+		secretsReader secrets.SecretsReader
+	}
+
+	verifyEd25519Filter struct {
+		header        string
+		keyRef        string
+		maxBody       int64
+		secretsReader secrets.SecretsReader
+
+		mu   sync.Mutex
+		raw  []byte
+		keys []ed25519.PublicKey
+	}
+)
+
+// NewVerifyEd25519 creates a filter specification whose instances verify
+// the Ed25519 signature of the requests, e.g. of webhooks, and reject the
+// requests without a valid signature with 403 Forbidden.
+//
+// The first argument is the name of the request header containing the
+// base64 encoded signature. The second argument references the public keys
+// in the secrets reader, e.g. the path of a file in the credentials paths.
+// The file contains one or more Ed25519 public keys, in PEM format, PKIX,
+// and the signature is accepted when it is valid with any of them, which
+// allows rotating the keys. When the secret changes, the new keys are used.
+//
+// The signature is over the canonical request, the method, the path with
+// the query, and the hex encoded SHA-256 hash of the body, separated by new
+// lines:
+//
+//	POST
+//	/hooks/orders?source=shop
+//	e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855
+//
+// The request body is buffered, up to the optional maximum size, by default
+// 1MB. The requests with a larger body are rejected.
+//
+// Example:
+//
+//	verifyEd25519("X-Signature", "/meta/credentials/webhook-keys.pem")
+func NewVerifyEd25519(sr secrets.SecretsReader) filters.Spec {
+	return &verifyEd25519Spec{secretsReader: sr}
+}
+
+func (*verifyEd25519Spec) Name() string { return filters.VerifyEd25519Name }
+
+func (s *verifyEd25519Spec) CreateFilter(args []interface{}) (filters.Filter, error) {
+	if len(args) != 2 && len(args) != 3 {
+		return nil, filters.ErrInvalidFilterParameters
+	}
+
+	header, ok := args[0].(string)
+	if !ok || !httpguts.ValidHeaderFieldName(header) {
+		return nil, filters.ErrInvalidFilterParameters
+	}
+
+	keyRef, ok := args[1].(string)
+	if !ok || keyRef == "" {
+		return nil, filters.ErrInvalidFilterParameters
+	}
+
+	f := &verifyEd25519Filter{
+		header:        header,
+		keyRef:        keyRef,
+		maxBody:       defaultVerifyEd25519MaxBody,
+		secretsReader: s.secretsReader,
+	}
+
+	if len(args) == 3 {
+		switch v := args[2].(type) {
+		case int:
+			f.maxBody = int64(v)
+		case float64:
+			f.maxBody = int64(v)
+		default:
+			return nil, filters.ErrInvalidFilterParameters
+		}
+
+		if f.maxBody <= 0 {
+			return nil, filters.ErrInvalidFilterParameters
+		}
+	}
+
+	return f, nil
+}
+
+func parseEd25519PublicKeys(data []byte) ([]ed25519.PublicKey, error) {
+	var keys []ed25519.PublicKey
+	for {
+		var block *pem.Block
+		block, data = pem.Decode(data)
+		if block == nil {
+			break
+		}
+
+		if block.Type != "PUBLIC KEY" {
+			continue
+		}
+
+		key, err := x509.ParsePKIXPublicKey(block.Bytes)
+		if err != nil {
+			return nil, err
+		}
+
+		if k, ok := key.(ed25519.PublicKey); ok {
+			keys = append(keys, k)
+		}
+	}
+
+	if len(keys) == 0 {
+		return nil, errNoEd25519PublicKey
+	}
+
+	return keys, nil
+}
+
+// publicKeys returns the current keys, parsed again only when the secret
+// has changed.
+func (f *verifyEd25519Filter) publicKeys() ([]ed25519.PublicKey, error) {
+	raw, ok := f.secretsReader.GetSecret(f.keyRef)
+	if !ok {
+		return nil, fmt.Errorf("public keys not found: %s", f.keyRef)
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.keys != nil && bytes.Equal(raw, f.raw) {
+		return f.keys, nil
+	}
+
+	keys, err := parseEd25519PublicKeys(raw)
+	if err != nil {
+		return nil, err
+	}
+
+	f.raw, f.keys = raw, keys
+	return keys, nil
+}
+
+// decodeSignature accepts both the standard and the URL safe base64
+// alphabets, with or without padding.
+func decodeSignature(s string) ([]byte, error) {
+	s = strings.TrimRight(strings.TrimSpace(s), "=")
+	if sig, err := base64.RawStdEncoding.DecodeString(s); err == nil {
+		return sig, nil
+	}
+
+	return base64.RawURLEncoding.DecodeString(s)
+}
+
+func (f *verifyEd25519Filter) Request(ctx filters.FilterContext) {
+	req := ctx.Request()
+	sig, err := decodeSignature(req.Header.Get(f.header))
+	if err != nil || len(sig) != ed25519.SignatureSize {
+		forbidden(ctx, AuthUnknown, missingSignature, "")
+		return
+	}
+
+	if req.ContentLength > f.maxBody {
+		forbidden(ctx, AuthUnknown, invalidSignature, "request body too large")
+		return
+	}
+
+	var body []byte
+	if req.Body != nil {
+		var (
+			original io.ReadCloser
+			ok       bool
+		)
+
+		if body, original, ok = net.BufferBody(req.Body, f.maxBody); !ok {
+			req.Body = original
+			forbidden(ctx, AuthUnknown, invalidSignature, "request body too large or failed")
+			return
+		}
+
+		req.Body = io.NopCloser(bytes.NewReader(body))
+	}
+
+	keys, err := f.publicKeys()
+	if err != nil {
+		ctx.Logger().Errorf("%s: %v", filters.VerifyEd25519Name, err)
+		forbidden(ctx, AuthUnknown, invalidSignature, "")
+		return
+	}
+
+	sum := sha256.Sum256(body)
+	message := []byte(req.Method + "\n" + req.URL.RequestURI() + "\n" + hex.EncodeToString(sum[:]))
+	for _, k := range keys {
+		if ed25519.Verify(k, message, sig) {
+			return
+		}
+	}
+
+	forbidden(ctx, AuthUnknown, invalidSignature, "")
+}
+
+func (*verifyEd25519Filter) Response(filters.FilterContext) {}
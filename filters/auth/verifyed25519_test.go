@@ -0,0 +1,261 @@
+package auth
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/pem"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/zalando/skipper/filters/filtertest"
+)
+
+func generateEd25519Key(t *testing.T) (ed25519.PublicKey, ed25519.PrivateKey) {
+	pub, key, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return pub, key
+}
+
+func marshalPublicKeys(t *testing.T, keys ...ed25519.PublicKey) []byte {
+	var b []byte
+	for _, k := range keys {
+		der, err := x509.MarshalPKIXPublicKey(k)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		b = append(b, pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der})...)
+	}
+
+	return b
+}
+
+func signRequest(key ed25519.PrivateKey, method, uri, body string) string {
+	sum := sha256.Sum256([]byte(body))
+	message := method + "\n" + uri + "\n" + hex.EncodeToString(sum[:])
+	return base64.StdEncoding.EncodeToString(ed25519.Sign(key, []byte(message)))
+}
+
+func TestVerifyEd25519Create(t *testing.T) {
+	for _, tt := range []struct {
+		msg  string
+		args []interface{}
+		err  bool
+	}{{
+		msg: "no args",
+		err: true,
+	}, {
+		msg:  "missing key ref",
+		args: []interface{}{"X-Signature"},
+		err:  true,
+	}, {
+		msg:  "invalid header",
+		args: []interface{}{"X Signature", "keys.pem"},
+		err:  true,
+	}, {
+		msg:  "empty key ref",
+		args: []interface{}{"X-Signature", ""},
+		err:  true,
+	}, {
+		msg:  "invalid max body",
+		args: []interface{}{"X-Signature", "keys.pem", 0},
+		err:  true,
+	}, {
+		msg:  "too many args",
+		args: []interface{}{"X-Signature", "keys.pem", 1024, 1},
+		err:  true,
+	}, {
+		msg:  "header and key ref",
+		args: []interface{}{"X-Signature", "keys.pem"},
+	}, {
+		msg:  "max body",
+		args: []interface{}{"X-Signature", "keys.pem", 1024.0},
+	}} {
+		t.Run(tt.msg, func(t *testing.T) {
+			_, err := NewVerifyEd25519(&testSecrets{}).CreateFilter(tt.args)
+			if tt.err && err == nil {
+				t.Error("expected error")
+			} else if !tt.err && err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+		})
+	}
+}
+
+func TestVerifyEd25519(t *testing.T) {
+	oldPub, oldKey := generateEd25519Key(t)
+	newPub, newKey := generateEd25519Key(t)
+	_, otherKey := generateEd25519Key(t)
+
+	const body = `{"order": 42}`
+	for _, tt := range []struct {
+		msg       string
+		keys      []ed25519.PublicKey
+		body      string
+		signature string
+		forbidden bool
+	}{{
+		msg:       "valid",
+		keys:      []ed25519.PublicKey{oldPub},
+		signature: signRequest(oldKey, "POST", "/hooks/orders?source=shop", body),
+	}, {
+		msg:       "valid without padding",
+		keys:      []ed25519.PublicKey{oldPub},
+		signature: strings.TrimRight(signRequest(oldKey, "POST", "/hooks/orders?source=shop", body), "="),
+	}, {
+		msg:       "rotated, old key",
+		keys:      []ed25519.PublicKey{newPub, oldPub},
+		signature: signRequest(oldKey, "POST", "/hooks/orders?source=shop", body),
+	}, {
+		msg:       "rotated, new key",
+		keys:      []ed25519.PublicKey{newPub, oldPub},
+		signature: signRequest(newKey, "POST", "/hooks/orders?source=shop", body),
+	}, {
+		msg:       "rotated out key",
+		keys:      []ed25519.PublicKey{newPub},
+		signature: signRequest(oldKey, "POST", "/hooks/orders?source=shop", body),
+		forbidden: true,
+	}, {
+		msg:       "unknown key",
+		keys:      []ed25519.PublicKey{oldPub},
+		signature: signRequest(otherKey, "POST", "/hooks/orders?source=shop", body),
+		forbidden: true,
+	}, {
+		msg:       "missing signature",
+		keys:      []ed25519.PublicKey{oldPub},
+		forbidden: true,
+	}, {
+		msg:       "malformed signature",
+		keys:      []ed25519.PublicKey{oldPub},
+		signature: "not a signature",
+		forbidden: true,
+	}, {
+		msg:       "different method",
+		keys:      []ed25519.PublicKey{oldPub},
+		signature: signRequest(oldKey, "PUT", "/hooks/orders?source=shop", body),
+		forbidden: true,
+	}, {
+		msg:       "different path",
+		keys:      []ed25519.PublicKey{oldPub},
+		signature: signRequest(oldKey, "POST", "/hooks/payments?source=shop", body),
+		forbidden: true,
+	}, {
+		msg:       "different query",
+		keys:      []ed25519.PublicKey{oldPub},
+		signature: signRequest(oldKey, "POST", "/hooks/orders?source=admin", body),
+		forbidden: true,
+	}, {
+		msg:       "tampered body",
+		keys:      []ed25519.PublicKey{oldPub},
+		body:      `{"order": 43}`,
+		signature: signRequest(oldKey, "POST", "/hooks/orders?source=shop", body),
+		forbidden: true,
+	}, {
+		msg:       "body too large",
+		keys:      []ed25519.PublicKey{oldPub},
+		body:      strings.Repeat("x", 65),
+		signature: signRequest(oldKey, "POST", "/hooks/orders?source=shop", strings.Repeat("x", 65)),
+		forbidden: true,
+	}, {
+		msg:       "no keys",
+		signature: signRequest(oldKey, "POST", "/hooks/orders?source=shop", body),
+		forbidden: true,
+	}} {
+		t.Run(tt.msg, func(t *testing.T) {
+			secrets := &testSecrets{secrets: make(map[string][]byte)}
+			if tt.keys != nil {
+				secrets.set("keys.pem", marshalPublicKeys(t, tt.keys...))
+			}
+
+			f, err := NewVerifyEd25519(secrets).CreateFilter([]interface{}{"X-Signature", "keys.pem", 64})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			requestBody := body
+			if tt.body != "" {
+				requestBody = tt.body
+			}
+
+			req, err := http.NewRequest("POST", "https://www.example.org/hooks/orders?source=shop", strings.NewReader(requestBody))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if tt.signature != "" {
+				req.Header.Set("X-Signature", tt.signature)
+			}
+
+			ctx := &filtertest.Context{FRequest: req, FStateBag: make(map[string]interface{})}
+			f.Request(ctx)
+
+			if !tt.forbidden {
+				if ctx.Served() {
+					t.Fatalf("unexpected rejection: %d", ctx.Response().StatusCode)
+				}
+
+				b, err := io.ReadAll(req.Body)
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				if !bytes.Equal(b, []byte(requestBody)) {
+					t.Errorf("failed to preserve the body: %s", b)
+				}
+
+				return
+			}
+
+			if !ctx.Served() || ctx.Response().StatusCode != http.StatusForbidden {
+				t.Error("failed to reject the request")
+			}
+		})
+	}
+}
+
+func TestVerifyEd25519KeyRotation(t *testing.T) {
+	oldPub, oldKey := generateEd25519Key(t)
+	newPub, newKey := generateEd25519Key(t)
+
+	secrets := &testSecrets{secrets: map[string][]byte{"keys.pem": marshalPublicKeys(t, oldPub)}}
+	f, err := NewVerifyEd25519(secrets).CreateFilter([]interface{}{"X-Signature", "keys.pem"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	verify := func(key ed25519.PrivateKey) bool {
+		req, err := http.NewRequest("GET", "https://www.example.org/hooks", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		req.Header.Set("X-Signature", signRequest(key, "GET", "/hooks", ""))
+		ctx := &filtertest.Context{FRequest: req, FStateBag: make(map[string]interface{})}
+		f.Request(ctx)
+		return !ctx.Served()
+	}
+
+	if !verify(oldKey) || verify(newKey) {
+		t.Fatal("failed to verify with the initial key")
+	}
+
+	secrets.set("keys.pem", marshalPublicKeys(t, oldPub, newPub))
+	if !verify(oldKey) || !verify(newKey) {
+		t.Fatal("failed to verify during the rotation")
+	}
+
+	secrets.set("keys.pem", marshalPublicKeys(t, newPub))
+	if verify(oldKey) || !verify(newKey) {
+		t.Fatal("failed to verify after the rotation")
+	}
+}
@@ -0,0 +1,142 @@
+package builtin
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/zalando/skipper/filters"
+)
+
+type normalizeAcceptLanguageSpec struct{}
+
+type normalizeAcceptLanguageFilter struct {
+	// supported languages in lower case, the first one is the default
+	supported []string
+	canonical map[string]string
+}
+
+type acceptedLanguage struct {
+	tag string
+	q   float64
+}
+
+// NewNormalizeAcceptLanguage creates a filter specification whose
+// instances parse the Accept-Language request header, pick the best
+// matching language from the supported ones, and rewrite the header
+// to contain only the chosen language tag. The choice is also stored
+// in the state bag under filters.AcceptLanguageKey.
+//
+// The first supported language is used as the default, when the
+// header is missing or none of the accepted languages is supported.
+//
+// Example:
+//
+//	normalizeAcceptLanguage("en", "de", "fr")
+func NewNormalizeAcceptLanguage() filters.Spec { return normalizeAcceptLanguageSpec{} }
+
+func (normalizeAcceptLanguageSpec) Name() string { return filters.NormalizeAcceptLanguageName }
+
+func (normalizeAcceptLanguageSpec) CreateFilter(args []interface{}) (filters.Filter, error) {
+	if len(args) == 0 {
+		return nil, filters.ErrInvalidFilterParameters
+	}
+
+	f := &normalizeAcceptLanguageFilter{canonical: make(map[string]string)}
+	for _, a := range args {
+		s, ok := a.(string)
+		if !ok || s == "" || strings.ContainsAny(s, ",;* ") {
+			return nil, filters.ErrInvalidFilterParameters
+		}
+
+		l := strings.ToLower(s)
+		if _, ok := f.canonical[l]; ok {
+			return nil, filters.ErrInvalidFilterParameters
+		}
+
+		f.supported = append(f.supported, l)
+		f.canonical[l] = s
+	}
+
+	return f, nil
+}
+
+// parseAcceptLanguage returns the accepted language tags in lower case,
+// ordered by their quality value, keeping the header order for equal
+// values. Tags with zero or invalid quality are dropped.
+func parseAcceptLanguage(h string) []acceptedLanguage {
+	var accepted []acceptedLanguage
+	for _, part := range strings.Split(h, ",") {
+		tag, params, _ := strings.Cut(part, ";")
+		tag = strings.ToLower(strings.TrimSpace(tag))
+		if tag == "" {
+			continue
+		}
+
+		q := 1.0
+		if params = strings.TrimSpace(params); params != "" {
+			v := strings.TrimSpace(strings.TrimPrefix(params, "q="))
+			if v == params {
+				continue
+			}
+
+			var err error
+			q, err = strconv.ParseFloat(v, 64)
+			if err != nil || q < 0 || q > 1 {
+				continue
+			}
+		}
+
+		if q > 0 {
+			accepted = append(accepted, acceptedLanguage{tag: tag, q: q})
+		}
+	}
+
+	sort.SliceStable(accepted, func(i, j int) bool { return accepted[i].q > accepted[j].q })
+	return accepted
+}
+
+func (f *normalizeAcceptLanguageFilter) match(tag string) (string, bool) {
+	if tag == "*" {
+		return f.supported[0], true
+	}
+
+	if _, ok := f.canonical[tag]; ok {
+		return tag, true
+	}
+
+	// a language range like de-DE matches the supported primary tag de
+	if primary, _, ok := strings.Cut(tag, "-"); ok {
+		if _, ok := f.canonical[primary]; ok {
+			return primary, true
+		}
+	}
+
+	// a language range like de matches the first supported de-XX
+	for _, s := range f.supported {
+		if strings.HasPrefix(s, tag+"-") {
+			return s, true
+		}
+	}
+
+	return "", false
+}
+
+func (f *normalizeAcceptLanguageFilter) best(h string) string {
+	for _, a := range parseAcceptLanguage(h) {
+		if l, ok := f.match(a.tag); ok {
+			return l
+		}
+	}
+
+	return f.supported[0]
+}
+
+func (f *normalizeAcceptLanguageFilter) Request(ctx filters.FilterContext) {
+	req := ctx.Request()
+	lang := f.canonical[f.best(strings.Join(req.Header.Values("Accept-Language"), ","))]
+	req.Header.Set("Accept-Language", lang)
+	ctx.StateBag()[filters.AcceptLanguageKey] = lang
+}
+
+func (f *normalizeAcceptLanguageFilter) Response(filters.FilterContext) {}
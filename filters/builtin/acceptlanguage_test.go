@@ -0,0 +1,153 @@
+package builtin
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/zalando/skipper/filters"
+	"github.com/zalando/skipper/filters/filtertest"
+)
+
+func TestNormalizeAcceptLanguageCreate(t *testing.T) {
+	for _, tt := range []struct {
+		msg  string
+		args []interface{}
+		err  bool
+	}{{
+		msg: "no arguments",
+		err: true,
+	}, {
+		msg:  "not a string",
+		args: []interface{}{"en", 42.0},
+		err:  true,
+	}, {
+		msg:  "empty language",
+		args: []interface{}{"en", ""},
+		err:  true,
+	}, {
+		msg:  "wildcard",
+		args: []interface{}{"*"},
+		err:  true,
+	}, {
+		msg:  "duplicate",
+		args: []interface{}{"en", "de", "EN"},
+		err:  true,
+	}, {
+		msg:  "languages",
+		args: []interface{}{"en", "de", "fr"},
+	}} {
+		t.Run(tt.msg, func(t *testing.T) {
+			_, err := NewNormalizeAcceptLanguage().CreateFilter(tt.args)
+			if tt.err && err == nil {
+				t.Error("expected error")
+			} else if !tt.err && err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+		})
+	}
+}
+
+func TestNormalizeAcceptLanguage(t *testing.T) {
+	for _, tt := range []struct {
+		msg      string
+		args     []interface{}
+		header   []string
+		expected string
+	}{{
+		msg:      "missing header uses the default",
+		args:     []interface{}{"en", "de", "fr"},
+		expected: "en",
+	}, {
+		msg:      "single supported language",
+		args:     []interface{}{"en", "de", "fr"},
+		header:   []string{"de"},
+		expected: "de",
+	}, {
+		msg:      "case insensitive",
+		args:     []interface{}{"en", "de", "fr"},
+		header:   []string{"FR"},
+		expected: "fr",
+	}, {
+		msg:      "quality values",
+		args:     []interface{}{"en", "de", "fr"},
+		header:   []string{"en;q=0.5, fr;q=0.8, de;q=0.7"},
+		expected: "fr",
+	}, {
+		msg:      "header order for equal quality",
+		args:     []interface{}{"en", "de", "fr"},
+		header:   []string{"de, fr"},
+		expected: "de",
+	}, {
+		msg:      "region falls back to primary tag",
+		args:     []interface{}{"en", "de", "fr"},
+		header:   []string{"de-CH, fr;q=0.9"},
+		expected: "de",
+	}, {
+		msg:      "primary tag matches supported region",
+		args:     []interface{}{"en-US", "de-DE"},
+		header:   []string{"de"},
+		expected: "de-DE",
+	}, {
+		msg:      "unsupported is skipped",
+		args:     []interface{}{"en", "de", "fr"},
+		header:   []string{"es, it;q=0.9, fr;q=0.1"},
+		expected: "fr",
+	}, {
+		msg:      "zero quality is not acceptable",
+		args:     []interface{}{"en", "de", "fr"},
+		header:   []string{"de;q=0, fr;q=0.1"},
+		expected: "fr",
+	}, {
+		msg:      "invalid quality is ignored",
+		args:     []interface{}{"en", "de", "fr"},
+		header:   []string{"de;q=foo, fr;q=0.1"},
+		expected: "fr",
+	}, {
+		msg:      "wildcard uses the default",
+		args:     []interface{}{"en", "de", "fr"},
+		header:   []string{"es, *;q=0.5"},
+		expected: "en",
+	}, {
+		msg:      "nothing supported uses the default",
+		args:     []interface{}{"de", "en"},
+		header:   []string{"es, it"},
+		expected: "de",
+	}, {
+		msg:      "multiple headers",
+		args:     []interface{}{"en", "de", "fr"},
+		header:   []string{"es", "fr;q=0.8"},
+		expected: "fr",
+	}, {
+		msg:      "canonical tag",
+		args:     []interface{}{"en-US", "de-DE"},
+		header:   []string{"DE-de"},
+		expected: "de-DE",
+	}} {
+		t.Run(tt.msg, func(t *testing.T) {
+			f, err := NewNormalizeAcceptLanguage().CreateFilter(tt.args)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			req, err := http.NewRequest("GET", "https://www.example.org", nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			for _, h := range tt.header {
+				req.Header.Add("Accept-Language", h)
+			}
+
+			ctx := &filtertest.Context{FRequest: req, FStateBag: make(map[string]interface{})}
+			f.Request(ctx)
+
+			if got := req.Header.Values("Accept-Language"); len(got) != 1 || got[0] != tt.expected {
+				t.Errorf("expected Accept-Language: %q, got: %q", tt.expected, got)
+			}
+
+			if got := ctx.StateBag()[filters.AcceptLanguageKey]; got != tt.expected {
+				t.Errorf("expected state bag value: %q, got: %v", tt.expected, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,292 @@
+package builtin
+
+import (
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/zalando/skipper/filters"
+	"github.com/zalando/skipper/internal/window"
+	"github.com/zalando/skipper/routing"
+)
+
+const (
+	autoKillHeader      = "X-Auto-Kill"
+	autoKillMinRequests = 10
+	autoKillStateKey    = "filter:autokill"
+)
+
+// AutoKillSpec is the specification of the autoKill filter.
+type AutoKillSpec struct {
+	mu       sync.Mutex
+	trackers map[autoKillKey]*autoKillTracker
+	now      func() time.Time
+}
+
+type autoKillPostProcessor struct {
+	spec *AutoKillSpec
+}
+
+type autoKillKey struct {
+	routeId, cohort string
+}
+
+type autoKillConfig struct {
+	threshold float64
+	window    time.Duration
+	cooldown  time.Duration
+}
+
+type autoKillFilter struct {
+	spec   *AutoKillSpec
+	config autoKillConfig
+}
+
+// the kinds of the counted responses
+const (
+	autoKillRequests = iota
+	autoKillErrors
+)
+
+type autoKillTracker struct {
+	config      autoKillConfig
+	mu          sync.Mutex
+	counts      *window.Counter
+	killedUntil time.Time
+}
+
+// NewAutoKill creates a filter specification whose instances gate the cohort
+// traffic, when the rate of the 5xx responses of the cohort exceeds the
+// configured threshold within a sliding window. While gated, the requests
+// get the X-Auto-Kill header set to the cohort, and they are looped back
+// to the routing, where a fallback route can send them to the stable
+// backend. After the cooldown, the cohort is enabled again.
+//
+// The cohort is taken from the TrafficSegment predicate, or, when it has no
+// label, the route id is used. The cohorts are tracked separately for each
+// route. Requests without a traffic segment are not tracked. The error rate
+// is evaluated only after at least 10 requests in the window. The
+// PostProcessor of the spec needs to be registered in the routing, to drop
+// the state of the deleted routes.
+//
+// Example:
+//
+//	canary: Path("/") && TrafficSegment(0, 0.1, "canary") && HeaderAbsent("X-Auto-Kill")
+//	  -> autoKill(0.05, "1m", "5m")
+//	  -> "https://canary.example.org";
+//	killed: Path("/") && Header("X-Auto-Kill", "canary") -> "https://stable.example.org";
+func NewAutoKill() *AutoKillSpec {
+	return &AutoKillSpec{
+		trackers: make(map[autoKillKey]*autoKillTracker),
+		now:      time.Now,
+	}
+}
+
+func (*AutoKillSpec) Name() string { return filters.AutoKillName }
+
+func (s *AutoKillSpec) CreateFilter(args []interface{}) (filters.Filter, error) {
+	if len(args) < 2 || len(args) > 3 {
+		return nil, filters.ErrInvalidFilterParameters
+	}
+
+	threshold, ok := args[0].(float64)
+	if !ok || threshold <= 0 || threshold >= 1 {
+		return nil, filters.ErrInvalidFilterParameters
+	}
+
+	window, err := positiveDurationArg(args[1])
+	if err != nil {
+		return nil, err
+	}
+
+	cooldown := window
+	if len(args) == 3 {
+		if cooldown, err = positiveDurationArg(args[2]); err != nil {
+			return nil, err
+		}
+	}
+
+	return &autoKillFilter{
+		spec:   s,
+		config: autoKillConfig{threshold: threshold, window: window, cooldown: cooldown},
+	}, nil
+}
+
+// positiveDurationArg parses a positive duration argument, either a string
+// like "10s" or a time.Duration.
+func positiveDurationArg(arg interface{}) (time.Duration, error) {
+	var d time.Duration
+	switch v := arg.(type) {
+	case string:
+		var err error
+		if d, err = time.ParseDuration(v); err != nil {
+			return 0, err
+		}
+	case time.Duration:
+		d = v
+	default:
+		return 0, filters.ErrInvalidFilterParameters
+	}
+
+	if d <= 0 {
+		return 0, filters.ErrInvalidFilterParameters
+	}
+
+	return d, nil
+}
+
+// tracker returns the error tracker of the cohort of the route. When the
+// configuration of the route has changed, the tracker is reset.
+func (s *AutoKillSpec) tracker(k autoKillKey, c autoKillConfig) *autoKillTracker {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	t, ok := s.trackers[k]
+	if !ok || t.config != c {
+		t = &autoKillTracker{config: c, counts: window.New(c.window, 2)}
+		s.trackers[k] = t
+	}
+
+	return t
+}
+
+// PostProcessor returns the routing.PostProcessor dropping the error
+// trackers of the routes, that were deleted or don't use the autoKill
+// filter anymore.
+func (s *AutoKillSpec) PostProcessor() routing.PostProcessor {
+	return autoKillPostProcessor{spec: s}
+}
+
+func (p autoKillPostProcessor) Do(routes []*routing.Route) []*routing.Route {
+	s := p.spec
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	inUse := make(map[string]struct{})
+	for _, r := range routes {
+		for _, f := range r.Filters {
+			if _, ok := f.Filter.(*autoKillFilter); ok {
+				inUse[r.Id] = struct{}{}
+			}
+		}
+	}
+
+	for k := range s.trackers {
+		if _, ok := inUse[k.routeId]; !ok {
+			delete(s.trackers, k)
+		}
+	}
+
+	return routes
+}
+
+// allow tells whether the cohort is enabled, and enables it again once the
+// cooldown has passed.
+func (t *autoKillTracker) allow(now time.Time) (allowed, reenabled bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.killedUntil.IsZero() {
+		return true, false
+	}
+
+	if now.Before(t.killedUntil) {
+		return false, false
+	}
+
+	t.killedUntil = time.Time{}
+	t.counts.Reset()
+	return true, true
+}
+
+// record counts a response, and tells whether it made the cohort gated, and
+// the error rate within the window.
+func (t *autoKillTracker) record(now time.Time, failed bool) (killed bool, rate float64) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if !t.killedUntil.IsZero() {
+		// a request proxied before the cohort was gated
+		return false, 0
+	}
+
+	t.counts.Inc(now, autoKillRequests)
+	if failed {
+		t.counts.Inc(now, autoKillErrors)
+	}
+
+	sum := t.counts.Sum(now)
+	if sum[autoKillRequests] < autoKillMinRequests {
+		return false, 0
+	}
+
+	rate = float64(sum[autoKillErrors]) / float64(sum[autoKillRequests])
+	if rate <= t.config.threshold {
+		return false, rate
+	}
+
+	t.killedUntil = now.Add(t.config.cooldown)
+	return true, rate
+}
+
+// segmentCohort returns the cohort of the traffic segment of the request,
+// or the route id, when the segment has no cohort.
+func segmentCohort(ctx filters.FilterContext) (string, bool) {
+	segment, ok := ctx.StateBag()[filters.TrafficSegmentKey].(routing.TrafficSegment)
+	if !ok {
+		return "", false
+	}
+
+	if segment.Cohort != "" {
+		return segment.Cohort, true
+	}
+
+	return segment.RouteId, true
+}
+
+func (f *autoKillFilter) Request(ctx filters.FilterContext) {
+	cohort, ok := segmentCohort(ctx)
+	if !ok {
+		return
+	}
+
+	segment := ctx.StateBag()[filters.TrafficSegmentKey].(routing.TrafficSegment)
+	t := f.spec.tracker(autoKillKey{routeId: segment.RouteId, cohort: cohort}, f.config)
+	allowed, reenabled := t.allow(f.spec.now())
+	if reenabled {
+		ctx.Logger().Infof("%s: cohort %s enabled after cooldown", filters.AutoKillName, cohort)
+	}
+
+	if allowed {
+		ctx.StateBag()[autoKillStateKey] = t
+		return
+	}
+
+	ctx.Request().Header.Set(autoKillHeader, cohort)
+	ctx.StateBag()[filters.LoopbackKey] = true
+}
+
+func (f *autoKillFilter) Response(ctx filters.FilterContext) {
+	t, ok := ctx.StateBag()[autoKillStateKey].(*autoKillTracker)
+	if !ok {
+		return
+	}
+
+	delete(ctx.StateBag(), autoKillStateKey)
+	failed := ctx.Response().StatusCode >= http.StatusInternalServerError
+	if killed, rate := t.record(f.spec.now(), failed); killed {
+		cohort, _ := segmentCohort(ctx)
+		ctx.Logger().Warnf(
+			"%s: cohort %s gated for %v, error rate %.3f above %.3f",
+			filters.AutoKillName,
+			cohort,
+			f.config.cooldown,
+			rate,
+			f.config.threshold,
+		)
+	}
+}
+
+// HandleErrorResponse is to opt-in for filters to get called
+// Response(ctx) in case of errors via proxy. It has to return true to opt-in.
+func (*autoKillFilter) HandleErrorResponse() bool { return true }
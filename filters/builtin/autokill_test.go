@@ -0,0 +1,232 @@
+package builtin
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/zalando/skipper/eskip"
+	"github.com/zalando/skipper/filters"
+	"github.com/zalando/skipper/filters/filtertest"
+	"github.com/zalando/skipper/routing"
+)
+
+func TestAutoKillCreate(t *testing.T) {
+	for _, tt := range []struct {
+		msg  string
+		args []interface{}
+		err  bool
+	}{{
+		msg: "no arguments",
+		err: true,
+	}, {
+		msg:  "missing window",
+		args: []interface{}{0.1},
+		err:  true,
+	}, {
+		msg:  "too many arguments",
+		args: []interface{}{0.1, "1m", "1m", "1m"},
+		err:  true,
+	}, {
+		msg:  "invalid threshold",
+		args: []interface{}{"0.1", "1m"},
+		err:  true,
+	}, {
+		msg:  "threshold out of range",
+		args: []interface{}{1.5, "1m"},
+		err:  true,
+	}, {
+		msg:  "zero threshold",
+		args: []interface{}{0.0, "1m"},
+		err:  true,
+	}, {
+		msg:  "invalid window",
+		args: []interface{}{0.1, "foo"},
+		err:  true,
+	}, {
+		msg:  "zero window",
+		args: []interface{}{0.1, "0s"},
+		err:  true,
+	}, {
+		msg:  "invalid cooldown",
+		args: []interface{}{0.1, "1m", 5.0},
+		err:  true,
+	}, {
+		msg:  "window",
+		args: []interface{}{0.1, "1m"},
+	}, {
+		msg:  "window and cooldown",
+		args: []interface{}{0.1, "1m", "5m"},
+	}} {
+		t.Run(tt.msg, func(t *testing.T) {
+			_, err := NewAutoKill().CreateFilter(tt.args)
+			if tt.err && err == nil {
+				t.Error("expected error")
+			} else if !tt.err && err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+		})
+	}
+}
+
+func TestAutoKill(t *testing.T) {
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	spec := NewAutoKill()
+	spec.now = func() time.Time { return now }
+
+	f, err := spec.CreateFilter([]interface{}{0.2, "10s", "1m"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// serve proxies a request of the cohort, and tells whether it was gated
+	serve := func(cohort string, status int) bool {
+		req, err := http.NewRequest("GET", "https://www.example.org", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		ctx := &filtertest.Context{
+			FRequest:  req,
+			FStateBag: map[string]interface{}{filters.TrafficSegmentKey: routing.TrafficSegment{Cohort: cohort}},
+		}
+
+		f.Request(ctx)
+		if ctx.FStateBag[filters.LoopbackKey] == true {
+			if h := req.Header.Get("X-Auto-Kill"); h != cohort {
+				t.Errorf("expected X-Auto-Kill: %q, got: %q", cohort, h)
+			}
+
+			return true
+		}
+
+		ctx.FResponse = &http.Response{StatusCode: status, Header: make(http.Header)}
+		f.Response(ctx)
+		return false
+	}
+
+	for i := 0; i < 9; i++ {
+		if serve("other", http.StatusInternalServerError) {
+			t.Fatal("gated before the minimum number of requests")
+		}
+	}
+
+	for i := 0; i < 36; i++ {
+		serve("canary", http.StatusOK)
+	}
+
+	for i := 0; i < 9; i++ {
+		serve("canary", http.StatusInternalServerError)
+	}
+
+	if serve("canary", http.StatusOK) {
+		t.Fatal("gated below the threshold")
+	}
+
+	// the early errors leave the window
+	now = now.Add(12 * time.Second)
+	for i := 0; i < 8; i++ {
+		serve("canary", http.StatusOK)
+	}
+
+	serve("canary", http.StatusBadGateway)
+	serve("canary", http.StatusServiceUnavailable)
+	serve("canary", http.StatusGatewayTimeout)
+	if !serve("canary", http.StatusOK) {
+		t.Fatal("not gated above the threshold")
+	}
+
+	if serve("stable", http.StatusOK) {
+		t.Fatal("other cohort gated")
+	}
+
+	now = now.Add(30 * time.Second)
+	if !serve("canary", http.StatusOK) {
+		t.Fatal("enabled before the cooldown")
+	}
+
+	now = now.Add(31 * time.Second)
+	if serve("canary", http.StatusOK) {
+		t.Fatal("not enabled after the cooldown")
+	}
+}
+
+func TestAutoKillWithoutSegment(t *testing.T) {
+	f, err := NewAutoKill().CreateFilter([]interface{}{0.1, "1m"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	req, err := http.NewRequest("GET", "https://www.example.org", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := &filtertest.Context{FRequest: req, FStateBag: make(map[string]interface{})}
+	f.Request(ctx)
+	if len(ctx.FStateBag) != 0 {
+		t.Errorf("unexpected state: %v", ctx.FStateBag)
+	}
+}
+
+func TestAutoKillPerRoute(t *testing.T) {
+	spec := NewAutoKill()
+	f, err := spec.CreateFilter([]interface{}{0.2, "10s", "1m"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// serve proxies a request of the canary cohort of the route, and tells
+	// whether it was gated
+	serve := func(routeId string, status int) bool {
+		req, err := http.NewRequest("GET", "https://www.example.org", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		ctx := &filtertest.Context{
+			FRequest: req,
+			FStateBag: map[string]interface{}{
+				filters.TrafficSegmentKey: routing.TrafficSegment{RouteId: routeId, Cohort: "canary"},
+			},
+		}
+
+		f.Request(ctx)
+		if ctx.FStateBag[filters.LoopbackKey] == true {
+			return true
+		}
+
+		ctx.FResponse = &http.Response{StatusCode: status, Header: make(http.Header)}
+		f.Response(ctx)
+		return false
+	}
+
+	for i := 0; i < autoKillMinRequests; i++ {
+		serve("checkout", http.StatusInternalServerError)
+	}
+
+	if !serve("checkout", http.StatusOK) {
+		t.Fatal("not gated above the threshold")
+	}
+
+	if serve("catalog", http.StatusOK) {
+		t.Fatal("the same cohort of another route gated")
+	}
+
+	spec.PostProcessor().Do([]*routing.Route{
+		{Route: eskip.Route{Id: "checkout"}, Filters: []*routing.RouteFilter{{Filter: f, Name: filters.AutoKillName}}},
+	})
+
+	if len(spec.trackers) != 1 {
+		t.Errorf("expected only the tracker of the remaining route, got: %v", spec.trackers)
+	}
+
+	if !serve("checkout", http.StatusOK) {
+		t.Error("the remaining route lost its state")
+	}
+
+	spec.PostProcessor().Do(nil)
+	if len(spec.trackers) != 0 {
+		t.Errorf("unexpected trackers without routes: %v", spec.trackers)
+	}
+}
@@ -0,0 +1,112 @@
+package builtin
+
+import (
+	"context"
+	"io"
+	"time"
+
+	"golang.org/x/time/rate"
+
+	"github.com/zalando/skipper/filters"
+	"github.com/zalando/skipper/net"
+)
+
+type bandwidthLimitSpec struct{}
+
+type bandwidthLimitFilter struct {
+	bytesPerSecond int64
+}
+
+type bandwidthLimitedBody struct {
+	ctx     context.Context
+	body    io.ReadCloser
+	limiter *rate.Limiter
+}
+
+// NewBandwidthLimit creates a filter specification whose instances limit
+// the rate of streaming the response body to the client, to at most the
+// given number of bytes per second, per request. The limit is either a
+// number of bytes, or a string with one of the units B, KB, MB or GB,
+// based on 1024, e.g. "1MB".
+//
+// The rate is enforced with a token bucket, that allows bursts of a
+// tenth of a second. Waiting for the bucket is canceled, when the request
+// context is canceled, e.g. when the client goes away.
+//
+// Example:
+//
+//	downloads: PathSubtree("/downloads") -> bandwidthLimit("1MB") -> "https://files.example.org";
+func NewBandwidthLimit() filters.Spec { return bandwidthLimitSpec{} }
+
+func (bandwidthLimitSpec) Name() string { return filters.BandwidthLimitName }
+
+func (bandwidthLimitSpec) CreateFilter(args []interface{}) (filters.Filter, error) {
+	if len(args) != 1 {
+		return nil, filters.ErrInvalidFilterParameters
+	}
+
+	var limit int64
+	switch v := args[0].(type) {
+	case int:
+		limit = int64(v)
+	case float64:
+		limit = int64(v)
+	case string:
+		var err error
+		if limit, err = net.ParseByteSize(v); err != nil {
+			return nil, filters.ErrInvalidFilterParameters
+		}
+	default:
+		return nil, filters.ErrInvalidFilterParameters
+	}
+
+	if limit <= 0 {
+		return nil, filters.ErrInvalidFilterParameters
+	}
+
+	return &bandwidthLimitFilter{bytesPerSecond: limit}, nil
+}
+
+func newBandwidthLimitedBody(ctx context.Context, body io.ReadCloser, bytesPerSecond int64) *bandwidthLimitedBody {
+	burst := int(bytesPerSecond / 10)
+	if burst < 1 {
+		burst = 1
+	}
+
+	l := rate.NewLimiter(rate.Limit(bytesPerSecond), burst)
+
+	// start with an empty bucket, to not exceed the rate with the first read
+	l.AllowN(time.Now(), burst)
+
+	return &bandwidthLimitedBody{ctx: ctx, body: body, limiter: l}
+}
+
+func (b *bandwidthLimitedBody) Read(p []byte) (int, error) {
+	if burst := b.limiter.Burst(); len(p) > burst {
+		p = p[:burst]
+	}
+
+	n, err := b.body.Read(p)
+	if n > 0 {
+		if werr := b.limiter.WaitN(b.ctx, n); werr != nil {
+			return 0, werr
+		}
+	}
+
+	return n, err
+}
+
+func (b *bandwidthLimitedBody) Close() error {
+	return b.body.Close()
+}
+
+func (f *bandwidthLimitFilter) Request(filters.FilterContext) {}
+
+func (f *bandwidthLimitFilter) Response(ctx filters.FilterContext) {
+	rsp := ctx.Response()
+	if rsp.Body == nil {
+		return
+	}
+
+	rsp.Body = newBandwidthLimitedBody(ctx.Request().Context(), rsp.Body, f.bytesPerSecond)
+}
@@ -0,0 +1,123 @@
+package builtin
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/zalando/skipper/eskip"
+	"github.com/zalando/skipper/proxy/proxytest"
+)
+
+func TestBandwidthLimitArgs(t *testing.T) {
+	for _, tt := range []struct {
+		msg      string
+		args     []interface{}
+		expected int64
+		fail     bool
+	}{{
+		msg:  "no args",
+		fail: true,
+	}, {
+		msg:  "too many args",
+		args: []interface{}{"1MB", "2MB"},
+		fail: true,
+	}, {
+		msg:  "invalid type",
+		args: []interface{}{true},
+		fail: true,
+	}, {
+		msg:  "invalid size",
+		args: []interface{}{"1 MiB"},
+		fail: true,
+	}, {
+		msg:  "zero",
+		args: []interface{}{0.0},
+		fail: true,
+	}, {
+		msg:      "bytes per second as number",
+		args:     []interface{}{1024.0},
+		expected: 1024,
+	}, {
+		msg:      "megabytes per second",
+		args:     []interface{}{"1MB"},
+		expected: 1 << 20,
+	}} {
+		t.Run(tt.msg, func(t *testing.T) {
+			f, err := NewBandwidthLimit().CreateFilter(tt.args)
+			if tt.fail {
+				if err == nil {
+					t.Error("failed to fail")
+				}
+
+				return
+			}
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if l := f.(*bandwidthLimitFilter).bytesPerSecond; l != tt.expected {
+				t.Errorf("expected limit: %d, got: %d", tt.expected, l)
+			}
+		})
+	}
+}
+
+func TestBandwidthLimitRate(t *testing.T) {
+	const (
+		limit = 256 << 10
+		size  = 128 << 10
+	)
+
+	backend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write(bytes.Repeat([]byte("a"), size))
+	}))
+	defer backend.Close()
+
+	p := proxytest.New(MakeRegistry(), eskip.MustParse(`* -> bandwidthLimit("256KB") -> "`+backend.URL+`"`)...)
+	defer p.Close()
+
+	start := time.Now()
+	rsp, err := http.Get(p.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer rsp.Body.Close()
+
+	n, err := io.Copy(io.Discard, rsp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	elapsed := time.Since(start)
+	if n != size {
+		t.Fatalf("expected %d bytes, got: %d", size, n)
+	}
+
+	// the expected duration is 500ms
+	r := float64(n) / elapsed.Seconds()
+	if r > 1.1*limit || r < 0.5*limit {
+		t.Errorf("delivered rate is not near the limit: %.0f bytes/s, limit: %d bytes/s", r, limit)
+	}
+}
+
+func TestBandwidthLimitCanceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	body := newBandwidthLimitedBody(ctx, io.NopCloser(bytes.NewReader(bytes.Repeat([]byte("a"), 1000))), 100)
+
+	b := make([]byte, 10)
+	if _, err := body.Read(b); err != nil {
+		t.Fatal(err)
+	}
+
+	cancel()
+	if _, err := body.Read(b); err == nil {
+		t.Error("expected error after canceling the context")
+	}
+}
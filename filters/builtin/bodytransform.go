@@ -0,0 +1,115 @@
+package builtin
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"strconv"
+
+	"github.com/zalando/skipper/net"
+)
+
+// the default maximum size of the bodies edited by the body
+// transformations, like jsonKeyCase or xmlToJSON
+const defaultBodyTransformMaxBytes = 2 << 20
+
+// transformBody reads the body, when it is not larger than maxBytes, and
+// returns the transformed content. When the body is too large, or it cannot
+// be read or transformed, it returns false, and the body to be used instead
+// of the original one, with the content read so far.
+func transformBody(body io.ReadCloser, maxBytes int64, transform func([]byte) ([]byte, error)) ([]byte, io.ReadCloser, bool) {
+	b, original, ok := net.BufferBody(body, maxBytes)
+	if !ok {
+		return nil, original, false
+	}
+
+	t, err := transform(b)
+	if err != nil {
+		return nil, io.NopCloser(bytes.NewReader(b)), false
+	}
+
+	return t, nil, true
+}
+
+func isIdentityEncoded(h http.Header) bool {
+	ce := h.Get("Content-Encoding")
+	return ce == "" || ce == "identity"
+}
+
+// transformRequestBody replaces the request body with its transformed
+// content.
+func transformRequestBody(r *http.Request, maxBytes int64, transform func([]byte) ([]byte, error)) bool {
+	if r.Body == nil || r.Body == http.NoBody || r.ContentLength > maxBytes || !isIdentityEncoded(r.Header) {
+		return false
+	}
+
+	b, original, ok := transformBody(r.Body, maxBytes, transform)
+	if !ok {
+		r.Body = original
+		return false
+	}
+
+	r.Body = io.NopCloser(bytes.NewReader(b))
+	r.ContentLength = int64(len(b))
+	r.TransferEncoding = nil
+	r.Header.Del("Transfer-Encoding")
+	r.Header.Set("Content-Length", strconv.Itoa(len(b)))
+	return true
+}
+
+// transformResponseBody replaces the response body with its transformed
+// content.
+func transformResponseBody(rsp *http.Response, maxBytes int64, transform func([]byte) ([]byte, error)) bool {
+	if rsp.Body == nil || rsp.ContentLength > maxBytes || !isIdentityEncoded(rsp.Header) {
+		return false
+	}
+
+	b, original, ok := transformBody(rsp.Body, maxBytes, transform)
+	if !ok {
+		rsp.Body = original
+		return false
+	}
+
+	rsp.Body = io.NopCloser(bytes.NewReader(b))
+
+	// the trailers can be only sent with the chunked encoding
+	if len(rsp.Trailer) > 0 || rsp.Header.Get("Trailer") != "" {
+		rsp.ContentLength = -1
+		rsp.Header.Del("Content-Length")
+		return true
+	}
+
+	rsp.ContentLength = int64(len(b))
+	rsp.TransferEncoding = nil
+	rsp.Header.Del("Transfer-Encoding")
+	rsp.Header.Set("Content-Length", strconv.Itoa(len(b)))
+	return true
+}
+
+// bodyTransformMaxBytesArg parses the optional maximum body size argument of
+// the body transformations.
+func bodyTransformMaxBytesArg(args []interface{}) (int64, bool) {
+	if len(args) == 0 {
+		return defaultBodyTransformMaxBytes, true
+	}
+
+	var (
+		maxBytes int64
+		err      error
+	)
+
+	switch v := args[0].(type) {
+	case int:
+		maxBytes = int64(v)
+	case float64:
+		maxBytes = int64(v)
+	case string:
+		if maxBytes, err = net.ParseByteSize(v); err != nil {
+			return 0, false
+		}
+	default:
+		return 0, false
+	}
+
+	return maxBytes, maxBytes > 0
+}
@@ -0,0 +1,93 @@
+package builtin
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"strconv"
+
+	"github.com/zalando/skipper/filters"
+	"github.com/zalando/skipper/net"
+)
+
+type bufferForCompareSpec struct{}
+
+type bufferForCompareFilter struct {
+	maxBytes int64
+}
+
+// NewBufferForCompare creates a filter specification whose instances
+// buffer the response body up to the given size, and, when the complete
+// body fits, pass it in the state bag to the following filters, e.g. to
+// compare or validate the responses of a canary. The buffered body is
+// stored under the filters.BufferedResponseBodyKey as []byte, and it is
+// sent to the client unchanged. When the body exceeds the size, it is
+// streamed to the client, and it is not available for the following
+// filters.
+//
+// The response filters are executed in the reverse order, therefore the
+// filters using the buffered body need to precede bufferForCompare in the
+// route.
+//
+// The size is either a number of bytes, or a string with one of the
+// units B, KB, MB or GB, based on 1024, e.g. "64KB".
+//
+// Example:
+//
+//	canary: Traffic(.05) -> bufferForCompare("64KB") -> "https://canary.example.org";
+func NewBufferForCompare() filters.Spec { return bufferForCompareSpec{} }
+
+func (bufferForCompareSpec) Name() string { return filters.BufferForCompareName }
+
+func (bufferForCompareSpec) CreateFilter(args []interface{}) (filters.Filter, error) {
+	if len(args) != 1 {
+		return nil, filters.ErrInvalidFilterParameters
+	}
+
+	var maxBytes int64
+	switch v := args[0].(type) {
+	case int:
+		maxBytes = int64(v)
+	case float64:
+		maxBytes = int64(v)
+	case string:
+		var err error
+		if maxBytes, err = net.ParseByteSize(v); err != nil {
+			return nil, filters.ErrInvalidFilterParameters
+		}
+	default:
+		return nil, filters.ErrInvalidFilterParameters
+	}
+
+	if maxBytes <= 0 {
+		return nil, filters.ErrInvalidFilterParameters
+	}
+
+	return &bufferForCompareFilter{maxBytes: maxBytes}, nil
+}
+
+func (f *bufferForCompareFilter) Request(filters.FilterContext) {}
+
+func (f *bufferForCompareFilter) Response(ctx filters.FilterContext) {
+	rsp := ctx.Response()
+	if rsp.Body == nil || rsp.ContentLength > f.maxBytes || ctx.Request().Method == http.MethodHead {
+		return
+	}
+
+	body, original, ok := net.BufferBody(rsp.Body, f.maxBytes)
+	if !ok {
+		rsp.Body = original
+		return
+	}
+
+	rsp.Body = io.NopCloser(bytes.NewReader(body))
+	ctx.StateBag()[filters.BufferedResponseBodyKey] = body
+
+	// the trailers can be only sent with the chunked encoding
+	if rsp.ContentLength < 0 && len(rsp.Trailer) == 0 && rsp.Header.Get("Trailer") == "" {
+		rsp.ContentLength = int64(len(body))
+		rsp.TransferEncoding = nil
+		rsp.Header.Del("Transfer-Encoding")
+		rsp.Header.Set("Content-Length", strconv.Itoa(len(body)))
+	}
+}
@@ -0,0 +1,155 @@
+package builtin
+
+import (
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/zalando/skipper/filters"
+	"github.com/zalando/skipper/filters/filtertest"
+)
+
+func TestBufferForCompareArgs(t *testing.T) {
+	for _, tt := range []struct {
+		msg      string
+		args     []interface{}
+		expected int64
+		fail     bool
+	}{{
+		msg:  "no args",
+		fail: true,
+	}, {
+		msg:  "too many args",
+		args: []interface{}{"16KB", "32KB"},
+		fail: true,
+	}, {
+		msg:  "invalid size",
+		args: []interface{}{"16XB"},
+		fail: true,
+	}, {
+		msg:  "zero",
+		args: []interface{}{0.0},
+		fail: true,
+	}, {
+		msg:      "bytes as number",
+		args:     []interface{}{512.0},
+		expected: 512,
+	}, {
+		msg:      "kilobytes",
+		args:     []interface{}{"64KB"},
+		expected: 64 << 10,
+	}} {
+		t.Run(tt.msg, func(t *testing.T) {
+			f, err := NewBufferForCompare().CreateFilter(tt.args)
+			if tt.fail {
+				if err == nil {
+					t.Error("failed to fail")
+				}
+
+				return
+			}
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if m := f.(*bufferForCompareFilter).maxBytes; m != tt.expected {
+				t.Errorf("expected max bytes: %d, got: %d", tt.expected, m)
+			}
+		})
+	}
+}
+
+func TestBufferForCompare(t *testing.T) {
+	for _, tt := range []struct {
+		msg            string
+		body           string
+		contentLength  int64
+		method         string
+		expectBuffered bool
+	}{{
+		msg:            "small streamed response",
+		body:           strings.Repeat("a", 1000),
+		contentLength:  -1,
+		expectBuffered: true,
+	}, {
+		msg:            "at the limit",
+		body:           strings.Repeat("a", 1024),
+		contentLength:  1024,
+		expectBuffered: true,
+	}, {
+		msg:           "large streamed response",
+		body:          strings.Repeat("a", 1025),
+		contentLength: -1,
+	}, {
+		msg:           "large content length",
+		body:          strings.Repeat("a", 4096),
+		contentLength: 4096,
+	}, {
+		msg:           "head request",
+		body:          "",
+		contentLength: -1,
+		method:        "HEAD",
+	}} {
+		t.Run(tt.msg, func(t *testing.T) {
+			f, err := NewBufferForCompare().CreateFilter([]interface{}{"1KB"})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			method := tt.method
+			if method == "" {
+				method = "GET"
+			}
+
+			req, err := http.NewRequest(method, "https://www.example.org", nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			ctx := &filtertest.Context{
+				FRequest:  req,
+				FStateBag: make(map[string]interface{}),
+				FResponse: &http.Response{
+					StatusCode:    http.StatusOK,
+					Header:        make(http.Header),
+					Body:          io.NopCloser(strings.NewReader(tt.body)),
+					ContentLength: tt.contentLength,
+				},
+			}
+
+			f.Response(ctx)
+
+			b, err := io.ReadAll(ctx.FResponse.Body)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if string(b) != tt.body {
+				t.Errorf("invalid body of length %d, expected length: %d", len(b), len(tt.body))
+			}
+
+			buffered, ok := ctx.FStateBag[filters.BufferedResponseBodyKey].([]byte)
+			if ok != tt.expectBuffered {
+				t.Fatalf("expected buffered: %v, got: %v", tt.expectBuffered, ok)
+			}
+
+			if !tt.expectBuffered {
+				if ctx.FResponse.ContentLength != tt.contentLength {
+					t.Errorf("expected unchanged content length: %d, got: %d", tt.contentLength, ctx.FResponse.ContentLength)
+				}
+
+				return
+			}
+
+			if string(buffered) != tt.body {
+				t.Errorf("invalid buffered body of length %d, expected length: %d", len(buffered), len(tt.body))
+			}
+
+			if ctx.FResponse.ContentLength != int64(len(tt.body)) {
+				t.Errorf("expected content length: %d, got: %d", len(tt.body), ctx.FResponse.ContentLength)
+			}
+		})
+	}
+}
@@ -136,14 +136,18 @@ func Filters() []filters.Spec {
 		NewModRequestHeader(),
 		NewModResponseHeader(),
 		NewDropQuery(),
+		NewNormalizeQuery(),
 		NewSetQuery(),
 		NewHealthCheck(),
 		NewStatic(),
 		NewRedirect(),
 		NewRedirectTo(),
 		NewRedirectLower(),
+		NewCanonicalPathRedirect(),
 		NewStripQuery(),
 		NewInlineContent(),
+		NewMaintenanceWindow(),
+		NewCohortHeaders(),
 		NewInlineContentIfStatus(),
 		flowid.New(),
 		xforward.New(),
@@ -153,11 +157,38 @@ func Filters() []filters.Spec {
 		NewStatus(),
 		NewCompress(),
 		NewDecompress(),
+		NewCohortEncoding(),
 		NewHeaderToQuery(),
 		NewQueryToHeader(),
+		NewMaxURLLength(),
+		NewMaxQueryParams(),
+		NewRetryAfterOnStatus(),
+		NewRewriteAuthChallenge(),
+		NewRequireResponseHeaders(),
+		NewCanaryCorrelationId(),
+		NewNormalizeAcceptLanguage(),
+		NewIdempotencyGuard(),
+		NewWebhookDedup(),
+		NewSendProxyProtocol(),
+		NewBufferForCompare(),
+		NewTagCacheability(),
+		NewRewriteJSONLinks(),
+		NewReorderStream(),
+		NewJSONKeyCase(),
+		NewXMLToJSON(),
+		NewDechunkSmallResponses(),
+		NewBandwidthLimit(),
+		NewLatencyCompare(),
+		NewSplitSkewMetric(),
+		NewMergeRequestHeaders(),
+		NewMergeResponseHeaders(),
+		NewLocalizeDateHeaders(),
 		NewBackendTimeout(),
 		NewReadTimeout(),
 		NewWriteTimeout(),
+		NewCohortTimeout(),
+		NewMaxRequestsPerConnection(),
+		NewHandle100Continue(),
 		NewSetDynamicBackendHostFromHeader(),
 		NewSetDynamicBackendSchemeFromHeader(),
 		NewSetDynamicBackendUrlFromHeader(),
@@ -165,6 +196,7 @@ func Filters() []filters.Spec {
 		NewSetDynamicBackendScheme(),
 		NewSetDynamicBackendUrl(),
 		NewOriginMarkerSpec(),
+		NewStampRouteVersion(),
 		diag.NewRandom(),
 		diag.NewRepeat(),
 		diag.NewRepeatHex(),
@@ -187,6 +219,7 @@ func Filters() []filters.Spec {
 		tee.NewTeeDeprecated(),
 		tee.NewTeeNoFollow(),
 		tee.NewTeeLoopback(),
+		tee.NewTeeCoalesce(),
 		sed.New(),
 		sed.NewDelimited(),
 		sed.NewRequest(),
@@ -205,6 +238,9 @@ func Filters() []filters.Spec {
 		tracing.NewBaggageToTagFilter(),
 		tracing.NewTag(),
 		tracing.NewStateBagToTag(),
+		tracing.NewCohortBaggage(),
+		tracing.NewCohortEvent(),
+		tracing.NewCohortTagSampled(),
 		//lint:ignore SA1019 due to backward compatibility
 		accesslog.NewAccessLogDisabled(),
 		accesslog.NewDisableAccessLog(),
@@ -214,6 +250,7 @@ func Filters() []filters.Spec {
 		scheduler.NewFifo(),
 		scheduler.NewLIFO(),
 		scheduler.NewLIFOGroup(),
+		scheduler.NewQueueConcurrency(),
 		rfc.NewPath(),
 		rfc.NewHost(),
 		fadein.NewFadeIn(),
@@ -231,5 +268,6 @@ func MakeRegistry() filters.Registry {
 	for _, s := range Filters() {
 		r.Register(s)
 	}
+	r.Register(NewTransformByContentType(r))
 	return r
 }
@@ -0,0 +1,77 @@
+package builtin
+
+import (
+	"fmt"
+
+	"golang.org/x/net/http/httpguts"
+
+	"github.com/zalando/skipper/filters"
+	"github.com/zalando/skipper/filters/flowid"
+	"github.com/zalando/skipper/routing"
+)
+
+type canaryCorrelationIdSpec struct {
+	generator flowid.Generator
+}
+
+type canaryCorrelationIdFilter struct {
+	header    string
+	generator flowid.Generator
+}
+
+// NewCanaryCorrelationId creates a filter specification whose instances
+// stamp the requests of a traffic cohort with a correlation id in the given
+// request header.
+//
+// The id is stored with the logical request, and the loopback and the tee
+// loopback requests derived from it get the same id, so that the logs of
+// the primary and the shadow requests can be joined. The requests without
+// a cohort get the header only when they were derived from a request that
+// already has an id.
+//
+// Example:
+//
+//	canary: Traffic(.1, "canary") -> canaryCorrelationId("X-Canary-Correlation-Id") -> teeLoopback("shadow") -> "https://canary.example.org";
+//	shadow: Tee("shadow") -> canaryCorrelationId("X-Canary-Correlation-Id") -> "https://shadow.example.org";
+func NewCanaryCorrelationId() filters.Spec {
+	return canaryCorrelationIdSpec{generator: flowid.NewULIDGenerator()}
+}
+
+func (canaryCorrelationIdSpec) Name() string { return filters.CanaryCorrelationIdName }
+
+func (s canaryCorrelationIdSpec) CreateFilter(args []interface{}) (filters.Filter, error) {
+	if len(args) != 1 {
+		return nil, filters.ErrInvalidFilterParameters
+	}
+
+	header, ok := args[0].(string)
+	if !ok {
+		return nil, filters.ErrInvalidFilterParameters
+	}
+
+	if !httpguts.ValidHeaderFieldName(header) {
+		return nil, fmt.Errorf("header name %s is invalid", header)
+	}
+
+	return &canaryCorrelationIdFilter{header: header, generator: s.generator}, nil
+}
+
+func newCorrelationId() *filters.CorrelationId { return &filters.CorrelationId{} }
+
+func (f *canaryCorrelationIdFilter) Request(ctx filters.FilterContext) {
+	req := ctx.Request()
+	cid := routing.FromContext(req.Context(), filters.CorrelationIdKey, newCorrelationId)
+
+	var id string
+	if segment, ok := ctx.StateBag()[filters.TrafficSegmentKey].(routing.TrafficSegment); ok && segment.Cohort != "" {
+		id = cid.Ensure(f.generator.MustGenerate)
+	} else {
+		id = cid.Get()
+	}
+
+	if id != "" {
+		req.Header.Set(f.header, id)
+	}
+}
+
+func (*canaryCorrelationIdFilter) Response(filters.FilterContext) {}
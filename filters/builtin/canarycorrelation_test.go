@@ -0,0 +1,167 @@
+package builtin
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/zalando/skipper/eskip"
+	"github.com/zalando/skipper/filters"
+	"github.com/zalando/skipper/filters/filtertest"
+	teepredicate "github.com/zalando/skipper/predicates/tee"
+	"github.com/zalando/skipper/predicates/traffic"
+	"github.com/zalando/skipper/proxy/proxytest"
+	"github.com/zalando/skipper/routing"
+)
+
+func TestCanaryCorrelationIdCreate(t *testing.T) {
+	for _, tt := range []struct {
+		msg  string
+		args []interface{}
+		err  bool
+	}{{
+		msg: "no arguments",
+		err: true,
+	}, {
+		msg:  "too many arguments",
+		args: []interface{}{"X-Correlation-Id", "X-Other"},
+		err:  true,
+	}, {
+		msg:  "not a string",
+		args: []interface{}{42.0},
+		err:  true,
+	}, {
+		msg:  "invalid header name",
+		args: []interface{}{"X Correlation Id"},
+		err:  true,
+	}, {
+		msg:  "valid header name",
+		args: []interface{}{"X-Correlation-Id"},
+	}} {
+		t.Run(tt.msg, func(t *testing.T) {
+			_, err := NewCanaryCorrelationId().CreateFilter(tt.args)
+			if tt.err && err == nil {
+				t.Error("expected error")
+			} else if !tt.err && err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+		})
+	}
+}
+
+func TestCanaryCorrelationId(t *testing.T) {
+	f, err := NewCanaryCorrelationId().CreateFilter([]interface{}{"X-Correlation-Id"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	newRequest := func() *http.Request {
+		req, err := http.NewRequest("GET", "https://www.example.org", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		return req.WithContext(routing.NewContext(req.Context()))
+	}
+
+	apply := func(req *http.Request, cohort string) string {
+		ctx := &filtertest.Context{
+			FRequest: req,
+			FStateBag: map[string]interface{}{
+				filters.TrafficSegmentKey: routing.TrafficSegment{RouteId: "r", Cohort: cohort},
+			},
+		}
+
+		f.Request(ctx)
+		return req.Header.Get("X-Correlation-Id")
+	}
+
+	t.Run("stable traffic", func(t *testing.T) {
+		if id := apply(newRequest(), ""); id != "" {
+			t.Errorf("unexpected correlation id: %s", id)
+		}
+	})
+
+	t.Run("cohort traffic", func(t *testing.T) {
+		first, second := apply(newRequest(), "canary"), apply(newRequest(), "canary")
+		if first == "" || second == "" || first == second {
+			t.Errorf("expected different correlation ids, got: %s, %s", first, second)
+		}
+	})
+
+	t.Run("loopback", func(t *testing.T) {
+		req := newRequest()
+		id := apply(req, "canary")
+
+		// the loopback request has the same routing context
+		req.Header.Del("X-Correlation-Id")
+		if loopbackId := apply(req, "canary"); loopbackId != id {
+			t.Errorf("expected the same correlation id, got: %s, %s", id, loopbackId)
+		}
+
+		req.Header.Del("X-Correlation-Id")
+		if loopbackId := apply(req, ""); loopbackId != id {
+			t.Errorf("expected the same correlation id without cohort, got: %s, %s", id, loopbackId)
+		}
+	})
+}
+
+func TestCanaryCorrelationIdTeeLoopback(t *testing.T) {
+	const header = "X-Canary-Correlation-Id"
+
+	primaryIds, shadowIds := make(chan string, 1), make(chan string, 1)
+	backend := func(ids chan<- string) *httptest.Server {
+		return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ids <- r.Header.Get(header)
+		}))
+	}
+
+	primary, shadow := backend(primaryIds), backend(shadowIds)
+	defer primary.Close()
+	defer shadow.Close()
+
+	// the tee loopback splits the request before the correlation id is
+	// stamped, so the shadow request can get it only from the shared id
+	routes := eskip.MustParse(fmt.Sprintf(`
+		canary: Path("/foo") && TrafficSegment(0, 1, "canary")
+			-> teeLoopback("A")
+			-> canaryCorrelationId("%[1]s")
+			-> "%[2]s";
+
+		shadow: Path("/foo") && TrafficSegment(0, 1) && Tee("A")
+			-> canaryCorrelationId("%[1]s")
+			-> "%[3]s";
+	`, header, primary.URL, shadow.URL))
+
+	p := proxytest.WithRoutingOptions(MakeRegistry(), routing.Options{
+		Predicates: []routing.PredicateSpec{teepredicate.New(), traffic.NewSegment()},
+	}, routes...)
+	defer p.Close()
+
+	for i := 0; i < 3; i++ {
+		rsp, err := http.Get(p.URL + "/foo")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		rsp.Body.Close()
+
+		var primaryId, shadowId string
+		for _, ids := range []struct {
+			id  *string
+			ids chan string
+		}{{&primaryId, primaryIds}, {&shadowId, shadowIds}} {
+			select {
+			case *ids.id = <-ids.ids:
+			case <-time.After(3 * time.Second):
+				t.Fatal("timeout waiting for the backend request")
+			}
+		}
+
+		if primaryId == "" || primaryId != shadowId {
+			t.Errorf("expected the same correlation id, got: %q, %q", primaryId, shadowId)
+		}
+	}
+}
@@ -0,0 +1,127 @@
+package builtin
+
+import (
+	"fmt"
+	"strconv"
+	"sync"
+	"sync/atomic"
+
+	"github.com/zalando/skipper/filters"
+	"github.com/zalando/skipper/routing"
+	"golang.org/x/net/http/httpguts"
+)
+
+// CanarySeqSpec is the specification of the canarySeq filter.
+type CanarySeqSpec struct {
+	mu        sync.Mutex
+	sequences map[string]*canarySequence
+}
+
+type canarySeqPostProcessor struct {
+	spec *CanarySeqSpec
+}
+
+// canarySequence is the sequence of a route, kept as long as the
+// definition of the route doesn't change.
+type canarySequence struct {
+	definition string
+	seq        *uint64
+}
+
+type canarySeqFilter struct {
+	header string
+	seq    *uint64
+}
+
+// NewCanarySeq creates a filter specification whose instances stamp the
+// cohort requests with a monotonically increasing sequence number in the
+// given request header, so that the arrival order of the requests can be
+// reconstructed from the backend logs. Only the requests matched by a
+// TrafficSegment predicate are stamped.
+//
+// The sequence is maintained per route and header, and it restarts from 1
+// only when the definition of the route changes. This requires the
+// PostProcessor of the spec to be registered in the routing, otherwise the
+// sequence is maintained per filter instance.
+//
+// Example:
+//
+//	canary: TrafficSegment(0.9, 1, "canary") -> canarySeq("X-Canary-Seq") -> "https://canary.example.org";
+func NewCanarySeq() *CanarySeqSpec {
+	return &CanarySeqSpec{sequences: make(map[string]*canarySequence)}
+}
+
+func (*CanarySeqSpec) Name() string { return filters.CanarySeqName }
+
+func (*CanarySeqSpec) CreateFilter(args []interface{}) (filters.Filter, error) {
+	if len(args) != 1 {
+		return nil, filters.ErrInvalidFilterParameters
+	}
+
+	header, ok := args[0].(string)
+	if !ok {
+		return nil, filters.ErrInvalidFilterParameters
+	}
+
+	if !httpguts.ValidHeaderFieldName(header) {
+		return nil, fmt.Errorf("header name %s is invalid", header)
+	}
+
+	return &canarySeqFilter{header: header, seq: new(uint64)}, nil
+}
+
+// PostProcessor returns the routing.PostProcessor passing the sequences on
+// to the filters of the new routing table, unless the definition of their
+// route changed, and dropping the sequences of the deleted routes.
+func (s *CanarySeqSpec) PostProcessor() routing.PostProcessor {
+	return canarySeqPostProcessor{spec: s}
+}
+
+func (p canarySeqPostProcessor) Do(routes []*routing.Route) []*routing.Route {
+	s := p.spec
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	inUse := make(map[string]struct{})
+	for _, r := range routes {
+		var definition string
+		for _, rf := range r.Filters {
+			f, ok := rf.Filter.(*canarySeqFilter)
+			if !ok {
+				continue
+			}
+
+			if definition == "" {
+				definition = r.Route.String()
+			}
+
+			key := r.Id + "/" + f.header
+			if cs, ok := s.sequences[key]; ok && cs.definition == definition {
+				f.seq = cs.seq
+			} else {
+				s.sequences[key] = &canarySequence{definition: definition, seq: f.seq}
+			}
+
+			inUse[key] = struct{}{}
+		}
+	}
+
+	for key := range s.sequences {
+		if _, ok := inUse[key]; !ok {
+			delete(s.sequences, key)
+		}
+	}
+
+	return routes
+}
+
+func (f *canarySeqFilter) Request(ctx filters.FilterContext) {
+	if _, ok := ctx.StateBag()[filters.TrafficSegmentKey]; !ok {
+		return
+	}
+
+	seq := atomic.AddUint64(f.seq, 1)
+	ctx.Request().Header.Set(f.header, strconv.FormatUint(seq, 10))
+}
+
+func (*canarySeqFilter) Response(filters.FilterContext) {}
@@ -0,0 +1,218 @@
+package builtin
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/zalando/skipper/eskip"
+	"github.com/zalando/skipper/filters"
+	"github.com/zalando/skipper/filters/filtertest"
+	"github.com/zalando/skipper/logging/loggingtest"
+	"github.com/zalando/skipper/predicates/traffic"
+	"github.com/zalando/skipper/proxy"
+	"github.com/zalando/skipper/routing"
+	"github.com/zalando/skipper/routing/testdataclient"
+)
+
+func TestCanarySeqCreate(t *testing.T) {
+	for _, tt := range []struct {
+		msg  string
+		args []interface{}
+		err  bool
+	}{{
+		msg: "no arguments",
+		err: true,
+	}, {
+		msg:  "too many arguments",
+		args: []interface{}{"X-Seq", "X-Other"},
+		err:  true,
+	}, {
+		msg:  "not a string",
+		args: []interface{}{42.0},
+		err:  true,
+	}, {
+		msg:  "invalid header name",
+		args: []interface{}{"X Seq"},
+		err:  true,
+	}, {
+		msg:  "valid header name",
+		args: []interface{}{"X-Seq"},
+	}} {
+		t.Run(tt.msg, func(t *testing.T) {
+			_, err := NewCanarySeq().CreateFilter(tt.args)
+			if tt.err && err == nil {
+				t.Error("expected error")
+			} else if !tt.err && err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+		})
+	}
+}
+
+func TestCanarySeq(t *testing.T) {
+	newFilter := func() *canarySeqFilter {
+		f, err := NewCanarySeq().CreateFilter([]interface{}{"X-Seq"})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		return f.(*canarySeqFilter)
+	}
+
+	stamp := func(f *canarySeqFilter) uint64 {
+		req, err := http.NewRequest("GET", "https://www.example.org", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		f.Request(&filtertest.Context{
+			FRequest:  req,
+			FStateBag: map[string]interface{}{filters.TrafficSegmentKey: routing.TrafficSegment{Min: 0.9, Max: 1, Cohort: "canary"}},
+		})
+		seq, err := strconv.ParseUint(req.Header.Get("X-Seq"), 10, 64)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		return seq
+	}
+
+	f := newFilter()
+	for i := uint64(1); i <= 3; i++ {
+		if seq := stamp(f); seq != i {
+			t.Errorf("expected sequence number %d, got: %d", i, seq)
+		}
+	}
+
+	const concurrency = 100
+	var (
+		wg   sync.WaitGroup
+		mu   sync.Mutex
+		seen = make(map[uint64]bool)
+	)
+
+	wg.Add(concurrency)
+	for i := 0; i < concurrency; i++ {
+		go func() {
+			defer wg.Done()
+			seq := stamp(f)
+			mu.Lock()
+			seen[seq] = true
+			mu.Unlock()
+		}()
+	}
+
+	wg.Wait()
+	if len(seen) != concurrency {
+		t.Errorf("expected %d unique sequence numbers, got: %d", concurrency, len(seen))
+	}
+
+	if seq := stamp(newFilter()); seq != 1 {
+		t.Errorf("expected the sequence of a new filter instance to start from 1, got: %d", seq)
+	}
+}
+
+func TestCanarySeqWithoutSegment(t *testing.T) {
+	f, err := NewCanarySeq().CreateFilter([]interface{}{"X-Seq"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	req, err := http.NewRequest("GET", "https://www.example.org", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	f.Request(&filtertest.Context{FRequest: req, FStateBag: make(map[string]interface{})})
+	if h := req.Header.Get("X-Seq"); h != "" {
+		t.Errorf("unexpected sequence number without a traffic segment: %s", h)
+	}
+}
+
+func TestCanarySeqRouteUpdates(t *testing.T) {
+	backend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(r.Header.Get("X-Seq")))
+	}))
+	defer backend.Close()
+
+	canary := func(header string) *eskip.Route {
+		return eskip.MustParse(`canary: Path("/canary") && TrafficSegment(0, 1, "canary") -> canarySeq("` + header + `") -> "` + backend.URL + `"`)[0]
+	}
+
+	other := func(path string) *eskip.Route {
+		return eskip.MustParse(`other: Path("` + path + `") -> "` + backend.URL + `"`)[0]
+	}
+
+	dc := testdataclient.New([]*eskip.Route{canary("X-Seq"), other("/other")})
+	defer dc.Close()
+
+	l := loggingtest.New()
+	defer l.Close()
+
+	spec := NewCanarySeq()
+	fr := make(filters.Registry)
+	fr.Register(spec)
+
+	rt := routing.New(routing.Options{
+		DataClients:    []routing.DataClient{dc},
+		FilterRegistry: fr,
+		Predicates:     []routing.PredicateSpec{traffic.NewSegment()},
+		PostProcessors: []routing.PostProcessor{spec.PostProcessor()},
+		Log:            l,
+	})
+	defer rt.Close()
+
+	p := proxy.WithParams(proxy.Params{Routing: rt})
+	defer p.Close()
+
+	s := httptest.NewServer(p)
+	defer s.Close()
+
+	update := func(upsert ...*eskip.Route) {
+		t.Helper()
+		l.Reset()
+		dc.Update(upsert, nil)
+		if err := l.WaitFor("route settings applied", time.Second); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	expect := func(expected ...string) {
+		t.Helper()
+		for _, e := range expected {
+			rsp, err := s.Client().Get(s.URL + "/canary")
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			b, err := io.ReadAll(rsp.Body)
+			rsp.Body.Close()
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if string(b) != e {
+				t.Errorf("unexpected sequence number: %s, expected: %s", b, e)
+			}
+		}
+	}
+
+	if err := l.WaitFor("route settings applied", time.Second); err != nil {
+		t.Fatal(err)
+	}
+
+	expect("1", "2")
+
+	// an unrelated route change doesn't restart the sequence
+	update(other("/changed"))
+	expect("3", "4")
+
+	// redefining the route restarts it
+	update(canary("x-seq"))
+	expect("1")
+}
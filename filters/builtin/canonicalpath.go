@@ -0,0 +1,158 @@
+package builtin
+
+import (
+	"net/url"
+	"strings"
+
+	"github.com/zalando/skipper/filters"
+)
+
+type trailingSlashPolicy int
+
+const (
+	keepTrailingSlash trailingSlashPolicy = iota
+	addTrailingSlash
+	removeTrailingSlash
+)
+
+type canonicalPathRedirectSpec struct{}
+
+type canonicalPathRedirectFilter struct {
+	code          int
+	trailingSlash trailingSlashPolicy
+}
+
+// NewCanonicalPathRedirect creates a filter specification whose instances
+// redirect the requests with a non-canonical path to the canonical one,
+// e.g. for the canonical URLs required for SEO, instead of silently
+// rewriting the path. In the canonical path, the duplicate slashes are
+// collapsed, and the dot-segments, "." and "..", also when percent-encoded,
+// are resolved, as in RFC 3986, 5.2.4. The query is kept.
+//
+// The first argument is the redirect status code, e.g. 301 or 308. The
+// optional second argument is the trailing slash policy: "keep" leaves the
+// trailing slash as it is, "add" requires it, and "remove" removes it, from
+// all the paths except the root. It defaults to "keep".
+//
+// Example:
+//
+//	canonicalPathRedirect(301, "remove")
+func NewCanonicalPathRedirect() filters.Spec { return canonicalPathRedirectSpec{} }
+
+func (canonicalPathRedirectSpec) Name() string { return filters.CanonicalPathRedirectName }
+
+func (canonicalPathRedirectSpec) CreateFilter(args []interface{}) (filters.Filter, error) {
+	if len(args) != 1 && len(args) != 2 {
+		return nil, filters.ErrInvalidFilterParameters
+	}
+
+	f := &canonicalPathRedirectFilter{}
+	switch v := args[0].(type) {
+	case int:
+		f.code = v
+	case float64:
+		f.code = int(v)
+	default:
+		return nil, filters.ErrInvalidFilterParameters
+	}
+
+	if f.code < 300 || f.code > 399 {
+		return nil, filters.ErrInvalidFilterParameters
+	}
+
+	if len(args) == 2 {
+		policy, _ := args[1].(string)
+		switch policy {
+		case "keep":
+			f.trailingSlash = keepTrailingSlash
+		case "add":
+			f.trailingSlash = addTrailingSlash
+		case "remove":
+			f.trailingSlash = removeTrailingSlash
+		default:
+			return nil, filters.ErrInvalidFilterParameters
+		}
+	}
+
+	return f, nil
+}
+
+func isDotSegment(s string) bool {
+	return s == "." || strings.EqualFold(s, "%2e")
+}
+
+func isDotDotSegment(s string) bool {
+	switch strings.ToLower(s) {
+	case "..", ".%2e", "%2e.", "%2e%2e":
+		return true
+	default:
+		return false
+	}
+}
+
+// canonicalPath returns the canonical form of an escaped path.
+func (f *canonicalPathRedirectFilter) canonicalPath(p string) string {
+	segments := strings.Split(p, "/")[1:]
+
+	var (
+		canonical []string
+		trailing  bool
+	)
+
+	for _, s := range segments {
+		// the path ending with a dot-segment is a directory, like with a
+		// trailing slash
+		trailing = s == "" || isDotSegment(s) || isDotDotSegment(s)
+
+		switch {
+		case s == "" || isDotSegment(s):
+		case isDotDotSegment(s):
+			if len(canonical) > 0 {
+				canonical = canonical[:len(canonical)-1]
+			}
+		default:
+			canonical = append(canonical, s)
+		}
+	}
+
+	if len(canonical) == 0 {
+		return "/"
+	}
+
+	switch f.trailingSlash {
+	case addTrailingSlash:
+		trailing = true
+	case removeTrailingSlash:
+		trailing = false
+	}
+
+	c := "/" + strings.Join(canonical, "/")
+	if trailing {
+		c += "/"
+	}
+
+	return c
+}
+
+func (f *canonicalPathRedirectFilter) Request(ctx filters.FilterContext) {
+	r := ctx.Request()
+	p := r.URL.EscapedPath()
+	if !strings.HasPrefix(p, "/") {
+		return
+	}
+
+	canonical := f.canonicalPath(p)
+	if canonical == p {
+		return
+	}
+
+	decoded, err := url.PathUnescape(canonical)
+	if err != nil {
+		return
+	}
+
+	// the query is kept by the redirect
+	Redirect(ctx, f.code, &url.URL{Path: decoded, RawPath: canonical})
+}
+
+func (*canonicalPathRedirectFilter) Response(filters.FilterContext) {}
@@ -0,0 +1,175 @@
+package builtin
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/zalando/skipper/filters/filtertest"
+)
+
+func TestCanonicalPathRedirectArgs(t *testing.T) {
+	for _, test := range []struct {
+		title string
+		args  []interface{}
+		fail  bool
+	}{{
+		title: "no args",
+		fail:  true,
+	}, {
+		title: "code",
+		args:  []interface{}{301.0},
+	}, {
+		title: "int code",
+		args:  []interface{}{308},
+	}, {
+		title: "code and policy",
+		args:  []interface{}{301.0, "remove"},
+	}, {
+		title: "not a redirect code",
+		args:  []interface{}{200.0},
+		fail:  true,
+	}, {
+		title: "code not a number",
+		args:  []interface{}{"301"},
+		fail:  true,
+	}, {
+		title: "invalid policy",
+		args:  []interface{}{301.0, "always"},
+		fail:  true,
+	}, {
+		title: "too many args",
+		args:  []interface{}{301.0, "add", "remove"},
+		fail:  true,
+	}} {
+		t.Run(test.title, func(t *testing.T) {
+			_, err := NewCanonicalPathRedirect().CreateFilter(test.args)
+			if test.fail && err == nil {
+				t.Fatal("failed to fail")
+			}
+
+			if !test.fail && err != nil {
+				t.Fatal(err)
+			}
+		})
+	}
+}
+
+func TestCanonicalPathRedirect(t *testing.T) {
+	for _, test := range []struct {
+		title    string
+		args     []interface{}
+		url      string
+		location string
+	}{{
+		title: "canonical",
+		args:  []interface{}{301.0},
+		url:   "https://www.example.org/a/b",
+	}, {
+		title: "root",
+		args:  []interface{}{301.0, "add"},
+		url:   "https://www.example.org/",
+	}, {
+		title:    "duplicate slashes",
+		args:     []interface{}{301.0},
+		url:      "https://www.example.org//a//b",
+		location: "https://www.example.org/a/b",
+	}, {
+		title:    "dot segment",
+		args:     []interface{}{301.0},
+		url:      "https://www.example.org/a/./b",
+		location: "https://www.example.org/a/b",
+	}, {
+		title:    "dot-dot segment",
+		args:     []interface{}{301.0},
+		url:      "https://www.example.org/a/../b",
+		location: "https://www.example.org/b",
+	}, {
+		title:    "dot-dot segment above the root",
+		args:     []interface{}{301.0},
+		url:      "https://www.example.org/../../a",
+		location: "https://www.example.org/a",
+	}, {
+		title:    "percent-encoded dot-dot segment",
+		args:     []interface{}{301.0},
+		url:      "https://www.example.org/a/%2E%2e/b",
+		location: "https://www.example.org/b",
+	}, {
+		title:    "trailing dot segment",
+		args:     []interface{}{301.0},
+		url:      "https://www.example.org/a/b/..",
+		location: "https://www.example.org/a/",
+	}, {
+		title: "keep trailing slash",
+		args:  []interface{}{301.0, "keep"},
+		url:   "https://www.example.org/a/",
+	}, {
+		title:    "add trailing slash",
+		args:     []interface{}{301.0, "add"},
+		url:      "https://www.example.org/a",
+		location: "https://www.example.org/a/",
+	}, {
+		title:    "remove trailing slash",
+		args:     []interface{}{308.0, "remove"},
+		url:      "https://www.example.org/a/b/",
+		location: "https://www.example.org/a/b",
+	}, {
+		title:    "remove trailing slash and collapse",
+		args:     []interface{}{301.0, "remove"},
+		url:      "https://www.example.org/a//b//",
+		location: "https://www.example.org/a/b",
+	}, {
+		title: "remove trailing slash keeps the root",
+		args:  []interface{}{301.0, "remove"},
+		url:   "https://www.example.org/",
+	}, {
+		title:    "only slashes",
+		args:     []interface{}{301.0, "remove"},
+		url:      "https://www.example.org///",
+		location: "https://www.example.org/",
+	}, {
+		title:    "keeps the query",
+		args:     []interface{}{301.0},
+		url:      "https://www.example.org/a//b?q=1&r=2",
+		location: "https://www.example.org/a/b?q=1&r=2",
+	}, {
+		title:    "keeps the escaping",
+		args:     []interface{}{301.0},
+		url:      "https://www.example.org/a%2Fb//c%20d",
+		location: "https://www.example.org/a%2Fb/c%20d",
+	}} {
+		t.Run(test.title, func(t *testing.T) {
+			f, err := NewCanonicalPathRedirect().CreateFilter(test.args)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			req, err := http.NewRequest("GET", test.url, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			ctx := &filtertest.Context{FRequest: req}
+			f.Request(ctx)
+
+			if test.location == "" {
+				if ctx.FServed {
+					t.Fatalf("unexpected redirect to %s", ctx.FResponse.Header.Get("Location"))
+				}
+
+				return
+			}
+
+			if !ctx.FServed {
+				t.Fatal("failed to redirect")
+			}
+
+			if ctx.FResponse.StatusCode != int(test.args[0].(float64)) {
+				t.Errorf("unexpected status code: %d", ctx.FResponse.StatusCode)
+			}
+
+			if l := ctx.FResponse.Header.Get("Location"); l != test.location {
+				t.Errorf("unexpected location: got %s, expected %s", l, test.location)
+			}
+		})
+	}
+}
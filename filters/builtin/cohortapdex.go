@@ -0,0 +1,217 @@
+package builtin
+
+import (
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/zalando/skipper/filters"
+	"github.com/zalando/skipper/internal/window"
+	"github.com/zalando/skipper/metrics"
+	"github.com/zalando/skipper/routing"
+)
+
+const (
+	cohortApdexStartKey      = "cohortApdex:start"
+	defaultCohortApdexWindow = time.Minute
+)
+
+// the metrics needed by the cohortApdex filter
+type cohortApdexGauges interface {
+	UpdateGauge(key string, value float64)
+}
+
+// CohortApdexSpec is the specification of the cohortApdex filter.
+type CohortApdexSpec struct {
+	metrics cohortApdexGauges
+	now     func() time.Time
+
+	mu       sync.Mutex
+	trackers map[cohortApdexKey]*cohortApdexTracker
+}
+
+type cohortApdexPostProcessor struct {
+	spec *CohortApdexSpec
+}
+
+type cohortApdexKey struct {
+	routeId, cohort string
+}
+
+type cohortApdexFilter struct {
+	spec      *CohortApdexSpec
+	threshold time.Duration
+	window    time.Duration
+}
+
+// the kinds of the counted responses
+const (
+	cohortApdexSatisfied = iota
+	cohortApdexTolerating
+	cohortApdexFrustrated
+	cohortApdexKinds
+)
+
+// cohortApdexTracker holds the counts of the responses of a cohort in a
+// sliding window.
+type cohortApdexTracker struct {
+	mu     sync.Mutex
+	counts *window.Counter
+}
+
+// NewCohortApdex creates a filter specification whose instances compute
+// the Apdex score of the cohorts, from the latency of the backend, and
+// expose it under the cohortapdex.<cohort>.<route id> gauge, so that the
+// experience of the users of a canary and of the stable traffic can be
+// compared with a single metric.
+//
+// Every response is classified against the threshold: satisfied, when the
+// latency is within the threshold, tolerating, when it is within four times
+// the threshold, and frustrated otherwise, or when the status is 5xx. The
+// score is (satisfied + tolerating / 2) / total, computed over a sliding
+// window, by default 1m.
+//
+// The cohort is taken from the TrafficSegment predicate, or, when it has no
+// label, the route id is used, and the gauge is cohortapdex.<route id>. The
+// cohorts are tracked separately for each route. Requests without a traffic
+// segment are not counted. The PostProcessor of the spec needs to be
+// registered in the routing, to drop the state of the deleted routes.
+//
+// Example:
+//
+//	stable: Path("/") && TrafficSegment(0, 0.9, "stable") -> cohortApdex("300ms") -> "https://stable.example.org";
+//	canary: Path("/") && TrafficSegment(0.9, 1, "canary") -> cohortApdex("300ms") -> "https://canary.example.org";
+func NewCohortApdex() *CohortApdexSpec {
+	return newCohortApdex(metrics.Default)
+}
+
+func newCohortApdex(m cohortApdexGauges) *CohortApdexSpec {
+	return &CohortApdexSpec{
+		metrics:  m,
+		now:      time.Now,
+		trackers: make(map[cohortApdexKey]*cohortApdexTracker),
+	}
+}
+
+func (*CohortApdexSpec) Name() string { return filters.CohortApdexName }
+
+func (s *CohortApdexSpec) CreateFilter(args []interface{}) (filters.Filter, error) {
+	if len(args) < 1 || len(args) > 2 {
+		return nil, filters.ErrInvalidFilterParameters
+	}
+
+	threshold, err := positiveDurationArg(args[0])
+	if err != nil {
+		return nil, err
+	}
+
+	window := defaultCohortApdexWindow
+	if len(args) == 2 {
+		if window, err = positiveDurationArg(args[1]); err != nil {
+			return nil, err
+		}
+	}
+
+	return &cohortApdexFilter{spec: s, threshold: threshold, window: window}, nil
+}
+
+// tracker returns the tracker of the cohort of a route. When the window of
+// the route has changed, the tracker is reset.
+func (s *CohortApdexSpec) tracker(k cohortApdexKey, w time.Duration) *cohortApdexTracker {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	t, ok := s.trackers[k]
+	if !ok || t.counts.Window() != w {
+		t = &cohortApdexTracker{counts: window.New(w, cohortApdexKinds)}
+		s.trackers[k] = t
+	}
+
+	return t
+}
+
+// PostProcessor returns the routing.PostProcessor dropping the trackers of
+// the routes, that were deleted or don't use the cohortApdex filter
+// anymore.
+func (s *CohortApdexSpec) PostProcessor() routing.PostProcessor {
+	return cohortApdexPostProcessor{spec: s}
+}
+
+func (p cohortApdexPostProcessor) Do(routes []*routing.Route) []*routing.Route {
+	s := p.spec
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	inUse := make(map[string]struct{})
+	for _, r := range routes {
+		for _, f := range r.Filters {
+			if _, ok := f.Filter.(*cohortApdexFilter); ok {
+				inUse[r.Id] = struct{}{}
+			}
+		}
+	}
+
+	for k := range s.trackers {
+		if _, ok := inUse[k.routeId]; !ok {
+			delete(s.trackers, k)
+		}
+	}
+
+	return routes
+}
+
+// record counts a response, and returns the score within the window.
+func (t *cohortApdexTracker) record(now time.Time, satisfied, tolerating bool) float64 {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	switch {
+	case satisfied:
+		t.counts.Inc(now, cohortApdexSatisfied)
+	case tolerating:
+		t.counts.Inc(now, cohortApdexTolerating)
+	default:
+		t.counts.Inc(now, cohortApdexFrustrated)
+	}
+
+	sum := t.counts.Sum(now)
+	total := sum[cohortApdexSatisfied] + sum[cohortApdexTolerating] + sum[cohortApdexFrustrated]
+	return (float64(sum[cohortApdexSatisfied]) + float64(sum[cohortApdexTolerating])/2) / float64(total)
+}
+
+func (f *cohortApdexFilter) Request(ctx filters.FilterContext) {
+	ctx.StateBag()[cohortApdexStartKey] = f.spec.now()
+}
+
+func (f *cohortApdexFilter) Response(ctx filters.FilterContext) {
+	start, ok := ctx.StateBag()[cohortApdexStartKey].(time.Time)
+	if !ok {
+		return
+	}
+
+	delete(ctx.StateBag(), cohortApdexStartKey)
+	cohort, ok := segmentCohort(ctx)
+	if !ok {
+		return
+	}
+
+	now := f.spec.now()
+	latency := now.Sub(start)
+	failed := ctx.Response().StatusCode >= http.StatusInternalServerError
+	satisfied := !failed && latency <= f.threshold
+	tolerating := !failed && latency <= 4*f.threshold
+
+	routeId := ctx.StateBag()[filters.TrafficSegmentKey].(routing.TrafficSegment).RouteId
+	score := f.spec.tracker(cohortApdexKey{routeId: routeId, cohort: cohort}, f.window).record(now, satisfied, tolerating)
+
+	key := "cohortapdex." + cohort
+	if cohort != routeId {
+		key += "." + routeId
+	}
+
+	f.spec.metrics.UpdateGauge(key, score)
+}
+
+// HandleErrorResponse is to opt-in for filters to get called
+// Response(ctx) in case of errors via proxy. It has to return true to opt-in.
+func (*cohortApdexFilter) HandleErrorResponse() bool { return true }
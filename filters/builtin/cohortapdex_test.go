@@ -0,0 +1,191 @@
+package builtin
+
+import (
+	"math"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/zalando/skipper/eskip"
+	"github.com/zalando/skipper/filters"
+	"github.com/zalando/skipper/filters/filtertest"
+	"github.com/zalando/skipper/metrics/metricstest"
+	"github.com/zalando/skipper/routing"
+)
+
+func TestCohortApdexCreate(t *testing.T) {
+	for _, tt := range []struct {
+		msg  string
+		args []interface{}
+		fail bool
+	}{{
+		msg:  "no arguments",
+		fail: true,
+	}, {
+		msg:  "invalid threshold",
+		args: []interface{}{"foo"},
+		fail: true,
+	}, {
+		msg:  "zero threshold",
+		args: []interface{}{"0s"},
+		fail: true,
+	}, {
+		msg:  "threshold not a duration",
+		args: []interface{}{300.0},
+		fail: true,
+	}, {
+		msg:  "invalid window",
+		args: []interface{}{"300ms", "-1m"},
+		fail: true,
+	}, {
+		msg:  "too many arguments",
+		args: []interface{}{"300ms", "1m", "1m"},
+		fail: true,
+	}, {
+		msg:  "threshold",
+		args: []interface{}{"300ms"},
+	}, {
+		msg:  "threshold and window",
+		args: []interface{}{"300ms", "5m"},
+	}} {
+		t.Run(tt.msg, func(t *testing.T) {
+			_, err := NewCohortApdex().CreateFilter(tt.args)
+			if tt.fail && err == nil {
+				t.Error("failed to fail")
+			} else if !tt.fail && err != nil {
+				t.Error(err)
+			}
+		})
+	}
+}
+
+func TestCohortApdex(t *testing.T) {
+	m := &metricstest.MockMetrics{}
+	spec := newCohortApdex(m)
+
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	spec.now = func() time.Time { return now }
+
+	f, err := spec.CreateFilter([]interface{}{"100ms", "10s"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	request := func(segment interface{}, latency time.Duration, status int) {
+		ctx := &filtertest.Context{FRequest: &http.Request{}, FStateBag: make(map[string]interface{})}
+		if segment != nil {
+			ctx.FStateBag[filters.TrafficSegmentKey] = segment
+		}
+
+		f.Request(ctx)
+		now = now.Add(latency)
+		ctx.FResponse = &http.Response{StatusCode: status}
+		f.Response(ctx)
+	}
+
+	stable := routing.TrafficSegment{Min: 0, Max: 0.9, RouteId: "stable"}
+	canary := routing.TrafficSegment{Min: 0.9, Max: 1, Cohort: "canary", RouteId: "canary"}
+
+	// stable: 3 satisfied, 1 tolerating
+	for i := 0; i < 3; i++ {
+		request(stable, 50*time.Millisecond, http.StatusOK)
+	}
+
+	request(stable, 300*time.Millisecond, http.StatusOK)
+
+	// canary: 1 satisfied, 1 tolerating, 1 frustrated by latency and 1 by
+	// the status
+	request(canary, 100*time.Millisecond, http.StatusOK)
+	request(canary, 400*time.Millisecond, http.StatusOK)
+	request(canary, 401*time.Millisecond, http.StatusOK)
+	request(canary, 10*time.Millisecond, http.StatusBadGateway)
+
+	// not counted without a segment
+	request(nil, time.Second, http.StatusOK)
+
+	checkGauge := func(key string, expected float64) {
+		t.Helper()
+		v, ok := m.Gauge(key)
+		if !ok {
+			t.Errorf("gauge not found: %s", key)
+			return
+		}
+
+		if math.Abs(v-expected) > 1e-9 {
+			t.Errorf("unexpected score of %s: %f, expected: %f", key, v, expected)
+		}
+	}
+
+	checkGauge("cohortapdex.stable", 3.5/4)
+	checkGauge("cohortapdex.canary", 1.5/4)
+
+	// the counts older than the window are dropped
+	now = now.Add(10 * time.Second)
+	request(canary, 10*time.Millisecond, http.StatusOK)
+	checkGauge("cohortapdex.canary", 1)
+}
+
+func TestCohortApdexPerRoute(t *testing.T) {
+	m := &metricstest.MockMetrics{}
+	spec := newCohortApdex(m)
+
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	spec.now = func() time.Time { return now }
+
+	shortWindow, err := spec.CreateFilter([]interface{}{"100ms", "10s"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	longWindow, err := spec.CreateFilter([]interface{}{"100ms", "1m"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	request := func(f filters.Filter, routeId string, status int) {
+		ctx := &filtertest.Context{
+			FRequest: &http.Request{},
+			FStateBag: map[string]interface{}{
+				filters.TrafficSegmentKey: routing.TrafficSegment{RouteId: routeId, Cohort: "canary"},
+			},
+		}
+
+		f.Request(ctx)
+		ctx.FResponse = &http.Response{StatusCode: status}
+		f.Response(ctx)
+	}
+
+	// the routes with the same cohort and different windows don't reset
+	// each other
+	request(shortWindow, "checkout", http.StatusOK)
+	request(longWindow, "catalog", http.StatusBadGateway)
+	request(shortWindow, "checkout", http.StatusBadGateway)
+	request(longWindow, "catalog", http.StatusBadGateway)
+
+	for key, expected := range map[string]float64{
+		"cohortapdex.canary.checkout": 0.5,
+		"cohortapdex.canary.catalog":  0,
+	} {
+		if v, ok := m.Gauge(key); !ok || v != expected {
+			t.Errorf("unexpected score of %s: %f, expected: %f", key, v, expected)
+		}
+	}
+
+	spec.PostProcessor().Do([]*routing.Route{
+		{Route: eskip.Route{Id: "checkout"}, Filters: []*routing.RouteFilter{{Filter: shortWindow, Name: filters.CohortApdexName}}},
+	})
+
+	if len(spec.trackers) != 1 {
+		t.Errorf("expected only the tracker of the remaining route, got: %v", spec.trackers)
+	}
+
+	request(shortWindow, "checkout", http.StatusOK)
+	if v, _ := m.Gauge("cohortapdex.canary.checkout"); math.Abs(v-2.0/3) > 1e-9 {
+		t.Errorf("the remaining route lost its state: %f", v)
+	}
+
+	spec.PostProcessor().Do(nil)
+	if len(spec.trackers) != 0 {
+		t.Errorf("unexpected trackers without routes: %v", spec.trackers)
+	}
+}
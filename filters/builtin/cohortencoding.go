@@ -0,0 +1,99 @@
+package builtin
+
+import (
+	"strconv"
+	"strings"
+
+	"golang.org/x/net/http/httpguts"
+
+	"github.com/zalando/skipper/filters"
+)
+
+type cohortEncodingSpec struct{}
+
+type cohortEncodingFilter struct {
+	encoding string
+}
+
+// NewCohortEncoding creates a filter specification whose instances force
+// the content encoding negotiated for the requests of a traffic cohort,
+// e.g. to compare the compressed and the uncompressed behavior of the
+// backends in a canary. The filter is engaged, when the request was assigned
+// to the traffic segment of the route, see the TrafficSegment predicate.
+//
+// When engaged, the filter replaces the Accept-Encoding header of the
+// request with the encoding, when the client accepts it, so that both the
+// backend and the compress filter in the same route negotiate only the
+// forced encoding. The identity encoding is always accepted.
+//
+// Example:
+//
+//	canary: TrafficSegment(0.9, 1, "canary") -> cohortEncoding("identity") -> compress() -> "https://canary.example.org";
+func NewCohortEncoding() filters.Spec { return cohortEncodingSpec{} }
+
+func (cohortEncodingSpec) Name() string { return filters.CohortEncodingName }
+
+func (cohortEncodingSpec) CreateFilter(args []interface{}) (filters.Filter, error) {
+	if len(args) != 1 {
+		return nil, filters.ErrInvalidFilterParameters
+	}
+
+	enc, ok := args[0].(string)
+	if !ok || enc == "*" || !httpguts.ValidHeaderFieldName(enc) {
+		return nil, filters.ErrInvalidFilterParameters
+	}
+
+	return cohortEncodingFilter{encoding: strings.ToLower(enc)}, nil
+}
+
+// acceptsEncoding tells whether the Accept-Encoding header allows the
+// encoding, listed explicitly or with the * wildcard, with a non-zero
+// quality.
+func acceptsEncoding(header []string, enc string) bool {
+	accepted, wildcard := false, false
+	for _, h := range header {
+		for _, s := range strings.Split(h, ",") {
+			name, params, _ := strings.Cut(s, ";")
+			name = strings.ToLower(strings.TrimSpace(name))
+			if name != enc && name != "*" {
+				continue
+			}
+
+			q := 1.0
+			for _, p := range strings.Split(params, ";") {
+				p = strings.TrimSpace(p)
+				if !strings.HasPrefix(p, "q=") {
+					continue
+				}
+
+				if f, err := strconv.ParseFloat(strings.TrimPrefix(p, "q="), 64); err == nil {
+					q = f
+				}
+			}
+
+			if name == enc {
+				// explicit entries take precedence over the wildcard
+				return q > 0
+			}
+
+			accepted, wildcard = q > 0, true
+		}
+	}
+
+	return wildcard && accepted
+}
+
+func (f cohortEncodingFilter) Request(ctx filters.FilterContext) {
+	if _, ok := segmentCohort(ctx); !ok {
+		return
+	}
+
+	req := ctx.Request()
+	if f.encoding != "identity" && !acceptsEncoding(req.Header.Values("Accept-Encoding"), f.encoding) {
+		return
+	}
+
+	req.Header.Set("Accept-Encoding", f.encoding)
+}
+
+func (cohortEncodingFilter) Response(filters.FilterContext) {}
@@ -0,0 +1,171 @@
+package builtin
+
+import (
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/zalando/skipper/filters"
+	"github.com/zalando/skipper/filters/filtertest"
+	"github.com/zalando/skipper/routing"
+)
+
+func TestCohortEncodingArgs(t *testing.T) {
+	for _, tt := range []struct {
+		msg  string
+		args []interface{}
+		fail bool
+	}{{
+		msg:  "no args",
+		fail: true,
+	}, {
+		msg:  "too many args",
+		args: []interface{}{"gzip", "br"},
+		fail: true,
+	}, {
+		msg:  "not a string",
+		args: []interface{}{42},
+		fail: true,
+	}, {
+		msg:  "wildcard",
+		args: []interface{}{"*"},
+		fail: true,
+	}, {
+		msg:  "invalid token",
+		args: []interface{}{"gzip, br"},
+		fail: true,
+	}, {
+		msg:  "identity",
+		args: []interface{}{"identity"},
+	}, {
+		msg:  "gzip",
+		args: []interface{}{"GZIP"},
+	}} {
+		t.Run(tt.msg, func(t *testing.T) {
+			_, err := NewCohortEncoding().CreateFilter(tt.args)
+			if tt.fail && err == nil {
+				t.Error("failed to fail")
+			} else if !tt.fail && err != nil {
+				t.Error(err)
+			}
+		})
+	}
+}
+
+func TestCohortEncoding(t *testing.T) {
+	for _, tt := range []struct {
+		msg            string
+		encoding       string
+		noSegment      bool
+		acceptEncoding string
+		expected       string
+	}{{
+		msg:            "identity",
+		encoding:       "identity",
+		acceptEncoding: "gzip, br",
+		expected:       "identity",
+	}, {
+		msg:      "identity without accept encoding",
+		encoding: "identity",
+		expected: "identity",
+	}, {
+		msg:            "gzip accepted",
+		encoding:       "gzip",
+		acceptEncoding: "br;q=1, gzip;q=0.5",
+		expected:       "gzip",
+	}, {
+		msg:            "gzip accepted by wildcard",
+		encoding:       "gzip",
+		acceptEncoding: "br, *;q=0.1",
+		expected:       "gzip",
+	}, {
+		msg:            "gzip rejected explicitly",
+		encoding:       "gzip",
+		acceptEncoding: "*, gzip;q=0",
+		expected:       "*, gzip;q=0",
+	}, {
+		msg:            "gzip not accepted",
+		encoding:       "gzip",
+		acceptEncoding: "br",
+		expected:       "br",
+	}, {
+		msg:      "gzip without accept encoding",
+		encoding: "gzip",
+	}, {
+		msg:            "no segment",
+		encoding:       "identity",
+		noSegment:      true,
+		acceptEncoding: "gzip",
+		expected:       "gzip",
+	}} {
+		t.Run(tt.msg, func(t *testing.T) {
+			f, err := NewCohortEncoding().CreateFilter([]interface{}{tt.encoding})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			req := &http.Request{Header: http.Header{}}
+			if tt.acceptEncoding != "" {
+				req.Header.Set("Accept-Encoding", tt.acceptEncoding)
+			}
+
+			ctx := &filtertest.Context{FRequest: req, FStateBag: make(map[string]interface{})}
+			if !tt.noSegment {
+				ctx.FStateBag[filters.TrafficSegmentKey] = routing.TrafficSegment{Min: 0.9, Max: 1, Cohort: "canary"}
+			}
+
+			f.Request(ctx)
+			if v := req.Header.Get("Accept-Encoding"); v != tt.expected {
+				t.Errorf("unexpected Accept-Encoding: %q, expected: %q", v, tt.expected)
+			}
+		})
+	}
+}
+
+func TestCohortEncodingWithCompress(t *testing.T) {
+	for _, tt := range []struct {
+		encoding string
+		expected string
+	}{{
+		encoding: "identity",
+		expected: "",
+	}, {
+		encoding: "gzip",
+		expected: "gzip",
+	}} {
+		t.Run(tt.encoding, func(t *testing.T) {
+			f, err := NewCohortEncoding().CreateFilter([]interface{}{tt.encoding})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			c, err := NewCompress().CreateFilter(nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			req := &http.Request{Header: http.Header{"Accept-Encoding": []string{"br, gzip"}}}
+			ctx := &filtertest.Context{
+				FRequest: req,
+				FStateBag: map[string]interface{}{
+					filters.TrafficSegmentKey: routing.TrafficSegment{Min: 0.9, Max: 1, Cohort: "canary"},
+				},
+				FResponse: &http.Response{
+					Header: http.Header{"Content-Type": []string{"text/plain"}},
+					Body:   io.NopCloser(strings.NewReader("Hello, world!")),
+				},
+			}
+
+			f.Request(ctx)
+			c.Request(ctx)
+			c.Response(ctx)
+			f.Response(ctx)
+			defer ctx.FResponse.Body.Close()
+
+			if ce := ctx.FResponse.Header.Get("Content-Encoding"); ce != tt.expected {
+				t.Errorf("unexpected Content-Encoding: %q, expected: %q", ce, tt.expected)
+			}
+		})
+	}
+}
@@ -0,0 +1,220 @@
+package builtin
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"golang.org/x/net/http/httpguts"
+	"gopkg.in/yaml.v2"
+
+	"github.com/zalando/skipper/filters"
+)
+
+const (
+	// the rules applied to the cohorts without their own rules, and to the
+	// requests without a traffic segment
+	defaultCohortHeadersKey = "*"
+
+	// how often the rules file is checked for changes
+	cohortHeadersCheckInterval = time.Second
+)
+
+type cohortHeadersSpec struct {
+	reloadedFiles[map[string]*cohortHeaderRules]
+}
+
+type cohortHeadersFilter struct {
+	rules *reloadedFile[map[string]*cohortHeaderRules]
+}
+
+type headerRulesConfig struct {
+	Set    map[string]string `yaml:"set"`
+	Add    map[string]string `yaml:"add"`
+	Remove []string          `yaml:"remove"`
+}
+
+type cohortHeaderRulesConfig struct {
+	Request  headerRulesConfig `yaml:"request"`
+	Response headerRulesConfig `yaml:"response"`
+}
+
+type cohortHeadersConfig struct {
+	Cohorts map[string]cohortHeaderRulesConfig `yaml:"cohorts"`
+}
+
+type headerRules struct {
+	set    http.Header
+	add    http.Header
+	remove []string
+}
+
+type cohortHeaderRules struct {
+	request, response headerRules
+}
+
+// NewCohortHeaders creates a filter specification whose instances apply
+// header rules specific to the cohort of the request, without separate
+// routes for the cohorts. The cohort is the one of the traffic segment of
+// the route, or the route id, when the segment has no cohort. The rules are
+// loaded from a YAML file, e.g. a mounted Kubernetes ConfigMap, and they are
+// reloaded when the file changes, without rebuilding the routing table.
+//
+// The rules of a cohort remove, set and add the request and the response
+// headers, in this order. The cohort * contains the rules of the cohorts
+// without their own rules, and of the requests without a traffic segment:
+//
+//	cohorts:
+//	  canary:
+//	    request:
+//	      set: {X-Variant: canary}
+//	      remove: [X-Legacy-Session]
+//	    response:
+//	      add: {Vary: X-Variant}
+//	  "*":
+//	    request:
+//	      set: {X-Variant: stable}
+//
+// Example:
+//
+//	canary: Path("/") && TrafficSegment(0, 0.1, "canary") -> cohortHeaders("/etc/skipper/cohort-headers.yaml") -> "https://app.example.org";
+//	stable: Path("/") -> cohortHeaders("/etc/skipper/cohort-headers.yaml") -> "https://app.example.org";
+func NewCohortHeaders() filters.Spec {
+	return &cohortHeadersSpec{
+		reloadedFiles: newReloadedFiles(
+			filters.CohortHeadersName,
+			"cohort header rules",
+			parseCohortHeaders,
+			cohortHeadersCheckInterval,
+		),
+	}
+}
+
+func (*cohortHeadersSpec) Name() string { return filters.CohortHeadersName }
+
+func (s *cohortHeadersSpec) CreateFilter(args []interface{}) (filters.Filter, error) {
+	if len(args) != 1 {
+		return nil, filters.ErrInvalidFilterParameters
+	}
+
+	file, ok := args[0].(string)
+	if !ok || file == "" {
+		return nil, filters.ErrInvalidFilterParameters
+	}
+
+	rules, err := s.get(file)
+	if err != nil {
+		return nil, err
+	}
+
+	return &cohortHeadersFilter{rules: rules}, nil
+}
+
+func parseHeaderValues(values map[string]string) (http.Header, error) {
+	if len(values) == 0 {
+		return nil, nil
+	}
+
+	h := make(http.Header, len(values))
+	for name, value := range values {
+		if !httpguts.ValidHeaderFieldName(name) {
+			return nil, fmt.Errorf("invalid header name: %s", name)
+		}
+
+		if !httpguts.ValidHeaderFieldValue(value) {
+			return nil, fmt.Errorf("invalid value of header %s", name)
+		}
+
+		h.Set(name, value)
+	}
+
+	return h, nil
+}
+
+func parseHeaderRules(c headerRulesConfig) (headerRules, error) {
+	var (
+		r   headerRules
+		err error
+	)
+
+	if r.set, err = parseHeaderValues(c.Set); err != nil {
+		return headerRules{}, err
+	}
+
+	if r.add, err = parseHeaderValues(c.Add); err != nil {
+		return headerRules{}, err
+	}
+
+	for _, name := range c.Remove {
+		if !httpguts.ValidHeaderFieldName(name) {
+			return headerRules{}, fmt.Errorf("invalid header name: %s", name)
+		}
+
+		r.remove = append(r.remove, http.CanonicalHeaderKey(name))
+	}
+
+	return r, nil
+}
+
+func parseCohortHeaders(data []byte) (map[string]*cohortHeaderRules, error) {
+	var c cohortHeadersConfig
+	if err := yaml.UnmarshalStrict(data, &c); err != nil {
+		return nil, err
+	}
+
+	cohorts := make(map[string]*cohortHeaderRules, len(c.Cohorts))
+	for name, cc := range c.Cohorts {
+		request, err := parseHeaderRules(cc.Request)
+		if err != nil {
+			return nil, fmt.Errorf("invalid request rules of cohort %s: %w", name, err)
+		}
+
+		response, err := parseHeaderRules(cc.Response)
+		if err != nil {
+			return nil, fmt.Errorf("invalid response rules of cohort %s: %w", name, err)
+		}
+
+		cohorts[name] = &cohortHeaderRules{request: request, response: response}
+	}
+
+	return cohorts, nil
+}
+
+// get returns the rules of the cohort, or the default rules.
+func (f *cohortHeadersFilter) get(cohort string, ok bool) *cohortHeaderRules {
+	cohorts, _ := f.rules.get()
+	if ok {
+		if r, ok := cohorts[cohort]; ok {
+			return r
+		}
+	}
+
+	return cohorts[defaultCohortHeadersKey]
+}
+
+func (r headerRules) apply(h http.Header) {
+	for _, name := range r.remove {
+		h.Del(name)
+	}
+
+	// copying the values, because they are shared by the requests
+	for name, values := range r.set {
+		h[name] = append([]string(nil), values...)
+	}
+
+	for name, values := range r.add {
+		h[name] = append(h[name], values...)
+	}
+}
+
+func (f *cohortHeadersFilter) Request(ctx filters.FilterContext) {
+	if r := f.get(segmentCohort(ctx)); r != nil {
+		r.request.apply(ctx.Request().Header)
+	}
+}
+
+func (f *cohortHeadersFilter) Response(ctx filters.FilterContext) {
+	if r := f.get(segmentCohort(ctx)); r != nil {
+		r.response.apply(ctx.Response().Header)
+	}
+}
@@ -0,0 +1,201 @@
+package builtin
+
+import (
+	"net/http"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/zalando/skipper/filters"
+	"github.com/zalando/skipper/filters/filtertest"
+	"github.com/zalando/skipper/routing"
+)
+
+const testCohortHeaders = `cohorts:
+  canary:
+    request:
+      set: {X-Variant: canary}
+      add: {X-Feature: new-checkout}
+      remove: [X-Legacy-Session]
+    response:
+      add: {Vary: X-Variant}
+  "*":
+    request:
+      set: {X-Variant: stable}
+`
+
+func testCohortHeadersSpec(now *time.Time) filters.Spec {
+	spec := NewCohortHeaders().(*cohortHeadersSpec)
+	spec.now = func() time.Time { return *now }
+	return spec
+}
+
+func cohortHeadersContext(cohort string) *filtertest.Context {
+	ctx := &filtertest.Context{
+		FRequest: &http.Request{Header: http.Header{
+			"X-Variant":        []string{"spoofed"},
+			"X-Feature":        []string{"dark-mode"},
+			"X-Legacy-Session": []string{"42"},
+		}},
+		FResponse: &http.Response{Header: http.Header{"Vary": []string{"Accept-Encoding"}}},
+		FStateBag: make(map[string]interface{}),
+	}
+
+	if cohort != "" {
+		ctx.FStateBag[filters.TrafficSegmentKey] = routing.TrafficSegment{RouteId: "route1", Cohort: cohort}
+	}
+
+	return ctx
+}
+
+func TestCohortHeadersArgs(t *testing.T) {
+	dir := t.TempDir()
+	valid := filepath.Join(dir, "valid.yaml")
+	writeMaintenanceSchedule(t, valid, testCohortHeaders, time.Now())
+
+	invalidName := filepath.Join(dir, "invalid-name.yaml")
+	writeMaintenanceSchedule(t, invalidName, "cohorts:\n  canary:\n    request:\n      set: {\"X Variant\": canary}\n", time.Now())
+
+	invalidValue := filepath.Join(dir, "invalid-value.yaml")
+	writeMaintenanceSchedule(t, invalidValue, "cohorts:\n  canary:\n    response:\n      add: {X-Variant: \"canary\\r\\nX-Injected: true\"}\n", time.Now())
+
+	unknownField := filepath.Join(dir, "unknown-field.yaml")
+	writeMaintenanceSchedule(t, unknownField, "cohorts:\n  canary:\n    request:\n      replace: {X-Variant: canary}\n", time.Now())
+
+	for _, tt := range []struct {
+		msg  string
+		args []interface{}
+		fail bool
+	}{
+		{msg: "no args", fail: true},
+		{msg: "not a string", args: []interface{}{42}, fail: true},
+		{msg: "empty", args: []interface{}{""}, fail: true},
+		{msg: "too many", args: []interface{}{valid, valid}, fail: true},
+		{msg: "missing file", args: []interface{}{filepath.Join(dir, "missing.yaml")}, fail: true},
+		{msg: "invalid header name", args: []interface{}{invalidName}, fail: true},
+		{msg: "invalid header value", args: []interface{}{invalidValue}, fail: true},
+		{msg: "unknown field", args: []interface{}{unknownField}, fail: true},
+		{msg: "valid", args: []interface{}{valid}},
+	} {
+		t.Run(tt.msg, func(t *testing.T) {
+			_, err := NewCohortHeaders().CreateFilter(tt.args)
+			if tt.fail != (err != nil) {
+				t.Errorf("expected failure: %v, got: %v", tt.fail, err)
+			}
+		})
+	}
+}
+
+func TestCohortHeaders(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "cohort-headers.yaml")
+	writeMaintenanceSchedule(t, file, testCohortHeaders, time.Now())
+
+	f, err := NewCohortHeaders().CreateFilter([]interface{}{file})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tt := range []struct {
+		msg             string
+		cohort          string
+		expectedRequest http.Header
+		expectedVary    []string
+	}{{
+		msg:    "cohort rules",
+		cohort: "canary",
+		expectedRequest: http.Header{
+			"X-Variant": []string{"canary"},
+			"X-Feature": []string{"dark-mode", "new-checkout"},
+		},
+		expectedVary: []string{"Accept-Encoding", "X-Variant"},
+	}, {
+		msg:    "default rules of other cohorts",
+		cohort: "experiment",
+		expectedRequest: http.Header{
+			"X-Variant":        []string{"stable"},
+			"X-Feature":        []string{"dark-mode"},
+			"X-Legacy-Session": []string{"42"},
+		},
+		expectedVary: []string{"Accept-Encoding"},
+	}, {
+		msg: "default rules without traffic segment",
+		expectedRequest: http.Header{
+			"X-Variant":        []string{"stable"},
+			"X-Feature":        []string{"dark-mode"},
+			"X-Legacy-Session": []string{"42"},
+		},
+		expectedVary: []string{"Accept-Encoding"},
+	}} {
+		t.Run(tt.msg, func(t *testing.T) {
+			ctx := cohortHeadersContext(tt.cohort)
+			f.Request(ctx)
+			f.Response(ctx)
+
+			if len(ctx.FRequest.Header) != len(tt.expectedRequest) {
+				t.Errorf("unexpected request headers: %v", ctx.FRequest.Header)
+			}
+
+			for name, values := range tt.expectedRequest {
+				if got := ctx.FRequest.Header[name]; !reflect.DeepEqual(got, values) {
+					t.Errorf("%s: expected %v, got %v", name, values, got)
+				}
+			}
+
+			if got := ctx.FResponse.Header["Vary"]; !reflect.DeepEqual(got, tt.expectedVary) {
+				t.Errorf("Vary: expected %v, got %v", tt.expectedVary, got)
+			}
+		})
+	}
+}
+
+func TestCohortHeadersNoDefault(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "cohort-headers.yaml")
+	writeMaintenanceSchedule(t, file, "cohorts:\n  canary:\n    request:\n      set: {X-Variant: canary}\n", time.Now())
+
+	f, err := NewCohortHeaders().CreateFilter([]interface{}{file})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := cohortHeadersContext("stable")
+	f.Request(ctx)
+	f.Response(ctx)
+	if v := ctx.FRequest.Header.Get("X-Variant"); v != "spoofed" {
+		t.Errorf("expected unchanged header, got: %s", v)
+	}
+}
+
+func TestCohortHeadersReload(t *testing.T) {
+	now := time.Date(2023, 6, 1, 12, 0, 0, 0, time.UTC)
+	file := filepath.Join(t.TempDir(), "cohort-headers.yaml")
+	writeMaintenanceSchedule(t, file, testCohortHeaders, now.Add(-time.Hour))
+
+	f, err := testCohortHeadersSpec(&now).CreateFilter([]interface{}{file})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	variant := func() string {
+		ctx := cohortHeadersContext("canary")
+		f.Request(ctx)
+		return ctx.FRequest.Header.Get("X-Variant")
+	}
+
+	writeMaintenanceSchedule(t, file, "cohorts:\n  canary:\n    request:\n      set: {X-Variant: canary-v2}\n", now.Add(-time.Minute))
+	if v := variant(); v != "canary" {
+		t.Errorf("expected the old rules within the check interval, got: %s", v)
+	}
+
+	now = now.Add(2 * time.Second)
+	if v := variant(); v != "canary-v2" {
+		t.Errorf("expected the reloaded rules, got: %s", v)
+	}
+
+	// keeps the last valid rules
+	writeMaintenanceSchedule(t, file, "cohorts: [", now)
+	now = now.Add(2 * time.Second)
+	if v := variant(); v != "canary-v2" {
+		t.Errorf("expected the last valid rules, got: %s", v)
+	}
+}
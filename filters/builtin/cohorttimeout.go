@@ -0,0 +1,112 @@
+package builtin
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/zalando/skipper/filters"
+)
+
+type cohortTimeoutSpec struct{}
+
+type cohortTimeoutFilter struct {
+	stable, canary time.Duration
+	canaries       map[string]bool
+}
+
+// NewCohortTimeout creates a filter specification whose instances set the
+// backend timeout of the request depending on its cohort, so that a canary
+// backend can fail fast without separate routes. The cohort is the one of
+// the traffic segment of the route, or the route id, when the segment has
+// no cohort. The requests of the canary cohorts, listed in the arguments,
+// get the canary timeout, while all the other requests get the stable
+// timeout. The timeout overrides the one set by the backendTimeout filter
+// earlier in the chain.
+//
+// When the backend times out, the proxy responds with 504 Gateway Timeout,
+// and the filter increments the cohorttimeout.<cohort>.timeout counter, or
+// the cohorttimeout.timeout counter for the requests without a traffic
+// segment.
+//
+// Example:
+//
+//	canary: Path("/") && TrafficSegment(0.9, 1, "canary") -> cohortTimeout("10s", "2s", "canary") -> "https://canary.example.org";
+//	stable: Path("/") && TrafficSegment(0, 0.9, "stable") -> cohortTimeout("10s", "2s", "canary") -> "https://stable.example.org";
+func NewCohortTimeout() filters.Spec { return &cohortTimeoutSpec{} }
+
+func (*cohortTimeoutSpec) Name() string { return filters.CohortTimeoutName }
+
+func parseCohortTimeout(arg interface{}) (time.Duration, error) {
+	var d time.Duration
+	switch v := arg.(type) {
+	case string:
+		var err error
+		if d, err = time.ParseDuration(v); err != nil {
+			return 0, err
+		}
+	case time.Duration:
+		d = v
+	default:
+		return 0, filters.ErrInvalidFilterParameters
+	}
+
+	if d <= 0 {
+		return 0, filters.ErrInvalidFilterParameters
+	}
+
+	return d, nil
+}
+
+func (*cohortTimeoutSpec) CreateFilter(args []interface{}) (filters.Filter, error) {
+	if len(args) < 3 {
+		return nil, filters.ErrInvalidFilterParameters
+	}
+
+	stable, err := parseCohortTimeout(args[0])
+	if err != nil {
+		return nil, err
+	}
+
+	canary, err := parseCohortTimeout(args[1])
+	if err != nil {
+		return nil, err
+	}
+
+	canaries := make(map[string]bool, len(args)-2)
+	for _, a := range args[2:] {
+		cohort, ok := a.(string)
+		if !ok || cohort == "" {
+			return nil, filters.ErrInvalidFilterParameters
+		}
+
+		canaries[cohort] = true
+	}
+
+	return &cohortTimeoutFilter{stable: stable, canary: canary, canaries: canaries}, nil
+}
+
+func (f *cohortTimeoutFilter) Request(ctx filters.FilterContext) {
+	if cohort, ok := segmentCohort(ctx); ok && f.canaries[cohort] {
+		ctx.StateBag()[filters.BackendTimeout] = f.canary
+		return
+	}
+
+	ctx.StateBag()[filters.BackendTimeout] = f.stable
+}
+
+func (f *cohortTimeoutFilter) Response(ctx filters.FilterContext) {
+	if ctx.Response().StatusCode != http.StatusGatewayTimeout {
+		return
+	}
+
+	key := "cohorttimeout"
+	if cohort, ok := segmentCohort(ctx); ok {
+		key += "." + cohort
+	}
+
+	ctx.Metrics().IncCounter(key + ".timeout")
+}
+
+// HandleErrorResponse is to opt-in for filters to get called
+// Response(ctx) in case of errors via proxy. It has to return true to opt-in.
+func (*cohortTimeoutFilter) HandleErrorResponse() bool { return true }
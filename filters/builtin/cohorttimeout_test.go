@@ -0,0 +1,166 @@
+package builtin
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/zalando/skipper/filters"
+	"github.com/zalando/skipper/filters/filtertest"
+	"github.com/zalando/skipper/metrics/metricstest"
+	"github.com/zalando/skipper/routing"
+)
+
+func TestCohortTimeoutCreate(t *testing.T) {
+	for _, tt := range []struct {
+		msg  string
+		args []interface{}
+		err  bool
+	}{{
+		msg: "no args",
+		err: true,
+	}, {
+		msg:  "missing canary timeout",
+		args: []interface{}{"10s"},
+		err:  true,
+	}, {
+		msg:  "missing canary cohort",
+		args: []interface{}{"10s", "2s"},
+		err:  true,
+	}, {
+		msg:  "invalid stable timeout",
+		args: []interface{}{"ten seconds", "2s", "canary"},
+		err:  true,
+	}, {
+		msg:  "invalid canary timeout type",
+		args: []interface{}{"10s", 2.0, "canary"},
+		err:  true,
+	}, {
+		msg:  "zero timeout",
+		args: []interface{}{"10s", "0s", "canary"},
+		err:  true,
+	}, {
+		msg:  "negative timeout",
+		args: []interface{}{"-1s", "2s", "canary"},
+		err:  true,
+	}, {
+		msg:  "invalid canary cohort",
+		args: []interface{}{"10s", "2s", 1.0},
+		err:  true,
+	}, {
+		msg:  "empty canary cohort",
+		args: []interface{}{"10s", "2s", ""},
+		err:  true,
+	}, {
+		msg:  "durations",
+		args: []interface{}{"10s", "2s", "canary"},
+	}, {
+		msg:  "duration values",
+		args: []interface{}{10 * time.Second, 2 * time.Second, "canary"},
+	}, {
+		msg:  "multiple canary cohorts",
+		args: []interface{}{"10s", "2s", "canary", "beta"},
+	}} {
+		t.Run(tt.msg, func(t *testing.T) {
+			_, err := NewCohortTimeout().CreateFilter(tt.args)
+			if tt.err && err == nil {
+				t.Error("expected error")
+			} else if !tt.err && err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+		})
+	}
+}
+
+func TestCohortTimeout(t *testing.T) {
+	f, err := NewCohortTimeout().CreateFilter([]interface{}{"10s", "2s", "canary", "beta"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tt := range []struct {
+		msg        string
+		segment    *routing.TrafficSegment
+		statusCode int
+		timeout    time.Duration
+		counter    string
+	}{{
+		msg:        "no segment",
+		statusCode: http.StatusOK,
+		timeout:    10 * time.Second,
+	}, {
+		msg:        "segment without cohort",
+		segment:    &routing.TrafficSegment{RouteId: "main"},
+		statusCode: http.StatusOK,
+		timeout:    10 * time.Second,
+	}, {
+		msg:        "stable cohort",
+		segment:    &routing.TrafficSegment{RouteId: "main", Cohort: "stable"},
+		statusCode: http.StatusOK,
+		timeout:    10 * time.Second,
+	}, {
+		msg:        "canary",
+		segment:    &routing.TrafficSegment{RouteId: "canary", Cohort: "canary"},
+		statusCode: http.StatusOK,
+		timeout:    2 * time.Second,
+	}, {
+		msg:        "other canary",
+		segment:    &routing.TrafficSegment{RouteId: "beta", Cohort: "beta"},
+		statusCode: http.StatusOK,
+		timeout:    2 * time.Second,
+	}, {
+		msg:        "canary route id without cohort",
+		segment:    &routing.TrafficSegment{RouteId: "canary"},
+		statusCode: http.StatusOK,
+		timeout:    2 * time.Second,
+	}, {
+		msg:        "timed out without segment",
+		statusCode: http.StatusGatewayTimeout,
+		timeout:    10 * time.Second,
+		counter:    "cohorttimeout.timeout",
+	}, {
+		msg:        "stable timed out",
+		segment:    &routing.TrafficSegment{RouteId: "main", Cohort: "stable"},
+		statusCode: http.StatusGatewayTimeout,
+		timeout:    10 * time.Second,
+		counter:    "cohorttimeout.stable.timeout",
+	}, {
+		msg:        "canary timed out",
+		segment:    &routing.TrafficSegment{RouteId: "canary", Cohort: "canary"},
+		statusCode: http.StatusGatewayTimeout,
+		timeout:    2 * time.Second,
+		counter:    "cohorttimeout.canary.timeout",
+	}} {
+		t.Run(tt.msg, func(t *testing.T) {
+			m := &metricstest.MockMetrics{}
+			ctx := &filtertest.Context{
+				FRequest:  &http.Request{Header: make(http.Header)},
+				FResponse: &http.Response{StatusCode: tt.statusCode},
+				FMetrics:  m,
+				FStateBag: map[string]interface{}{
+					filters.BackendTimeout: time.Minute,
+				},
+			}
+
+			if tt.segment != nil {
+				ctx.FStateBag[filters.TrafficSegmentKey] = *tt.segment
+			}
+
+			f.Request(ctx)
+			if d := ctx.FStateBag[filters.BackendTimeout]; d != tt.timeout {
+				t.Errorf("expected timeout: %v, got: %v", tt.timeout, d)
+			}
+
+			f.Response(ctx)
+			m.WithCounters(func(c map[string]int64) {
+				if tt.counter == "" && len(c) > 0 {
+					t.Errorf("unexpected counters: %v", c)
+				}
+
+				if tt.counter != "" && c[tt.counter] != 1 {
+					t.Errorf("expected counter %s, got: %v", tt.counter, c)
+				}
+			})
+		})
+	}
+}
@@ -0,0 +1,262 @@
+package builtin
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/zalando/skipper/filters"
+	"github.com/zalando/skipper/metrics"
+	"github.com/zalando/skipper/net"
+	"github.com/zalando/skipper/routing"
+)
+
+const (
+	// DefaultCohortWALSink is the name of the sink configured with the
+	// -cohort-wal-file flag.
+	DefaultCohortWALSink = "default"
+
+	defaultCohortWALBufferSize = 1024
+)
+
+// CohortWALSink is an append-only sink of the cohort assignment records.
+// The records of a sink are appended by a single goroutine, so Append
+// doesn't need to be safe for concurrent use.
+type CohortWALSink interface {
+	Append(record []byte) error
+}
+
+// CohortWALOptions for the cohortWAL filter.
+type CohortWALOptions struct {
+	// Sinks maps the sink names, referenced by the filters, to the sinks.
+	Sinks map[string]CohortWALSink
+
+	// BufferSize is the number of the records buffered per sink, that
+	// were not appended yet. When the buffer is full, the new records are
+	// dropped. Defaults to 1024.
+	BufferSize int
+
+	// Metrics counts the dropped records, and the failed appends, per
+	// sink. Defaults to metrics.Default.
+	Metrics metrics.Metrics
+}
+
+// CohortWALRecord is the record of a cohort assignment appended by the
+// cohortWAL filter.
+type CohortWALRecord struct {
+	Time    time.Time `json:"time"`
+	Key     string    `json:"key,omitempty"`
+	Cohort  string    `json:"cohort"`
+	RouteId string    `json:"routeId"`
+
+	// Random is the random value of the request that was used to match
+	// the traffic segment.
+	Random float64 `json:"random"`
+}
+
+type cohortWALWriter struct {
+	name    string
+	sink    CohortWALSink
+	records chan []byte
+	metrics metrics.Metrics
+	quit    chan struct{}
+	done    chan struct{}
+}
+
+// CohortWALSpec is the specification of the cohortWAL filter.
+type CohortWALSpec struct {
+	writers map[string]*cohortWALWriter
+	once    sync.Once
+}
+
+type cohortWALFilter struct {
+	writer *cohortWALWriter
+	key    *net.RequestSelector
+}
+
+// FileCohortWALSink appends the cohort assignment records to a file, one
+// JSON record per line.
+type FileCohortWALSink struct {
+	file *os.File
+}
+
+// NewFileCohortWALSink opens the file for appending, creating it when it
+// doesn't exist.
+func NewFileCohortWALSink(path string) (*FileCohortWALSink, error) {
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return nil, err
+	}
+
+	return &FileCohortWALSink{file: f}, nil
+}
+
+// Append writes the record to the end of the file, followed by a new line.
+func (s *FileCohortWALSink) Append(record []byte) error {
+	_, err := s.file.Write(append(record, '\n'))
+	return err
+}
+
+// Close closes the file.
+func (s *FileCohortWALSink) Close() error {
+	return s.file.Close()
+}
+
+// NewCohortWAL creates a filter specification whose instances append the
+// cohort assignments of the requests to an append-only sink, e.g. to audit
+// later which clients were assigned to which variants of an experiment.
+//
+// The records are appended asynchronously, without blocking the requests.
+// The records that don't fit the bounded buffer of the sink are dropped,
+// and counted, just like the failed appends. Close stops the writers after
+// appending the buffered records.
+//
+// Example:
+//
+//	canary: TrafficSegment(0.9, 1, "canary") -> cohortWAL("default", "cookie:SID") -> "https://canary.example.org";
+func NewCohortWAL(o CohortWALOptions) *CohortWALSpec {
+	if o.BufferSize <= 0 {
+		o.BufferSize = defaultCohortWALBufferSize
+	}
+
+	if o.Metrics == nil {
+		o.Metrics = metrics.Default
+	}
+
+	s := &CohortWALSpec{writers: make(map[string]*cohortWALWriter)}
+	for name, sink := range o.Sinks {
+		w := &cohortWALWriter{
+			name:    name,
+			sink:    sink,
+			records: make(chan []byte, o.BufferSize),
+			metrics: o.Metrics,
+			quit:    make(chan struct{}),
+			done:    make(chan struct{}),
+		}
+
+		s.writers[name] = w
+		go w.run()
+	}
+
+	return s
+}
+
+func (w *cohortWALWriter) write(r []byte) {
+	if err := w.sink.Append(r); err != nil {
+		log.Debugf("%s: failed to append to sink %s: %v", filters.CohortWALName, w.name, err)
+		w.metrics.IncCounter(fmt.Sprintf("%s.%s.errors", filters.CohortWALName, w.name))
+	}
+}
+
+func (w *cohortWALWriter) run() {
+	defer close(w.done)
+	for {
+		select {
+		case r := <-w.records:
+			w.write(r)
+		case <-w.quit:
+			// append the buffered records before stopping
+			for {
+				select {
+				case r := <-w.records:
+					w.write(r)
+				default:
+					return
+				}
+			}
+		}
+	}
+}
+
+func (w *cohortWALWriter) append(r []byte) {
+	select {
+	case w.records <- r:
+	default:
+		w.metrics.IncCounter(fmt.Sprintf("%s.%s.dropped", filters.CohortWALName, w.name))
+	}
+}
+
+func (*CohortWALSpec) Name() string { return filters.CohortWALName }
+
+// CreateFilter expects the name of the sink, and optionally the request
+// attribute identifying the client, used as the assignment key:
+// "header:<name>", "cookie:<name>" or "query:<name>".
+func (s *CohortWALSpec) CreateFilter(args []interface{}) (filters.Filter, error) {
+	if len(args) == 0 || len(args) > 2 {
+		return nil, filters.ErrInvalidFilterParameters
+	}
+
+	name, ok := args[0].(string)
+	if !ok {
+		return nil, filters.ErrInvalidFilterParameters
+	}
+
+	w, ok := s.writers[name]
+	if !ok {
+		return nil, fmt.Errorf("%s: sink %q is not configured", filters.CohortWALName, name)
+	}
+
+	f := &cohortWALFilter{writer: w}
+	if len(args) == 2 {
+		k, ok := args[1].(string)
+		if !ok {
+			return nil, filters.ErrInvalidFilterParameters
+		}
+
+		key, err := net.ParseRequestSelector(k, "header:", "cookie:", "query:")
+		if err != nil {
+			return nil, fmt.Errorf("%s: invalid assignment key: %w", filters.CohortWALName, err)
+		}
+
+		f.key = &key
+	}
+
+	return f, nil
+}
+
+// Close stops the writers, after appending the buffered records. The
+// records of the filters used after Close are not appended, and the sinks
+// are not closed.
+func (s *CohortWALSpec) Close() {
+	s.once.Do(func() {
+		for _, w := range s.writers {
+			close(w.quit)
+		}
+
+		for _, w := range s.writers {
+			<-w.done
+		}
+	})
+}
+
+func (f *cohortWALFilter) Request(ctx filters.FilterContext) {
+	segment, ok := ctx.StateBag()[filters.TrafficSegmentKey].(routing.TrafficSegment)
+	if !ok || segment.Cohort == "" {
+		return
+	}
+
+	r := CohortWALRecord{
+		Time:    time.Now(),
+		Cohort:  segment.Cohort,
+		RouteId: segment.RouteId,
+		Random:  segment.Random,
+	}
+
+	if f.key != nil {
+		r.Key = f.key.Value(ctx.Request())
+	}
+
+	b, err := json.Marshal(r)
+	if err != nil {
+		ctx.Logger().Errorf("%s: failed to encode record: %v", filters.CohortWALName, err)
+		return
+	}
+
+	f.writer.append(b)
+}
+
+func (*cohortWALFilter) Response(filters.FilterContext) {}
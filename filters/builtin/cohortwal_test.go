@@ -0,0 +1,252 @@
+package builtin
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/zalando/skipper/filters"
+	"github.com/zalando/skipper/filters/filtertest"
+	"github.com/zalando/skipper/metrics/metricstest"
+	"github.com/zalando/skipper/routing"
+)
+
+type memoryWALSink struct {
+	mu      sync.Mutex
+	records []CohortWALRecord
+	block   chan struct{}
+	fail    bool
+}
+
+func (s *memoryWALSink) Append(record []byte) error {
+	if s.block != nil {
+		<-s.block
+	}
+
+	if s.fail {
+		return errors.New("sink failure")
+	}
+
+	var r CohortWALRecord
+	if err := json.Unmarshal(record, &r); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.records = append(s.records, r)
+	return nil
+}
+
+func newCohortWALContext(t *testing.T, segment *routing.TrafficSegment) *filtertest.Context {
+	req, err := http.NewRequest("GET", "https://www.example.org/?user=query-user", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	req.Header.Set("X-User-Id", "header-user")
+	req.AddCookie(&http.Cookie{Name: "SID", Value: "cookie-user"})
+
+	ctx := &filtertest.Context{FRequest: req, FStateBag: make(map[string]interface{})}
+	if segment != nil {
+		ctx.FStateBag[filters.TrafficSegmentKey] = *segment
+	}
+
+	return ctx
+}
+
+func TestCohortWALArgs(t *testing.T) {
+	spec := NewCohortWAL(CohortWALOptions{Sinks: map[string]CohortWALSink{"default": &memoryWALSink{}}})
+	defer spec.Close()
+
+	for _, tt := range []struct {
+		msg  string
+		args []interface{}
+		fail bool
+	}{{
+		msg:  "no args",
+		fail: true,
+	}, {
+		msg:  "sink",
+		args: []interface{}{"default"},
+	}, {
+		msg:  "unknown sink",
+		args: []interface{}{"audit"},
+		fail: true,
+	}, {
+		msg:  "invalid sink type",
+		args: []interface{}{42.0},
+		fail: true,
+	}, {
+		msg:  "sink and key",
+		args: []interface{}{"default", "cookie:SID"},
+	}, {
+		msg:  "invalid key type",
+		args: []interface{}{"default", "body:user"},
+		fail: true,
+	}, {
+		msg:  "key without name",
+		args: []interface{}{"default", "header:"},
+		fail: true,
+	}, {
+		msg:  "too many args",
+		args: []interface{}{"default", "cookie:SID", "header:X-User-Id"},
+		fail: true,
+	}} {
+		t.Run(tt.msg, func(t *testing.T) {
+			_, err := spec.CreateFilter(tt.args)
+			if tt.fail && err == nil {
+				t.Fatal("failed to fail")
+			}
+
+			if !tt.fail && err != nil {
+				t.Fatal(err)
+			}
+		})
+	}
+}
+
+func TestCohortWAL(t *testing.T) {
+	sink := &memoryWALSink{}
+	spec := NewCohortWAL(CohortWALOptions{Sinks: map[string]CohortWALSink{"default": sink}})
+
+	for _, key := range []string{"header:X-User-Id", "cookie:SID", "query:user", ""} {
+		args := []interface{}{"default"}
+		if key != "" {
+			args = append(args, key)
+		}
+
+		f, err := spec.CreateFilter(args)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		f.Request(newCohortWALContext(t, &routing.TrafficSegment{Cohort: "canary", Random: 0.95, RouteId: "canary"}))
+
+		// no records without cohort
+		f.Request(newCohortWALContext(t, &routing.TrafficSegment{Random: 0.5, RouteId: "main"}))
+		f.Request(newCohortWALContext(t, nil))
+	}
+
+	spec.Close()
+
+	expectedKeys := []string{"header-user", "cookie-user", "query-user", ""}
+	if len(sink.records) != len(expectedKeys) {
+		t.Fatalf("unexpected records: %+v", sink.records)
+	}
+
+	for i, r := range sink.records {
+		if r.Key != expectedKeys[i] || r.Cohort != "canary" || r.RouteId != "canary" || r.Random != 0.95 || r.Time.IsZero() {
+			t.Errorf("unexpected record: %+v", r)
+		}
+	}
+}
+
+func TestCohortWALDropsRecords(t *testing.T) {
+	m := &metricstest.MockMetrics{}
+	sink := &memoryWALSink{block: make(chan struct{})}
+	spec := NewCohortWAL(CohortWALOptions{
+		Sinks:      map[string]CohortWALSink{"default": sink},
+		BufferSize: 2,
+		Metrics:    m,
+	})
+
+	f, err := spec.CreateFilter([]interface{}{"default"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the writer blocks on the first record, the next two are buffered, and
+	// the rest is dropped without blocking the requests
+	for i := 0; i < 10; i++ {
+		f.Request(newCohortWALContext(t, &routing.TrafficSegment{Cohort: "canary"}))
+	}
+
+	close(sink.block)
+	spec.Close()
+
+	if len(sink.records) < 2 || len(sink.records) > 3 {
+		t.Errorf("unexpected number of records: %d", len(sink.records))
+	}
+
+	m.WithCounters(func(c map[string]int64) {
+		if dropped := c["cohortWAL.default.dropped"]; dropped+int64(len(sink.records)) != 10 {
+			t.Errorf("unexpected number of dropped records: %d", dropped)
+		}
+	})
+}
+
+func TestCohortWALSinkErrors(t *testing.T) {
+	m := &metricstest.MockMetrics{}
+	spec := NewCohortWAL(CohortWALOptions{
+		Sinks:   map[string]CohortWALSink{"default": &memoryWALSink{fail: true}},
+		Metrics: m,
+	})
+
+	f, err := spec.CreateFilter([]interface{}{"default"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	f.Request(newCohortWALContext(t, &routing.TrafficSegment{Cohort: "canary"}))
+	spec.Close()
+
+	m.WithCounters(func(c map[string]int64) {
+		if errs := c["cohortWAL.default.errors"]; errs != 1 {
+			t.Errorf("unexpected number of errors: %d", errs)
+		}
+	})
+}
+
+func TestFileCohortWALSink(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cohorts.wal")
+	for i := 0; i < 2; i++ {
+		s, err := NewFileCohortWALSink(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		spec := NewCohortWAL(CohortWALOptions{Sinks: map[string]CohortWALSink{"default": s}})
+		f, err := spec.CreateFilter([]interface{}{"default", "cookie:SID"})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		f.Request(newCohortWALContext(t, &routing.TrafficSegment{Cohort: "canary"}))
+		spec.Close()
+		if err := s.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer file.Close()
+
+	var n int
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		var r CohortWALRecord
+		if err := json.Unmarshal(scanner.Bytes(), &r); err != nil {
+			t.Fatal(err)
+		}
+
+		if r.Key != "cookie-user" || r.Cohort != "canary" {
+			t.Errorf("unexpected record: %+v", r)
+		}
+
+		n++
+	}
+
+	if n != 2 {
+		t.Errorf("failed to append the records: %d", n)
+	}
+}
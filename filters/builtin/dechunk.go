@@ -0,0 +1,91 @@
+package builtin
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"strconv"
+
+	"github.com/zalando/skipper/filters"
+	"github.com/zalando/skipper/net"
+)
+
+type dechunkSpec struct{}
+
+type dechunkFilter struct {
+	limit int64
+}
+
+// NewDechunkSmallResponses creates a filter specification whose
+// instances buffer the chunked responses up to the given size limit,
+// and when the complete body fits, they set the Content-Length header,
+// so that the response is sent to the client without the chunked
+// transfer encoding. Larger responses are streamed chunked, as before.
+//
+// Responses that announce trailers are not buffered, because the
+// trailers can be only preserved with the chunked encoding.
+//
+// The limit is either a number of bytes, or a string with one of the
+// units B, KB, MB or GB, based on 1024, e.g. "16KB".
+//
+// Example:
+//
+//	r: * -> dechunkSmallResponses("16KB") -> "https://www.example.org";
+func NewDechunkSmallResponses() filters.Spec { return dechunkSpec{} }
+
+func (dechunkSpec) Name() string { return filters.DechunkSmallResponsesName }
+
+func (dechunkSpec) CreateFilter(args []interface{}) (filters.Filter, error) {
+	if len(args) != 1 {
+		return nil, filters.ErrInvalidFilterParameters
+	}
+
+	var limit int64
+	switch v := args[0].(type) {
+	case int:
+		limit = int64(v)
+	case float64:
+		limit = int64(v)
+	case string:
+		var err error
+		if limit, err = net.ParseByteSize(v); err != nil {
+			return nil, filters.ErrInvalidFilterParameters
+		}
+	default:
+		return nil, filters.ErrInvalidFilterParameters
+	}
+
+	if limit <= 0 {
+		return nil, filters.ErrInvalidFilterParameters
+	}
+
+	return &dechunkFilter{limit: limit}, nil
+}
+
+func (f *dechunkFilter) Request(filters.FilterContext) {}
+
+func (f *dechunkFilter) Response(ctx filters.FilterContext) {
+	rsp := ctx.Response()
+	if rsp.ContentLength >= 0 || rsp.Body == nil || len(rsp.Trailer) > 0 || rsp.Header.Get("Trailer") != "" {
+		return
+	}
+
+	if ctx.Request().Method == http.MethodHead ||
+		rsp.StatusCode < 200 ||
+		rsp.StatusCode == http.StatusNoContent ||
+		rsp.StatusCode == http.StatusNotModified {
+		return
+	}
+
+	body, original, ok := net.BufferBody(rsp.Body, f.limit)
+	if !ok {
+		rsp.Body = original
+		return
+	}
+
+	rsp.Body = io.NopCloser(bytes.NewReader(body))
+	rsp.ContentLength = int64(len(body))
+	rsp.TransferEncoding = nil
+	rsp.Header.Del("Transfer-Encoding")
+	rsp.Header.Set("Content-Length", strconv.Itoa(len(body)))
+}
@@ -0,0 +1,153 @@
+package builtin
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/zalando/skipper/eskip"
+	"github.com/zalando/skipper/proxy/proxytest"
+)
+
+func TestDechunkSmallResponsesArgs(t *testing.T) {
+	for _, tt := range []struct {
+		msg      string
+		args     []interface{}
+		expected int64
+		fail     bool
+	}{{
+		msg:  "no args",
+		fail: true,
+	}, {
+		msg:  "too many args",
+		args: []interface{}{"16KB", "32KB"},
+		fail: true,
+	}, {
+		msg:  "invalid type",
+		args: []interface{}{true},
+		fail: true,
+	}, {
+		msg:  "invalid size",
+		args: []interface{}{"16XB"},
+		fail: true,
+	}, {
+		msg:  "zero",
+		args: []interface{}{0.0},
+		fail: true,
+	}, {
+		msg:  "negative",
+		args: []interface{}{"-1KB"},
+		fail: true,
+	}, {
+		msg:      "bytes as number",
+		args:     []interface{}{512.0},
+		expected: 512,
+	}, {
+		msg:      "bytes",
+		args:     []interface{}{"512B"},
+		expected: 512,
+	}, {
+		msg:      "bytes without unit",
+		args:     []interface{}{"512"},
+		expected: 512,
+	}, {
+		msg:      "kilobytes",
+		args:     []interface{}{"16KB"},
+		expected: 16 << 10,
+	}, {
+		msg:      "megabytes lower case",
+		args:     []interface{}{"2mb"},
+		expected: 2 << 20,
+	}} {
+		t.Run(tt.msg, func(t *testing.T) {
+			f, err := NewDechunkSmallResponses().CreateFilter(tt.args)
+			if tt.fail {
+				if err == nil {
+					t.Error("failed to fail")
+				}
+
+				return
+			}
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if l := f.(*dechunkFilter).limit; l != tt.expected {
+				t.Errorf("expected limit: %d, got: %d", tt.expected, l)
+			}
+		})
+	}
+}
+
+func TestDechunkSmallResponses(t *testing.T) {
+	for _, tt := range []struct {
+		msg             string
+		body            string
+		trailer         bool
+		expectedChunked bool
+	}{{
+		msg:  "small response",
+		body: strings.Repeat("a", 1000),
+	}, {
+		msg:  "at the limit",
+		body: strings.Repeat("a", 1024),
+	}, {
+		msg:             "large response",
+		body:            strings.Repeat("a", 1025),
+		expectedChunked: true,
+	}, {
+		msg:             "trailers announced",
+		body:            strings.Repeat("a", 10),
+		trailer:         true,
+		expectedChunked: true,
+	}} {
+		t.Run(tt.msg, func(t *testing.T) {
+			backend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if tt.trailer {
+					w.Header().Set("Trailer", "X-Checksum")
+				}
+
+				// flushing forces the chunked encoding
+				io.WriteString(w, tt.body[:5])
+				w.(http.Flusher).Flush()
+				io.WriteString(w, tt.body[5:])
+
+				if tt.trailer {
+					w.Header().Set("X-Checksum", "foo")
+				}
+			}))
+			defer backend.Close()
+
+			p := proxytest.New(MakeRegistry(), eskip.MustParse(`* -> dechunkSmallResponses("1KB") -> "`+backend.URL+`"`)...)
+			defer p.Close()
+
+			rsp, err := http.Get(p.URL)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			defer rsp.Body.Close()
+
+			b, err := io.ReadAll(rsp.Body)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if string(b) != tt.body {
+				t.Errorf("invalid body of length %d, expected length: %d", len(b), len(tt.body))
+			}
+
+			chunked := len(rsp.TransferEncoding) == 1 && rsp.TransferEncoding[0] == "chunked"
+			if chunked != tt.expectedChunked {
+				t.Errorf("expected chunked: %v, got transfer encoding: %v", tt.expectedChunked, rsp.TransferEncoding)
+			}
+
+			if !tt.expectedChunked && rsp.ContentLength != int64(len(tt.body)) {
+				t.Errorf("expected content length: %d, got: %d", len(tt.body), rsp.ContentLength)
+			}
+		})
+	}
+}
@@ -0,0 +1,155 @@
+package builtin
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strconv"
+
+	"github.com/zalando/skipper/filters"
+	"github.com/zalando/skipper/routing"
+)
+
+const (
+	errorEnrichCohort  = "cohort"
+	errorEnrichRoute   = "route"
+	errorEnrichSegment = "segment"
+	errorEnrichBody    = "body"
+
+	maxErrorEnrichBody = 64 << 10
+)
+
+type errorEnrichSpec struct{}
+
+type errorEnrichFilter struct {
+	cohort, route, segment, body bool
+}
+
+type errorEnrichSegmentInterval struct {
+	Min float64 `json:"min"`
+	Max float64 `json:"max"`
+}
+
+type errorEnrichment struct {
+	Status        int                         `json:"status"`
+	Cohort        string                      `json:"cohort,omitempty"`
+	RouteId       string                      `json:"routeId,omitempty"`
+	Segment       *errorEnrichSegmentInterval `json:"segment,omitempty"`
+	Body          *string                     `json:"body,omitempty"`
+	BodyTruncated bool                        `json:"bodyTruncated,omitempty"`
+}
+
+// NewErrorEnrich creates a filter specification whose instances wrap the
+// error responses, with status 400 or above, of the cohort traffic into
+// a JSON document containing the status, the cohort, the route id and the
+// segment interval, as provided by the TrafficSegment predicate, and the
+// original body. The cohort is the one of the traffic segment of the route,
+// or the route id, when the segment has no cohort. The arguments can select
+// what to include, out of "cohort", "route", "segment" and "body". Without
+// arguments, all of them are included. Responses of requests without a
+// traffic segment are not changed.
+//
+// The filter exposes internals of the routing and it is meant for test
+// environments, therefore it is not registered by default.
+//
+// Example:
+//
+//	canary: TrafficSegment(0.9, 1, "canary") -> errorEnrich("cohort", "route") -> "https://canary.example.org";
+func NewErrorEnrich() filters.Spec { return errorEnrichSpec{} }
+
+func (errorEnrichSpec) Name() string { return filters.ErrorEnrichName }
+
+func (errorEnrichSpec) CreateFilter(args []interface{}) (filters.Filter, error) {
+	if len(args) == 0 {
+		return &errorEnrichFilter{cohort: true, route: true, segment: true, body: true}, nil
+	}
+
+	f := &errorEnrichFilter{}
+	for _, a := range args {
+		s, ok := a.(string)
+		if !ok {
+			return nil, filters.ErrInvalidFilterParameters
+		}
+
+		switch s {
+		case errorEnrichCohort:
+			f.cohort = true
+		case errorEnrichRoute:
+			f.route = true
+		case errorEnrichSegment:
+			f.segment = true
+		case errorEnrichBody:
+			f.body = true
+		default:
+			return nil, filters.ErrInvalidFilterParameters
+		}
+	}
+
+	return f, nil
+}
+
+func (*errorEnrichFilter) Request(filters.FilterContext) {}
+
+func (f *errorEnrichFilter) Response(ctx filters.FilterContext) {
+	rsp := ctx.Response()
+	if rsp.StatusCode < http.StatusBadRequest {
+		return
+	}
+
+	cohort, ok := segmentCohort(ctx)
+	if !ok {
+		return
+	}
+
+	segment := ctx.StateBag()[filters.TrafficSegmentKey].(routing.TrafficSegment)
+	e := errorEnrichment{Status: rsp.StatusCode}
+	if f.cohort {
+		e.Cohort = cohort
+	}
+
+	if f.route {
+		e.RouteId = segment.RouteId
+	}
+
+	if f.segment {
+		e.Segment = &errorEnrichSegmentInterval{Min: segment.Min, Max: segment.Max}
+	}
+
+	// encoded bodies are not included
+	if f.body && rsp.Body != nil && rsp.Header.Get("Content-Encoding") == "" {
+		b, err := io.ReadAll(io.LimitReader(rsp.Body, maxErrorEnrichBody+1))
+		if err != nil {
+			ctx.Logger().Errorf("%s: failed to read the response body: %v", filters.ErrorEnrichName, err)
+			return
+		}
+
+		if len(b) > maxErrorEnrichBody {
+			b = b[:maxErrorEnrichBody]
+			e.BodyTruncated = true
+		}
+
+		s := string(b)
+		e.Body = &s
+	}
+
+	b, err := json.Marshal(e)
+	if err != nil {
+		ctx.Logger().Errorf("%s: failed to encode the error: %v", filters.ErrorEnrichName, err)
+		return
+	}
+
+	if rsp.Body != nil {
+		rsp.Body.Close()
+	}
+
+	if rsp.Header == nil {
+		rsp.Header = make(http.Header)
+	}
+
+	rsp.Body = io.NopCloser(bytes.NewReader(b))
+	rsp.ContentLength = int64(len(b))
+	rsp.Header.Del("Content-Encoding")
+	rsp.Header.Set("Content-Type", "application/json")
+	rsp.Header.Set("Content-Length", strconv.Itoa(len(b)))
+}
@@ -0,0 +1,163 @@
+package builtin
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/zalando/skipper/filters"
+	"github.com/zalando/skipper/filters/filtertest"
+	"github.com/zalando/skipper/routing"
+)
+
+func TestErrorEnrichCreate(t *testing.T) {
+	for _, tt := range []struct {
+		msg  string
+		args []interface{}
+		err  bool
+	}{{
+		msg: "no args",
+	}, {
+		msg:  "selected fields",
+		args: []interface{}{"cohort", "route"},
+	}, {
+		msg:  "all fields",
+		args: []interface{}{"cohort", "route", "segment", "body"},
+	}, {
+		msg:  "unknown field",
+		args: []interface{}{"cohort", "foo"},
+		err:  true,
+	}, {
+		msg:  "not a string",
+		args: []interface{}{42.0},
+		err:  true,
+	}} {
+		t.Run(tt.msg, func(t *testing.T) {
+			_, err := NewErrorEnrich().CreateFilter(tt.args)
+			if tt.err && err == nil {
+				t.Error("expected error")
+			} else if !tt.err && err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+		})
+	}
+}
+
+func TestErrorEnrich(t *testing.T) {
+	canary := routing.TrafficSegment{Min: 0.9, Max: 1, Cohort: "canary", Random: 0.95, RouteId: "canaryRoute"}
+	for _, tt := range []struct {
+		msg      string
+		args     []interface{}
+		status   int
+		segment  *routing.TrafficSegment
+		header   http.Header
+		body     string
+		expected string
+	}{{
+		msg:      "success not changed",
+		status:   http.StatusOK,
+		segment:  &canary,
+		body:     "OK",
+		expected: "OK",
+	}, {
+		msg:      "no segment",
+		status:   http.StatusBadGateway,
+		body:     "error",
+		expected: "error",
+	}, {
+		msg:      "no cohort",
+		args:     []interface{}{"cohort", "route"},
+		status:   http.StatusBadGateway,
+		segment:  &routing.TrafficSegment{Min: 0, Max: 0.9, RouteId: "stable"},
+		body:     "error",
+		expected: `{"status":502,"cohort":"stable","routeId":"stable"}`,
+	}, {
+		msg:      "all fields",
+		status:   http.StatusBadGateway,
+		segment:  &canary,
+		body:     "error",
+		expected: `{"status":502,"cohort":"canary","routeId":"canaryRoute","segment":{"min":0.9,"max":1},"body":"error"}`,
+	}, {
+		msg:      "selected fields",
+		args:     []interface{}{"cohort", "route"},
+		status:   http.StatusNotFound,
+		segment:  &canary,
+		body:     "error",
+		expected: `{"status":404,"cohort":"canary","routeId":"canaryRoute"}`,
+	}, {
+		msg:      "encoded body not included",
+		status:   http.StatusInternalServerError,
+		segment:  &canary,
+		header:   http.Header{"Content-Encoding": []string{"gzip"}},
+		body:     "\x1f\x8b",
+		expected: `{"status":500,"cohort":"canary","routeId":"canaryRoute","segment":{"min":0.9,"max":1}}`,
+	}} {
+		t.Run(tt.msg, func(t *testing.T) {
+			f, err := NewErrorEnrich().CreateFilter(tt.args)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			rsp := &http.Response{
+				StatusCode: tt.status,
+				Header:     http.Header{"Content-Type": []string{"text/plain"}},
+				Body:       io.NopCloser(strings.NewReader(tt.body)),
+			}
+
+			for k, v := range tt.header {
+				rsp.Header[k] = v
+			}
+
+			ctx := &filtertest.Context{FResponse: rsp, FStateBag: make(map[string]interface{})}
+			if tt.segment != nil {
+				ctx.FStateBag[filters.TrafficSegmentKey] = *tt.segment
+			}
+
+			f.Response(ctx)
+
+			b, err := io.ReadAll(rsp.Body)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if string(b) != tt.expected {
+				t.Errorf("expected body: %s, got: %s", tt.expected, b)
+			}
+
+			if tt.expected != tt.body && (rsp.Header.Get("Content-Type") != "application/json" || rsp.Header.Get("Content-Encoding") != "") {
+				t.Errorf("unexpected headers: %v", rsp.Header)
+			}
+		})
+	}
+}
+
+func TestErrorEnrichTruncatesBody(t *testing.T) {
+	f, err := NewErrorEnrich().CreateFilter([]interface{}{"body"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rsp := &http.Response{
+		StatusCode: http.StatusServiceUnavailable,
+		Header:     make(http.Header),
+		Body:       io.NopCloser(strings.NewReader(strings.Repeat("x", maxErrorEnrichBody+1))),
+	}
+
+	ctx := &filtertest.Context{
+		FResponse: rsp,
+		FStateBag: map[string]interface{}{filters.TrafficSegmentKey: routing.TrafficSegment{Cohort: "canary"}},
+	}
+
+	f.Response(ctx)
+
+	var e errorEnrichment
+	if err := json.NewDecoder(rsp.Body).Decode(&e); err != nil {
+		t.Fatal(err)
+	}
+
+	if !e.BodyTruncated || e.Body == nil || len(*e.Body) != maxErrorEnrichBody {
+		t.Error("failed to truncate the body")
+	}
+}
@@ -0,0 +1,274 @@
+package builtin
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/opentracing/opentracing-go"
+	"github.com/opentracing/opentracing-go/ext"
+
+	"github.com/zalando/skipper/filters"
+	"github.com/zalando/skipper/net"
+)
+
+const (
+	failoverReconcileStateKey       = "filter:failoverreconcile"
+	defaultFailoverReconcileMaxBody = 1 << 20
+	failoverReconcileTimeout        = 10 * time.Second
+)
+
+// the hop-by-hop headers, not forwarded to the stable backend
+var failoverHopHeaders = []string{
+	"Connection",
+	"Proxy-Connection",
+	"Keep-Alive",
+	"Proxy-Authenticate",
+	"Proxy-Authorization",
+	"Te",
+	"Trailer",
+	"Transfer-Encoding",
+	"Upgrade",
+}
+
+// FailoverReconcileSpec is the filter specification of failoverReconcile.
+type FailoverReconcileSpec struct {
+	client *http.Client
+}
+
+type failoverReconcileFilter struct {
+	client  *http.Client
+	stable  *url.URL
+	methods map[string]bool
+	maxBody int64
+}
+
+// NewFailoverReconcile creates a filter specification whose instances hide
+// the failures of a canary backend: when the backend of the route responds
+// with a 5xx status, or can't be reached, the request is replayed against
+// the stable backend, and its response is returned to the client instead.
+//
+// Only the requests with a method in the idempotency scope are replayed:
+// "safe" allows GET, HEAD, OPTIONS and TRACE, and "idempotent", the
+// default, also PUT and DELETE. To be able to replay it, the request body
+// is buffered before sending the request to the canary, up to the
+// optional maximum size, by default 1MB. The requests with a larger body
+// are not replayed.
+//
+// The replayed requests are counted with the
+// failoverReconcile.<cohort>.failover custom counter, and the failed
+// replays with failoverReconcile.<cohort>.failed, where the cohort is the
+// one of the traffic segment of the route, or the route id, when it has no
+// cohort.
+//
+// The replayed requests are sent with the round tripper of the proxy, set
+// with SetTransport, and traced as children of the proxy span.
+//
+// Example:
+//
+//	canary: Path("/") && TrafficSegment(0.9, 1, "canary")
+//	  -> failoverReconcile("https://stable.example.org", "safe")
+//	  -> "https://canary.example.org";
+func NewFailoverReconcile() *FailoverReconcileSpec {
+	return &FailoverReconcileSpec{
+		client: &http.Client{
+			Timeout: failoverReconcileTimeout,
+			CheckRedirect: func(*http.Request, []*http.Request) error {
+				return http.ErrUseLastResponse
+			},
+		},
+	}
+}
+
+// SetTransport sets the round tripper used to replay the requests, by
+// default http.DefaultTransport. It needs to be called before the filters
+// handle requests.
+func (s *FailoverReconcileSpec) SetTransport(rt http.RoundTripper) {
+	s.client.Transport = rt
+}
+
+func (*FailoverReconcileSpec) Name() string { return filters.FailoverReconcileName }
+
+func idempotencyScope(scope string) (map[string]bool, error) {
+	methods := map[string]bool{
+		http.MethodGet:     true,
+		http.MethodHead:    true,
+		http.MethodOptions: true,
+		http.MethodTrace:   true,
+	}
+
+	switch scope {
+	case "safe":
+	case "idempotent":
+		methods[http.MethodPut] = true
+		methods[http.MethodDelete] = true
+	default:
+		return nil, fmt.Errorf("%s: invalid idempotency scope %q, expected \"safe\" or \"idempotent\"", filters.FailoverReconcileName, scope)
+	}
+
+	return methods, nil
+}
+
+func (s *FailoverReconcileSpec) CreateFilter(args []interface{}) (filters.Filter, error) {
+	if len(args) < 1 || len(args) > 3 {
+		return nil, filters.ErrInvalidFilterParameters
+	}
+
+	backend, ok := args[0].(string)
+	if !ok {
+		return nil, filters.ErrInvalidFilterParameters
+	}
+
+	stable, err := url.Parse(backend)
+	if err != nil || (stable.Scheme != "http" && stable.Scheme != "https") || stable.Host == "" {
+		return nil, fmt.Errorf("%s: invalid stable backend %q", filters.FailoverReconcileName, backend)
+	}
+
+	scope := "idempotent"
+	if len(args) > 1 {
+		if scope, ok = args[1].(string); !ok {
+			return nil, filters.ErrInvalidFilterParameters
+		}
+	}
+
+	methods, err := idempotencyScope(scope)
+	if err != nil {
+		return nil, err
+	}
+
+	f := &failoverReconcileFilter{
+		client:  s.client,
+		stable:  stable,
+		methods: methods,
+		maxBody: defaultFailoverReconcileMaxBody,
+	}
+
+	if len(args) > 2 {
+		switch v := args[2].(type) {
+		case int:
+			f.maxBody = int64(v)
+		case float64:
+			f.maxBody = int64(v)
+		default:
+			return nil, filters.ErrInvalidFilterParameters
+		}
+
+		if f.maxBody <= 0 {
+			return nil, filters.ErrInvalidFilterParameters
+		}
+	}
+
+	return f, nil
+}
+
+// bufferBody buffers the request body, and tells whether it fits the
+// limit. When it doesn't, the body is restored unbuffered.
+func (f *failoverReconcileFilter) bufferBody(req *http.Request) ([]byte, bool) {
+	if req.Body == nil || req.Body == http.NoBody {
+		return nil, true
+	}
+
+	if req.ContentLength > f.maxBody {
+		return nil, false
+	}
+
+	body, original, ok := net.BufferBody(req.Body, f.maxBody)
+	if !ok {
+		req.Body = original
+		return nil, false
+	}
+
+	req.Body = io.NopCloser(bytes.NewReader(body))
+	return body, true
+}
+
+func (f *failoverReconcileFilter) Request(ctx filters.FilterContext) {
+	req := ctx.Request()
+	if !f.methods[req.Method] {
+		return
+	}
+
+	if body, ok := f.bufferBody(req); ok {
+		ctx.StateBag()[failoverReconcileStateKey] = body
+	}
+}
+
+func (f *failoverReconcileFilter) replay(ctx filters.FilterContext, body []byte) (*http.Response, error) {
+	req := ctx.Request()
+	u := *req.URL
+	u.Scheme = f.stable.Scheme
+	u.Host = f.stable.Host
+
+	sr, err := http.NewRequestWithContext(req.Context(), req.Method, u.String(), bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+
+	sr.Header = req.Header.Clone()
+	for _, h := range failoverHopHeaders {
+		sr.Header.Del(h)
+	}
+
+	if parent := ctx.ParentSpan(); parent != nil {
+		span := ctx.Tracer().StartSpan(filters.FailoverReconcileName, opentracing.ChildOf(parent.Context()))
+		defer span.Finish()
+
+		ext.SpanKindRPCClient.Set(span)
+		ext.HTTPMethod.Set(span, sr.Method)
+		ext.HTTPUrl.Set(span, u.String())
+		_ = ctx.Tracer().Inject(span.Context(), opentracing.HTTPHeaders, opentracing.HTTPHeadersCarrier(sr.Header))
+	}
+
+	return f.client.Do(sr)
+}
+
+func (f *failoverReconcileFilter) Response(ctx filters.FilterContext) {
+	body, ok := ctx.StateBag()[failoverReconcileStateKey].([]byte)
+	if !ok {
+		return
+	}
+
+	delete(ctx.StateBag(), failoverReconcileStateKey)
+	rsp := ctx.Response()
+	if rsp.StatusCode < http.StatusInternalServerError {
+		return
+	}
+
+	key := filters.FailoverReconcileName
+	if cohort, ok := segmentCohort(ctx); ok {
+		key += "." + cohort
+	}
+
+	ctx.Metrics().IncCounter(key + ".failover")
+	stable, err := f.replay(ctx, body)
+	if err != nil {
+		ctx.Metrics().IncCounter(key + ".failed")
+		ctx.Logger().Errorf("%s: failed to replay the request against %s: %v", filters.FailoverReconcileName, f.stable.Host, err)
+		return
+	}
+
+	ctx.Logger().Infof(
+		"%s: replayed the request against %s after status %d, got status %d",
+		filters.FailoverReconcileName,
+		f.stable.Host,
+		rsp.StatusCode,
+		stable.StatusCode,
+	)
+
+	if rsp.Body != nil {
+		rsp.Body.Close()
+	}
+
+	rsp.StatusCode = stable.StatusCode
+	rsp.Status = stable.Status
+	rsp.Header = stable.Header
+	rsp.ContentLength = stable.ContentLength
+	rsp.Body = stable.Body
+}
+
+// HandleErrorResponse is to opt-in for filters to get called
+// Response(ctx) in case of errors via proxy. It has to return true to opt-in.
+func (*failoverReconcileFilter) HandleErrorResponse() bool { return true }
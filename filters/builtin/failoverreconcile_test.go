@@ -0,0 +1,331 @@
+package builtin
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/zalando/skipper/eskip"
+	"github.com/zalando/skipper/filters"
+	"github.com/zalando/skipper/filters/filtertest"
+	"github.com/zalando/skipper/metrics/metricstest"
+	"github.com/zalando/skipper/proxy/proxytest"
+	"github.com/zalando/skipper/routing"
+)
+
+func TestFailoverReconcileCreate(t *testing.T) {
+	for _, tt := range []struct {
+		msg  string
+		args []interface{}
+		err  bool
+	}{{
+		msg: "no arguments",
+		err: true,
+	}, {
+		msg:  "not a string",
+		args: []interface{}{42.0},
+		err:  true,
+	}, {
+		msg:  "invalid backend",
+		args: []interface{}{"stable.example.org"},
+		err:  true,
+	}, {
+		msg:  "invalid scheme",
+		args: []interface{}{"ftp://stable.example.org"},
+		err:  true,
+	}, {
+		msg:  "invalid scope",
+		args: []interface{}{"https://stable.example.org", "all"},
+		err:  true,
+	}, {
+		msg:  "scope not a string",
+		args: []interface{}{"https://stable.example.org", 1.0},
+		err:  true,
+	}, {
+		msg:  "invalid max body",
+		args: []interface{}{"https://stable.example.org", "safe", 0.0},
+		err:  true,
+	}, {
+		msg:  "too many arguments",
+		args: []interface{}{"https://stable.example.org", "safe", 1024.0, 1.0},
+		err:  true,
+	}, {
+		msg:  "backend",
+		args: []interface{}{"https://stable.example.org"},
+	}, {
+		msg:  "safe scope",
+		args: []interface{}{"https://stable.example.org", "safe"},
+	}, {
+		msg:  "idempotent scope and max body",
+		args: []interface{}{"http://stable.example.org:9090", "idempotent", 1024},
+	}} {
+		t.Run(tt.msg, func(t *testing.T) {
+			_, err := NewFailoverReconcile().CreateFilter(tt.args)
+			if tt.err && err == nil {
+				t.Error("expected error")
+			} else if !tt.err && err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+		})
+	}
+}
+
+// stableRequest records the request received by the stable backend.
+type stableRequest struct {
+	method, uri, body, header string
+}
+
+func newStableBackend(t *testing.T, status int) (*httptest.Server, <-chan stableRequest) {
+	requests := make(chan stableRequest, 1)
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, err := io.ReadAll(r.Body)
+		if err != nil {
+			t.Error(err)
+		}
+
+		requests <- stableRequest{method: r.Method, uri: r.RequestURI, body: string(b), header: r.Header.Get("X-Test")}
+		w.Header().Set("X-Backend", "stable")
+		w.WriteHeader(status)
+		fmt.Fprint(w, "stable")
+	}))
+
+	return s, requests
+}
+
+func TestFailoverReconcile(t *testing.T) {
+	stable, requests := newStableBackend(t, http.StatusOK)
+	defer stable.Close()
+
+	for _, tt := range []struct {
+		msg        string
+		scope      string
+		method     string
+		body       string
+		status     int
+		failover   bool
+		stableBody string
+	}{{
+		msg:    "success",
+		method: "GET",
+		status: http.StatusOK,
+	}, {
+		msg:    "client error",
+		method: "GET",
+		status: http.StatusNotFound,
+	}, {
+		msg:      "server error",
+		method:   "GET",
+		status:   http.StatusInternalServerError,
+		failover: true,
+	}, {
+		msg:      "gateway error",
+		method:   "GET",
+		status:   http.StatusBadGateway,
+		failover: true,
+	}, {
+		msg:      "idempotent write",
+		method:   "PUT",
+		body:     `{"name": "foo"}`,
+		status:   http.StatusServiceUnavailable,
+		failover: true,
+	}, {
+		msg:    "idempotent write in safe scope",
+		scope:  "safe",
+		method: "PUT",
+		body:   `{"name": "foo"}`,
+		status: http.StatusServiceUnavailable,
+	}, {
+		msg:    "non-idempotent write",
+		method: "POST",
+		body:   `{"name": "foo"}`,
+		status: http.StatusInternalServerError,
+	}, {
+		msg:    "body too large",
+		method: "PUT",
+		body:   strings.Repeat("x", 65),
+		status: http.StatusInternalServerError,
+	}} {
+		t.Run(tt.msg, func(t *testing.T) {
+			args := []interface{}{stable.URL, "idempotent", 64.0}
+			if tt.scope != "" {
+				args[1] = tt.scope
+			}
+
+			f, err := NewFailoverReconcile().CreateFilter(args)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			req, err := http.NewRequest(tt.method, "https://www.example.org/items/1?lang=en", strings.NewReader(tt.body))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			req.Header.Set("X-Test", "foo")
+			req.Header.Set("Connection", "close")
+			m := &metricstest.MockMetrics{}
+			ctx := &filtertest.Context{
+				FRequest: req,
+				FMetrics: m,
+				FStateBag: map[string]interface{}{
+					filters.TrafficSegmentKey: routing.TrafficSegment{RouteId: "canary_route", Cohort: "canary"},
+				},
+			}
+
+			f.Request(ctx)
+
+			// the canary consumes the request body
+			b, err := io.ReadAll(req.Body)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if string(b) != tt.body {
+				t.Fatalf("failed to preserve the request body: %s", b)
+			}
+
+			ctx.FResponse = &http.Response{
+				StatusCode: tt.status,
+				Header:     http.Header{"X-Backend": []string{"canary"}},
+				Body:       io.NopCloser(strings.NewReader("canary")),
+			}
+
+			f.Response(ctx)
+
+			var failovers int64
+			m.WithCounters(func(c map[string]int64) { failovers = c["failoverReconcile.canary.failover"] })
+
+			rsp := ctx.Response()
+			body, err := io.ReadAll(rsp.Body)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if !tt.failover {
+				if failovers != 0 || rsp.StatusCode != tt.status || string(body) != "canary" {
+					t.Errorf("unexpected failover, status: %d, body: %s", rsp.StatusCode, body)
+				}
+
+				return
+			}
+
+			if failovers != 1 {
+				t.Errorf("failed to count the failover: %d", failovers)
+			}
+
+			if rsp.StatusCode != http.StatusOK || rsp.Header.Get("X-Backend") != "stable" || string(body) != "stable" {
+				t.Errorf("failed to return the stable response, status: %d, body: %s", rsp.StatusCode, body)
+			}
+
+			sr := <-requests
+			expected := stableRequest{method: tt.method, uri: "/items/1?lang=en", body: tt.body, header: "foo"}
+			if sr != expected {
+				t.Errorf("unexpected stable request, expected: %+v, got: %+v", expected, sr)
+			}
+		})
+	}
+}
+
+func TestFailoverReconcileStableFails(t *testing.T) {
+	stable := httptest.NewServer(http.NotFoundHandler())
+	stable.Close()
+
+	f, err := NewFailoverReconcile().CreateFilter([]interface{}{stable.URL})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	req, err := http.NewRequest("GET", "https://www.example.org/items", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	m := &metricstest.MockMetrics{}
+	ctx := &filtertest.Context{FRequest: req, FMetrics: m, FStateBag: make(map[string]interface{})}
+	f.Request(ctx)
+
+	ctx.FResponse = &http.Response{StatusCode: http.StatusInternalServerError, Body: io.NopCloser(strings.NewReader("canary"))}
+	f.Response(ctx)
+
+	if ctx.Response().StatusCode != http.StatusInternalServerError {
+		t.Errorf("failed to keep the canary response: %d", ctx.Response().StatusCode)
+	}
+
+	m.WithCounters(func(c map[string]int64) {
+		if c["failoverReconcile.failover"] != 1 || c["failoverReconcile.failed"] != 1 {
+			t.Errorf("failed to count the failed failover: %v", c)
+		}
+	})
+}
+
+type roundTripperFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripperFunc) RoundTrip(r *http.Request) (*http.Response, error) { return f(r) }
+
+func TestFailoverReconcileProxy(t *testing.T) {
+	stable, requests := newStableBackend(t, http.StatusOK)
+	defer stable.Close()
+
+	canary := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.Copy(io.Discard, r.Body)
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer canary.Close()
+
+	unreachable := httptest.NewServer(http.NotFoundHandler())
+	unreachable.Close()
+
+	routes := eskip.MustParse(fmt.Sprintf(`
+		canary: Path("/canary") -> failoverReconcile("%[1]s") -> "%[2]s";
+		down: Path("/down") -> failoverReconcile("%[1]s") -> "%[3]s";
+	`, stable.URL, canary.URL, unreachable.URL))
+
+	var replayed int32
+	spec := NewFailoverReconcile()
+	spec.SetTransport(roundTripperFunc(func(r *http.Request) (*http.Response, error) {
+		atomic.AddInt32(&replayed, 1)
+		return http.DefaultTransport.RoundTrip(r)
+	}))
+
+	fr := MakeRegistry()
+	fr.Register(spec)
+
+	p := proxytest.New(fr, routes...)
+	defer p.Close()
+
+	for _, path := range []string{"/canary", "/down"} {
+		t.Run(path, func(t *testing.T) {
+			req, err := http.NewRequest("PUT", p.URL+path, strings.NewReader("payload"))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			rsp, err := http.DefaultClient.Do(req)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			defer rsp.Body.Close()
+			body, err := io.ReadAll(rsp.Body)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if rsp.StatusCode != http.StatusOK || string(body) != "stable" {
+				t.Errorf("failed to return the stable response, status: %d, body: %s", rsp.StatusCode, body)
+			}
+
+			if sr := <-requests; sr.uri != path || sr.body != "payload" {
+				t.Errorf("unexpected stable request: %+v", sr)
+			}
+		})
+	}
+
+	if n := atomic.LoadInt32(&replayed); n != 2 {
+		t.Errorf("failed to replay with the transport, got %d requests", n)
+	}
+}
@@ -0,0 +1,106 @@
+package builtin
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/zalando/skipper/filters"
+)
+
+const (
+	handle100ContinueStrip = "strip"
+	handle100ContinueAuto  = "auto"
+
+	defaultHandle100ContinueMaxBytes = 1 << 20
+)
+
+type handle100ContinueSpec struct{}
+
+type handle100ContinueFilter struct {
+	strip    bool
+	maxBytes int64
+}
+
+// NewHandle100Continue creates a filter specification whose instances
+// change how the Expect: 100-continue requests are handled, e.g. when the
+// backends don't respond with 100 Continue, and the clients wait for it
+// until their timeout.
+//
+// The Skipper server itself responds with 100 Continue to the client when
+// the proxy starts reading the request body. When the Expect header is
+// removed from the outgoing request, the proxy reads and forwards the body
+// without waiting for the confirmation of the backend, so the 100 Continue
+// is sent by the edge.
+//
+// In "strip" mode, the Expect header is always removed. In "auto" mode, it
+// is removed only when the Content-Length of the request is known and it
+// doesn't exceed the maximum size, otherwise the header is forwarded, so
+// that the large uploads still wait for the confirmation of the backend,
+// and the backend can reject them before the body is sent.
+//
+// Example:
+//
+//	handle100Continue("auto", "4MB")
+func NewHandle100Continue() filters.Spec { return handle100ContinueSpec{} }
+
+func (handle100ContinueSpec) Name() string { return filters.Handle100ContinueName }
+
+// CreateFilter expects the mode, "strip" or "auto", and in "auto" mode an
+// optional maximum size, either as a number of bytes, or as a string with a
+// unit, e.g. "4MB". The default maximum size is 1MB.
+func (handle100ContinueSpec) CreateFilter(args []interface{}) (filters.Filter, error) {
+	if len(args) == 0 || len(args) > 2 {
+		return nil, filters.ErrInvalidFilterParameters
+	}
+
+	mode, ok := args[0].(string)
+	if !ok {
+		return nil, filters.ErrInvalidFilterParameters
+	}
+
+	switch mode {
+	case handle100ContinueStrip:
+		if len(args) != 1 {
+			return nil, filters.ErrInvalidFilterParameters
+		}
+
+		return &handle100ContinueFilter{strip: true}, nil
+	case handle100ContinueAuto:
+		maxBytes, ok := bodyTransformMaxBytesArg(args[1:])
+		if !ok {
+			return nil, filters.ErrInvalidFilterParameters
+		}
+
+		if len(args) == 1 {
+			maxBytes = defaultHandle100ContinueMaxBytes
+		}
+
+		return &handle100ContinueFilter{maxBytes: maxBytes}, nil
+	default:
+		return nil, filters.ErrInvalidFilterParameters
+	}
+}
+
+func expects100Continue(r *http.Request) bool {
+	for _, v := range r.Header.Values("Expect") {
+		if strings.EqualFold(strings.TrimSpace(v), "100-continue") {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (f *handle100ContinueFilter) Request(ctx filters.FilterContext) {
+	r := ctx.Request()
+	if r.ProtoMajor != 1 || !expects100Continue(r) {
+		return
+	}
+
+	// when the length is unknown, ContentLength is -1
+	if f.strip || r.ContentLength >= 0 && r.ContentLength <= f.maxBytes {
+		r.Header.Del("Expect")
+	}
+}
+
+func (*handle100ContinueFilter) Response(filters.FilterContext) {}
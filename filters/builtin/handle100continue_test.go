@@ -0,0 +1,235 @@
+package builtin
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/zalando/skipper/eskip"
+	"github.com/zalando/skipper/filters"
+	"github.com/zalando/skipper/filters/filtertest"
+	"github.com/zalando/skipper/proxy/proxytest"
+)
+
+func TestHandle100ContinueArgs(t *testing.T) {
+	for _, tt := range []struct {
+		msg      string
+		args     []interface{}
+		strip    bool
+		expected int64
+		fail     bool
+	}{{
+		msg:  "no args",
+		fail: true,
+	}, {
+		msg:  "invalid mode",
+		args: []interface{}{"always"},
+		fail: true,
+	}, {
+		msg:  "invalid mode type",
+		args: []interface{}{42.0},
+		fail: true,
+	}, {
+		msg:   "strip",
+		args:  []interface{}{"strip"},
+		strip: true,
+	}, {
+		msg:  "strip with size",
+		args: []interface{}{"strip", "1MB"},
+		fail: true,
+	}, {
+		msg:      "auto with default size",
+		args:     []interface{}{"auto"},
+		expected: 1 << 20,
+	}, {
+		msg:      "auto with size",
+		args:     []interface{}{"auto", "4MB"},
+		expected: 4 << 20,
+	}, {
+		msg:      "auto with size as number",
+		args:     []interface{}{"auto", 512.0},
+		expected: 512,
+	}, {
+		msg:  "auto with invalid size",
+		args: []interface{}{"auto", "4XB"},
+		fail: true,
+	}, {
+		msg:  "auto with zero size",
+		args: []interface{}{"auto", 0.0},
+		fail: true,
+	}, {
+		msg:  "too many args",
+		args: []interface{}{"auto", "4MB", "4MB"},
+		fail: true,
+	}} {
+		t.Run(tt.msg, func(t *testing.T) {
+			f, err := NewHandle100Continue().CreateFilter(tt.args)
+			if tt.fail {
+				if err == nil {
+					t.Error("failed to fail")
+				}
+
+				return
+			}
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			hf := f.(*handle100ContinueFilter)
+			if hf.strip != tt.strip || hf.maxBytes != tt.expected {
+				t.Errorf("unexpected filter: %+v", hf)
+			}
+		})
+	}
+}
+
+func TestHandle100ContinueRequest(t *testing.T) {
+	for _, tt := range []struct {
+		msg           string
+		args          []interface{}
+		expect        string
+		contentLength int64
+		forwarded     bool
+	}{{
+		msg:           "strip small request",
+		args:          []interface{}{"strip"},
+		expect:        "100-continue",
+		contentLength: 42,
+	}, {
+		msg:           "strip large request",
+		args:          []interface{}{"strip"},
+		expect:        "100-continue",
+		contentLength: 1 << 30,
+	}, {
+		msg:           "strip unknown length",
+		args:          []interface{}{"strip"},
+		expect:        "100-Continue",
+		contentLength: -1,
+	}, {
+		msg:           "auto small request",
+		args:          []interface{}{"auto", "1KB"},
+		expect:        "100-continue",
+		contentLength: 1 << 10,
+	}, {
+		msg:           "auto large request",
+		args:          []interface{}{"auto", "1KB"},
+		expect:        "100-continue",
+		contentLength: 1<<10 + 1,
+		forwarded:     true,
+	}, {
+		msg:           "auto unknown length",
+		args:          []interface{}{"auto", "1KB"},
+		expect:        "100-continue",
+		contentLength: -1,
+		forwarded:     true,
+	}, {
+		msg:           "other expectation",
+		args:          []interface{}{"strip"},
+		expect:        "something-else",
+		contentLength: 42,
+		forwarded:     true,
+	}} {
+		t.Run(tt.msg, func(t *testing.T) {
+			f, err := NewHandle100Continue().CreateFilter(tt.args)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			req, err := http.NewRequest("POST", "https://www.example.org/upload", nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			req.Header.Set("Expect", tt.expect)
+			req.ContentLength = tt.contentLength
+
+			f.Request(&filtertest.Context{FRequest: req})
+			if forwarded := req.Header.Get("Expect") == tt.expect; forwarded != tt.forwarded {
+				t.Errorf("unexpected Expect header: %q", req.Header.Get("Expect"))
+			}
+		})
+	}
+}
+
+func TestHandle100ContinueProxy(t *testing.T) {
+	for _, tt := range []struct {
+		msg       string
+		filter    string
+		body      string
+		forwarded bool
+	}{{
+		msg:    "strip",
+		filter: `handle100Continue("strip")`,
+		body:   strings.Repeat("x", 2048),
+	}, {
+		msg:    "auto small upload",
+		filter: `handle100Continue("auto", "1KB")`,
+		body:   strings.Repeat("x", 512),
+	}, {
+		msg:       "auto large upload",
+		filter:    `handle100Continue("auto", "1KB")`,
+		body:      strings.Repeat("x", 2048),
+		forwarded: true,
+	}} {
+		t.Run(tt.msg, func(t *testing.T) {
+			expectHeaders := make(chan string, 1)
+			backend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				expectHeaders <- r.Header.Get("Expect")
+				b, err := io.ReadAll(r.Body)
+				if err != nil {
+					w.WriteHeader(http.StatusBadRequest)
+					return
+				}
+
+				fmt.Fprint(w, len(b))
+			}))
+			defer backend.Close()
+
+			routes, err := eskip.Parse(fmt.Sprintf(`* -> %s -> "%s"`, tt.filter, backend.URL))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			fr := make(filters.Registry)
+			fr.Register(NewHandle100Continue())
+			p := proxytest.New(fr, routes...)
+			defer p.Close()
+
+			req, err := http.NewRequest("POST", p.URL, strings.NewReader(tt.body))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			req.Header.Set("Expect", "100-continue")
+
+			// the client waits for the 100 Continue, sent either by the
+			// edge or by the backend
+			client := &http.Client{Transport: &http.Transport{ExpectContinueTimeout: 10 * time.Second}}
+			defer client.CloseIdleConnections()
+
+			rsp, err := client.Do(req)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			defer rsp.Body.Close()
+			b, err := io.ReadAll(rsp.Body)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if rsp.StatusCode != http.StatusOK || string(b) != fmt.Sprint(len(tt.body)) {
+				t.Fatalf("unexpected response: %d, %s", rsp.StatusCode, b)
+			}
+
+			if expect := <-expectHeaders; (expect == "100-continue") != tt.forwarded {
+				t.Errorf("unexpected Expect header at the backend: %q", expect)
+			}
+		})
+	}
+}
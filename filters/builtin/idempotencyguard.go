@@ -0,0 +1,65 @@
+package builtin
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/zalando/skipper/filters"
+	"github.com/zalando/skipper/rfc"
+	"golang.org/x/net/http/httpguts"
+)
+
+type idempotencyGuardSpec struct{}
+
+type idempotencyGuardFilter struct {
+	header string
+}
+
+// NewIdempotencyGuard creates a filter specification whose instances
+// reject the cohort write requests, i.e. requests with a method other than
+// GET, HEAD, OPTIONS or TRACE, with 400 Bad Request when they don't contain
+// the given idempotency key header. Only the requests matched by a
+// TrafficSegment predicate are checked, other requests and read requests
+// pass through.
+//
+// It is meant to protect backends that can't safely handle retried
+// writes, e.g. canaries.
+//
+// Example:
+//
+//	canary: TrafficSegment(0.9, 1, "canary") -> idempotencyGuard("Idempotency-Key") -> "https://canary.example.org";
+func NewIdempotencyGuard() filters.Spec { return idempotencyGuardSpec{} }
+
+func (idempotencyGuardSpec) Name() string { return filters.IdempotencyGuardName }
+
+func (idempotencyGuardSpec) CreateFilter(args []interface{}) (filters.Filter, error) {
+	if len(args) != 1 {
+		return nil, filters.ErrInvalidFilterParameters
+	}
+
+	header, ok := args[0].(string)
+	if !ok {
+		return nil, filters.ErrInvalidFilterParameters
+	}
+
+	if !httpguts.ValidHeaderFieldName(header) {
+		return nil, fmt.Errorf("header name %s is invalid", header)
+	}
+
+	return &idempotencyGuardFilter{header: header}, nil
+}
+
+func (f *idempotencyGuardFilter) Request(ctx filters.FilterContext) {
+	if _, ok := ctx.StateBag()[filters.TrafficSegmentKey]; !ok {
+		return
+	}
+
+	req := ctx.Request()
+	if rfc.IsSafeMethod(req.Method) || req.Header.Get(f.header) != "" {
+		return
+	}
+
+	ctx.Serve(&http.Response{StatusCode: http.StatusBadRequest})
+}
+
+func (*idempotencyGuardFilter) Response(filters.FilterContext) {}
@@ -0,0 +1,103 @@
+package builtin
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/zalando/skipper/filters"
+	"github.com/zalando/skipper/filters/filtertest"
+	"github.com/zalando/skipper/routing"
+)
+
+func TestIdempotencyGuardCreate(t *testing.T) {
+	for _, tt := range []struct {
+		msg  string
+		args []interface{}
+		err  bool
+	}{{
+		msg: "no arguments",
+		err: true,
+	}, {
+		msg:  "too many arguments",
+		args: []interface{}{"Idempotency-Key", "X-Other"},
+		err:  true,
+	}, {
+		msg:  "not a string",
+		args: []interface{}{42.0},
+		err:  true,
+	}, {
+		msg:  "invalid header name",
+		args: []interface{}{"Idempotency Key"},
+		err:  true,
+	}, {
+		msg:  "valid header name",
+		args: []interface{}{"Idempotency-Key"},
+	}} {
+		t.Run(tt.msg, func(t *testing.T) {
+			_, err := NewIdempotencyGuard().CreateFilter(tt.args)
+			if tt.err && err == nil {
+				t.Error("expected error")
+			} else if !tt.err && err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+		})
+	}
+}
+
+func TestIdempotencyGuard(t *testing.T) {
+	for _, tt := range []struct {
+		method    string
+		key       string
+		noSegment bool
+		served    bool
+	}{
+		{method: "GET"},
+		{method: "HEAD"},
+		{method: "OPTIONS"},
+		{method: "TRACE"},
+		{method: "POST", served: true},
+		{method: "PUT", served: true},
+		{method: "PATCH", served: true},
+		{method: "DELETE", served: true},
+		{method: "POST", key: "b6f7a1c2"},
+		{method: "PATCH", key: "b6f7a1c2"},
+		{method: "POST", noSegment: true},
+		{method: "DELETE", noSegment: true},
+	} {
+		name := tt.method + " " + tt.key
+		if tt.noSegment {
+			name += " no segment"
+		}
+
+		t.Run(name, func(t *testing.T) {
+			f, err := NewIdempotencyGuard().CreateFilter([]interface{}{"Idempotency-Key"})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			req, err := http.NewRequest(tt.method, "https://www.example.org", nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if tt.key != "" {
+				req.Header.Set("Idempotency-Key", tt.key)
+			}
+
+			ctx := &filtertest.Context{FRequest: req, FStateBag: make(map[string]interface{})}
+			if !tt.noSegment {
+				ctx.FStateBag[filters.TrafficSegmentKey] = routing.TrafficSegment{Min: 0.9, Max: 1, Cohort: "canary", RouteId: "canary"}
+			}
+
+			f.Request(ctx)
+
+			if ctx.FServed != tt.served {
+				t.Fatalf("expected served: %v, got: %v", tt.served, ctx.FServed)
+			}
+
+			if tt.served && ctx.FResponse.StatusCode != http.StatusBadRequest {
+				t.Errorf("expected status %d, got: %d", http.StatusBadRequest, ctx.FResponse.StatusCode)
+			}
+		})
+	}
+}
@@ -0,0 +1,227 @@
+package builtin
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"strings"
+	"unicode"
+
+	"github.com/zalando/skipper/filters"
+)
+
+var errTrailingJSON = errors.New("unexpected data after the JSON value")
+
+type jsonKeyCaseSpec struct{}
+
+type jsonKeyCaseFilter struct {
+	convert  func([]string) string
+	maxBytes int64
+}
+
+type jsonFrame struct {
+	object bool
+	items  int
+}
+
+// NewJSONKeyCase creates a filter specification whose instances convert the
+// keys of the objects in the JSON request and response bodies to the given
+// case, one of "camel", "pascal", "snake" or "kebab". The words of the keys
+// are separated at the underscores, hyphens, spaces and the changes from
+// lower to upper case, e.g. "user_id", "user-id" and "UserID" are all
+// converted to "userId" in camel case. The values, and the order of the
+// keys, are not changed.
+//
+// Only the uncompressed JSON bodies are edited, that are not larger than
+// the optional maximum size, by default 2MB. Larger, or invalid, bodies are
+// streamed unchanged.
+//
+// Example:
+//
+//	jsonKeyCase("camel")
+//	jsonKeyCase("snake", "8MB")
+func NewJSONKeyCase() filters.Spec { return jsonKeyCaseSpec{} }
+
+func (jsonKeyCaseSpec) Name() string { return filters.JSONKeyCaseName }
+
+func (jsonKeyCaseSpec) CreateFilter(args []interface{}) (filters.Filter, error) {
+	if len(args) != 1 && len(args) != 2 {
+		return nil, filters.ErrInvalidFilterParameters
+	}
+
+	c, ok := args[0].(string)
+	if !ok {
+		return nil, filters.ErrInvalidFilterParameters
+	}
+
+	f := &jsonKeyCaseFilter{}
+	switch c {
+	case "camel":
+		f.convert = camelCase
+	case "pascal":
+		f.convert = pascalCase
+	case "snake":
+		f.convert = func(words []string) string { return strings.ToLower(strings.Join(words, "_")) }
+	case "kebab":
+		f.convert = func(words []string) string { return strings.ToLower(strings.Join(words, "-")) }
+	default:
+		return nil, filters.ErrInvalidFilterParameters
+	}
+
+	if f.maxBytes, ok = bodyTransformMaxBytesArg(args[1:]); !ok {
+		return nil, filters.ErrInvalidFilterParameters
+	}
+
+	return f, nil
+}
+
+// splitWords splits a key at the separators and at the case changes. A run
+// of upper case letters is one word, except for its last letter when it is
+// followed by a lower case letter, e.g. "HTTPServer" is split into "HTTP" and
+// "Server".
+func splitWords(s string) []string {
+	var (
+		words []string
+		word  []rune
+	)
+
+	r := []rune(s)
+	for i, c := range r {
+		if c == '_' || c == '-' || c == ' ' {
+			if len(word) > 0 {
+				words = append(words, string(word))
+				word = nil
+			}
+
+			continue
+		}
+
+		if unicode.IsUpper(c) && len(word) > 0 {
+			prev := word[len(word)-1]
+			nextLower := i+1 < len(r) && unicode.IsLower(r[i+1])
+			if !unicode.IsUpper(prev) || nextLower {
+				words = append(words, string(word))
+				word = nil
+			}
+		}
+
+		word = append(word, c)
+	}
+
+	if len(word) > 0 {
+		words = append(words, string(word))
+	}
+
+	return words
+}
+
+func capitalize(word string) string {
+	r := []rune(strings.ToLower(word))
+	r[0] = unicode.ToUpper(r[0])
+	return string(r)
+}
+
+func pascalCase(words []string) string {
+	var b strings.Builder
+	for _, w := range words {
+		b.WriteString(capitalize(w))
+	}
+
+	return b.String()
+}
+
+func camelCase(words []string) string {
+	if len(words) == 0 {
+		return ""
+	}
+
+	return strings.ToLower(words[0]) + pascalCase(words[1:])
+}
+
+// transform converts the keys, keeping their order, by walking the tokens
+// of the document.
+func (f *jsonKeyCaseFilter) transform(doc []byte) ([]byte, error) {
+	dec := json.NewDecoder(bytes.NewReader(doc))
+	dec.UseNumber()
+
+	var (
+		out   bytes.Buffer
+		stack []jsonFrame
+	)
+
+	for {
+		t, err := dec.Token()
+		if err == io.EOF {
+			if len(stack) > 0 || out.Len() == 0 {
+				return nil, io.ErrUnexpectedEOF
+			}
+
+			return out.Bytes(), nil
+		}
+
+		if err != nil {
+			return nil, err
+		}
+
+		if len(stack) == 0 && out.Len() > 0 {
+			return nil, errTrailingJSON
+		}
+
+		if d, ok := t.(json.Delim); ok && (d == '}' || d == ']') {
+			out.WriteByte(byte(d))
+			stack = stack[:len(stack)-1]
+			continue
+		}
+
+		key := false
+		if len(stack) > 0 {
+			top := &stack[len(stack)-1]
+			switch {
+			case top.object && top.items%2 == 1:
+				out.WriteByte(':')
+			case top.items > 0:
+				out.WriteByte(',')
+			}
+
+			key = top.object && top.items%2 == 0
+			top.items++
+		}
+
+		switch v := t.(type) {
+		case json.Delim:
+			out.WriteByte(byte(v))
+			stack = append(stack, jsonFrame{object: v == '{'})
+		case string:
+			if key {
+				v = f.convert(splitWords(v))
+			}
+
+			out.Write(encodeJSONString(v))
+		case json.Number:
+			out.WriteString(string(v))
+		case bool:
+			if v {
+				out.WriteString("true")
+			} else {
+				out.WriteString("false")
+			}
+		case nil:
+			out.WriteString("null")
+		}
+	}
+}
+
+func (f *jsonKeyCaseFilter) Request(ctx filters.FilterContext) {
+	r := ctx.Request()
+	if isJSON(r.Header.Get("Content-Type")) {
+		transformRequestBody(r, f.maxBytes, f.transform)
+	}
+}
+
+func (f *jsonKeyCaseFilter) Response(ctx filters.FilterContext) {
+	rsp := ctx.Response()
+	if isJSON(rsp.Header.Get("Content-Type")) {
+		transformResponseBody(rsp, f.maxBytes, f.transform)
+	}
+}
@@ -0,0 +1,178 @@
+package builtin
+
+import (
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/zalando/skipper/filters/filtertest"
+)
+
+func TestJSONKeyCaseArgs(t *testing.T) {
+	for _, tt := range []struct {
+		msg  string
+		args []interface{}
+		fail bool
+	}{
+		{msg: "no args", fail: true},
+		{msg: "unknown case", args: []interface{}{"title"}, fail: true},
+		{msg: "not a string", args: []interface{}{42}, fail: true},
+		{msg: "invalid size", args: []interface{}{"camel", "1XB"}, fail: true},
+		{msg: "too many", args: []interface{}{"camel", "1MB", "snake"}, fail: true},
+		{msg: "camel", args: []interface{}{"camel"}},
+		{msg: "snake with size", args: []interface{}{"snake", "64KB"}},
+	} {
+		t.Run(tt.msg, func(t *testing.T) {
+			_, err := NewJSONKeyCase().CreateFilter(tt.args)
+			if tt.fail != (err != nil) {
+				t.Errorf("expected failure: %v, got: %v", tt.fail, err)
+			}
+		})
+	}
+}
+
+func TestJSONKeyCaseSplitWords(t *testing.T) {
+	for key, expected := range map[string]string{
+		"user_id":         "user,id",
+		"user-id":         "user,id",
+		"userId":          "user,Id",
+		"UserID":          "User,ID",
+		"HTTPServerError": "HTTP,Server,Error",
+		"__private":       "private",
+		"v2Items":         "v2,Items",
+		"":                "",
+	} {
+		if words := strings.Join(splitWords(key), ","); words != expected {
+			t.Errorf("%q: expected %q, got %q", key, expected, words)
+		}
+	}
+}
+
+func TestJSONKeyCase(t *testing.T) {
+	const doc = `{"user_id": 1, "UserName": "Jane_Doe", "home-address": {"zip_code": "10115", "HTTPProxy": null}, ` +
+		`"order_items": [{"item_id": 1.5e3, "in_stock": true}, "not_a_key"]}`
+
+	for _, tt := range []struct {
+		keyCase  string
+		expected string
+	}{{
+		keyCase:  "camel",
+		expected: `{"userId":1,"userName":"Jane_Doe","homeAddress":{"zipCode":"10115","httpProxy":null},"orderItems":[{"itemId":1.5e3,"inStock":true},"not_a_key"]}`,
+	}, {
+		keyCase:  "pascal",
+		expected: `{"UserId":1,"UserName":"Jane_Doe","HomeAddress":{"ZipCode":"10115","HttpProxy":null},"OrderItems":[{"ItemId":1.5e3,"InStock":true},"not_a_key"]}`,
+	}, {
+		keyCase:  "snake",
+		expected: `{"user_id":1,"user_name":"Jane_Doe","home_address":{"zip_code":"10115","http_proxy":null},"order_items":[{"item_id":1.5e3,"in_stock":true},"not_a_key"]}`,
+	}, {
+		keyCase:  "kebab",
+		expected: `{"user-id":1,"user-name":"Jane_Doe","home-address":{"zip-code":"10115","http-proxy":null},"order-items":[{"item-id":1.5e3,"in-stock":true},"not_a_key"]}`,
+	}} {
+		t.Run(tt.keyCase, func(t *testing.T) {
+			f, err := NewJSONKeyCase().CreateFilter([]interface{}{tt.keyCase})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			ctx := &filtertest.Context{FResponse: &http.Response{
+				Header:        http.Header{"Content-Type": []string{"application/json"}},
+				ContentLength: int64(len(doc)),
+				Body:          io.NopCloser(strings.NewReader(doc)),
+			}}
+
+			f.Response(ctx)
+			b, err := io.ReadAll(ctx.FResponse.Body)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if string(b) != tt.expected {
+				t.Errorf("expected %s, got %s", tt.expected, b)
+			}
+
+			if ctx.FResponse.ContentLength != int64(len(b)) {
+				t.Errorf("invalid content length: %d", ctx.FResponse.ContentLength)
+			}
+		})
+	}
+}
+
+func TestJSONKeyCaseRequest(t *testing.T) {
+	f, err := NewJSONKeyCase().CreateFilter([]interface{}{"snake"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	const doc = `{"firstName": "Jane", "lastName": "Doe"}`
+	r, err := http.NewRequest("POST", "https://www.example.org/users", strings.NewReader(doc))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	r.Header.Set("Content-Type", "application/json; charset=utf-8")
+	f.Request(&filtertest.Context{FRequest: r})
+
+	b, err := io.ReadAll(r.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	const expected = `{"first_name":"Jane","last_name":"Doe"}`
+	if string(b) != expected {
+		t.Errorf("expected %s, got %s", expected, b)
+	}
+
+	if r.ContentLength != int64(len(expected)) {
+		t.Errorf("invalid content length: %d", r.ContentLength)
+	}
+}
+
+func TestJSONKeyCaseUnchanged(t *testing.T) {
+	for _, tt := range []struct {
+		msg         string
+		contentType string
+		encoding    string
+		body        string
+		maxBytes    string
+	}{
+		{msg: "not JSON", contentType: "text/plain", body: `{"user_id": 1}`},
+		{msg: "compressed", contentType: "application/json", encoding: "gzip", body: `{"user_id": 1}`},
+		{msg: "invalid JSON", contentType: "application/json", body: `{"user_id": 1`},
+		{msg: "empty", contentType: "application/json", body: ""},
+		{msg: "multiple values", contentType: "application/json", body: `{"user_id": 1} {"user_id": 2}`},
+		{msg: "too large", contentType: "application/json", body: `{"user_id": 1}`, maxBytes: "8B"},
+	} {
+		t.Run(tt.msg, func(t *testing.T) {
+			args := []interface{}{"camel"}
+			if tt.maxBytes != "" {
+				args = append(args, tt.maxBytes)
+			}
+
+			f, err := NewJSONKeyCase().CreateFilter(args)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			rsp := &http.Response{
+				Header:        http.Header{"Content-Type": []string{tt.contentType}},
+				ContentLength: -1,
+				Body:          io.NopCloser(strings.NewReader(tt.body)),
+			}
+
+			if tt.encoding != "" {
+				rsp.Header.Set("Content-Encoding", tt.encoding)
+			}
+
+			f.Response(&filtertest.Context{FResponse: rsp})
+			b, err := io.ReadAll(rsp.Body)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if string(b) != tt.body {
+				t.Errorf("expected unchanged body %s, got %s", tt.body, b)
+			}
+		})
+	}
+}
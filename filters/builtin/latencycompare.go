@@ -0,0 +1,157 @@
+package builtin
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/opentracing/opentracing-go"
+
+	"github.com/zalando/skipper/filters"
+	"github.com/zalando/skipper/routing"
+)
+
+const (
+	latencyCompareStartKey      = "latencyCompare:start"
+	defaultLatencyCompareFactor = 1.5
+
+	// the weight of the latest sample in the moving average of the baseline
+	latencyCompareAlpha = 0.1
+)
+
+var latencyCompareBaselineName = regexp.MustCompile(`^[A-Za-z0-9_.-]+$`)
+
+// latencyBaseline holds the exponentially weighted moving average of the
+// stable latency, stored as float64 bits, in seconds.
+type latencyBaseline struct {
+	value uint64
+}
+
+type latencyCompareSpec struct {
+	mu        sync.Mutex
+	baselines map[string]*latencyBaseline
+}
+
+type latencyCompareFilter struct {
+	name     string
+	factor   float64
+	baseline *latencyBaseline
+}
+
+// NewLatencyCompare creates a filter specification whose instances
+// compare the latency of the canary cohorts with the latency of the
+// stable traffic sharing the same baseline.
+//
+// Requests of routes without a cohort, as defined by the TrafficSegment
+// predicate, update the moving average of the baseline. Requests of
+// routes with a cohort measure their latency under the
+// latencycompare.<baseline>.<cohort> metrics key, and when their latency
+// exceeds the baseline multiplied by the factor, defaulting to 1.5, they
+// increment the latencycompare.<baseline>.<cohort>.slow counter and tag
+// the active span with latency.regression=true.
+//
+// Examples:
+//
+//	stable: TrafficSegment(0, 0.9) -> latencyCompare("checkout") -> "https://stable.example.org";
+//	canary: TrafficSegment(0.9, 1, "canary") -> latencyCompare("checkout", 2) -> "https://canary.example.org";
+func NewLatencyCompare() filters.Spec {
+	return &latencyCompareSpec{baselines: make(map[string]*latencyBaseline)}
+}
+
+func (*latencyCompareSpec) Name() string { return filters.LatencyCompareName }
+
+func (s *latencyCompareSpec) CreateFilter(args []interface{}) (filters.Filter, error) {
+	if len(args) < 1 || len(args) > 2 {
+		return nil, filters.ErrInvalidFilterParameters
+	}
+
+	name, ok := args[0].(string)
+	if !ok {
+		return nil, filters.ErrInvalidFilterParameters
+	}
+
+	if !latencyCompareBaselineName.MatchString(name) {
+		return nil, fmt.Errorf("baseline name %s is invalid", name)
+	}
+
+	factor := defaultLatencyCompareFactor
+	if len(args) == 2 {
+		switch v := args[1].(type) {
+		case int:
+			factor = float64(v)
+		case float64:
+			factor = v
+		default:
+			return nil, filters.ErrInvalidFilterParameters
+		}
+
+		if factor < 1 {
+			return nil, filters.ErrInvalidFilterParameters
+		}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	b, ok := s.baselines[name]
+	if !ok {
+		b = &latencyBaseline{}
+		s.baselines[name] = b
+	}
+
+	return &latencyCompareFilter{name: name, factor: factor, baseline: b}, nil
+}
+
+func (b *latencyBaseline) load() float64 {
+	return math.Float64frombits(atomic.LoadUint64(&b.value))
+}
+
+func (b *latencyBaseline) update(sample float64) {
+	for {
+		old := atomic.LoadUint64(&b.value)
+		current := math.Float64frombits(old)
+
+		next := sample
+		if current > 0 {
+			next = current + latencyCompareAlpha*(sample-current)
+		}
+
+		if atomic.CompareAndSwapUint64(&b.value, old, math.Float64bits(next)) {
+			return
+		}
+	}
+}
+
+func (f *latencyCompareFilter) Request(ctx filters.FilterContext) {
+	ctx.StateBag()[latencyCompareStartKey] = time.Now()
+}
+
+func (f *latencyCompareFilter) Response(ctx filters.FilterContext) {
+	start, ok := ctx.StateBag()[latencyCompareStartKey].(time.Time)
+	if !ok {
+		return
+	}
+
+	latency := time.Since(start).Seconds()
+	segment, _ := ctx.StateBag()[filters.TrafficSegmentKey].(routing.TrafficSegment)
+	if segment.Cohort == "" {
+		f.baseline.update(latency)
+		return
+	}
+
+	key := "latencycompare." + f.name + "." + segment.Cohort
+	ctx.Metrics().MeasureSince(key, start)
+
+	baseline := f.baseline.load()
+	if baseline <= 0 || latency <= baseline*f.factor {
+		return
+	}
+
+	ctx.Metrics().IncCounter(key + ".slow")
+	if span := opentracing.SpanFromContext(ctx.Request().Context()); span != nil {
+		span.SetTag("latency.regression", true)
+	}
+}
@@ -0,0 +1,137 @@
+package builtin
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/opentracing/opentracing-go"
+	"github.com/opentracing/opentracing-go/mocktracer"
+
+	"github.com/zalando/skipper/filters"
+	"github.com/zalando/skipper/filters/filtertest"
+	"github.com/zalando/skipper/metrics/metricstest"
+	"github.com/zalando/skipper/routing"
+)
+
+func TestLatencyCompareCreate(t *testing.T) {
+	for _, tt := range []struct {
+		msg  string
+		args []interface{}
+		err  bool
+	}{{
+		msg: "no args",
+		err: true,
+	}, {
+		msg:  "invalid baseline type",
+		args: []interface{}{42.0},
+		err:  true,
+	}, {
+		msg:  "empty baseline",
+		args: []interface{}{""},
+		err:  true,
+	}, {
+		msg:  "invalid baseline name",
+		args: []interface{}{"check out"},
+		err:  true,
+	}, {
+		msg:  "invalid factor",
+		args: []interface{}{"checkout", 0.5},
+		err:  true,
+	}, {
+		msg:  "too many args",
+		args: []interface{}{"checkout", 2.0, 3.0},
+		err:  true,
+	}, {
+		msg:  "baseline",
+		args: []interface{}{"checkout"},
+	}, {
+		msg:  "baseline with factor",
+		args: []interface{}{"checkout", 2.0},
+	}} {
+		t.Run(tt.msg, func(t *testing.T) {
+			_, err := NewLatencyCompare().CreateFilter(tt.args)
+			if tt.err && err == nil {
+				t.Error("expected error")
+			} else if !tt.err && err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+		})
+	}
+}
+
+func TestLatencyCompare(t *testing.T) {
+	spec := NewLatencyCompare()
+	stable, err := spec.CreateFilter([]interface{}{"checkout"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	canary, err := spec.CreateFilter([]interface{}{"checkout"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tracer := mocktracer.New()
+	m := &metricstest.MockMetrics{}
+	run := func(f filters.Filter, cohort string, latency time.Duration) *mocktracer.MockSpan {
+		span := tracer.StartSpan("test").(*mocktracer.MockSpan)
+		req, err := http.NewRequestWithContext(
+			opentracing.ContextWithSpan(context.Background(), span),
+			"GET",
+			"https://www.example.org",
+			nil,
+		)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		ctx := &filtertest.Context{
+			FRequest:  req,
+			FResponse: &http.Response{StatusCode: http.StatusOK},
+			FMetrics:  m,
+			FStateBag: map[string]interface{}{
+				filters.TrafficSegmentKey: routing.TrafficSegment{Cohort: cohort},
+			},
+		}
+
+		f.Request(ctx)
+		ctx.FStateBag[latencyCompareStartKey] = time.Now().Add(-latency)
+		f.Response(ctx)
+		return span
+	}
+
+	slow := func() int64 {
+		var v int64
+		m.WithCounters(func(c map[string]int64) { v = c["latencycompare.checkout.canary.slow"] })
+		return v
+	}
+
+	// no baseline yet
+	if span := run(canary, "canary", time.Second); span.Tag("latency.regression") != nil || slow() != 0 {
+		t.Fatal("unexpected regression without baseline")
+	}
+
+	for i := 0; i < 10; i++ {
+		run(stable, "", 100*time.Millisecond)
+	}
+
+	if span := run(canary, "canary", 120*time.Millisecond); span.Tag("latency.regression") != nil || slow() != 0 {
+		t.Error("unexpected regression within the factor")
+	}
+
+	if span := run(canary, "canary", time.Second); span.Tag("latency.regression") != true || slow() != 1 {
+		t.Error("failed to detect regression")
+	}
+
+	m.WithMeasures(func(measures map[string][]time.Duration) {
+		if n := len(measures["latencycompare.checkout.canary"]); n != 3 {
+			t.Errorf("expected 3 cohort measurements, got: %d", n)
+		}
+
+		if _, ok := measures["latencycompare.checkout."]; ok {
+			t.Error("unexpected measurement of the stable traffic")
+		}
+	})
+}
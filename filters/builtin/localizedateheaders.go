@@ -0,0 +1,101 @@
+package builtin
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/zalando/skipper/filters"
+	"golang.org/x/net/http/httpguts"
+)
+
+type localizeDateHeadersSpec struct{}
+
+type localizeDateHeadersFilter struct {
+	headers  []string
+	location *time.Location
+}
+
+// NewLocalizeDateHeaders creates a filter specification whose instances
+// rewrite the timestamps in the named response headers to the configured
+// timezone, for clients that can't handle UTC. The last argument is the
+// name of the timezone, as accepted by time.LoadLocation.
+//
+// The timestamps in RFC 1123 format, either with the GMT or UTC zone, or
+// with a numeric offset, and in RFC 3339 format are rewritten, keeping
+// their format. Other values are left untouched.
+//
+// Example:
+//
+//	localizeDateHeaders("X-Event-Time", "Last-Modified", "Europe/Berlin")
+func NewLocalizeDateHeaders() filters.Spec { return localizeDateHeadersSpec{} }
+
+func (localizeDateHeadersSpec) Name() string { return filters.LocalizeDateHeadersName }
+
+func (localizeDateHeadersSpec) CreateFilter(args []interface{}) (filters.Filter, error) {
+	if len(args) < 2 {
+		return nil, filters.ErrInvalidFilterParameters
+	}
+
+	zone, ok := args[len(args)-1].(string)
+	if !ok {
+		return nil, filters.ErrInvalidFilterParameters
+	}
+
+	location, err := time.LoadLocation(zone)
+	if err != nil {
+		return nil, fmt.Errorf("%s: invalid timezone %q: %w", filters.LocalizeDateHeadersName, zone, err)
+	}
+
+	f := &localizeDateHeadersFilter{location: location}
+	for _, a := range args[:len(args)-1] {
+		name, ok := a.(string)
+		if !ok {
+			return nil, filters.ErrInvalidFilterParameters
+		}
+
+		if !httpguts.ValidHeaderFieldName(name) {
+			return nil, fmt.Errorf("header name %s is invalid", name)
+		}
+
+		f.headers = append(f.headers, http.CanonicalHeaderKey(name))
+	}
+
+	return f, nil
+}
+
+// localize rewrites a timestamp to the location, keeping its format. It
+// returns false when the value is not a supported timestamp.
+func localize(value string, location *time.Location) (string, bool) {
+	if t, err := time.Parse(time.RFC3339Nano, value); err == nil {
+		return t.In(location).Format(time.RFC3339Nano), true
+	}
+
+	if t, err := time.Parse(time.RFC1123Z, value); err == nil {
+		return t.In(location).Format(time.RFC1123Z), true
+	}
+
+	// the offset of the zone abbreviations other than GMT and UTC is
+	// unknown to the parser
+	if strings.HasSuffix(value, " GMT") || strings.HasSuffix(value, " UTC") {
+		if t, err := time.Parse(time.RFC1123, value); err == nil {
+			return t.In(location).Format(time.RFC1123), true
+		}
+	}
+
+	return "", false
+}
+
+func (*localizeDateHeadersFilter) Request(filters.FilterContext) {}
+
+func (f *localizeDateHeadersFilter) Response(ctx filters.FilterContext) {
+	h := ctx.Response().Header
+	for _, name := range f.headers {
+		for i, v := range h[name] {
+			if l, ok := localize(v, f.location); ok {
+				h[name][i] = l
+			}
+		}
+	}
+}
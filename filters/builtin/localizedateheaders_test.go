@@ -0,0 +1,165 @@
+package builtin
+
+import (
+	"net/http"
+	"reflect"
+	"testing"
+
+	"github.com/zalando/skipper/filters/filtertest"
+)
+
+func TestLocalizeDateHeadersCreate(t *testing.T) {
+	for _, tt := range []struct {
+		msg  string
+		args []interface{}
+		err  bool
+	}{{
+		msg: "no args",
+		err: true,
+	}, {
+		msg:  "no header",
+		args: []interface{}{"Europe/Berlin"},
+		err:  true,
+	}, {
+		msg:  "invalid timezone",
+		args: []interface{}{"X-Event-Time", "Europe/Nowhere"},
+		err:  true,
+	}, {
+		msg:  "timezone not a string",
+		args: []interface{}{"X-Event-Time", 1.0},
+		err:  true,
+	}, {
+		msg:  "header not a string",
+		args: []interface{}{42.0, "Europe/Berlin"},
+		err:  true,
+	}, {
+		msg:  "invalid header name",
+		args: []interface{}{"X Event", "Europe/Berlin"},
+		err:  true,
+	}, {
+		msg:  "header",
+		args: []interface{}{"X-Event-Time", "Europe/Berlin"},
+	}, {
+		msg:  "headers",
+		args: []interface{}{"X-Event-Time", "Last-Modified", "UTC"},
+	}} {
+		t.Run(tt.msg, func(t *testing.T) {
+			_, err := NewLocalizeDateHeaders().CreateFilter(tt.args)
+			if tt.err && err == nil {
+				t.Error("expected error")
+			} else if !tt.err && err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+		})
+	}
+}
+
+func TestLocalizeDateHeaders(t *testing.T) {
+	for _, tt := range []struct {
+		msg      string
+		zone     string
+		value    string
+		expected string
+	}{{
+		msg:      "RFC 1123 GMT",
+		zone:     "Europe/Berlin",
+		value:    "Mon, 15 Jan 2024 12:00:00 GMT",
+		expected: "Mon, 15 Jan 2024 13:00:00 CET",
+	}, {
+		msg:      "RFC 1123 UTC in summer time",
+		zone:     "Europe/Berlin",
+		value:    "Mon, 15 Jul 2024 12:00:00 UTC",
+		expected: "Mon, 15 Jul 2024 14:00:00 CEST",
+	}, {
+		msg:      "RFC 1123 numeric offset",
+		zone:     "America/New_York",
+		value:    "Mon, 15 Jan 2024 12:00:00 +0100",
+		expected: "Mon, 15 Jan 2024 06:00:00 -0500",
+	}, {
+		msg:      "RFC 3339 UTC",
+		zone:     "Europe/Berlin",
+		value:    "2024-01-15T12:00:00Z",
+		expected: "2024-01-15T13:00:00+01:00",
+	}, {
+		msg:      "RFC 3339 offset",
+		zone:     "Asia/Tokyo",
+		value:    "2024-01-15T12:00:00-03:00",
+		expected: "2024-01-16T00:00:00+09:00",
+	}, {
+		msg:      "RFC 3339 fractional seconds",
+		zone:     "Europe/Berlin",
+		value:    "2024-01-15T12:00:00.123Z",
+		expected: "2024-01-15T13:00:00.123+01:00",
+	}, {
+		msg:      "to UTC",
+		zone:     "UTC",
+		value:    "2024-01-15T13:00:00+01:00",
+		expected: "2024-01-15T12:00:00Z",
+	}, {
+		msg:      "RFC 1123 with an ambiguous zone",
+		zone:     "Europe/Berlin",
+		value:    "Mon, 15 Jan 2024 12:00:00 EST",
+		expected: "Mon, 15 Jan 2024 12:00:00 EST",
+	}, {
+		msg:      "unix timestamp",
+		zone:     "Europe/Berlin",
+		value:    "1705320000",
+		expected: "1705320000",
+	}, {
+		msg:      "malformed",
+		zone:     "Europe/Berlin",
+		value:    "yesterday",
+		expected: "yesterday",
+	}, {
+		msg:      "invalid date",
+		zone:     "Europe/Berlin",
+		value:    "2024-02-30T12:00:00Z",
+		expected: "2024-02-30T12:00:00Z",
+	}} {
+		t.Run(tt.msg, func(t *testing.T) {
+			f, err := NewLocalizeDateHeaders().CreateFilter([]interface{}{"x-event-time", tt.zone})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			ctx := &filtertest.Context{FResponse: &http.Response{Header: http.Header{
+				"X-Event-Time": []string{tt.value},
+				"Date":         []string{"Mon, 15 Jan 2024 12:00:00 GMT"},
+			}}}
+
+			f.Response(ctx)
+
+			expected := http.Header{
+				"X-Event-Time": []string{tt.expected},
+				"Date":         []string{"Mon, 15 Jan 2024 12:00:00 GMT"},
+			}
+
+			if !reflect.DeepEqual(ctx.FResponse.Header, expected) {
+				t.Errorf("expected %v, got %v", expected, ctx.FResponse.Header)
+			}
+		})
+	}
+}
+
+func TestLocalizeDateHeadersRepeated(t *testing.T) {
+	f, err := NewLocalizeDateHeaders().CreateFilter([]interface{}{"X-Event-Time", "Last-Modified", "Europe/Berlin"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := &filtertest.Context{FResponse: &http.Response{Header: http.Header{
+		"X-Event-Time":  []string{"2024-01-15T12:00:00Z", "invalid", "2024-07-15T12:00:00Z"},
+		"Last-Modified": []string{"Mon, 15 Jan 2024 12:00:00 GMT"},
+	}}}
+
+	f.Response(ctx)
+
+	expected := http.Header{
+		"X-Event-Time":  []string{"2024-01-15T13:00:00+01:00", "invalid", "2024-07-15T14:00:00+02:00"},
+		"Last-Modified": []string{"Mon, 15 Jan 2024 13:00:00 CET"},
+	}
+
+	if !reflect.DeepEqual(ctx.FResponse.Header, expected) {
+		t.Errorf("expected %v, got %v", expected, ctx.FResponse.Header)
+	}
+}
@@ -0,0 +1,210 @@
+package builtin
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"path"
+	"strconv"
+	"time"
+
+	"gopkg.in/yaml.v2"
+
+	"github.com/zalando/skipper/filters"
+)
+
+const (
+	defaultMaintenanceBody        = "Service unavailable due to maintenance"
+	defaultMaintenanceContentType = "text/plain; charset=utf-8"
+
+	// how often the schedule file is checked for changes
+	maintenanceCheckInterval = time.Second
+)
+
+type maintenanceWindowSpec struct {
+	reloadedFiles[[]maintenanceWindow]
+}
+
+type maintenanceWindowFilter struct {
+	schedule *reloadedFile[[]maintenanceWindow]
+}
+
+type maintenanceWindowConfig struct {
+	Start       string   `yaml:"start"`
+	End         string   `yaml:"end"`
+	Paths       []string `yaml:"paths"`
+	Body        string   `yaml:"body"`
+	ContentType string   `yaml:"contentType"`
+}
+
+type maintenanceScheduleConfig struct {
+	Windows []maintenanceWindowConfig `yaml:"windows"`
+}
+
+type maintenanceWindow struct {
+	start, end  time.Time
+	paths       []string
+	body        string
+	contentType string
+}
+
+// NewMaintenanceWindow creates a filter specification whose instances
+// respond with 503 Service Unavailable, when the current time falls in a
+// maintenance window declared in a schedule file, without editing the
+// routes. Outside the windows, the requests pass through. The file is
+// reloaded when it changes.
+//
+// The schedule file is YAML, containing the windows with the start and
+// end time in RFC3339 format, the optional path patterns of the requests
+// affected by the window, as path.Match, and the optional response body and
+// content type:
+//
+//	windows:
+//	- start: 2023-06-01T22:00:00Z
+//	  end: 2023-06-02T02:00:00Z
+//	  paths: ["/api/*", "/checkout"]
+//	  body: '{"error": "maintenance"}'
+//	  contentType: application/json
+//
+// Without paths, the window affects all the requests of the routes with the
+// filter. The response contains the Retry-After header with the end of the
+// window.
+//
+// Example:
+//
+//	api: PathSubtree("/api") -> maintenanceWindow("/etc/skipper/maintenance.yaml") -> "https://api.example.org";
+func NewMaintenanceWindow() filters.Spec {
+	return &maintenanceWindowSpec{
+		reloadedFiles: newReloadedFiles(
+			filters.MaintenanceWindowName,
+			"maintenance schedule",
+			parseMaintenanceSchedule,
+			maintenanceCheckInterval,
+		),
+	}
+}
+
+func (*maintenanceWindowSpec) Name() string { return filters.MaintenanceWindowName }
+
+func (s *maintenanceWindowSpec) CreateFilter(args []interface{}) (filters.Filter, error) {
+	if len(args) != 1 {
+		return nil, filters.ErrInvalidFilterParameters
+	}
+
+	file, ok := args[0].(string)
+	if !ok || file == "" {
+		return nil, filters.ErrInvalidFilterParameters
+	}
+
+	sc, err := s.get(file)
+	if err != nil {
+		return nil, err
+	}
+
+	return &maintenanceWindowFilter{schedule: sc}, nil
+}
+
+func parseMaintenanceSchedule(data []byte) ([]maintenanceWindow, error) {
+	var c maintenanceScheduleConfig
+	if err := yaml.Unmarshal(data, &c); err != nil {
+		return nil, err
+	}
+
+	windows := make([]maintenanceWindow, 0, len(c.Windows))
+	for i, wc := range c.Windows {
+		start, err := time.Parse(time.RFC3339, wc.Start)
+		if err != nil {
+			return nil, fmt.Errorf("invalid start of maintenance window %d: %w", i, err)
+		}
+
+		end, err := time.Parse(time.RFC3339, wc.End)
+		if err != nil {
+			return nil, fmt.Errorf("invalid end of maintenance window %d: %w", i, err)
+		}
+
+		if !end.After(start) {
+			return nil, fmt.Errorf("maintenance window %d ends before it starts", i)
+		}
+
+		for _, p := range wc.Paths {
+			if _, err := path.Match(p, ""); err != nil {
+				return nil, fmt.Errorf("invalid path pattern of maintenance window %d: %w", i, err)
+			}
+		}
+
+		w := maintenanceWindow{
+			start:       start,
+			end:         end,
+			paths:       wc.Paths,
+			body:        wc.Body,
+			contentType: wc.ContentType,
+		}
+
+		if w.body == "" {
+			w.body = defaultMaintenanceBody
+		}
+
+		if w.contentType == "" {
+			w.contentType = defaultMaintenanceContentType
+		}
+
+		windows = append(windows, w)
+	}
+
+	return windows, nil
+}
+
+// active returns the maintenance window affecting the request path at the
+// current time, if any.
+func (f *maintenanceWindowFilter) active(p string) (maintenanceWindow, time.Time, bool) {
+	windows, now := f.schedule.get()
+	for _, w := range windows {
+		if now.Before(w.start) || !now.Before(w.end) || !w.matchPath(p) {
+			continue
+		}
+
+		return w, now, true
+	}
+
+	return maintenanceWindow{}, now, false
+}
+
+func (w maintenanceWindow) matchPath(p string) bool {
+	if len(w.paths) == 0 {
+		return true
+	}
+
+	for _, pattern := range w.paths {
+		if m, _ := path.Match(pattern, p); m {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (f *maintenanceWindowFilter) Request(ctx filters.FilterContext) {
+	w, now, ok := f.active(ctx.Request().URL.Path)
+	if !ok {
+		return
+	}
+
+	retryAfter := int(w.end.Sub(now).Round(time.Second) / time.Second)
+	if retryAfter < 1 {
+		retryAfter = 1
+	}
+
+	ctx.Serve(&http.Response{
+		StatusCode: http.StatusServiceUnavailable,
+		Header: http.Header{
+			"Content-Type":   []string{w.contentType},
+			"Content-Length": []string{strconv.Itoa(len(w.body))},
+			"Retry-After":    []string{strconv.Itoa(retryAfter)},
+		},
+		ContentLength: int64(len(w.body)),
+		Body:          io.NopCloser(bytes.NewBufferString(w.body)),
+	})
+}
+
+func (*maintenanceWindowFilter) Response(filters.FilterContext) {}
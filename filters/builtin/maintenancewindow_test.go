@@ -0,0 +1,226 @@
+package builtin
+
+import (
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/zalando/skipper/filters"
+	"github.com/zalando/skipper/filters/filtertest"
+)
+
+const testMaintenanceSchedule = `windows:
+- start: 2023-06-01T22:00:00Z
+  end: 2023-06-02T02:00:00Z
+  paths: ["/api/*"]
+  body: '{"error": "maintenance"}'
+  contentType: application/json
+- start: 2023-06-10T00:00:00Z
+  end: 2023-06-10T01:00:00Z
+`
+
+func writeMaintenanceSchedule(t *testing.T, file, content string, modTime time.Time) {
+	if err := os.WriteFile(file, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := os.Chtimes(file, modTime, modTime); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func testMaintenanceWindowSpec(now *time.Time) filters.Spec {
+	spec := NewMaintenanceWindow().(*maintenanceWindowSpec)
+	spec.now = func() time.Time { return *now }
+	return spec
+}
+
+func serveMaintenanceWindow(t *testing.T, f filters.Filter, p string) *http.Response {
+	ctx := &filtertest.Context{FRequest: &http.Request{URL: &url.URL{Path: p}}}
+	f.Request(ctx)
+	if !ctx.FServed {
+		return nil
+	}
+
+	return ctx.FResponse
+}
+
+func TestMaintenanceWindowArgs(t *testing.T) {
+	dir := t.TempDir()
+	valid := filepath.Join(dir, "valid.yaml")
+	writeMaintenanceSchedule(t, valid, testMaintenanceSchedule, time.Now())
+
+	invalid := filepath.Join(dir, "invalid.yaml")
+	writeMaintenanceSchedule(t, invalid, "windows:\n- start: yesterday\n  end: today\n", time.Now())
+
+	reversed := filepath.Join(dir, "reversed.yaml")
+	writeMaintenanceSchedule(t, reversed, "windows:\n- start: 2023-06-02T00:00:00Z\n  end: 2023-06-01T00:00:00Z\n", time.Now())
+
+	pattern := filepath.Join(dir, "pattern.yaml")
+	writeMaintenanceSchedule(t, pattern, "windows:\n- start: 2023-06-01T00:00:00Z\n  end: 2023-06-02T00:00:00Z\n  paths: [\"/api/[\"]\n", time.Now())
+
+	for _, tt := range []struct {
+		msg  string
+		args []interface{}
+		fail bool
+	}{{
+		msg:  "no args",
+		fail: true,
+	}, {
+		msg:  "not a string",
+		args: []interface{}{42},
+		fail: true,
+	}, {
+		msg:  "missing file",
+		args: []interface{}{filepath.Join(dir, "missing.yaml")},
+		fail: true,
+	}, {
+		msg:  "invalid time",
+		args: []interface{}{invalid},
+		fail: true,
+	}, {
+		msg:  "end before start",
+		args: []interface{}{reversed},
+		fail: true,
+	}, {
+		msg:  "invalid path pattern",
+		args: []interface{}{pattern},
+		fail: true,
+	}, {
+		msg:  "valid",
+		args: []interface{}{valid},
+	}} {
+		t.Run(tt.msg, func(t *testing.T) {
+			_, err := NewMaintenanceWindow().CreateFilter(tt.args)
+			if tt.fail && err == nil {
+				t.Error("failed to fail")
+			} else if !tt.fail && err != nil {
+				t.Error(err)
+			}
+		})
+	}
+}
+
+func TestMaintenanceWindow(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "maintenance.yaml")
+	writeMaintenanceSchedule(t, file, testMaintenanceSchedule, time.Now())
+
+	for _, tt := range []struct {
+		msg         string
+		now         time.Time
+		path        string
+		served      bool
+		body        string
+		contentType string
+		retryAfter  string
+	}{{
+		msg:  "before the window",
+		now:  time.Date(2023, 6, 1, 21, 59, 59, 0, time.UTC),
+		path: "/api/orders",
+	}, {
+		msg:         "inside the window",
+		now:         time.Date(2023, 6, 1, 23, 0, 0, 0, time.UTC),
+		path:        "/api/orders",
+		served:      true,
+		body:        `{"error": "maintenance"}`,
+		contentType: "application/json",
+		retryAfter:  "10800",
+	}, {
+		msg:  "inside the window, other path",
+		now:  time.Date(2023, 6, 1, 23, 0, 0, 0, time.UTC),
+		path: "/static/app.js",
+	}, {
+		msg:  "at the end of the window",
+		now:  time.Date(2023, 6, 2, 2, 0, 0, 0, time.UTC),
+		path: "/api/orders",
+	}, {
+		msg:         "inside the window without paths",
+		now:         time.Date(2023, 6, 10, 0, 30, 0, 0, time.UTC),
+		path:        "/static/app.js",
+		served:      true,
+		body:        defaultMaintenanceBody,
+		contentType: defaultMaintenanceContentType,
+		retryAfter:  "1800",
+	}} {
+		t.Run(tt.msg, func(t *testing.T) {
+			now := tt.now
+			f, err := testMaintenanceWindowSpec(&now).CreateFilter([]interface{}{file})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			rsp := serveMaintenanceWindow(t, f, tt.path)
+			if !tt.served {
+				if rsp != nil {
+					t.Errorf("unexpected response: %d", rsp.StatusCode)
+				}
+
+				return
+			}
+
+			if rsp == nil {
+				t.Fatal("failed to serve the maintenance response")
+			}
+
+			b, err := io.ReadAll(rsp.Body)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if rsp.StatusCode != http.StatusServiceUnavailable {
+				t.Errorf("unexpected status: %d", rsp.StatusCode)
+			}
+
+			if string(b) != tt.body {
+				t.Errorf("unexpected body: %s", b)
+			}
+
+			if ct := rsp.Header.Get("Content-Type"); ct != tt.contentType {
+				t.Errorf("unexpected content type: %s", ct)
+			}
+
+			if ra := rsp.Header.Get("Retry-After"); ra != tt.retryAfter {
+				t.Errorf("unexpected Retry-After: %s", ra)
+			}
+		})
+	}
+}
+
+func TestMaintenanceWindowReload(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "maintenance.yaml")
+	modTime := time.Now().Add(-time.Hour)
+	writeMaintenanceSchedule(t, file, "windows: []\n", modTime)
+
+	now := time.Date(2023, 6, 1, 23, 0, 0, 0, time.UTC)
+	f, err := testMaintenanceWindowSpec(&now).CreateFilter([]interface{}{file})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if rsp := serveMaintenanceWindow(t, f, "/api/orders"); rsp != nil {
+		t.Fatal("unexpected maintenance response")
+	}
+
+	writeMaintenanceSchedule(t, file, testMaintenanceSchedule, modTime.Add(time.Minute))
+
+	// not checked again within the check interval
+	if rsp := serveMaintenanceWindow(t, f, "/api/orders"); rsp != nil {
+		t.Fatal("unexpected maintenance response before the check interval")
+	}
+
+	now = now.Add(maintenanceCheckInterval)
+	if rsp := serveMaintenanceWindow(t, f, "/api/orders"); rsp == nil || rsp.StatusCode != http.StatusServiceUnavailable {
+		t.Fatal("failed to reload the schedule")
+	}
+
+	// invalid changes keep the last valid schedule
+	writeMaintenanceSchedule(t, file, "windows: {", modTime.Add(2*time.Minute))
+	now = now.Add(maintenanceCheckInterval)
+	if rsp := serveMaintenanceWindow(t, f, "/api/orders"); rsp == nil || rsp.StatusCode != http.StatusServiceUnavailable {
+		t.Fatal("failed to keep the last valid schedule")
+	}
+}
@@ -0,0 +1,70 @@
+package builtin
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/zalando/skipper/filters"
+)
+
+type maxQueryParamsSpec struct{}
+
+type maxQueryParams int
+
+// NewMaxQueryParams creates a filter specification whose instances reject
+// requests with more query parameters than the configured limit. Every
+// occurrence of a parameter is counted, also the repeated names, and the
+// rejected requests are served with 400 Bad Request.
+//
+// Example:
+//
+//	maxQueryParams(50)
+func NewMaxQueryParams() filters.Spec { return maxQueryParamsSpec{} }
+
+func (maxQueryParamsSpec) Name() string { return filters.MaxQueryParamsName }
+
+func (maxQueryParamsSpec) CreateFilter(args []interface{}) (filters.Filter, error) {
+	if len(args) != 1 {
+		return nil, filters.ErrInvalidFilterParameters
+	}
+
+	var limit int
+	switch v := args[0].(type) {
+	case int:
+		limit = v
+	case float64:
+		limit = int(v)
+	default:
+		return nil, filters.ErrInvalidFilterParameters
+	}
+
+	if limit <= 0 {
+		return nil, filters.ErrInvalidFilterParameters
+	}
+
+	return maxQueryParams(limit), nil
+}
+
+// countQueryParams counts the parameters in the raw query, without parsing
+// it, and stops counting above the limit. Like url.ParseQuery, it skips the
+// empty parts.
+func countQueryParams(query string, limit int) int {
+	var n int
+	for query != "" && n <= limit {
+		var part string
+		part, query, _ = strings.Cut(query, "&")
+		if part != "" {
+			n++
+		}
+	}
+
+	return n
+}
+
+func (limit maxQueryParams) Request(ctx filters.FilterContext) {
+	if countQueryParams(ctx.Request().URL.RawQuery, int(limit)) > int(limit) {
+		ctx.Serve(&http.Response{StatusCode: http.StatusBadRequest})
+	}
+}
+
+func (maxQueryParams) Response(filters.FilterContext) {}
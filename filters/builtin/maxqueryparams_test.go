@@ -0,0 +1,118 @@
+package builtin
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/zalando/skipper/filters/filtertest"
+)
+
+func TestMaxQueryParamsCreate(t *testing.T) {
+	for _, tt := range []struct {
+		msg  string
+		args []interface{}
+		err  bool
+	}{{
+		msg:  "no arguments",
+		args: nil,
+		err:  true,
+	}, {
+		msg:  "too many arguments",
+		args: []interface{}{50.0, 10.0},
+		err:  true,
+	}, {
+		msg:  "wrong type",
+		args: []interface{}{"50"},
+		err:  true,
+	}, {
+		msg:  "non-positive limit",
+		args: []interface{}{0.0},
+		err:  true,
+	}, {
+		msg:  "float limit",
+		args: []interface{}{50.0},
+	}, {
+		msg:  "int limit",
+		args: []interface{}{50},
+	}} {
+		t.Run(tt.msg, func(t *testing.T) {
+			_, err := NewMaxQueryParams().CreateFilter(tt.args)
+			if tt.err && err == nil {
+				t.Error("expected error")
+			} else if !tt.err && err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+		})
+	}
+}
+
+func TestMaxQueryParams(t *testing.T) {
+	const limit = 3
+
+	params := func(n int) string {
+		var p []string
+		for i := 0; i < n; i++ {
+			p = append(p, "p"+strconv.Itoa(i)+"=v")
+		}
+
+		return strings.Join(p, "&")
+	}
+
+	for _, tt := range []struct {
+		msg    string
+		query  string
+		served bool
+	}{{
+		msg: "no query",
+	}, {
+		msg:   "under the limit",
+		query: params(limit - 1),
+	}, {
+		msg:   "at the limit",
+		query: params(limit),
+	}, {
+		msg:    "over the limit",
+		query:  params(limit + 1),
+		served: true,
+	}, {
+		msg:    "far over the limit",
+		query:  params(1000),
+		served: true,
+	}, {
+		msg:    "repeated names counted",
+		query:  "a=1&a=2&a=3&a=4",
+		served: true,
+	}, {
+		msg:    "parameters without value counted",
+		query:  "a&b&c&d",
+		served: true,
+	}, {
+		msg:   "empty parts skipped",
+		query: "a=1&&b=2&&&c=3&",
+	}} {
+		t.Run(tt.msg, func(t *testing.T) {
+			f, err := NewMaxQueryParams().CreateFilter([]interface{}{float64(limit)})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			req, err := http.NewRequest("GET", "http://www.example.org/search?"+tt.query, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			ctx := &filtertest.Context{FRequest: req}
+			f.Request(ctx)
+
+			if ctx.FServed != tt.served {
+				t.Fatalf("expected served: %v, got: %v", tt.served, ctx.FServed)
+			}
+
+			if tt.served && ctx.FResponse.StatusCode != http.StatusBadRequest {
+				t.Errorf("expected status %d, got: %d", http.StatusBadRequest, ctx.FResponse.StatusCode)
+			}
+		})
+	}
+}
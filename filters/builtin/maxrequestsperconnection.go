@@ -0,0 +1,76 @@
+package builtin
+
+import (
+	"github.com/zalando/skipper/filters"
+	"github.com/zalando/skipper/net"
+)
+
+const closeConnectionKey = "filter::maxRequestsPerConnection::close"
+
+type maxRequestsPerConnectionSpec struct{}
+
+type maxRequestsPerConnectionFilter struct {
+	limit int64
+}
+
+// NewMaxRequestsPerConnection creates a filter specification whose
+// instances limit the number of the requests served on a keep-alive client
+// connection, e.g. to force the clients to reconnect periodically through
+// an L4 load balancer. When the number of the request on its connection,
+// as numbered by the proxy, reaches the limit, the filter sets the
+// Connection: close header on the response, so that the server closes the
+// connection after the response, and the client reconnects.
+//
+// The requests are numbered only when the server prepares the connections
+// with net.ConnContext, as the Skipper proxy server does, see
+// net.ServeConnRequest. HTTP/2 connections are not limited, because the
+// Connection header is invalid in HTTP/2.
+//
+// Example:
+//
+//	maxRequestsPerConnection(1000)
+func NewMaxRequestsPerConnection() filters.Spec { return maxRequestsPerConnectionSpec{} }
+
+func (maxRequestsPerConnectionSpec) Name() string { return filters.MaxRequestsPerConnectionName }
+
+func (maxRequestsPerConnectionSpec) CreateFilter(args []interface{}) (filters.Filter, error) {
+	if len(args) != 1 {
+		return nil, filters.ErrInvalidFilterParameters
+	}
+
+	var limit int64
+	switch v := args[0].(type) {
+	case int:
+		limit = int64(v)
+	case float64:
+		limit = int64(v)
+		if float64(limit) != v {
+			return nil, filters.ErrInvalidFilterParameters
+		}
+	default:
+		return nil, filters.ErrInvalidFilterParameters
+	}
+
+	if limit <= 0 {
+		return nil, filters.ErrInvalidFilterParameters
+	}
+
+	return &maxRequestsPerConnectionFilter{limit: limit}, nil
+}
+
+func (f *maxRequestsPerConnectionFilter) Request(ctx filters.FilterContext) {
+	r := ctx.Request()
+	if r.ProtoMajor != 1 {
+		return
+	}
+
+	if n, ok := net.ConnRequestNumber(r); ok && n >= f.limit {
+		ctx.StateBag()[closeConnectionKey] = true
+	}
+}
+
+func (f *maxRequestsPerConnectionFilter) Response(ctx filters.FilterContext) {
+	if close, _ := ctx.StateBag()[closeConnectionKey].(bool); close {
+		ctx.Response().Header.Set("Connection", "close")
+	}
+}
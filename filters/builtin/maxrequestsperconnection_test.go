@@ -0,0 +1,160 @@
+package builtin
+
+import (
+	"io"
+	stdnet "net"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/zalando/skipper/eskip"
+	"github.com/zalando/skipper/filters"
+	"github.com/zalando/skipper/filters/filtertest"
+	"github.com/zalando/skipper/logging/loggingtest"
+	"github.com/zalando/skipper/net"
+	"github.com/zalando/skipper/proxy"
+	"github.com/zalando/skipper/routing"
+	"github.com/zalando/skipper/routing/testdataclient"
+)
+
+func TestMaxRequestsPerConnectionArgs(t *testing.T) {
+	for _, test := range []struct {
+		title string
+		args  []interface{}
+		fail  bool
+	}{{
+		title: "no args",
+		fail:  true,
+	}, {
+		title: "limit",
+		args:  []interface{}{1000.0},
+	}, {
+		title: "int limit",
+		args:  []interface{}{1000},
+	}, {
+		title: "zero",
+		args:  []interface{}{0.0},
+		fail:  true,
+	}, {
+		title: "fraction",
+		args:  []interface{}{1.5},
+		fail:  true,
+	}, {
+		title: "not a number",
+		args:  []interface{}{"1000"},
+		fail:  true,
+	}, {
+		title: "too many args",
+		args:  []interface{}{1000.0, 1000.0},
+		fail:  true,
+	}} {
+		t.Run(test.title, func(t *testing.T) {
+			_, err := NewMaxRequestsPerConnection().CreateFilter(test.args)
+			if test.fail && err == nil {
+				t.Fatal("failed to fail")
+			}
+
+			if !test.fail && err != nil {
+				t.Fatal(err)
+			}
+		})
+	}
+}
+
+func TestMaxRequestsPerConnection(t *testing.T) {
+	routes, err := eskip.Parse(`* -> maxRequestsPerConnection(3) -> status(204) -> <shunt>`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dc := testdataclient.New(routes)
+	defer dc.Close()
+
+	l := loggingtest.New()
+	defer l.Close()
+
+	fr := make(filters.Registry)
+	fr.Register(NewMaxRequestsPerConnection())
+	fr.Register(NewStatus())
+
+	rt := routing.New(routing.Options{
+		DataClients:    []routing.DataClient{dc},
+		FilterRegistry: fr,
+		Log:            l,
+	})
+	defer rt.Close()
+
+	p := proxy.WithParams(proxy.Params{Routing: rt})
+	defer p.Close()
+
+	if err := l.WaitFor("route settings applied", 120*time.Millisecond); err != nil {
+		t.Fatal(err)
+	}
+
+	var (
+		mu    sync.Mutex
+		conns int
+	)
+
+	s := httptest.NewUnstartedServer(p)
+	s.Config.ConnContext = net.ConnContext
+	s.Config.ConnState = func(_ stdnet.Conn, state http.ConnState) {
+		if state == http.StateNew {
+			mu.Lock()
+			conns++
+			mu.Unlock()
+		}
+	}
+
+	s.Start()
+	defer s.Close()
+
+	client := s.Client()
+	for i := 1; i <= 7; i++ {
+		rsp, err := client.Get(s.URL)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		io.Copy(io.Discard, rsp.Body)
+		rsp.Body.Close()
+
+		if rsp.StatusCode != http.StatusNoContent {
+			t.Fatalf("unexpected status code: %d", rsp.StatusCode)
+		}
+
+		// every third request of a connection closes it
+		expectClose := i%3 == 0
+		if rsp.Close != expectClose {
+			t.Errorf("unexpected connection close in request %d: %v", i, rsp.Close)
+		}
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if conns != 3 {
+		t.Errorf("unexpected number of connections: %d", conns)
+	}
+}
+
+func TestMaxRequestsPerConnectionWithoutConnContext(t *testing.T) {
+	f, err := NewMaxRequestsPerConnection().CreateFilter([]interface{}{1.0})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	req := httptest.NewRequest("GET", "https://www.example.org", nil)
+	ctx := &filtertest.Context{
+		FRequest:  req,
+		FResponse: &http.Response{Header: make(http.Header)},
+		FStateBag: make(map[string]interface{}),
+	}
+
+	f.Request(ctx)
+	f.Response(ctx)
+	if h := ctx.FResponse.Header.Get("Connection"); h != "" {
+		t.Errorf("unexpected Connection header: %s", h)
+	}
+}
@@ -0,0 +1,59 @@
+package builtin
+
+import (
+	"net/http"
+
+	"github.com/zalando/skipper/filters"
+)
+
+type maxURLLengthSpec struct{}
+
+type maxURLLength int
+
+// NewMaxURLLength creates a filter specification whose instances reject
+// requests with a request-target longer than the configured limit. The
+// length is measured on the raw request-target as received by the proxy,
+// and the rejected requests are served with 414 URI Too Long.
+//
+// Example:
+//
+//	maxURLLength(2048)
+func NewMaxURLLength() filters.Spec { return maxURLLengthSpec{} }
+
+func (maxURLLengthSpec) Name() string { return filters.MaxURLLengthName }
+
+func (maxURLLengthSpec) CreateFilter(args []interface{}) (filters.Filter, error) {
+	if len(args) != 1 {
+		return nil, filters.ErrInvalidFilterParameters
+	}
+
+	var limit int
+	switch v := args[0].(type) {
+	case int:
+		limit = v
+	case float64:
+		limit = int(v)
+	default:
+		return nil, filters.ErrInvalidFilterParameters
+	}
+
+	if limit <= 0 {
+		return nil, filters.ErrInvalidFilterParameters
+	}
+
+	return maxURLLength(limit), nil
+}
+
+func (limit maxURLLength) Request(ctx filters.FilterContext) {
+	req := ctx.Request()
+	target := req.RequestURI
+	if target == "" {
+		target = req.URL.RequestURI()
+	}
+
+	if len(target) > int(limit) {
+		ctx.Serve(&http.Response{StatusCode: http.StatusRequestURITooLong})
+	}
+}
+
+func (maxURLLength) Response(filters.FilterContext) {}
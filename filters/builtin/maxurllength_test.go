@@ -0,0 +1,94 @@
+package builtin
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/zalando/skipper/filters/filtertest"
+)
+
+func TestMaxURLLengthCreate(t *testing.T) {
+	for _, tt := range []struct {
+		msg  string
+		args []interface{}
+		err  bool
+	}{{
+		msg:  "no arguments",
+		args: nil,
+		err:  true,
+	}, {
+		msg:  "too many arguments",
+		args: []interface{}{2048.0, 1024.0},
+		err:  true,
+	}, {
+		msg:  "wrong type",
+		args: []interface{}{"2048"},
+		err:  true,
+	}, {
+		msg:  "non-positive limit",
+		args: []interface{}{0.0},
+		err:  true,
+	}, {
+		msg:  "float limit",
+		args: []interface{}{2048.0},
+	}, {
+		msg:  "int limit",
+		args: []interface{}{2048},
+	}} {
+		t.Run(tt.msg, func(t *testing.T) {
+			_, err := NewMaxURLLength().CreateFilter(tt.args)
+			if tt.err && err == nil {
+				t.Error("expected error")
+			} else if !tt.err && err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+		})
+	}
+}
+
+func TestMaxURLLength(t *testing.T) {
+	const limit = 32
+
+	for _, tt := range []struct {
+		msg    string
+		length int
+		served bool
+	}{{
+		msg:    "under the limit",
+		length: limit - 1,
+	}, {
+		msg:    "at the limit",
+		length: limit,
+	}, {
+		msg:    "over the limit",
+		length: limit + 1,
+		served: true,
+	}} {
+		t.Run(tt.msg, func(t *testing.T) {
+			f, err := NewMaxURLLength().CreateFilter([]interface{}{float64(limit)})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			// request-target: "/" + path + "?q=" + query
+			target := "/" + strings.Repeat("a", tt.length-len("/?q=x")) + "?q=x"
+			req, err := http.NewRequest("GET", "http://www.example.org"+target, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			req.RequestURI = target
+
+			ctx := &filtertest.Context{FRequest: req}
+			f.Request(ctx)
+
+			if ctx.FServed != tt.served {
+				t.Fatalf("expected served: %v, got: %v", tt.served, ctx.FServed)
+			}
+
+			if tt.served && ctx.FResponse.StatusCode != http.StatusRequestURITooLong {
+				t.Errorf("expected status %d, got: %d", http.StatusRequestURITooLong, ctx.FResponse.StatusCode)
+			}
+		})
+	}
+}
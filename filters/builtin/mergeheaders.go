@@ -0,0 +1,105 @@
+package builtin
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/zalando/skipper/filters"
+	"golang.org/x/net/http/httpguts"
+)
+
+type mergeHeadersSpec struct {
+	response bool
+}
+
+type mergeHeadersFilter struct {
+	response bool
+	headers  []string
+}
+
+// NewMergeRequestHeaders creates a filter specification whose instances
+// join the repeated instances of the named request headers into a single
+// comma separated value, for backends that mishandle repeated headers.
+// The repeated Cookie headers are joined with "; ", as defined by RFC
+// 6265.
+//
+// Example:
+//
+//	mergeRequestHeaders("X-Forwarded-For", "Accept")
+func NewMergeRequestHeaders() filters.Spec { return mergeHeadersSpec{} }
+
+// NewMergeResponseHeaders creates a filter specification whose instances
+// join the repeated instances of the named response headers into a single
+// comma separated value.
+//
+// Example:
+//
+//	mergeResponseHeaders("Vary", "Cache-Control")
+func NewMergeResponseHeaders() filters.Spec { return mergeHeadersSpec{response: true} }
+
+func (s mergeHeadersSpec) Name() string {
+	if s.response {
+		return filters.MergeResponseHeadersName
+	}
+
+	return filters.MergeRequestHeadersName
+}
+
+func (s mergeHeadersSpec) CreateFilter(args []interface{}) (filters.Filter, error) {
+	if len(args) == 0 {
+		return nil, filters.ErrInvalidFilterParameters
+	}
+
+	f := &mergeHeadersFilter{response: s.response}
+	for _, a := range args {
+		name, ok := a.(string)
+		if !ok {
+			return nil, filters.ErrInvalidFilterParameters
+		}
+
+		if !httpguts.ValidHeaderFieldName(name) {
+			return nil, fmt.Errorf("header name %s is invalid", name)
+		}
+
+		name = http.CanonicalHeaderKey(name)
+
+		// the values of Set-Cookie may contain commas, e.g. in the
+		// expiry date, and therefore they cannot be joined
+		if name == "Set-Cookie" {
+			return nil, fmt.Errorf("header %s cannot be merged", name)
+		}
+
+		f.headers = append(f.headers, name)
+	}
+
+	return f, nil
+}
+
+func (f *mergeHeadersFilter) merge(h http.Header) {
+	for _, name := range f.headers {
+		values := h[name]
+		if len(values) < 2 {
+			continue
+		}
+
+		separator := ", "
+		if name == "Cookie" {
+			separator = "; "
+		}
+
+		h[name] = []string{strings.Join(values, separator)}
+	}
+}
+
+func (f *mergeHeadersFilter) Request(ctx filters.FilterContext) {
+	if !f.response {
+		f.merge(ctx.Request().Header)
+	}
+}
+
+func (f *mergeHeadersFilter) Response(ctx filters.FilterContext) {
+	if f.response {
+		f.merge(ctx.Response().Header)
+	}
+}
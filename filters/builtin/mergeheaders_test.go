@@ -0,0 +1,142 @@
+package builtin
+
+import (
+	"net/http"
+	"reflect"
+	"testing"
+
+	"github.com/zalando/skipper/filters/filtertest"
+)
+
+func TestMergeHeadersCreate(t *testing.T) {
+	for _, tt := range []struct {
+		msg  string
+		args []interface{}
+		err  bool
+	}{{
+		msg: "no args",
+		err: true,
+	}, {
+		msg:  "not a string",
+		args: []interface{}{"Accept", 42.0},
+		err:  true,
+	}, {
+		msg:  "invalid header name",
+		args: []interface{}{"X Foo"},
+		err:  true,
+	}, {
+		msg:  "Set-Cookie",
+		args: []interface{}{"set-cookie"},
+		err:  true,
+	}, {
+		msg:  "headers",
+		args: []interface{}{"X-Forwarded-For", "Accept"},
+	}} {
+		t.Run(tt.msg, func(t *testing.T) {
+			_, reqErr := NewMergeRequestHeaders().CreateFilter(tt.args)
+			_, rspErr := NewMergeResponseHeaders().CreateFilter(tt.args)
+			for _, err := range []error{reqErr, rspErr} {
+				if tt.err && err == nil {
+					t.Error("expected error")
+				} else if !tt.err && err != nil {
+					t.Errorf("unexpected error: %v", err)
+				}
+			}
+		})
+	}
+}
+
+func TestMergeHeaders(t *testing.T) {
+	for _, tt := range []struct {
+		msg      string
+		args     []interface{}
+		header   http.Header
+		expected http.Header
+	}{{
+		msg:  "repeated headers",
+		args: []interface{}{"x-forwarded-for", "Accept"},
+		header: http.Header{
+			"X-Forwarded-For": []string{"192.0.2.1", "192.0.2.2, 192.0.2.3"},
+			"Accept":          []string{"text/html", "application/json"},
+		},
+		expected: http.Header{
+			"X-Forwarded-For": []string{"192.0.2.1, 192.0.2.2, 192.0.2.3"},
+			"Accept":          []string{"text/html, application/json"},
+		},
+	}, {
+		msg:      "single value",
+		args:     []interface{}{"Accept"},
+		header:   http.Header{"Accept": []string{"text/html"}},
+		expected: http.Header{"Accept": []string{"text/html"}},
+	}, {
+		msg:      "missing header",
+		args:     []interface{}{"Accept"},
+		header:   http.Header{"X-Foo": []string{"foo", "bar"}},
+		expected: http.Header{"X-Foo": []string{"foo", "bar"}},
+	}, {
+		msg:  "other headers are not merged",
+		args: []interface{}{"Accept"},
+		header: http.Header{
+			"Accept": []string{"text/html", "application/json"},
+			"X-Foo":  []string{"foo", "bar"},
+		},
+		expected: http.Header{
+			"Accept": []string{"text/html, application/json"},
+			"X-Foo":  []string{"foo", "bar"},
+		},
+	}} {
+		t.Run(tt.msg, func(t *testing.T) {
+			f, err := NewMergeRequestHeaders().CreateFilter(tt.args)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			req := &http.Request{Header: tt.header.Clone()}
+			rsp := &http.Response{Header: tt.header.Clone()}
+			ctx := &filtertest.Context{FRequest: req, FResponse: rsp}
+			f.Request(ctx)
+			f.Response(ctx)
+
+			if !reflect.DeepEqual(req.Header, tt.expected) {
+				t.Errorf("request: expected %v, got %v", tt.expected, req.Header)
+			}
+
+			if !reflect.DeepEqual(rsp.Header, tt.header) {
+				t.Errorf("response should not be modified, got %v", rsp.Header)
+			}
+
+			f, err = NewMergeResponseHeaders().CreateFilter(tt.args)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			req = &http.Request{Header: tt.header.Clone()}
+			rsp = &http.Response{Header: tt.header.Clone()}
+			ctx = &filtertest.Context{FRequest: req, FResponse: rsp}
+			f.Request(ctx)
+			f.Response(ctx)
+
+			if !reflect.DeepEqual(rsp.Header, tt.expected) {
+				t.Errorf("response: expected %v, got %v", tt.expected, rsp.Header)
+			}
+
+			if !reflect.DeepEqual(req.Header, tt.header) {
+				t.Errorf("request should not be modified, got %v", req.Header)
+			}
+		})
+	}
+}
+
+func TestMergeRequestCookieHeaders(t *testing.T) {
+	f, err := NewMergeRequestHeaders().CreateFilter([]interface{}{"Cookie"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	req := &http.Request{Header: http.Header{"Cookie": []string{"a=1", "b=2"}}}
+	f.Request(&filtertest.Context{FRequest: req})
+
+	if got := req.Header["Cookie"]; len(got) != 1 || got[0] != "a=1; b=2" {
+		t.Errorf("unexpected cookie header: %v", got)
+	}
+}
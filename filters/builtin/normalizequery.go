@@ -0,0 +1,160 @@
+package builtin
+
+import (
+	"net/http"
+	"net/url"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/zalando/skipper/filters"
+)
+
+type normalizeQuerySpec struct{}
+
+type normalizeQueryFilter struct {
+	drop         []string
+	sort         bool
+	redirectCode int
+}
+
+type queryParam struct {
+	key string
+	raw string
+}
+
+// NewNormalizeQuery creates a filter specification whose instances
+// canonicalize the query string of the request, by dropping the
+// parameters matching one of the configured patterns, and optionally
+// sorting the remaining parameters by their key. The order of the values
+// of the same key is preserved, and the parameters are kept in their
+// original encoding.
+//
+// The filter accepts the following options as string arguments:
+//
+//   - "drop=<pattern>,<pattern>...": drops the parameters whose key matches
+//     one of the patterns, using the syntax of path.Match, e.g. "utm_*"
+//   - "sort": sorts the parameters by their key
+//   - "redirect" or "redirect=<code>": when the query of a GET or HEAD
+//     request is not canonical, redirects the client to the canonical URL,
+//     by default with 301, instead of forwarding the request
+//
+// Example:
+//
+//	normalizeQuery("drop=utm_*,fbclid", "sort", "redirect")
+func NewNormalizeQuery() filters.Spec { return normalizeQuerySpec{} }
+
+func (normalizeQuerySpec) Name() string { return filters.NormalizeQueryName }
+
+func (normalizeQuerySpec) CreateFilter(args []interface{}) (filters.Filter, error) {
+	if len(args) == 0 {
+		return nil, filters.ErrInvalidFilterParameters
+	}
+
+	f := &normalizeQueryFilter{}
+	for _, a := range args {
+		s, ok := a.(string)
+		if !ok {
+			return nil, filters.ErrInvalidFilterParameters
+		}
+
+		option, value, hasValue := strings.Cut(s, "=")
+		switch {
+		case option == "drop" && hasValue:
+			for _, p := range strings.Split(value, ",") {
+				p = strings.TrimSpace(p)
+				if p == "" {
+					return nil, filters.ErrInvalidFilterParameters
+				}
+
+				if _, err := path.Match(p, ""); err != nil {
+					return nil, filters.ErrInvalidFilterParameters
+				}
+
+				f.drop = append(f.drop, p)
+			}
+		case option == "sort" && !hasValue:
+			f.sort = true
+		case option == "redirect" && !hasValue:
+			f.redirectCode = http.StatusMovedPermanently
+		case option == "redirect":
+			code, err := strconv.Atoi(value)
+			if err != nil {
+				return nil, filters.ErrInvalidFilterParameters
+			}
+
+			switch code {
+			case http.StatusMovedPermanently, http.StatusFound, http.StatusTemporaryRedirect, http.StatusPermanentRedirect:
+				f.redirectCode = code
+			default:
+				return nil, filters.ErrInvalidFilterParameters
+			}
+		default:
+			return nil, filters.ErrInvalidFilterParameters
+		}
+	}
+
+	return f, nil
+}
+
+func (f *normalizeQueryFilter) dropped(key string) bool {
+	for _, p := range f.drop {
+		if m, _ := path.Match(p, key); m {
+			return true
+		}
+	}
+
+	return false
+}
+
+// normalize returns the canonical form of the raw query.
+func (f *normalizeQueryFilter) normalize(rawQuery string) string {
+	var params []queryParam
+	for _, raw := range strings.Split(rawQuery, "&") {
+		if raw == "" {
+			continue
+		}
+
+		key, _, _ := strings.Cut(raw, "=")
+		if k, err := url.QueryUnescape(key); err == nil {
+			key = k
+		}
+
+		if f.dropped(key) {
+			continue
+		}
+
+		params = append(params, queryParam{key: key, raw: raw})
+	}
+
+	if f.sort {
+		sort.SliceStable(params, func(i, j int) bool { return params[i].key < params[j].key })
+	}
+
+	raw := make([]string, len(params))
+	for i, p := range params {
+		raw[i] = p.raw
+	}
+
+	return strings.Join(raw, "&")
+}
+
+func (f *normalizeQueryFilter) Request(ctx filters.FilterContext) {
+	r := ctx.Request()
+	if r.URL.RawQuery == "" {
+		return
+	}
+
+	q := f.normalize(r.URL.RawQuery)
+	if q == r.URL.RawQuery {
+		return
+	}
+
+	r.URL.RawQuery = q
+	if f.redirectCode != 0 && (r.Method == http.MethodGet || r.Method == http.MethodHead) {
+		redirectWithType(ctx, f.redirectCode, &url.URL{Path: r.URL.Path, RawPath: r.URL.RawPath}, redTo)
+	}
+}
+
+func (*normalizeQueryFilter) Response(filters.FilterContext) {}
@@ -0,0 +1,156 @@
+package builtin
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/zalando/skipper/filters/filtertest"
+)
+
+func TestNormalizeQueryArgs(t *testing.T) {
+	for _, tt := range []struct {
+		msg  string
+		args []interface{}
+		fail bool
+	}{{
+		msg:  "no args",
+		fail: true,
+	}, {
+		msg:  "invalid type",
+		args: []interface{}{1.0},
+		fail: true,
+	}, {
+		msg:  "unknown option",
+		args: []interface{}{"shuffle"},
+		fail: true,
+	}, {
+		msg:  "empty pattern",
+		args: []interface{}{"drop=utm_*,"},
+		fail: true,
+	}, {
+		msg:  "invalid pattern",
+		args: []interface{}{"drop=[utm"},
+		fail: true,
+	}, {
+		msg:  "invalid redirect code",
+		args: []interface{}{"redirect=200"},
+		fail: true,
+	}, {
+		msg:  "all options",
+		args: []interface{}{"drop=utm_*,fbclid", "sort", "redirect=308"},
+	}} {
+		t.Run(tt.msg, func(t *testing.T) {
+			_, err := NewNormalizeQuery().CreateFilter(tt.args)
+			if tt.fail && err == nil {
+				t.Error("failed to fail")
+			} else if !tt.fail && err != nil {
+				t.Error(err)
+			}
+		})
+	}
+}
+
+func TestNormalizeQuery(t *testing.T) {
+	for _, tt := range []struct {
+		msg              string
+		args             []interface{}
+		method           string
+		url              string
+		expectedQuery    string
+		expectedLocation string
+		expectedStatus   int
+	}{{
+		msg:           "drop tracking params",
+		args:          []interface{}{"drop=utm_*,fbclid"},
+		url:           "https://www.example.org/foo?b=2&utm_source=x&a=1&fbclid=y&utm_medium=z",
+		expectedQuery: "b=2&a=1",
+	}, {
+		msg:           "drop escaped key",
+		args:          []interface{}{"drop=utm_*"},
+		url:           "https://www.example.org/foo?utm%5Fsource=x&a=1",
+		expectedQuery: "a=1",
+	}, {
+		msg:           "sort keeps the order of the values",
+		args:          []interface{}{"sort"},
+		url:           "https://www.example.org/foo?c=3&a=2&b=1&a=1",
+		expectedQuery: "a=2&a=1&b=1&c=3",
+	}, {
+		msg:           "keep encoding",
+		args:          []interface{}{"sort"},
+		url:           "https://www.example.org/foo?q=a+b%2Fc&a",
+		expectedQuery: "a&q=a+b%2Fc",
+	}, {
+		msg:           "drop and sort",
+		args:          []interface{}{"drop=utm_*,fbclid", "sort"},
+		url:           "https://www.example.org/foo?b=2&utm_source=x&a=1&&fbclid=y",
+		expectedQuery: "a=1&b=2",
+	}, {
+		msg:           "canonical query in redirect mode",
+		args:          []interface{}{"drop=utm_*", "sort", "redirect"},
+		url:           "https://www.example.org/foo?a=1&b=2",
+		expectedQuery: "a=1&b=2",
+	}, {
+		msg:              "redirect",
+		args:             []interface{}{"drop=utm_*", "sort", "redirect"},
+		url:              "https://www.example.org/foo?b=2&utm_source=x&a=1",
+		expectedQuery:    "a=1&b=2",
+		expectedStatus:   http.StatusMovedPermanently,
+		expectedLocation: "https://www.example.org/foo?a=1&b=2",
+	}, {
+		msg:              "redirect with custom code, all dropped",
+		args:             []interface{}{"drop=utm_*", "redirect=308"},
+		url:              "https://www.example.org/foo?utm_source=x",
+		expectedStatus:   http.StatusPermanentRedirect,
+		expectedLocation: "https://www.example.org/foo",
+	}, {
+		msg:           "no redirect for unsafe methods",
+		args:          []interface{}{"drop=utm_*", "redirect"},
+		method:        "POST",
+		url:           "https://www.example.org/foo?utm_source=x&a=1",
+		expectedQuery: "a=1",
+	}} {
+		t.Run(tt.msg, func(t *testing.T) {
+			f, err := NewNormalizeQuery().CreateFilter(tt.args)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			method := tt.method
+			if method == "" {
+				method = "GET"
+			}
+
+			req, err := http.NewRequest(method, tt.url, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			ctx := &filtertest.Context{FRequest: req}
+			f.Request(ctx)
+
+			if ctx.FRequest.URL.RawQuery != tt.expectedQuery {
+				t.Errorf("expected query: %q, got: %q", tt.expectedQuery, ctx.FRequest.URL.RawQuery)
+			}
+
+			if tt.expectedStatus == 0 {
+				if ctx.FServed {
+					t.Errorf("unexpected response: %d", ctx.FResponse.StatusCode)
+				}
+
+				return
+			}
+
+			if !ctx.FServed {
+				t.Fatal("expected redirect")
+			}
+
+			if ctx.FResponse.StatusCode != tt.expectedStatus {
+				t.Errorf("expected status: %d, got: %d", tt.expectedStatus, ctx.FResponse.StatusCode)
+			}
+
+			if l := ctx.FResponse.Header.Get("Location"); l != tt.expectedLocation {
+				t.Errorf("expected location: %s, got: %s", tt.expectedLocation, l)
+			}
+		})
+	}
+}
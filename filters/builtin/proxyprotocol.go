@@ -0,0 +1,42 @@
+package builtin
+
+import (
+	"github.com/zalando/skipper/filters"
+)
+
+type sendProxyProtocolSpec struct{}
+
+type sendProxyProtocolFilter string
+
+// NewSendProxyProtocol creates a filter specification whose instances make
+// the proxy send the PROXY protocol header, version "v1" or "v2", to the
+// backend, carrying the address of the client and the address that the
+// client connected to. The header is sent right after the TCP connection
+// to the backend is established. Since the connections to the backend are
+// then tied to a single client connection, they are not reused.
+//
+// Example:
+//
+//	sendProxyProtocol("v2")
+func NewSendProxyProtocol() filters.Spec { return sendProxyProtocolSpec{} }
+
+func (sendProxyProtocolSpec) Name() string { return filters.SendProxyProtocolName }
+
+func (sendProxyProtocolSpec) CreateFilter(args []interface{}) (filters.Filter, error) {
+	if len(args) != 1 {
+		return nil, filters.ErrInvalidFilterParameters
+	}
+
+	switch v, _ := args[0].(string); v {
+	case "v1", "v2":
+		return sendProxyProtocolFilter(v), nil
+	default:
+		return nil, filters.ErrInvalidFilterParameters
+	}
+}
+
+func (f sendProxyProtocolFilter) Request(ctx filters.FilterContext) {
+	ctx.StateBag()[filters.ProxyProtocolKey] = string(f)
+}
+
+func (sendProxyProtocolFilter) Response(filters.FilterContext) {}
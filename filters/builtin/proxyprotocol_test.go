@@ -0,0 +1,58 @@
+package builtin
+
+import (
+	"testing"
+
+	"github.com/zalando/skipper/filters"
+	"github.com/zalando/skipper/filters/filtertest"
+)
+
+func TestSendProxyProtocol(t *testing.T) {
+	for _, tt := range []struct {
+		msg  string
+		args []interface{}
+		err  bool
+	}{{
+		msg: "no args",
+		err: true,
+	}, {
+		msg:  "too many args",
+		args: []interface{}{"v1", "v2"},
+		err:  true,
+	}, {
+		msg:  "invalid version",
+		args: []interface{}{"v3"},
+		err:  true,
+	}, {
+		msg:  "not a string",
+		args: []interface{}{2.0},
+		err:  true,
+	}, {
+		msg:  "v1",
+		args: []interface{}{"v1"},
+	}, {
+		msg:  "v2",
+		args: []interface{}{"v2"},
+	}} {
+		t.Run(tt.msg, func(t *testing.T) {
+			f, err := NewSendProxyProtocol().CreateFilter(tt.args)
+			if tt.err {
+				if err == nil {
+					t.Error("expected error")
+				}
+
+				return
+			}
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			ctx := &filtertest.Context{FStateBag: make(map[string]interface{})}
+			f.Request(ctx)
+			if v := ctx.FStateBag[filters.ProxyProtocolKey]; v != tt.args[0] {
+				t.Errorf("unexpected version in the state bag: %v", v)
+			}
+		})
+	}
+}
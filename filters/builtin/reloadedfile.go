@@ -0,0 +1,115 @@
+package builtin
+
+import (
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// reloadedFiles holds the files referenced by the filters of a
+// specification, parsed into the content type T. The filters referencing
+// the same file share its content.
+type reloadedFiles[T any] struct {
+	// the name of the filter, used in the logs
+	name string
+
+	// what the file contains, used in the errors
+	description string
+
+	parse         func([]byte) (T, error)
+	now           func() time.Time
+	checkInterval time.Duration
+
+	mu    sync.Mutex
+	files map[string]*reloadedFile[T]
+}
+
+// reloadedFile holds the content of a file. On access, the file is checked
+// for changes at most once per check interval, and reloaded when its
+// modification time has changed. When the changed file is invalid, the last
+// valid content is kept.
+type reloadedFile[T any] struct {
+	owner *reloadedFiles[T]
+	file  string
+
+	mu      sync.Mutex
+	checked time.Time
+	modTime time.Time
+	content T
+}
+
+func newReloadedFiles[T any](name, description string, parse func([]byte) (T, error), checkInterval time.Duration) reloadedFiles[T] {
+	return reloadedFiles[T]{
+		name:          name,
+		description:   description,
+		parse:         parse,
+		now:           time.Now,
+		checkInterval: checkInterval,
+		files:         make(map[string]*reloadedFile[T]),
+	}
+}
+
+// get returns the shared file, loading it on first use.
+func (rf *reloadedFiles[T]) get(file string) (*reloadedFile[T], error) {
+	rf.mu.Lock()
+	defer rf.mu.Unlock()
+
+	if f, ok := rf.files[file]; ok {
+		return f, nil
+	}
+
+	f := &reloadedFile[T]{owner: rf, file: file}
+	if err := f.load(); err != nil {
+		return nil, err
+	}
+
+	rf.files[file] = f
+	return f, nil
+}
+
+// load reads the file, when it has changed. It expects the lock to be held,
+// or to be called before the file is shared.
+func (f *reloadedFile[T]) load() error {
+	fi, err := os.Stat(f.file)
+	if err != nil {
+		return err
+	}
+
+	f.checked = f.owner.now()
+	if fi.ModTime().Equal(f.modTime) {
+		return nil
+	}
+
+	data, err := os.ReadFile(f.file)
+	if err != nil {
+		return err
+	}
+
+	content, err := f.owner.parse(data)
+	if err != nil {
+		return fmt.Errorf("failed to load %s %s: %w", f.owner.description, f.file, err)
+	}
+
+	f.content = content
+	f.modTime = fi.ModTime()
+	return nil
+}
+
+// get returns the current content of the file, and the time of the access.
+func (f *reloadedFile[T]) get() (T, time.Time) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	now := f.owner.now()
+	if now.Sub(f.checked) >= f.owner.checkInterval {
+		// keeping the last valid content on errors
+		if err := f.load(); err != nil {
+			log.Errorf("%s: %v", f.owner.name, err)
+		}
+	}
+
+	return f.content, now
+}
@@ -0,0 +1,322 @@
+package builtin
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"mime"
+	"strconv"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/zalando/skipper/filters"
+)
+
+type reorderStreamSpec struct{}
+
+type reorderStreamFilter struct {
+	field   string
+	window  time.Duration
+	maxSize int
+}
+
+type streamEvent struct {
+	data     []byte
+	seq      int64
+	hasSeq   bool
+	received time.Time
+}
+
+type reorderedBody struct {
+	filter   *reorderStreamFilter
+	upstream io.ReadCloser
+	reader   *io.PipeReader
+	writer   *io.PipeWriter
+	events   chan *streamEvent
+	quit     chan struct{}
+	once     sync.Once
+
+	// set before closing the events channel
+	readErr error
+
+	// owned by the run goroutine
+	next     int64
+	started  bool
+	buffered map[int64]*streamEvent
+}
+
+// NewReorderStream creates a filter specification whose instances reorder
+// the events of server-sent event streams, text/event-stream, by a sequence
+// number, for backends that emit the events out of order. The sequence
+// number is read from the event field with the given name, e.g. "id" or a
+// custom field like "X-Seq".
+//
+// The first event defines the start of the sequence. The events following
+// a gap in the sequence are buffered until the missing events arrive, or
+// until the oldest buffered event has waited for the time window, or the
+// buffer reaches its maximum size. Then the buffered events are flushed in
+// order, skipping the gap, and the missing events arriving later are
+// dropped. The events without a sequence number are passed through.
+//
+// Example:
+//
+//	events: Path("/events") -> reorderStream("X-Seq", "2s", 100) -> "https://events.example.org";
+func NewReorderStream() filters.Spec { return reorderStreamSpec{} }
+
+func (reorderStreamSpec) Name() string { return filters.ReorderStreamName }
+
+func (reorderStreamSpec) CreateFilter(args []interface{}) (filters.Filter, error) {
+	if len(args) != 3 {
+		return nil, filters.ErrInvalidFilterParameters
+	}
+
+	field, ok := args[0].(string)
+	if !ok || field == "" || bytes.ContainsAny([]byte(field), ":\r\n") {
+		return nil, filters.ErrInvalidFilterParameters
+	}
+
+	window, err := positiveDurationArg(args[1])
+	if err != nil {
+		return nil, filters.ErrInvalidFilterParameters
+	}
+
+	var maxSize int
+	switch v := args[2].(type) {
+	case int:
+		maxSize = v
+	case float64:
+		maxSize = int(v)
+	default:
+		return nil, filters.ErrInvalidFilterParameters
+	}
+
+	if maxSize <= 0 {
+		return nil, filters.ErrInvalidFilterParameters
+	}
+
+	return &reorderStreamFilter{field: field, window: window, maxSize: maxSize}, nil
+}
+
+// sequence returns the sequence number of the event, from the field with
+// the configured name.
+func (f *reorderStreamFilter) sequence(event []byte) (int64, bool) {
+	for _, line := range bytes.Split(event, []byte("\n")) {
+		name, value, _ := bytes.Cut(bytes.TrimRight(line, "\r"), []byte(":"))
+		if string(name) != f.field {
+			continue
+		}
+
+		seq, err := strconv.ParseInt(string(bytes.TrimPrefix(value, []byte(" "))), 10, 64)
+		return seq, err == nil
+	}
+
+	return 0, false
+}
+
+func newReorderedBody(f *reorderStreamFilter, upstream io.ReadCloser) *reorderedBody {
+	pr, pw := io.Pipe()
+	b := &reorderedBody{
+		filter:   f,
+		upstream: upstream,
+		reader:   pr,
+		writer:   pw,
+		events:   make(chan *streamEvent),
+		quit:     make(chan struct{}),
+		buffered: make(map[int64]*streamEvent),
+	}
+
+	go b.readEvents()
+	go b.run()
+	return b
+}
+
+// readEvents reads the events from the upstream, separated by empty lines.
+func (b *reorderedBody) readEvents() {
+	defer close(b.events)
+
+	r := bufio.NewReader(b.upstream)
+	var event []byte
+	for {
+		line, err := r.ReadBytes('\n')
+		event = append(event, line...)
+		if len(event) > 0 && (err != nil || len(bytes.TrimRight(line, "\r\n")) == 0) {
+			e := &streamEvent{data: event, received: time.Now()}
+			e.seq, e.hasSeq = b.filter.sequence(event)
+			select {
+			case b.events <- e:
+			case <-b.quit:
+				return
+			}
+
+			event = nil
+		}
+
+		if err != nil {
+			if err != io.EOF {
+				b.readErr = err
+			}
+
+			return
+		}
+	}
+}
+
+func (b *reorderedBody) write(e *streamEvent) bool {
+	_, err := b.writer.Write(e.data)
+	return err == nil
+}
+
+// drain writes the buffered events following the last written one.
+func (b *reorderedBody) drain() bool {
+	for {
+		e, ok := b.buffered[b.next]
+		if !ok {
+			return true
+		}
+
+		delete(b.buffered, b.next)
+		b.next++
+		if !b.write(e) {
+			return false
+		}
+	}
+}
+
+// oldest returns the buffered event with the lowest sequence number, and
+// the time when the earliest buffered event was received.
+func (b *reorderedBody) oldest() (*streamEvent, time.Time) {
+	var (
+		lowest   *streamEvent
+		earliest time.Time
+	)
+
+	for _, e := range b.buffered {
+		if lowest == nil || e.seq < lowest.seq {
+			lowest = e
+		}
+
+		if earliest.IsZero() || e.received.Before(earliest) {
+			earliest = e.received
+		}
+	}
+
+	return lowest, earliest
+}
+
+// skip gives up waiting for the missing events, and continues the sequence
+// with the lowest buffered event.
+func (b *reorderedBody) skip() bool {
+	lowest, _ := b.oldest()
+	if lowest == nil {
+		return true
+	}
+
+	b.next = lowest.seq
+	return b.drain()
+}
+
+func (b *reorderedBody) receive(e *streamEvent) bool {
+	switch {
+	case !e.hasSeq:
+		return b.write(e)
+	case !b.started:
+		b.started = true
+		b.next = e.seq + 1
+		return b.write(e)
+	case e.seq < b.next:
+		log.Debugf("%s: dropping late event %d", filters.ReorderStreamName, e.seq)
+		return true
+	case e.seq == b.next:
+		b.next++
+		return b.write(e) && b.drain()
+	default:
+		b.buffered[e.seq] = e
+		if len(b.buffered) >= b.filter.maxSize {
+			return b.skip()
+		}
+
+		return true
+	}
+}
+
+func (b *reorderedBody) run() {
+	timer := time.NewTimer(b.filter.window)
+	defer timer.Stop()
+
+	for {
+		var timeout <-chan time.Time
+		if len(b.buffered) > 0 {
+			_, earliest := b.oldest()
+			if !timer.Stop() {
+				select {
+				case <-timer.C:
+				default:
+				}
+			}
+
+			timer.Reset(time.Until(earliest.Add(b.filter.window)))
+			timeout = timer.C
+		}
+
+		ok := true
+		select {
+		case e, open := <-b.events:
+			if !open {
+				// flush the rest in order
+				for ok && len(b.buffered) > 0 {
+					ok = b.skip()
+				}
+
+				b.writer.CloseWithError(b.readErr)
+				return
+			}
+
+			ok = b.receive(e)
+		case <-timeout:
+			ok = b.skip()
+		case <-b.quit:
+			return
+		}
+
+		if !ok {
+			b.Close()
+			return
+		}
+	}
+}
+
+func (b *reorderedBody) Read(p []byte) (int, error) {
+	return b.reader.Read(p)
+}
+
+func (b *reorderedBody) Close() error {
+	var err error
+	b.once.Do(func() {
+		close(b.quit)
+		b.reader.Close()
+		err = b.upstream.Close()
+	})
+
+	return err
+}
+
+func (f *reorderStreamFilter) Request(filters.FilterContext) {}
+
+func (f *reorderStreamFilter) Response(ctx filters.FilterContext) {
+	rsp := ctx.Response()
+	if rsp.Body == nil {
+		return
+	}
+
+	if mt, _, err := mime.ParseMediaType(rsp.Header.Get("Content-Type")); err != nil || mt != "text/event-stream" {
+		return
+	}
+
+	rsp.Body = newReorderedBody(f, rsp.Body)
+
+	// late events are dropped
+	rsp.ContentLength = -1
+	rsp.Header.Del("Content-Length")
+}
@@ -0,0 +1,247 @@
+package builtin
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/zalando/skipper/filters/filtertest"
+)
+
+func TestReorderStreamArgs(t *testing.T) {
+	for _, tt := range []struct {
+		msg  string
+		args []interface{}
+		fail bool
+	}{{
+		msg:  "no args",
+		fail: true,
+	}, {
+		msg:  "missing size",
+		args: []interface{}{"X-Seq", "2s"},
+		fail: true,
+	}, {
+		msg:  "invalid field",
+		args: []interface{}{"X-Seq:", "2s", 100},
+		fail: true,
+	}, {
+		msg:  "invalid window",
+		args: []interface{}{"X-Seq", "-2s", 100},
+		fail: true,
+	}, {
+		msg:  "invalid size",
+		args: []interface{}{"X-Seq", "2s", 0},
+		fail: true,
+	}, {
+		msg:  "valid",
+		args: []interface{}{"X-Seq", "2s", 100},
+	}, {
+		msg:  "valid, float size",
+		args: []interface{}{"id", "500ms", float64(100)},
+	}} {
+		t.Run(tt.msg, func(t *testing.T) {
+			_, err := NewReorderStream().CreateFilter(tt.args)
+			if tt.fail && err == nil {
+				t.Error("failed to fail")
+			} else if !tt.fail && err != nil {
+				t.Error(err)
+			}
+		})
+	}
+}
+
+func sseEvent(seq int) string {
+	return fmt.Sprintf("X-Seq: %d\ndata: event %d\n\n", seq, seq)
+}
+
+// feedEvents writes the events to the stream, waiting between them, when
+// the sequence number is negative.
+func feedEvents(w *io.PipeWriter, pause time.Duration, seqs ...int) {
+	for _, s := range seqs {
+		if s < 0 {
+			time.Sleep(pause)
+			continue
+		}
+
+		if _, err := io.WriteString(w, sseEvent(s)); err != nil {
+			return
+		}
+	}
+
+	w.Close()
+}
+
+func TestReorderStream(t *testing.T) {
+	for _, tt := range []struct {
+		msg      string
+		window   string
+		size     int
+		events   []int
+		expected []int
+	}{{
+		msg:      "in order",
+		window:   "1s",
+		size:     100,
+		events:   []int{1, 2, 3, 4},
+		expected: []int{1, 2, 3, 4},
+	}, {
+		msg:      "reordered",
+		window:   "1s",
+		size:     100,
+		events:   []int{1, 3, 2, 5, 4, 6},
+		expected: []int{1, 2, 3, 4, 5, 6},
+	}, {
+		msg:      "duplicate dropped",
+		window:   "1s",
+		size:     100,
+		events:   []int{1, 2, 2, 3},
+		expected: []int{1, 2, 3},
+	}, {
+		msg:      "late event dropped after the window",
+		window:   "30ms",
+		size:     100,
+		events:   []int{1, 3, 4, -1, 2, 5},
+		expected: []int{1, 3, 4, 5},
+	}, {
+		msg:      "gap skipped when the buffer is full",
+		window:   "1s",
+		size:     2,
+		events:   []int{1, 3, 4, 2, 5},
+		expected: []int{1, 3, 4, 5},
+	}, {
+		msg:      "buffered events flushed at the end",
+		window:   "1s",
+		size:     100,
+		events:   []int{1, 4, 3},
+		expected: []int{1, 3, 4},
+	}} {
+		t.Run(tt.msg, func(t *testing.T) {
+			f, err := NewReorderStream().CreateFilter([]interface{}{"X-Seq", tt.window, tt.size})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			pr, pw := io.Pipe()
+			go feedEvents(pw, 90*time.Millisecond, tt.events...)
+
+			ctx := &filtertest.Context{
+				FRequest: &http.Request{Method: "GET"},
+				FResponse: &http.Response{
+					Header:        http.Header{"Content-Type": []string{"text/event-stream; charset=utf-8"}},
+					Body:          pr,
+					ContentLength: 42,
+				},
+			}
+
+			f.Response(ctx)
+			if ctx.FResponse.ContentLength != -1 {
+				t.Errorf("unexpected content length: %d", ctx.FResponse.ContentLength)
+			}
+
+			b, err := io.ReadAll(ctx.FResponse.Body)
+			ctx.FResponse.Body.Close()
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var expected strings.Builder
+			for _, s := range tt.expected {
+				expected.WriteString(sseEvent(s))
+			}
+
+			if string(b) != expected.String() {
+				t.Errorf("unexpected stream, got:\n%s\nexpected:\n%s", b, expected.String())
+			}
+		})
+	}
+}
+
+func TestReorderStreamEventsWithoutSequence(t *testing.T) {
+	f, err := NewReorderStream().CreateFilter([]interface{}{"X-Seq", "1s", 100})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	body := ": keepalive\n\n" + sseEvent(1) + sseEvent(2)
+	ctx := &filtertest.Context{
+		FRequest: &http.Request{Method: "GET"},
+		FResponse: &http.Response{
+			Header: http.Header{"Content-Type": []string{"text/event-stream"}},
+			Body:   io.NopCloser(strings.NewReader(body)),
+		},
+	}
+
+	f.Response(ctx)
+	b, err := io.ReadAll(ctx.FResponse.Body)
+	ctx.FResponse.Body.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if string(b) != body {
+		t.Errorf("unexpected stream: %s", b)
+	}
+}
+
+func TestReorderStreamIgnoresOtherContent(t *testing.T) {
+	f, err := NewReorderStream().CreateFilter([]interface{}{"X-Seq", "1s", 100})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	body := sseEvent(2) + sseEvent(1)
+	ctx := &filtertest.Context{
+		FRequest: &http.Request{Method: "GET"},
+		FResponse: &http.Response{
+			Header:        http.Header{"Content-Type": []string{"text/plain"}},
+			Body:          io.NopCloser(strings.NewReader(body)),
+			ContentLength: int64(len(body)),
+		},
+	}
+
+	f.Response(ctx)
+	b, err := io.ReadAll(ctx.FResponse.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if string(b) != body || ctx.FResponse.ContentLength != int64(len(body)) {
+		t.Errorf("unexpected response: %s, %d", b, ctx.FResponse.ContentLength)
+	}
+}
+
+func TestReorderStreamClose(t *testing.T) {
+	f, err := NewReorderStream().CreateFilter([]interface{}{"X-Seq", "1s", 100})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	pr, pw := io.Pipe()
+	defer pw.Close()
+	go io.WriteString(pw, sseEvent(1)+sseEvent(3))
+
+	ctx := &filtertest.Context{
+		FRequest: &http.Request{Method: "GET"},
+		FResponse: &http.Response{
+			Header: http.Header{"Content-Type": []string{"text/event-stream"}},
+			Body:   pr,
+		},
+	}
+
+	f.Response(ctx)
+	p := make([]byte, len(sseEvent(1)))
+	if _, err := io.ReadFull(ctx.FResponse.Body, p); err != nil || string(p) != sseEvent(1) {
+		t.Fatalf("unexpected first event: %q, %v", p, err)
+	}
+
+	if err := ctx.FResponse.Body.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := ctx.FResponse.Body.Read(p); err == nil {
+		t.Error("failed to close the stream")
+	}
+}
@@ -0,0 +1,110 @@
+package builtin
+
+import (
+	"net/http"
+	"strings"
+
+	"golang.org/x/net/http/httpguts"
+
+	"github.com/zalando/skipper/filters"
+)
+
+type requireResponseHeadersSpec struct{}
+
+type requiredHeader struct {
+	name string
+
+	// the value set when the header is missing, when hasDefault is true,
+	// otherwise the response is rejected
+	value      string
+	hasDefault bool
+}
+
+type requireResponseHeadersFilter struct {
+	headers []requiredHeader
+}
+
+// NewRequireResponseHeaders creates a filter specification whose instances
+// guarantee that the responses contain the listed headers, e.g. the
+// security headers. Every argument names a required header. When the
+// argument has the form of a header line, "Name: value", and the response
+// lacks the header, the header is set to the value. When the argument is
+// only the name of the header, and the response lacks it, the response is
+// replaced with an empty 502 Bad Gateway response.
+//
+// Example:
+//
+//	requireResponseHeaders("Strict-Transport-Security: max-age=31536000", "X-Content-Type-Options")
+func NewRequireResponseHeaders() filters.Spec { return requireResponseHeadersSpec{} }
+
+func (requireResponseHeadersSpec) Name() string { return filters.RequireResponseHeadersName }
+
+func parseRequiredHeader(arg interface{}) (requiredHeader, error) {
+	s, ok := arg.(string)
+	if !ok {
+		return requiredHeader{}, filters.ErrInvalidFilterParameters
+	}
+
+	var h requiredHeader
+	h.name, h.value, h.hasDefault = strings.Cut(s, ":")
+	h.name = strings.TrimSpace(h.name)
+	h.value = strings.TrimSpace(h.value)
+	if !httpguts.ValidHeaderFieldName(h.name) ||
+		h.hasDefault && (h.value == "" || !httpguts.ValidHeaderFieldValue(h.value)) {
+		return requiredHeader{}, filters.ErrInvalidFilterParameters
+	}
+
+	h.name = http.CanonicalHeaderKey(h.name)
+	return h, nil
+}
+
+func (requireResponseHeadersSpec) CreateFilter(args []interface{}) (filters.Filter, error) {
+	if len(args) == 0 {
+		return nil, filters.ErrInvalidFilterParameters
+	}
+
+	f := &requireResponseHeadersFilter{}
+	for _, a := range args {
+		h, err := parseRequiredHeader(a)
+		if err != nil {
+			return nil, err
+		}
+
+		f.headers = append(f.headers, h)
+	}
+
+	return f, nil
+}
+
+func (*requireResponseHeadersFilter) Request(filters.FilterContext) {}
+
+func (f *requireResponseHeadersFilter) Response(ctx filters.FilterContext) {
+	rsp := ctx.Response()
+	for _, h := range f.headers {
+		if rsp.Header.Get(h.name) != "" {
+			continue
+		}
+
+		if h.hasDefault {
+			rsp.Header.Set(h.name, h.value)
+			continue
+		}
+
+		ctx.Logger().Errorf(
+			"%s: response lacks the required header %s",
+			filters.RequireResponseHeadersName,
+			h.name,
+		)
+
+		if err := rsp.Body.Close(); err != nil {
+			ctx.Logger().Errorf("%v", err)
+		}
+
+		rsp.StatusCode = http.StatusBadGateway
+		rsp.Status = http.StatusText(http.StatusBadGateway)
+		rsp.Header = http.Header{"Content-Length": []string{"0"}}
+		rsp.ContentLength = 0
+		rsp.Body = http.NoBody
+		return
+	}
+}
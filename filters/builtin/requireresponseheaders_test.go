@@ -0,0 +1,161 @@
+package builtin
+
+import (
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/zalando/skipper/filters/filtertest"
+)
+
+func TestRequireResponseHeadersCreate(t *testing.T) {
+	for _, tt := range []struct {
+		msg  string
+		args []interface{}
+		err  bool
+	}{{
+		msg: "no args",
+		err: true,
+	}, {
+		msg:  "not a string",
+		args: []interface{}{42.0},
+		err:  true,
+	}, {
+		msg:  "empty name",
+		args: []interface{}{""},
+		err:  true,
+	}, {
+		msg:  "invalid name",
+		args: []interface{}{"X Content Type Options"},
+		err:  true,
+	}, {
+		msg:  "empty default",
+		args: []interface{}{"X-Content-Type-Options:"},
+		err:  true,
+	}, {
+		msg:  "invalid default",
+		args: []interface{}{"X-Content-Type-Options: no\nsniff"},
+		err:  true,
+	}, {
+		msg:  "reject",
+		args: []interface{}{"X-Content-Type-Options"},
+	}, {
+		msg:  "default",
+		args: []interface{}{"Strict-Transport-Security: max-age=31536000"},
+	}, {
+		msg:  "mixed",
+		args: []interface{}{"Strict-Transport-Security: max-age=31536000", "X-Content-Type-Options"},
+	}} {
+		t.Run(tt.msg, func(t *testing.T) {
+			_, err := NewRequireResponseHeaders().CreateFilter(tt.args)
+			if tt.err && err == nil {
+				t.Error("expected error")
+			} else if !tt.err && err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+		})
+	}
+}
+
+func TestRequireResponseHeaders(t *testing.T) {
+	f, err := NewRequireResponseHeaders().CreateFilter([]interface{}{
+		"strict-transport-security: max-age=31536000",
+		"X-Content-Type-Options",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tt := range []struct {
+		msg            string
+		header         http.Header
+		expectedStatus int
+		expectedHeader http.Header
+		expectedBody   string
+	}{{
+		msg: "all present",
+		header: http.Header{
+			"Strict-Transport-Security": []string{"max-age=60"},
+			"X-Content-Type-Options":    []string{"nosniff"},
+		},
+		expectedStatus: http.StatusOK,
+		expectedHeader: http.Header{
+			"Strict-Transport-Security": []string{"max-age=60"},
+			"X-Content-Type-Options":    []string{"nosniff"},
+		},
+		expectedBody: "Hello, world!",
+	}, {
+		msg: "missing with default",
+		header: http.Header{
+			"X-Content-Type-Options": []string{"nosniff"},
+		},
+		expectedStatus: http.StatusOK,
+		expectedHeader: http.Header{
+			"Strict-Transport-Security": []string{"max-age=31536000"},
+			"X-Content-Type-Options":    []string{"nosniff"},
+		},
+		expectedBody: "Hello, world!",
+	}, {
+		msg: "empty with default",
+		header: http.Header{
+			"Strict-Transport-Security": []string{""},
+			"X-Content-Type-Options":    []string{"nosniff"},
+		},
+		expectedStatus: http.StatusOK,
+		expectedHeader: http.Header{
+			"Strict-Transport-Security": []string{"max-age=31536000"},
+			"X-Content-Type-Options":    []string{"nosniff"},
+		},
+		expectedBody: "Hello, world!",
+	}, {
+		msg: "missing with reject",
+		header: http.Header{
+			"Strict-Transport-Security": []string{"max-age=60"},
+			"Content-Type":              []string{"text/plain"},
+		},
+		expectedStatus: http.StatusBadGateway,
+		expectedHeader: http.Header{"Content-Length": []string{"0"}},
+	}, {
+		msg:            "all missing",
+		header:         http.Header{},
+		expectedStatus: http.StatusBadGateway,
+		expectedHeader: http.Header{"Content-Length": []string{"0"}},
+	}} {
+		t.Run(tt.msg, func(t *testing.T) {
+			ctx := &filtertest.Context{
+				FResponse: &http.Response{
+					StatusCode: http.StatusOK,
+					Header:     tt.header,
+					Body:       io.NopCloser(strings.NewReader("Hello, world!")),
+				},
+			}
+
+			f.Response(ctx)
+
+			rsp := ctx.Response()
+			if rsp.StatusCode != tt.expectedStatus {
+				t.Errorf("expected status: %d, got: %d", tt.expectedStatus, rsp.StatusCode)
+			}
+
+			if len(rsp.Header) != len(tt.expectedHeader) {
+				t.Errorf("expected header: %v, got: %v", tt.expectedHeader, rsp.Header)
+			}
+
+			for name := range tt.expectedHeader {
+				if rsp.Header.Get(name) != tt.expectedHeader.Get(name) {
+					t.Errorf("expected header: %v, got: %v", tt.expectedHeader, rsp.Header)
+				}
+			}
+
+			body, err := io.ReadAll(rsp.Body)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if string(body) != tt.expectedBody {
+				t.Errorf("expected body: %q, got: %q", tt.expectedBody, body)
+			}
+		})
+	}
+}
@@ -0,0 +1,150 @@
+package builtin
+
+import (
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/zalando/skipper/eskip"
+	"github.com/zalando/skipper/filters"
+)
+
+const (
+	retryAfterDynamic         = "dynamic"
+	defaultRetryAfterTemplate = "${response.header.X-Retry-After}"
+)
+
+type retryAfterSpec struct{}
+
+type retryAfterFilter struct {
+	status   int
+	value    string
+	template *eskip.Template
+}
+
+// NewRetryAfterOnStatus creates a filter specification whose instances set
+// the Retry-After header on responses with the configured status code,
+// unless the response already contains it.
+//
+// The value is either static, defined as a duration string or a number of
+// seconds, or "dynamic". In the dynamic mode, the value is resolved from the
+// template passed as the optional third argument, defaulting to
+// "${response.header.X-Retry-After}", and it is expected to resolve to a
+// duration string or a number of seconds.
+//
+// Examples:
+//
+//	retryAfterOnStatus(503, "5s")
+//	retryAfterOnStatus(429, "dynamic", "${response.header.X-RateLimit-Reset}")
+func NewRetryAfterOnStatus() filters.Spec { return retryAfterSpec{} }
+
+func (retryAfterSpec) Name() string { return filters.RetryAfterOnStatusName }
+
+func (retryAfterSpec) CreateFilter(args []interface{}) (filters.Filter, error) {
+	if len(args) < 2 || len(args) > 3 {
+		return nil, filters.ErrInvalidFilterParameters
+	}
+
+	var status int
+	switch v := args[0].(type) {
+	case int:
+		status = v
+	case float64:
+		status = int(v)
+	default:
+		return nil, filters.ErrInvalidFilterParameters
+	}
+
+	if status < 100 || status > 599 {
+		return nil, filters.ErrInvalidFilterParameters
+	}
+
+	f := &retryAfterFilter{status: status}
+	switch v := args[1].(type) {
+	case float64:
+		if len(args) != 2 || v < 0 {
+			return nil, filters.ErrInvalidFilterParameters
+		}
+
+		f.value = strconv.Itoa(int(v))
+	case string:
+		if v == retryAfterDynamic {
+			t := defaultRetryAfterTemplate
+			if len(args) == 3 {
+				s, ok := args[2].(string)
+				if !ok {
+					return nil, filters.ErrInvalidFilterParameters
+				}
+
+				t = s
+			}
+
+			f.template = eskip.NewTemplate(t)
+			break
+		}
+
+		if len(args) != 2 {
+			return nil, filters.ErrInvalidFilterParameters
+		}
+
+		seconds, ok := parseRetryAfterSeconds(v)
+		if !ok {
+			return nil, filters.ErrInvalidFilterParameters
+		}
+
+		f.value = seconds
+	default:
+		return nil, filters.ErrInvalidFilterParameters
+	}
+
+	return f, nil
+}
+
+// parseRetryAfterSeconds accepts a duration string or a number of seconds,
+// and returns the number of seconds as expected by the Retry-After header,
+// rounded up.
+func parseRetryAfterSeconds(v string) (string, bool) {
+	if s, err := strconv.Atoi(v); err == nil {
+		if s < 0 {
+			return "", false
+		}
+
+		return v, true
+	}
+
+	d, err := time.ParseDuration(v)
+	if err != nil || d < 0 {
+		return "", false
+	}
+
+	return strconv.Itoa(int(math.Ceil(d.Seconds()))), true
+}
+
+func (f *retryAfterFilter) Request(filters.FilterContext) {}
+
+func (f *retryAfterFilter) Response(ctx filters.FilterContext) {
+	rsp := ctx.Response()
+	if rsp.StatusCode != f.status || rsp.Header.Get("Retry-After") != "" {
+		return
+	}
+
+	value := f.value
+	if f.template != nil {
+		v, ok := f.template.ApplyContext(ctx)
+		if !ok {
+			return
+		}
+
+		if value, ok = parseRetryAfterSeconds(v); !ok {
+			ctx.Logger().Debugf("%s: invalid dynamic value: %q", filters.RetryAfterOnStatusName, v)
+			return
+		}
+	}
+
+	if rsp.Header == nil {
+		rsp.Header = make(http.Header)
+	}
+
+	rsp.Header.Set("Retry-After", value)
+}
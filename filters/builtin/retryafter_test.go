@@ -0,0 +1,145 @@
+package builtin
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/zalando/skipper/filters/filtertest"
+)
+
+func TestRetryAfterOnStatusCreate(t *testing.T) {
+	for _, tt := range []struct {
+		msg  string
+		args []interface{}
+		err  bool
+	}{{
+		msg:  "no arguments",
+		args: nil,
+		err:  true,
+	}, {
+		msg:  "missing value",
+		args: []interface{}{503.0},
+		err:  true,
+	}, {
+		msg:  "invalid status",
+		args: []interface{}{"503", "5s"},
+		err:  true,
+	}, {
+		msg:  "status out of range",
+		args: []interface{}{1000.0, "5s"},
+		err:  true,
+	}, {
+		msg:  "invalid static value",
+		args: []interface{}{503.0, "foo"},
+		err:  true,
+	}, {
+		msg:  "static value with template",
+		args: []interface{}{503.0, "5s", "${response.header.X-Foo}"},
+		err:  true,
+	}, {
+		msg:  "invalid template",
+		args: []interface{}{429.0, "dynamic", 42.0},
+		err:  true,
+	}, {
+		msg:  "static duration",
+		args: []interface{}{503.0, "5s"},
+	}, {
+		msg:  "static seconds",
+		args: []interface{}{503.0, 5.0},
+	}, {
+		msg:  "dynamic",
+		args: []interface{}{429.0, "dynamic"},
+	}, {
+		msg:  "dynamic with template",
+		args: []interface{}{429.0, "dynamic", "${response.header.X-RateLimit-Reset}"},
+	}} {
+		t.Run(tt.msg, func(t *testing.T) {
+			_, err := NewRetryAfterOnStatus().CreateFilter(tt.args)
+			if tt.err && err == nil {
+				t.Error("expected error")
+			} else if !tt.err && err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+		})
+	}
+}
+
+func TestRetryAfterOnStatus(t *testing.T) {
+	for _, tt := range []struct {
+		msg      string
+		args     []interface{}
+		status   int
+		header   http.Header
+		expected string
+	}{{
+		msg:      "static duration on 503",
+		args:     []interface{}{503.0, "5s"},
+		status:   http.StatusServiceUnavailable,
+		expected: "5",
+	}, {
+		msg:      "static duration rounded up",
+		args:     []interface{}{503.0, "1500ms"},
+		status:   http.StatusServiceUnavailable,
+		expected: "2",
+	}, {
+		msg:      "static seconds on 503",
+		args:     []interface{}{503.0, 10.0},
+		status:   http.StatusServiceUnavailable,
+		expected: "10",
+	}, {
+		msg:    "not matching status",
+		args:   []interface{}{503.0, "5s"},
+		status: http.StatusOK,
+	}, {
+		msg:      "existing header is kept",
+		args:     []interface{}{503.0, "5s"},
+		status:   http.StatusServiceUnavailable,
+		header:   http.Header{"Retry-After": []string{"60"}},
+		expected: "60",
+	}, {
+		msg:      "dynamic on 429 from default header",
+		args:     []interface{}{429.0, "dynamic"},
+		status:   http.StatusTooManyRequests,
+		header:   http.Header{"X-Retry-After": []string{"3"}},
+		expected: "3",
+	}, {
+		msg:      "dynamic on 429 from custom template",
+		args:     []interface{}{429.0, "dynamic", "${response.header.X-RateLimit-Reset}"},
+		status:   http.StatusTooManyRequests,
+		header:   http.Header{"X-Ratelimit-Reset": []string{"2m"}},
+		expected: "120",
+	}, {
+		msg:    "dynamic not resolved",
+		args:   []interface{}{429.0, "dynamic"},
+		status: http.StatusTooManyRequests,
+	}, {
+		msg:    "dynamic resolved to invalid value",
+		args:   []interface{}{429.0, "dynamic"},
+		status: http.StatusTooManyRequests,
+		header: http.Header{"X-Retry-After": []string{"soon"}},
+	}} {
+		t.Run(tt.msg, func(t *testing.T) {
+			f, err := NewRetryAfterOnStatus().CreateFilter(tt.args)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			req, err := http.NewRequest("GET", "https://www.example.org", nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			rsp := &http.Response{StatusCode: tt.status, Header: make(http.Header)}
+			for k, v := range tt.header {
+				rsp.Header[k] = v
+			}
+
+			ctx := &filtertest.Context{FRequest: req, FResponse: rsp}
+			f.Response(ctx)
+
+			if got := rsp.Header.Get("Retry-After"); got != tt.expected {
+				t.Errorf("expected Retry-After: %q, got: %q", tt.expected, got)
+			}
+		})
+	}
+}
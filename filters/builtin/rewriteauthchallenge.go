@@ -0,0 +1,162 @@
+package builtin
+
+import (
+	"net/http"
+	"regexp"
+	"strings"
+
+	"github.com/zalando/skipper/filters"
+)
+
+var (
+	// the auth scheme, followed optionally by the auth params or a token68,
+	// see RFC 7235
+	authChallenge = regexp.MustCompile(`^[!#$%&'*+.^_` + "`" + `|~0-9A-Za-z-]+( [^\x00-\x1f\x7f]*)?$`)
+
+	authParamName = regexp.MustCompile(`^[!#$%&'*+.^_` + "`" + `|~0-9A-Za-z-]+$`)
+)
+
+type rewriteAuthChallengeSpec struct{}
+
+type rewriteAuthChallengeFilter struct {
+	challenge string
+	preserve  []string
+}
+
+// NewRewriteAuthChallenge creates a filter specification whose instances
+// replace the WWW-Authenticate header of the 401 responses with the
+// configured challenge, e.g. to present a single, consistent realm to the
+// clients, when the routes proxy to backends of different auth realms.
+//
+// The optional further arguments name the auth params, e.g. "error" or
+// "scope", that are copied from the challenges of the backend response to
+// the configured challenge, when present. The first occurrence of a param
+// is used. The responses with other status codes are not changed.
+//
+// Examples:
+//
+//	rewriteAuthChallenge("Bearer realm=\"edge\"")
+//	rewriteAuthChallenge("Bearer realm=\"edge\"", "error", "scope")
+func NewRewriteAuthChallenge() filters.Spec { return rewriteAuthChallengeSpec{} }
+
+func (rewriteAuthChallengeSpec) Name() string { return filters.RewriteAuthChallengeName }
+
+func (rewriteAuthChallengeSpec) CreateFilter(args []interface{}) (filters.Filter, error) {
+	if len(args) == 0 {
+		return nil, filters.ErrInvalidFilterParameters
+	}
+
+	challenge, ok := args[0].(string)
+	if !ok || !authChallenge.MatchString(challenge) {
+		return nil, filters.ErrInvalidFilterParameters
+	}
+
+	f := &rewriteAuthChallengeFilter{challenge: challenge}
+	for _, a := range args[1:] {
+		p, ok := a.(string)
+		if !ok || !authParamName.MatchString(p) {
+			return nil, filters.ErrInvalidFilterParameters
+		}
+
+		f.preserve = append(f.preserve, strings.ToLower(p))
+	}
+
+	return f, nil
+}
+
+func (*rewriteAuthChallengeFilter) Request(filters.FilterContext) {}
+
+// authParams returns the auth params of the challenges, with lower case
+// names, keeping the first occurrence of each name, and the values as they
+// appear in the header, including the quotes.
+func authParams(values []string) map[string]string {
+	params := make(map[string]string)
+	for _, v := range values {
+		for {
+			i := strings.IndexAny(v, "=\"")
+			if i < 0 {
+				break
+			}
+
+			if v[i] == '"' {
+				// a quoted string without a param name
+				v = skipQuoted(v[i:])
+				continue
+			}
+
+			// the name is the last token before the '='
+			name := strings.TrimRight(v[:i], " ")
+			if j := strings.LastIndexAny(name, " ,"); j >= 0 {
+				name = name[j+1:]
+			}
+
+			v = strings.TrimLeft(v[i+1:], " ")
+
+			// the padding of a token68, e.g. of a Negotiate challenge
+			if v == "" || v[0] == '=' || v[0] == ',' {
+				v = strings.TrimLeft(v, "=")
+				continue
+			}
+
+			var value string
+			if v[0] == '"' {
+				rest := skipQuoted(v)
+				value, v = v[:len(v)-len(rest)], rest
+			} else {
+				end := strings.IndexAny(v, ", ")
+				if end < 0 {
+					end = len(v)
+				}
+
+				value, v = v[:end], v[end:]
+			}
+
+			name = strings.ToLower(name)
+			if _, ok := params[name]; !ok && name != "" {
+				params[name] = value
+			}
+		}
+	}
+
+	return params
+}
+
+// skipQuoted returns the rest of s after the quoted string at its start.
+func skipQuoted(s string) string {
+	for i := 1; i < len(s); i++ {
+		switch s[i] {
+		case '\\':
+			i++
+		case '"':
+			return s[i+1:]
+		}
+	}
+
+	return ""
+}
+
+func (f *rewriteAuthChallengeFilter) Response(ctx filters.FilterContext) {
+	rsp := ctx.Response()
+	if rsp.StatusCode != http.StatusUnauthorized {
+		return
+	}
+
+	challenge := f.challenge
+	if len(f.preserve) > 0 {
+		params := authParams(rsp.Header.Values("WWW-Authenticate"))
+		for _, p := range f.preserve {
+			v, ok := params[p]
+			if !ok {
+				continue
+			}
+
+			if strings.Contains(challenge, " ") {
+				challenge += ", " + p + "=" + v
+			} else {
+				challenge += " " + p + "=" + v
+			}
+		}
+	}
+
+	rsp.Header.Set("WWW-Authenticate", challenge)
+}
@@ -0,0 +1,143 @@
+package builtin
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/zalando/skipper/filters/filtertest"
+)
+
+func TestRewriteAuthChallengeCreate(t *testing.T) {
+	for _, tt := range []struct {
+		msg  string
+		args []interface{}
+		err  bool
+	}{{
+		msg: "no arguments",
+		err: true,
+	}, {
+		msg:  "invalid type",
+		args: []interface{}{401.0},
+		err:  true,
+	}, {
+		msg:  "empty challenge",
+		args: []interface{}{""},
+		err:  true,
+	}, {
+		msg:  "invalid scheme",
+		args: []interface{}{"Bearer/1 realm=\"edge\""},
+		err:  true,
+	}, {
+		msg:  "line break",
+		args: []interface{}{"Bearer realm=\"edge\"\r\nX-Injected: true"},
+		err:  true,
+	}, {
+		msg:  "scheme only",
+		args: []interface{}{"Bearer"},
+	}, {
+		msg:  "challenge",
+		args: []interface{}{"Bearer realm=\"edge\""},
+	}, {
+		msg:  "preserved params",
+		args: []interface{}{"Bearer realm=\"edge\"", "error", "scope"},
+	}, {
+		msg:  "invalid preserved param",
+		args: []interface{}{"Bearer realm=\"edge\"", "error description"},
+		err:  true,
+	}, {
+		msg:  "invalid preserved param type",
+		args: []interface{}{"Bearer realm=\"edge\"", 42.0},
+		err:  true,
+	}} {
+		t.Run(tt.msg, func(t *testing.T) {
+			_, err := NewRewriteAuthChallenge().CreateFilter(tt.args)
+			if tt.err && err == nil {
+				t.Error("failed to fail")
+			} else if !tt.err && err != nil {
+				t.Error(err)
+			}
+		})
+	}
+}
+
+func TestRewriteAuthChallenge(t *testing.T) {
+	for _, tt := range []struct {
+		msg      string
+		args     []interface{}
+		status   int
+		incoming []string
+		expected []string
+	}{{
+		msg:      "bearer challenge",
+		args:     []interface{}{`Bearer realm="edge"`},
+		status:   http.StatusUnauthorized,
+		incoming: []string{`Bearer realm="backend-a"`},
+		expected: []string{`Bearer realm="edge"`},
+	}, {
+		msg:      "basic challenge",
+		args:     []interface{}{`Bearer realm="edge"`},
+		status:   http.StatusUnauthorized,
+		incoming: []string{`Basic realm="backend-b", charset="UTF-8"`},
+		expected: []string{`Bearer realm="edge"`},
+	}, {
+		msg:      "multiple challenges",
+		args:     []interface{}{`Bearer realm="edge"`},
+		status:   http.StatusUnauthorized,
+		incoming: []string{`Negotiate`, `Basic realm="backend-c"`},
+		expected: []string{`Bearer realm="edge"`},
+	}, {
+		msg:      "missing challenge",
+		args:     []interface{}{`Bearer realm="edge"`},
+		status:   http.StatusUnauthorized,
+		expected: []string{`Bearer realm="edge"`},
+	}, {
+		msg:      "other status",
+		args:     []interface{}{`Bearer realm="edge"`},
+		status:   http.StatusForbidden,
+		incoming: []string{`Bearer realm="backend-a"`},
+		expected: []string{`Bearer realm="backend-a"`},
+	}, {
+		msg:      "preserved params",
+		args:     []interface{}{`Bearer realm="edge"`, "error", "scope", "error_description"},
+		status:   http.StatusUnauthorized,
+		incoming: []string{`Bearer realm="backend, a", error="invalid_token", scope="read write"`},
+		expected: []string{`Bearer realm="edge", error="invalid_token", scope="read write"`},
+	}, {
+		msg:      "preserved params of multiple challenges",
+		args:     []interface{}{`Bearer`, "Error", "scope"},
+		status:   http.StatusUnauthorized,
+		incoming: []string{`Negotiate dG9rZW4=, Bearer error=insufficient_scope`, `Bearer scope="admin", error="invalid_token"`},
+		expected: []string{`Bearer error=insufficient_scope, scope="admin"`},
+	}, {
+		msg:      "quoted string with escaped quote",
+		args:     []interface{}{`Bearer realm="edge"`, "error"},
+		status:   http.StatusUnauthorized,
+		incoming: []string{`Bearer realm="say \"error=fake\"", error="invalid_request"`},
+		expected: []string{`Bearer realm="edge", error="invalid_request"`},
+	}} {
+		t.Run(tt.msg, func(t *testing.T) {
+			f, err := NewRewriteAuthChallenge().CreateFilter(tt.args)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			rsp := &http.Response{StatusCode: tt.status, Header: http.Header{}}
+			for _, v := range tt.incoming {
+				rsp.Header.Add("WWW-Authenticate", v)
+			}
+
+			f.Response(&filtertest.Context{FResponse: rsp})
+
+			got := rsp.Header.Values("WWW-Authenticate")
+			if len(got) != len(tt.expected) {
+				t.Fatalf("unexpected challenges: %q, expected: %q", got, tt.expected)
+			}
+
+			for i := range got {
+				if got[i] != tt.expected[i] {
+					t.Errorf("unexpected challenge: %q, expected: %q", got[i], tt.expected[i])
+				}
+			}
+		})
+	}
+}
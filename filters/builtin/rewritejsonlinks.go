@@ -0,0 +1,361 @@
+package builtin
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"mime"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/tidwall/gjson"
+
+	"github.com/zalando/skipper/filters"
+	"github.com/zalando/skipper/net"
+)
+
+const defaultRewriteJSONLinksMaxBytes = 2 << 20
+
+var errInvalidJSONPath = errors.New("invalid JSONPath")
+
+type rewriteJSONLinksSpec struct{}
+
+type rewriteJSONLinksFilter struct {
+	path        []jsonPathStep
+	pattern     *regexp.Regexp
+	replacement string
+	maxBytes    int64
+}
+
+// jsonPathStep is a step of the supported JSONPath subset. It selects the
+// children of the current nodes, or of the nodes and all their descendants
+// when recursive, with the given name or index, or all of them.
+type jsonPathStep struct {
+	recursive bool
+	wildcard  bool
+	name      string
+	index     int
+}
+
+// NewRewriteJSONLinks creates a filter specification whose instances
+// rewrite the URLs in JSON response bodies, e.g. the HATEOAS links leaking
+// the internal hosts of the backends. The string values selected by the
+// JSONPath are edited by replacing the matches of the regular expression,
+// as regexp.ReplaceAllString. The rest of the body is not changed.
+//
+// The supported JSONPath subset contains the root $, the child .name or
+// ['name'], the recursive descent ..name, the wildcards .* and [*], and the
+// array index [n].
+//
+// Only the uncompressed JSON responses are edited, that are not larger than
+// the optional maximum size, by default 2MB. Larger responses are streamed
+// unchanged.
+//
+// Example:
+//
+//	rewriteJSONLinks("$.._links..href", "https?://[^/]+", "https://edge.example.org")
+func NewRewriteJSONLinks() filters.Spec { return rewriteJSONLinksSpec{} }
+
+func (rewriteJSONLinksSpec) Name() string { return filters.RewriteJSONLinksName }
+
+func (rewriteJSONLinksSpec) CreateFilter(args []interface{}) (filters.Filter, error) {
+	if len(args) != 3 && len(args) != 4 {
+		return nil, filters.ErrInvalidFilterParameters
+	}
+
+	p, ok := args[0].(string)
+	if !ok {
+		return nil, filters.ErrInvalidFilterParameters
+	}
+
+	path, err := parseJSONPath(p)
+	if err != nil {
+		return nil, err
+	}
+
+	expr, ok := args[1].(string)
+	if !ok {
+		return nil, filters.ErrInvalidFilterParameters
+	}
+
+	pattern, err := regexp.Compile(expr)
+	if err != nil {
+		return nil, err
+	}
+
+	replacement, ok := args[2].(string)
+	if !ok {
+		return nil, filters.ErrInvalidFilterParameters
+	}
+
+	f := &rewriteJSONLinksFilter{
+		path:        path,
+		pattern:     pattern,
+		replacement: replacement,
+		maxBytes:    defaultRewriteJSONLinksMaxBytes,
+	}
+
+	if len(args) == 4 {
+		switch v := args[3].(type) {
+		case int:
+			f.maxBytes = int64(v)
+		case float64:
+			f.maxBytes = int64(v)
+		case string:
+			if f.maxBytes, err = net.ParseByteSize(v); err != nil {
+				return nil, filters.ErrInvalidFilterParameters
+			}
+		default:
+			return nil, filters.ErrInvalidFilterParameters
+		}
+
+		if f.maxBytes <= 0 {
+			return nil, filters.ErrInvalidFilterParameters
+		}
+	}
+
+	return f, nil
+}
+
+func parseJSONPath(p string) ([]jsonPathStep, error) {
+	if !strings.HasPrefix(p, "$") {
+		return nil, errInvalidJSONPath
+	}
+
+	var steps []jsonPathStep
+	for p = p[1:]; p != ""; {
+		var s jsonPathStep
+		switch {
+		case strings.HasPrefix(p, ".."):
+			s.recursive = true
+			p = p[2:]
+		case p[0] == '.':
+			p = p[1:]
+		case p[0] != '[':
+			return nil, errInvalidJSONPath
+		}
+
+		if p != "" && p[0] == '[' {
+			end := strings.IndexByte(p, ']')
+			if end < 0 {
+				return nil, errInvalidJSONPath
+			}
+
+			sel := p[1:end]
+			p = p[end+1:]
+			switch {
+			case sel == "*":
+				s.wildcard = true
+			case len(sel) >= 2 && (sel[0] == '\'' || sel[0] == '"') && sel[len(sel)-1] == sel[0]:
+				s.name, s.index = sel[1:len(sel)-1], -1
+			default:
+				i, err := strconv.Atoi(sel)
+				if err != nil || i < 0 {
+					return nil, errInvalidJSONPath
+				}
+
+				s.index = i
+			}
+		} else {
+			end := strings.IndexAny(p, ".[")
+			if end < 0 {
+				end = len(p)
+			}
+
+			s.name, s.index, p = p[:end], -1, p[end:]
+			if s.name == "*" {
+				s.wildcard = true
+			} else if s.name == "" {
+				return nil, errInvalidJSONPath
+			}
+		}
+
+		steps = append(steps, s)
+	}
+
+	if len(steps) == 0 {
+		return nil, errInvalidJSONPath
+	}
+
+	return steps, nil
+}
+
+// descendants appends the node and all its nested values.
+func descendants(nodes []gjson.Result, node gjson.Result) []gjson.Result {
+	nodes = append(nodes, node)
+	if node.IsObject() || node.IsArray() {
+		node.ForEach(func(_, value gjson.Result) bool {
+			nodes = descendants(nodes, value)
+			return true
+		})
+	}
+
+	return nodes
+}
+
+func (s jsonPathStep) selectChildren(selected []gjson.Result, node gjson.Result) []gjson.Result {
+	switch {
+	case node.IsObject():
+		if s.index >= 0 && !s.wildcard {
+			return selected
+		}
+
+		node.ForEach(func(key, value gjson.Result) bool {
+			if s.wildcard || key.String() == s.name {
+				selected = append(selected, value)
+			}
+
+			return true
+		})
+	case node.IsArray():
+		if s.index < 0 && !s.wildcard {
+			return selected
+		}
+
+		var i int
+		node.ForEach(func(_, value gjson.Result) bool {
+			if s.wildcard || i == s.index {
+				selected = append(selected, value)
+			}
+
+			i++
+			return true
+		})
+	}
+
+	return selected
+}
+
+// stringNodes returns the string values selected by the path, keyed by
+// their offset in the document.
+func stringNodes(doc []byte, path []jsonPathStep) map[int]gjson.Result {
+	nodes := []gjson.Result{gjson.ParseBytes(doc)}
+	for _, s := range path {
+		var next []gjson.Result
+		for _, n := range nodes {
+			if !s.recursive {
+				next = s.selectChildren(next, n)
+				continue
+			}
+
+			for _, d := range descendants(nil, n) {
+				next = s.selectChildren(next, d)
+			}
+		}
+
+		nodes = next
+	}
+
+	strs := make(map[int]gjson.Result)
+	for _, n := range nodes {
+		if n.Type == gjson.String && n.Index > 0 {
+			strs[n.Index] = n
+		}
+	}
+
+	return strs
+}
+
+func encodeJSONString(s string) []byte {
+	var b bytes.Buffer
+	enc := json.NewEncoder(&b)
+	enc.SetEscapeHTML(false)
+	enc.Encode(s)
+	return bytes.TrimSuffix(b.Bytes(), []byte("\n"))
+}
+
+// rewrite returns the edited document, or nil when there was nothing to
+// edit.
+func (f *rewriteJSONLinksFilter) rewrite(doc []byte) []byte {
+	// the offsets of the values are only known when the document starts
+	// at the first byte
+	start := len(doc) - len(bytes.TrimLeft(doc, " \t\r\n"))
+	trimmed := doc[start:]
+	if !gjson.ValidBytes(trimmed) {
+		return nil
+	}
+
+	strs := stringNodes(trimmed, f.path)
+	offsets := make([]int, 0, len(strs))
+	for i := range strs {
+		offsets = append(offsets, i)
+	}
+
+	sort.Ints(offsets)
+
+	var (
+		out     []byte
+		last    int
+		changed bool
+	)
+
+	for _, i := range offsets {
+		n := strs[i]
+		v := f.pattern.ReplaceAllString(n.Str, f.replacement)
+		if v == n.Str {
+			continue
+		}
+
+		out = append(out, trimmed[last:i]...)
+		out = append(out, encodeJSONString(v)...)
+		last = i + len(n.Raw)
+		changed = true
+	}
+
+	if !changed {
+		return nil
+	}
+
+	return append(append(doc[:start:start], out...), trimmed[last:]...)
+}
+
+func isJSON(contentType string) bool {
+	mt, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+
+	return mt == "application/json" || strings.HasSuffix(mt, "+json")
+}
+
+func (f *rewriteJSONLinksFilter) Request(filters.FilterContext) {}
+
+func (f *rewriteJSONLinksFilter) Response(ctx filters.FilterContext) {
+	rsp := ctx.Response()
+	if rsp.Body == nil || rsp.ContentLength > f.maxBytes || !isJSON(rsp.Header.Get("Content-Type")) {
+		return
+	}
+
+	if ce := rsp.Header.Get("Content-Encoding"); ce != "" && ce != "identity" {
+		return
+	}
+
+	original, restored, ok := net.BufferBody(rsp.Body, f.maxBytes)
+	if !ok {
+		rsp.Body = restored
+		return
+	}
+
+	body := f.rewrite(original)
+	if body == nil {
+		rsp.Body = io.NopCloser(bytes.NewReader(original))
+		return
+	}
+
+	rsp.Body = io.NopCloser(bytes.NewReader(body))
+
+	// the trailers can be only sent with the chunked encoding
+	if len(rsp.Trailer) > 0 || rsp.Header.Get("Trailer") != "" {
+		rsp.ContentLength = -1
+		rsp.Header.Del("Content-Length")
+		return
+	}
+
+	rsp.ContentLength = int64(len(body))
+	rsp.TransferEncoding = nil
+	rsp.Header.Del("Transfer-Encoding")
+	rsp.Header.Set("Content-Length", strconv.Itoa(len(body)))
+}
@@ -0,0 +1,194 @@
+package builtin
+
+import (
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/zalando/skipper/filters/filtertest"
+)
+
+func TestRewriteJSONLinksArgs(t *testing.T) {
+	for _, tt := range []struct {
+		msg  string
+		args []interface{}
+		fail bool
+	}{{
+		msg:  "no args",
+		fail: true,
+	}, {
+		msg:  "missing replacement",
+		args: []interface{}{"$..href", "https?://[^/]+"},
+		fail: true,
+	}, {
+		msg:  "path without root",
+		args: []interface{}{"_links.href", "https?://[^/]+", "https://edge.example.org"},
+		fail: true,
+	}, {
+		msg:  "root only",
+		args: []interface{}{"$", "https?://[^/]+", "https://edge.example.org"},
+		fail: true,
+	}, {
+		msg:  "empty name",
+		args: []interface{}{"$._links.", "https?://[^/]+", "https://edge.example.org"},
+		fail: true,
+	}, {
+		msg:  "invalid index",
+		args: []interface{}{"$.items[x]", "https?://[^/]+", "https://edge.example.org"},
+		fail: true,
+	}, {
+		msg:  "unclosed bracket",
+		args: []interface{}{"$.items[0", "https?://[^/]+", "https://edge.example.org"},
+		fail: true,
+	}, {
+		msg:  "invalid regexp",
+		args: []interface{}{"$..href", "https?://[^/", "https://edge.example.org"},
+		fail: true,
+	}, {
+		msg:  "invalid size",
+		args: []interface{}{"$..href", "https?://[^/]+", "https://edge.example.org", "1XB"},
+		fail: true,
+	}, {
+		msg:  "recursive descent",
+		args: []interface{}{"$.._links..href", "https?://[^/]+", "https://edge.example.org"},
+	}, {
+		msg:  "brackets and size",
+		args: []interface{}{"$['items'][*]._links.self['href']", "https?://[^/]+", "https://edge.example.org", "64KB"},
+	}} {
+		t.Run(tt.msg, func(t *testing.T) {
+			_, err := NewRewriteJSONLinks().CreateFilter(tt.args)
+			if tt.fail && err == nil {
+				t.Error("failed to fail")
+			} else if !tt.fail && err != nil {
+				t.Error(err)
+			}
+		})
+	}
+}
+
+func TestRewriteJSONLinks(t *testing.T) {
+	const nested = `{
+  "_links": {"self": {"href": "http://backend.internal:8080/orders"}},
+  "name": "http://backend.internal/not-a-link",
+  "_embedded": {
+    "orders": [
+      {"id": 1, "_links": {"self": {"href": "http://backend.internal/orders/1"}, "items": [{"href": "http://backend.internal/orders/1/items?a=1&b=<2>"}, {"href": 42}]}},
+      {"id": 2, "_links": {"self": {"href": "https://edge.example.org/orders/2"}}}
+    ]
+  }
+}`
+
+	const nestedRewritten = `{
+  "_links": {"self": {"href": "https://edge.example.org/orders"}},
+  "name": "http://backend.internal/not-a-link",
+  "_embedded": {
+    "orders": [
+      {"id": 1, "_links": {"self": {"href": "https://edge.example.org/orders/1"}, "items": [{"href": "https://edge.example.org/orders/1/items?a=1&b=<2>"}, {"href": 42}]}},
+      {"id": 2, "_links": {"self": {"href": "https://edge.example.org/orders/2"}}}
+    ]
+  }
+}`
+
+	for _, tt := range []struct {
+		msg         string
+		path        string
+		maxBytes    interface{}
+		contentType string
+		encoding    string
+		body        string
+		expected    string
+	}{{
+		msg:      "nested link objects and arrays",
+		path:     "$.._links..href",
+		body:     nested,
+		expected: nestedRewritten,
+	}, {
+		msg:      "array index",
+		path:     "$.items[1].href",
+		body:     `[{"items": [{"href": "http://a/1"}, {"href": "http://a/2"}]}, {"items": [{"href": "http://a/3"}, {"href": "http://a/4"}]}]`,
+		expected: `[{"items": [{"href": "http://a/1"}, {"href": "http://a/2"}]}, {"items": [{"href": "http://a/3"}, {"href": "http://a/4"}]}]`,
+	}, {
+		msg:      "array wildcard",
+		path:     "$[*].items[1].href",
+		body:     `[{"items": [{"href": "http://a/1"}, {"href": "http://a/2"}]}, {"items": [{"href": "http://a/3"}, {"href": "http://a/4"}]}]`,
+		expected: `[{"items": [{"href": "http://a/1"}, {"href": "https://edge.example.org/2"}]}, {"items": [{"href": "http://a/3"}, {"href": "https://edge.example.org/4"}]}]`,
+	}, {
+		msg:      "array of strings",
+		path:     "$.links.*",
+		body:     `  {"links": ["http://a/1", "http://b/2"]}`,
+		expected: `  {"links": ["https://edge.example.org/1", "https://edge.example.org/2"]}`,
+	}, {
+		msg:         "json suffix content type",
+		path:        "$._links.self.href",
+		contentType: "application/hal+json; charset=utf-8",
+		body:        `{"_links": {"self": {"href": "http://a/1"}}}`,
+		expected:    `{"_links": {"self": {"href": "https://edge.example.org/1"}}}`,
+	}, {
+		msg:         "not json",
+		path:        "$._links.self.href",
+		contentType: "text/plain",
+		body:        `{"_links": {"self": {"href": "http://a/1"}}}`,
+		expected:    `{"_links": {"self": {"href": "http://a/1"}}}`,
+	}, {
+		msg:      "compressed",
+		path:     "$._links.self.href",
+		encoding: "gzip",
+		body:     `{"_links": {"self": {"href": "http://a/1"}}}`,
+		expected: `{"_links": {"self": {"href": "http://a/1"}}}`,
+	}, {
+		msg:      "invalid json",
+		path:     "$._links.self.href",
+		body:     `{"_links": {"self": {"href": "http://a/1"}}`,
+		expected: `{"_links": {"self": {"href": "http://a/1"}}`,
+	}, {
+		msg:      "too large",
+		path:     "$._links.self.href",
+		maxBytes: 16.0,
+		body:     `{"_links": {"self": {"href": "http://a/1"}}}`,
+		expected: `{"_links": {"self": {"href": "http://a/1"}}}`,
+	}} {
+		t.Run(tt.msg, func(t *testing.T) {
+			args := []interface{}{tt.path, "https?://[^/]+", "https://edge.example.org"}
+			if tt.maxBytes != nil {
+				args = append(args, tt.maxBytes)
+			}
+
+			f, err := NewRewriteJSONLinks().CreateFilter(args)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			contentType := tt.contentType
+			if contentType == "" {
+				contentType = "application/json"
+			}
+
+			rsp := &http.Response{
+				StatusCode:    http.StatusOK,
+				Header:        http.Header{"Content-Type": []string{contentType}},
+				Body:          io.NopCloser(strings.NewReader(tt.body)),
+				ContentLength: -1,
+			}
+
+			if tt.encoding != "" {
+				rsp.Header.Set("Content-Encoding", tt.encoding)
+			}
+
+			f.Response(&filtertest.Context{FResponse: rsp})
+
+			b, err := io.ReadAll(rsp.Body)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if string(b) != tt.expected {
+				t.Errorf("invalid body, expected:\n%s\ngot:\n%s", tt.expected, b)
+			}
+
+			if tt.body != tt.expected && rsp.ContentLength != int64(len(b)) {
+				t.Errorf("expected content length: %d, got: %d", len(b), rsp.ContentLength)
+			}
+		})
+	}
+}
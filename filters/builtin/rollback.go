@@ -0,0 +1,86 @@
+package builtin
+
+import (
+	"net/http"
+
+	"golang.org/x/net/http/httpguts"
+
+	"github.com/zalando/skipper/filters"
+	"github.com/zalando/skipper/routing"
+)
+
+type rollbackToSpec struct {
+	slots *routing.SlotRegistry
+}
+
+type rollbackToFilter struct {
+	slots  *routing.SlotRegistry
+	header string
+	slot   string
+}
+
+// NewRollbackTo creates a filter specification whose instances roll back
+// the traffic of a cohort to a known-good deployment slot, without a route
+// update. The filter is engaged, when a rollback of the request's cohort to
+// its slot is registered in the slot registry, typically with the admin
+// API, see routing.SlotRegistry. The cohort is the one of the traffic
+// segment of the route, or the route id, when the segment has no cohort.
+//
+// When engaged, the filter sets the slot header of the request to the name
+// of the slot, and the request is processed again as with a loopback
+// backend, so that the routes of the slot can match it based on the
+// header. The routes of the slot should not contain the filter.
+//
+// Example:
+//
+//	canary: Traffic(.1, "canary", "true") -> rollbackTo("X-Deployment-Slot", "blue") -> "https://green.example.org";
+//	blue: Header("X-Deployment-Slot", "blue") -> "https://blue.example.org";
+//	main: * -> "https://blue.example.org";
+func NewRollbackTo(slots *routing.SlotRegistry) filters.Spec {
+	return &rollbackToSpec{slots: slots}
+}
+
+func (*rollbackToSpec) Name() string { return filters.RollbackToName }
+
+func (s *rollbackToSpec) CreateFilter(args []interface{}) (filters.Filter, error) {
+	if len(args) != 2 {
+		return nil, filters.ErrInvalidFilterParameters
+	}
+
+	header, ok := args[0].(string)
+	if !ok || !httpguts.ValidHeaderFieldName(header) {
+		return nil, filters.ErrInvalidFilterParameters
+	}
+
+	slot, ok := args[1].(string)
+	if !ok || !httpguts.ValidHeaderFieldValue(slot) || !s.slots.Known(slot) {
+		return nil, filters.ErrInvalidFilterParameters
+	}
+
+	return &rollbackToFilter{
+		slots:  s.slots,
+		header: http.CanonicalHeaderKey(header),
+		slot:   slot,
+	}, nil
+}
+
+func (f *rollbackToFilter) Request(ctx filters.FilterContext) {
+	cohort, ok := segmentCohort(ctx)
+	if !ok {
+		return
+	}
+
+	if slot, ok := f.slots.Rollback(cohort); !ok || slot != f.slot {
+		return
+	}
+
+	// prevent looping, when the routes of the slot match the cohort, too
+	if ctx.Request().Header.Get(f.header) == f.slot {
+		return
+	}
+
+	ctx.Request().Header.Set(f.header, f.slot)
+	ctx.StateBag()[filters.LoopbackKey] = true
+}
+
+func (*rollbackToFilter) Response(filters.FilterContext) {}
@@ -0,0 +1,128 @@
+package builtin
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/zalando/skipper/filters"
+	"github.com/zalando/skipper/filters/filtertest"
+	"github.com/zalando/skipper/routing"
+)
+
+func TestRollbackToCreate(t *testing.T) {
+	slots := routing.NewSlotRegistry("blue", "green")
+	for _, tt := range []struct {
+		msg  string
+		args []interface{}
+		err  bool
+	}{{
+		msg: "no arguments",
+		err: true,
+	}, {
+		msg:  "missing slot",
+		args: []interface{}{"X-Deployment-Slot"},
+		err:  true,
+	}, {
+		msg:  "invalid header",
+		args: []interface{}{"X Deployment Slot", "blue"},
+		err:  true,
+	}, {
+		msg:  "invalid slot",
+		args: []interface{}{"X-Deployment-Slot", 1.0},
+		err:  true,
+	}, {
+		msg:  "unknown slot",
+		args: []interface{}{"X-Deployment-Slot", "red"},
+		err:  true,
+	}, {
+		msg:  "slot",
+		args: []interface{}{"X-Deployment-Slot", "blue"},
+	}} {
+		t.Run(tt.msg, func(t *testing.T) {
+			_, err := NewRollbackTo(slots).CreateFilter(tt.args)
+			if tt.err && err == nil {
+				t.Error("expected error")
+			} else if !tt.err && err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+		})
+	}
+}
+
+func TestRollbackTo(t *testing.T) {
+	slots := routing.NewSlotRegistry("blue", "green")
+	f, err := NewRollbackTo(slots).CreateFilter([]interface{}{"X-Deployment-Slot", "blue"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	request := func(segment *routing.TrafficSegment, slot string) *filtertest.Context {
+		req, err := http.NewRequest("GET", "https://www.example.org", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if slot != "" {
+			req.Header.Set("X-Deployment-Slot", slot)
+		}
+
+		ctx := &filtertest.Context{FRequest: req, FStateBag: make(map[string]interface{})}
+		if segment != nil {
+			ctx.FStateBag[filters.TrafficSegmentKey] = *segment
+		}
+
+		f.Request(ctx)
+		return ctx
+	}
+
+	engaged := func(ctx *filtertest.Context) bool {
+		loopback, _ := ctx.FStateBag[filters.LoopbackKey].(bool)
+		return loopback && ctx.FRequest.Header.Get("X-Deployment-Slot") == "blue"
+	}
+
+	canary := &routing.TrafficSegment{Cohort: "canary", RouteId: "canary"}
+	if engaged(request(canary, "")) {
+		t.Error("unexpected rollback before engaged")
+	}
+
+	if err := slots.Engage("canary", "blue"); err != nil {
+		t.Fatal(err)
+	}
+
+	if !engaged(request(canary, "")) {
+		t.Error("expected rollback")
+	}
+
+	if engaged(request(&routing.TrafficSegment{Cohort: "other"}, "")) {
+		t.Error("unexpected rollback of other cohort")
+	}
+
+	if engaged(request(nil, "")) {
+		t.Error("unexpected rollback without segment")
+	}
+
+	if ctx := request(canary, "blue"); ctx.FStateBag[filters.LoopbackKey] != nil {
+		t.Error("unexpected loopback of rolled back request")
+	}
+
+	if err := slots.Engage("canary", "green"); err != nil {
+		t.Fatal(err)
+	}
+
+	if engaged(request(canary, "")) {
+		t.Error("unexpected rollback to other slot")
+	}
+
+	if err := slots.Engage("route1", "blue"); err != nil {
+		t.Fatal(err)
+	}
+
+	if !engaged(request(&routing.TrafficSegment{RouteId: "route1"}, "")) {
+		t.Error("expected rollback by route id")
+	}
+
+	slots.Release("route1")
+	if engaged(request(&routing.TrafficSegment{RouteId: "route1"}, "")) {
+		t.Error("unexpected rollback after release")
+	}
+}
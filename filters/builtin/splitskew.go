@@ -0,0 +1,161 @@
+package builtin
+
+import (
+	"fmt"
+	"math/rand"
+	"sync"
+
+	"github.com/zalando/skipper/filters"
+	"github.com/zalando/skipper/metrics"
+	"github.com/zalando/skipper/routing"
+)
+
+const defaultSplitSkewName = "default"
+
+// the metrics needed by the splitSkewMetric filter
+type splitSkewGauges interface {
+	UpdateGauge(key string, value float64)
+}
+
+type splitSkewSpec struct {
+	mu      sync.Mutex
+	metrics splitSkewGauges
+	splits  map[string]*splitSkew
+}
+
+type splitSkewFilter struct {
+	split      *splitSkew
+	sampleRate float64
+}
+
+// splitSkew holds the observed request counts of the cohorts of a split.
+type splitSkew struct {
+	name    string
+	metrics splitSkewGauges
+
+	mu      sync.Mutex
+	total   int64
+	cohorts map[string]*splitSkewCohort
+}
+
+type splitSkewCohort struct {
+	expected float64
+	count    int64
+}
+
+// NewSplitSkewMetric creates a filter specification whose instances verify
+// that the observed traffic of the cohorts matches the intervals of the
+// TrafficSegment predicates. The filter counts the requests of the cohorts
+// of a split, and exposes the difference of the observed share of each
+// cohort and its expected share, the width of its interval, under the
+// splitskew.<split>.<cohort> gauge. The cohort is the one of the traffic
+// segment, or the route id, when the segment has no cohort.
+//
+// All the routes of a split need to contain the filter with the same split
+// name, that defaults to "default". The optional sample rate, from (0, 1],
+// limits the share of the requests counted. The counts of a cohort are reset
+// when its interval changes.
+//
+// Example:
+//
+//	stable: Path("/") && TrafficSegment(0, 0.9) -> splitSkewMetric("checkout", 0.1) -> "https://stable.example.org";
+//	canary: Path("/") && TrafficSegment(0.9, 1, "canary") -> splitSkewMetric("checkout", 0.1) -> "https://canary.example.org";
+func NewSplitSkewMetric() filters.Spec {
+	return newSplitSkewMetric(metrics.Default)
+}
+
+func newSplitSkewMetric(m splitSkewGauges) filters.Spec {
+	return &splitSkewSpec{metrics: m, splits: make(map[string]*splitSkew)}
+}
+
+func (*splitSkewSpec) Name() string { return filters.SplitSkewMetricName }
+
+func (s *splitSkewSpec) CreateFilter(args []interface{}) (filters.Filter, error) {
+	if len(args) > 2 {
+		return nil, filters.ErrInvalidFilterParameters
+	}
+
+	name := defaultSplitSkewName
+	if len(args) > 0 {
+		if n, ok := args[0].(string); ok {
+			if !latencyCompareBaselineName.MatchString(n) {
+				return nil, fmt.Errorf("split name %s is invalid", n)
+			}
+
+			name, args = n, args[1:]
+		}
+	}
+
+	sampleRate := 1.0
+	if len(args) > 1 {
+		return nil, filters.ErrInvalidFilterParameters
+	}
+
+	if len(args) == 1 {
+		switch v := args[0].(type) {
+		case int:
+			sampleRate = float64(v)
+		case float64:
+			sampleRate = v
+		default:
+			return nil, filters.ErrInvalidFilterParameters
+		}
+
+		if sampleRate <= 0 || sampleRate > 1 {
+			return nil, filters.ErrInvalidFilterParameters
+		}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	split, ok := s.splits[name]
+	if !ok {
+		split = &splitSkew{name: name, metrics: s.metrics, cohorts: make(map[string]*splitSkewCohort)}
+		s.splits[name] = split
+	}
+
+	return &splitSkewFilter{split: split, sampleRate: sampleRate}, nil
+}
+
+// observe counts a request of the cohort, and updates the skew of all the
+// cohorts of the split, because the observed shares of the others change,
+// too.
+func (s *splitSkew) observe(cohort string, expected float64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	c, ok := s.cohorts[cohort]
+	if !ok || c.expected != expected {
+		if ok {
+			s.total -= c.count
+		}
+
+		c = &splitSkewCohort{expected: expected}
+		s.cohorts[cohort] = c
+	}
+
+	c.count++
+	s.total++
+
+	for name, c := range s.cohorts {
+		observed := float64(c.count) / float64(s.total)
+		s.metrics.UpdateGauge("splitskew."+s.name+"."+name, observed-c.expected)
+	}
+}
+
+func (f *splitSkewFilter) Request(ctx filters.FilterContext) {
+	cohort, ok := segmentCohort(ctx)
+	if !ok {
+		return
+	}
+
+	if f.sampleRate < 1 && rand.Float64() >= f.sampleRate {
+		return
+	}
+
+	segment := ctx.StateBag()[filters.TrafficSegmentKey].(routing.TrafficSegment)
+	f.split.observe(cohort, segment.Max-segment.Min)
+}
+
+func (*splitSkewFilter) Response(filters.FilterContext) {}
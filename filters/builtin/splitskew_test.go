@@ -0,0 +1,144 @@
+package builtin
+
+import (
+	"math"
+	"net/http"
+	"testing"
+
+	"github.com/zalando/skipper/filters"
+	"github.com/zalando/skipper/filters/filtertest"
+	"github.com/zalando/skipper/metrics/metricstest"
+	"github.com/zalando/skipper/routing"
+)
+
+func TestSplitSkewMetricArgs(t *testing.T) {
+	for _, tt := range []struct {
+		msg  string
+		args []interface{}
+		fail bool
+	}{{
+		msg: "no args",
+	}, {
+		msg:  "split name",
+		args: []interface{}{"checkout"},
+	}, {
+		msg:  "sample rate",
+		args: []interface{}{0.1},
+	}, {
+		msg:  "split name and sample rate",
+		args: []interface{}{"checkout", 1},
+	}, {
+		msg:  "invalid split name",
+		args: []interface{}{"check out"},
+		fail: true,
+	}, {
+		msg:  "zero sample rate",
+		args: []interface{}{"checkout", 0.0},
+		fail: true,
+	}, {
+		msg:  "sample rate too large",
+		args: []interface{}{1.5},
+		fail: true,
+	}, {
+		msg:  "sample rate not a number",
+		args: []interface{}{"checkout", "0.1"},
+		fail: true,
+	}, {
+		msg:  "too many args",
+		args: []interface{}{"checkout", 0.1, 0.2},
+		fail: true,
+	}} {
+		t.Run(tt.msg, func(t *testing.T) {
+			_, err := NewSplitSkewMetric().CreateFilter(tt.args)
+			if tt.fail && err == nil {
+				t.Error("failed to fail")
+			} else if !tt.fail && err != nil {
+				t.Error(err)
+			}
+		})
+	}
+}
+
+func TestSplitSkewMetric(t *testing.T) {
+	m := &metricstest.MockMetrics{}
+	spec := newSplitSkewMetric(m)
+
+	stable, err := spec.CreateFilter([]interface{}{"checkout"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	canary, err := spec.CreateFilter([]interface{}{"checkout"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	request := func(f filters.Filter, segment interface{}) {
+		ctx := &filtertest.Context{FRequest: &http.Request{}, FStateBag: make(map[string]interface{})}
+		if segment != nil {
+			ctx.FStateBag[filters.TrafficSegmentKey] = segment
+		}
+
+		f.Request(ctx)
+	}
+
+	// 70% stable and 30% canary, while configured 90% and 10%
+	for i := 0; i < 7; i++ {
+		request(stable, routing.TrafficSegment{Min: 0, Max: 0.9, RouteId: "stable"})
+	}
+
+	for i := 0; i < 3; i++ {
+		request(canary, routing.TrafficSegment{Min: 0.9, Max: 1, Cohort: "canary", RouteId: "canary"})
+	}
+
+	// not counted without a segment
+	request(stable, nil)
+
+	for key, expected := range map[string]float64{
+		"splitskew.checkout.stable": -0.2,
+		"splitskew.checkout.canary": 0.2,
+	} {
+		v, ok := m.Gauge(key)
+		if !ok {
+			t.Errorf("gauge not found: %s", key)
+			continue
+		}
+
+		if math.Abs(v-expected) > 1e-9 {
+			t.Errorf("unexpected skew of %s: %f, expected: %f", key, v, expected)
+		}
+	}
+
+	// the counts of the cohort are reset when its interval changes
+	request(canary, routing.TrafficSegment{Min: 0.5, Max: 1, Cohort: "canary", RouteId: "canary"})
+	if v, _ := m.Gauge("splitskew.checkout.canary"); math.Abs(v-(1.0/8-0.5)) > 1e-9 {
+		t.Errorf("unexpected skew after the interval change: %f", v)
+	}
+}
+
+func TestSplitSkewMetricSampling(t *testing.T) {
+	m := &metricstest.MockMetrics{}
+	f, err := newSplitSkewMetric(m).CreateFilter([]interface{}{0.5})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	const n = 1000
+	for i := 0; i < n; i++ {
+		ctx := &filtertest.Context{
+			FRequest:  &http.Request{},
+			FStateBag: map[string]interface{}{filters.TrafficSegmentKey: routing.TrafficSegment{Min: 0, Max: 1, RouteId: "all"}},
+		}
+
+		f.Request(ctx)
+	}
+
+	count := f.(*splitSkewFilter).split.total
+	if count < n/4 || count > 3*n/4 {
+		t.Errorf("unexpected number of sampled requests: %d", count)
+	}
+
+	if v, ok := m.Gauge("splitskew.default.all"); !ok || v != 0 {
+		t.Errorf("unexpected skew: %f, %v", v, ok)
+	}
+}
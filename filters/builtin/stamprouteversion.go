@@ -0,0 +1,65 @@
+package builtin
+
+import (
+	"golang.org/x/net/http/httpguts"
+
+	"github.com/zalando/skipper/filters"
+)
+
+const defaultRouteVersionKey = "version"
+
+type stampRouteVersionSpec struct{}
+
+type stampRouteVersionFilter struct {
+	header string
+	key    string
+}
+
+// NewStampRouteVersion creates a filter specification whose instances set
+// the deployment version of the matched route in a response header, so that
+// the clients can see which deployment served them, e.g. during a canary
+// release. The version is read from the metadata of the route, set by the
+// data client, e.g. by the Kubernetes data client from the
+// zalando.org/skipper-route-version annotation. The optional second
+// argument is the metadata attribute, by default "version". When the route
+// has no version, the header is not set.
+//
+// Example:
+//
+//	stampRouteVersion("X-Deployment-Version")
+func NewStampRouteVersion() filters.Spec { return stampRouteVersionSpec{} }
+
+func (stampRouteVersionSpec) Name() string { return filters.StampRouteVersionName }
+
+func (stampRouteVersionSpec) CreateFilter(args []interface{}) (filters.Filter, error) {
+	if len(args) != 1 && len(args) != 2 {
+		return nil, filters.ErrInvalidFilterParameters
+	}
+
+	header, ok := args[0].(string)
+	if !ok || !httpguts.ValidHeaderFieldName(header) {
+		return nil, filters.ErrInvalidFilterParameters
+	}
+
+	f := &stampRouteVersionFilter{header: header, key: defaultRouteVersionKey}
+	if len(args) == 2 {
+		if f.key, ok = args[1].(string); !ok || f.key == "" {
+			return nil, filters.ErrInvalidFilterParameters
+		}
+	}
+
+	return f, nil
+}
+
+func (*stampRouteVersionFilter) Request(filters.FilterContext) {}
+
+func (f *stampRouteVersionFilter) Response(ctx filters.FilterContext) {
+	metadata, ok := ctx.StateBag()[filters.RouteMetadataKey].(map[string]string)
+	if !ok {
+		return
+	}
+
+	if v := metadata[f.key]; v != "" && httpguts.ValidHeaderFieldValue(v) {
+		ctx.Response().Header.Set(f.header, v)
+	}
+}
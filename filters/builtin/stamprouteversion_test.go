@@ -0,0 +1,111 @@
+package builtin
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/zalando/skipper/eskip"
+	"github.com/zalando/skipper/filters"
+	"github.com/zalando/skipper/filters/filtertest"
+	"github.com/zalando/skipper/proxy/proxytest"
+)
+
+func TestStampRouteVersionArgs(t *testing.T) {
+	for _, tt := range []struct {
+		msg  string
+		args []interface{}
+		fail bool
+	}{
+		{msg: "no args", fail: true},
+		{msg: "not a string", args: []interface{}{42}, fail: true},
+		{msg: "empty header", args: []interface{}{""}, fail: true},
+		{msg: "invalid header", args: []interface{}{"X Version"}, fail: true},
+		{msg: "empty key", args: []interface{}{"X-Version", ""}, fail: true},
+		{msg: "too many", args: []interface{}{"X-Version", "version", "release"}, fail: true},
+		{msg: "header", args: []interface{}{"X-Version"}},
+		{msg: "header and key", args: []interface{}{"X-Version", "release"}},
+	} {
+		t.Run(tt.msg, func(t *testing.T) {
+			_, err := NewStampRouteVersion().CreateFilter(tt.args)
+			if tt.fail != (err != nil) {
+				t.Errorf("expected failure: %v, got: %v", tt.fail, err)
+			}
+		})
+	}
+}
+
+func TestStampRouteVersion(t *testing.T) {
+	for _, tt := range []struct {
+		msg      string
+		args     []interface{}
+		metadata map[string]string
+		expected string
+	}{{
+		msg:      "version",
+		args:     []interface{}{"X-Version"},
+		metadata: map[string]string{"version": "v1.4.2"},
+		expected: "v1.4.2",
+	}, {
+		msg:      "custom key",
+		args:     []interface{}{"X-Version", "release"},
+		metadata: map[string]string{"version": "v1.4.2", "release": "2023-06-01"},
+		expected: "2023-06-01",
+	}, {
+		msg:      "no version",
+		args:     []interface{}{"X-Version"},
+		metadata: map[string]string{"team": "checkout"},
+	}, {
+		msg:  "no metadata",
+		args: []interface{}{"X-Version"},
+	}, {
+		msg:      "invalid value",
+		args:     []interface{}{"X-Version"},
+		metadata: map[string]string{"version": "v1\r\nX-Injected: true"},
+	}} {
+		t.Run(tt.msg, func(t *testing.T) {
+			f, err := NewStampRouteVersion().CreateFilter(tt.args)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			ctx := &filtertest.Context{
+				FStateBag: make(map[string]interface{}),
+				FResponse: &http.Response{Header: make(http.Header)},
+			}
+
+			if tt.metadata != nil {
+				ctx.FStateBag[filters.RouteMetadataKey] = tt.metadata
+			}
+
+			f.Response(ctx)
+			if v := ctx.FResponse.Header.Get("X-Version"); v != tt.expected {
+				t.Errorf("expected version %q, got %q", tt.expected, v)
+			}
+		})
+	}
+}
+
+func TestStampRouteVersionProxy(t *testing.T) {
+	fr := make(filters.Registry)
+	fr.Register(NewStampRouteVersion())
+	fr.Register(NewStatus())
+
+	canary := eskip.MustParse(`canary: Path("/canary") -> stampRouteVersion("X-Version") -> status(204) -> <shunt>`)[0]
+	canary.Metadata = map[string]string{"version": "v2"}
+	stable := eskip.MustParse(`stable: Path("/stable") -> stampRouteVersion("X-Version") -> status(204) -> <shunt>`)[0]
+
+	p := proxytest.New(fr, canary, stable)
+	defer p.Close()
+
+	for path, expected := range map[string]string{"/canary": "v2", "/stable": ""} {
+		rsp, err := http.Get(p.URL + path)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		rsp.Body.Close()
+		if v := rsp.Header.Get("X-Version"); v != expected {
+			t.Errorf("%s: expected version %q, got %q", path, expected, v)
+		}
+	}
+}
@@ -1,14 +1,30 @@
 package builtin
 
-import "github.com/zalando/skipper/filters"
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/zalando/skipper/eskip"
+	"github.com/zalando/skipper/filters"
+)
 
 type statusSpec struct{}
 
 type statusFilter int
 
+type statusTemplateFilter struct {
+	template *eskip.Template
+}
+
 // Creates a filter specification whose instances set the
 // status of the response to a fixed value regardless of
 // backend response.
+//
+// The status can be also defined as a template, e.g.
+// status("${request.header.X-Desired-Status}"), resolved
+// at request time. When the template resolves to an invalid
+// HTTP status code, the status is set to 500. A string argument
+// without placeholders needs to be a valid status code.
 func NewStatus() filters.Spec { return new(statusSpec) }
 
 func (s *statusSpec) Name() string { return filters.StatusName }
@@ -23,6 +39,18 @@ func (s *statusSpec) CreateFilter(args []interface{}) (filters.Filter, error) {
 		return statusFilter(c), nil
 	case float64:
 		return statusFilter(c), nil
+	case string:
+		t := eskip.NewTemplate(c)
+		if t.HasPlaceholders() {
+			return &statusTemplateFilter{template: t}, nil
+		}
+
+		code, err := strconv.Atoi(c)
+		if err != nil || code < 100 || code > 599 {
+			return nil, filters.ErrInvalidFilterParameters
+		}
+
+		return statusFilter(code), nil
 	default:
 		return nil, filters.ErrInvalidFilterParameters
 	}
@@ -33,3 +61,16 @@ func (f statusFilter) Request(filters.FilterContext) {}
 func (f statusFilter) Response(ctx filters.FilterContext) {
 	ctx.Response().StatusCode = int(f)
 }
+
+func (f *statusTemplateFilter) Request(filters.FilterContext) {}
+
+func (f *statusTemplateFilter) Response(ctx filters.FilterContext) {
+	code := http.StatusInternalServerError
+	if value, ok := f.template.ApplyContext(ctx); ok {
+		if c, err := strconv.Atoi(value); err == nil && c >= 100 && c <= 599 {
+			code = c
+		}
+	}
+
+	ctx.Response().StatusCode = code
+}
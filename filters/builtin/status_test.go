@@ -12,6 +12,7 @@ func TestStatus(t *testing.T) {
 	for _, ti := range []struct {
 		msg          string
 		args         []interface{}
+		header       http.Header
 		expectedCode int
 	}{{
 		msg:          "no arguments",
@@ -23,12 +24,43 @@ func TestStatus(t *testing.T) {
 		expectedCode: http.StatusNotFound,
 	}, {
 		msg:          "invalid code argument",
-		args:         []interface{}{"418"},
+		args:         []interface{}{[]interface{}{"418"}},
 		expectedCode: http.StatusNotFound,
 	}, {
 		msg:          "set status",
 		args:         []interface{}{float64(http.StatusTeapot)},
 		expectedCode: http.StatusTeapot,
+	}, {
+		msg:          "set status from string literal",
+		args:         []interface{}{"418"},
+		expectedCode: http.StatusTeapot,
+	}, {
+		msg:          "invalid string literal",
+		args:         []interface{}{"abc"},
+		expectedCode: http.StatusNotFound,
+	}, {
+		msg:          "string literal out of range",
+		args:         []interface{}{"1000"},
+		expectedCode: http.StatusNotFound,
+	}, {
+		msg:          "set status from template",
+		args:         []interface{}{"${request.header.X-Desired-Status}"},
+		header:       http.Header{"X-Desired-Status": []string{"429"}},
+		expectedCode: http.StatusTooManyRequests,
+	}, {
+		msg:          "template not resolved",
+		args:         []interface{}{"${request.header.X-Desired-Status}"},
+		expectedCode: http.StatusInternalServerError,
+	}, {
+		msg:          "template resolved to non-number",
+		args:         []interface{}{"${request.header.X-Desired-Status}"},
+		header:       http.Header{"X-Desired-Status": []string{"teapot"}},
+		expectedCode: http.StatusInternalServerError,
+	}, {
+		msg:          "template resolved to invalid status",
+		args:         []interface{}{"${request.header.X-Desired-Status}"},
+		header:       http.Header{"X-Desired-Status": []string{"1000"}},
+		expectedCode: http.StatusInternalServerError,
 	}} {
 		fr := make(filters.Registry)
 		fr.Register(NewStatus())
@@ -44,6 +76,9 @@ func TestStatus(t *testing.T) {
 		}
 
 		req.Close = true
+		for k, v := range ti.header {
+			req.Header[k] = v
+		}
 
 		rsp, err := (&http.Client{}).Do(req)
 		if err != nil {
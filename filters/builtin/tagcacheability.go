@@ -0,0 +1,129 @@
+package builtin
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/zalando/skipper/filters"
+)
+
+const cacheableHeader = "X-Cacheable"
+
+type tagCacheabilitySpec struct{}
+
+type tagCacheabilityFilter struct{}
+
+// the status codes that are cacheable by default, see RFC 9110, 15.1
+var heuristicallyCacheable = map[int]bool{
+	http.StatusOK:                   true,
+	http.StatusNonAuthoritativeInfo: true,
+	http.StatusNoContent:            true,
+	http.StatusPartialContent:       true,
+	http.StatusMultipleChoices:      true,
+	http.StatusMovedPermanently:     true,
+	http.StatusPermanentRedirect:    true,
+	http.StatusNotFound:             true,
+	http.StatusMethodNotAllowed:     true,
+	http.StatusGone:                 true,
+	http.StatusRequestURITooLong:    true,
+	http.StatusNotImplemented:       true,
+}
+
+// NewTagCacheability creates a filter specification whose instances tag
+// the responses with whether they are cacheable by a shared cache,
+// following the rules of RFC 9111, based on the request method, the
+// response status and the Cache-Control, Expires and Vary headers. The
+// result is set as "true" or "false" in the X-Cacheable response header,
+// and it is stored in the state bag under filters.CacheableKey as bool,
+// for the preceding response filters in the route.
+//
+// Example:
+//
+//	warmup: * -> tagCacheability() -> "https://www.example.org";
+func NewTagCacheability() filters.Spec { return tagCacheabilitySpec{} }
+
+func (tagCacheabilitySpec) Name() string { return filters.TagCacheabilityName }
+
+func (tagCacheabilitySpec) CreateFilter(args []interface{}) (filters.Filter, error) {
+	if len(args) != 0 {
+		return nil, filters.ErrInvalidFilterParameters
+	}
+
+	return tagCacheabilityFilter{}, nil
+}
+
+// cacheDirectives returns the Cache-Control directives with lower case
+// names, and with the values without quotes.
+func cacheDirectives(h http.Header) map[string]string {
+	d := make(map[string]string)
+	for _, v := range h.Values("Cache-Control") {
+		for _, di := range strings.Split(v, ",") {
+			name, value, _ := strings.Cut(strings.TrimSpace(di), "=")
+			if name == "" {
+				continue
+			}
+
+			d[strings.ToLower(name)] = strings.Trim(value, `"`)
+		}
+	}
+
+	return d
+}
+
+func cacheable(req *http.Request, rsp *http.Response) bool {
+	if req.Method != http.MethodGet && req.Method != http.MethodHead {
+		return false
+	}
+
+	if _, ok := cacheDirectives(req.Header)["no-store"]; ok {
+		return false
+	}
+
+	d := cacheDirectives(rsp.Header)
+	if _, ok := d["no-store"]; ok {
+		return false
+	}
+
+	if _, ok := d["private"]; ok {
+		return false
+	}
+
+	for _, v := range rsp.Header.Values("Vary") {
+		if strings.TrimSpace(v) == "*" {
+			return false
+		}
+	}
+
+	_, public := d["public"]
+	_, sMaxAge := d["s-maxage"]
+	if req.Header.Get("Authorization") != "" {
+		if _, mustRevalidate := d["must-revalidate"]; !public && !sMaxAge && !mustRevalidate {
+			return false
+		}
+	}
+
+	if heuristicallyCacheable[rsp.StatusCode] || public || sMaxAge {
+		return true
+	}
+
+	if _, ok := d["max-age"]; ok {
+		return true
+	}
+
+	return rsp.Header.Get("Expires") != ""
+}
+
+func (tagCacheabilityFilter) Request(filters.FilterContext) {}
+
+func (tagCacheabilityFilter) Response(ctx filters.FilterContext) {
+	c := cacheable(ctx.Request(), ctx.Response())
+	ctx.StateBag()[filters.CacheableKey] = c
+
+	rsp := ctx.Response()
+	if rsp.Header == nil {
+		rsp.Header = make(http.Header)
+	}
+
+	rsp.Header.Set(cacheableHeader, strconv.FormatBool(c))
+}
@@ -0,0 +1,126 @@
+package builtin
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/zalando/skipper/filters"
+	"github.com/zalando/skipper/filters/filtertest"
+)
+
+func TestTagCacheabilityArgs(t *testing.T) {
+	if _, err := NewTagCacheability().CreateFilter([]interface{}{"foo"}); err == nil {
+		t.Error("failed to fail")
+	}
+}
+
+func TestTagCacheability(t *testing.T) {
+	for _, tt := range []struct {
+		msg            string
+		method         string
+		requestHeader  http.Header
+		status         int
+		responseHeader http.Header
+		expected       bool
+	}{{
+		msg:      "ok",
+		status:   http.StatusOK,
+		expected: true,
+	}, {
+		msg:      "head",
+		method:   "HEAD",
+		status:   http.StatusOK,
+		expected: true,
+	}, {
+		msg:      "not found",
+		status:   http.StatusNotFound,
+		expected: true,
+	}, {
+		msg:    "post",
+		method: "POST",
+		status: http.StatusOK,
+	}, {
+		msg:    "not cacheable status",
+		status: http.StatusInternalServerError,
+	}, {
+		msg:            "not cacheable status with max-age",
+		status:         http.StatusInternalServerError,
+		responseHeader: http.Header{"Cache-Control": []string{"max-age=10"}},
+		expected:       true,
+	}, {
+		msg:            "not cacheable status with expires",
+		status:         http.StatusFound,
+		responseHeader: http.Header{"Expires": []string{"Thu, 01 Dec 2044 16:00:00 GMT"}},
+		expected:       true,
+	}, {
+		msg:            "no-store",
+		status:         http.StatusOK,
+		responseHeader: http.Header{"Cache-Control": []string{"max-age=60, No-Store"}},
+	}, {
+		msg:            "private",
+		status:         http.StatusOK,
+		responseHeader: http.Header{"Cache-Control": []string{"private"}},
+	}, {
+		msg:           "request no-store",
+		status:        http.StatusOK,
+		requestHeader: http.Header{"Cache-Control": []string{"no-store"}},
+	}, {
+		msg:            "vary all",
+		status:         http.StatusOK,
+		responseHeader: http.Header{"Vary": []string{"*"}},
+	}, {
+		msg:           "authorized",
+		status:        http.StatusOK,
+		requestHeader: http.Header{"Authorization": []string{"Bearer foo"}},
+	}, {
+		msg:            "authorized public",
+		status:         http.StatusOK,
+		requestHeader:  http.Header{"Authorization": []string{"Bearer foo"}},
+		responseHeader: http.Header{"Cache-Control": []string{"public"}},
+		expected:       true,
+	}, {
+		msg:            "authorized s-maxage",
+		status:         http.StatusOK,
+		requestHeader:  http.Header{"Authorization": []string{"Bearer foo"}},
+		responseHeader: http.Header{"Cache-Control": []string{"s-maxage=60"}},
+		expected:       true,
+	}} {
+		t.Run(tt.msg, func(t *testing.T) {
+			f, err := NewTagCacheability().CreateFilter(nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			method := tt.method
+			if method == "" {
+				method = "GET"
+			}
+
+			req, err := http.NewRequest(method, "https://www.example.org", nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if tt.requestHeader != nil {
+				req.Header = tt.requestHeader
+			}
+
+			rsp := &http.Response{StatusCode: tt.status, Header: tt.responseHeader}
+			ctx := &filtertest.Context{FRequest: req, FResponse: rsp, FStateBag: make(map[string]interface{})}
+			f.Response(ctx)
+
+			if c, ok := ctx.FStateBag[filters.CacheableKey].(bool); !ok || c != tt.expected {
+				t.Errorf("expected cacheable flag: %v, got: %v", tt.expected, ctx.FStateBag[filters.CacheableKey])
+			}
+
+			expectedHeader := "false"
+			if tt.expected {
+				expectedHeader = "true"
+			}
+
+			if h := rsp.Header.Get("X-Cacheable"); h != expectedHeader {
+				t.Errorf("expected header: %s, got: %s", expectedHeader, h)
+			}
+		})
+	}
+}
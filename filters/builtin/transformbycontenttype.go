@@ -0,0 +1,149 @@
+package builtin
+
+import (
+	"fmt"
+	"io"
+	"mime"
+	"strings"
+
+	"github.com/zalando/skipper/eskip"
+	"github.com/zalando/skipper/filters"
+)
+
+type transformByContentTypeSpec struct {
+	registry filters.Registry
+}
+
+type transformByContentTypeFilter struct {
+	transforms []contentTypeTransform
+}
+
+type contentTypeTransform struct {
+	mediaType string
+	filters   []filters.Filter
+}
+
+// NewTransformByContentType creates a filter specification whose instances
+// apply the filter chain configured for the content type of the request,
+// and, independently, for the content type of the response. This way, a
+// single route can handle the bodies of different formats with the right
+// transformations.
+//
+// The arguments are in the format of <media type>:<filters>, where the
+// filters are an eskip filter chain, looked up in the registry. The media
+// type can be a wildcard like text/* or */*. The first argument whose media
+// type matches is used. The requests and the responses with other content
+// types are not changed. Unlike in the routes, the filters of a chain are
+// applied as a pipeline, in the same order both to the matching requests
+// and to the matching responses, e.g. converting XML to JSON first, and
+// then editing the JSON.
+//
+// The filters of the chain are created from the given registry, which
+// therefore needs to contain the specifications referenced in the routes.
+//
+// Example:
+//
+//	transformByContentType("application/json:jsonKeyCase(\"camel\")", "application/xml:xmlToJSON() -> jsonKeyCase(\"camel\")")
+func NewTransformByContentType(registry filters.Registry) filters.Spec {
+	return &transformByContentTypeSpec{registry: registry}
+}
+
+func (*transformByContentTypeSpec) Name() string { return filters.TransformByContentTypeName }
+
+func (s *transformByContentTypeSpec) CreateFilter(args []interface{}) (filters.Filter, error) {
+	if len(args) == 0 {
+		return nil, filters.ErrInvalidFilterParameters
+	}
+
+	f := &transformByContentTypeFilter{}
+	for _, a := range args {
+		arg, ok := a.(string)
+		if !ok {
+			return nil, filters.ErrInvalidFilterParameters
+		}
+
+		mediaType, chain, ok := strings.Cut(arg, ":")
+		mediaType = strings.ToLower(strings.TrimSpace(mediaType))
+		if !ok || !strings.Contains(mediaType, "/") {
+			return nil, fmt.Errorf("invalid content type transformation: %s", arg)
+		}
+
+		defs, err := eskip.ParseFilters(chain)
+		if err != nil {
+			return nil, fmt.Errorf("invalid filters of content type %s: %w", mediaType, err)
+		}
+
+		if len(defs) == 0 {
+			return nil, fmt.Errorf("missing filters of content type %s", mediaType)
+		}
+
+		t := contentTypeTransform{mediaType: mediaType}
+		for _, d := range defs {
+			spec, ok := s.registry[d.Name]
+			if !ok {
+				return nil, fmt.Errorf("filter not found: %s", d.Name)
+			}
+
+			sub, err := spec.CreateFilter(d.Args)
+			if err != nil {
+				return nil, fmt.Errorf("failed to create filter %s of content type %s: %w", d.Name, mediaType, err)
+			}
+
+			t.filters = append(t.filters, sub)
+		}
+
+		f.transforms = append(f.transforms, t)
+	}
+
+	return f, nil
+}
+
+func (t contentTypeTransform) match(mediaType string) bool {
+	if t.mediaType == "*/*" || t.mediaType == mediaType {
+		return true
+	}
+
+	prefix := strings.TrimSuffix(t.mediaType, "*")
+	return len(prefix) < len(t.mediaType) && strings.HasPrefix(mediaType, prefix)
+}
+
+// transform returns the filters configured for the content type.
+func (f *transformByContentTypeFilter) transform(contentType string) []filters.Filter {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return nil
+	}
+
+	for _, t := range f.transforms {
+		if t.match(mediaType) {
+			return t.filters
+		}
+	}
+
+	return nil
+}
+
+func (f *transformByContentTypeFilter) Request(ctx filters.FilterContext) {
+	for _, sub := range f.transform(ctx.Request().Header.Get("Content-Type")) {
+		sub.Request(ctx)
+	}
+}
+
+func (f *transformByContentTypeFilter) Response(ctx filters.FilterContext) {
+	for _, sub := range f.transform(ctx.Response().Header.Get("Content-Type")) {
+		sub.Response(ctx)
+	}
+}
+
+// Close closes the filters of the chains that need it.
+func (f *transformByContentTypeFilter) Close() error {
+	for _, t := range f.transforms {
+		for _, sub := range t.filters {
+			if c, ok := sub.(io.Closer); ok {
+				c.Close()
+			}
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,156 @@
+package builtin
+
+import (
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/zalando/skipper/filters"
+	"github.com/zalando/skipper/filters/filtertest"
+)
+
+func TestTransformByContentTypeArgs(t *testing.T) {
+	registry := MakeRegistry()
+	spec := registry[filters.TransformByContentTypeName]
+
+	for _, tt := range []struct {
+		msg  string
+		args []interface{}
+		fail bool
+	}{
+		{msg: "no args", fail: true},
+		{msg: "not a string", args: []interface{}{42}, fail: true},
+		{msg: "missing filters", args: []interface{}{"application/json"}, fail: true},
+		{msg: "empty filters", args: []interface{}{"application/json:"}, fail: true},
+		{msg: "invalid media type", args: []interface{}{"json:jsonKeyCase(\"camel\")"}, fail: true},
+		{msg: "invalid chain", args: []interface{}{"application/json:jsonKeyCase(camel)"}, fail: true},
+		{msg: "unknown filter", args: []interface{}{"application/json:noSuchFilter()"}, fail: true},
+		{msg: "invalid filter args", args: []interface{}{"application/json:jsonKeyCase(\"title\")"}, fail: true},
+		{msg: "single", args: []interface{}{"application/json:jsonKeyCase(\"camel\")"}},
+		{msg: "wildcard and chain", args: []interface{}{"text/*:setResponseHeader(\"X-Text\", \"true\") -> setRequestHeader(\"X-Text\", \"true\")"}},
+	} {
+		t.Run(tt.msg, func(t *testing.T) {
+			_, err := spec.CreateFilter(tt.args)
+			if tt.fail != (err != nil) {
+				t.Errorf("expected failure: %v, got: %v", tt.fail, err)
+			}
+		})
+	}
+}
+
+func TestTransformByContentType(t *testing.T) {
+	spec := MakeRegistry()[filters.TransformByContentTypeName]
+	f, err := spec.CreateFilter([]interface{}{
+		"application/json:jsonKeyCase(\"camel\")",
+		"application/xml:xmlToJSON() -> jsonKeyCase(\"camel\")",
+		"text/*:setResponseHeader(\"X-Text\", \"true\")",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tt := range []struct {
+		msg                 string
+		contentType         string
+		body                string
+		expectedBody        string
+		expectedContentType string
+		expectedHeader      string
+	}{{
+		msg:                 "JSON",
+		contentType:         "application/json",
+		body:                `{"user_id": 42, "home_address": {"zip_code": "10115"}}`,
+		expectedBody:        `{"userId":42,"homeAddress":{"zipCode":"10115"}}`,
+		expectedContentType: "application/json",
+	}, {
+		msg:                 "XML",
+		contentType:         "application/xml; charset=utf-8",
+		body:                `<order order_id="42"><line_item>a</line_item><line_item>b</line_item></order>`,
+		expectedBody:        `{"order":{"@orderId":"42","lineItem":["a","b"]}}`,
+		expectedContentType: "application/json",
+	}, {
+		msg:                 "wildcard",
+		contentType:         "text/plain",
+		body:                "user_id",
+		expectedBody:        "user_id",
+		expectedContentType: "text/plain",
+		expectedHeader:      "true",
+	}, {
+		msg:                 "no match",
+		contentType:         "application/octet-stream",
+		body:                `{"user_id": 42}`,
+		expectedBody:        `{"user_id": 42}`,
+		expectedContentType: "application/octet-stream",
+	}} {
+		t.Run(tt.msg, func(t *testing.T) {
+			rsp := &http.Response{
+				Header:        http.Header{"Content-Type": []string{tt.contentType}},
+				ContentLength: int64(len(tt.body)),
+				Body:          io.NopCloser(strings.NewReader(tt.body)),
+			}
+
+			f.Response(&filtertest.Context{FResponse: rsp})
+			b, err := io.ReadAll(rsp.Body)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if string(b) != tt.expectedBody {
+				t.Errorf("expected body %s, got %s", tt.expectedBody, b)
+			}
+
+			if ct := rsp.Header.Get("Content-Type"); ct != tt.expectedContentType {
+				t.Errorf("expected content type %s, got %s", tt.expectedContentType, ct)
+			}
+
+			if h := rsp.Header.Get("X-Text"); h != tt.expectedHeader {
+				t.Errorf("expected header %q, got %q", tt.expectedHeader, h)
+			}
+		})
+	}
+}
+
+func TestTransformByContentTypeRequestAndResponse(t *testing.T) {
+	spec := MakeRegistry()[filters.TransformByContentTypeName]
+	f, err := spec.CreateFilter([]interface{}{
+		"application/xml:xmlToJSON()",
+		"application/json:jsonKeyCase(\"snake\")",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	r, err := http.NewRequest("POST", "https://www.example.org/orders", strings.NewReader(`<order><item>a</item></order>`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	r.Header.Set("Content-Type", "application/xml")
+	ctx := &filtertest.Context{FRequest: r}
+	f.Request(ctx)
+
+	b, err := io.ReadAll(r.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if string(b) != `{"order":{"item":"a"}}` {
+		t.Errorf("unexpected request body: %s", b)
+	}
+
+	// the response is dispatched by its own content type
+	ctx.FResponse = &http.Response{
+		Header: http.Header{"Content-Type": []string{"application/json"}},
+		Body:   io.NopCloser(strings.NewReader(`{"orderId": 42}`)),
+	}
+
+	f.Response(ctx)
+	if b, err = io.ReadAll(ctx.FResponse.Body); err != nil {
+		t.Fatal(err)
+	}
+
+	if string(b) != `{"order_id":42}` {
+		t.Errorf("unexpected response body: %s", b)
+	}
+}
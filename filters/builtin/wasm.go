@@ -0,0 +1,280 @@
+package builtin
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/tetratelabs/wazero"
+
+	"github.com/zalando/skipper/filters"
+	"github.com/zalando/skipper/net"
+)
+
+const (
+	wasmAllocName     = "alloc"
+	wasmTransformName = "transform"
+
+	defaultWasmTimeout     = 100 * time.Millisecond
+	defaultWasmMaxBodySize = net.DefaultMaxBodySize
+
+	// 16MB, the size of a WASM memory page is 64KB
+	wasmMemoryLimitPages = 256
+)
+
+// WasmResponseSpec is the specification of the wasmResponse filter.
+type WasmResponseSpec struct {
+	mu      sync.Mutex
+	runtime wazero.Runtime
+	cache   wazero.CompilationCache
+	modules map[string]*wasmModule
+}
+
+// wasmModule is a module compiled from a file, valid as long as the file
+// doesn't change.
+type wasmModule struct {
+	modTime  time.Time
+	size     int64
+	compiled wazero.CompiledModule
+}
+
+type wasmFilter struct {
+	runtime     wazero.Runtime
+	module      wazero.CompiledModule
+	timeout     time.Duration
+	maxBodySize int64
+}
+
+// NewWasmResponse creates a filter specification whose instances
+// transform the response body with a WASM module.
+//
+// The module is loaded and compiled when the first filter using it is
+// created, and it is compiled again only when the modification time or the
+// size of the file changes. It needs to export its memory, an alloc(size i32) i32 function, returning the
+// offset where the input of the given size can be written, and a
+// transform(offset i32, size i32) i64 function, returning the offset of
+// the output in the upper and its size in the lower 32 bits.
+//
+// The module is instantiated for every response, without any host
+// functions, and with its memory capped to 16MB. The execution is aborted
+// after the timeout, 100ms by default. Responses with a body larger than
+// the maximum body size, 1MB by default, or with a Content-Encoding, are
+// not transformed. When the transformation fails, the original body is
+// returned.
+//
+// The spec needs to be closed, to release the compiled modules.
+//
+// Example:
+//
+//	r: * -> wasmResponse("/etc/skipper/transform.wasm", "50ms", "256KB") -> "https://www.example.org";
+func NewWasmResponse() *WasmResponseSpec {
+	ctx := context.Background()
+	cache := wazero.NewCompilationCache()
+	return &WasmResponseSpec{
+		runtime: wazero.NewRuntimeWithConfig(ctx, wazero.NewRuntimeConfig().
+			WithMemoryLimitPages(wasmMemoryLimitPages).
+			WithCloseOnContextDone(true).
+			WithCompilationCache(cache)),
+		cache:   cache,
+		modules: make(map[string]*wasmModule),
+	}
+}
+
+func (*WasmResponseSpec) Name() string { return filters.WasmResponseName }
+
+func (s *WasmResponseSpec) CreateFilter(args []interface{}) (filters.Filter, error) {
+	if len(args) < 1 || len(args) > 3 {
+		return nil, filters.ErrInvalidFilterParameters
+	}
+
+	path, ok := args[0].(string)
+	if !ok {
+		return nil, filters.ErrInvalidFilterParameters
+	}
+
+	f := &wasmFilter{timeout: defaultWasmTimeout, maxBodySize: defaultWasmMaxBodySize}
+	if len(args) > 1 {
+		s, ok := args[1].(string)
+		if !ok {
+			return nil, filters.ErrInvalidFilterParameters
+		}
+
+		d, err := time.ParseDuration(s)
+		if err != nil || d <= 0 {
+			return nil, filters.ErrInvalidFilterParameters
+		}
+
+		f.timeout = d
+	}
+
+	if len(args) > 2 {
+		s, ok := args[2].(string)
+		if !ok {
+			return nil, filters.ErrInvalidFilterParameters
+		}
+
+		size, err := net.ParseByteSize(s)
+		if err != nil || size <= 0 || size > 1<<31 {
+			return nil, filters.ErrInvalidFilterParameters
+		}
+
+		f.maxBodySize = size
+	}
+
+	m, err := s.module(path)
+	if err != nil {
+		return nil, err
+	}
+
+	f.runtime, f.module = s.runtime, m
+	return f, nil
+}
+
+// module returns the compiled module of the file, and compiles it only
+// when it was not compiled yet, or the file changed since.
+func (s *WasmResponseSpec) module(path string) (wazero.CompiledModule, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	fi, err := os.Stat(path)
+	if err != nil {
+		return nil, fmt.Errorf("%s: failed to read module: %w", filters.WasmResponseName, err)
+	}
+
+	m, ok := s.modules[path]
+	if ok && m.modTime.Equal(fi.ModTime()) && m.size == fi.Size() {
+		return m.compiled, nil
+	}
+
+	bin, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("%s: failed to read module: %w", filters.WasmResponseName, err)
+	}
+
+	ctx := context.Background()
+	compiled, err := s.runtime.CompileModule(ctx, bin)
+	if err != nil {
+		return nil, fmt.Errorf("%s: failed to compile module %s: %w", filters.WasmResponseName, path, err)
+	}
+
+	if err := validateWasmModule(compiled); err != nil {
+		compiled.Close(ctx)
+		return nil, fmt.Errorf("%s: invalid module %s: %w", filters.WasmResponseName, path, err)
+	}
+
+	// the replaced module is not closed, because the filters of the older
+	// routing tables may still use it, it is released with the runtime
+	s.modules[path] = &wasmModule{modTime: fi.ModTime(), size: fi.Size(), compiled: compiled}
+	return compiled, nil
+}
+
+// Close releases the runtime, with all the modules compiled by it.
+func (s *WasmResponseSpec) Close() error {
+	ctx := context.Background()
+	err := s.runtime.Close(ctx)
+	s.cache.Close(ctx)
+	return err
+}
+
+func validateWasmModule(m wazero.CompiledModule) error {
+	if len(m.ImportedFunctions()) > 0 {
+		return errors.New("imported functions are not supported")
+	}
+
+	if len(m.ExportedMemories()) == 0 {
+		return errors.New("missing exported memory")
+	}
+
+	functions := m.ExportedFunctions()
+	alloc, ok := functions[wasmAllocName]
+	if !ok {
+		return fmt.Errorf("missing exported function %s", wasmAllocName)
+	}
+
+	if len(alloc.ParamTypes()) != 1 || len(alloc.ResultTypes()) != 1 {
+		return fmt.Errorf("invalid signature of %s", wasmAllocName)
+	}
+
+	transform, ok := functions[wasmTransformName]
+	if !ok {
+		return fmt.Errorf("missing exported function %s", wasmTransformName)
+	}
+
+	if len(transform.ParamTypes()) != 2 || len(transform.ResultTypes()) != 1 {
+		return fmt.Errorf("invalid signature of %s", wasmTransformName)
+	}
+
+	return nil
+}
+
+func (f *wasmFilter) transform(body []byte) ([]byte, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), f.timeout)
+	defer cancel()
+
+	// instantiating the module for every call means that no state is
+	// shared between the responses
+	mod, err := f.runtime.InstantiateModule(ctx, f.module, wazero.NewModuleConfig().WithName(""))
+	if err != nil {
+		return nil, err
+	}
+
+	defer mod.Close(ctx)
+
+	r, err := mod.ExportedFunction(wasmAllocName).Call(ctx, uint64(len(body)))
+	if err != nil {
+		return nil, err
+	}
+
+	offset := uint32(r[0])
+	if !mod.Memory().Write(offset, body) {
+		return nil, errors.New("input out of memory range")
+	}
+
+	r, err = mod.ExportedFunction(wasmTransformName).Call(ctx, uint64(offset), uint64(len(body)))
+	if err != nil {
+		return nil, err
+	}
+
+	out, ok := mod.Memory().Read(uint32(r[0]>>32), uint32(r[0]))
+	if !ok {
+		return nil, errors.New("output out of memory range")
+	}
+
+	// the memory is released when the module is closed
+	return append([]byte(nil), out...), nil
+}
+
+func (*wasmFilter) Request(filters.FilterContext) {}
+
+func (f *wasmFilter) Response(ctx filters.FilterContext) {
+	rsp := ctx.Response()
+	if rsp.Body == nil || rsp.ContentLength > f.maxBodySize {
+		return
+	}
+
+	if e := rsp.Header.Get("Content-Encoding"); e != "" && e != "identity" {
+		return
+	}
+
+	body, original, ok := net.BufferBody(rsp.Body, f.maxBodySize)
+	if !ok {
+		rsp.Body = original
+		return
+	}
+
+	out, err := f.transform(body)
+	if err != nil {
+		ctx.Logger().Errorf("%s: failed to transform response body: %v", filters.WasmResponseName, err)
+		out = body
+	}
+
+	rsp.Body = io.NopCloser(bytes.NewReader(out))
+	rsp.ContentLength = int64(len(out))
+	rsp.Header.Set("Content-Length", strconv.Itoa(len(out)))
+}
@@ -0,0 +1,281 @@
+package builtin
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/zalando/skipper/filters"
+	"github.com/zalando/skipper/filters/filtertest"
+)
+
+// uppercase transforms the input in place:
+//
+//	(func (param $p i32) (param $n i32) (result i64)
+//	  (local $i i32) (local $c i32)
+//	  (block (loop
+//	    (br_if 1 (i32.ge_u (local.get $i) (local.get $n)))
+//	    (local.set $c (i32.load8_u (i32.add (local.get $p) (local.get $i))))
+//	    (if (i32.lt_u (i32.sub (local.get $c) (i32.const 97)) (i32.const 26))
+//	      (then (i32.store8 (i32.add (local.get $p) (local.get $i)) (i32.sub (local.get $c) (i32.const 32)))))
+//	    (local.set $i (i32.add (local.get $i) (i32.const 1)))
+//	    (br 0)))
+//	  (i64.or (i64.shl (i64.extend_i32_u (local.get $p)) (i64.const 32)) (i64.extend_i32_u (local.get $n))))
+var wasmUppercase = []byte{
+	0x01, 0x02, 0x7f, // two i32 locals
+	0x02, 0x40, 0x03, 0x40,
+	0x20, 0x02, 0x20, 0x01, 0x4f, 0x0d, 0x01,
+	0x20, 0x00, 0x20, 0x02, 0x6a, 0x2d, 0x00, 0x00, 0x21, 0x03,
+	0x20, 0x03, 0x41, 0xe1, 0x00, 0x6b, 0x41, 0x1a, 0x49, 0x04, 0x40,
+	0x20, 0x00, 0x20, 0x02, 0x6a, 0x20, 0x03, 0x41, 0x20, 0x6b, 0x3a, 0x00, 0x00,
+	0x0b,
+	0x20, 0x02, 0x41, 0x01, 0x6a, 0x21, 0x02,
+	0x0c, 0x00, 0x0b, 0x0b,
+	0x20, 0x00, 0xad, 0x42, 0x20, 0x86, 0x20, 0x01, 0xad, 0x84,
+	0x0b,
+}
+
+// infinite never returns: (func (param i32) (param i32) (result i64) (loop (br 0)) unreachable)
+var wasmInfinite = []byte{0x00, 0x03, 0x40, 0x0c, 0x00, 0x0b, 0x00, 0x0b}
+
+// wasmAlloc returns offset 1024: (func (param i32) (result i32) (i32.const 1024))
+var wasmAlloc = []byte{0x00, 0x41, 0x80, 0x08, 0x0b}
+
+func wasmSection(id byte, content ...byte) []byte {
+	return append([]byte{id, byte(len(content))}, content...)
+}
+
+func wasmBody(code []byte) []byte {
+	return append([]byte{byte(len(code))}, code...)
+}
+
+// testWasmModule assembles a module exporting memory, alloc and transform.
+func testWasmModule(memoryPages byte, transform []byte, exportTransform bool) []byte {
+	m := []byte{0x00, 0x61, 0x73, 0x6d, 0x01, 0x00, 0x00, 0x00}
+	m = append(m, wasmSection(0x01, 0x02,
+		0x60, 0x01, 0x7f, 0x01, 0x7f,
+		0x60, 0x02, 0x7f, 0x7f, 0x01, 0x7e)...)
+	m = append(m, wasmSection(0x03, 0x02, 0x00, 0x01)...)
+	m = append(m, wasmSection(0x05, 0x01, 0x00, memoryPages)...)
+
+	exports := []byte{0x02,
+		0x06, 'm', 'e', 'm', 'o', 'r', 'y', 0x02, 0x00,
+		0x05, 'a', 'l', 'l', 'o', 'c', 0x00, 0x00,
+	}
+	if exportTransform {
+		exports[0]++
+		exports = append(exports, 0x09, 't', 'r', 'a', 'n', 's', 'f', 'o', 'r', 'm', 0x00, 0x01)
+	}
+
+	m = append(m, wasmSection(0x07, exports...)...)
+
+	code := []byte{0x02}
+	code = append(code, wasmBody(wasmAlloc)...)
+	code = append(code, wasmBody(transform)...)
+	return append(m, wasmSection(0x0a, code...)...)
+}
+
+func writeWasmModule(t *testing.T, module []byte) string {
+	t.Helper()
+
+	name := filepath.Join(t.TempDir(), "transform.wasm")
+	if err := os.WriteFile(name, module, 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	return name
+}
+
+func TestWasmResponseCreate(t *testing.T) {
+	valid := writeWasmModule(t, testWasmModule(1, wasmUppercase, true))
+	for _, tt := range []struct {
+		msg  string
+		args []interface{}
+		err  bool
+	}{{
+		msg: "no args",
+		err: true,
+	}, {
+		msg:  "missing file",
+		args: []interface{}{filepath.Join(t.TempDir(), "missing.wasm")},
+		err:  true,
+	}, {
+		msg:  "invalid module",
+		args: []interface{}{writeWasmModule(t, []byte("foo"))},
+		err:  true,
+	}, {
+		msg:  "missing transform",
+		args: []interface{}{writeWasmModule(t, testWasmModule(1, wasmUppercase, false))},
+		err:  true,
+	}, {
+		msg:  "memory above the limit",
+		args: []interface{}{writeWasmModule(t, memoryAboveLimit())},
+		err:  true,
+	}, {
+		msg:  "invalid timeout",
+		args: []interface{}{valid, "foo"},
+		err:  true,
+	}, {
+		msg:  "invalid max body size",
+		args: []interface{}{valid, "10ms", "foo"},
+		err:  true,
+	}, {
+		msg:  "valid",
+		args: []interface{}{valid},
+	}, {
+		msg:  "valid with timeout and max body size",
+		args: []interface{}{valid, "10ms", "1KB"},
+	}} {
+		t.Run(tt.msg, func(t *testing.T) {
+			spec := NewWasmResponse()
+			defer spec.Close()
+
+			_, err := spec.CreateFilter(tt.args)
+			if tt.err {
+				if err == nil {
+					t.Fatal("expected error")
+				}
+
+				return
+			}
+
+			if err != nil {
+				t.Fatal(err)
+			}
+		})
+	}
+}
+
+// memoryAboveLimit returns a module declaring more memory pages than allowed.
+func memoryAboveLimit() []byte {
+	m := testWasmModule(1, wasmUppercase, true)
+
+	// replace the one byte page count of the memory section with the two
+	// byte LEB128 encoding of 300
+	i := bytes.Index(m, []byte{0x05, 0x03, 0x01, 0x00, 0x01})
+	return append(append(m[:i:i], 0x05, 0x04, 0x01, 0x00, 0xac, 0x02), m[i+5:]...)
+}
+
+func serveWasmResponse(t *testing.T, f filters.Filter, body string, header http.Header) *http.Response {
+	t.Helper()
+
+	rsp := &http.Response{
+		StatusCode:    http.StatusOK,
+		Header:        header,
+		Body:          io.NopCloser(strings.NewReader(body)),
+		ContentLength: int64(len(body)),
+	}
+
+	if rsp.Header == nil {
+		rsp.Header = make(http.Header)
+	}
+
+	f.Response(&filtertest.Context{FResponse: rsp})
+	return rsp
+}
+
+func readWasmResponse(t *testing.T, rsp *http.Response) string {
+	t.Helper()
+
+	b, err := io.ReadAll(rsp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return string(b)
+}
+
+func TestWasmResponseUppercase(t *testing.T) {
+	spec := NewWasmResponse()
+	defer spec.Close()
+
+	f, err := spec.CreateFilter([]interface{}{writeWasmModule(t, testWasmModule(1, wasmUppercase, true)), "1s", "16B"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rsp := serveWasmResponse(t, f, "Hello, world!", nil)
+	if got := readWasmResponse(t, rsp); got != "HELLO, WORLD!" {
+		t.Errorf("unexpected body: %q", got)
+	}
+
+	if rsp.ContentLength != 13 || rsp.Header.Get("Content-Length") != "13" {
+		t.Errorf("unexpected content length: %d, %s", rsp.ContentLength, rsp.Header.Get("Content-Length"))
+	}
+
+	// instances do not share state
+	if got := readWasmResponse(t, serveWasmResponse(t, f, "foo", nil)); got != "FOO" {
+		t.Errorf("unexpected body: %q", got)
+	}
+
+	if got := readWasmResponse(t, serveWasmResponse(t, f, "Hello, world! Hello!", nil)); got != "Hello, world! Hello!" {
+		t.Errorf("body above the limit was transformed: %q", got)
+	}
+
+	rsp = serveWasmResponse(t, f, "foo", http.Header{"Content-Encoding": []string{"gzip"}})
+	if got := readWasmResponse(t, rsp); got != "foo" {
+		t.Errorf("encoded body was transformed: %q", got)
+	}
+}
+
+func TestWasmResponseTimeout(t *testing.T) {
+	spec := NewWasmResponse()
+	defer spec.Close()
+
+	f, err := spec.CreateFilter([]interface{}{writeWasmModule(t, testWasmModule(1, wasmInfinite, true)), "20ms"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	start := time.Now()
+	rsp := serveWasmResponse(t, f, "foo", nil)
+	if d := time.Since(start); d > time.Second {
+		t.Errorf("execution was not aborted in time: %v", d)
+	}
+
+	if got := readWasmResponse(t, rsp); got != "foo" {
+		t.Errorf("expected the original body, got: %q", got)
+	}
+}
+
+func TestWasmResponseCompiledOnce(t *testing.T) {
+	spec := NewWasmResponse()
+	defer spec.Close()
+
+	path := writeWasmModule(t, testWasmModule(1, wasmUppercase, true))
+	create := func() *wasmFilter {
+		t.Helper()
+		f, err := spec.CreateFilter([]interface{}{path})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		return f.(*wasmFilter)
+	}
+
+	f1, f2 := create(), create()
+	if f1.module != f2.module {
+		t.Error("the module was compiled again without a change of the file")
+	}
+
+	if err := os.Chtimes(path, time.Now(), time.Now().Add(time.Hour)); err != nil {
+		t.Fatal(err)
+	}
+
+	f3 := create()
+	if f3.module == f1.module {
+		t.Error("the module was not compiled again after the file changed")
+	}
+
+	// the filters of the older routing tables keep working
+	for _, f := range []*wasmFilter{f1, f3} {
+		if got := readWasmResponse(t, serveWasmResponse(t, f, "foo", nil)); got != "FOO" {
+			t.Errorf("unexpected body: %q", got)
+		}
+	}
+}
@@ -0,0 +1,300 @@
+package builtin
+
+import (
+	"container/list"
+	"context"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/zalando/skipper/filters"
+	"github.com/zalando/skipper/net"
+	"golang.org/x/net/http/httpguts"
+)
+
+const (
+	defaultDedupStoreSize = 100000
+	webhookDedupKey       = "filter::webhookDedup::key"
+)
+
+// DedupStore stores the keys of the deduplicated requests.
+type DedupStore interface {
+
+	// Add stores the key for the duration of the ttl, and returns true
+	// when the key was not stored yet, or it was already expired.
+	Add(ctx context.Context, key string, ttl time.Duration) (bool, error)
+
+	// Remove deletes the key, so that the next request with the same key
+	// is not considered a duplicate.
+	Remove(ctx context.Context, key string) error
+}
+
+// WebhookDedupOptions is used to create the webhookDedup filter
+// specification.
+type WebhookDedupOptions struct {
+
+	// Store is used to track the delivery IDs. When not set, an in-memory
+	// store is used, with the capacity of 100000 keys.
+	Store DedupStore
+}
+
+type dedupEntry struct {
+	key     string
+	expires time.Time
+}
+
+type memoryDedupStore struct {
+	mu      sync.Mutex
+	maxSize int
+	entries map[string]*list.Element
+	order   *list.List
+	now     func() time.Time
+}
+
+type redisDedupStore struct {
+	ring   *net.RedisRingClient
+	script *net.RedisScript
+}
+
+type webhookDedupSpec struct {
+	store DedupStore
+}
+
+type webhookDedupFilter struct {
+	header string
+	window time.Duration
+	status int
+	scope  string
+	store  DedupStore
+}
+
+// stores the key only when it doesn't exist yet, and returns 1 when it
+// was stored
+const dedupScript = `
+if redis.call("SET", KEYS[1], "1", "NX", "PX", ARGV[1]) then
+	return 1
+end
+
+return 0
+`
+
+// NewMemoryDedupStore creates an in-memory DedupStore, that keeps at most
+// maxSize keys. When the store is full, the oldest keys are evicted even
+// if they are not expired yet.
+func NewMemoryDedupStore(maxSize int) DedupStore {
+	if maxSize <= 0 {
+		maxSize = defaultDedupStoreSize
+	}
+
+	return &memoryDedupStore{
+		maxSize: maxSize,
+		entries: make(map[string]*list.Element),
+		order:   list.New(),
+		now:     time.Now,
+	}
+}
+
+func (s *memoryDedupStore) remove(e *list.Element) {
+	s.order.Remove(e)
+	delete(s.entries, e.Value.(*dedupEntry).key)
+}
+
+func (s *memoryDedupStore) Add(_ context.Context, key string, ttl time.Duration) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	if e, ok := s.entries[key]; ok {
+		if now.Before(e.Value.(*dedupEntry).expires) {
+			return false, nil
+		}
+
+		s.remove(e)
+	}
+
+	for e := s.order.Front(); e != nil && !now.Before(e.Value.(*dedupEntry).expires); e = s.order.Front() {
+		s.remove(e)
+	}
+
+	for s.order.Len() >= s.maxSize {
+		s.remove(s.order.Front())
+	}
+
+	s.entries[key] = s.order.PushBack(&dedupEntry{key: key, expires: now.Add(ttl)})
+	return true, nil
+}
+
+func (s *memoryDedupStore) Remove(_ context.Context, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if e, ok := s.entries[key]; ok {
+		s.remove(e)
+	}
+
+	return nil
+}
+
+// NewRedisDedupStore creates a DedupStore, that stores the keys in the
+// Redis ring, shared by the skipper instances.
+func NewRedisDedupStore(ring *net.RedisRingClient) DedupStore {
+	return &redisDedupStore{ring: ring, script: ring.NewScript(dedupScript)}
+}
+
+func (s *redisDedupStore) Add(ctx context.Context, key string, ttl time.Duration) (bool, error) {
+	r, err := s.ring.RunScript(ctx, s.script, []string{"webhookdedup." + key}, ttl.Milliseconds())
+	if err != nil {
+		return false, err
+	}
+
+	added, ok := r.(int64)
+	if !ok {
+		return false, fmt.Errorf("unexpected result of the dedup script: %v", r)
+	}
+
+	return added == 1, nil
+}
+
+func (s *redisDedupStore) Remove(ctx context.Context, key string) error {
+	_, err := s.ring.Del(ctx, "webhookdedup."+key)
+	return err
+}
+
+// NewWebhookDedup creates a filter specification for the webhookDedup()
+// filter, using an in-memory store.
+func NewWebhookDedup() filters.Spec {
+	return NewWebhookDedupWithOptions(WebhookDedupOptions{})
+}
+
+// NewWebhookDedupWithOptions creates a filter specification whose
+// instances drop the duplicate deliveries of webhooks. The delivery ID is
+// taken from the configured request header, and the requests with a
+// delivery ID already seen within the configured window are responded
+// with 200 OK, or with the optional status code, without forwarding them
+// to the backend. Requests without the header pass through.
+//
+// The delivery ID is reserved when the request is forwarded, and it is
+// released again, when the backend fails to process the delivery, i.e.
+// it responds with a status other than 2xx, or it cannot be reached, so
+// that the retry of the sender is forwarded, too.
+//
+// The delivery IDs are tracked per idempotency scope, which defaults to
+// the name of the header, and can be set explicitly to share the
+// deduplication between routes or to separate it.
+//
+// Example:
+//
+//	webhookDedup("X-Delivery-Id", "24h")
+//	webhookDedup("X-Delivery-Id", "24h", 202, "github")
+func NewWebhookDedupWithOptions(o WebhookDedupOptions) filters.Spec {
+	store := o.Store
+	if store == nil {
+		store = NewMemoryDedupStore(defaultDedupStoreSize)
+	}
+
+	return &webhookDedupSpec{store: store}
+}
+
+func (*webhookDedupSpec) Name() string { return filters.WebhookDedupName }
+
+func (s *webhookDedupSpec) CreateFilter(args []interface{}) (filters.Filter, error) {
+	if len(args) < 2 || len(args) > 4 {
+		return nil, filters.ErrInvalidFilterParameters
+	}
+
+	header, ok := args[0].(string)
+	if !ok || !httpguts.ValidHeaderFieldName(header) {
+		return nil, filters.ErrInvalidFilterParameters
+	}
+
+	var window time.Duration
+	switch v := args[1].(type) {
+	case string:
+		d, err := time.ParseDuration(v)
+		if err != nil {
+			return nil, err
+		}
+
+		window = d
+	case time.Duration:
+		window = v
+	default:
+		return nil, filters.ErrInvalidFilterParameters
+	}
+
+	if window <= 0 {
+		return nil, filters.ErrInvalidFilterParameters
+	}
+
+	f := &webhookDedupFilter{
+		header: header,
+		window: window,
+		status: http.StatusOK,
+		scope:  http.CanonicalHeaderKey(header),
+		store:  s.store,
+	}
+
+	if len(args) > 2 {
+		status, ok := args[2].(float64)
+		if !ok || status < 100 || status > 599 {
+			return nil, filters.ErrInvalidFilterParameters
+		}
+
+		f.status = int(status)
+	}
+
+	if len(args) > 3 {
+		scope, ok := args[3].(string)
+		if !ok || scope == "" {
+			return nil, filters.ErrInvalidFilterParameters
+		}
+
+		f.scope = scope
+	}
+
+	return f, nil
+}
+
+func (f *webhookDedupFilter) Request(ctx filters.FilterContext) {
+	id := ctx.Request().Header.Get(f.header)
+	if id == "" {
+		return
+	}
+
+	added, err := f.store.Add(ctx.Request().Context(), f.scope+"."+id, f.window)
+	if err != nil {
+		ctx.Logger().Errorf("%s: failed to store the delivery ID, forwarding the request: %v", filters.WebhookDedupName, err)
+		return
+	}
+
+	if added {
+		ctx.StateBag()[webhookDedupKey] = f.scope + "." + id
+		return
+	}
+
+	ctx.Logger().Infof("%s: dropping duplicate delivery %s in scope %s", filters.WebhookDedupName, id, f.scope)
+	ctx.Serve(&http.Response{StatusCode: f.status})
+}
+
+func (f *webhookDedupFilter) Response(ctx filters.FilterContext) {
+	key, ok := ctx.StateBag()[webhookDedupKey].(string)
+	if !ok {
+		return
+	}
+
+	delete(ctx.StateBag(), webhookDedupKey)
+	if rsp := ctx.Response(); rsp != nil && rsp.StatusCode >= 200 && rsp.StatusCode < 300 {
+		return
+	}
+
+	// the request context may be already canceled when the client went
+	// away, but the key needs to be released anyway
+	if err := f.store.Remove(context.Background(), key); err != nil {
+		ctx.Logger().Errorf("%s: failed to release the delivery ID of a failed delivery: %v", filters.WebhookDedupName, err)
+	}
+}
+
+// HandleErrorResponse is to opt-in for filters to get called
+// Response(ctx) in case of errors via proxy. It has to return true to opt-in.
+func (*webhookDedupFilter) HandleErrorResponse() bool { return true }
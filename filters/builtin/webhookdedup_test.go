@@ -0,0 +1,294 @@
+package builtin
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/zalando/skipper/eskip"
+	"github.com/zalando/skipper/filters"
+	"github.com/zalando/skipper/filters/filtertest"
+	"github.com/zalando/skipper/proxy/proxytest"
+)
+
+func TestWebhookDedupCreate(t *testing.T) {
+	for _, tt := range []struct {
+		msg  string
+		args []interface{}
+		err  bool
+	}{{
+		msg: "no args",
+		err: true,
+	}, {
+		msg:  "missing window",
+		args: []interface{}{"X-Delivery-Id"},
+		err:  true,
+	}, {
+		msg:  "invalid header",
+		args: []interface{}{"X Delivery", "24h"},
+		err:  true,
+	}, {
+		msg:  "invalid window",
+		args: []interface{}{"X-Delivery-Id", "foo"},
+		err:  true,
+	}, {
+		msg:  "negative window",
+		args: []interface{}{"X-Delivery-Id", "-1h"},
+		err:  true,
+	}, {
+		msg:  "invalid status",
+		args: []interface{}{"X-Delivery-Id", "24h", 1000.0},
+		err:  true,
+	}, {
+		msg:  "invalid scope",
+		args: []interface{}{"X-Delivery-Id", "24h", 200.0, 42.0},
+		err:  true,
+	}, {
+		msg:  "too many args",
+		args: []interface{}{"X-Delivery-Id", "24h", 200.0, "github", "foo"},
+		err:  true,
+	}, {
+		msg:  "header and window",
+		args: []interface{}{"X-Delivery-Id", "24h"},
+	}, {
+		msg:  "with status",
+		args: []interface{}{"X-Delivery-Id", "24h", 202.0},
+	}, {
+		msg:  "with scope",
+		args: []interface{}{"X-Delivery-Id", "24h", 202.0, "github"},
+	}} {
+		t.Run(tt.msg, func(t *testing.T) {
+			_, err := NewWebhookDedup().CreateFilter(tt.args)
+			if tt.err && err == nil {
+				t.Error("expected error")
+			} else if !tt.err && err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+		})
+	}
+}
+
+type testClock struct{ now time.Time }
+
+func (c *testClock) Now() time.Time { return c.now }
+
+func newTestDedupStore(maxSize int) (*memoryDedupStore, *testClock) {
+	c := &testClock{now: time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)}
+	s := NewMemoryDedupStore(maxSize).(*memoryDedupStore)
+	s.now = c.Now
+	return s, c
+}
+
+func dedupRequest(t *testing.T, f filters.Filter, id string) *filtertest.Context {
+	t.Helper()
+
+	req, err := http.NewRequest("POST", "https://www.example.org/webhook", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if id != "" {
+		req.Header.Set("X-Delivery-Id", id)
+	}
+
+	ctx := &filtertest.Context{FRequest: req, FStateBag: make(map[string]interface{})}
+	f.Request(ctx)
+	return ctx
+}
+
+func TestWebhookDedup(t *testing.T) {
+	store, clock := newTestDedupStore(10)
+	spec := NewWebhookDedupWithOptions(WebhookDedupOptions{Store: store})
+
+	f, err := spec.CreateFilter([]interface{}{"X-Delivery-Id", "24h"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("first delivery", func(t *testing.T) {
+		if ctx := dedupRequest(t, f, "foo"); ctx.FServed {
+			t.Error("first delivery served")
+		}
+	})
+
+	t.Run("duplicate within window", func(t *testing.T) {
+		clock.now = clock.now.Add(time.Hour)
+		ctx := dedupRequest(t, f, "foo")
+		if !ctx.FServed || ctx.FResponse.StatusCode != http.StatusOK {
+			t.Error("duplicate delivery not served with 200")
+		}
+	})
+
+	t.Run("other delivery", func(t *testing.T) {
+		if ctx := dedupRequest(t, f, "bar"); ctx.FServed {
+			t.Error("other delivery served")
+		}
+	})
+
+	t.Run("no delivery id", func(t *testing.T) {
+		if ctx := dedupRequest(t, f, ""); ctx.FServed {
+			t.Error("request without delivery ID served")
+		}
+	})
+
+	t.Run("expired", func(t *testing.T) {
+		clock.now = clock.now.Add(24 * time.Hour)
+		if ctx := dedupRequest(t, f, "foo"); ctx.FServed {
+			t.Error("expired delivery served")
+		}
+
+		if ctx := dedupRequest(t, f, "foo"); !ctx.FServed {
+			t.Error("duplicate delivery after expiry not served")
+		}
+	})
+
+	t.Run("custom status and scope", func(t *testing.T) {
+		f, err := spec.CreateFilter([]interface{}{"X-Delivery-Id", "24h", 202.0, "github"})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if ctx := dedupRequest(t, f, "foo"); ctx.FServed {
+			t.Error("first delivery in scope served")
+		}
+
+		ctx := dedupRequest(t, f, "foo")
+		if !ctx.FServed || ctx.FResponse.StatusCode != http.StatusAccepted {
+			t.Error("duplicate delivery not served with 202")
+		}
+	})
+}
+
+func TestWebhookDedupReleasesFailedDelivery(t *testing.T) {
+	store, _ := newTestDedupStore(10)
+	spec := NewWebhookDedupWithOptions(WebhookDedupOptions{Store: store})
+
+	f, err := spec.CreateFilter([]interface{}{"X-Delivery-Id", "24h"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tt := range []struct {
+		msg      string
+		response *http.Response
+		release  bool
+	}{{
+		msg:      "processed",
+		response: &http.Response{StatusCode: http.StatusNoContent},
+	}, {
+		msg:      "backend error",
+		response: &http.Response{StatusCode: http.StatusServiceUnavailable},
+		release:  true,
+	}, {
+		msg:      "rejected",
+		response: &http.Response{StatusCode: http.StatusBadRequest},
+		release:  true,
+	}, {
+		msg:     "no response",
+		release: true,
+	}} {
+		t.Run(tt.msg, func(t *testing.T) {
+			ctx := dedupRequest(t, f, tt.msg)
+			if ctx.FServed {
+				t.Fatal("first delivery served")
+			}
+
+			ctx.FResponse = tt.response
+			f.Response(ctx)
+
+			if ctx := dedupRequest(t, f, tt.msg); ctx.FServed == tt.release {
+				t.Errorf("unexpected handling of the retry, forwarded: %v", !ctx.FServed)
+			}
+		})
+	}
+}
+
+func TestWebhookDedupRetryAfterBackendFailure(t *testing.T) {
+	var requests int32
+	backend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&requests, 1) == 1 {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer backend.Close()
+
+	p := proxytest.New(MakeRegistry(), eskip.MustParse(`* -> webhookDedup("X-Delivery-Id", "24h") -> "`+backend.URL+`"`)...)
+	defer p.Close()
+
+	deliver := func() int {
+		t.Helper()
+		req, err := http.NewRequest("POST", p.URL, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		req.Header.Set("X-Delivery-Id", "foo")
+		rsp, err := p.Client().Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		rsp.Body.Close()
+		return rsp.StatusCode
+	}
+
+	for i, expected := range []int{http.StatusInternalServerError, http.StatusNoContent, http.StatusOK} {
+		if status := deliver(); status != expected {
+			t.Errorf("unexpected status of delivery %d: %d, expected: %d", i, status, expected)
+		}
+	}
+
+	if n := atomic.LoadInt32(&requests); n != 2 {
+		t.Errorf("unexpected number of forwarded deliveries: %d", n)
+	}
+}
+
+func TestMemoryDedupStoreBounded(t *testing.T) {
+	store, _ := newTestDedupStore(2)
+	for _, key := range []string{"foo", "bar", "baz"} {
+		if added, _ := store.Add(context.Background(), key, time.Hour); !added {
+			t.Fatalf("failed to add %s", key)
+		}
+	}
+
+	if store.order.Len() != 2 || len(store.entries) != 2 {
+		t.Errorf("store not bounded: %d", store.order.Len())
+	}
+
+	if added, _ := store.Add(context.Background(), "foo", time.Hour); !added {
+		t.Error("oldest key not evicted")
+	}
+
+	if added, _ := store.Add(context.Background(), "baz", time.Hour); added {
+		t.Error("newest key evicted")
+	}
+}
+
+type failingDedupStore struct{}
+
+func (failingDedupStore) Add(context.Context, string, time.Duration) (bool, error) {
+	return false, errors.New("store failed")
+}
+
+func (failingDedupStore) Remove(context.Context, string) error {
+	return errors.New("store failed")
+}
+
+func TestWebhookDedupStoreFailure(t *testing.T) {
+	spec := NewWebhookDedupWithOptions(WebhookDedupOptions{Store: failingDedupStore{}})
+	f, err := spec.CreateFilter([]interface{}{"X-Delivery-Id", "24h"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if ctx := dedupRequest(t, f, "foo"); ctx.FServed {
+		t.Error("request served on store failure")
+	}
+}
@@ -0,0 +1,220 @@
+package builtin
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"io"
+	"mime"
+	"strings"
+
+	"github.com/zalando/skipper/filters"
+)
+
+var errNoXMLRoot = errors.New("missing XML root element")
+
+type xmlToJSONSpec struct{}
+
+type xmlToJSONFilter struct {
+	maxBytes int64
+}
+
+type xmlElement struct {
+	name     string
+	attrs    []xml.Attr
+	children []*xmlElement
+	text     strings.Builder
+}
+
+// NewXMLToJSON creates a filter specification whose instances convert the
+// XML request and response bodies to JSON, and set their content type to
+// application/json.
+//
+// The root element becomes an object with a single key, the name of the
+// element. An element without attributes and child elements becomes a
+// string with its text. Otherwise, it becomes an object, where the
+// attributes have the keys prefixed with @, the child elements have the keys
+// of their names, and the text, when not empty, has the key #text. The
+// repeated child elements with the same name become arrays. The namespaces
+// are dropped from the names, and the values are always strings:
+//
+//	<order id="42"><item>a</item><item>b</item></order>
+//
+// becomes:
+//
+//	{"order":{"@id":"42","item":["a","b"]}}
+//
+// Only the uncompressed XML bodies are converted, that are not larger than
+// the optional maximum size, by default 2MB. Larger, or invalid, bodies are
+// streamed unchanged.
+//
+// Example:
+//
+//	xmlToJSON()
+//	xmlToJSON("8MB")
+func NewXMLToJSON() filters.Spec { return xmlToJSONSpec{} }
+
+func (xmlToJSONSpec) Name() string { return filters.XMLToJSONName }
+
+func (xmlToJSONSpec) CreateFilter(args []interface{}) (filters.Filter, error) {
+	if len(args) > 1 {
+		return nil, filters.ErrInvalidFilterParameters
+	}
+
+	maxBytes, ok := bodyTransformMaxBytesArg(args)
+	if !ok {
+		return nil, filters.ErrInvalidFilterParameters
+	}
+
+	return &xmlToJSONFilter{maxBytes: maxBytes}, nil
+}
+
+func isXML(contentType string) bool {
+	mt, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+
+	return mt == "application/xml" || mt == "text/xml" || strings.HasSuffix(mt, "+xml")
+}
+
+func parseXMLDocument(doc []byte) (*xmlElement, error) {
+	dec := xml.NewDecoder(bytes.NewReader(doc))
+
+	var (
+		root  *xmlElement
+		stack []*xmlElement
+	)
+
+	for {
+		t, err := dec.Token()
+		if err == io.EOF {
+			if root == nil {
+				return nil, errNoXMLRoot
+			}
+
+			return root, nil
+		}
+
+		if err != nil {
+			return nil, err
+		}
+
+		switch v := t.(type) {
+		case xml.StartElement:
+			e := &xmlElement{name: v.Name.Local}
+			for _, a := range v.Attr {
+				if a.Name.Space != "xmlns" && a.Name.Local != "xmlns" {
+					e.attrs = append(e.attrs, a)
+				}
+			}
+
+			if len(stack) > 0 {
+				parent := stack[len(stack)-1]
+				parent.children = append(parent.children, e)
+			} else if root == nil {
+				root = e
+			}
+
+			stack = append(stack, e)
+		case xml.EndElement:
+			stack = stack[:len(stack)-1]
+		case xml.CharData:
+			if len(stack) > 0 {
+				stack[len(stack)-1].text.Write(v)
+			}
+		}
+	}
+}
+
+func (e *xmlElement) writeJSON(out *bytes.Buffer) {
+	text := strings.TrimSpace(e.text.String())
+	if len(e.attrs) == 0 && len(e.children) == 0 {
+		out.Write(encodeJSONString(text))
+		return
+	}
+
+	out.WriteByte('{')
+	first := true
+	field := func(key string) {
+		if !first {
+			out.WriteByte(',')
+		}
+
+		first = false
+		out.Write(encodeJSONString(key))
+		out.WriteByte(':')
+	}
+
+	for _, a := range e.attrs {
+		field("@" + a.Name.Local)
+		out.Write(encodeJSONString(a.Value))
+	}
+
+	// the children grouped by name, in the order of their first occurrence
+	var names []string
+	groups := make(map[string][]*xmlElement)
+	for _, c := range e.children {
+		if _, ok := groups[c.name]; !ok {
+			names = append(names, c.name)
+		}
+
+		groups[c.name] = append(groups[c.name], c)
+	}
+
+	for _, name := range names {
+		field(name)
+		g := groups[name]
+		if len(g) == 1 {
+			g[0].writeJSON(out)
+			continue
+		}
+
+		out.WriteByte('[')
+		for i, c := range g {
+			if i > 0 {
+				out.WriteByte(',')
+			}
+
+			c.writeJSON(out)
+		}
+
+		out.WriteByte(']')
+	}
+
+	if text != "" {
+		field("#text")
+		out.Write(encodeJSONString(text))
+	}
+
+	out.WriteByte('}')
+}
+
+func xmlToJSON(doc []byte) ([]byte, error) {
+	root, err := parseXMLDocument(doc)
+	if err != nil {
+		return nil, err
+	}
+
+	var out bytes.Buffer
+	out.WriteByte('{')
+	out.Write(encodeJSONString(root.name))
+	out.WriteByte(':')
+	root.writeJSON(&out)
+	out.WriteByte('}')
+	return out.Bytes(), nil
+}
+
+func (f *xmlToJSONFilter) Request(ctx filters.FilterContext) {
+	r := ctx.Request()
+	if isXML(r.Header.Get("Content-Type")) && transformRequestBody(r, f.maxBytes, xmlToJSON) {
+		r.Header.Set("Content-Type", "application/json")
+	}
+}
+
+func (f *xmlToJSONFilter) Response(ctx filters.FilterContext) {
+	rsp := ctx.Response()
+	if isXML(rsp.Header.Get("Content-Type")) && transformResponseBody(rsp, f.maxBytes, xmlToJSON) {
+		rsp.Header.Set("Content-Type", "application/json")
+	}
+}
@@ -0,0 +1,167 @@
+package builtin
+
+import (
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/zalando/skipper/filters/filtertest"
+)
+
+func TestXMLToJSONArgs(t *testing.T) {
+	for _, tt := range []struct {
+		msg  string
+		args []interface{}
+		fail bool
+	}{
+		{msg: "no args"},
+		{msg: "size", args: []interface{}{"64KB"}},
+		{msg: "invalid size", args: []interface{}{"1XB"}, fail: true},
+		{msg: "negative size", args: []interface{}{-1}, fail: true},
+		{msg: "too many", args: []interface{}{"1MB", "2MB"}, fail: true},
+	} {
+		t.Run(tt.msg, func(t *testing.T) {
+			_, err := NewXMLToJSON().CreateFilter(tt.args)
+			if tt.fail != (err != nil) {
+				t.Errorf("expected failure: %v, got: %v", tt.fail, err)
+			}
+		})
+	}
+}
+
+func TestXMLToJSON(t *testing.T) {
+	for _, tt := range []struct {
+		msg      string
+		doc      string
+		expected string
+	}{{
+		msg:      "text only",
+		doc:      `<name>Jane</name>`,
+		expected: `{"name":"Jane"}`,
+	}, {
+		msg:      "empty",
+		doc:      `<?xml version="1.0"?><empty/>`,
+		expected: `{"empty":""}`,
+	}, {
+		msg:      "attributes and repeated children",
+		doc:      `<order id="42"><item>a</item><item>b</item></order>`,
+		expected: `{"order":{"@id":"42","item":["a","b"]}}`,
+	}, {
+		msg: "nested, with whitespace",
+		doc: `<?xml version="1.0" encoding="UTF-8"?>
+<order id="42" status="open">
+  <customer><name>Jane &amp; John</name><city>Berlin</city></customer>
+  <item sku="a-1">Book</item>
+  <note/>
+  <item sku="b-2">Pen</item>
+</order>`,
+		expected: `{"order":{"@id":"42","@status":"open","customer":{"name":"Jane & John","city":"Berlin"},` +
+			`"item":[{"@sku":"a-1","#text":"Book"},{"@sku":"b-2","#text":"Pen"}],"note":""}}`,
+	}, {
+		msg:      "namespaces",
+		doc:      `<ns:order xmlns:ns="urn:orders" xmlns="urn:default" ns:id="42"><ns:item>a</ns:item></ns:order>`,
+		expected: `{"order":{"@id":"42","item":"a"}}`,
+	}, {
+		msg:      "mixed content",
+		doc:      `<p>Hello <b>world</b></p>`,
+		expected: `{"p":{"b":"world","#text":"Hello"}}`,
+	}} {
+		t.Run(tt.msg, func(t *testing.T) {
+			f, err := NewXMLToJSON().CreateFilter(nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			rsp := &http.Response{
+				Header:        http.Header{"Content-Type": []string{"application/xml; charset=utf-8"}},
+				ContentLength: int64(len(tt.doc)),
+				Body:          io.NopCloser(strings.NewReader(tt.doc)),
+			}
+
+			f.Response(&filtertest.Context{FResponse: rsp})
+			b, err := io.ReadAll(rsp.Body)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if string(b) != tt.expected {
+				t.Errorf("expected %s, got %s", tt.expected, b)
+			}
+
+			if ct := rsp.Header.Get("Content-Type"); ct != "application/json" {
+				t.Errorf("invalid content type: %s", ct)
+			}
+
+			if rsp.ContentLength != int64(len(b)) {
+				t.Errorf("invalid content length: %d", rsp.ContentLength)
+			}
+		})
+	}
+}
+
+func TestXMLToJSONRequest(t *testing.T) {
+	f, err := NewXMLToJSON().CreateFilter(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	r, err := http.NewRequest("POST", "https://www.example.org/orders", strings.NewReader(`<order><item>a</item></order>`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	r.Header.Set("Content-Type", "text/xml")
+	f.Request(&filtertest.Context{FRequest: r})
+
+	b, err := io.ReadAll(r.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if string(b) != `{"order":{"item":"a"}}` {
+		t.Errorf("unexpected body: %s", b)
+	}
+
+	if ct := r.Header.Get("Content-Type"); ct != "application/json" {
+		t.Errorf("invalid content type: %s", ct)
+	}
+}
+
+func TestXMLToJSONUnchanged(t *testing.T) {
+	for _, tt := range []struct {
+		msg         string
+		contentType string
+		body        string
+	}{
+		{msg: "not XML", contentType: "text/plain", body: `<order/>`},
+		{msg: "invalid XML", contentType: "application/xml", body: `<order><item></order>`},
+		{msg: "no root", contentType: "application/xml", body: `<?xml version="1.0"?>`},
+	} {
+		t.Run(tt.msg, func(t *testing.T) {
+			f, err := NewXMLToJSON().CreateFilter(nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			rsp := &http.Response{
+				Header: http.Header{"Content-Type": []string{tt.contentType}},
+				Body:   io.NopCloser(strings.NewReader(tt.body)),
+			}
+
+			f.Response(&filtertest.Context{FResponse: rsp})
+			b, err := io.ReadAll(rsp.Body)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if string(b) != tt.body {
+				t.Errorf("expected unchanged body %s, got %s", tt.body, b)
+			}
+
+			if ct := rsp.Header.Get("Content-Type"); ct != tt.contentType {
+				t.Errorf("expected unchanged content type, got: %s", ct)
+			}
+		})
+	}
+}
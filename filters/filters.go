@@ -4,6 +4,7 @@ import (
 	"errors"
 	"io"
 	"net/http"
+	"sync"
 	"time"
 
 	log "github.com/sirupsen/logrus"
@@ -27,6 +28,14 @@ const (
 	// BackendTimeout is the key used in the state bag to configure backend timeout in proxy
 	BackendTimeout = "backend:timeout"
 
+	// ProxyProtocolKey is the key used in the state bag to request the proxy to send the
+	// PROXY protocol header, of the version stored as the value, "v1" or "v2", to the backend
+	ProxyProtocolKey = "backend:proxyprotocol"
+
+	// LoopbackKey is the key used in the state bag by the filters to request the proxy to
+	// process the request as if the route had a loopback backend, when the value is true
+	LoopbackKey = "routing:loopback"
+
 	// ReadTimeout is the key used in the state bag to configure read request body timeout in proxy
 	ReadTimeout = "read:timeout"
 
@@ -35,6 +44,34 @@ const (
 
 	// BackendRatelimit is the key used in the state bag to configure backend ratelimit in proxy
 	BackendRatelimit = "backend:ratelimit"
+
+	// TrafficSegmentKey is the key used in the state bag by the proxy to pass the traffic
+	// segment metadata (routing.TrafficSegment) of the matched route to the filters
+	TrafficSegmentKey = "traffic:segment"
+
+	// RouteMetadataKey is the key used in the state bag by the proxy to pass the
+	// metadata (map[string]string) of the matched route, set by the data client,
+	// to the filters
+	RouteMetadataKey = "route:metadata"
+
+	// BufferedResponseBodyKey is the key used in the state bag by the bufferForCompare
+	// filter to pass the complete buffered response body ([]byte) to the following filters
+	BufferedResponseBodyKey = "response:buffered:body"
+
+	// CacheableKey is the key used in the state bag by the tagCacheability filter
+	// to pass whether the response is cacheable by a shared cache (bool) to the
+	// following filters
+	CacheableKey = "response:cacheable"
+
+	// AcceptLanguageKey is the key used in the state bag to pass the language
+	// chosen by the normalizeAcceptLanguage filter to the following filters
+	AcceptLanguageKey = "request:language"
+
+	// CorrelationIdKey is the key used in the routing context of the request
+	// (routing.FromContext) to store the correlation id (*CorrelationId) of the
+	// logical request. The proxy shares it with the requests created by Split,
+	// and the loopback requests use the same routing context
+	CorrelationIdKey = "request:correlation:id"
 )
 
 // FilterContext object providing state and information that is unique to a request.
@@ -119,6 +156,7 @@ type FilterContext interface {
 	// new request body is written.
 	// The StateBag and filterMetrics object are not preserved in the new context.
 	// Therefore, you can't access state bag values set in the previous context.
+	// The correlation id, see CorrelationIdKey, is shared with the new request.
 	Split() (FilterContext, error)
 
 	// Performs a new route lookup and executes the matched route if any
@@ -204,6 +242,32 @@ func (r Registry) Register(s Spec) {
 	r[name] = s
 }
 
+// CorrelationId holds the correlation id shared by a request and the
+// requests derived from it by the loopback and the tee loopback.
+type CorrelationId struct {
+	mu sync.Mutex
+	id string
+}
+
+// Ensure returns the correlation id, and calls generate to set it, when it
+// was not set yet.
+func (c *CorrelationId) Ensure(generate func() string) string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.id == "" {
+		c.id = generate()
+	}
+
+	return c.id
+}
+
+// Get returns the correlation id, or empty string, when it was not set yet.
+func (c *CorrelationId) Get() string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.id
+}
+
 // All Skipper filter names
 const (
 	BackendIsProxyName                         = "backendIsProxy"
@@ -225,15 +289,20 @@ const (
 	SetPathName                                = "setPath"
 	RedirectToName                             = "redirectTo"
 	RedirectToLowerName                        = "redirectToLower"
+	CanonicalPathRedirectName                  = "canonicalPathRedirect"
 	StaticName                                 = "static"
 	StripQueryName                             = "stripQuery"
 	PreserveHostName                           = "preserveHost"
 	StatusName                                 = "status"
 	CompressName                               = "compress"
 	DecompressName                             = "decompress"
+	CohortEncodingName                         = "cohortEncoding"
 	SetQueryName                               = "setQuery"
 	DropQueryName                              = "dropQuery"
+	NormalizeQueryName                         = "normalizeQuery"
 	InlineContentName                          = "inlineContent"
+	MaintenanceWindowName                      = "maintenanceWindow"
+	CohortHeadersName                          = "cohortHeaders"
 	InlineContentIfStatusName                  = "inlineContentIfStatus"
 	FlowIdName                                 = "flowId"
 	XforwardName                               = "xforward"
@@ -246,6 +315,9 @@ const (
 	BackendTimeoutName                         = "backendTimeout"
 	ReadTimeoutName                            = "readTimeout"
 	WriteTimeoutName                           = "writeTimeout"
+	CohortTimeoutName                          = "cohortTimeout"
+	MaxRequestsPerConnectionName               = "maxRequestsPerConnection"
+	Handle100ContinueName                      = "handle100Continue"
 	BlockName                                  = "blockContent"
 	BlockHexName                               = "blockContentHex"
 	LatencyName                                = "latency"
@@ -264,6 +336,10 @@ const (
 	TeeName                                    = "tee"
 	TeenfName                                  = "teenf"
 	TeeLoopbackName                            = "teeLoopback"
+	TeeCoalesceName                            = "teeCoalesce"
+	TeeToKafkaName                             = "teeToKafka"
+	CohortWALName                              = "cohortWAL"
+	ReplaySynthesizeName                       = "replaySynthesize"
 	SedName                                    = "sed"
 	SedDelimName                               = "sedDelim"
 	SedRequestName                             = "sedRequest"
@@ -307,6 +383,7 @@ const (
 	ClusterLeakyBucketRatelimitName            = "clusterLeakyBucketRatelimit"
 	BackendRateLimitName                       = "backendRatelimit"
 	RatelimitFailClosedName                    = "ratelimitFailClosed"
+	RatelimitHeadersName                       = "ratelimitHeaders"
 	LuaName                                    = "lua"
 	CorsOriginName                             = "corsOrigin"
 	HeaderToQueryName                          = "headerToQuery"
@@ -324,19 +401,57 @@ const (
 	ApiUsageMonitoringName                     = "apiUsageMonitoring"
 	FifoName                                   = "fifo"
 	LifoName                                   = "lifo"
+	QueueConcurrencyName                       = "queueConcurrency"
 	LifoGroupName                              = "lifoGroup"
 	RfcPathName                                = "rfcPath"
 	RfcHostName                                = "rfcHost"
 	BearerInjectorName                         = "bearerinjector"
+	SignResponseName                           = "signResponse"
+	VerifyEd25519Name                          = "verifyEd25519"
 	TracingBaggageToTagName                    = "tracingBaggageToTag"
 	StateBagToTagName                          = "stateBagToTag"
 	TracingTagName                             = "tracingTag"
 	TracingSpanNameName                        = "tracingSpanName"
+	SetCohortBaggageName                       = "setCohortBaggage"
+	TraceCohortEventName                       = "traceCohortEvent"
+	CohortTagSampledName                       = "cohortTagSampled"
 	OriginMarkerName                           = "originMarker"
 	FadeInName                                 = "fadeIn"
 	EndpointCreatedName                        = "endpointCreated"
 	ConsistentHashKeyName                      = "consistentHashKey"
 	ConsistentHashBalanceFactorName            = "consistentHashBalanceFactor"
+	MaxURLLengthName                           = "maxURLLength"
+	MaxQueryParamsName                         = "maxQueryParams"
+	RetryAfterOnStatusName                     = "retryAfterOnStatus"
+	RewriteAuthChallengeName                   = "rewriteAuthChallenge"
+	RequireResponseHeadersName                 = "requireResponseHeaders"
+	CanarySeqName                              = "canarySeq"
+	CanaryCorrelationIdName                    = "canaryCorrelationId"
+	NormalizeAcceptLanguageName                = "normalizeAcceptLanguage"
+	IdempotencyGuardName                       = "idempotencyGuard"
+	WebhookDedupName                           = "webhookDedup"
+	ErrorEnrichName                            = "errorEnrich"
+	SendProxyProtocolName                      = "sendProxyProtocol"
+	AutoKillName                               = "autoKill"
+	FailoverReconcileName                      = "failoverReconcile"
+	RollbackToName                             = "rollbackTo"
+	StampRouteVersionName                      = "stampRouteVersion"
+	BufferForCompareName                       = "bufferForCompare"
+	TagCacheabilityName                        = "tagCacheability"
+	RewriteJSONLinksName                       = "rewriteJSONLinks"
+	ReorderStreamName                          = "reorderStream"
+	TransformByContentTypeName                 = "transformByContentType"
+	JSONKeyCaseName                            = "jsonKeyCase"
+	XMLToJSONName                              = "xmlToJSON"
+	DechunkSmallResponsesName                  = "dechunkSmallResponses"
+	BandwidthLimitName                         = "bandwidthLimit"
+	LatencyCompareName                         = "latencyCompare"
+	SplitSkewMetricName                        = "splitSkewMetric"
+	CohortApdexName                            = "cohortApdex"
+	MergeRequestHeadersName                    = "mergeRequestHeaders"
+	MergeResponseHeadersName                   = "mergeResponseHeaders"
+	LocalizeDateHeadersName                    = "localizeDateHeaders"
+	WasmResponseName                           = "wasmResponse"
 
 	// Undocumented filters
 	HealthCheckName        = "healthcheck"
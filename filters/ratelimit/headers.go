@@ -0,0 +1,75 @@
+package ratelimit
+
+import (
+	"net/http"
+
+	"github.com/zalando/skipper/filters"
+	"github.com/zalando/skipper/ratelimit"
+)
+
+const headersStateKey = "filter:ratelimit:headers"
+
+type headersSpec struct{}
+type headersFilter struct{}
+
+// headersState collects the state of the ratelimits applied to the request.
+type headersState struct {
+	set                     bool
+	limit, remaining, reset int
+}
+
+// NewRatelimitHeaders creates a filter specification whose instances set
+// the RateLimit-Limit, RateLimit-Remaining and RateLimit-Reset headers, as
+// defined by the IETF draft "RateLimit header fields for HTTP", on every
+// response, based on the state of the ratelimit filters following it in
+// the route. When multiple ratelimits apply, the headers show the one with
+// the least remaining calls.
+//
+// Example:
+//
+//	r: * -> ratelimitHeaders() -> clientRatelimit(10, "1m") -> "https://www.example.org";
+func NewRatelimitHeaders() filters.Spec { return &headersSpec{} }
+
+func (*headersSpec) Name() string { return filters.RatelimitHeadersName }
+
+func (*headersSpec) CreateFilter(args []interface{}) (filters.Filter, error) {
+	if len(args) != 0 {
+		return nil, filters.ErrInvalidFilterParameters
+	}
+
+	return &headersFilter{}, nil
+}
+
+// update stores the state of a ratelimit, unless a more restrictive one was
+// already stored.
+func (h *headersState) update(limit, remaining, reset int) {
+	if h == nil {
+		return
+	}
+
+	if h.set && (h.remaining < remaining || h.remaining == remaining && h.reset >= reset) {
+		return
+	}
+
+	h.set, h.limit, h.remaining, h.reset = true, limit, remaining, reset
+}
+
+func (*headersFilter) Request(ctx filters.FilterContext) {
+	ctx.StateBag()[headersStateKey] = &headersState{}
+}
+
+func (*headersFilter) Response(ctx filters.FilterContext) {
+	h, ok := ctx.StateBag()[headersStateKey].(*headersState)
+	if !ok || !h.set {
+		return
+	}
+
+	rsp := ctx.Response()
+	if rsp.Header == nil {
+		rsp.Header = make(http.Header)
+	}
+
+	for k, v := range ratelimit.RateLimitHeaders(h.limit, h.remaining, h.reset) {
+		rsp.Header[k] = v
+	}
+}
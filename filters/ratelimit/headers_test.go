@@ -0,0 +1,101 @@
+package ratelimit
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/zalando/skipper/filters"
+	"github.com/zalando/skipper/filters/filtertest"
+	"github.com/zalando/skipper/ratelimit"
+)
+
+func TestRatelimitHeadersArgs(t *testing.T) {
+	if _, err := NewRatelimitHeaders().CreateFilter([]interface{}{"foo"}); err == nil {
+		t.Error("expected error")
+	}
+}
+
+func TestRatelimitHeaders(t *testing.T) {
+	registry := ratelimit.NewRegistry()
+	defer registry.Close()
+
+	provider := NewRatelimitProvider(registry)
+	headers, err := NewRatelimitHeaders().CreateFilter(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	service, err := NewRatelimit(provider).CreateFilter([]interface{}{3, "1m"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	client, err := NewClientRatelimit(provider).CreateFilter([]interface{}{10, "10s"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// serve runs the filters in the order of the route, and returns the
+	// response
+	serve := func(fs ...filters.Filter) *http.Response {
+		req, err := http.NewRequest("GET", "https://www.example.org", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		req.Header.Set("X-Forwarded-For", "192.0.2.1")
+		ctx := &filtertest.Context{FRequest: req, FStateBag: make(map[string]interface{})}
+		var processed []filters.Filter
+		for _, f := range fs {
+			processed = append(processed, f)
+			f.Request(ctx)
+			if ctx.FServed {
+				break
+			}
+		}
+
+		if !ctx.FServed {
+			ctx.FResponse = &http.Response{StatusCode: http.StatusOK, Header: make(http.Header)}
+		}
+
+		for i := len(processed) - 1; i >= 0; i-- {
+			processed[i].Response(ctx)
+		}
+
+		return ctx.FResponse
+	}
+
+	// the client ratelimit only estimates the remaining calls
+	for i, expected := range []struct {
+		status                  int
+		limit, remaining, reset string
+	}{
+		{status: http.StatusOK, limit: "10", remaining: "1", reset: "10"},
+		{status: http.StatusOK, limit: "3", remaining: "1", reset: "60"},
+		{status: http.StatusOK, limit: "3", remaining: "0", reset: "60"},
+		{status: http.StatusTooManyRequests, limit: "3", remaining: "0", reset: "60"},
+	} {
+		rsp := serve(headers, client, service)
+		if rsp.StatusCode != expected.status {
+			t.Errorf("%d: expected status %d, got: %d", i, expected.status, rsp.StatusCode)
+		}
+
+		if got := rsp.Header.Get(ratelimit.LimitHeader); got != expected.limit {
+			t.Errorf("%d: expected limit %s, got: %s", i, expected.limit, got)
+		}
+
+		if got := rsp.Header.Get(ratelimit.RemainingHeader); got != expected.remaining {
+			t.Errorf("%d: expected remaining %s, got: %s", i, expected.remaining, got)
+		}
+
+		if got := rsp.Header.Get(ratelimit.ResetHeader); got != expected.reset {
+			t.Errorf("%d: expected reset %s, got: %s", i, expected.reset, got)
+		}
+	}
+
+	// without the headers filter, the headers are not set
+	rsp := serve(client)
+	if rsp.Header.Get(ratelimit.LimitHeader) != "" {
+		t.Errorf("unexpected headers: %v", rsp.Header)
+	}
+}
@@ -7,6 +7,7 @@ package ratelimit
 
 import (
 	"context"
+	"math"
 	"net/http"
 	"strconv"
 	"strings"
@@ -45,6 +46,10 @@ type limit interface {
 	// RetryAfter is used to inform the client how many seconds it
 	// should wait before making a new request
 	RetryAfter(string) int
+
+	// Remaining is used to inform the client how many calls are
+	// left in the current time window
+	Remaining(string) int
 }
 
 // RegistryAdapter adapts ratelimit.Registry to RateLimitProvider interface.
@@ -475,15 +480,31 @@ func (f *filter) Request(ctx filters.FilterContext) {
 		return
 	}
 
+	maxHits := f.settings.MaxHits
+	if f.maxHits != 0 {
+		maxHits = f.maxHits
+	}
+
+	headers, _ := ctx.StateBag()[headersStateKey].(*headersState)
 	if !rateLimiter.Allow(ctx.Request().Context(), s) {
-		maxHits := f.settings.MaxHits
-		if f.maxHits != 0 {
-			maxHits = f.maxHits
-		}
+		retryAfter := rateLimiter.RetryAfter(s)
+		headers.update(maxHits, 0, retryAfter)
 		ctx.Serve(&http.Response{
 			StatusCode: f.statusCode,
-			Header:     ratelimit.Headers(maxHits, f.settings.TimeWindow, rateLimiter.RetryAfter(s)),
+			Header:     ratelimit.Headers(maxHits, f.settings.TimeWindow, retryAfter),
 		})
+
+		return
+	}
+
+	if headers != nil {
+		remaining := rateLimiter.Remaining(s)
+		reset := int(math.Ceil(f.settings.TimeWindow.Seconds()))
+		if remaining == 0 {
+			reset = rateLimiter.RetryAfter(s)
+		}
+
+		headers.update(maxHits, remaining, reset)
 	}
 }
 
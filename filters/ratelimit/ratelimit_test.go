@@ -80,6 +80,7 @@ func (l *testLimit) get(s ratelimit.Settings) limit {
 
 func (l *testLimit) Allow(context.Context, string) bool { return false }
 func (l *testLimit) RetryAfter(string) int              { return 31415 }
+func (l *testLimit) Remaining(string) int               { return 0 }
 
 func TestRateLimit(t *testing.T) {
 	test := func(
@@ -414,6 +415,7 @@ func (n *noLimit) get(ratelimit.Settings) limit {
 }
 func (n *noLimit) Allow(context.Context, string) bool { return true }
 func (n *noLimit) RetryAfter(string) int              { panic("unexpected RetryAfter call") }
+func (n *noLimit) Remaining(string) int               { panic("unexpected Remaining call") }
 
 func TestNilLimit(t *testing.T) {
 	f := &filter{provider: &noLimit{nilLimit: true}}
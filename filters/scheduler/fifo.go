@@ -16,19 +16,36 @@ const (
 )
 
 type (
-	fifoSpec   struct{}
+	fifoSpec struct {
+		name          string
+		timeoutStatus int
+	}
 	fifoFilter struct {
-		config scheduler.Config
-		queue  *scheduler.FifoQueue
+		config        scheduler.Config
+		queue         *scheduler.FifoQueue
+		timeoutStatus int
 	}
 )
 
 func NewFifo() filters.Spec {
-	return &fifoSpec{}
+	return &fifoSpec{name: filters.FifoName, timeoutStatus: http.StatusBadGateway}
+}
+
+// NewQueueConcurrency creates a filter specification whose instances
+// limit the number of concurrent requests of a route, and queue the
+// requests above the limit in FIFO order, up to the queue size. It is
+// the same as fifo(), except that requests exceeding the maximum wait
+// time are rejected with 503, like when the queue is full.
+//
+// Example:
+//
+//	queueConcurrency(50, 200, "30s")
+func NewQueueConcurrency() filters.Spec {
+	return &fifoSpec{name: filters.QueueConcurrencyName, timeoutStatus: http.StatusServiceUnavailable}
 }
 
-func (*fifoSpec) Name() string {
-	return filters.FifoName
+func (s *fifoSpec) Name() string {
+	return s.name
 }
 
 // CreateFilter creates a fifoFilter, that will use a semaphore based
@@ -70,6 +87,7 @@ func (s *fifoSpec) CreateFilter(args []interface{}) (filters.Filter, error) {
 			MaxQueueSize:   qs,
 			Timeout:        d,
 		},
+		timeoutStatus: s.timeoutStatus,
 	}, nil
 }
 
@@ -90,7 +108,7 @@ func (f *fifoFilter) SetQueue(fq *scheduler.FifoQueue) {
 // if the bounded queue returns an error. Status code by Error:
 //
 // - 503 if queue full
-// - 502 if queue timeout
+// - 502 if queue timeout, 503 in case of queueConcurrency()
 // - 500 if error unknown
 func (f *fifoFilter) Request(ctx filters.FilterContext) {
 	q := f.GetQueue()
@@ -112,7 +130,7 @@ func (f *fifoFilter) Request(ctx filters.FilterContext) {
 			return
 		case scheduler.ErrQueueTimeout:
 			ctx.Serve(&http.Response{
-				StatusCode: http.StatusBadGateway,
+				StatusCode: f.timeoutStatus,
 				Status:     "Queue Timeout - https://opensource.zalando.com/skipper/operation/operation/#scheduler",
 			})
 			return
@@ -0,0 +1,239 @@
+package scheduler
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/zalando/skipper/eskip"
+	"github.com/zalando/skipper/filters"
+	"github.com/zalando/skipper/filters/filtertest"
+	"github.com/zalando/skipper/routing"
+	"github.com/zalando/skipper/scheduler"
+)
+
+func TestCreateQueueConcurrencyFilter(t *testing.T) {
+	for _, tt := range []struct {
+		name string
+		args []interface{}
+		err  bool
+	}{{
+		name: "no args",
+		err:  true,
+	}, {
+		name: "missing max wait",
+		args: []interface{}{50, 200},
+		err:  true,
+	}, {
+		name: "too many args",
+		args: []interface{}{50, 200, "30s", "fifo"},
+		err:  true,
+	}, {
+		name: "invalid concurrency",
+		args: []interface{}{0, 200, "30s"},
+		err:  true,
+	}, {
+		name: "invalid queue size",
+		args: []interface{}{50, -1, "30s"},
+		err:  true,
+	}, {
+		name: "invalid max wait",
+		args: []interface{}{50, 200, "foo"},
+		err:  true,
+	}, {
+		name: "max wait too short",
+		args: []interface{}{50, 200, "1us"},
+		err:  true,
+	}, {
+		name: "ok",
+		args: []interface{}{50.0, 200.0, "30s"},
+	}} {
+		t.Run(tt.name, func(t *testing.T) {
+			f, err := NewQueueConcurrency().CreateFilter(tt.args)
+			if tt.err {
+				if err == nil {
+					t.Fatal("expected error")
+				}
+
+				return
+			}
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			c := f.(*fifoFilter).Config()
+			if c.MaxConcurrency != 50 || c.MaxQueueSize != 200 || c.Timeout != 30*time.Second {
+				t.Errorf("unexpected config: %+v", c)
+			}
+		})
+	}
+}
+
+func createQueueConcurrency(t *testing.T, args ...interface{}) filters.Filter {
+	t.Helper()
+
+	f, err := NewQueueConcurrency().CreateFilter(args)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	reg := scheduler.RegistryWith(scheduler.Options{})
+	t.Cleanup(reg.Close)
+
+	reg.Do([]*routing.Route{{
+		Route:   eskip.Route{Id: "test"},
+		Filters: []*routing.RouteFilter{{Filter: f, Name: filters.QueueConcurrencyName}},
+	}})
+
+	return f
+}
+
+func queueConcurrencyContext(t *testing.T, ctx context.Context) *filtertest.Context {
+	t.Helper()
+
+	req, err := http.NewRequestWithContext(ctx, "GET", "https://www.example.org", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return &filtertest.Context{
+		FRequest:  req,
+		FStateBag: make(map[string]interface{}),
+	}
+}
+
+func TestQueueConcurrencyPassthrough(t *testing.T) {
+	f := createQueueConcurrency(t, 2, 0, "1s")
+
+	for i := 0; i < 3; i++ {
+		first := queueConcurrencyContext(t, context.Background())
+		second := queueConcurrencyContext(t, context.Background())
+
+		f.Request(first)
+		f.Request(second)
+		if first.FServed || second.FServed {
+			t.Fatal("unexpected rejection within the concurrency limit")
+		}
+
+		f.Response(first)
+		f.Response(second)
+	}
+}
+
+func TestQueueConcurrencyQueueing(t *testing.T) {
+	f := createQueueConcurrency(t, 1, 2, "1s")
+
+	active := queueConcurrencyContext(t, context.Background())
+	f.Request(active)
+	if active.FServed {
+		t.Fatal("unexpected rejection")
+	}
+
+	order := make(chan int, 2)
+	finished := make(chan *filtertest.Context, 2)
+	enqueue := func(i int) {
+		ctx := queueConcurrencyContext(t, context.Background())
+		go func() {
+			f.Request(ctx)
+			order <- i
+			finished <- ctx
+		}()
+	}
+
+	enqueue(1)
+	waitQueued(t, f, 1)
+	enqueue(2)
+	waitQueued(t, f, 2)
+
+	select {
+	case <-order:
+		t.Fatal("queued request was processed before the active one finished")
+	case <-time.After(30 * time.Millisecond):
+	}
+
+	f.Response(active)
+	for expected := 1; expected <= 2; expected++ {
+		if i := <-order; i != expected {
+			t.Fatalf("expected request %d to be processed, got: %d", expected, i)
+		}
+
+		ctx := <-finished
+		if ctx.FServed {
+			t.Fatalf("unexpected rejection of request %d", expected)
+		}
+
+		f.Response(ctx)
+	}
+}
+
+func TestQueueConcurrencyOverflow(t *testing.T) {
+	t.Run("queue full", func(t *testing.T) {
+		f := createQueueConcurrency(t, 1, 0, "1s")
+
+		active := queueConcurrencyContext(t, context.Background())
+		f.Request(active)
+		defer f.Response(active)
+
+		rejected := queueConcurrencyContext(t, context.Background())
+		f.Request(rejected)
+		if !rejected.FServed || rejected.FResponse.StatusCode != http.StatusServiceUnavailable {
+			t.Fatalf("expected rejection with 503, got: %+v", rejected.FResponse)
+		}
+	})
+
+	t.Run("max wait exceeded", func(t *testing.T) {
+		f := createQueueConcurrency(t, 1, 1, "10ms")
+
+		active := queueConcurrencyContext(t, context.Background())
+		f.Request(active)
+		defer f.Response(active)
+
+		rejected := queueConcurrencyContext(t, context.Background())
+		f.Request(rejected)
+		if !rejected.FServed || rejected.FResponse.StatusCode != http.StatusServiceUnavailable {
+			t.Fatalf("expected rejection with 503, got: %+v", rejected.FResponse)
+		}
+	})
+
+	t.Run("canceled request leaves the queue", func(t *testing.T) {
+		f := createQueueConcurrency(t, 1, 1, "1s")
+
+		active := queueConcurrencyContext(t, context.Background())
+		f.Request(active)
+		defer f.Response(active)
+
+		cctx, cancel := context.WithCancel(context.Background())
+		canceled := queueConcurrencyContext(t, cctx)
+		done := make(chan struct{})
+		go func() {
+			f.Request(canceled)
+			close(done)
+		}()
+
+		waitQueued(t, f, 1)
+		cancel()
+		<-done
+
+		if canceled.FServed {
+			t.Error("canceled request should not be served by the filter")
+		}
+
+		waitQueued(t, f, 0)
+	})
+}
+
+func waitQueued(t *testing.T, f filters.Filter, n int) {
+	t.Helper()
+
+	q := f.(*fifoFilter).GetQueue()
+	timeout := time.After(time.Second)
+	for q.Status().QueuedRequests != n {
+		select {
+		case <-timeout:
+			t.Fatalf("timeout waiting for %d queued requests, got: %d", n, q.Status().QueuedRequests)
+		case <-time.After(time.Millisecond):
+		}
+	}
+}
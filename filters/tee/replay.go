@@ -0,0 +1,327 @@
+package tee
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/zalando/skipper/eskip"
+	"github.com/zalando/skipper/filters"
+	"github.com/zalando/skipper/metrics"
+	"github.com/zalando/skipper/routing"
+)
+
+const (
+	// ReplaySynthesizedHeader is set on the requests synthesized by the
+	// replaySynthesize filter.
+	ReplaySynthesizedHeader = "X-Replay-Synthesized"
+
+	defaultReplayTimeout = 5 * time.Second
+)
+
+// ReplayOptions for the replaySynthesize filter.
+type ReplayOptions struct {
+	// Timeout of the synthesized requests, defaults to 5s.
+	Timeout time.Duration
+
+	// Metrics used to count the synthesized requests, defaults to
+	// metrics.Default.
+	Metrics metrics.Metrics
+}
+
+// ReplaySynthesizeSpec is the specification of the replaySynthesize filter.
+type ReplaySynthesizeSpec struct {
+	client  *http.Client
+	metrics metrics.Metrics
+
+	mu      sync.Mutex
+	filters map[string]*replayFilter
+}
+
+type replayPostProcessor struct {
+	spec *ReplaySynthesizeSpec
+}
+
+type replayFilter struct {
+	interval time.Duration
+	captures []*KafkaMessage
+	client   *http.Client
+	metrics  metrics.Metrics
+
+	// cancelled by Close, to stop the generator and its pending request
+	ctx    context.Context
+	cancel context.CancelFunc
+
+	mu      sync.Mutex
+	started bool
+	closed  bool
+	done    chan struct{}
+}
+
+// NewReplaySynthesize returns a filter specification whose instances
+// re-issue previously captured requests against the backend of their
+// route, at the configured rate, independent of the live traffic, e.g. to
+// warm up a canary. The captured requests are read from a file containing
+// one JSON message per line, in the format published by the teeToKafka
+// filter.
+//
+// The requests are synthesized in the background, from when the route is
+// created until it is deleted or replaced, or until the Close method of the
+// spec is called. They are marked with the X-Replay-Synthesized header, and
+// counted with the replaySynthesize.<route id>.requests and
+// replaySynthesize.<route id>.errors custom counters. The PostProcessor of
+// the spec needs to be registered in the routing, to start the generators.
+//
+// Example:
+//
+//	warmup: Path("/__warmup") && False() -> replaySynthesize(10, "/var/captures/checkout.jsonl") -> "https://canary.example.org";
+func NewReplaySynthesize(o ReplayOptions) *ReplaySynthesizeSpec {
+	if o.Timeout <= 0 {
+		o.Timeout = defaultReplayTimeout
+	}
+
+	if o.Metrics == nil {
+		o.Metrics = metrics.Default
+	}
+
+	return &ReplaySynthesizeSpec{
+		client: &http.Client{
+			Timeout: o.Timeout,
+			CheckRedirect: func(*http.Request, []*http.Request) error {
+				return http.ErrUseLastResponse
+			},
+		},
+		metrics: o.Metrics,
+		filters: make(map[string]*replayFilter),
+	}
+}
+
+func (*ReplaySynthesizeSpec) Name() string { return filters.ReplaySynthesizeName }
+
+func (s *ReplaySynthesizeSpec) CreateFilter(args []interface{}) (filters.Filter, error) {
+	if len(args) != 2 {
+		return nil, filters.ErrInvalidFilterParameters
+	}
+
+	var rate float64
+	switch v := args[0].(type) {
+	case int:
+		rate = float64(v)
+	case float64:
+		rate = v
+	default:
+		return nil, filters.ErrInvalidFilterParameters
+	}
+
+	if rate <= 0 {
+		return nil, fmt.Errorf("%s: rate requires value >0, %w", filters.ReplaySynthesizeName, filters.ErrInvalidFilterParameters)
+	}
+
+	source, ok := args[1].(string)
+	if !ok {
+		return nil, filters.ErrInvalidFilterParameters
+	}
+
+	captures, err := readCaptures(source)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", filters.ReplaySynthesizeName, err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	return &replayFilter{
+		interval: time.Duration(float64(time.Second) / rate),
+		captures: captures,
+		client:   s.client,
+		metrics:  s.metrics,
+		ctx:      ctx,
+		cancel:   cancel,
+		done:     make(chan struct{}),
+	}, nil
+}
+
+// PostProcessor returns the routing.PostProcessor starting the generators
+// of the replaySynthesize filters for the backends of their routes.
+func (s *ReplaySynthesizeSpec) PostProcessor() routing.PostProcessor {
+	return replayPostProcessor{spec: s}
+}
+
+// Close stops all generators.
+func (s *ReplaySynthesizeSpec) Close() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for id, f := range s.filters {
+		f.Close()
+		delete(s.filters, id)
+	}
+}
+
+func (p replayPostProcessor) Do(routes []*routing.Route) []*routing.Route {
+	s := p.spec
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	inUse := make(map[string]struct{})
+	for _, r := range routes {
+		for _, f := range r.Filters {
+			rf, ok := f.Filter.(*replayFilter)
+			if !ok {
+				continue
+			}
+
+			if r.BackendType != eskip.NetworkBackend {
+				log.Warnf("%s: route %s has no network backend, no requests are synthesized", filters.ReplaySynthesizeName, r.Id)
+				continue
+			}
+
+			if old, ok := s.filters[r.Id]; ok && old != rf {
+				old.Close()
+			}
+
+			s.filters[r.Id] = rf
+			inUse[r.Id] = struct{}{}
+			rf.start(r.Id, &url.URL{Scheme: r.Scheme, Host: r.Host})
+		}
+	}
+
+	for id, f := range s.filters {
+		if _, ok := inUse[id]; !ok {
+			f.Close()
+			delete(s.filters, id)
+		}
+	}
+
+	return routes
+}
+
+func readCaptures(source string) ([]*KafkaMessage, error) {
+	f, err := os.Open(source)
+	if err != nil {
+		return nil, err
+	}
+
+	defer f.Close()
+
+	var captures []*KafkaMessage
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(nil, 16*defaultKafkaMaxBodySize)
+	for line := 1; scanner.Scan(); line++ {
+		b := bytes.TrimSpace(scanner.Bytes())
+		if len(b) == 0 {
+			continue
+		}
+
+		m := &KafkaMessage{}
+		if err := json.Unmarshal(b, m); err != nil {
+			return nil, fmt.Errorf("invalid capture in %s, line %d: %w", source, line, err)
+		}
+
+		if m.Method == "" {
+			m.Method = http.MethodGet
+		}
+
+		captures = append(captures, m)
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	if len(captures) == 0 {
+		return nil, fmt.Errorf("no captured requests in %s", source)
+	}
+
+	return captures, nil
+}
+
+func (f *replayFilter) start(routeID string, target *url.URL) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.started || f.closed {
+		return
+	}
+
+	f.started = true
+	go f.run(routeID, target)
+}
+
+func (f *replayFilter) run(routeID string, target *url.URL) {
+	defer close(f.done)
+
+	requestsKey := fmt.Sprintf("%s.%s.requests", filters.ReplaySynthesizeName, routeID)
+	errorsKey := fmt.Sprintf("%s.%s.errors", filters.ReplaySynthesizeName, routeID)
+
+	ticker := time.NewTicker(f.interval)
+	defer ticker.Stop()
+
+	for next := 0; ; next = (next + 1) % len(f.captures) {
+		select {
+		case <-ticker.C:
+		case <-f.ctx.Done():
+			return
+		}
+
+		f.metrics.IncCounter(requestsKey)
+		if err := f.replay(target, f.captures[next]); err != nil && f.ctx.Err() == nil {
+			log.Debugf("%s: failed to synthesize request for route %s: %v", filters.ReplaySynthesizeName, routeID, err)
+			f.metrics.IncCounter(errorsKey)
+		}
+	}
+}
+
+func (f *replayFilter) replay(target *url.URL, m *KafkaMessage) error {
+	u := *target
+	u.Path = m.Path
+	u.RawQuery = m.Query
+
+	req, err := http.NewRequestWithContext(f.ctx, m.Method, u.String(), bytes.NewReader(m.Body))
+	if err != nil {
+		return err
+	}
+
+	for k, v := range m.Headers {
+		req.Header[k] = v
+	}
+
+	req.Header.Set(ReplaySynthesizedHeader, "true")
+
+	rsp, err := f.client.Do(req)
+	if err != nil {
+		return err
+	}
+
+	rsp.Body.Close()
+	return nil
+}
+
+func (*replayFilter) Request(filters.FilterContext) {}
+
+func (*replayFilter) Response(filters.FilterContext) {}
+
+// Close stops the generator of the filter.
+func (f *replayFilter) Close() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.closed {
+		return nil
+	}
+
+	f.closed = true
+	f.cancel()
+	if f.started {
+		<-f.done
+	}
+
+	return nil
+}
@@ -0,0 +1,268 @@
+package tee
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/zalando/skipper/eskip"
+	"github.com/zalando/skipper/filters"
+	"github.com/zalando/skipper/metrics/metricstest"
+	"github.com/zalando/skipper/routing"
+)
+
+const testCaptures = `
+{"method":"GET","host":"www.example.org","path":"/foo","query":"q=1","headers":{"Accept":["application/json"]}}
+{"method":"POST","host":"www.example.org","path":"/bar","body":"SGVsbG8="}
+`
+
+func writeCaptures(t *testing.T, content string) string {
+	t.Helper()
+
+	name := filepath.Join(t.TempDir(), "captures.jsonl")
+	require.NoError(t, os.WriteFile(name, []byte(content), 0o644))
+	return name
+}
+
+func TestReplaySynthesizeCreate(t *testing.T) {
+	valid := writeCaptures(t, testCaptures)
+	empty := writeCaptures(t, "\n")
+	invalid := writeCaptures(t, "{\"method\":\"GET\"}\nfoo\n")
+
+	spec := NewReplaySynthesize(ReplayOptions{})
+	for _, tt := range []struct {
+		msg  string
+		args []interface{}
+		err  bool
+	}{{
+		msg: "no args",
+		err: true,
+	}, {
+		msg:  "missing source",
+		args: []interface{}{10.0},
+		err:  true,
+	}, {
+		msg:  "invalid rate",
+		args: []interface{}{"10", valid},
+		err:  true,
+	}, {
+		msg:  "zero rate",
+		args: []interface{}{0.0, valid},
+		err:  true,
+	}, {
+		msg:  "missing source file",
+		args: []interface{}{10.0, filepath.Join(t.TempDir(), "missing.jsonl")},
+		err:  true,
+	}, {
+		msg:  "empty source",
+		args: []interface{}{10.0, empty},
+		err:  true,
+	}, {
+		msg:  "invalid source",
+		args: []interface{}{10.0, invalid},
+		err:  true,
+	}, {
+		msg:  "valid",
+		args: []interface{}{10.0, valid},
+	}} {
+		t.Run(tt.msg, func(t *testing.T) {
+			f, err := spec.CreateFilter(tt.args)
+			if tt.err {
+				assert.Error(t, err)
+				return
+			}
+
+			require.NoError(t, err)
+			f.(filters.FilterCloser).Close()
+		})
+	}
+}
+
+type replayBackend struct {
+	mu       sync.Mutex
+	requests []*http.Request
+	bodies   []string
+}
+
+func (b *replayBackend) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, _ := io.ReadAll(r.Body)
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.requests = append(b.requests, r)
+	b.bodies = append(b.bodies, string(body))
+}
+
+func (b *replayBackend) count() int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return len(b.requests)
+}
+
+func waitFor(t *testing.T, condition func() bool) {
+	t.Helper()
+
+	timeout := time.After(3 * time.Second)
+	for !condition() {
+		select {
+		case <-timeout:
+			t.Fatal("timeout")
+		case <-time.After(5 * time.Millisecond):
+		}
+	}
+}
+
+func TestReplaySynthesize(t *testing.T) {
+	backend := &replayBackend{}
+	server := httptest.NewServer(backend)
+	defer server.Close()
+
+	u, err := url.Parse(server.URL)
+	require.NoError(t, err)
+
+	m := &metricstest.MockMetrics{}
+	spec := NewReplaySynthesize(ReplayOptions{Metrics: m})
+	defer spec.Close()
+
+	f, err := spec.CreateFilter([]interface{}{100.0, writeCaptures(t, testCaptures)})
+	require.NoError(t, err)
+
+	route := &routing.Route{
+		Route:   eskip.Route{Id: "canary"},
+		Scheme:  u.Scheme,
+		Host:    u.Host,
+		Filters: []*routing.RouteFilter{{Filter: f, Name: filters.ReplaySynthesizeName}},
+	}
+
+	spec.PostProcessor().Do([]*routing.Route{route})
+	waitFor(t, func() bool { return backend.count() >= 3 })
+
+	backend.mu.Lock()
+	first, second, third := backend.requests[0], backend.requests[1], backend.requests[2]
+	assert.Equal(t, "GET", first.Method)
+	assert.Equal(t, "/foo", first.URL.Path)
+	assert.Equal(t, "q=1", first.URL.RawQuery)
+	assert.Equal(t, "application/json", first.Header.Get("Accept"))
+	assert.Equal(t, "true", first.Header.Get(ReplaySynthesizedHeader))
+	assert.Equal(t, "POST", second.Method)
+	assert.Equal(t, "/bar", second.URL.Path)
+	assert.Equal(t, "Hello", backend.bodies[1])
+	assert.Equal(t, "/foo", third.URL.Path, "captures are replayed round robin")
+	backend.mu.Unlock()
+
+	// the route is deleted
+	spec.PostProcessor().Do(nil)
+	stopped := backend.count()
+	time.Sleep(50 * time.Millisecond)
+	assert.Equal(t, stopped, backend.count(), "requests synthesized after the route was deleted")
+
+	m.WithCounters(func(counters map[string]int64) {
+		assert.Equal(t, int64(stopped), counters["replaySynthesize.canary.requests"])
+		assert.Zero(t, counters["replaySynthesize.canary.errors"])
+	})
+}
+
+func TestReplaySynthesizeErrorsAndShutdown(t *testing.T) {
+	server := httptest.NewServer(http.NotFoundHandler())
+	u, err := url.Parse(server.URL)
+	require.NoError(t, err)
+	server.Close()
+
+	m := &metricstest.MockMetrics{}
+	spec := NewReplaySynthesize(ReplayOptions{Metrics: m, Timeout: 100 * time.Millisecond})
+
+	f, err := spec.CreateFilter([]interface{}{100.0, writeCaptures(t, testCaptures)})
+	require.NoError(t, err)
+
+	spec.PostProcessor().Do([]*routing.Route{{
+		Route:   eskip.Route{Id: "canary"},
+		Scheme:  u.Scheme,
+		Host:    u.Host,
+		Filters: []*routing.RouteFilter{{Filter: f, Name: filters.ReplaySynthesizeName}},
+	}})
+
+	errors := func() (v int64) {
+		m.WithCounters(func(counters map[string]int64) { v = counters["replaySynthesize.canary.errors"] })
+		return
+	}
+
+	waitFor(t, func() bool { return errors() >= 2 })
+
+	spec.Close()
+	stopped := errors()
+	time.Sleep(50 * time.Millisecond)
+	assert.Equal(t, stopped, errors(), "requests synthesized after shutdown")
+}
+
+func TestReplaySynthesizeCloseCancelsPendingRequest(t *testing.T) {
+	received := make(chan struct{}, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case received <- struct{}{}:
+		default:
+		}
+
+		<-r.Context().Done()
+	}))
+	defer server.Close()
+
+	u, err := url.Parse(server.URL)
+	require.NoError(t, err)
+
+	m := &metricstest.MockMetrics{}
+	spec := NewReplaySynthesize(ReplayOptions{Metrics: m, Timeout: time.Minute})
+	defer spec.Close()
+
+	f, err := spec.CreateFilter([]interface{}{100.0, writeCaptures(t, testCaptures)})
+	require.NoError(t, err)
+
+	spec.PostProcessor().Do([]*routing.Route{{
+		Route:   eskip.Route{Id: "canary"},
+		Scheme:  u.Scheme,
+		Host:    u.Host,
+		Filters: []*routing.RouteFilter{{Filter: f, Name: filters.ReplaySynthesizeName}},
+	}})
+
+	<-received
+
+	// the route is deleted, while the backend holds the request
+	closed := make(chan struct{})
+	go func() {
+		spec.PostProcessor().Do(nil)
+		close(closed)
+	}()
+
+	select {
+	case <-closed:
+	case <-time.After(3 * time.Second):
+		t.Fatal("failed to cancel the pending request")
+	}
+
+	m.WithCounters(func(counters map[string]int64) {
+		assert.Zero(t, counters["replaySynthesize.canary.errors"])
+	})
+}
+
+func TestReplaySynthesizeNoNetworkBackend(t *testing.T) {
+	spec := NewReplaySynthesize(ReplayOptions{Metrics: &metricstest.MockMetrics{}})
+	defer spec.Close()
+
+	f, err := spec.CreateFilter([]interface{}{100.0, writeCaptures(t, testCaptures)})
+	require.NoError(t, err)
+
+	spec.PostProcessor().Do([]*routing.Route{{
+		Route:   eskip.Route{Id: "shunt", BackendType: eskip.ShuntBackend},
+		Filters: []*routing.RouteFilter{{Filter: f, Name: filters.ReplaySynthesizeName}},
+	}})
+
+	assert.False(t, f.(*replayFilter).started)
+}
@@ -195,7 +195,12 @@ func (tt *teeTie) Read(b []byte) (int, error) {
 	return n, err
 }
 
-func (tt *teeTie) Close() error { return nil }
+// Close ends the copy of the body, when it was not read to the end, so that
+// its reader is not blocked.
+func (tt *teeTie) Close() error {
+	tt.w.CloseWithError(io.ErrUnexpectedEOF)
+	return nil
+}
 
 // We do not touch response at all
 func (r *tee) Response(filters.FilterContext) {}
@@ -0,0 +1,150 @@
+package tee
+
+import (
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/cespare/xxhash/v2"
+
+	"github.com/zalando/skipper/filters"
+	"github.com/zalando/skipper/net"
+	teepredicate "github.com/zalando/skipper/predicates/tee"
+)
+
+const coalesceSuppressedMetricsKey = "teeCoalesce.suppressed"
+
+var defaultCoalesceKeySelectors = []string{"method", "host", "path", "query"}
+
+type teeCoalesceSpec struct{}
+
+type teeCoalesceFilter struct {
+	window    time.Duration
+	selectors []net.RequestSelector
+	now       func() time.Time
+
+	mu        sync.Mutex
+	seen      map[uint64]time.Time
+	lastPrune time.Time
+}
+
+// NewTeeCoalesce returns a filter specification whose instances suppress
+// shadow requests, created by the teeLoopback filter, that are identical to
+// a shadow request seen within the configured time window. The identity of
+// a request is defined by the key selectors passed as additional arguments:
+// "method", "host", "path", "query" or "header:<name>", where the first
+// value of the header is used. When no selectors are specified, the method,
+// host, path and query are used.
+//
+// Requests that were not created by teeLoopback are not affected, so the
+// filter does not change the primary request path.
+//
+// Example:
+//
+//	shadow: Tee("A") -> teeCoalesce("1s", "path", "header:Authorization") -> "https://shadow.example.org";
+func NewTeeCoalesce() filters.Spec { return &teeCoalesceSpec{} }
+
+func (*teeCoalesceSpec) Name() string { return filters.TeeCoalesceName }
+
+func (*teeCoalesceSpec) CreateFilter(args []interface{}) (filters.Filter, error) {
+	if len(args) == 0 {
+		return nil, filters.ErrInvalidFilterParameters
+	}
+
+	var window time.Duration
+	switch v := args[0].(type) {
+	case string:
+		d, err := time.ParseDuration(v)
+		if err != nil {
+			return nil, fmt.Errorf("%s: invalid window: %w", filters.TeeCoalesceName, err)
+		}
+		window = d
+	case float64:
+		window = time.Duration(v) * time.Millisecond
+	default:
+		return nil, filters.ErrInvalidFilterParameters
+	}
+
+	if window <= 0 {
+		return nil, filters.ErrInvalidFilterParameters
+	}
+
+	keys := defaultCoalesceKeySelectors
+	if len(args) > 1 {
+		keys = nil
+		for _, a := range args[1:] {
+			s, ok := a.(string)
+			if !ok {
+				return nil, filters.ErrInvalidFilterParameters
+			}
+			keys = append(keys, s)
+		}
+	}
+
+	selectors := make([]net.RequestSelector, 0, len(keys))
+	for _, k := range keys {
+		sel, err := net.ParseRequestSelector(k, "method", "host", "path", "query", "header:")
+		if err != nil {
+			return nil, fmt.Errorf("%s: invalid key selector: %w", filters.TeeCoalesceName, err)
+		}
+		selectors = append(selectors, sel)
+	}
+
+	return &teeCoalesceFilter{
+		window:    window,
+		selectors: selectors,
+		now:       time.Now,
+		seen:      make(map[uint64]time.Time),
+	}, nil
+}
+
+func (f *teeCoalesceFilter) key(r *http.Request) uint64 {
+	d := xxhash.New()
+	for _, sel := range f.selectors {
+		d.WriteString(sel.Value(r))
+		// separator to avoid ambiguity between adjacent values
+		d.Write([]byte{0})
+	}
+
+	return d.Sum64()
+}
+
+// duplicate records the request key and reports whether the same key was
+// already seen within the window.
+func (f *teeCoalesceFilter) duplicate(key uint64) bool {
+	now := f.now()
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if now.Sub(f.lastPrune) > f.window {
+		for k, t := range f.seen {
+			if now.Sub(t) > f.window {
+				delete(f.seen, k)
+			}
+		}
+		f.lastPrune = now
+	}
+
+	if t, ok := f.seen[key]; ok && now.Sub(t) <= f.window {
+		return true
+	}
+
+	f.seen[key] = now
+	return false
+}
+
+func (f *teeCoalesceFilter) Request(ctx filters.FilterContext) {
+	req := ctx.Request()
+	if req.Header.Get(teepredicate.HeaderKey) == "" {
+		return
+	}
+
+	if f.duplicate(f.key(req)) {
+		ctx.Metrics().IncCounter(coalesceSuppressedMetricsKey)
+		ctx.Serve(&http.Response{StatusCode: http.StatusNoContent})
+	}
+}
+
+func (*teeCoalesceFilter) Response(filters.FilterContext) {}
@@ -0,0 +1,115 @@
+package tee
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/zalando/skipper/filters/filtertest"
+	"github.com/zalando/skipper/metrics/metricstest"
+	teepredicate "github.com/zalando/skipper/predicates/tee"
+)
+
+func TestTeeCoalesceCreate(t *testing.T) {
+	for _, tt := range []struct {
+		msg  string
+		args []interface{}
+		err  bool
+	}{{
+		msg: "no arguments",
+		err: true,
+	}, {
+		msg:  "invalid window",
+		args: []interface{}{"foo"},
+		err:  true,
+	}, {
+		msg:  "negative window",
+		args: []interface{}{"-1s"},
+		err:  true,
+	}, {
+		msg:  "invalid key selector",
+		args: []interface{}{"1s", "body"},
+		err:  true,
+	}, {
+		msg:  "empty header selector",
+		args: []interface{}{"1s", "header:"},
+		err:  true,
+	}, {
+		msg:  "window only",
+		args: []interface{}{"1s"},
+	}, {
+		msg:  "window in milliseconds",
+		args: []interface{}{100.0},
+	}, {
+		msg:  "window and selectors",
+		args: []interface{}{"1s", "method", "path", "header:X-Foo"},
+	}} {
+		t.Run(tt.msg, func(t *testing.T) {
+			_, err := NewTeeCoalesce().CreateFilter(tt.args)
+			if tt.err && err == nil {
+				t.Error("expected error")
+			} else if !tt.err && err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+		})
+	}
+}
+
+func TestTeeCoalesce(t *testing.T) {
+	f, err := NewTeeCoalesce().CreateFilter([]interface{}{"1s", "path", "header:X-Foo"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	now := time.Now()
+	cf := f.(*teeCoalesceFilter)
+	cf.now = func() time.Time { return now }
+
+	m := &metricstest.MockMetrics{}
+	serve := func(path, foo string, shadow bool) bool {
+		req, err := http.NewRequest("GET", "https://www.example.org"+path, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		req.Header.Set("X-Foo", foo)
+		if shadow {
+			req.Header.Set(teepredicate.HeaderKey, "A")
+		}
+
+		ctx := &filtertest.Context{FRequest: req, FMetrics: m}
+		f.Request(ctx)
+		return ctx.FServed
+	}
+
+	if serve("/foo", "bar", true) {
+		t.Error("first shadow request suppressed")
+	}
+
+	if !serve("/foo", "bar", true) {
+		t.Error("duplicate shadow request not suppressed")
+	}
+
+	if serve("/foo", "baz", true) {
+		t.Error("shadow request with different header suppressed")
+	}
+
+	if serve("/bar", "bar", true) {
+		t.Error("shadow request with different path suppressed")
+	}
+
+	if serve("/foo", "bar", false) {
+		t.Error("primary request suppressed")
+	}
+
+	now = now.Add(2 * time.Second)
+	if serve("/foo", "bar", true) {
+		t.Error("shadow request suppressed after the window")
+	}
+
+	m.WithCounters(func(counters map[string]int64) {
+		if counters[coalesceSuppressedMetricsKey] != 1 {
+			t.Errorf("expected 1 suppressed request, got: %d", counters[coalesceSuppressedMetricsKey])
+		}
+	})
+}
@@ -0,0 +1,258 @@
+package tee
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"regexp"
+	"time"
+
+	"github.com/segmentio/kafka-go"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/zalando/skipper/filters"
+	"github.com/zalando/skipper/metrics"
+	"github.com/zalando/skipper/routing"
+)
+
+const (
+	// DefaultKafkaProducer is the name of the producer used by the
+	// teeToKafka filters that do not reference a producer explicitly.
+	DefaultKafkaProducer = "default"
+
+	defaultKafkaMaxBodySize = 64 * 1024
+
+	kafkaAsyncErrorsMetricsKey = "teeToKafka.async.errors"
+)
+
+// https://github.com/apache/kafka/blob/trunk/clients/src/main/java/org/apache/kafka/common/internals/Topic.java
+var kafkaTopicName = regexp.MustCompile(`^[a-zA-Z0-9._-]{1,249}$`)
+
+// KafkaProducer publishes messages to a Kafka topic. Publish must not
+// block on the delivery of the message.
+type KafkaProducer interface {
+	Publish(topic string, message []byte) error
+}
+
+// KafkaOptions for the teeToKafka filter.
+type KafkaOptions struct {
+	// Producers maps the producer names, referenced by the filters,
+	// to the producers.
+	Producers map[string]KafkaProducer
+
+	// MaxBodySize caps the size of the request body copied into the
+	// messages, defaults to 64KB.
+	MaxBodySize int64
+}
+
+// KafkaMessage is the serialized copy of a request published by the
+// teeToKafka filter.
+type KafkaMessage struct {
+	Time          time.Time           `json:"time"`
+	Method        string              `json:"method"`
+	Host          string              `json:"host"`
+	Path          string              `json:"path"`
+	Query         string              `json:"query,omitempty"`
+	Headers       map[string][]string `json:"headers,omitempty"`
+	Body          []byte              `json:"body,omitempty"`
+	BodyTruncated bool                `json:"bodyTruncated,omitempty"`
+	Cohort        string              `json:"cohort,omitempty"`
+}
+
+type teeKafkaSpec struct {
+	options KafkaOptions
+}
+
+type teeKafkaFilter struct {
+	topic       string
+	producer    KafkaProducer
+	headers     []string
+	maxBodySize int64
+}
+
+// NewTeeToKafka returns a filter specification whose instances publish a
+// serialized copy of the requests to a Kafka topic, e.g. for offline canary
+// analysis. The copy contains the method, the host, the path and query, the
+// selected headers, and the request body capped to the configured size. The
+// message is published when the request body was consumed by the backend
+// request or it was closed, without blocking the request, and the publish
+// failures are only counted.
+//
+// The first argument is the topic, the optional second argument the name of
+// the producer, defaulting to "default", and the optional further arguments
+// the names of the headers to copy.
+//
+// Example:
+//
+//	canary: TrafficSegment(0.9, 1, "canary") -> teeToKafka("canary-requests", "default", "Content-Type") -> "https://canary.example.org";
+func NewTeeToKafka(o KafkaOptions) filters.Spec {
+	if o.MaxBodySize <= 0 {
+		o.MaxBodySize = defaultKafkaMaxBodySize
+	}
+
+	return &teeKafkaSpec{options: o}
+}
+
+func (*teeKafkaSpec) Name() string { return filters.TeeToKafkaName }
+
+func (s *teeKafkaSpec) CreateFilter(args []interface{}) (filters.Filter, error) {
+	if len(args) == 0 {
+		return nil, filters.ErrInvalidFilterParameters
+	}
+
+	topic, ok := args[0].(string)
+	if !ok {
+		return nil, filters.ErrInvalidFilterParameters
+	}
+
+	if !kafkaTopicName.MatchString(topic) || topic == "." || topic == ".." {
+		return nil, fmt.Errorf("%s: invalid topic name %q", filters.TeeToKafkaName, topic)
+	}
+
+	name := DefaultKafkaProducer
+	if len(args) > 1 {
+		if name, ok = args[1].(string); !ok {
+			return nil, filters.ErrInvalidFilterParameters
+		}
+	}
+
+	producer, ok := s.options.Producers[name]
+	if !ok {
+		return nil, fmt.Errorf("%s: kafka producer %q is not configured", filters.TeeToKafkaName, name)
+	}
+
+	f := &teeKafkaFilter{
+		topic:       topic,
+		producer:    producer,
+		maxBodySize: s.options.MaxBodySize,
+	}
+
+	if len(args) > 2 {
+		args = args[2:]
+	} else {
+		args = nil
+	}
+
+	for _, a := range args {
+		h, ok := a.(string)
+		if !ok {
+			return nil, filters.ErrInvalidFilterParameters
+		}
+
+		f.headers = append(f.headers, http.CanonicalHeaderKey(h))
+	}
+
+	return f, nil
+}
+
+func (f *teeKafkaFilter) Request(ctx filters.FilterContext) {
+	req := ctx.Request()
+	m := &KafkaMessage{
+		Time:   time.Now(),
+		Method: req.Method,
+		Host:   req.Host,
+		Path:   req.URL.Path,
+		Query:  req.URL.RawQuery,
+	}
+
+	for _, h := range f.headers {
+		if v, ok := req.Header[h]; ok {
+			if m.Headers == nil {
+				m.Headers = make(map[string][]string)
+			}
+
+			m.Headers[h] = v
+		}
+	}
+
+	if segment, ok := ctx.StateBag()[filters.TrafficSegmentKey].(routing.TrafficSegment); ok {
+		m.Cohort = segment.Cohort
+	}
+
+	publish := func(body []byte, truncated bool) {
+		m.Body, m.BodyTruncated = body, truncated
+		if err := f.publish(m); err != nil {
+			ctx.Logger().Debugf("%s: failed to publish to topic %s: %v", filters.TeeToKafkaName, f.topic, err)
+			ctx.Metrics().IncCounter(fmt.Sprintf("%s.%s.errors", filters.TeeToKafkaName, f.topic))
+		}
+	}
+
+	// see proxy.go:231
+	if req.Body == nil || req.Body == http.NoBody || req.ContentLength == 0 {
+		publish(nil, false)
+		return
+	}
+
+	// the body is copied the same way as by the tee filter, and the copy is
+	// consumed in the background, while the request body is forwarded
+	pr, pw := io.Pipe()
+	req.Body = &teeTie{req.Body, pw}
+	go func() {
+		body, truncated := readCapped(pr, f.maxBodySize)
+		publish(body, truncated)
+	}()
+}
+
+// readCapped reads the copy of the request body up to max bytes, and drains
+// the rest, so that forwarding the request body is not blocked.
+func readCapped(r io.Reader, max int64) ([]byte, bool) {
+	b, _ := io.ReadAll(io.LimitReader(r, max))
+	n, _ := io.Copy(io.Discard, r)
+	return b, n > 0
+}
+
+func (f *teeKafkaFilter) publish(m *KafkaMessage) error {
+	b, err := json.Marshal(m)
+	if err != nil {
+		return err
+	}
+
+	return f.producer.Publish(f.topic, b)
+}
+
+func (*teeKafkaFilter) Response(filters.FilterContext) {}
+
+// AsyncKafkaProducer is a KafkaProducer writing asynchronously to the
+// Kafka brokers.
+type AsyncKafkaProducer struct {
+	writer *kafka.Writer
+}
+
+// NewKafkaProducer creates a producer writing asynchronously to the given
+// brokers. The delivery failures are counted in the provided metrics.
+func NewKafkaProducer(brokers []string, m metrics.Metrics) (*AsyncKafkaProducer, error) {
+	if len(brokers) == 0 {
+		return nil, fmt.Errorf("no kafka brokers")
+	}
+
+	if m == nil {
+		m = metrics.Default
+	}
+
+	return &AsyncKafkaProducer{
+		writer: &kafka.Writer{
+			Addr:     kafka.TCP(brokers...),
+			Balancer: &kafka.LeastBytes{},
+			Async:    true,
+			Completion: func(messages []kafka.Message, err error) {
+				if err != nil {
+					log.Debugf("Failed to write %d messages to kafka: %v", len(messages), err)
+					m.IncCounterBy(kafkaAsyncErrorsMetricsKey, int64(len(messages)))
+				}
+			},
+		},
+	}, nil
+}
+
+// Publish enqueues the message to the topic, without waiting for the
+// delivery.
+func (p *AsyncKafkaProducer) Publish(topic string, message []byte) error {
+	return p.writer.WriteMessages(context.Background(), kafka.Message{Topic: topic, Value: message})
+}
+
+// Close flushes the pending messages and closes the producer.
+func (p *AsyncKafkaProducer) Close() error {
+	return p.writer.Close()
+}
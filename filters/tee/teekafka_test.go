@@ -0,0 +1,209 @@
+package tee
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/zalando/skipper/filters"
+	"github.com/zalando/skipper/filters/filtertest"
+	"github.com/zalando/skipper/metrics/metricstest"
+	"github.com/zalando/skipper/routing"
+)
+
+type testKafkaProducer struct {
+	mu       sync.Mutex
+	topics   []string
+	messages []KafkaMessage
+	err      error
+}
+
+func (p *testKafkaProducer) Publish(topic string, message []byte) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.err != nil {
+		return p.err
+	}
+
+	var m KafkaMessage
+	if err := json.Unmarshal(message, &m); err != nil {
+		return err
+	}
+
+	p.topics = append(p.topics, topic)
+	p.messages = append(p.messages, m)
+	return nil
+}
+
+func (p *testKafkaProducer) published() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return len(p.messages)
+}
+
+func TestTeeToKafkaCreate(t *testing.T) {
+	spec := NewTeeToKafka(KafkaOptions{Producers: map[string]KafkaProducer{
+		DefaultKafkaProducer: &testKafkaProducer{},
+		"analytics":          &testKafkaProducer{},
+	}})
+
+	for _, tt := range []struct {
+		msg  string
+		args []interface{}
+		err  bool
+	}{{
+		msg: "no args",
+		err: true,
+	}, {
+		msg:  "invalid topic type",
+		args: []interface{}{42.0},
+		err:  true,
+	}, {
+		msg:  "empty topic",
+		args: []interface{}{""},
+		err:  true,
+	}, {
+		msg:  "invalid topic",
+		args: []interface{}{"canary requests"},
+		err:  true,
+	}, {
+		msg:  "dot topic",
+		args: []interface{}{".."},
+		err:  true,
+	}, {
+		msg:  "unknown producer",
+		args: []interface{}{"canary-requests", "foo"},
+		err:  true,
+	}, {
+		msg:  "invalid header",
+		args: []interface{}{"canary-requests", "default", 42.0},
+		err:  true,
+	}, {
+		msg:  "default producer",
+		args: []interface{}{"canary-requests"},
+	}, {
+		msg:  "named producer with headers",
+		args: []interface{}{"canary-requests", "analytics", "Content-Type", "X-Flow-Id"},
+	}} {
+		t.Run(tt.msg, func(t *testing.T) {
+			_, err := spec.CreateFilter(tt.args)
+			if tt.err {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestTeeToKafka(t *testing.T) {
+	for _, tt := range []struct {
+		msg       string
+		body      string
+		maxBody   int64
+		closeOnly bool
+		expected  string
+		truncated bool
+	}{{
+		msg: "no body",
+	}, {
+		msg:      "body",
+		body:     "Hello, world!",
+		expected: "Hello, world!",
+	}, {
+		msg:       "capped body",
+		body:      "Hello, world!",
+		maxBody:   5,
+		expected:  "Hello",
+		truncated: true,
+	}, {
+		msg:       "body closed before consumed",
+		body:      "Hello, world!",
+		closeOnly: true,
+	}} {
+		t.Run(tt.msg, func(t *testing.T) {
+			p := &testKafkaProducer{}
+			spec := NewTeeToKafka(KafkaOptions{
+				Producers:   map[string]KafkaProducer{DefaultKafkaProducer: p},
+				MaxBodySize: tt.maxBody,
+			})
+
+			f, err := spec.CreateFilter([]interface{}{"canary-requests", "default", "content-type"})
+			require.NoError(t, err)
+
+			var body io.Reader
+			if tt.body != "" {
+				body = strings.NewReader(tt.body)
+			}
+
+			req, err := http.NewRequest("POST", "https://www.example.org/foo?bar=baz", body)
+			require.NoError(t, err)
+
+			req.Header.Set("Content-Type", "text/plain")
+			req.Header.Set("Authorization", "Bearer secret")
+
+			ctx := &filtertest.Context{
+				FRequest: req,
+				FStateBag: map[string]interface{}{
+					filters.TrafficSegmentKey: routing.TrafficSegment{Cohort: "canary"},
+				},
+			}
+
+			f.Request(ctx)
+			if tt.body != "" {
+				time.Sleep(10 * time.Millisecond)
+				assert.Zero(t, p.published(), "published before the body was consumed")
+
+				if !tt.closeOnly {
+					b, err := io.ReadAll(req.Body)
+					require.NoError(t, err)
+					assert.Equal(t, tt.body, string(b), "request body was modified")
+				}
+
+				require.NoError(t, req.Body.Close())
+			}
+
+			require.Eventually(t, func() bool { return p.published() == 1 }, time.Second, time.Millisecond)
+			assert.Equal(t, []string{"canary-requests"}, p.topics)
+
+			m := p.messages[0]
+			assert.Equal(t, "POST", m.Method)
+			assert.Equal(t, "www.example.org", m.Host)
+			assert.Equal(t, "/foo", m.Path)
+			assert.Equal(t, "bar=baz", m.Query)
+			assert.Equal(t, map[string][]string{"Content-Type": {"text/plain"}}, m.Headers)
+			assert.Equal(t, "canary", m.Cohort)
+			assert.Equal(t, tt.expected, string(m.Body))
+			assert.Equal(t, tt.truncated, m.BodyTruncated)
+		})
+	}
+}
+
+func TestTeeToKafkaPublishFailure(t *testing.T) {
+	p := &testKafkaProducer{err: errors.New("kafka unavailable")}
+	spec := NewTeeToKafka(KafkaOptions{Producers: map[string]KafkaProducer{DefaultKafkaProducer: p}})
+
+	f, err := spec.CreateFilter([]interface{}{"canary-requests"})
+	require.NoError(t, err)
+
+	req, err := http.NewRequest("GET", "https://www.example.org/foo", nil)
+	require.NoError(t, err)
+
+	m := &metricstest.MockMetrics{}
+	ctx := &filtertest.Context{FRequest: req, FMetrics: m, FStateBag: make(map[string]interface{})}
+	f.Request(ctx)
+
+	assert.False(t, ctx.FServed)
+	m.WithCounters(func(counters map[string]int64) {
+		assert.Equal(t, int64(1), counters["teeToKafka.canary-requests.errors"])
+	})
+}
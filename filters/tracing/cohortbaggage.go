@@ -0,0 +1,70 @@
+package tracing
+
+import (
+	"fmt"
+
+	"github.com/opentracing/opentracing-go"
+	"golang.org/x/net/http/httpguts"
+
+	"github.com/zalando/skipper/filters"
+	"github.com/zalando/skipper/routing"
+)
+
+type cohortBaggageSpec struct{}
+
+type cohortBaggageFilter struct {
+	key string
+}
+
+// NewCohortBaggage creates a filter specification whose instances
+// write the traffic cohort of the matched route, as defined by the
+// TrafficSegment predicate, into the baggage of the active trace, so that
+// the upstream services inherit the cohort decision.
+//
+// When the request has no active span, or the route has no cohort, the
+// filter does nothing.
+//
+// Example:
+//
+//	canary: TrafficSegment(0.9, 1, "canary") -> setCohortBaggage("cohort") -> "https://canary.example.org";
+func NewCohortBaggage() filters.Spec {
+	return cohortBaggageSpec{}
+}
+
+func (cohortBaggageSpec) Name() string {
+	return filters.SetCohortBaggageName
+}
+
+func (cohortBaggageSpec) CreateFilter(args []interface{}) (filters.Filter, error) {
+	if len(args) != 1 {
+		return nil, filters.ErrInvalidFilterParameters
+	}
+
+	key, ok := args[0].(string)
+	if !ok {
+		return nil, filters.ErrInvalidFilterParameters
+	}
+
+	// baggage items are propagated e.g. as HTTP headers
+	if !httpguts.ValidHeaderFieldName(key) {
+		return nil, fmt.Errorf("baggage key %s is invalid", key)
+	}
+
+	return cohortBaggageFilter{key: key}, nil
+}
+
+func (f cohortBaggageFilter) Request(ctx filters.FilterContext) {
+	segment, ok := ctx.StateBag()[filters.TrafficSegmentKey].(routing.TrafficSegment)
+	if !ok || segment.Cohort == "" {
+		return
+	}
+
+	span := opentracing.SpanFromContext(ctx.Request().Context())
+	if span == nil {
+		return
+	}
+
+	span.SetBaggageItem(f.key, segment.Cohort)
+}
+
+func (cohortBaggageFilter) Response(filters.FilterContext) {}
@@ -0,0 +1,97 @@
+package tracing
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/opentracing/opentracing-go"
+	"github.com/zalando/skipper/filters"
+	"github.com/zalando/skipper/filters/filtertest"
+	"github.com/zalando/skipper/routing"
+	"github.com/zalando/skipper/tracing/tracingtest"
+)
+
+func TestCohortBaggageCreate(t *testing.T) {
+	for _, ti := range []struct {
+		msg  string
+		args []interface{}
+		err  bool
+	}{{
+		msg: "no args",
+		err: true,
+	}, {
+		msg:  "too many args",
+		args: []interface{}{"cohort", "foo"},
+		err:  true,
+	}, {
+		msg:  "not a string",
+		args: []interface{}{42.0},
+		err:  true,
+	}, {
+		msg:  "empty key",
+		args: []interface{}{""},
+		err:  true,
+	}, {
+		msg:  "invalid key",
+		args: []interface{}{"my cohort"},
+		err:  true,
+	}, {
+		msg:  "valid key",
+		args: []interface{}{"cohort"},
+	}} {
+		t.Run(ti.msg, func(t *testing.T) {
+			_, err := NewCohortBaggage().CreateFilter(ti.args)
+			if ti.err && err == nil {
+				t.Error("failed to fail")
+			} else if !ti.err && err != nil {
+				t.Error(err)
+			}
+		})
+	}
+}
+
+func TestCohortBaggage(t *testing.T) {
+	for _, ti := range []struct {
+		msg      string
+		segment  interface{}
+		noSpan   bool
+		expected string
+	}{{
+		msg:      "cohort",
+		segment:  routing.TrafficSegment{Min: 0.9, Max: 1, Cohort: "canary", Random: 0.95},
+		expected: "canary",
+	}, {
+		msg:     "no cohort",
+		segment: routing.TrafficSegment{Min: 0, Max: 0.9, Random: 0.5},
+	}, {
+		msg: "no segment",
+	}, {
+		msg:     "no active span",
+		segment: routing.TrafficSegment{Min: 0.9, Max: 1, Cohort: "canary", Random: 0.95},
+		noSpan:  true,
+	}} {
+		t.Run(ti.msg, func(t *testing.T) {
+			f, err := NewCohortBaggage().CreateFilter([]interface{}{"cohort"})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			req := &http.Request{Header: http.Header{}}
+			span := tracingtest.NewSpan("start_span")
+			if !ti.noSpan {
+				req = req.WithContext(opentracing.ContextWithSpan(req.Context(), span))
+			}
+
+			ctx := &filtertest.Context{FRequest: req, FStateBag: make(map[string]interface{})}
+			if ti.segment != nil {
+				ctx.FStateBag[filters.TrafficSegmentKey] = ti.segment
+			}
+
+			f.Request(ctx)
+
+			if v := span.BaggageItem("cohort"); v != ti.expected {
+				t.Errorf("expected baggage item: %q, got: %q", ti.expected, v)
+			}
+		})
+	}
+}
@@ -0,0 +1,67 @@
+package tracing
+
+import (
+	"github.com/opentracing/opentracing-go"
+	"github.com/opentracing/opentracing-go/log"
+
+	"github.com/zalando/skipper/filters"
+	"github.com/zalando/skipper/routing"
+)
+
+const cohortAssignmentEvent = "cohort_assignment"
+
+type cohortEventSpec struct{}
+
+type cohortEventFilter struct{}
+
+// NewCohortEvent creates a filter specification whose instances log an
+// event on the active span, when the request was assigned to the traffic
+// segment of the matched route, as defined by the TrafficSegment
+// predicate. The event contains the cohort, the random value of the
+// request and the interval of the segment, to correlate the split decision
+// with the timing of the downstream spans.
+//
+// When the request has no active span, or the route has no traffic
+// segment, the filter does nothing.
+//
+// Example:
+//
+//	canary: TrafficSegment(0.9, 1, "canary") -> traceCohortEvent() -> "https://canary.example.org";
+func NewCohortEvent() filters.Spec {
+	return cohortEventSpec{}
+}
+
+func (cohortEventSpec) Name() string {
+	return filters.TraceCohortEventName
+}
+
+func (cohortEventSpec) CreateFilter(args []interface{}) (filters.Filter, error) {
+	if len(args) != 0 {
+		return nil, filters.ErrInvalidFilterParameters
+	}
+
+	return cohortEventFilter{}, nil
+}
+
+func (cohortEventFilter) Request(ctx filters.FilterContext) {
+	segment, ok := ctx.StateBag()[filters.TrafficSegmentKey].(routing.TrafficSegment)
+	if !ok {
+		return
+	}
+
+	span := opentracing.SpanFromContext(ctx.Request().Context())
+	if span == nil {
+		return
+	}
+
+	span.LogFields(
+		log.String("event", cohortAssignmentEvent),
+		log.String("cohort", segment.Cohort),
+		log.String("route", segment.RouteId),
+		log.Float64("random", segment.Random),
+		log.Float64("min", segment.Min),
+		log.Float64("max", segment.Max),
+	)
+}
+
+func (cohortEventFilter) Response(filters.FilterContext) {}
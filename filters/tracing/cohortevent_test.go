@@ -0,0 +1,109 @@
+package tracing
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/opentracing/opentracing-go"
+	"github.com/opentracing/opentracing-go/mocktracer"
+	"github.com/zalando/skipper/filters"
+	"github.com/zalando/skipper/filters/filtertest"
+	"github.com/zalando/skipper/routing"
+)
+
+func TestCohortEventCreate(t *testing.T) {
+	if _, err := NewCohortEvent().CreateFilter(nil); err != nil {
+		t.Error(err)
+	}
+
+	if _, err := NewCohortEvent().CreateFilter([]interface{}{"cohort"}); err == nil {
+		t.Error("failed to fail")
+	}
+}
+
+func TestCohortEvent(t *testing.T) {
+	tracer := mocktracer.New()
+	for _, ti := range []struct {
+		msg      string
+		segment  interface{}
+		noSpan   bool
+		expected map[string]string
+	}{{
+		msg:     "cohort",
+		segment: routing.TrafficSegment{Min: 0.9, Max: 1, Cohort: "canary", Random: 0.95, RouteId: "canary"},
+		expected: map[string]string{
+			"event":  cohortAssignmentEvent,
+			"cohort": "canary",
+			"route":  "canary",
+			"random": "0.95",
+			"min":    "0.9",
+			"max":    "1",
+		},
+	}, {
+		msg:     "no cohort",
+		segment: routing.TrafficSegment{Min: 0, Max: 0.9, Random: 0.5, RouteId: "main"},
+		expected: map[string]string{
+			"event":  cohortAssignmentEvent,
+			"cohort": "",
+			"route":  "main",
+			"random": "0.5",
+			"min":    "0",
+			"max":    "0.9",
+		},
+	}, {
+		msg: "no segment",
+	}, {
+		msg:     "no active span",
+		segment: routing.TrafficSegment{Min: 0.9, Max: 1, Cohort: "canary", Random: 0.95},
+		noSpan:  true,
+	}} {
+		t.Run(ti.msg, func(t *testing.T) {
+			f, err := NewCohortEvent().CreateFilter(nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			req := &http.Request{Header: http.Header{}}
+			span := tracer.StartSpan("proxy").(*mocktracer.MockSpan)
+			defer span.Finish()
+			if !ti.noSpan {
+				req = req.WithContext(opentracing.ContextWithSpan(req.Context(), span))
+			}
+
+			ctx := &filtertest.Context{FRequest: req, FStateBag: make(map[string]interface{})}
+			if ti.segment != nil {
+				ctx.FStateBag[filters.TrafficSegmentKey] = ti.segment
+			}
+
+			f.Request(ctx)
+
+			logs := span.Logs()
+			if ti.expected == nil {
+				if len(logs) != 0 {
+					t.Errorf("unexpected logs: %v", logs)
+				}
+
+				return
+			}
+
+			if len(logs) != 1 {
+				t.Fatalf("expected one log record, got: %d", len(logs))
+			}
+
+			fields := make(map[string]string)
+			for _, f := range logs[0].Fields {
+				fields[f.Key] = f.ValueString
+			}
+
+			if len(fields) != len(ti.expected) {
+				t.Errorf("unexpected fields: %v", fields)
+			}
+
+			for k, v := range ti.expected {
+				if fields[k] != v {
+					t.Errorf("unexpected field %s: %q, expected: %q", k, fields[k], v)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,72 @@
+package tracing
+
+import (
+	"github.com/opentracing/opentracing-go"
+
+	"github.com/zalando/skipper/filters"
+	"github.com/zalando/skipper/routing"
+)
+
+const cohortTag = "cohort"
+
+type cohortTagSampledSpec struct{}
+
+type cohortTagSampledFilter struct{}
+
+// samplingContext is implemented by the span contexts of the tracers that
+// expose their sampling decision, e.g. Jaeger.
+type samplingContext interface {
+	IsSampled() bool
+}
+
+// NewCohortTagSampled creates a filter specification whose instances tag
+// the active span with the traffic cohort of the matched route, as defined
+// by the TrafficSegment predicate, only when the trace is sampled, to avoid
+// the overhead of the tag on the unsampled traces.
+//
+// When the request has no active span, the trace is not sampled, or the
+// route has no cohort, the filter does nothing. When the tracer doesn't
+// expose its sampling decision, the trace is considered sampled.
+//
+// Example:
+//
+//	canary: TrafficSegment(0.9, 1, "canary") -> cohortTagSampled() -> "https://canary.example.org";
+func NewCohortTagSampled() filters.Spec {
+	return cohortTagSampledSpec{}
+}
+
+func (cohortTagSampledSpec) Name() string {
+	return filters.CohortTagSampledName
+}
+
+func (cohortTagSampledSpec) CreateFilter(args []interface{}) (filters.Filter, error) {
+	if len(args) != 0 {
+		return nil, filters.ErrInvalidFilterParameters
+	}
+
+	return cohortTagSampledFilter{}, nil
+}
+
+func isSampled(span opentracing.Span) bool {
+	if sc, ok := span.Context().(samplingContext); ok {
+		return sc.IsSampled()
+	}
+
+	return true
+}
+
+func (cohortTagSampledFilter) Request(ctx filters.FilterContext) {
+	segment, ok := ctx.StateBag()[filters.TrafficSegmentKey].(routing.TrafficSegment)
+	if !ok || segment.Cohort == "" {
+		return
+	}
+
+	span := opentracing.SpanFromContext(ctx.Request().Context())
+	if span == nil || !isSampled(span) {
+		return
+	}
+
+	span.SetTag(cohortTag, segment.Cohort)
+}
+
+func (cohortTagSampledFilter) Response(filters.FilterContext) {}
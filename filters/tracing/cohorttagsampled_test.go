@@ -0,0 +1,109 @@
+package tracing
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/opentracing/opentracing-go"
+	"github.com/opentracing/opentracing-go/mocktracer"
+	"github.com/zalando/skipper/filters"
+	"github.com/zalando/skipper/filters/filtertest"
+	"github.com/zalando/skipper/routing"
+)
+
+type samplingSpanContext struct {
+	mocktracer.MockSpanContext
+	sampled bool
+}
+
+func (c samplingSpanContext) IsSampled() bool { return c.sampled }
+
+// samplingSpan exposes the sampling decision, like e.g. the Jaeger spans.
+type samplingSpan struct {
+	*mocktracer.MockSpan
+	sampled bool
+}
+
+func (s samplingSpan) Context() opentracing.SpanContext {
+	return samplingSpanContext{MockSpanContext: s.MockSpan.SpanContext, sampled: s.sampled}
+}
+
+func TestCohortTagSampledCreate(t *testing.T) {
+	if _, err := NewCohortTagSampled().CreateFilter(nil); err != nil {
+		t.Error(err)
+	}
+
+	if _, err := NewCohortTagSampled().CreateFilter([]interface{}{"cohort"}); err == nil {
+		t.Error("failed to fail")
+	}
+}
+
+func TestCohortTagSampled(t *testing.T) {
+	tracer := mocktracer.New()
+	for _, ti := range []struct {
+		msg      string
+		segment  interface{}
+		noSpan   bool
+		sampling bool
+		sampled  bool
+		expected interface{}
+	}{{
+		msg:      "sampled",
+		segment:  routing.TrafficSegment{Min: 0.9, Max: 1, Cohort: "canary"},
+		sampling: true,
+		sampled:  true,
+		expected: "canary",
+	}, {
+		msg:      "not sampled",
+		segment:  routing.TrafficSegment{Min: 0.9, Max: 1, Cohort: "canary"},
+		sampling: true,
+	}, {
+		msg:      "unknown sampling decision",
+		segment:  routing.TrafficSegment{Min: 0.9, Max: 1, Cohort: "canary"},
+		expected: "canary",
+	}, {
+		msg:      "no cohort",
+		segment:  routing.TrafficSegment{Min: 0, Max: 0.9},
+		sampling: true,
+		sampled:  true,
+	}, {
+		msg:      "no segment",
+		sampling: true,
+		sampled:  true,
+	}, {
+		msg:     "no active span",
+		segment: routing.TrafficSegment{Min: 0.9, Max: 1, Cohort: "canary"},
+		noSpan:  true,
+	}} {
+		t.Run(ti.msg, func(t *testing.T) {
+			f, err := NewCohortTagSampled().CreateFilter(nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			req := &http.Request{Header: http.Header{}}
+			span := tracer.StartSpan("proxy").(*mocktracer.MockSpan)
+			defer span.Finish()
+
+			var active opentracing.Span = span
+			if ti.sampling {
+				active = samplingSpan{MockSpan: span, sampled: ti.sampled}
+			}
+
+			if !ti.noSpan {
+				req = req.WithContext(opentracing.ContextWithSpan(req.Context(), active))
+			}
+
+			ctx := &filtertest.Context{FRequest: req, FStateBag: make(map[string]interface{})}
+			if ti.segment != nil {
+				ctx.FStateBag[filters.TrafficSegmentKey] = ti.segment
+			}
+
+			f.Request(ctx)
+
+			if tag := span.Tag(cohortTag); tag != ti.expected {
+				t.Errorf("unexpected cohort tag: %v, expected: %v", tag, ti.expected)
+			}
+		})
+	}
+}
@@ -0,0 +1,83 @@
+/*
+Package window implements a counter of events in a sliding time window,
+shared by the filters and predicates evaluating the recent responses of a
+route, like autoKill, cohortApdex and ErrorRateBelow.
+*/
+package window
+
+import "time"
+
+// Buckets is the number of the buckets approximating the window.
+const Buckets = 10
+
+type bucket struct {
+	start  time.Time
+	counts []int
+}
+
+// Counter counts events of a fixed number of kinds within a sliding window.
+// The window is approximated by buckets, so the events leave the window in
+// steps of a tenth of its duration. A Counter is not safe for concurrent
+// use.
+type Counter struct {
+	window  time.Duration
+	size    time.Duration
+	buckets [Buckets]bucket
+}
+
+// New creates a counter of the given number of event kinds.
+func New(window time.Duration, kinds int) *Counter {
+	c := &Counter{window: window, size: window / Buckets}
+	if c.size <= 0 {
+		c.size = 1
+	}
+
+	for i := range c.buckets {
+		c.buckets[i].counts = make([]int, kinds)
+	}
+
+	return c
+}
+
+// Window returns the duration of the window.
+func (c *Counter) Window() time.Duration {
+	return c.window
+}
+
+// Inc counts an event of the kind.
+func (c *Counter) Inc(now time.Time, kind int) {
+	start := now.Truncate(c.size)
+	b := &c.buckets[(start.UnixNano()/int64(c.size))%Buckets]
+	if !b.start.Equal(start) {
+		b.start = start
+		for i := range b.counts {
+			b.counts[i] = 0
+		}
+	}
+
+	b.counts[kind]++
+}
+
+// Sum returns the count of the events of every kind within the window.
+func (c *Counter) Sum(now time.Time) []int {
+	sum := make([]int, len(c.buckets[0].counts))
+	for _, b := range c.buckets {
+		if now.Sub(b.start) < c.window {
+			for i, n := range b.counts {
+				sum[i] += n
+			}
+		}
+	}
+
+	return sum
+}
+
+// Reset drops all the counted events.
+func (c *Counter) Reset() {
+	for i := range c.buckets {
+		c.buckets[i].start = time.Time{}
+		for j := range c.buckets[i].counts {
+			c.buckets[i].counts[j] = 0
+		}
+	}
+}
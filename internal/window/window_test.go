@@ -0,0 +1,65 @@
+package window
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestCounter(t *testing.T) {
+	const (
+		requests = iota
+		errors
+	)
+
+	now := time.Date(2023, 6, 1, 12, 0, 0, 0, time.UTC)
+	c := New(time.Minute, 2)
+	if c.Window() != time.Minute {
+		t.Fatalf("unexpected window: %v", c.Window())
+	}
+
+	if sum := c.Sum(now); !reflect.DeepEqual(sum, []int{0, 0}) {
+		t.Fatalf("unexpected initial counts: %v", sum)
+	}
+
+	c.Inc(now, requests)
+	c.Inc(now, requests)
+	c.Inc(now, errors)
+
+	now = now.Add(30 * time.Second)
+	c.Inc(now, requests)
+	if sum := c.Sum(now); !reflect.DeepEqual(sum, []int{3, 1}) {
+		t.Fatalf("failed to count within the window: %v", sum)
+	}
+
+	// the first bucket leaves the window
+	now = now.Add(30 * time.Second)
+	if sum := c.Sum(now); !reflect.DeepEqual(sum, []int{1, 0}) {
+		t.Fatalf("failed to slide the window: %v", sum)
+	}
+
+	// the bucket of the first events is reused a window later
+	c.Inc(now, errors)
+	if sum := c.Sum(now); !reflect.DeepEqual(sum, []int{1, 1}) {
+		t.Fatalf("failed to reuse the bucket: %v", sum)
+	}
+
+	c.Reset()
+	if sum := c.Sum(now); !reflect.DeepEqual(sum, []int{0, 0}) {
+		t.Fatalf("failed to reset: %v", sum)
+	}
+
+	now = now.Add(2 * time.Minute)
+	if sum := c.Sum(now); !reflect.DeepEqual(sum, []int{0, 0}) {
+		t.Fatalf("unexpected counts after the window: %v", sum)
+	}
+}
+
+func TestCounterShortWindow(t *testing.T) {
+	now := time.Date(2023, 6, 1, 12, 0, 0, 0, time.UTC)
+	c := New(5, 1)
+	c.Inc(now, 0)
+	if sum := c.Sum(now); sum[0] != 1 {
+		t.Fatalf("failed to count with a window shorter than the buckets: %v", sum)
+	}
+}
@@ -0,0 +1,27 @@
+package net
+
+import (
+	"bytes"
+	"io"
+)
+
+type restoredBody struct {
+	io.Reader
+	io.Closer
+}
+
+// BufferBody reads the body, when it is not larger than maxBytes. When the
+// body fits, it is closed, and its content is returned. When it is too
+// large, or it cannot be read, BufferBody returns false, and the body to be
+// used instead of the original one: it returns the content read so far,
+// and then the rest of the original body, or its error again.
+func BufferBody(body io.ReadCloser, maxBytes int64) ([]byte, io.ReadCloser, bool) {
+	var buf bytes.Buffer
+	n, err := io.CopyN(&buf, body, maxBytes+1)
+	if err != io.EOF || n > maxBytes {
+		return nil, &restoredBody{Reader: io.MultiReader(&buf, body), Closer: body}, false
+	}
+
+	body.Close()
+	return buf.Bytes(), nil, true
+}
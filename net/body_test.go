@@ -0,0 +1,64 @@
+package net
+
+import (
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"testing/iotest"
+)
+
+type testBody struct {
+	io.Reader
+	closed bool
+}
+
+func (b *testBody) Close() error {
+	b.closed = true
+	return nil
+}
+
+func TestBufferBody(t *testing.T) {
+	t.Run("fits", func(t *testing.T) {
+		body := &testBody{Reader: strings.NewReader("foobar")}
+		b, restored, ok := BufferBody(body, 6)
+		if !ok || string(b) != "foobar" || restored != nil {
+			t.Fatalf("failed to buffer the body: %q, %v", b, ok)
+		}
+
+		if !body.closed {
+			t.Error("failed to close the body")
+		}
+	})
+
+	t.Run("too large", func(t *testing.T) {
+		body := &testBody{Reader: strings.NewReader("foobarbaz")}
+		_, restored, ok := BufferBody(body, 6)
+		if ok {
+			t.Fatal("failed to detect the large body")
+		}
+
+		b, err := io.ReadAll(restored)
+		if err != nil || string(b) != "foobarbaz" {
+			t.Fatalf("failed to restore the body: %q, %v", b, err)
+		}
+
+		restored.Close()
+		if !body.closed {
+			t.Error("failed to close the original body")
+		}
+	})
+
+	t.Run("failing", func(t *testing.T) {
+		errRead := errors.New("test error")
+		body := &testBody{Reader: io.MultiReader(strings.NewReader("foo"), iotest.ErrReader(errRead))}
+		_, restored, ok := BufferBody(body, 6)
+		if ok {
+			t.Fatal("failed to detect the failing body")
+		}
+
+		if b, err := io.ReadAll(restored); err != errRead || string(b) != "foo" {
+			t.Fatalf("failed to restore the body: %q, %v", b, err)
+		}
+	})
+}
@@ -0,0 +1,50 @@
+package net
+
+import (
+	"errors"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// DefaultMaxBodySize is the default limit of the request and response
+// bodies that the filters and predicates read into memory.
+const DefaultMaxBodySize = 1 << 20
+
+// ParseByteSize parses sizes like 1024, "512B", "16KB" or "2MB". The
+// units are based on 1024. Negative sizes and sizes overflowing int64 are
+// rejected.
+func ParseByteSize(s string) (int64, error) {
+	s = strings.ToUpper(strings.TrimSpace(s))
+	multiplier := int64(1)
+	for _, u := range []struct {
+		suffix     string
+		multiplier int64
+	}{
+		{"KB", 1 << 10},
+		{"MB", 1 << 20},
+		{"GB", 1 << 30},
+		{"B", 1},
+	} {
+		if strings.HasSuffix(s, u.suffix) {
+			s = strings.TrimSpace(strings.TrimSuffix(s, u.suffix))
+			multiplier = u.multiplier
+			break
+		}
+	}
+
+	n, err := strconv.ParseInt(s, 10, 64)
+	if err != nil {
+		return 0, err
+	}
+
+	if n < 0 {
+		return 0, errors.New("negative size")
+	}
+
+	if n > math.MaxInt64/multiplier {
+		return 0, errors.New("size too large")
+	}
+
+	return n * multiplier, nil
+}
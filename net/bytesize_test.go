@@ -0,0 +1,46 @@
+package net
+
+import "testing"
+
+func TestParseByteSize(t *testing.T) {
+	for _, ti := range []struct {
+		size     string
+		expected int64
+		fail     bool
+	}{
+		{size: "1024", expected: 1024},
+		{size: "512B", expected: 512},
+		{size: "16KB", expected: 16 << 10},
+		{size: " 2 mb ", expected: 2 << 20},
+		{size: "3GB", expected: 3 << 30},
+		{size: "0", expected: 0},
+		{size: "", fail: true},
+		{size: "KB", fail: true},
+		{size: "1.5MB", fail: true},
+		{size: "10TB", fail: true},
+		{size: "-1KB", fail: true},
+		{size: "-1", fail: true},
+		{size: "9223372036854775807", expected: 9223372036854775807},
+		{size: "9223372036854775807KB", fail: true},
+		{size: "8589934592GB", fail: true},
+	} {
+		t.Run(ti.size, func(t *testing.T) {
+			n, err := ParseByteSize(ti.size)
+			if ti.fail {
+				if err == nil {
+					t.Fatalf("failed to fail, got: %d", n)
+				}
+
+				return
+			}
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if n != ti.expected {
+				t.Errorf("unexpected size: %d, expected: %d", n, ti.expected)
+			}
+		})
+	}
+}
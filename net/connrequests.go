@@ -0,0 +1,50 @@
+package net
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"sync/atomic"
+)
+
+type (
+	connRequestsKey      struct{}
+	connRequestNumberKey struct{}
+)
+
+// connRequests holds the count of the requests served on an incoming
+// connection.
+type connRequests struct {
+	served int64
+}
+
+// ConnContext prepares the context of an incoming connection for numbering
+// its requests, see ServeConnRequest. It is meant to be used as the
+// ConnContext function of an http.Server.
+func ConnContext(ctx context.Context, _ net.Conn) context.Context {
+	return context.WithValue(ctx, connRequestsKey{}, &connRequests{})
+}
+
+// ServeConnRequest numbers the request in the order of the requests served
+// on its incoming connection, starting from 1, and returns the request with
+// the number stored in its context, see ConnRequestNumber. It is meant to
+// be called once per request, and it returns the request unchanged, when
+// the server didn't prepare the connection with ConnContext.
+func ServeConnRequest(r *http.Request) *http.Request {
+	c, ok := r.Context().Value(connRequestsKey{}).(*connRequests)
+	if !ok {
+		return r
+	}
+
+	n := atomic.AddInt64(&c.served, 1)
+	return r.WithContext(context.WithValue(r.Context(), connRequestNumberKey{}, n))
+}
+
+// ConnRequestNumber returns the number of the request on its incoming
+// connection, as set by ServeConnRequest. The number is greater than 1 for
+// the requests of a reused, keep-alive connection. It returns false, when
+// the request was not numbered.
+func ConnRequestNumber(r *http.Request) (int64, bool) {
+	n, ok := r.Context().Value(connRequestNumberKey{}).(int64)
+	return n, ok
+}
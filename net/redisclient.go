@@ -400,6 +400,11 @@ func (r *RedisRingClient) Set(ctx context.Context, key string, value interface{}
 	return res.Result()
 }
 
+func (r *RedisRingClient) Del(ctx context.Context, keys ...string) (int64, error) {
+	res := r.ring.Del(ctx, keys...)
+	return res.Val(), res.Err()
+}
+
 func (r *RedisRingClient) ZAdd(ctx context.Context, key string, val int64, score float64) (int64, error) {
 	res := r.ring.ZAdd(ctx, key, redis.Z{Member: val, Score: score})
 	return res.Val(), res.Err()
@@ -510,6 +510,32 @@ func TestRedisClientZAddZCard(t *testing.T) {
 	}
 }
 
+func TestRedisClientDel(t *testing.T) {
+	redisAddr, done := redistest.NewTestRedis(t)
+	defer done()
+
+	cli := NewRedisRingClient(&RedisOptions{Addrs: []string{redisAddr}})
+	defer cli.Close()
+
+	ctx := context.Background()
+	if _, err := cli.Set(ctx, "k1", "foo", 0); err != nil {
+		t.Fatalf("Failed to set: %v", err)
+	}
+
+	n, err := cli.Del(ctx, "k1", "k2")
+	if err != nil {
+		t.Fatalf("Failed to delete: %v", err)
+	}
+
+	if n != 1 {
+		t.Errorf("Unexpected number of deleted keys: %d", n)
+	}
+
+	if _, err := cli.Get(ctx, "k1"); err == nil {
+		t.Error("Failed to delete the key")
+	}
+}
+
 func TestRedisClientExpire(t *testing.T) {
 	redisAddr, done := redistest.NewTestRedis(t)
 	defer done()
@@ -0,0 +1,84 @@
+package net
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// RequestSelector selects the value of a request attribute. It is parsed
+// from the syntax used by the filter and predicate arguments: "method",
+// "host", "path", "query" for the raw query, or "header:<name>",
+// "cookie:<name>" and "query:<name>" for a named value.
+type RequestSelector struct {
+	kind string
+	name string
+}
+
+// ParseRequestSelector parses a request selector, accepting only the kinds
+// listed in allowed. The kinds selecting a named value are listed with a
+// trailing colon, e.g. "header:".
+func ParseRequestSelector(s string, allowed ...string) (RequestSelector, error) {
+	kind, name, named := strings.Cut(s, ":")
+	if named {
+		if name == "" {
+			return RequestSelector{}, fmt.Errorf("invalid request selector: %q", s)
+		}
+
+		kind += ":"
+	}
+
+	var ok bool
+	for _, a := range allowed {
+		if a == kind {
+			ok = true
+			break
+		}
+	}
+
+	if !ok {
+		return RequestSelector{}, fmt.Errorf("invalid request selector: %q", s)
+	}
+
+	switch kind {
+	case "method", "host", "path", "query":
+	case "header:":
+		name = http.CanonicalHeaderKey(name)
+	case "cookie:", "query:":
+	default:
+		return RequestSelector{}, fmt.Errorf("invalid request selector: %q", s)
+	}
+
+	return RequestSelector{kind: kind, name: name}, nil
+}
+
+// String returns the selector in its canonical form.
+func (s RequestSelector) String() string {
+	return s.kind + s.name
+}
+
+// Value returns the selected value of the request, or an empty string,
+// when the request doesn't have it. For headers, the first value is
+// returned.
+func (s RequestSelector) Value(r *http.Request) string {
+	switch s.kind {
+	case "method":
+		return r.Method
+	case "host":
+		return r.Host
+	case "path":
+		return r.URL.Path
+	case "query":
+		return r.URL.RawQuery
+	case "header:":
+		return r.Header.Get(s.name)
+	case "cookie:":
+		if c, err := r.Cookie(s.name); err == nil {
+			return c.Value
+		}
+	case "query:":
+		return r.URL.Query().Get(s.name)
+	}
+
+	return ""
+}
@@ -0,0 +1,78 @@
+package net
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestParseRequestSelector(t *testing.T) {
+	all := []string{"method", "host", "path", "query", "header:", "cookie:", "query:"}
+	for _, ti := range []struct {
+		selector string
+		allowed  []string
+		expected string
+		fail     bool
+	}{
+		{selector: "path", allowed: all, expected: "path"},
+		{selector: "query", allowed: all, expected: "query"},
+		{selector: "header:x-api-key", allowed: all, expected: "header:X-Api-Key"},
+		{selector: "cookie:SID", allowed: all, expected: "cookie:SID"},
+		{selector: "query:id", allowed: all, expected: "query:id"},
+		{selector: "header:", allowed: all, fail: true},
+		{selector: "foo", allowed: all, fail: true},
+		{selector: "foo:bar", allowed: all, fail: true},
+		{selector: "path:foo", allowed: all, fail: true},
+		{selector: "path", allowed: []string{"header:", "cookie:"}, fail: true},
+		{selector: "query:id", allowed: []string{"query"}, fail: true},
+		{selector: "header:X-Foo", allowed: nil, fail: true},
+	} {
+		t.Run(ti.selector, func(t *testing.T) {
+			s, err := ParseRequestSelector(ti.selector, ti.allowed...)
+			if ti.fail {
+				if err == nil {
+					t.Fatal("failed to fail")
+				}
+
+				return
+			}
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if s.String() != ti.expected {
+				t.Errorf("unexpected selector: %s, expected: %s", s, ti.expected)
+			}
+		})
+	}
+}
+
+func TestRequestSelectorValue(t *testing.T) {
+	r := httptest.NewRequest("POST", "https://www.example.org/foo?id=42&x=y", nil)
+	r.Header.Add("X-Foo", "bar")
+	r.Header.Add("X-Foo", "baz")
+	r.AddCookie(&http.Cookie{Name: "SID", Value: "s3ss10n"})
+
+	for selector, expected := range map[string]string{
+		"method":       "POST",
+		"host":         "www.example.org",
+		"path":         "/foo",
+		"query":        "id=42&x=y",
+		"header:x-foo": "bar",
+		"header:X-Bar": "",
+		"cookie:SID":   "s3ss10n",
+		"cookie:foo":   "",
+		"query:id":     "42",
+		"query:foo":    "",
+	} {
+		s, err := ParseRequestSelector(selector, "method", "host", "path", "query", "header:", "cookie:", "query:")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if v := s.Value(r); v != expected {
+			t.Errorf("unexpected value of %s: %q, expected: %q", selector, v, expected)
+		}
+	}
+}
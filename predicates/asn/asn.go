@@ -0,0 +1,91 @@
+/*
+Package asn implements a predicate to match routes based on the
+autonomous system number (ASN) of the client IP address, looked up in a
+MaxMind ASN database, e.g. GeoLite2-ASN.
+
+The client IP address is taken from the X-Forwarded-For header, when it
+is set, and from the remote address of the request otherwise, the same
+way as the Source predicate does.
+
+Examples:
+
+	// matches requests from the Google and Facebook networks
+	cloud: ClientASN(15169, 32934) -> "https://cloud.example.org";
+*/
+package asn
+
+import (
+	"net"
+	"net/http"
+
+	"github.com/oschwald/maxminddb-golang"
+
+	snet "github.com/zalando/skipper/net"
+	"github.com/zalando/skipper/predicates"
+	"github.com/zalando/skipper/routing"
+)
+
+type spec struct {
+	db *maxminddb.Reader
+}
+
+type predicate struct {
+	db   *maxminddb.Reader
+	asns map[uint]struct{}
+}
+
+type record struct {
+	ASN uint `maxminddb:"autonomous_system_number"`
+}
+
+// New creates a ClientASN predicate specification, whose instances
+// look up the client ASN in db.
+func New(db *maxminddb.Reader) routing.PredicateSpec { return &spec{db: db} }
+
+func (*spec) Name() string { return predicates.ClientASNName }
+
+func (s *spec) Create(args []interface{}) (routing.Predicate, error) {
+	if len(args) == 0 {
+		return nil, predicates.ErrInvalidPredicateParameters
+	}
+
+	asns := make(map[uint]struct{})
+	for _, a := range args {
+		var n uint
+		switch v := a.(type) {
+		case int:
+			if v <= 0 {
+				return nil, predicates.ErrInvalidPredicateParameters
+			}
+
+			n = uint(v)
+		case float64:
+			if v <= 0 || v != float64(uint32(v)) {
+				return nil, predicates.ErrInvalidPredicateParameters
+			}
+
+			n = uint(v)
+		default:
+			return nil, predicates.ErrInvalidPredicateParameters
+		}
+
+		asns[n] = struct{}{}
+	}
+
+	return &predicate{db: s.db, asns: asns}, nil
+}
+
+func (p *predicate) Match(r *http.Request) bool {
+	addr := snet.RemoteAddr(r)
+	if !addr.IsValid() {
+		return false
+	}
+
+	var rec record
+	if err := p.db.Lookup(net.IP(addr.AsSlice()), &rec); err != nil || rec.ASN == 0 {
+		return false
+	}
+
+	_, ok := p.asns[rec.ASN]
+	return ok
+}
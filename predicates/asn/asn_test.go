@@ -0,0 +1,225 @@
+package asn
+
+import (
+	"bytes"
+	"encoding/binary"
+	"net/http"
+	"net/netip"
+	"testing"
+
+	"github.com/oschwald/maxminddb-golang"
+)
+
+// mmdb type numbers, see https://maxmind.github.io/MaxMind-DB/
+const (
+	typeString = 2
+	typeUint16 = 5
+	typeUint32 = 6
+	typeMap    = 7
+)
+
+type testNode struct {
+	children [2]*testNode
+	data     int
+	leaf     bool
+}
+
+func encodeControl(b *bytes.Buffer, typ, size int) {
+	b.WriteByte(byte(typ<<5 | size))
+}
+
+func encodeString(b *bytes.Buffer, s string) {
+	encodeControl(b, typeString, len(s))
+	b.WriteString(s)
+}
+
+func encodeUint(b *bytes.Buffer, typ int, v uint32) {
+	var buf [4]byte
+	binary.BigEndian.PutUint32(buf[:], v)
+	p := bytes.TrimLeft(buf[:], "\x00")
+	encodeControl(b, typ, len(p))
+	b.Write(p)
+}
+
+// testDatabase creates an IPv4 only MaxMind database with 24 bit
+// records, mapping the networks to the ASNs.
+func testDatabase(t *testing.T, networks map[string]uint32) *maxminddb.Reader {
+	var data bytes.Buffer
+	root := &testNode{}
+	for cidr, asn := range networks {
+		prefix := netip.MustParsePrefix(cidr)
+		offset := data.Len()
+
+		encodeControl(&data, typeMap, 1)
+		encodeString(&data, "autonomous_system_number")
+		encodeUint(&data, typeUint32, asn)
+
+		ip := prefix.Addr().As4()
+		n := root
+		for i := 0; i < prefix.Bits(); i++ {
+			bit := ip[i/8] >> (7 - i%8) & 1
+			if n.children[bit] == nil {
+				n.children[bit] = &testNode{}
+			}
+
+			n = n.children[bit]
+		}
+
+		n.leaf, n.data = true, offset
+	}
+
+	var nodes []*testNode
+	index := make(map[*testNode]int)
+	var walk func(*testNode)
+	walk = func(n *testNode) {
+		if n == nil || n.leaf {
+			return
+		}
+
+		index[n] = len(nodes)
+		nodes = append(nodes, n)
+		walk(n.children[0])
+		walk(n.children[1])
+	}
+
+	walk(root)
+
+	var db bytes.Buffer
+	count := len(nodes)
+	for _, n := range nodes {
+		for _, c := range n.children {
+			var record int
+			switch {
+			case c == nil:
+				record = count
+			case c.leaf:
+				record = count + 16 + c.data
+			default:
+				record = index[c]
+			}
+
+			db.Write([]byte{byte(record >> 16), byte(record >> 8), byte(record)})
+		}
+	}
+
+	db.Write(make([]byte, 16))
+	db.Write(data.Bytes())
+	db.WriteString("\xAB\xCD\xEFMaxMind.com")
+	encodeControl(&db, typeMap, 5)
+	encodeString(&db, "binary_format_major_version")
+	encodeUint(&db, typeUint16, 2)
+	encodeString(&db, "database_type")
+	encodeString(&db, "Test-ASN")
+	encodeString(&db, "ip_version")
+	encodeUint(&db, typeUint16, 4)
+	encodeString(&db, "node_count")
+	encodeUint(&db, typeUint32, uint32(count))
+	encodeString(&db, "record_size")
+	encodeUint(&db, typeUint16, 24)
+
+	r, err := maxminddb.FromBytes(db.Bytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return r
+}
+
+func TestCreate(t *testing.T) {
+	for _, tt := range []struct {
+		msg  string
+		args []interface{}
+		err  bool
+	}{{
+		msg: "no args",
+		err: true,
+	}, {
+		msg:  "not a number",
+		args: []interface{}{"15169"},
+		err:  true,
+	}, {
+		msg:  "zero",
+		args: []interface{}{0.0},
+		err:  true,
+	}, {
+		msg:  "fraction",
+		args: []interface{}{15169.5},
+		err:  true,
+	}, {
+		msg:  "too large",
+		args: []interface{}{float64(1 << 32)},
+		err:  true,
+	}, {
+		msg:  "single",
+		args: []interface{}{15169.0},
+	}, {
+		msg:  "multiple",
+		args: []interface{}{15169.0, 32934},
+	}} {
+		t.Run(tt.msg, func(t *testing.T) {
+			_, err := New(nil).Create(tt.args)
+			if tt.err && err == nil {
+				t.Error("failed to fail")
+			} else if !tt.err && err != nil {
+				t.Error(err)
+			}
+		})
+	}
+}
+
+func TestMatch(t *testing.T) {
+	db := testDatabase(t, map[string]uint32{
+		"8.8.8.0/24":    15169,
+		"31.13.64.0/18": 32934,
+		"1.2.3.0/24":    64512,
+	})
+	defer db.Close()
+
+	p, err := New(db).Create([]interface{}{15169.0, 32934.0})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tt := range []struct {
+		msg        string
+		remoteAddr string
+		xff        string
+		match      bool
+	}{{
+		msg:        "first ASN",
+		remoteAddr: "8.8.8.8:443",
+		match:      true,
+	}, {
+		msg:        "second ASN",
+		remoteAddr: "31.13.70.1:443",
+		match:      true,
+	}, {
+		msg:        "from X-Forwarded-For",
+		remoteAddr: "10.0.0.1:443",
+		xff:        "8.8.8.8, 10.0.0.2",
+		match:      true,
+	}, {
+		msg:        "other ASN",
+		remoteAddr: "1.2.3.4:443",
+	}, {
+		msg:        "not in database",
+		remoteAddr: "9.9.9.9:443",
+	}, {
+		msg:        "IPv6 lookup fails",
+		remoteAddr: "[2001:4860::8888]:443",
+	}, {
+		msg:        "invalid address",
+		remoteAddr: "foo",
+	}} {
+		t.Run(tt.msg, func(t *testing.T) {
+			req := &http.Request{RemoteAddr: tt.remoteAddr, Header: http.Header{}}
+			if tt.xff != "" {
+				req.Header.Set("X-Forwarded-For", tt.xff)
+			}
+
+			if m := p.Match(req); m != tt.match {
+				t.Errorf("expected match: %v, got: %v", tt.match, m)
+			}
+		})
+	}
+}
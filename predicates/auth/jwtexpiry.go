@@ -0,0 +1,73 @@
+package auth
+
+import (
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/zalando/skipper/jwt"
+	"github.com/zalando/skipper/predicates"
+	"github.com/zalando/skipper/routing"
+)
+
+type (
+	expiringWithinSpec      struct{}
+	expiringWithinPredicate struct {
+		window time.Duration
+		now    func() time.Time
+	}
+)
+
+// NewJWTExpiringWithin creates a predicate specification, whose instances
+// match the requests with a JWT bearer token that expires within the given
+// window, according to its exp claim, e.g. to route the requests to a
+// filter hinting the clients to refresh their tokens pre-emptively. The
+// token is decoded but not verified. The requests with a missing or
+// malformed token, a token without the exp claim, or an already expired
+// token don't match.
+//
+// Example:
+//
+//	refresh: JWTExpiringWithin("5m") -> setResponseHeader("X-Token-Refresh", "true") -> "https://api.example.org";
+func NewJWTExpiringWithin() routing.PredicateSpec { return expiringWithinSpec{} }
+
+func (expiringWithinSpec) Name() string { return predicates.JWTExpiringWithinName }
+
+func (expiringWithinSpec) Create(args []interface{}) (routing.Predicate, error) {
+	if len(args) != 1 {
+		return nil, predicates.ErrInvalidPredicateParameters
+	}
+
+	s, ok := args[0].(string)
+	if !ok {
+		return nil, predicates.ErrInvalidPredicateParameters
+	}
+
+	window, err := time.ParseDuration(s)
+	if err != nil || window <= 0 {
+		return nil, predicates.ErrInvalidPredicateParameters
+	}
+
+	return &expiringWithinPredicate{window: window, now: time.Now}, nil
+}
+
+func (p *expiringWithinPredicate) Match(r *http.Request) bool {
+	ahead := r.Header.Get(authHeaderName)
+	tv := strings.TrimPrefix(ahead, authHeaderPrefix)
+	if tv == ahead {
+		return false
+	}
+
+	token, err := jwt.Parse(tv)
+	if err != nil {
+		return false
+	}
+
+	exp, ok := token.Claims["exp"].(float64)
+	if !ok {
+		return false
+	}
+
+	remaining := time.Unix(int64(exp), 0).Sub(p.now())
+	return remaining > 0 && remaining <= p.window
+}
@@ -0,0 +1,136 @@
+package auth
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/zalando/skipper/predicates"
+)
+
+func TestJWTExpiringWithinName(t *testing.T) {
+	if NewJWTExpiringWithin().Name() != predicates.JWTExpiringWithinName {
+		t.Errorf("invalid name: %s", NewJWTExpiringWithin().Name())
+	}
+}
+
+func TestJWTExpiringWithinCreate(t *testing.T) {
+	for _, tt := range []struct {
+		name string
+		args []interface{}
+		err  bool
+	}{{
+		name: "no args",
+		err:  true,
+	}, {
+		name: "too many args",
+		args: []interface{}{"5m", "10m"},
+		err:  true,
+	}, {
+		name: "not a string",
+		args: []interface{}{300.0},
+		err:  true,
+	}, {
+		name: "invalid duration",
+		args: []interface{}{"5 minutes"},
+		err:  true,
+	}, {
+		name: "negative duration",
+		args: []interface{}{"-5m"},
+		err:  true,
+	}, {
+		name: "valid",
+		args: []interface{}{"5m"},
+	}} {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewJWTExpiringWithin().Create(tt.args)
+			if tt.err && err == nil {
+				t.Error("failed to fail")
+			} else if !tt.err && err != nil {
+				t.Error(err)
+			}
+		})
+	}
+}
+
+func testToken(t *testing.T, claims map[string]interface{}) string {
+	payload, err := json.Marshal(claims)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	header := base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"none"}`))
+	return header + "." + base64.RawURLEncoding.EncodeToString(payload) + ".c2ln"
+}
+
+func TestJWTExpiringWithinMatch(t *testing.T) {
+	now := time.Date(2023, 1, 1, 12, 0, 0, 0, time.UTC)
+	for _, tt := range []struct {
+		name     string
+		header   func(t *testing.T) string
+		expected bool
+	}{{
+		name: "expires within the window",
+		header: func(t *testing.T) string {
+			return "Bearer " + testToken(t, map[string]interface{}{"exp": now.Add(2 * time.Minute).Unix()})
+		},
+		expected: true,
+	}, {
+		name: "expires at the end of the window",
+		header: func(t *testing.T) string {
+			return "Bearer " + testToken(t, map[string]interface{}{"exp": now.Add(5 * time.Minute).Unix()})
+		},
+		expected: true,
+	}, {
+		name: "expires after the window",
+		header: func(t *testing.T) string {
+			return "Bearer " + testToken(t, map[string]interface{}{"exp": now.Add(time.Hour).Unix()})
+		},
+	}, {
+		name: "already expired",
+		header: func(t *testing.T) string {
+			return "Bearer " + testToken(t, map[string]interface{}{"exp": now.Add(-time.Minute).Unix()})
+		},
+	}, {
+		name: "no exp claim",
+		header: func(t *testing.T) string {
+			return "Bearer " + testToken(t, map[string]interface{}{"sub": "foo"})
+		},
+	}, {
+		name: "exp not a number",
+		header: func(t *testing.T) string {
+			return "Bearer " + testToken(t, map[string]interface{}{"exp": "soon"})
+		},
+	}, {
+		name:   "malformed token",
+		header: func(*testing.T) string { return "Bearer not-a-token" },
+	}, {
+		name: "not a bearer token",
+		header: func(t *testing.T) string {
+			return "Basic " + testToken(t, map[string]interface{}{"exp": now.Add(2 * time.Minute).Unix()})
+		},
+	}, {
+		name:   "no token",
+		header: func(*testing.T) string { return "" },
+	}} {
+		t.Run(tt.name, func(t *testing.T) {
+			p, err := NewJWTExpiringWithin().Create([]interface{}{"5m"})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			p.(*expiringWithinPredicate).now = func() time.Time { return now }
+
+			r := &http.Request{Header: http.Header{}}
+			if h := tt.header(t); h != "" {
+				r.Header.Set("Authorization", h)
+			}
+
+			if m := p.Match(r); m != tt.expected {
+				t.Errorf("unexpected match result: %v, expected: %v", m, tt.expected)
+			}
+		})
+	}
+}
@@ -0,0 +1,241 @@
+/*
+Package bloom implements a predicate to match routes based on the
+membership of a request attribute in a prebuilt bloom filter, e.g. for
+very large allow or deny lists of API keys.
+
+The bloom filter file is expected in the binary format of the
+github.com/bits-and-blooms/bloom/v3 library, as written by its WriteTo
+method. The file is reloaded in the background when it changes.
+
+A bloom filter can report false positives, but no false negatives: when
+the attribute is in the set, the predicate always matches, while it can
+also match for some attributes that are not in the set, with the false
+positive rate chosen when building the filter. Therefore it is better
+suited for deny lists or as a prefilter than for strict allow lists.
+
+Examples:
+
+	// matches requests with an API key probably found in the bloom filter
+	denied: InBloomFilter("header:X-Api-Key", "/etc/skipper/keys.bloom") -> status(403) -> <shunt>;
+*/
+package bloom
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	bitsbloom "github.com/bits-and-blooms/bloom/v3"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/zalando/skipper/net"
+	"github.com/zalando/skipper/predicates"
+	"github.com/zalando/skipper/routing"
+)
+
+const defaultRefreshInterval = time.Minute
+
+type file struct {
+	path    string
+	modTime time.Time
+	size    int64
+	filter  atomic.Value // *bitsbloom.BloomFilter
+}
+
+// Spec is the InBloomFilter predicate specification. It reloads the
+// bloom filter files in the background, and it needs to be closed, when
+// not used anymore.
+type Spec struct {
+	mu    sync.Mutex
+	files map[string]*file
+	quit  chan struct{}
+	once  sync.Once
+}
+
+type postProcessor struct {
+	spec *Spec
+}
+
+type predicate struct {
+	attribute net.RequestSelector
+	file      *file
+}
+
+// New creates an InBloomFilter predicate specification, that checks the
+// used bloom filter files for changes with the refresh interval. When
+// refresh is not positive, the files are checked every minute.
+func New(refresh time.Duration) *Spec {
+	if refresh <= 0 {
+		refresh = defaultRefreshInterval
+	}
+
+	s := &Spec{
+		files: make(map[string]*file),
+		quit:  make(chan struct{}),
+	}
+
+	go s.run(refresh)
+	return s
+}
+
+func (*Spec) Name() string { return predicates.InBloomFilterName }
+
+func load(path string) (*bitsbloom.BloomFilter, os.FileInfo, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	bf := &bitsbloom.BloomFilter{}
+	if _, err := bf.ReadFrom(f); err != nil {
+		return nil, nil, fmt.Errorf("failed to read bloom filter from %s: %w", path, err)
+	}
+
+	return bf, info, nil
+}
+
+func (s *Spec) file(path string) (*file, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if f, ok := s.files[path]; ok {
+		return f, nil
+	}
+
+	bf, info, err := load(path)
+	if err != nil {
+		return nil, err
+	}
+
+	f := &file{path: path, modTime: info.ModTime(), size: info.Size()}
+	f.filter.Store(bf)
+	s.files[path] = f
+	return f, nil
+}
+
+func (s *Spec) Create(args []interface{}) (routing.Predicate, error) {
+	if len(args) != 2 {
+		return nil, predicates.ErrInvalidPredicateParameters
+	}
+
+	a, ok := args[0].(string)
+	if !ok {
+		return nil, predicates.ErrInvalidPredicateParameters
+	}
+
+	attr, err := net.ParseRequestSelector(a, "path", "host", "header:", "query:", "cookie:")
+	if err != nil {
+		return nil, err
+	}
+
+	path, ok := args[1].(string)
+	if !ok || path == "" {
+		return nil, predicates.ErrInvalidPredicateParameters
+	}
+
+	f, err := s.file(path)
+	if err != nil {
+		return nil, err
+	}
+
+	return &predicate{attribute: attr, file: f}, nil
+}
+
+func (s *Spec) refresh() {
+	s.mu.Lock()
+	files := make([]*file, 0, len(s.files))
+	for _, f := range s.files {
+		files = append(files, f)
+	}
+	s.mu.Unlock()
+
+	for _, f := range files {
+		info, err := os.Stat(f.path)
+		if err != nil {
+			log.Errorf("Failed to check bloom filter file %s: %v", f.path, err)
+			continue
+		}
+
+		if info.ModTime().Equal(f.modTime) && info.Size() == f.size {
+			continue
+		}
+
+		bf, info, err := load(f.path)
+		if err != nil {
+			log.Errorf("Failed to reload bloom filter: %v", err)
+			continue
+		}
+
+		f.modTime, f.size = info.ModTime(), info.Size()
+		f.filter.Store(bf)
+		log.Infof("Bloom filter reloaded from %s", f.path)
+	}
+}
+
+func (s *Spec) run(refresh time.Duration) {
+	ticker := time.NewTicker(refresh)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			s.refresh()
+		case <-s.quit:
+			return
+		}
+	}
+}
+
+// PostProcessor returns the routing.PostProcessor dropping the bloom
+// filter files, that are not used anymore by any route, so that they are
+// not kept in memory and not reloaded.
+func (s *Spec) PostProcessor() routing.PostProcessor {
+	return postProcessor{spec: s}
+}
+
+func (p postProcessor) Do(routes []*routing.Route) []*routing.Route {
+	s := p.spec
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	inUse := make(map[string]struct{})
+	for _, r := range routes {
+		for _, rp := range r.Predicates {
+			if bp, ok := rp.(*predicate); ok {
+				inUse[bp.file.path] = struct{}{}
+			}
+		}
+	}
+
+	for path := range s.files {
+		if _, ok := inUse[path]; !ok {
+			delete(s.files, path)
+		}
+	}
+
+	return routes
+}
+
+// Close stops the background reloading of the bloom filter files.
+func (s *Spec) Close() {
+	s.once.Do(func() { close(s.quit) })
+}
+
+func (p *predicate) Match(r *http.Request) bool {
+	v := p.attribute.Value(r)
+	if v == "" {
+		return false
+	}
+
+	return p.file.filter.Load().(*bitsbloom.BloomFilter).TestString(v)
+}
@@ -0,0 +1,244 @@
+package bloom
+
+import (
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	bitsbloom "github.com/bits-and-blooms/bloom/v3"
+
+	"github.com/zalando/skipper/routing"
+)
+
+func writeBloomFilter(t *testing.T, path string, keys ...string) {
+	bf := bitsbloom.NewWithEstimates(1000, 0.0001)
+	for _, k := range keys {
+		bf.AddString(k)
+	}
+
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer f.Close()
+
+	if _, err := bf.WriteTo(f); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestCreate(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "keys.bloom")
+	writeBloomFilter(t, path, "foo")
+
+	invalid := filepath.Join(t.TempDir(), "invalid.bloom")
+	if err := os.WriteFile(invalid, []byte("foo"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	s := New(time.Hour)
+	defer s.Close()
+
+	for _, tt := range []struct {
+		msg  string
+		args []interface{}
+		err  bool
+	}{{
+		msg: "no args",
+		err: true,
+	}, {
+		msg:  "missing file",
+		args: []interface{}{"header:X-Api-Key"},
+		err:  true,
+	}, {
+		msg:  "invalid attribute type",
+		args: []interface{}{42.0, path},
+		err:  true,
+	}, {
+		msg:  "unknown attribute",
+		args: []interface{}{"body:foo", path},
+		err:  true,
+	}, {
+		msg:  "attribute without name",
+		args: []interface{}{"header:", path},
+		err:  true,
+	}, {
+		msg:  "not existing file",
+		args: []interface{}{"header:X-Api-Key", filepath.Join(t.TempDir(), "missing.bloom")},
+		err:  true,
+	}, {
+		msg:  "invalid file",
+		args: []interface{}{"header:X-Api-Key", invalid},
+		err:  true,
+	}, {
+		msg:  "header",
+		args: []interface{}{"header:X-Api-Key", path},
+	}, {
+		msg:  "query",
+		args: []interface{}{"query:key", path},
+	}, {
+		msg:  "cookie",
+		args: []interface{}{"cookie:key", path},
+	}, {
+		msg:  "path",
+		args: []interface{}{"path", path},
+	}, {
+		msg:  "host",
+		args: []interface{}{"host", path},
+	}} {
+		t.Run(tt.msg, func(t *testing.T) {
+			_, err := s.Create(tt.args)
+			if tt.err && err == nil {
+				t.Error("failed to fail")
+			} else if !tt.err && err != nil {
+				t.Error(err)
+			}
+		})
+	}
+}
+
+func TestMatch(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "keys.bloom")
+	writeBloomFilter(t, path, "key-1", "key-2", "/allowed")
+
+	s := New(time.Hour)
+	defer s.Close()
+
+	for _, tt := range []struct {
+		msg   string
+		attr  string
+		url   string
+		setup func(*http.Request)
+		match bool
+	}{{
+		msg:   "header member",
+		attr:  "header:X-Api-Key",
+		url:   "https://www.example.org",
+		setup: func(r *http.Request) { r.Header.Set("X-Api-Key", "key-1") },
+		match: true,
+	}, {
+		msg:   "header not member",
+		attr:  "header:X-Api-Key",
+		url:   "https://www.example.org",
+		setup: func(r *http.Request) { r.Header.Set("X-Api-Key", "key-3") },
+	}, {
+		msg:  "header missing",
+		attr: "header:X-Api-Key",
+		url:  "https://www.example.org",
+	}, {
+		msg:   "query member",
+		attr:  "query:key",
+		url:   "https://www.example.org?key=key-2",
+		match: true,
+	}, {
+		msg:  "query not member",
+		attr: "query:key",
+		url:  "https://www.example.org?key=key-4",
+	}, {
+		msg:   "cookie member",
+		attr:  "cookie:key",
+		url:   "https://www.example.org",
+		setup: func(r *http.Request) { r.AddCookie(&http.Cookie{Name: "key", Value: "key-1"}) },
+		match: true,
+	}, {
+		msg:   "path member",
+		attr:  "path",
+		url:   "https://www.example.org/allowed",
+		match: true,
+	}, {
+		msg:  "path not member",
+		attr: "path",
+		url:  "https://www.example.org/denied",
+	}} {
+		t.Run(tt.msg, func(t *testing.T) {
+			p, err := s.Create([]interface{}{tt.attr, path})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			r, err := http.NewRequest("GET", tt.url, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if tt.setup != nil {
+				tt.setup(r)
+			}
+
+			if m := p.Match(r); m != tt.match {
+				t.Errorf("expected match: %v, got: %v", tt.match, m)
+			}
+		})
+	}
+}
+
+func TestReload(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "keys.bloom")
+	writeBloomFilter(t, path, "key-1")
+
+	s := New(time.Hour)
+	defer s.Close()
+
+	p, err := s.Create([]interface{}{"header:X-Api-Key", path})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	r := &http.Request{Header: http.Header{"X-Api-Key": []string{"key-2"}}}
+	if p.Match(r) {
+		t.Fatal("unexpected match before reload")
+	}
+
+	writeBloomFilter(t, path, "key-1", "key-2")
+	future := time.Now().Add(time.Minute)
+	if err := os.Chtimes(path, future, future); err != nil {
+		t.Fatal(err)
+	}
+
+	s.refresh()
+	if !p.Match(r) {
+		t.Fatal("failed to match after reload")
+	}
+
+	// invalid files are ignored
+	if err := os.WriteFile(path, []byte("foo"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	s.refresh()
+	if !p.Match(r) {
+		t.Error("failed to keep the previous bloom filter")
+	}
+}
+
+func TestPostProcessorDropsUnusedFiles(t *testing.T) {
+	dir := t.TempDir()
+	used, unused := filepath.Join(dir, "used.bloom"), filepath.Join(dir, "unused.bloom")
+	writeBloomFilter(t, used, "key-1")
+	writeBloomFilter(t, unused, "key-1")
+
+	s := New(time.Hour)
+	defer s.Close()
+
+	p, err := s.Create([]interface{}{"header:X-Api-Key", used})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := s.Create([]interface{}{"header:X-Api-Key", unused}); err != nil {
+		t.Fatal(err)
+	}
+
+	s.PostProcessor().Do([]*routing.Route{{Predicates: []routing.Predicate{p}}})
+	if _, ok := s.files[used]; !ok || len(s.files) != 1 {
+		t.Errorf("unexpected files after post-processing: %v", s.files)
+	}
+
+	s.PostProcessor().Do(nil)
+	if len(s.files) != 0 {
+		t.Errorf("unexpected files without routes: %v", s.files)
+	}
+}
@@ -0,0 +1,200 @@
+/*
+Package burst implements a predicate to match the requests arriving in a
+burst, e.g. of a campaign, in order to route the spikes of coordinated
+traffic to a queue or a cache.
+
+The requests are grouped by a request attribute, and counted per group in
+a sliding window. The predicate matches, when the count of the group,
+including the current request, exceeds the threshold within the window.
+Only the requests evaluated by the predicate are counted, i.e. the
+requests whose preceding predicates of the route matched.
+
+The counts are tracked in memory, per skipper instance. The sliding window
+is approximated by ten buckets, so that the memory of a group is constant,
+and the number of the tracked groups is bounded. When the store is full,
+the least recently seen groups are evicted, and their counting starts again
+from zero.
+
+Examples:
+
+	// more than 500 requests of the same campaign within 10 seconds go to the queue
+	burst: BurstDetected("header:X-Campaign", 500, "10s") -> "https://queue.example.org";
+	main: * -> "https://www.example.org";
+*/
+package burst
+
+import (
+	"container/list"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/zalando/skipper/net"
+	"github.com/zalando/skipper/predicates"
+	"github.com/zalando/skipper/routing"
+)
+
+const (
+	defaultMaxGroups = 10000
+
+	// the number of buckets approximating the sliding window
+	buckets = 10
+)
+
+type group struct {
+	key     string
+	last    int64 // the index of the last counted bucket
+	buckets [buckets]int
+}
+
+// Spec is the BurstDetected predicate specification. The predicates
+// created by the same specification share the store of the groups.
+type Spec struct {
+	mu        sync.Mutex
+	maxGroups int
+	groups    map[string]*list.Element
+	order     *list.List
+	now       func() time.Time
+}
+
+type predicate struct {
+	spec       *Spec
+	attribute  net.RequestSelector
+	threshold  int
+	bucketSize time.Duration
+	keyPrefix  string
+}
+
+// New creates a BurstDetected predicate specification, that tracks the
+// request counts of at most maxGroups groups. When maxGroups is not
+// positive, 10000 groups are tracked.
+func New(maxGroups int) *Spec {
+	if maxGroups <= 0 {
+		maxGroups = defaultMaxGroups
+	}
+
+	return &Spec{
+		maxGroups: maxGroups,
+		groups:    make(map[string]*list.Element),
+		order:     list.New(),
+		now:       time.Now,
+	}
+}
+
+func (*Spec) Name() string { return predicates.BurstDetectedName }
+
+// Create a predicate instance with three arguments: the attribute grouping
+// the requests, either "header:<name>", "cookie:<name>" or "query:<name>",
+// the positive threshold of the requests, and the duration of the window,
+// e.g. "10s".
+func (s *Spec) Create(args []interface{}) (routing.Predicate, error) {
+	if len(args) != 3 {
+		return nil, predicates.ErrInvalidPredicateParameters
+	}
+
+	a, ok := args[0].(string)
+	if !ok {
+		return nil, predicates.ErrInvalidPredicateParameters
+	}
+
+	attr, err := net.ParseRequestSelector(a, "header:", "cookie:", "query:")
+	if err != nil {
+		return nil, err
+	}
+
+	var threshold int
+	switch v := args[1].(type) {
+	case int:
+		threshold = v
+	case float64:
+		threshold = int(v)
+		if float64(threshold) != v {
+			return nil, predicates.ErrInvalidPredicateParameters
+		}
+	default:
+		return nil, predicates.ErrInvalidPredicateParameters
+	}
+
+	if threshold <= 0 {
+		return nil, predicates.ErrInvalidPredicateParameters
+	}
+
+	ws, ok := args[2].(string)
+	if !ok {
+		return nil, predicates.ErrInvalidPredicateParameters
+	}
+
+	window, err := time.ParseDuration(ws)
+	if err != nil || window < buckets {
+		return nil, predicates.ErrInvalidPredicateParameters
+	}
+
+	return &predicate{
+		spec:       s,
+		attribute:  attr,
+		threshold:  threshold,
+		bucketSize: window / buckets,
+
+		// the groups of the predicates with different windows are
+		// counted separately
+		keyPrefix: attr.String() + "\x00" + window.String() + "\x00",
+	}, nil
+}
+
+// count adds a request to the group, and returns the count of the requests
+// of the group within the window, including the current one.
+func (g *group) count(bucket int64) int {
+	if bucket-g.last >= buckets {
+		g.buckets = [buckets]int{}
+	} else {
+		for i := g.last + 1; i <= bucket; i++ {
+			g.buckets[i%buckets] = 0
+		}
+	}
+
+	if bucket > g.last {
+		g.last = bucket
+	}
+
+	g.buckets[g.last%buckets]++
+
+	var sum int
+	for _, c := range g.buckets {
+		sum += c
+	}
+
+	return sum
+}
+
+func (s *Spec) increment(key string, bucketSize time.Duration) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	bucket := s.now().UnixNano() / int64(bucketSize)
+	if e, ok := s.groups[key]; ok {
+		s.order.MoveToBack(e)
+		return e.Value.(*group).count(bucket)
+	}
+
+	for s.order.Len() >= s.maxGroups {
+		e := s.order.Front()
+		s.order.Remove(e)
+		delete(s.groups, e.Value.(*group).key)
+	}
+
+	g := &group{key: key, last: bucket}
+	s.groups[key] = s.order.PushBack(g)
+	return g.count(bucket)
+}
+
+// Match counts the request for its group, and matches when the count
+// within the window, including the current request, exceeds the threshold.
+// Requests without the attribute don't match, and they are not counted.
+func (p *predicate) Match(r *http.Request) bool {
+	v := p.attribute.Value(r)
+	if v == "" {
+		return false
+	}
+
+	return p.spec.increment(p.keyPrefix+v, p.bucketSize) > p.threshold
+}
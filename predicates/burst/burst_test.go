@@ -0,0 +1,191 @@
+package burst
+
+import (
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestCreate(t *testing.T) {
+	s := New(0)
+	for _, tt := range []struct {
+		msg  string
+		args []interface{}
+		err  bool
+	}{{
+		msg: "no args",
+		err: true,
+	}, {
+		msg:  "missing window",
+		args: []interface{}{"header:X-Campaign", 500.0},
+		err:  true,
+	}, {
+		msg:  "invalid attribute",
+		args: []interface{}{"path:foo", 500.0, "10s"},
+		err:  true,
+	}, {
+		msg:  "attribute without name",
+		args: []interface{}{"header:", 500.0, "10s"},
+		err:  true,
+	}, {
+		msg:  "invalid threshold",
+		args: []interface{}{"header:X-Campaign", 0.0, "10s"},
+		err:  true,
+	}, {
+		msg:  "fractional threshold",
+		args: []interface{}{"header:X-Campaign", 1.5, "10s"},
+		err:  true,
+	}, {
+		msg:  "invalid window",
+		args: []interface{}{"header:X-Campaign", 500.0, "ten seconds"},
+		err:  true,
+	}, {
+		msg:  "negative window",
+		args: []interface{}{"header:X-Campaign", 500.0, "-10s"},
+		err:  true,
+	}, {
+		msg:  "header",
+		args: []interface{}{"header:X-Campaign", 500.0, "10s"},
+	}, {
+		msg:  "cookie",
+		args: []interface{}{"cookie:campaign", 500, "1m"},
+	}, {
+		msg:  "query",
+		args: []interface{}{"query:utm_campaign", 500.0, "10s"},
+	}} {
+		t.Run(tt.msg, func(t *testing.T) {
+			_, err := s.Create(tt.args)
+			if tt.err && err == nil {
+				t.Fatal("failed to fail")
+			}
+
+			if !tt.err && err != nil {
+				t.Fatal(err)
+			}
+		})
+	}
+}
+
+type clock struct{ now time.Time }
+
+func (c *clock) get() time.Time { return c.now }
+
+func campaignRequest(t *testing.T, campaign string) *http.Request {
+	r, err := http.NewRequest("GET", "https://www.example.org/?utm_campaign="+campaign, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if campaign != "" {
+		r.Header.Set("X-Campaign", campaign)
+	}
+
+	return r
+}
+
+func TestCampaignBurst(t *testing.T) {
+	c := &clock{now: time.Unix(1700000000, 0)}
+	s := New(0)
+	s.now = c.get
+
+	p, err := s.Create([]interface{}{"header:X-Campaign", 5.0, "10s"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// steady traffic below the threshold
+	for i := 0; i < 20; i++ {
+		if p.Match(campaignRequest(t, "spring")) {
+			t.Fatalf("unexpected match of steady traffic at request %d", i)
+		}
+
+		c.now = c.now.Add(2 * time.Second)
+	}
+
+	// the campaign burst
+	for i := 0; i < 5; i++ {
+		if p.Match(campaignRequest(t, "summer")) {
+			t.Fatalf("unexpected match below the threshold at request %d", i)
+		}
+	}
+
+	for i := 0; i < 10; i++ {
+		if !p.Match(campaignRequest(t, "summer")) {
+			t.Fatalf("failed to match the burst at request %d", i)
+		}
+	}
+
+	// other groups are not affected
+	if p.Match(campaignRequest(t, "spring")) {
+		t.Fatal("unexpected match of another group")
+	}
+
+	// requests without the attribute are not counted
+	if p.Match(campaignRequest(t, "")) {
+		t.Fatal("unexpected match without the attribute")
+	}
+
+	// the burst is over after the window
+	c.now = c.now.Add(11 * time.Second)
+	if p.Match(campaignRequest(t, "summer")) {
+		t.Fatal("unexpected match after the window")
+	}
+}
+
+func TestSlidingWindow(t *testing.T) {
+	c := &clock{now: time.Unix(1700000000, 0)}
+	s := New(0)
+	s.now = c.get
+
+	p, err := s.Create([]interface{}{"query:utm_campaign", 4.0, "10s"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// two requests in the first half of the window
+	p.Match(campaignRequest(t, "summer"))
+	p.Match(campaignRequest(t, "summer"))
+
+	// two requests in the second half
+	c.now = c.now.Add(6 * time.Second)
+	p.Match(campaignRequest(t, "summer"))
+	p.Match(campaignRequest(t, "summer"))
+
+	if !p.Match(campaignRequest(t, "summer")) {
+		t.Fatal("failed to match within the window")
+	}
+
+	// the first two requests leave the window
+	c.now = c.now.Add(5 * time.Second)
+	if p.Match(campaignRequest(t, "summer")) {
+		t.Fatal("unexpected match after the first requests left the window")
+	}
+}
+
+func TestBoundedGroups(t *testing.T) {
+	c := &clock{now: time.Unix(1700000000, 0)}
+	s := New(2)
+	s.now = c.get
+
+	p, err := s.Create([]interface{}{"header:X-Campaign", 1.0, "10s"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	p.Match(campaignRequest(t, "a"))
+	p.Match(campaignRequest(t, "b"))
+	p.Match(campaignRequest(t, "c"))
+
+	if len(s.groups) != 2 || s.order.Len() != 2 {
+		t.Fatalf("unexpected number of groups: %d", len(s.groups))
+	}
+
+	// the least recently seen group was evicted, and starts from zero
+	if p.Match(campaignRequest(t, "a")) {
+		t.Fatal("unexpected match of an evicted group")
+	}
+
+	if !p.Match(campaignRequest(t, "c")) {
+		t.Fatal("failed to match a tracked group")
+	}
+}
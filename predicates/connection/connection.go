@@ -0,0 +1,50 @@
+/*
+Package connection implements the ConnectionReused predicate, that matches
+the requests received on a reused, keep-alive client connection, e.g. to
+route the first request of a connection differently, or for connection
+level debugging.
+
+The requests are numbered on their connection by the proxy, when the server
+prepares the connections with net.ConnContext, as the Skipper proxy server
+does. Without it, the predicate doesn't match. In case of HTTP/2, all the
+requests following the first one on the same connection are considered
+reused.
+
+Examples:
+
+	reused: Path("/debug") && ConnectionReused() -> setResponseHeader("X-Connection", "reused") -> <shunt>;
+	fresh: Path("/debug") -> setResponseHeader("X-Connection", "new") -> <shunt>;
+*/
+package connection
+
+import (
+	"net/http"
+
+	"github.com/zalando/skipper/net"
+	"github.com/zalando/skipper/predicates"
+	"github.com/zalando/skipper/routing"
+)
+
+type spec struct{}
+
+type predicate struct{}
+
+// NewReused creates a new ConnectionReused predicate specification.
+func NewReused() routing.PredicateSpec { return &spec{} }
+
+func (*spec) Name() string { return predicates.ConnectionReusedName }
+
+// Create a predicate instance, that matches the requests of a reused
+// connection. It doesn't accept arguments.
+func (*spec) Create(args []interface{}) (routing.Predicate, error) {
+	if len(args) != 0 {
+		return nil, predicates.ErrInvalidPredicateParameters
+	}
+
+	return &predicate{}, nil
+}
+
+func (*predicate) Match(r *http.Request) bool {
+	n, ok := net.ConnRequestNumber(r)
+	return ok && n > 1
+}
@@ -0,0 +1,116 @@
+package connection_test
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/zalando/skipper/eskip"
+	"github.com/zalando/skipper/filters/builtin"
+	"github.com/zalando/skipper/logging/loggingtest"
+	"github.com/zalando/skipper/net"
+	"github.com/zalando/skipper/predicates/connection"
+	"github.com/zalando/skipper/proxy"
+	"github.com/zalando/skipper/routing"
+	"github.com/zalando/skipper/routing/testdataclient"
+)
+
+func TestCreate(t *testing.T) {
+	if _, err := connection.NewReused().Create(nil); err != nil {
+		t.Error(err)
+	}
+
+	if _, err := connection.NewReused().Create([]interface{}{"foo"}); err == nil {
+		t.Error("failed to fail")
+	}
+}
+
+func TestConnectionReusedWithoutConnContext(t *testing.T) {
+	p, err := connection.NewReused().Create(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	req := httptest.NewRequest("GET", "https://www.example.org", nil)
+	if p.Match(net.ServeConnRequest(req)) {
+		t.Error("unexpected match without a prepared connection")
+	}
+}
+
+func TestConnectionReused(t *testing.T) {
+	routes := eskip.MustParse(`
+		reused: ConnectionReused() -> inlineContent("reused") -> <shunt>;
+		fresh: * -> inlineContent("new") -> <shunt>;
+	`)
+
+	dc := testdataclient.New(routes)
+	defer dc.Close()
+
+	l := loggingtest.New()
+	defer l.Close()
+
+	rt := routing.New(routing.Options{
+		DataClients:    []routing.DataClient{dc},
+		FilterRegistry: builtin.MakeRegistry(),
+		Predicates:     []routing.PredicateSpec{connection.NewReused()},
+		Log:            l,
+	})
+	defer rt.Close()
+
+	p := proxy.WithParams(proxy.Params{Routing: rt})
+	defer p.Close()
+
+	if err := l.WaitFor("route settings applied", 120*time.Millisecond); err != nil {
+		t.Fatal(err)
+	}
+
+	s := httptest.NewUnstartedServer(p)
+	s.Config.ConnContext = net.ConnContext
+	s.Start()
+	defer s.Close()
+
+	client := s.Client()
+	get := func(close bool) string {
+		t.Helper()
+		req, err := http.NewRequest("GET", s.URL, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		req.Close = close
+		rsp, err := client.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		defer rsp.Body.Close()
+		b, err := io.ReadAll(rsp.Body)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		return string(b)
+	}
+
+	for i, expected := range []string{"new", "reused", "reused"} {
+		if got := get(false); got != expected {
+			t.Errorf("unexpected response to request %d: %s, expected: %s", i, got, expected)
+		}
+	}
+
+	// a closed connection is not reused
+	if got := get(true); got != "reused" {
+		t.Errorf("unexpected response to the closing request: %s", got)
+	}
+
+	if got := get(false); got != "new" {
+		t.Errorf("unexpected response on a new connection: %s", got)
+	}
+
+	client.CloseIdleConnections()
+	if got := get(false); got != "new" {
+		t.Errorf("unexpected response after closing the idle connections: %s", got)
+	}
+}
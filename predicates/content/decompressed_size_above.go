@@ -0,0 +1,217 @@
+package content
+
+import (
+	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/andybalholm/brotli"
+	"github.com/zalando/skipper/net"
+	"github.com/zalando/skipper/predicates"
+	"github.com/zalando/skipper/routing"
+)
+
+type decompressedSizeAboveSpec struct{}
+
+type decompressedSizeAbovePredicate struct {
+	min int64
+}
+
+// measuredBody replaces the request body after measuring it. It reads the
+// buffered part of the body first, and then the rest of the original body.
+// It stores the results of the measurement, so that the predicates of
+// other routes, evaluated for the same request, don't need to measure the
+// body again.
+type measuredBody struct {
+	io.Reader
+	io.Closer
+
+	// the counted bytes of the decompressed and the compressed body
+	size, compressedSize int64
+
+	// set when the decompression of the body finished, with or without
+	// error, before reaching the limit, so the counts are final
+	complete bool
+}
+
+// NewDecompressedSizeAbove creates a predicate specification, whose
+// instances match requests whose body size after decompression is above
+// min.
+//
+// When the request body is not encoded, and the Content-Length is known,
+// it is used. When the body is encoded, and the
+// X-Decompressed-Content-Length header is set, its value is used.
+// Otherwise the body is measured by decompressing it while streaming, up
+// to min+1 bytes, and the read part of the body is buffered and forwarded
+// unchanged. The supported content codings are gzip, deflate and br.
+// Requests with other codings don't match. When more than min bytes of
+// the compressed body are read before reaching the limit, the request
+// matches, too. At most net.DefaultMaxBodySize bytes of the body are
+// buffered. When min is larger, and the decompressed size doesn't exceed
+// it before reaching this limit, the request doesn't match.
+//
+// example: DecompressedSizeAbove("10MB")
+// example: DecompressedSizeAbove(10485760)
+func NewDecompressedSizeAbove() routing.PredicateSpec { return &decompressedSizeAboveSpec{} }
+
+func (*decompressedSizeAboveSpec) Name() string {
+	return predicates.DecompressedSizeAboveName
+}
+
+// Create a predicate instance with a single argument, the size limit
+// (exclusive), either as a number, or as string with unit, e.g. "10MB".
+func (*decompressedSizeAboveSpec) Create(args []interface{}) (routing.Predicate, error) {
+	if len(args) != 1 {
+		return nil, predicates.ErrInvalidPredicateParameters
+	}
+
+	var (
+		min int64
+		ok  bool
+	)
+
+	switch v := args[0].(type) {
+	case int:
+		min, ok = int64(v), true
+	case float64:
+		min, ok = int64(v), true
+	case string:
+		var err error
+		min, err = net.ParseByteSize(v)
+		ok = err == nil
+	}
+
+	if !ok || min <= 0 {
+		return nil, predicates.ErrInvalidPredicateParameters
+	}
+
+	return &decompressedSizeAbovePredicate{min: min}, nil
+}
+
+// contentCodings returns the content codings applied to the request body,
+// in the order they were applied.
+func contentCodings(req *http.Request) []string {
+	var cs []string
+	for _, h := range req.Header.Values("Content-Encoding") {
+		for _, c := range strings.Split(h, ",") {
+			if c = strings.ToLower(strings.TrimSpace(c)); c != "" && c != "identity" {
+				cs = append(cs, c)
+			}
+		}
+	}
+
+	return cs
+}
+
+func newMeasureDecoder(coding string, r io.Reader) (io.Reader, error) {
+	switch coding {
+	case "gzip":
+		return gzip.NewReader(r)
+	case "deflate":
+		return flate.NewReader(r), nil
+	case "br":
+		return brotli.NewReader(r), nil
+	default:
+		return nil, fmt.Errorf("unsupported content coding: %s", coding)
+	}
+}
+
+type countingReader struct {
+	reader io.Reader
+	count  int64
+}
+
+func (r *countingReader) Read(p []byte) (int, error) {
+	n, err := r.reader.Read(p)
+	r.count += int64(n)
+	return n, err
+}
+
+// measure decompresses the request body up to limit+1 bytes, reading at
+// most limit+1 bytes of the compressed body, but not more than
+// net.DefaultMaxBodySize+1, and replaces the body with a measuredBody.
+func measure(req *http.Request, codings []string, limit int64) *measuredBody {
+	maxRead := limit
+	if maxRead > net.DefaultMaxBodySize {
+		maxRead = net.DefaultMaxBodySize
+	}
+
+	var buf bytes.Buffer
+	compressed := &countingReader{reader: io.TeeReader(io.LimitReader(req.Body, maxRead+1), &buf)}
+
+	var (
+		decoded io.Reader = compressed
+		err     error
+	)
+
+	// the codings are decoded in the reverse order of their application
+	for i := len(codings) - 1; i >= 0 && err == nil; i-- {
+		decoded, err = newMeasureDecoder(codings[i], decoded)
+	}
+
+	var size int64
+	if err == nil {
+		// decoding errors only stop the measurement
+		size, _ = io.Copy(io.Discard, io.LimitReader(decoded, limit+1))
+	}
+
+	body := &measuredBody{
+		Reader:         io.MultiReader(&buf, req.Body),
+		Closer:         req.Body,
+		size:           size,
+		compressedSize: compressed.count,
+		complete:       size <= limit && compressed.count <= maxRead,
+	}
+
+	req.Body = body
+	return body
+}
+
+func supportedCodings(codings []string) bool {
+	for _, c := range codings {
+		switch c {
+		case "gzip", "deflate", "br":
+		default:
+			return false
+		}
+	}
+
+	return true
+}
+
+func (p *decompressedSizeAbovePredicate) Match(req *http.Request) bool {
+	codings := contentCodings(req)
+	if len(codings) == 0 && req.ContentLength >= 0 {
+		return req.ContentLength > p.min
+	}
+
+	if len(codings) > 0 {
+		if h := req.Header.Get(DecompressedSizeHintHeader); h != "" {
+			if size, err := strconv.ParseInt(h, 10, 64); err == nil && size >= 0 {
+				return size > p.min
+			}
+		}
+	}
+
+	if req.Body == nil || req.Body == http.NoBody || !supportedCodings(codings) {
+		return false
+	}
+
+	if b, ok := req.Body.(*measuredBody); ok {
+		if b.size > p.min || b.compressedSize > p.min {
+			return true
+		}
+
+		if b.complete {
+			return false
+		}
+	}
+
+	b := measure(req, codings, p.min)
+	return b.size > p.min || b.compressedSize > p.min
+}
@@ -0,0 +1,299 @@
+package content
+
+import (
+	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"io"
+	"net/http"
+	"testing"
+
+	"github.com/andybalholm/brotli"
+)
+
+func TestDecompressedSizeAboveCreate(t *testing.T) {
+	for _, tc := range []struct {
+		msg      string
+		args     []interface{}
+		expected int64
+		err      bool
+	}{{
+		msg: "no args",
+		err: true,
+	}, {
+		msg:  "too many args",
+		args: []interface{}{1000.0, 10.0},
+		err:  true,
+	}, {
+		msg:  "invalid type",
+		args: []interface{}{true},
+		err:  true,
+	}, {
+		msg:  "invalid size",
+		args: []interface{}{"10XB"},
+		err:  true,
+	}, {
+		msg:  "negative size",
+		args: []interface{}{"-1KB"},
+		err:  true,
+	}, {
+		msg:  "size overflow",
+		args: []interface{}{"9223372036854775807KB"},
+		err:  true,
+	}, {
+		msg:  "zero",
+		args: []interface{}{0.0},
+		err:  true,
+	}, {
+		msg:      "number",
+		args:     []interface{}{1000.0},
+		expected: 1000,
+	}, {
+		msg:      "size with unit",
+		args:     []interface{}{"10MB"},
+		expected: 10 << 20,
+	}, {
+		msg:      "size without unit",
+		args:     []interface{}{"512"},
+		expected: 512,
+	}} {
+		t.Run(tc.msg, func(t *testing.T) {
+			p, err := NewDecompressedSizeAbove().Create(tc.args)
+			if tc.err {
+				if err == nil {
+					t.Error("expected error")
+				}
+
+				return
+			}
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if min := p.(*decompressedSizeAbovePredicate).min; min != tc.expected {
+				t.Errorf("expected: %d, got: %d", tc.expected, min)
+			}
+		})
+	}
+}
+
+func compress(t *testing.T, coding string, size int) []byte {
+	var (
+		buf bytes.Buffer
+		w   io.WriteCloser
+	)
+
+	switch coding {
+	case "gzip":
+		w = gzip.NewWriter(&buf)
+	case "deflate":
+		w, _ = flate.NewWriter(&buf, flate.BestCompression)
+	case "br":
+		w = brotli.NewWriter(&buf)
+	}
+
+	if _, err := w.Write(bytes.Repeat([]byte("x"), size)); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	return buf.Bytes()
+}
+
+func TestDecompressedSizeAboveMatch(t *testing.T) {
+	for _, tc := range []struct {
+		msg      string
+		min      interface{}
+		body     []byte
+		length   int64
+		encoding string
+		hint     string
+		match    bool
+	}{{
+		msg:    "not encoded above min",
+		min:    1000.0,
+		length: 1001,
+		match:  true,
+	}, {
+		msg:    "not encoded at min",
+		min:    1000.0,
+		length: 1000,
+	}, {
+		msg:    "not encoded with unknown length above min",
+		min:    1000.0,
+		body:   bytes.Repeat([]byte("x"), 1001),
+		length: -1,
+		match:  true,
+	}, {
+		msg:    "not encoded with unknown length below min",
+		min:    1000.0,
+		body:   bytes.Repeat([]byte("x"), 999),
+		length: -1,
+	}, {
+		msg:      "gzip above min",
+		min:      "1MB",
+		body:     compress(t, "gzip", 2<<20),
+		encoding: "gzip",
+		match:    true,
+	}, {
+		msg:      "gzip below min",
+		min:      "1MB",
+		body:     compress(t, "gzip", 512<<10),
+		encoding: "gzip",
+	}, {
+		msg:      "gzip at min",
+		min:      "1MB",
+		body:     compress(t, "gzip", 1<<20),
+		encoding: "gzip",
+	}, {
+		msg:      "deflate above min",
+		min:      "1MB",
+		body:     compress(t, "deflate", 2<<20),
+		encoding: "deflate",
+		match:    true,
+	}, {
+		msg:      "brotli above min",
+		min:      "1MB",
+		body:     compress(t, "br", 2<<20),
+		encoding: "br",
+		match:    true,
+	}, {
+		msg:      "unsupported coding",
+		min:      "1MB",
+		body:     compress(t, "gzip", 2<<20),
+		encoding: "zstd",
+	}, {
+		msg:      "invalid compressed body",
+		min:      1000.0,
+		body:     bytes.Repeat([]byte("x"), 100),
+		encoding: "gzip",
+	}, {
+		msg:      "compressed body above min",
+		min:      100.0,
+		body:     bytes.Repeat([]byte{0}, 1000),
+		encoding: "deflate",
+		match:    true,
+	}, {
+		msg:      "gzip above min larger than the buffer limit",
+		min:      "2MB",
+		body:     compress(t, "gzip", 4<<20),
+		encoding: "gzip",
+		match:    true,
+	}, {
+		msg:    "not encoded with unknown length above the buffer limit",
+		min:    "2MB",
+		body:   bytes.Repeat([]byte("x"), 3<<20),
+		length: -1,
+	}, {
+		msg:      "hint above min",
+		min:      "1MB",
+		body:     compress(t, "gzip", 512<<10),
+		encoding: "gzip",
+		hint:     "2097152",
+		match:    true,
+	}, {
+		msg:      "hint below min",
+		min:      "1MB",
+		body:     compress(t, "gzip", 2<<20),
+		encoding: "gzip",
+		hint:     "1024",
+	}} {
+		t.Run(tc.msg, func(t *testing.T) {
+			p, err := NewDecompressedSizeAbove().Create([]interface{}{tc.min})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			req := &http.Request{ContentLength: tc.length, Header: http.Header{}, Body: http.NoBody}
+			if tc.body != nil {
+				req.Body = io.NopCloser(bytes.NewReader(tc.body))
+				if tc.length == 0 {
+					req.ContentLength = int64(len(tc.body))
+				}
+			}
+
+			if tc.encoding != "" {
+				req.Header.Set("Content-Encoding", tc.encoding)
+			}
+
+			if tc.hint != "" {
+				req.Header.Set(DecompressedSizeHintHeader, tc.hint)
+			}
+
+			if p.Match(req) != tc.match {
+				t.Errorf("expected match: %v", tc.match)
+			}
+
+			if tc.body == nil {
+				return
+			}
+
+			b, err := io.ReadAll(req.Body)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if !bytes.Equal(b, tc.body) {
+				t.Error("the body was changed")
+			}
+		})
+	}
+}
+
+func TestDecompressedSizeAboveMeasuredOnce(t *testing.T) {
+	create := func(min string) *decompressedSizeAbovePredicate {
+		p, err := NewDecompressedSizeAbove().Create([]interface{}{min})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		return p.(*decompressedSizeAbovePredicate)
+	}
+
+	body := compress(t, "gzip", 2<<20)
+	req := &http.Request{
+		ContentLength: int64(len(body)),
+		Header:        http.Header{"Content-Encoding": []string{"gzip"}},
+		Body:          io.NopCloser(bytes.NewReader(body)),
+	}
+
+	if create("3MB").Match(req) {
+		t.Fatal("unexpected match")
+	}
+
+	measured := req.Body
+	if !create("1MB").Match(req) {
+		t.Fatal("failed to match")
+	}
+
+	if create("4MB").Match(req) {
+		t.Fatal("unexpected match")
+	}
+
+	if req.Body != measured {
+		t.Error("the body was measured again")
+	}
+
+	// a larger limit after an incomplete measurement measures again
+	req.Body = io.NopCloser(bytes.NewReader(body))
+	if !create("1MB").Match(req) {
+		t.Fatal("failed to match")
+	}
+
+	if create("3MB").Match(req) {
+		t.Fatal("unexpected match")
+	}
+
+	b, err := io.ReadAll(req.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !bytes.Equal(b, body) {
+		t.Error("the body was changed")
+	}
+}
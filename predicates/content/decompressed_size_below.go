@@ -0,0 +1,112 @@
+package content
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/zalando/skipper/predicates"
+	"github.com/zalando/skipper/routing"
+)
+
+// DecompressedSizeHintHeader is the request header, that an upstream
+// component can use to declare the decompressed size of the request body.
+const DecompressedSizeHintHeader = "X-Decompressed-Content-Length"
+
+const defaultDecompressionRatio = 10
+
+type decompressedSizeBelowSpec struct{}
+
+type decompressedSizeBelowPredicate struct {
+	max   int64
+	ratio float64
+}
+
+// NewDecompressedSizeBelow creates a predicate specification, whose instances
+// match requests whose estimated body size after decompression is below max.
+//
+// As the decompressed size is not known before reading the body, it is
+// estimated: the value of the X-Decompressed-Content-Length header is used
+// when the request body is encoded and the header is set. Otherwise the
+// Content-Length is multiplied by the expected compression ratio, which
+// is 10 by default, for each content coding applied. Requests with unknown
+// content length don't match.
+//
+// example: DecompressedSizeBelow(1048576)
+// example: DecompressedSizeBelow(1048576, 20)
+func NewDecompressedSizeBelow() routing.PredicateSpec { return &decompressedSizeBelowSpec{} }
+
+func (*decompressedSizeBelowSpec) Name() string {
+	return predicates.DecompressedSizeBelowName
+}
+
+func (*decompressedSizeBelowSpec) Create(args []interface{}) (routing.Predicate, error) {
+	if len(args) == 0 || len(args) > 2 {
+		return nil, predicates.ErrInvalidPredicateParameters
+	}
+
+	x, ok := args[0].(float64)
+	if !ok || x <= 0 {
+		return nil, predicates.ErrInvalidPredicateParameters
+	}
+
+	p := &decompressedSizeBelowPredicate{max: int64(x), ratio: defaultDecompressionRatio}
+	if len(args) == 2 {
+		r, ok := args[1].(float64)
+		if !ok || r < 1 {
+			return nil, predicates.ErrInvalidPredicateParameters
+		}
+
+		p.ratio = r
+	}
+
+	return p, nil
+}
+
+// codings returns the number of content codings applied to the request body
+func codings(req *http.Request) int {
+	var n int
+	for _, h := range req.Header.Values("Content-Encoding") {
+		for _, c := range strings.Split(h, ",") {
+			if c = strings.TrimSpace(c); c != "" && !strings.EqualFold(c, "identity") {
+				n++
+			}
+		}
+	}
+
+	return n
+}
+
+func (p *decompressedSizeBelowPredicate) estimate(req *http.Request) (int64, bool) {
+	n := codings(req)
+	if n == 0 {
+		return req.ContentLength, req.ContentLength >= 0
+	}
+
+	if h := req.Header.Get(DecompressedSizeHintHeader); h != "" {
+		if size, err := strconv.ParseInt(h, 10, 64); err == nil && size >= 0 {
+			return size, true
+		}
+	}
+
+	if req.ContentLength < 0 {
+		return 0, false
+	}
+
+	size := float64(req.ContentLength)
+	for i := 0; i < n; i++ {
+		size *= p.ratio
+	}
+
+	// avoid overflow of huge estimates
+	if size >= float64(p.max) {
+		return p.max, true
+	}
+
+	return int64(size), true
+}
+
+func (p *decompressedSizeBelowPredicate) Match(req *http.Request) bool {
+	size, ok := p.estimate(req)
+	return ok && size < p.max
+}
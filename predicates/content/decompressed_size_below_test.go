@@ -0,0 +1,152 @@
+package content
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestDecompressedSizeBelowCreate(t *testing.T) {
+	for _, tc := range []struct {
+		msg  string
+		args []interface{}
+		err  bool
+	}{{
+		msg: "no args",
+		err: true,
+	}, {
+		msg:  "too many args",
+		args: []interface{}{1000.0, 10.0, 1.0},
+		err:  true,
+	}, {
+		msg:  "not a number",
+		args: []interface{}{"1000"},
+		err:  true,
+	}, {
+		msg:  "zero max",
+		args: []interface{}{0.0},
+		err:  true,
+	}, {
+		msg:  "ratio below one",
+		args: []interface{}{1000.0, 0.5},
+		err:  true,
+	}, {
+		msg:  "max",
+		args: []interface{}{1000.0},
+	}, {
+		msg:  "max and ratio",
+		args: []interface{}{1000.0, 20.0},
+	}} {
+		t.Run(tc.msg, func(t *testing.T) {
+			_, err := NewDecompressedSizeBelow().Create(tc.args)
+			if tc.err && err == nil {
+				t.Error("expected error")
+			} else if !tc.err && err != nil {
+				t.Error(err)
+			}
+		})
+	}
+}
+
+func TestDecompressedSizeBelowMatch(t *testing.T) {
+	for _, tc := range []struct {
+		msg    string
+		args   []interface{}
+		length int64
+		header http.Header
+		match  bool
+	}{{
+		msg:    "not encoded below max",
+		args:   []interface{}{1000.0},
+		length: 999,
+		match:  true,
+	}, {
+		msg:    "not encoded at max",
+		args:   []interface{}{1000.0},
+		length: 1000,
+	}, {
+		msg:    "identity encoding",
+		args:   []interface{}{1000.0},
+		length: 999,
+		header: http.Header{"Content-Encoding": []string{"identity"}},
+		match:  true,
+	}, {
+		msg:    "unknown length",
+		args:   []interface{}{1000.0},
+		length: -1,
+	}, {
+		msg:    "encoded with default ratio below max",
+		args:   []interface{}{1000.0},
+		length: 99,
+		header: http.Header{"Content-Encoding": []string{"gzip"}},
+		match:  true,
+	}, {
+		msg:    "encoded with default ratio at max",
+		args:   []interface{}{1000.0},
+		length: 100,
+		header: http.Header{"Content-Encoding": []string{"gzip"}},
+	}, {
+		msg:    "encoded with custom ratio",
+		args:   []interface{}{1000.0, 2.0},
+		length: 400,
+		header: http.Header{"Content-Encoding": []string{"gzip"}},
+		match:  true,
+	}, {
+		msg:    "multiple codings",
+		args:   []interface{}{1000.0},
+		length: 20,
+		header: http.Header{"Content-Encoding": []string{"gzip, br"}},
+	}, {
+		msg:    "encoded with unknown length",
+		args:   []interface{}{1000.0},
+		length: -1,
+		header: http.Header{"Content-Encoding": []string{"gzip"}},
+	}, {
+		msg:    "hint below max",
+		args:   []interface{}{1000.0},
+		length: 500,
+		header: http.Header{"Content-Encoding": []string{"gzip"}, "X-Decompressed-Content-Length": []string{"999"}},
+		match:  true,
+	}, {
+		msg:    "hint at max",
+		args:   []interface{}{1000.0},
+		length: 10,
+		header: http.Header{"Content-Encoding": []string{"gzip"}, "X-Decompressed-Content-Length": []string{"1000"}},
+	}, {
+		msg:    "hint with unknown length",
+		args:   []interface{}{1000.0},
+		length: -1,
+		header: http.Header{"Content-Encoding": []string{"gzip"}, "X-Decompressed-Content-Length": []string{"999"}},
+		match:  true,
+	}, {
+		msg:    "invalid hint falls back to estimate",
+		args:   []interface{}{1000.0},
+		length: 500,
+		header: http.Header{"Content-Encoding": []string{"gzip"}, "X-Decompressed-Content-Length": []string{"small"}},
+	}, {
+		msg:    "hint ignored without encoding",
+		args:   []interface{}{1000.0},
+		length: 1000,
+		header: http.Header{"X-Decompressed-Content-Length": []string{"10"}},
+	}, {
+		msg:    "huge estimate",
+		args:   []interface{}{1000.0, 1e300},
+		length: 1e18,
+		header: http.Header{"Content-Encoding": []string{"gzip, gzip"}},
+	}} {
+		t.Run(tc.msg, func(t *testing.T) {
+			p, err := NewDecompressedSizeBelow().Create(tc.args)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			req := &http.Request{ContentLength: tc.length, Header: http.Header{}}
+			for k, v := range tc.header {
+				req.Header[k] = v
+			}
+
+			if p.Match(req) != tc.match {
+				t.Errorf("expected match: %v", tc.match)
+			}
+		})
+	}
+}
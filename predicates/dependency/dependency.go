@@ -0,0 +1,77 @@
+/*
+Package dependency implements a predicate to match the requests only when
+a dependency of the route is healthy, i.e. when the circuit breaker of the
+dependency, tracked by the proxy, is closed. It allows deactivating a
+canary route, that depends on a flaky service, while the service is
+failing, so that the traffic falls back to the stable routes.
+
+The dependencies are named by the hosts of the circuit breaker settings,
+e.g. as configured with the -breaker flag:
+
+	-breaker type=consecutive,host=payments.example.org,failures=5
+
+The predicate checks the breaker of the host with its configured
+settings, and it doesn't check the breakers created with route specific
+settings, e.g. by the consecutiveBreaker filter. While the breaker of the
+host is not used, or it was recycled as idle, the dependency is
+considered healthy.
+
+Examples:
+
+	canary: Path("/checkout") && TrafficSegment(0, 0.1) && DependencyHealthy("payments.example.org")
+	  -> "https://checkout-canary.example.org";
+	main: Path("/checkout") -> "https://checkout.example.org";
+*/
+package dependency
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/zalando/skipper/circuit"
+	"github.com/zalando/skipper/predicates"
+	"github.com/zalando/skipper/routing"
+)
+
+type spec struct {
+	registry *circuit.Registry
+}
+
+type predicate struct {
+	registry *circuit.Registry
+	host     string
+}
+
+// New creates a DependencyHealthy predicate specification, checking the
+// circuit breakers of the registry. The registry is typically the one used
+// as proxy.Params.CircuitBreakers. When the registry is nil, the creation
+// of every predicate fails, because no dependency is known.
+func New(registry *circuit.Registry) routing.PredicateSpec {
+	return &spec{registry: registry}
+}
+
+func (*spec) Name() string { return predicates.DependencyHealthyName }
+
+// Create a predicate instance with a single argument, the name of the
+// dependency, which needs to be a host with configured circuit breaker
+// settings.
+func (s *spec) Create(args []interface{}) (routing.Predicate, error) {
+	if len(args) != 1 {
+		return nil, predicates.ErrInvalidPredicateParameters
+	}
+
+	name, ok := args[0].(string)
+	if !ok || name == "" {
+		return nil, predicates.ErrInvalidPredicateParameters
+	}
+
+	if s.registry == nil || !s.registry.HasHost(name) {
+		return nil, fmt.Errorf("unknown dependency: %s", name)
+	}
+
+	return &predicate{registry: s.registry, host: name}, nil
+}
+
+func (p *predicate) Match(*http.Request) bool {
+	return p.registry.Closed(p.host)
+}
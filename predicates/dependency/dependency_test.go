@@ -0,0 +1,113 @@
+package dependency
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/zalando/skipper/circuit"
+)
+
+func TestCreate(t *testing.T) {
+	registry := circuit.NewRegistry(circuit.BreakerSettings{
+		Type:     circuit.ConsecutiveFailures,
+		Host:     "payments.example.org",
+		Failures: 3,
+	})
+
+	for _, test := range []struct {
+		title    string
+		registry *circuit.Registry
+		args     []interface{}
+		fail     bool
+	}{{
+		title:    "no args",
+		registry: registry,
+		fail:     true,
+	}, {
+		title:    "too many args",
+		registry: registry,
+		args:     []interface{}{"payments.example.org", "stock.example.org"},
+		fail:     true,
+	}, {
+		title:    "not a string",
+		registry: registry,
+		args:     []interface{}{42.0},
+		fail:     true,
+	}, {
+		title:    "empty name",
+		registry: registry,
+		args:     []interface{}{""},
+		fail:     true,
+	}, {
+		title:    "unknown name",
+		registry: registry,
+		args:     []interface{}{"stock.example.org"},
+		fail:     true,
+	}, {
+		title: "no registry",
+		args:  []interface{}{"payments.example.org"},
+		fail:  true,
+	}, {
+		title:    "known name",
+		registry: registry,
+		args:     []interface{}{"payments.example.org"},
+	}} {
+		t.Run(test.title, func(t *testing.T) {
+			_, err := New(test.registry).Create(test.args)
+			if test.fail && err == nil {
+				t.Fatal("failed to fail")
+			}
+
+			if !test.fail && err != nil {
+				t.Fatal(err)
+			}
+		})
+	}
+}
+
+func TestMatch(t *testing.T) {
+	registry := circuit.NewRegistry(
+		circuit.BreakerSettings{IdleTTL: time.Hour},
+		circuit.BreakerSettings{
+			Type:     circuit.ConsecutiveFailures,
+			Host:     "payments.example.org",
+			Failures: 2,
+			Timeout:  time.Hour,
+		},
+	)
+
+	p, err := New(registry).Create([]interface{}{"payments.example.org"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	req, err := http.NewRequest("GET", "https://www.example.org/checkout", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !p.Match(req) {
+		t.Fatal("failed to match before the breaker was used")
+	}
+
+	b := registry.Get(circuit.BreakerSettings{Host: "payments.example.org"})
+	fail := func() {
+		done, ok := b.Allow()
+		if !ok {
+			t.Fatal("unexpected open breaker")
+		}
+
+		done(false)
+	}
+
+	fail()
+	if !p.Match(req) {
+		t.Fatal("failed to match while the breaker is closed")
+	}
+
+	fail()
+	if p.Match(req) {
+		t.Fatal("unexpected match while the breaker is open")
+	}
+}
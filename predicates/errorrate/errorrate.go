@@ -0,0 +1,205 @@
+/*
+Package errorrate implements a predicate to match the requests only while
+the recent ratio of the 5xx responses of the route is below a threshold,
+e.g. to protect a canary route: when the canary starts failing, the route
+stops matching, and the traffic flows to a fallback route.
+
+The responses of the route are counted in a sliding window, approximated
+by ten buckets, and the ratio is evaluated only after at least ten
+responses in the window. The counts are tracked in memory, per skipper
+instance, by route id, so they are kept across the routing updates, as
+long as the id and the window of the route don't change.
+
+While the route doesn't match, it serves no responses, so the failed
+responses leave the window eventually, and the route matches again, i.e.
+the window acts as the cooldown.
+
+Examples:
+
+	canary: Path("/") && TrafficSegment(0.9, 1, "canary") && ErrorRateBelow(0.05, "1m") -> "https://canary.example.org";
+	main: Path("/") -> "https://www.example.org";
+*/
+package errorrate
+
+import (
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/zalando/skipper/eskip"
+	"github.com/zalando/skipper/internal/window"
+	"github.com/zalando/skipper/predicates"
+	"github.com/zalando/skipper/routing"
+)
+
+const minResponses = 10
+
+// the kinds of the counted responses
+const (
+	responses = iota
+	errors
+)
+
+type tracker struct {
+	mu      sync.Mutex
+	routeId string
+	counts  *window.Counter
+	last    time.Time
+
+	// set when the tracker was dropped as idle, while it may still be
+	// used by the current predicate of the route
+	dropped bool
+}
+
+// Spec is the ErrorRateBelow predicate specification. The predicates of
+// the same specification share the trackers of the routes.
+type Spec struct {
+	mu       sync.Mutex
+	trackers map[string]*tracker
+	now      func() time.Time
+}
+
+type predicate struct {
+	spec      *Spec
+	threshold float64
+	window    time.Duration
+
+	// set by the routing, before the predicate is used
+	tracker *tracker
+}
+
+// New creates an ErrorRateBelow predicate specification.
+func New() *Spec {
+	return &Spec{
+		trackers: make(map[string]*tracker),
+		now:      time.Now,
+	}
+}
+
+func (*Spec) Name() string { return predicates.ErrorRateBelowName }
+
+// ArgsSchema declares the arguments: the threshold of the ratio of the 5xx
+// responses and the duration of the window, e.g. "1m".
+func (*Spec) ArgsSchema() eskip.ArgsSchema {
+	return eskip.ArgsSchema{Required: []eskip.ArgType{eskip.ArgNumber, eskip.ArgDuration}}
+}
+
+// Create a predicate instance with two arguments, as converted according to
+// ArgsSchema: the threshold, between 0 (exclusive) and 1 (inclusive), and
+// the window.
+func (s *Spec) Create(args []interface{}) (routing.Predicate, error) {
+	if len(args) != 2 {
+		return nil, predicates.ErrInvalidPredicateParameters
+	}
+
+	threshold, ok := args[0].(float64)
+	if !ok || threshold <= 0 || threshold > 1 {
+		return nil, predicates.ErrInvalidPredicateParameters
+	}
+
+	w, ok := args[1].(time.Duration)
+	if !ok || w < window.Buckets {
+		return nil, predicates.ErrInvalidPredicateParameters
+	}
+
+	return &predicate{spec: s, threshold: threshold, window: w}, nil
+}
+
+// tracker returns the tracker of the route, creating it when it doesn't
+// exist, or when the window of the route changed. The idle trackers, e.g.
+// of the deleted routes, are dropped.
+func (s *Spec) tracker(routeId string, w time.Duration) *tracker {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	for id, t := range s.trackers {
+		if id != routeId && t.drop(now) {
+			delete(s.trackers, id)
+		}
+	}
+
+	t, ok := s.trackers[routeId]
+	if !ok || t.counts.Window() != w {
+		t = &tracker{routeId: routeId, counts: window.New(w, 2), last: now}
+		s.trackers[routeId] = t
+	}
+
+	return t
+}
+
+// readopt stores a dropped tracker again, that got a new response, unless
+// the route got a new tracker meanwhile.
+func (s *Spec) readopt(t *tracker) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.trackers[t.routeId]; !ok {
+		s.trackers[t.routeId] = t
+	}
+}
+
+// drop marks the tracker dropped, when it had no responses within the
+// window.
+func (t *tracker) drop(now time.Time) bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.dropped = now.Sub(t.last) > t.counts.Window()
+	return t.dropped
+}
+
+// observe counts a response, and tells whether the tracker was dropped.
+func (t *tracker) observe(now time.Time, failed bool) (dropped bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.counts.Inc(now, responses)
+	if failed {
+		t.counts.Inc(now, errors)
+	}
+
+	t.last = now
+	dropped, t.dropped = t.dropped, false
+	return dropped
+}
+
+// rate returns the ratio of the failed responses within the window, and
+// false, when there were not enough responses to evaluate it.
+func (t *tracker) rate(now time.Time) (float64, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	sum := t.counts.Sum(now)
+	if sum[responses] < minResponses {
+		return 0, false
+	}
+
+	return float64(sum[errors]) / float64(sum[responses]), true
+}
+
+func (p *predicate) SetRouteId(id string) {
+	p.tracker = p.spec.tracker(id, p.window)
+}
+
+func (p *predicate) ObserveResponse(statusCode int) {
+	if p.tracker == nil {
+		return
+	}
+
+	if p.tracker.observe(p.spec.now(), statusCode >= 500 && statusCode < 600) {
+		p.spec.readopt(p.tracker)
+	}
+}
+
+// Match matches while the ratio of the 5xx responses of the route within
+// the window is below the threshold, or when there were less than ten
+// responses within the window.
+func (p *predicate) Match(*http.Request) bool {
+	if p.tracker == nil {
+		return true
+	}
+
+	rate, ok := p.tracker.rate(p.spec.now())
+	return !ok || rate < p.threshold
+}
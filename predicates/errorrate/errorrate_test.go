@@ -0,0 +1,239 @@
+package errorrate
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/zalando/skipper/eskip"
+	"github.com/zalando/skipper/filters/builtin"
+	"github.com/zalando/skipper/proxy/proxytest"
+	"github.com/zalando/skipper/routing"
+)
+
+func TestCreate(t *testing.T) {
+	s := New()
+	for _, tt := range []struct {
+		msg  string
+		args []interface{}
+		err  bool
+	}{{
+		msg: "no args",
+		err: true,
+	}, {
+		msg:  "missing window",
+		args: []interface{}{0.05},
+		err:  true,
+	}, {
+		msg:  "zero threshold",
+		args: []interface{}{0.0, "1m"},
+		err:  true,
+	}, {
+		msg:  "threshold above one",
+		args: []interface{}{1.5, "1m"},
+		err:  true,
+	}, {
+		msg:  "invalid threshold",
+		args: []interface{}{"5%", "1m"},
+		err:  true,
+	}, {
+		msg:  "invalid window",
+		args: []interface{}{0.05, "one minute"},
+		err:  true,
+	}, {
+		msg:  "negative window",
+		args: []interface{}{0.05, "-1m"},
+		err:  true,
+	}, {
+		msg:  "too many args",
+		args: []interface{}{0.05, "1m", "1m"},
+		err:  true,
+	}, {
+		msg:  "window too short",
+		args: []interface{}{0.05, "5ns"},
+		err:  true,
+	}, {
+		msg:  "threshold and window",
+		args: []interface{}{0.05, "1m"},
+	}, {
+		msg:  "threshold one",
+		args: []interface{}{1.0, "10s"},
+	}} {
+		t.Run(tt.msg, func(t *testing.T) {
+			args, err := s.ArgsSchema().Parse(tt.args)
+			if err == nil {
+				_, err = s.Create(args)
+			}
+
+			if tt.err && err == nil {
+				t.Fatal("failed to fail")
+			}
+
+			if !tt.err && err != nil {
+				t.Fatal(err)
+			}
+		})
+	}
+}
+
+type clock struct {
+	now time.Time
+}
+
+func (c *clock) get() time.Time { return c.now }
+
+func newTestSpec() (*Spec, *clock) {
+	c := &clock{now: time.Date(2026, 10, 14, 12, 0, 0, 0, time.UTC)}
+	s := New()
+	s.now = c.get
+	return s, c
+}
+
+func create(t *testing.T, s *Spec, routeId string, threshold float64, window string) routing.ResponseObserverPredicate {
+	args, err := s.ArgsSchema().Parse([]interface{}{threshold, window})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	p, err := s.Create(args)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	o := p.(routing.ResponseObserverPredicate)
+	o.SetRouteId(routeId)
+	return o
+}
+
+func observe(p routing.ResponseObserverPredicate, ok, failed int) {
+	for i := 0; i < ok; i++ {
+		p.ObserveResponse(http.StatusOK)
+	}
+
+	for i := 0; i < failed; i++ {
+		p.ObserveResponse(http.StatusBadGateway)
+	}
+}
+
+func TestMatch(t *testing.T) {
+	s, c := newTestSpec()
+	p := create(t, s, "canary", 0.2, "10s")
+
+	if !p.Match(nil) {
+		t.Fatal("failed to match without responses")
+	}
+
+	// not enough responses
+	observe(p, 0, 9)
+	if !p.Match(nil) {
+		t.Fatal("failed to match with too few responses")
+	}
+
+	observe(p, 41, 0)
+	if !p.Match(nil) {
+		t.Fatal("failed to match below the threshold")
+	}
+
+	// the 4xx responses don't count as errors
+	for i := 0; i < 10; i++ {
+		p.ObserveResponse(http.StatusNotFound)
+	}
+
+	if !p.Match(nil) {
+		t.Fatal("failed to match with client errors")
+	}
+
+	observe(p, 0, 4)
+	if p.Match(nil) {
+		t.Fatal("unexpected match above the threshold")
+	}
+
+	// the errors leave the window
+	c.now = c.now.Add(5 * time.Second)
+	if p.Match(nil) {
+		t.Fatal("unexpected match within the window")
+	}
+
+	c.now = c.now.Add(6 * time.Second)
+	if !p.Match(nil) {
+		t.Fatal("failed to match after the errors left the window")
+	}
+}
+
+func TestStateKeptAcrossUpdates(t *testing.T) {
+	s, c := newTestSpec()
+	p := create(t, s, "canary", 0.5, "10s")
+	observe(p, 0, 10)
+	if p.Match(nil) {
+		t.Fatal("unexpected match")
+	}
+
+	// the routing update creates new predicates
+	if create(t, s, "canary", 0.5, "10s").Match(nil) {
+		t.Fatal("unexpected match after update")
+	}
+
+	if !create(t, s, "other", 0.5, "10s").Match(nil) {
+		t.Fatal("failed to match other route")
+	}
+
+	if !create(t, s, "canary", 0.5, "20s").Match(nil) {
+		t.Fatal("failed to match after the window changed")
+	}
+
+	// idle trackers are dropped, and adopted again by the predicates
+	// holding them, when they get new responses
+	p = create(t, s, "idle", 0.5, "10s")
+	c.now = c.now.Add(11 * time.Second)
+	create(t, s, "other", 0.5, "10s")
+	if _, ok := s.trackers["idle"]; ok {
+		t.Fatal("failed to drop the idle tracker")
+	}
+
+	observe(p, 0, 10)
+	if create(t, s, "idle", 0.5, "10s").Match(nil) {
+		t.Fatal("unexpected match after the dropped tracker got responses")
+	}
+}
+
+func TestProxy(t *testing.T) {
+	canary := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer canary.Close()
+
+	stable := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer stable.Close()
+
+	routes, err := eskip.Parse(fmt.Sprintf(`
+		canary: Path("/") && ErrorRateBelow(0.5, "1m") -> "%s";
+		stable: Path("/") -> "%s";
+	`, canary.URL, stable.URL))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	p := proxytest.WithRoutingOptions(builtin.MakeRegistry(), routing.Options{
+		Predicates: []routing.PredicateSpec{New()},
+	}, routes...)
+	defer p.Close()
+
+	statusCodes := make(map[int]int)
+	for i := 0; i < 20; i++ {
+		rsp, err := http.Get(p.URL)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		rsp.Body.Close()
+		statusCodes[rsp.StatusCode]++
+	}
+
+	if statusCodes[http.StatusInternalServerError] != minResponses || statusCodes[http.StatusOK] != 20-minResponses {
+		t.Errorf("unexpected status codes: %v", statusCodes)
+	}
+}
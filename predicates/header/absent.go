@@ -0,0 +1,59 @@
+/*
+Package header implements custom predicates to match routes based on
+the request headers, complementing the builtin Header and HeaderRegexp
+predicates.
+
+Examples:
+
+	// matches requests without the X-Tenant header, or with an empty value
+	noTenant: HeaderAbsent("X-Tenant") -> setRequestHeader("X-Tenant", "default") -> <loopback>;
+
+	// matches requests bypassing the caches
+	cacheBypass: CacheControl("no-cache") -> "https://canary.example.org";
+
+	// matches requests declaring the gRPC status trailer
+	grpc: HasTrailer("Grpc-Status") -> "https://grpc.example.org";
+*/
+package header
+
+import (
+	"net/http"
+
+	"github.com/zalando/skipper/predicates"
+	"github.com/zalando/skipper/routing"
+)
+
+type absentSpec struct{}
+
+type absentPredicate struct {
+	name string
+}
+
+// NewAbsent creates a predicate specification, whose instances match
+// requests where the header is not set, or all its values are empty.
+func NewAbsent() routing.PredicateSpec { return &absentSpec{} }
+
+func (*absentSpec) Name() string { return predicates.HeaderAbsentName }
+
+func (*absentSpec) Create(args []interface{}) (routing.Predicate, error) {
+	if len(args) != 1 {
+		return nil, predicates.ErrInvalidPredicateParameters
+	}
+
+	name, ok := args[0].(string)
+	if !ok || name == "" {
+		return nil, predicates.ErrInvalidPredicateParameters
+	}
+
+	return &absentPredicate{name: http.CanonicalHeaderKey(name)}, nil
+}
+
+func (p *absentPredicate) Match(req *http.Request) bool {
+	for _, v := range req.Header[p.name] {
+		if v != "" {
+			return false
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,86 @@
+package header
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestAbsentCreate(t *testing.T) {
+	for _, tt := range []struct {
+		msg  string
+		args []interface{}
+		err  bool
+	}{{
+		msg: "no args",
+		err: true,
+	}, {
+		msg:  "too many args",
+		args: []interface{}{"X-Tenant", "foo"},
+		err:  true,
+	}, {
+		msg:  "not a string",
+		args: []interface{}{42.0},
+		err:  true,
+	}, {
+		msg:  "empty name",
+		args: []interface{}{""},
+		err:  true,
+	}, {
+		msg:  "header name",
+		args: []interface{}{"X-Tenant"},
+	}} {
+		t.Run(tt.msg, func(t *testing.T) {
+			_, err := NewAbsent().Create(tt.args)
+			if tt.err && err == nil {
+				t.Error("failed to fail")
+			} else if !tt.err && err != nil {
+				t.Error(err)
+			}
+		})
+	}
+}
+
+func TestAbsentMatch(t *testing.T) {
+	for _, tt := range []struct {
+		msg    string
+		header http.Header
+		match  bool
+	}{{
+		msg:   "absent",
+		match: true,
+	}, {
+		msg:    "other header",
+		header: http.Header{"X-Foo": []string{"bar"}},
+		match:  true,
+	}, {
+		msg:    "empty",
+		header: http.Header{"X-Tenant": []string{""}},
+		match:  true,
+	}, {
+		msg:    "present",
+		header: http.Header{"X-Tenant": []string{"foo"}},
+	}, {
+		msg:    "present after empty value",
+		header: http.Header{"X-Tenant": []string{"", "foo"}},
+	}} {
+		t.Run(tt.msg, func(t *testing.T) {
+			p, err := NewAbsent().Create([]interface{}{"x-tenant"})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			req, err := http.NewRequest("GET", "https://www.example.org", nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			for k, v := range tt.header {
+				req.Header[k] = v
+			}
+
+			if m := p.Match(req); m != tt.match {
+				t.Errorf("expected match: %v, got: %v", tt.match, m)
+			}
+		})
+	}
+}
@@ -0,0 +1,102 @@
+package header
+
+import (
+	"net/http"
+	"strings"
+
+	"golang.org/x/net/http/httpguts"
+
+	"github.com/zalando/skipper/predicates"
+	"github.com/zalando/skipper/routing"
+)
+
+type cacheControlSpec struct{}
+
+type cacheControlPredicate struct {
+	directive string
+	value     string
+	hasValue  bool
+}
+
+// NewCacheControl creates a predicate specification, whose instances match
+// requests with the given directive in the Cache-Control header. The
+// directive is either a name, e.g. "no-cache", matching the directive with
+// any value, or a name and a value, e.g. "max-age=0", matching only the
+// same value. The directive names are matched case-insensitively.
+func NewCacheControl() routing.PredicateSpec { return &cacheControlSpec{} }
+
+func (*cacheControlSpec) Name() string { return predicates.CacheControlName }
+
+func (*cacheControlSpec) Create(args []interface{}) (routing.Predicate, error) {
+	if len(args) != 1 {
+		return nil, predicates.ErrInvalidPredicateParameters
+	}
+
+	d, ok := args[0].(string)
+	if !ok {
+		return nil, predicates.ErrInvalidPredicateParameters
+	}
+
+	p := &cacheControlPredicate{}
+	p.directive, p.value, p.hasValue = strings.Cut(d, "=")
+	if !httpguts.ValidHeaderFieldName(p.directive) || p.hasValue && !httpguts.ValidHeaderFieldName(p.value) {
+		return nil, predicates.ErrInvalidPredicateParameters
+	}
+
+	p.directive = strings.ToLower(p.directive)
+	return p, nil
+}
+
+// splitDirectives splits a Cache-Control header value at the commas outside
+// of the quoted strings.
+func splitDirectives(h string) []string {
+	var (
+		d      []string
+		start  int
+		quoted bool
+	)
+
+	for i := 0; i < len(h); i++ {
+		switch h[i] {
+		case '\\':
+			if quoted {
+				i++
+			}
+		case '"':
+			quoted = !quoted
+		case ',':
+			if !quoted {
+				d = append(d, h[start:i])
+				start = i + 1
+			}
+		}
+	}
+
+	return append(d, h[start:])
+}
+
+func (p *cacheControlPredicate) Match(req *http.Request) bool {
+	for _, h := range req.Header.Values("Cache-Control") {
+		for _, d := range splitDirectives(h) {
+			name, value, _ := strings.Cut(strings.TrimSpace(d), "=")
+			if !strings.EqualFold(strings.TrimSpace(name), p.directive) {
+				continue
+			}
+
+			if !p.hasValue {
+				return true
+			}
+
+			value = strings.TrimSpace(value)
+			if len(value) >= 2 && value[0] == '"' && value[len(value)-1] == '"' {
+				value = value[1 : len(value)-1]
+			}
+
+			if value == p.value {
+				return true
+			}
+		}
+	}
+
+	return false
+}
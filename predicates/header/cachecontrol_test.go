@@ -0,0 +1,135 @@
+package header
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestCacheControlCreate(t *testing.T) {
+	for _, tt := range []struct {
+		msg  string
+		args []interface{}
+		err  bool
+	}{{
+		msg: "no args",
+		err: true,
+	}, {
+		msg:  "too many args",
+		args: []interface{}{"no-cache", "no-store"},
+		err:  true,
+	}, {
+		msg:  "not a string",
+		args: []interface{}{42.0},
+		err:  true,
+	}, {
+		msg:  "empty directive",
+		args: []interface{}{""},
+		err:  true,
+	}, {
+		msg:  "invalid token",
+		args: []interface{}{"no cache"},
+		err:  true,
+	}, {
+		msg:  "empty value",
+		args: []interface{}{"max-age="},
+		err:  true,
+	}, {
+		msg:  "directive",
+		args: []interface{}{"no-cache"},
+	}, {
+		msg:  "directive with value",
+		args: []interface{}{"max-age=0"},
+	}} {
+		t.Run(tt.msg, func(t *testing.T) {
+			_, err := NewCacheControl().Create(tt.args)
+			if tt.err && err == nil {
+				t.Error("failed to fail")
+			} else if !tt.err && err != nil {
+				t.Error(err)
+			}
+		})
+	}
+}
+
+func TestCacheControlMatch(t *testing.T) {
+	for _, tt := range []struct {
+		msg       string
+		directive string
+		header    []string
+		expected  bool
+	}{{
+		msg:       "no header",
+		directive: "no-cache",
+	}, {
+		msg:       "single directive",
+		directive: "no-cache",
+		header:    []string{"no-cache"},
+		expected:  true,
+	}, {
+		msg:       "case insensitive",
+		directive: "no-cache",
+		header:    []string{"No-Cache"},
+		expected:  true,
+	}, {
+		msg:       "list of directives",
+		directive: "no-cache",
+		header:    []string{"max-age=0, no-cache"},
+		expected:  true,
+	}, {
+		msg:       "multiple headers",
+		directive: "no-store",
+		header:    []string{"max-age=0", "no-store"},
+		expected:  true,
+	}, {
+		msg:       "other directive",
+		directive: "no-cache",
+		header:    []string{"no-store, max-age=0"},
+	}, {
+		msg:       "directive prefix",
+		directive: "no",
+		header:    []string{"no-cache"},
+	}, {
+		msg:       "any value",
+		directive: "max-age",
+		header:    []string{"max-age=60"},
+		expected:  true,
+	}, {
+		msg:       "value",
+		directive: "max-age=0",
+		header:    []string{"no-store, max-age=0"},
+		expected:  true,
+	}, {
+		msg:       "quoted value",
+		directive: "max-age=0",
+		header:    []string{`max-age="0"`},
+		expected:  true,
+	}, {
+		msg:       "different value",
+		directive: "max-age=0",
+		header:    []string{"max-age=60"},
+	}, {
+		msg:       "value without directive value",
+		directive: "max-age=0",
+		header:    []string{"max-age"},
+	}, {
+		msg:       "comma in quoted string",
+		directive: "no-store",
+		header:    []string{`foo="a, no-store"`},
+	}} {
+		t.Run(tt.msg, func(t *testing.T) {
+			p, err := NewCacheControl().Create([]interface{}{tt.directive})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			req := &http.Request{Header: http.Header{}}
+			for _, h := range tt.header {
+				req.Header.Add("Cache-Control", h)
+			}
+
+			if m := p.Match(req); m != tt.expected {
+				t.Errorf("expected match: %v, got: %v", tt.expected, m)
+			}
+		})
+	}
+}
@@ -0,0 +1,50 @@
+package header
+
+import (
+	"net/http"
+
+	"github.com/zalando/skipper/predicates"
+	"github.com/zalando/skipper/routing"
+)
+
+// the preconditions defined by RFC 9110, section 13.1
+var conditionalHeaders = []string{
+	"If-None-Match",
+	"If-Modified-Since",
+	"If-Match",
+	"If-Unmodified-Since",
+}
+
+type conditionalSpec struct{}
+
+type conditionalPredicate struct{}
+
+// NewConditional creates a predicate specification, whose instances match
+// the conditional requests, i.e. the requests with any of the If-None-Match,
+// If-Modified-Since, If-Match or If-Unmodified-Since headers, e.g. to route
+// the revalidation requests to a cache.
+//
+// Example:
+//
+//	revalidate: Method("GET") && Conditional() -> "https://cache.example.org";
+func NewConditional() routing.PredicateSpec { return &conditionalSpec{} }
+
+func (*conditionalSpec) Name() string { return predicates.ConditionalName }
+
+func (*conditionalSpec) Create(args []interface{}) (routing.Predicate, error) {
+	if len(args) != 0 {
+		return nil, predicates.ErrInvalidPredicateParameters
+	}
+
+	return &conditionalPredicate{}, nil
+}
+
+func (*conditionalPredicate) Match(req *http.Request) bool {
+	for _, h := range conditionalHeaders {
+		if _, ok := req.Header[h]; ok {
+			return true
+		}
+	}
+
+	return false
+}
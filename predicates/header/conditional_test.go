@@ -0,0 +1,73 @@
+package header
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestConditionalCreate(t *testing.T) {
+	if _, err := NewConditional().Create([]interface{}{"If-None-Match"}); err == nil {
+		t.Error("failed to fail")
+	}
+
+	if _, err := NewConditional().Create(nil); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestConditionalMatch(t *testing.T) {
+	for _, tt := range []struct {
+		msg    string
+		method string
+		header http.Header
+		match  bool
+	}{{
+		msg:    "plain GET",
+		method: "GET",
+	}, {
+		msg:    "unrelated headers",
+		method: "GET",
+		header: http.Header{"Cache-Control": []string{"no-cache"}, "If-Range": []string{`"v1"`}},
+	}, {
+		msg:    "If-None-Match",
+		method: "GET",
+		header: http.Header{"If-None-Match": []string{`"v1"`}},
+		match:  true,
+	}, {
+		msg:    "If-Modified-Since",
+		method: "GET",
+		header: http.Header{"If-Modified-Since": []string{"Wed, 14 Oct 2026 12:00:00 GMT"}},
+		match:  true,
+	}, {
+		msg:    "If-Match",
+		method: "PUT",
+		header: http.Header{"If-Match": []string{`"v1"`}},
+		match:  true,
+	}, {
+		msg:    "If-Unmodified-Since",
+		method: "DELETE",
+		header: http.Header{"If-Unmodified-Since": []string{"Wed, 14 Oct 2026 12:00:00 GMT"}},
+		match:  true,
+	}, {
+		msg:    "empty value",
+		method: "GET",
+		header: http.Header{"If-None-Match": []string{""}},
+		match:  true,
+	}} {
+		t.Run(tt.msg, func(t *testing.T) {
+			p, err := NewConditional().Create(nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			req := &http.Request{Method: tt.method, Header: tt.header}
+			if req.Header == nil {
+				req.Header = make(http.Header)
+			}
+
+			if m := p.Match(req); m != tt.match {
+				t.Errorf("expected match: %v, got: %v", tt.match, m)
+			}
+		})
+	}
+}
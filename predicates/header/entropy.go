@@ -0,0 +1,65 @@
+package header
+
+import (
+	"math"
+	"net/http"
+
+	"github.com/zalando/skipper/predicates"
+	"github.com/zalando/skipper/routing"
+)
+
+type entropyAboveSpec struct{}
+
+type entropyAbovePredicate struct {
+	name      string
+	threshold float64
+}
+
+// NewEntropyAbove creates a predicate specification, whose instances match
+// requests where the Shannon entropy of the header value, in bits per
+// byte, is above the threshold. Requests without the header don't match.
+func NewEntropyAbove() routing.PredicateSpec { return &entropyAboveSpec{} }
+
+func (*entropyAboveSpec) Name() string { return predicates.HeaderEntropyAboveName }
+
+func (*entropyAboveSpec) Create(args []interface{}) (routing.Predicate, error) {
+	if len(args) != 2 {
+		return nil, predicates.ErrInvalidPredicateParameters
+	}
+
+	name, ok := args[0].(string)
+	if !ok || name == "" {
+		return nil, predicates.ErrInvalidPredicateParameters
+	}
+
+	threshold, ok := args[1].(float64)
+	if !ok || threshold < 0 || threshold > 8 {
+		return nil, predicates.ErrInvalidPredicateParameters
+	}
+
+	return &entropyAbovePredicate{name: http.CanonicalHeaderKey(name), threshold: threshold}, nil
+}
+
+// entropy returns the Shannon entropy of s in bits per byte
+func entropy(s string) float64 {
+	var counts [256]int
+	for i := 0; i < len(s); i++ {
+		counts[s[i]]++
+	}
+
+	var e float64
+	n := float64(len(s))
+	for _, c := range counts {
+		if c > 0 {
+			p := float64(c) / n
+			e -= p * math.Log2(p)
+		}
+	}
+
+	return e
+}
+
+func (p *entropyAbovePredicate) Match(req *http.Request) bool {
+	v := req.Header.Get(p.name)
+	return v != "" && entropy(v) > p.threshold
+}
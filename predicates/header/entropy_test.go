@@ -0,0 +1,98 @@
+package header
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestEntropyAboveCreate(t *testing.T) {
+	for _, tt := range []struct {
+		msg  string
+		args []interface{}
+		err  bool
+	}{{
+		msg: "no args",
+		err: true,
+	}, {
+		msg:  "missing threshold",
+		args: []interface{}{"X-Token"},
+		err:  true,
+	}, {
+		msg:  "invalid name",
+		args: []interface{}{42.0, 3.5},
+		err:  true,
+	}, {
+		msg:  "invalid threshold",
+		args: []interface{}{"X-Token", "3.5"},
+		err:  true,
+	}, {
+		msg:  "threshold out of range",
+		args: []interface{}{"X-Token", 9.0},
+		err:  true,
+	}, {
+		msg:  "valid",
+		args: []interface{}{"X-Token", 3.5},
+	}} {
+		t.Run(tt.msg, func(t *testing.T) {
+			_, err := NewEntropyAbove().Create(tt.args)
+			if tt.err && err == nil {
+				t.Error("failed to fail")
+			} else if !tt.err && err != nil {
+				t.Error(err)
+			}
+		})
+	}
+}
+
+func TestEntropy(t *testing.T) {
+	for _, tt := range []struct {
+		value    string
+		expected float64
+	}{
+		{"", 0},
+		{"aaaa", 0},
+		{"abab", 1},
+		{"abcd", 2},
+		{"0123456789abcdef", 4},
+	} {
+		if e := entropy(tt.value); e != tt.expected {
+			t.Errorf("expected entropy of %q: %v, got: %v", tt.value, tt.expected, e)
+		}
+	}
+}
+
+func TestEntropyAboveMatch(t *testing.T) {
+	p, err := NewEntropyAbove().Create([]interface{}{"X-Token", 3.5})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tt := range []struct {
+		msg   string
+		value string
+		match bool
+	}{{
+		msg: "missing",
+	}, {
+		msg:   "low entropy",
+		value: "aaaaaaaaaaaaaaaaaaaaaaaa",
+	}, {
+		msg:   "low entropy word",
+		value: "hello-hello-hello",
+	}, {
+		msg:   "high entropy random token",
+		value: "f8Kq2Zp9LmXo4RtB7vNc1YsW",
+		match: true,
+	}} {
+		t.Run(tt.msg, func(t *testing.T) {
+			req := &http.Request{Header: http.Header{}}
+			if tt.value != "" {
+				req.Header.Set("X-Token", tt.value)
+			}
+
+			if m := p.Match(req); m != tt.match {
+				t.Errorf("expected match: %v, got: %v, entropy: %v", tt.match, m, entropy(tt.value))
+			}
+		})
+	}
+}
@@ -0,0 +1,57 @@
+package header
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/zalando/skipper/predicates"
+	"github.com/zalando/skipper/routing"
+)
+
+type trailerSpec struct{}
+
+type trailerPredicate struct {
+	name string
+}
+
+// NewHasTrailer creates a predicate specification, whose instances match
+// requests declaring the trailer with the given name in the Trailer
+// header, e.g. the gRPC requests declaring Grpc-Status.
+//
+// The predicate matches only on the declaration of the trailer, because the
+// values of the trailers arrive after the request body, when the routing
+// has already happened. The Go HTTP server accepts the declaration of the
+// trailers only for chunked requests, and moves it from the Trailer header
+// to the Trailer field of the request.
+func NewHasTrailer() routing.PredicateSpec { return &trailerSpec{} }
+
+func (*trailerSpec) Name() string { return predicates.HasTrailerName }
+
+func (*trailerSpec) Create(args []interface{}) (routing.Predicate, error) {
+	if len(args) != 1 {
+		return nil, predicates.ErrInvalidPredicateParameters
+	}
+
+	name, ok := args[0].(string)
+	if !ok || name == "" {
+		return nil, predicates.ErrInvalidPredicateParameters
+	}
+
+	return &trailerPredicate{name: http.CanonicalHeaderKey(name)}, nil
+}
+
+func (p *trailerPredicate) Match(req *http.Request) bool {
+	if _, ok := req.Trailer[p.name]; ok {
+		return true
+	}
+
+	for _, v := range req.Header["Trailer"] {
+		for _, name := range strings.Split(v, ",") {
+			if http.CanonicalHeaderKey(strings.TrimSpace(name)) == p.name {
+				return true
+			}
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,144 @@
+package header
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestHasTrailerCreate(t *testing.T) {
+	for _, tt := range []struct {
+		msg  string
+		args []interface{}
+		err  bool
+	}{{
+		msg: "no args",
+		err: true,
+	}, {
+		msg:  "too many args",
+		args: []interface{}{"Grpc-Status", "Grpc-Message"},
+		err:  true,
+	}, {
+		msg:  "not a string",
+		args: []interface{}{42.0},
+		err:  true,
+	}, {
+		msg:  "empty name",
+		args: []interface{}{""},
+		err:  true,
+	}, {
+		msg:  "trailer name",
+		args: []interface{}{"Grpc-Status"},
+	}} {
+		t.Run(tt.msg, func(t *testing.T) {
+			_, err := NewHasTrailer().Create(tt.args)
+			if tt.err && err == nil {
+				t.Error("failed to fail")
+			} else if !tt.err && err != nil {
+				t.Error(err)
+			}
+		})
+	}
+}
+
+func TestHasTrailerMatch(t *testing.T) {
+	for _, tt := range []struct {
+		msg     string
+		header  http.Header
+		trailer http.Header
+		match   bool
+	}{{
+		msg: "no trailers",
+	}, {
+		msg:     "declared trailer",
+		trailer: http.Header{"Grpc-Status": nil},
+		match:   true,
+	}, {
+		msg:     "one of multiple declared trailers",
+		trailer: http.Header{"Grpc-Message": nil, "Grpc-Status": nil},
+		match:   true,
+	}, {
+		msg:     "other declared trailer",
+		trailer: http.Header{"Grpc-Message": nil},
+	}, {
+		msg:    "declared in the header",
+		header: http.Header{"Trailer": []string{"grpc-message, grpc-status"}},
+		match:  true,
+	}, {
+		msg:    "declared in multiple headers",
+		header: http.Header{"Trailer": []string{"Grpc-Message", "Grpc-Status"}},
+		match:  true,
+	}, {
+		msg:    "other declared in the header",
+		header: http.Header{"Trailer": []string{"Grpc-Message, Grpc-Status-Details-Bin"}},
+	}, {
+		msg:    "header, but not trailer",
+		header: http.Header{"Grpc-Status": []string{"0"}},
+	}} {
+		t.Run(tt.msg, func(t *testing.T) {
+			p, err := NewHasTrailer().Create([]interface{}{"grpc-status"})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			r := &http.Request{Header: tt.header, Trailer: tt.trailer}
+			if r.Header == nil {
+				r.Header = make(http.Header)
+			}
+
+			if m := p.Match(r); m != tt.match {
+				t.Errorf("expected match: %v, got: %v", tt.match, m)
+			}
+		})
+	}
+}
+
+func TestHasTrailerServer(t *testing.T) {
+	p, err := NewHasTrailer().Create([]interface{}{"Grpc-Status"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	matched := make(chan bool, 1)
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		matched <- p.Match(r)
+		io.Copy(io.Discard, r.Body)
+	}))
+	defer s.Close()
+
+	for _, tt := range []struct {
+		msg     string
+		trailer http.Header
+		match   bool
+	}{{
+		msg: "without declared trailers",
+	}, {
+		msg:     "with declared trailer",
+		trailer: http.Header{"Grpc-Status": []string{"0"}},
+		match:   true,
+	}, {
+		msg:     "with other declared trailer",
+		trailer: http.Header{"Grpc-Message": []string{"ok"}},
+	}} {
+		t.Run(tt.msg, func(t *testing.T) {
+			// the client sends the declared trailers with a chunked request body
+			req, err := http.NewRequest("POST", s.URL, io.NopCloser(strings.NewReader("payload")))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			req.Trailer = tt.trailer
+			rsp, err := http.DefaultClient.Do(req)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			rsp.Body.Close()
+			if m := <-matched; m != tt.match {
+				t.Errorf("expected match: %v, got: %v", tt.match, m)
+			}
+		})
+	}
+}
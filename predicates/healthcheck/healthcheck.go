@@ -0,0 +1,74 @@
+/*
+Package healthcheck implements the HealthCheck predicate, that matches the
+requests looking like the probes of health checkers, e.g. of load
+balancers or of Kubernetes, based on the path prefix or the User-Agent
+header of the request.
+
+The predicate can keep the probes out of the traffic split of canary
+deployments, with a route to the stable backend that wins over the routes
+with the TrafficSegment predicate, for having more predicates:
+
+	probes: Path("/api") && HealthCheck("/healthz", "^(kube-probe|ELB-HealthChecker)/") -> "https://stable.example.org";
+	stable: Path("/api") && TrafficSegment(0, 0.9) -> "https://stable.example.org";
+	canary: Path("/api") && TrafficSegment(0.9, 1, "canary") -> "https://canary.example.org";
+*/
+package healthcheck
+
+import (
+	"net/http"
+	"regexp"
+	"strings"
+
+	"github.com/zalando/skipper/predicates"
+	"github.com/zalando/skipper/routing"
+)
+
+type spec struct{}
+
+type predicate struct {
+	prefix    string
+	userAgent *regexp.Regexp
+}
+
+// New creates a new HealthCheck predicate specification.
+func New() routing.PredicateSpec { return &spec{} }
+
+func (*spec) Name() string { return predicates.HealthCheckName }
+
+// Create a predicate instance, that matches the requests whose path starts
+// with the prefix, the first argument, or whose User-Agent header matches
+// the regular expression, the second argument. Either argument can be
+// empty, to match only by the other one.
+func (*spec) Create(args []interface{}) (routing.Predicate, error) {
+	if len(args) != 2 {
+		return nil, predicates.ErrInvalidPredicateParameters
+	}
+
+	prefix, ok := args[0].(string)
+	if !ok || prefix != "" && !strings.HasPrefix(prefix, "/") {
+		return nil, predicates.ErrInvalidPredicateParameters
+	}
+
+	pattern, ok := args[1].(string)
+	if !ok || prefix == "" && pattern == "" {
+		return nil, predicates.ErrInvalidPredicateParameters
+	}
+
+	p := &predicate{prefix: prefix}
+	if pattern != "" {
+		var err error
+		if p.userAgent, err = regexp.Compile(pattern); err != nil {
+			return nil, err
+		}
+	}
+
+	return p, nil
+}
+
+func (p *predicate) Match(r *http.Request) bool {
+	if p.prefix != "" && strings.HasPrefix(r.URL.Path, p.prefix) {
+		return true
+	}
+
+	return p.userAgent != nil && p.userAgent.MatchString(r.Header.Get("User-Agent"))
+}
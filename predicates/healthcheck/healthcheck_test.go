@@ -0,0 +1,120 @@
+package healthcheck
+
+import (
+	"net/http"
+	"net/url"
+	"testing"
+
+	"github.com/zalando/skipper/predicates"
+)
+
+func TestName(t *testing.T) {
+	if New().Name() != predicates.HealthCheckName {
+		t.Errorf("invalid name: %s", New().Name())
+	}
+}
+
+func TestCreate(t *testing.T) {
+	for _, tt := range []struct {
+		msg  string
+		args []interface{}
+		err  bool
+	}{{
+		msg: "no args",
+		err: true,
+	}, {
+		msg:  "missing pattern",
+		args: []interface{}{"/healthz"},
+		err:  true,
+	}, {
+		msg:  "prefix not a string",
+		args: []interface{}{1.0, "kube-probe"},
+		err:  true,
+	}, {
+		msg:  "relative prefix",
+		args: []interface{}{"healthz", "kube-probe"},
+		err:  true,
+	}, {
+		msg:  "invalid pattern",
+		args: []interface{}{"/healthz", "kube-probe/("},
+		err:  true,
+	}, {
+		msg:  "both empty",
+		args: []interface{}{"", ""},
+		err:  true,
+	}, {
+		msg:  "prefix only",
+		args: []interface{}{"/healthz", ""},
+	}, {
+		msg:  "pattern only",
+		args: []interface{}{"", "^kube-probe/"},
+	}, {
+		msg:  "prefix and pattern",
+		args: []interface{}{"/healthz", "^kube-probe/"},
+	}} {
+		t.Run(tt.msg, func(t *testing.T) {
+			_, err := New().Create(tt.args)
+			if tt.err && err == nil {
+				t.Error("failed to fail")
+			} else if !tt.err && err != nil {
+				t.Error(err)
+			}
+		})
+	}
+}
+
+func TestMatch(t *testing.T) {
+	for _, tt := range []struct {
+		msg       string
+		args      []interface{}
+		path      string
+		userAgent string
+		expected  bool
+	}{{
+		msg:      "path prefix",
+		args:     []interface{}{"/healthz", "^kube-probe/"},
+		path:     "/healthz/ready",
+		expected: true,
+	}, {
+		msg:       "user agent",
+		args:      []interface{}{"/healthz", "^kube-probe/"},
+		path:      "/api",
+		userAgent: "kube-probe/1.27",
+		expected:  true,
+	}, {
+		msg:       "regular request",
+		args:      []interface{}{"/healthz", "^kube-probe/"},
+		path:      "/api",
+		userAgent: "Mozilla/5.0",
+	}, {
+		msg:  "no user agent",
+		args: []interface{}{"/healthz", "^kube-probe/"},
+		path: "/api",
+	}, {
+		msg:       "prefix only",
+		args:      []interface{}{"/healthz", ""},
+		path:      "/api",
+		userAgent: "kube-probe/1.27",
+	}, {
+		msg:       "pattern only",
+		args:      []interface{}{"", "^kube-probe/"},
+		path:      "/healthz",
+		userAgent: "curl/8.0",
+	}} {
+		t.Run(tt.msg, func(t *testing.T) {
+			p, err := New().Create(tt.args)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			r := &http.Request{URL: &url.URL{Path: tt.path}, Header: http.Header{}}
+			if tt.userAgent != "" {
+				r.Header.Set("User-Agent", tt.userAgent)
+			}
+
+			if m := p.Match(r); m != tt.expected {
+				t.Errorf("unexpected match result: %v, expected: %v", m, tt.expected)
+			}
+		})
+	}
+}
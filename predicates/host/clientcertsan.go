@@ -0,0 +1,86 @@
+package host
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/zalando/skipper/predicates"
+	"github.com/zalando/skipper/routing"
+)
+
+type clientCertSANSpec struct{}
+
+type clientCertSANPredicate struct {
+	// lower case, without the leading "*." of wildcard patterns
+	name     string
+	wildcard bool
+}
+
+// NewClientCertSAN creates a predicate specification, whose instances match
+// the DNS names of the subject alternative names (SAN) of the TLS client
+// certificate.
+//
+// The ClientCertSAN predicate requires a single string pattern, and matches
+// if any DNS name of the client certificate equals to it, ignoring case. A
+// pattern starting with "*." matches the DNS names that have exactly one
+// more, non-empty label, e.g. "*.payments.example.com" matches
+// "api.payments.example.com", but not "payments.example.com" or
+// "v1.api.payments.example.com". Wildcard DNS names of the certificate
+// match only the same wildcard pattern. The predicate does not match
+// plaintext requests, or when the client did not present a certificate.
+func NewClientCertSAN() routing.PredicateSpec { return &clientCertSANSpec{} }
+
+func (*clientCertSANSpec) Name() string {
+	return predicates.ClientCertSANName
+}
+
+func (*clientCertSANSpec) Create(args []interface{}) (routing.Predicate, error) {
+	if len(args) != 1 {
+		return nil, predicates.ErrInvalidPredicateParameters
+	}
+
+	pattern, ok := args[0].(string)
+	if !ok || pattern == "" {
+		return nil, predicates.ErrInvalidPredicateParameters
+	}
+
+	p := &clientCertSANPredicate{name: strings.ToLower(pattern)}
+	if strings.HasPrefix(p.name, "*.") {
+		p.wildcard = true
+		p.name = p.name[2:]
+	}
+
+	if p.name == "" || strings.Contains(p.name, "*") {
+		return nil, predicates.ErrInvalidPredicateParameters
+	}
+
+	return p, nil
+}
+
+func (p *clientCertSANPredicate) matchName(dnsName string) bool {
+	dnsName = strings.ToLower(dnsName)
+	if !p.wildcard {
+		return dnsName == p.name
+	}
+
+	if dnsName == "*."+p.name {
+		return true
+	}
+
+	label, rest, ok := strings.Cut(dnsName, ".")
+	return ok && label != "" && label != "*" && rest == p.name
+}
+
+func (p *clientCertSANPredicate) Match(r *http.Request) bool {
+	if r.TLS == nil || len(r.TLS.PeerCertificates) == 0 {
+		return false
+	}
+
+	for _, dnsName := range r.TLS.PeerCertificates[0].DNSNames {
+		if p.matchName(dnsName) {
+			return true
+		}
+	}
+
+	return false
+}
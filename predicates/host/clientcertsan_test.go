@@ -0,0 +1,171 @@
+package host
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"math/big"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func clientCert(t *testing.T, dnsNames ...string) *x509.Certificate {
+	t.Helper()
+
+	pub, key, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "client"},
+		DNSNames:     dnsNames,
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, template, pub, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return cert
+}
+
+func TestClientCertSANArgs(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		args []interface{}
+	}{
+		{name: "no args", args: []interface{}{}},
+		{name: "not a string", args: []interface{}{1.2}},
+		{name: "empty", args: []interface{}{""}},
+		{name: "wildcard only", args: []interface{}{"*."}},
+		{name: "inner wildcard", args: []interface{}{"api.*.example.com"}},
+		{name: "partial wildcard", args: []interface{}{"api*.example.com"}},
+		{name: "too many", args: []interface{}{"api.example.com", "www.example.com"}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			if _, err := NewClientCertSAN().Create(tc.args); err == nil {
+				t.Errorf("expected error for arguments: %v", tc.args)
+			}
+		})
+	}
+}
+
+func TestClientCertSANMatch(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		pattern  string
+		dnsNames []string
+		noCert   bool
+		noTLS    bool
+		expected bool
+	}{{
+		name:     "exact",
+		pattern:  "api.payments.example.com",
+		dnsNames: []string{"api.payments.example.com"},
+		expected: true,
+	}, {
+		name:     "exact, ignoring case",
+		pattern:  "API.payments.example.com",
+		dnsNames: []string{"api.Payments.example.com"},
+		expected: true,
+	}, {
+		name:     "exact, one of multiple SANs",
+		pattern:  "api.payments.example.com",
+		dnsNames: []string{"www.example.com", "api.payments.example.com", "api.example.com"},
+		expected: true,
+	}, {
+		name:     "exact, no matching SAN",
+		pattern:  "api.payments.example.com",
+		dnsNames: []string{"www.example.com", "api.example.com"},
+	}, {
+		name:     "exact pattern does not match wildcard SAN",
+		pattern:  "api.payments.example.com",
+		dnsNames: []string{"*.payments.example.com"},
+	}, {
+		name:     "wildcard",
+		pattern:  "*.payments.example.com",
+		dnsNames: []string{"api.payments.example.com"},
+		expected: true,
+	}, {
+		name:     "wildcard, one of multiple SANs",
+		pattern:  "*.payments.example.com",
+		dnsNames: []string{"payments.example.com", "www.example.com", "checkout.payments.example.com"},
+		expected: true,
+	}, {
+		name:     "wildcard, same wildcard SAN",
+		pattern:  "*.payments.example.com",
+		dnsNames: []string{"*.payments.example.com"},
+		expected: true,
+	}, {
+		name:     "wildcard does not match the parent domain",
+		pattern:  "*.payments.example.com",
+		dnsNames: []string{"payments.example.com"},
+	}, {
+		name:     "wildcard matches a single label",
+		pattern:  "*.payments.example.com",
+		dnsNames: []string{"v1.api.payments.example.com"},
+	}, {
+		name:     "wildcard does not match other domains",
+		pattern:  "*.payments.example.com",
+		dnsNames: []string{"api.example.com", "api.paymentsexample.com", "api.payments.example.org"},
+	}, {
+		name:    "no SAN",
+		pattern: "*.payments.example.com",
+	}, {
+		name:    "no client certificate",
+		pattern: "*.payments.example.com",
+		noCert:  true,
+	}, {
+		name:    "plaintext",
+		pattern: "*.payments.example.com",
+		noTLS:   true,
+	}} {
+		t.Run(tc.name, func(t *testing.T) {
+			p, err := NewClientCertSAN().Create([]interface{}{tc.pattern})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			r := &http.Request{Host: "api.example.com"}
+			if !tc.noTLS {
+				r.TLS = &tls.ConnectionState{ServerName: "api.example.com"}
+				if !tc.noCert {
+					r.TLS.PeerCertificates = []*x509.Certificate{clientCert(t, tc.dnsNames...)}
+				}
+			}
+
+			if m := p.Match(r); m != tc.expected {
+				t.Errorf("expected match: %v, got: %v", tc.expected, m)
+			}
+		})
+	}
+}
+
+func TestClientCertSANLeafOnly(t *testing.T) {
+	p, err := NewClientCertSAN().Create([]interface{}{"*.payments.example.com"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	r := &http.Request{TLS: &tls.ConnectionState{PeerCertificates: []*x509.Certificate{
+		clientCert(t, "api.example.com"),
+		clientCert(t, "ca.payments.example.com"),
+	}}}
+
+	if p.Match(r) {
+		t.Error("expected to match only the SANs of the client certificate, not of the chain")
+	}
+}
@@ -0,0 +1,85 @@
+package host
+
+import (
+	"net/http"
+	"regexp"
+	"strings"
+
+	"github.com/zalando/skipper/predicates"
+	"github.com/zalando/skipper/routing"
+)
+
+type sniSpec struct{}
+
+type sniRegexpSpec struct{}
+
+type sniPredicate struct {
+	serverName string
+}
+
+type sniRegexpPredicate struct {
+	regexp *regexp.Regexp
+}
+
+// NewSNI creates a predicate specification, whose instances match the TLS
+// server name indication (SNI) of the request.
+//
+// The SNI predicate requires a single string server name and matches if the
+// server name sent by the client in the TLS handshake equals to it, ignoring
+// case. It does not match plaintext requests, or when the client did not send
+// the server name. The predicate does not consider the Host header, and
+// therefore it can be used to detect requests where the Host differs from the
+// SNI.
+func NewSNI() routing.PredicateSpec { return &sniSpec{} }
+
+// NewSNIRegexp creates a predicate specification, whose instances match the
+// TLS server name indication (SNI) of the request against a regular
+// expression. Like SNI, it does not match plaintext requests.
+func NewSNIRegexp() routing.PredicateSpec { return &sniRegexpSpec{} }
+
+func (*sniSpec) Name() string {
+	return predicates.SNIName
+}
+
+func (*sniSpec) Create(args []interface{}) (routing.Predicate, error) {
+	if len(args) != 1 {
+		return nil, predicates.ErrInvalidPredicateParameters
+	}
+
+	serverName, ok := args[0].(string)
+	if !ok || serverName == "" {
+		return nil, predicates.ErrInvalidPredicateParameters
+	}
+
+	return &sniPredicate{serverName: serverName}, nil
+}
+
+func (*sniRegexpSpec) Name() string {
+	return predicates.SNIRegexpName
+}
+
+func (*sniRegexpSpec) Create(args []interface{}) (routing.Predicate, error) {
+	if len(args) != 1 {
+		return nil, predicates.ErrInvalidPredicateParameters
+	}
+
+	expr, ok := args[0].(string)
+	if !ok {
+		return nil, predicates.ErrInvalidPredicateParameters
+	}
+
+	rx, err := regexp.Compile(expr)
+	if err != nil {
+		return nil, err
+	}
+
+	return &sniRegexpPredicate{regexp: rx}, nil
+}
+
+func (p *sniPredicate) Match(r *http.Request) bool {
+	return r.TLS != nil && strings.EqualFold(r.TLS.ServerName, p.serverName)
+}
+
+func (p *sniRegexpPredicate) Match(r *http.Request) bool {
+	return r.TLS != nil && r.TLS.ServerName != "" && p.regexp.MatchString(r.TLS.ServerName)
+}
@@ -0,0 +1,105 @@
+package host
+
+import (
+	"crypto/tls"
+	"net/http"
+	"testing"
+)
+
+func TestSNIArgs(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		args []interface{}
+	}{
+		{name: "no args", args: []interface{}{}},
+		{name: "not a string", args: []interface{}{1.2}},
+		{name: "empty", args: []interface{}{""}},
+		{name: "too many", args: []interface{}{"api.example.org", "www.example.org"}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			if _, err := NewSNI().Create(tc.args); err == nil {
+				t.Errorf("expected error for arguments: %v", tc.args)
+			}
+		})
+	}
+
+	for _, tc := range []struct {
+		name string
+		args []interface{}
+	}{
+		{name: "no args", args: []interface{}{}},
+		{name: "not a string", args: []interface{}{1.2}},
+		{name: "invalid regexp", args: []interface{}{"api.(example"}},
+		{name: "too many", args: []interface{}{"^api[.]", "^www[.]"}},
+	} {
+		t.Run("regexp "+tc.name, func(t *testing.T) {
+			if _, err := NewSNIRegexp().Create(tc.args); err == nil {
+				t.Errorf("expected error for arguments: %v", tc.args)
+			}
+		})
+	}
+}
+
+func TestSNIMatch(t *testing.T) {
+	for _, tc := range []struct {
+		name       string
+		host       string
+		serverName string
+		plaintext  bool
+		sni        bool
+		sniRegexp  bool
+	}{{
+		name:       "matching host and SNI",
+		host:       "api.example.org",
+		serverName: "api.example.org",
+		sni:        true,
+		sniRegexp:  true,
+	}, {
+		name:       "SNI differs from host",
+		host:       "www.example.org",
+		serverName: "api.example.org",
+		sni:        true,
+		sniRegexp:  true,
+	}, {
+		name:       "host matches but SNI differs",
+		host:       "api.example.org",
+		serverName: "www.example.org",
+	}, {
+		name:       "SNI case insensitive",
+		host:       "api.example.org",
+		serverName: "API.example.org",
+		sni:        true,
+	}, {
+		name: "no SNI",
+		host: "api.example.org",
+	}, {
+		name:      "plaintext",
+		host:      "api.example.org",
+		plaintext: true,
+	}} {
+		t.Run(tc.name, func(t *testing.T) {
+			r := &http.Request{Host: tc.host}
+			if !tc.plaintext {
+				r.TLS = &tls.ConnectionState{ServerName: tc.serverName}
+			}
+
+			sni, err := NewSNI().Create([]interface{}{"api.example.org"})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if m := sni.Match(r); m != tc.sni {
+				t.Errorf("SNI: expected match: %v, got: %v", tc.sni, m)
+			}
+
+			sniRegexp, err := NewSNIRegexp().Create([]interface{}{"^api[.]example[.]org$"})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if m := sniRegexp.Match(r); m != tc.sniRegexp {
+				t.Errorf("SNIRegexp: expected match: %v, got: %v", tc.sniRegexp, m)
+			}
+		})
+	}
+}
@@ -0,0 +1,64 @@
+package interval
+
+import (
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/zalando/skipper/predicates"
+	"github.com/zalando/skipper/routing"
+)
+
+type untilSpec struct{}
+
+type untilPredicate struct {
+	expiry   time.Time
+	getTime  func() time.Time
+	once     sync.Once
+	onExpiry func()
+}
+
+// NewUntil creates the Until predicate, that matches only before the expiry
+// time of the route, e.g. of a temporary canary route, so that the route is
+// skipped after the expiry without a routing update. The expiry is counted
+// by the routing, see routing.Options.RouteExpiryMetrics.
+//
+// The expiry time is a string in RFC3339 format, or a number of the Unix
+// time in seconds.
+//
+// Example:
+//
+//	canary: Path("/") && TrafficSegment(0.9, 1, "canary") && Until("2023-07-01T00:00:00Z") -> "https://canary.example.org";
+func NewUntil() routing.PredicateSpec { return untilSpec{} }
+
+func (untilSpec) Name() string { return predicates.UntilName }
+
+func (untilSpec) Create(args []interface{}) (routing.Predicate, error) {
+	p := &untilPredicate{getTime: time.Now}
+	if len(args) != 1 || !parseRFC(args[0], &p.expiry) && !parseUnix(args[0], &p.expiry) {
+		return nil, predicates.ErrInvalidPredicateParameters
+	}
+
+	return p, nil
+}
+
+func (p *untilPredicate) Expiry() time.Time { return p.expiry }
+
+func (p *untilPredicate) OnExpiry(f func()) {
+	// the routes loaded after their expiry are not counted
+	if p.getTime().Before(p.expiry) {
+		p.onExpiry = f
+	}
+}
+
+func (p *untilPredicate) Match(*http.Request) bool {
+	if p.getTime().Before(p.expiry) {
+		return true
+	}
+
+	if p.onExpiry != nil {
+		p.once.Do(p.onExpiry)
+	}
+
+	return false
+}
@@ -0,0 +1,99 @@
+package interval
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/zalando/skipper/routing"
+)
+
+func TestUntilCreate(t *testing.T) {
+	for _, tt := range []struct {
+		msg  string
+		args []interface{}
+		err  bool
+	}{{
+		msg: "no args",
+		err: true,
+	}, {
+		msg:  "too many args",
+		args: []interface{}{"2023-07-01T00:00:00Z", "Europe/Berlin"},
+		err:  true,
+	}, {
+		msg:  "invalid timestamp",
+		args: []interface{}{"2023-07-01"},
+		err:  true,
+	}, {
+		msg:  "RFC3339",
+		args: []interface{}{"2023-07-01T00:00:00+02:00"},
+	}, {
+		msg:  "Unix time",
+		args: []interface{}{float64(1688162400)},
+	}} {
+		t.Run(tt.msg, func(t *testing.T) {
+			_, err := NewUntil().Create(tt.args)
+			if tt.err && err == nil {
+				t.Error("failed to fail")
+			} else if !tt.err && err != nil {
+				t.Error(err)
+			}
+		})
+	}
+}
+
+func TestUntil(t *testing.T) {
+	expiry := time.Date(2023, 7, 1, 0, 0, 0, 0, time.UTC)
+	p, err := NewUntil().Create([]interface{}{expiry.Format(time.RFC3339)})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ep, ok := p.(routing.ExpiringPredicate)
+	if !ok {
+		t.Fatal("not an expiring predicate")
+	}
+
+	if !ep.Expiry().Equal(expiry) {
+		t.Errorf("unexpected expiry: %v", ep.Expiry())
+	}
+
+	now := expiry.Add(-time.Second)
+	p.(*untilPredicate).getTime = func() time.Time { return now }
+
+	var expired int
+	ep.OnExpiry(func() { expired++ })
+
+	r := &http.Request{}
+	if !p.Match(r) {
+		t.Error("failed to match before the expiry")
+	}
+
+	now = expiry
+	for i := 0; i < 3; i++ {
+		if p.Match(r) {
+			t.Error("unexpected match after the expiry")
+		}
+	}
+
+	if expired != 1 {
+		t.Errorf("unexpected number of expiry calls: %d", expired)
+	}
+}
+
+func TestUntilLoadedAfterExpiry(t *testing.T) {
+	p, err := NewUntil().Create([]interface{}{time.Now().Add(-time.Hour).Format(time.RFC3339)})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var expired int
+	p.(routing.ExpiringPredicate).OnExpiry(func() { expired++ })
+	if p.Match(&http.Request{}) {
+		t.Error("unexpected match after the expiry")
+	}
+
+	if expired != 0 {
+		t.Errorf("unexpected expiry calls: %d", expired)
+	}
+}
@@ -11,6 +11,12 @@ Examples:
 
 	// matches GET or POST request
 	example1: Methods("GET", "post") -> "http://example.org";
+
+	// matches GET, HEAD, OPTIONS or TRACE request
+	example2: SafeMethod() -> "http://replica.example.org";
+
+	// matches any other request
+	example3: UnsafeMethod() -> "http://primary.example.org";
 */
 package methods
 
@@ -0,0 +1,50 @@
+package methods
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/zalando/skipper/predicates"
+	"github.com/zalando/skipper/rfc"
+	"github.com/zalando/skipper/routing"
+)
+
+type (
+	safetySpec struct {
+		safe bool
+	}
+
+	safetyPredicate struct {
+		safe bool
+	}
+)
+
+// NewSafe creates a new SafeMethod predicate specification, whose
+// instances match the requests with a safe method, as defined by
+// RFC 7231: GET, HEAD, OPTIONS or TRACE.
+func NewSafe() routing.PredicateSpec { return &safetySpec{safe: true} }
+
+// NewUnsafe creates a new UnsafeMethod predicate specification, whose
+// instances match the requests with a method that is not safe, as defined
+// by RFC 7231, e.g. POST or DELETE.
+func NewUnsafe() routing.PredicateSpec { return &safetySpec{safe: false} }
+
+func (s *safetySpec) Name() string {
+	if s.safe {
+		return predicates.SafeMethodName
+	}
+
+	return predicates.UnsafeMethodName
+}
+
+func (s *safetySpec) Create(args []interface{}) (routing.Predicate, error) {
+	if len(args) != 0 {
+		return nil, predicates.ErrInvalidPredicateParameters
+	}
+
+	return &safetyPredicate{safe: s.safe}, nil
+}
+
+func (p *safetyPredicate) Match(r *http.Request) bool {
+	return rfc.IsSafeMethod(strings.ToUpper(r.Method)) == p.safe
+}
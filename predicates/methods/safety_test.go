@@ -0,0 +1,58 @@
+package methods
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestSafetyArgs(t *testing.T) {
+	for _, s := range []*safetySpec{NewSafe().(*safetySpec), NewUnsafe().(*safetySpec)} {
+		if _, err := s.Create([]interface{}{"GET"}); err == nil {
+			t.Errorf("%s: failed to fail", s.Name())
+		}
+
+		if _, err := s.Create(nil); err != nil {
+			t.Errorf("%s: %v", s.Name(), err)
+		}
+	}
+}
+
+func TestSafety(t *testing.T) {
+	safe, err := NewSafe().Create(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	unsafe, err := NewUnsafe().Create(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tt := range []struct {
+		method string
+		safe   bool
+	}{
+		{http.MethodGet, true},
+		{http.MethodHead, true},
+		{http.MethodOptions, true},
+		{http.MethodTrace, true},
+		{"get", true},
+		{http.MethodPost, false},
+		{http.MethodPut, false},
+		{http.MethodPatch, false},
+		{http.MethodDelete, false},
+		{http.MethodConnect, false},
+		{"PROPFIND", false},
+	} {
+		t.Run(tt.method, func(t *testing.T) {
+			r := &http.Request{Method: tt.method}
+			if m := safe.Match(r); m != tt.safe {
+				t.Errorf("SafeMethod: expected match: %v, got: %v", tt.safe, m)
+			}
+
+			if m := unsafe.Match(r); m == tt.safe {
+				t.Errorf("UnsafeMethod: expected match: %v, got: %v", !tt.safe, m)
+			}
+		})
+	}
+}
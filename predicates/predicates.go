@@ -18,32 +18,70 @@ const (
 	PathRegexpName            = "PathRegexp"
 	HostName                  = "Host"
 	HostAnyName               = "HostAny"
+	SNIName                   = "SNI"
+	SNIRegexpName             = "SNIRegexp"
+	ClientCertSANName         = "ClientCertSAN"
 	ForwardedHostName         = "ForwardedHost"
 	ForwardedProtocolName     = "ForwardedProtocol"
 	WeightName                = "Weight"
+	RouteWeightName           = "RouteWeight"
+	GlobalName                = "Global"
 	TrueName                  = "True"
 	FalseName                 = "False"
 	ShutdownName              = "Shutdown"
+	QueuePositionBelowName    = "QueuePositionBelow"
+	RouteCountAboveName       = "RouteCountAbove"
 	MethodName                = "Method"
 	MethodsName               = "Methods"
+	SafeMethodName            = "SafeMethod"
+	UnsafeMethodName          = "UnsafeMethod"
 	HeaderName                = "Header"
 	HeaderRegexpName          = "HeaderRegexp"
+	HeaderAbsentName          = "HeaderAbsent"
+	HeaderEntropyAboveName    = "HeaderEntropyAbove"
+	CacheControlName          = "CacheControl"
+	HasTrailerName            = "HasTrailer"
+	DeviceClassName           = "DeviceClass"
+	ConditionalName           = "Conditional"
+	ConnectionReusedName      = "ConnectionReused"
 	CookieName                = "Cookie"
+	BurstDetectedName         = "BurstDetected"
+	DependencyHealthyName     = "DependencyHealthy"
+	ErrorRateBelowName        = "ErrorRateBelow"
 	JWTPayloadAnyKVName       = "JWTPayloadAnyKV"
 	JWTPayloadAllKVName       = "JWTPayloadAllKV"
 	JWTPayloadAnyKVRegexpName = "JWTPayloadAnyKVRegexp"
 	JWTPayloadAllKVRegexpName = "JWTPayloadAllKVRegexp"
+	JWTExpiringWithinName     = "JWTExpiringWithin"
 	HeaderSHA256Name          = "HeaderSHA256"
 	AfterName                 = "After"
 	BeforeName                = "Before"
 	BetweenName               = "Between"
+	UntilName                 = "Until"
 	CronName                  = "Cron"
 	QueryParamName            = "QueryParam"
 	SourceName                = "Source"
 	SourceFromLastName        = "SourceFromLast"
 	ClientIPName              = "ClientIP"
+	ClientASNName             = "ClientASN"
+	UnixSocketName            = "UnixSocket"
+	InBloomFilterName         = "InBloomFilter"
 	TeeName                   = "Tee"
 	TrafficName               = "Traffic"
 	TrafficSegmentName        = "TrafficSegment"
+	SegmentExceptName         = "SegmentExcept"
+	ExperimentName            = "Experiment"
+	TrafficDecayName          = "TrafficDecay"
+	URLSegmentName            = "URLSegment"
+	SessionPathSegmentName    = "SessionPathSegment"
+	ClusterSegmentName        = "ClusterSegment"
 	ContentLengthBetweenName  = "ContentLengthBetween"
+	DecompressedSizeBelowName = "DecompressedSizeBelow"
+	DecompressedSizeAboveName = "DecompressedSizeAbove"
+	ScoreAboveName            = "ScoreAbove"
+	HealthCheckName           = "HealthCheck"
+	PathPrefixSetName         = "PathPrefixSet"
+
+	RequestTargetLengthAboveName = "RequestTargetLengthAbove"
+	SessionRequestCountBelowName = "SessionRequestCountBelow"
 )
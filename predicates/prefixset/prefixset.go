@@ -0,0 +1,270 @@
+/*
+Package prefixset implements the PathPrefixSet predicate, that matches the
+request path against a large set of path prefixes, e.g. one per object,
+loaded from a file. The prefixes are stored in a radix tree, so that the
+lookup of the longest matching prefix takes time proportional only to the
+length of the path, independent of the number of the prefixes.
+
+The file contains one prefix per line. The empty lines and the lines
+starting with # are ignored. The prefixes need to start with a /. A prefix
+matches the path that equals to it, or that continues it with a /, like
+the PathSubtree predicate. A prefix ending with a / matches all the paths
+starting with it.
+
+The file is checked for changes at most once per second, and it is
+reloaded in the background when it changes. When the changed file cannot be
+loaded, the last loaded prefixes stay in use.
+
+Example:
+
+	objects: PathPrefixSet("/etc/skipper/prefixes.txt") -> "https://objects.example.org";
+*/
+package prefixset
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"net/http"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/zalando/skipper/predicates"
+	"github.com/zalando/skipper/routing"
+)
+
+const checkInterval = time.Second
+
+type spec struct {
+	mu            sync.Mutex
+	sets          map[string]*prefixSet
+	now           func() time.Time
+	checkInterval time.Duration
+}
+
+type predicate struct {
+	set *prefixSet
+}
+
+// prefixSet holds the prefixes loaded from a file, shared by the
+// predicates referencing the same file.
+type prefixSet struct {
+	file          string
+	now           func() time.Time
+	checkInterval time.Duration
+
+	tree      atomic.Value // of *node
+	checked   int64        // unix nanoseconds, accessed atomically
+	reloading int32        // accessed atomically
+	modTime   time.Time    // accessed only while reloading
+}
+
+// node is a node of the radix tree. The children are sorted by the first
+// byte of their label, and the labels of the siblings never start with the
+// same byte.
+type node struct {
+	label    string
+	terminal bool
+	children []*node
+}
+
+// New creates a new PathPrefixSet predicate specification.
+func New() routing.PredicateSpec {
+	return &spec{
+		sets:          make(map[string]*prefixSet),
+		now:           time.Now,
+		checkInterval: checkInterval,
+	}
+}
+
+func (*spec) Name() string { return predicates.PathPrefixSetName }
+
+// Create a predicate instance, that matches the requests whose path has a
+// prefix listed in the file, the only argument.
+func (s *spec) Create(args []interface{}) (routing.Predicate, error) {
+	if len(args) != 1 {
+		return nil, predicates.ErrInvalidPredicateParameters
+	}
+
+	file, ok := args[0].(string)
+	if !ok || file == "" {
+		return nil, predicates.ErrInvalidPredicateParameters
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if set, ok := s.sets[file]; ok {
+		return &predicate{set: set}, nil
+	}
+
+	set := &prefixSet{file: file, now: s.now, checkInterval: s.checkInterval}
+	if err := set.load(); err != nil {
+		return nil, err
+	}
+
+	s.sets[file] = set
+	return &predicate{set: set}, nil
+}
+
+func commonPrefixLength(a, b string) int {
+	i := 0
+	for i < len(a) && i < len(b) && a[i] == b[i] {
+		i++
+	}
+
+	return i
+}
+
+// child returns the index of the child whose label starts with c, or the
+// index where such a child needs to be inserted.
+func (n *node) child(c byte) (int, bool) {
+	i := sort.Search(len(n.children), func(i int) bool { return n.children[i].label[0] >= c })
+	return i, i < len(n.children) && n.children[i].label[0] == c
+}
+
+func (n *node) insert(key string) {
+	for {
+		if key == "" {
+			n.terminal = true
+			return
+		}
+
+		i, ok := n.child(key[0])
+		if !ok {
+			n.children = append(n.children, nil)
+			copy(n.children[i+1:], n.children[i:])
+			n.children[i] = &node{label: key, terminal: true}
+			return
+		}
+
+		c := n.children[i]
+		l := commonPrefixLength(c.label, key)
+		if l < len(c.label) {
+			// split the child at the common prefix
+			split := &node{label: c.label[:l], children: []*node{c}}
+			c.label = c.label[l:]
+			n.children[i] = split
+			c = split
+		}
+
+		n, key = c, key[l:]
+	}
+}
+
+// matchesAt tells whether the prefix of the given length is a path prefix of
+// p, ending at a segment boundary.
+func matchesAt(p string, length int) bool {
+	return length == len(p) || p[length-1] == '/' || p[length] == '/'
+}
+
+// longestPrefix returns the length of the longest matching prefix of the
+// path.
+func (n *node) longestPrefix(p string) (int, bool) {
+	var (
+		longest int
+		found   bool
+		offset  int
+	)
+
+	for {
+		if n.terminal && offset > 0 && matchesAt(p, offset) {
+			longest, found = offset, true
+		}
+
+		if offset == len(p) {
+			return longest, found
+		}
+
+		i, ok := n.child(p[offset])
+		if !ok || !strings.HasPrefix(p[offset:], n.children[i].label) {
+			return longest, found
+		}
+
+		n = n.children[i]
+		offset += len(n.label)
+	}
+}
+
+func parse(data []byte) (*node, error) {
+	root := &node{}
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for line := 1; scanner.Scan(); line++ {
+		p := strings.TrimSpace(scanner.Text())
+		if p == "" || p[0] == '#' {
+			continue
+		}
+
+		if p[0] != '/' {
+			return nil, fmt.Errorf("invalid prefix in line %d: %s", line, p)
+		}
+
+		root.insert(p)
+	}
+
+	return root, scanner.Err()
+}
+
+// load reads the file when it has changed since the last load.
+func (s *prefixSet) load() error {
+	atomic.StoreInt64(&s.checked, s.now().UnixNano())
+	fi, err := os.Stat(s.file)
+	if err != nil {
+		return err
+	}
+
+	if fi.ModTime().Equal(s.modTime) {
+		return nil
+	}
+
+	data, err := os.ReadFile(s.file)
+	if err != nil {
+		return err
+	}
+
+	tree, err := parse(data)
+	if err != nil {
+		return fmt.Errorf("failed to load path prefixes from %s: %w", s.file, err)
+	}
+
+	s.tree.Store(tree)
+	s.modTime = fi.ModTime()
+	return nil
+}
+
+func (s *prefixSet) reload() {
+	defer atomic.StoreInt32(&s.reloading, 0)
+	if err := s.load(); err != nil {
+		log.Errorf("%s: %v", predicates.PathPrefixSetName, err)
+	}
+}
+
+// checkReload starts reloading the file in the background, when it was not
+// checked within the check interval.
+func (s *prefixSet) checkReload() {
+	if s.now().UnixNano()-atomic.LoadInt64(&s.checked) < int64(s.checkInterval) {
+		return
+	}
+
+	if atomic.CompareAndSwapInt32(&s.reloading, 0, 1) {
+		go s.reload()
+	}
+}
+
+// longestPrefix returns the longest prefix of the path in the set.
+func (s *prefixSet) longestPrefix(p string) (string, bool) {
+	s.checkReload()
+	l, ok := s.tree.Load().(*node).longestPrefix(p)
+	return p[:l], ok
+}
+
+func (p *predicate) Match(r *http.Request) bool {
+	_, ok := p.set.longestPrefix(r.URL.Path)
+	return ok
+}
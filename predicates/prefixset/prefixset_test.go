@@ -0,0 +1,265 @@
+package prefixset
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/zalando/skipper/predicates"
+)
+
+const testPrefixes = `# objects
+/objects/1
+/objects/12
+/objects/12/parts
+
+/static/
+/api/v1
+`
+
+func writePrefixes(t testing.TB, file, content string, modTime time.Time) {
+	if err := os.WriteFile(file, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := os.Chtimes(file, modTime, modTime); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestName(t *testing.T) {
+	if New().Name() != predicates.PathPrefixSetName {
+		t.Errorf("invalid name: %s", New().Name())
+	}
+}
+
+func TestCreate(t *testing.T) {
+	dir := t.TempDir()
+	valid := filepath.Join(dir, "valid.txt")
+	writePrefixes(t, valid, testPrefixes, time.Now())
+
+	invalid := filepath.Join(dir, "invalid.txt")
+	writePrefixes(t, invalid, "/objects\nobjects\n", time.Now())
+
+	for _, tt := range []struct {
+		msg  string
+		args []interface{}
+		err  bool
+	}{{
+		msg: "no args",
+		err: true,
+	}, {
+		msg:  "not a string",
+		args: []interface{}{42.0},
+		err:  true,
+	}, {
+		msg:  "missing file",
+		args: []interface{}{filepath.Join(dir, "missing.txt")},
+		err:  true,
+	}, {
+		msg:  "relative prefix",
+		args: []interface{}{invalid},
+		err:  true,
+	}, {
+		msg:  "valid",
+		args: []interface{}{valid},
+	}} {
+		t.Run(tt.msg, func(t *testing.T) {
+			_, err := New().Create(tt.args)
+			if tt.err && err == nil {
+				t.Error("failed to fail")
+			} else if !tt.err && err != nil {
+				t.Error(err)
+			}
+		})
+	}
+}
+
+func TestLongestPrefix(t *testing.T) {
+	root, err := parse([]byte(testPrefixes))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tt := range []struct {
+		path     string
+		expected string
+		match    bool
+	}{{
+		path:     "/objects/1",
+		expected: "/objects/1",
+		match:    true,
+	}, {
+		path:     "/objects/1/meta",
+		expected: "/objects/1",
+		match:    true,
+	}, {
+		path:     "/objects/12",
+		expected: "/objects/12",
+		match:    true,
+	}, {
+		path:     "/objects/12/meta",
+		expected: "/objects/12",
+		match:    true,
+	}, {
+		path:     "/objects/12/parts/3",
+		expected: "/objects/12/parts",
+		match:    true,
+	}, {
+		path:     "/objects/12/partsx",
+		expected: "/objects/12",
+		match:    true,
+	}, {
+		path: "/objects/123",
+	}, {
+		path: "/objects",
+	}, {
+		path:     "/static/app.js",
+		expected: "/static/",
+		match:    true,
+	}, {
+		path: "/static",
+	}, {
+		path: "/api/v10",
+	}, {
+		path:     "/api/v1/users",
+		expected: "/api/v1",
+		match:    true,
+	}, {
+		path: "/",
+	}, {
+		path: "",
+	}} {
+		t.Run(tt.path, func(t *testing.T) {
+			l, ok := root.longestPrefix(tt.path)
+			if ok != tt.match {
+				t.Fatalf("unexpected match result: %v", ok)
+			}
+
+			if tt.path[:l] != tt.expected {
+				t.Errorf("unexpected longest prefix: %q, expected: %q", tt.path[:l], tt.expected)
+			}
+		})
+	}
+}
+
+func TestRootPrefix(t *testing.T) {
+	root, err := parse([]byte("/\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, p := range []string{"/", "/foo", "/foo/bar"} {
+		if _, ok := root.longestPrefix(p); !ok {
+			t.Errorf("failed to match %s", p)
+		}
+	}
+}
+
+func TestMatch(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "prefixes.txt")
+	writePrefixes(t, file, testPrefixes, time.Now())
+
+	p, err := New().Create([]interface{}{file})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for path, expected := range map[string]bool{
+		"/objects/12/parts/3": true,
+		"/objects/123":        false,
+	} {
+		if m := p.Match(&http.Request{URL: &url.URL{Path: path}}); m != expected {
+			t.Errorf("unexpected match result for %s: %v", path, m)
+		}
+	}
+}
+
+func TestReload(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "prefixes.txt")
+	modTime := time.Now().Add(-time.Hour)
+	writePrefixes(t, file, "/foo\n", modTime)
+
+	var (
+		mu  sync.Mutex
+		now = time.Now()
+	)
+
+	s := New().(*spec)
+	s.now = func() time.Time {
+		mu.Lock()
+		defer mu.Unlock()
+		return now
+	}
+
+	p, err := s.Create([]interface{}{file})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	bar := &http.Request{URL: &url.URL{Path: "/bar"}}
+	if p.Match(bar) {
+		t.Fatal("unexpected match")
+	}
+
+	writePrefixes(t, file, "/foo\n/bar\n", modTime.Add(time.Minute))
+	mu.Lock()
+	now = now.Add(checkInterval)
+	mu.Unlock()
+
+	timeout := time.After(time.Second)
+	for !p.Match(bar) {
+		select {
+		case <-timeout:
+			t.Fatal("failed to reload the prefixes")
+		case <-time.After(time.Millisecond):
+		}
+	}
+
+	// invalid changes keep the last loaded prefixes
+	writePrefixes(t, file, "bar\n", modTime.Add(2*time.Minute))
+	mu.Lock()
+	now = now.Add(checkInterval)
+	mu.Unlock()
+
+	for i := 0; i < 10; i++ {
+		if !p.Match(bar) {
+			t.Fatal("failed to keep the last loaded prefixes")
+		}
+
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func BenchmarkPathPrefixSet(b *testing.B) {
+	const n = 1000000
+
+	var prefixes strings.Builder
+	for i := 0; i < n; i++ {
+		fmt.Fprintf(&prefixes, "/objects/%d/%x\n", i%1000, i)
+	}
+
+	file := filepath.Join(b.TempDir(), "prefixes.txt")
+	writePrefixes(b, file, prefixes.String(), time.Now())
+
+	p, err := New().Create([]interface{}{file})
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	req := &http.Request{URL: &url.URL{Path: fmt.Sprintf("/objects/%d/%x/parts/1", 424242%1000, 424242)}}
+	if !p.Match(req) {
+		b.Fatal("failed to match")
+	}
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		p.Match(req)
+	}
+}
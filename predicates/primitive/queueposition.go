@@ -0,0 +1,76 @@
+package primitive
+
+import (
+	"net/http"
+
+	"github.com/zalando/skipper/predicates"
+	"github.com/zalando/skipper/routing"
+)
+
+// QueueDepth reports the current number of the pending inbound connections
+// of the proxy. It is implemented by *queuelistener.QueueDepth.
+type QueueDepth interface {
+	Value() int
+}
+
+type queuePositionBelowSpec struct {
+	depth QueueDepth
+}
+
+type queuePositionBelow struct {
+	depth QueueDepth
+	limit int
+}
+
+// NewQueuePositionBelow provides a predicate spec to create predicates
+// that evaluate to true when the current inbound queue depth of the proxy
+// is below the given limit. E.g. under overload, half of the requests can
+// be shed by a fallback route:
+//
+//	app: Path("/") && QueuePositionBelow(100) -> "https://app.example.org";
+//	appUnderLoad: Path("/") && TrafficSegment(0, .5) -> "https://app.example.org";
+//	shed: Path("/") -> status(503) -> <shunt>;
+//
+// When the depth is nil, e.g. the TCP queue is not enabled, the depth is
+// always 0.
+func NewQueuePositionBelow(depth QueueDepth) routing.PredicateSpec {
+	return &queuePositionBelowSpec{depth: depth}
+}
+
+func (*queuePositionBelowSpec) Name() string { return predicates.QueuePositionBelowName }
+
+// Create returns a Predicate that evaluates to true when the queue depth is
+// below the limit passed as the only argument.
+func (s *queuePositionBelowSpec) Create(args []interface{}) (routing.Predicate, error) {
+	if len(args) != 1 {
+		return nil, predicates.ErrInvalidPredicateParameters
+	}
+
+	var limit int
+	switch v := args[0].(type) {
+	case int:
+		limit = v
+	case float64:
+		if v != float64(int(v)) {
+			return nil, predicates.ErrInvalidPredicateParameters
+		}
+
+		limit = int(v)
+	default:
+		return nil, predicates.ErrInvalidPredicateParameters
+	}
+
+	if limit <= 0 {
+		return nil, predicates.ErrInvalidPredicateParameters
+	}
+
+	return &queuePositionBelow{depth: s.depth, limit: limit}, nil
+}
+
+func (p *queuePositionBelow) Match(*http.Request) bool {
+	if p.depth == nil {
+		return true
+	}
+
+	return p.depth.Value() < p.limit
+}
@@ -0,0 +1,86 @@
+package primitive
+
+import (
+	"net/http"
+	"testing"
+)
+
+type testQueueDepth int
+
+func (d *testQueueDepth) Value() int { return int(*d) }
+
+func TestQueuePositionBelowCreate(t *testing.T) {
+	for _, tt := range []struct {
+		msg  string
+		args []interface{}
+		err  bool
+	}{{
+		msg: "no args",
+		err: true,
+	}, {
+		msg:  "too many args",
+		args: []interface{}{10.0, 20.0},
+		err:  true,
+	}, {
+		msg:  "not a number",
+		args: []interface{}{"10"},
+		err:  true,
+	}, {
+		msg:  "fraction",
+		args: []interface{}{1.5},
+		err:  true,
+	}, {
+		msg:  "zero",
+		args: []interface{}{0.0},
+		err:  true,
+	}, {
+		msg:  "valid",
+		args: []interface{}{10.0},
+	}, {
+		msg:  "valid int",
+		args: []interface{}{10},
+	}} {
+		t.Run(tt.msg, func(t *testing.T) {
+			_, err := NewQueuePositionBelow(nil).Create(tt.args)
+			if tt.err && err == nil {
+				t.Error("failed to fail")
+			} else if !tt.err && err != nil {
+				t.Error(err)
+			}
+		})
+	}
+}
+
+func TestQueuePositionBelow(t *testing.T) {
+	var depth testQueueDepth
+	p, err := NewQueuePositionBelow(&depth).Create([]interface{}{3.0})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	req, _ := http.NewRequest("GET", "https://www.example.org", nil)
+	for _, tt := range []struct {
+		depth    int
+		expected bool
+	}{
+		{0, true},
+		{2, true},
+		{3, false},
+		{42, false},
+		{1, true},
+	} {
+		depth = testQueueDepth(tt.depth)
+		if m := p.Match(req); m != tt.expected {
+			t.Errorf("unexpected match at depth %d: %v", tt.depth, m)
+		}
+	}
+
+	p, err = NewQueuePositionBelow(nil).Create([]interface{}{1.0})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !p.Match(req) {
+		t.Error("failed to match without queue depth")
+	}
+}
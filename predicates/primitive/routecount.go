@@ -0,0 +1,72 @@
+package primitive
+
+import (
+	"net/http"
+
+	"github.com/zalando/skipper/predicates"
+	"github.com/zalando/skipper/routing"
+)
+
+// RouteCount reports the current number of the routes in the live routing
+// table. It is implemented by *routing.RouteCount.
+type RouteCount interface {
+	Value() int
+}
+
+type routeCountAboveSpec struct {
+	count RouteCount
+}
+
+type routeCountAbove struct {
+	count RouteCount
+	limit int
+}
+
+// NewRouteCountAbove provides a predicate spec to create predicates that
+// evaluate to true when the live routing table contains more routes than
+// the given limit. It is a safety valve against the pathological growth of
+// the routing table, e.g. caused by a misbehaving data client, activating
+// a simple, degraded route:
+//
+//	degraded: Path("/") && RouteCountAbove(100000) -> "https://fallback.example.org";
+//	app: Path("/") -> "https://app.example.org";
+//
+// The count is read in constant time. When the count is nil, it is always
+// 0.
+func NewRouteCountAbove(count RouteCount) routing.PredicateSpec {
+	return &routeCountAboveSpec{count: count}
+}
+
+func (*routeCountAboveSpec) Name() string { return predicates.RouteCountAboveName }
+
+// Create returns a Predicate that evaluates to true when the route count is
+// above the limit passed as the only argument.
+func (s *routeCountAboveSpec) Create(args []interface{}) (routing.Predicate, error) {
+	if len(args) != 1 {
+		return nil, predicates.ErrInvalidPredicateParameters
+	}
+
+	var limit int
+	switch v := args[0].(type) {
+	case int:
+		limit = v
+	case float64:
+		if v != float64(int(v)) {
+			return nil, predicates.ErrInvalidPredicateParameters
+		}
+
+		limit = int(v)
+	default:
+		return nil, predicates.ErrInvalidPredicateParameters
+	}
+
+	if limit < 0 {
+		return nil, predicates.ErrInvalidPredicateParameters
+	}
+
+	return &routeCountAbove{count: s.count, limit: limit}, nil
+}
+
+func (p *routeCountAbove) Match(*http.Request) bool {
+	return p.count != nil && p.count.Value() > p.limit
+}
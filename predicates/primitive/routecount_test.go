@@ -0,0 +1,102 @@
+package primitive
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/zalando/skipper/routing"
+)
+
+type testRouteCount int
+
+func (c *testRouteCount) Value() int { return int(*c) }
+
+func TestRouteCountAboveCreate(t *testing.T) {
+	for _, tt := range []struct {
+		msg  string
+		args []interface{}
+		err  bool
+	}{{
+		msg: "no args",
+		err: true,
+	}, {
+		msg:  "too many args",
+		args: []interface{}{10.0, 20.0},
+		err:  true,
+	}, {
+		msg:  "not a number",
+		args: []interface{}{"10"},
+		err:  true,
+	}, {
+		msg:  "fraction",
+		args: []interface{}{1.5},
+		err:  true,
+	}, {
+		msg:  "negative",
+		args: []interface{}{-1.0},
+		err:  true,
+	}, {
+		msg:  "zero",
+		args: []interface{}{0.0},
+	}, {
+		msg:  "valid",
+		args: []interface{}{10.0},
+	}, {
+		msg:  "valid int",
+		args: []interface{}{10},
+	}} {
+		t.Run(tt.msg, func(t *testing.T) {
+			_, err := NewRouteCountAbove(nil).Create(tt.args)
+			if tt.err && err == nil {
+				t.Error("failed to fail")
+			} else if !tt.err && err != nil {
+				t.Error(err)
+			}
+		})
+	}
+}
+
+func TestRouteCountAbove(t *testing.T) {
+	var count testRouteCount
+	p, err := NewRouteCountAbove(&count).Create([]interface{}{3.0})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	req := &http.Request{}
+	for _, tt := range []struct {
+		count int
+		match bool
+	}{
+		{count: 0},
+		{count: 3},
+		{count: 4, match: true},
+		{count: 100000, match: true},
+		{count: 2},
+	} {
+		count = testRouteCount(tt.count)
+		if m := p.Match(req); m != tt.match {
+			t.Errorf("count %d: expected match: %v, got: %v", tt.count, tt.match, m)
+		}
+	}
+}
+
+func TestRouteCountAboveNilCount(t *testing.T) {
+	p, err := NewRouteCountAbove(nil).Create([]interface{}{0.0})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if p.Match(&http.Request{}) {
+		t.Error("unexpected match without a route count")
+	}
+
+	var count *routing.RouteCount
+	if p, err = NewRouteCountAbove(count).Create([]interface{}{0.0}); err != nil {
+		t.Fatal(err)
+	}
+
+	if p.Match(&http.Request{}) {
+		t.Error("unexpected match with a nil route count")
+	}
+}
@@ -0,0 +1,60 @@
+/*
+Package requesttarget implements the RequestTargetLengthAbove predicate,
+that matches requests with an abnormally long request target, e.g. to route
+them to a stricter handling.
+
+The length is measured on the raw request target, i.e. the path and the
+query as received from the client, in bytes.
+
+Examples:
+
+	suspicious: RequestTargetLengthAbove(4096) -> status(414) -> <shunt>;
+	strict: PathSubtree("/api") && RequestTargetLengthAbove(1024) -> "https://strict.example.org";
+*/
+package requesttarget
+
+import (
+	"net/http"
+
+	"github.com/zalando/skipper/eskip"
+	"github.com/zalando/skipper/predicates"
+	"github.com/zalando/skipper/routing"
+)
+
+type spec struct{}
+
+type predicate int
+
+// New creates a new RequestTargetLengthAbove predicate specification.
+func New() routing.PredicateSpec { return &spec{} }
+
+func (*spec) Name() string { return predicates.RequestTargetLengthAboveName }
+
+// ArgsSchema declares the only argument, the threshold of the length.
+func (*spec) ArgsSchema() eskip.ArgsSchema {
+	return eskip.ArgsSchema{Required: []eskip.ArgType{eskip.ArgInt}}
+}
+
+// Create a predicate instance, that matches when the length of the request
+// target is above the threshold.
+func (*spec) Create(args []interface{}) (routing.Predicate, error) {
+	if len(args) != 1 {
+		return nil, predicates.ErrInvalidPredicateParameters
+	}
+
+	threshold, ok := args[0].(int)
+	if !ok || threshold < 0 {
+		return nil, predicates.ErrInvalidPredicateParameters
+	}
+
+	return predicate(threshold), nil
+}
+
+func (threshold predicate) Match(req *http.Request) bool {
+	target := req.RequestURI
+	if target == "" {
+		target = req.URL.RequestURI()
+	}
+
+	return len(target) > int(threshold)
+}
@@ -0,0 +1,115 @@
+package requesttarget
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func create(args ...interface{}) (predicate, error) {
+	s := New().(*spec)
+	typed, err := s.ArgsSchema().Parse(args)
+	if err != nil {
+		return 0, err
+	}
+
+	p, err := s.Create(typed)
+	if err != nil {
+		return 0, err
+	}
+
+	return p.(predicate), nil
+}
+
+func TestCreate(t *testing.T) {
+	for _, tt := range []struct {
+		msg  string
+		args []interface{}
+		err  bool
+	}{{
+		msg: "no args",
+		err: true,
+	}, {
+		msg:  "too many args",
+		args: []interface{}{4096.0, 1024.0},
+		err:  true,
+	}, {
+		msg:  "not a number",
+		args: []interface{}{"4096"},
+		err:  true,
+	}, {
+		msg:  "not an integer",
+		args: []interface{}{4096.5},
+		err:  true,
+	}, {
+		msg:  "negative",
+		args: []interface{}{-1.0},
+		err:  true,
+	}, {
+		msg:  "threshold",
+		args: []interface{}{4096.0},
+	}} {
+		t.Run(tt.msg, func(t *testing.T) {
+			_, err := create(tt.args...)
+			if tt.err && err == nil {
+				t.Error("failed to fail")
+			} else if !tt.err && err != nil {
+				t.Error(err)
+			}
+		})
+	}
+}
+
+func TestMatch(t *testing.T) {
+	const threshold = 64
+
+	p, err := create(float64(threshold))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tt := range []struct {
+		msg    string
+		target string
+		raw    bool
+		match  bool
+	}{{
+		msg:    "normal target",
+		target: "/api/orders?page=2",
+	}, {
+		msg:    "at the threshold",
+		target: "/" + strings.Repeat("a", threshold-1),
+	}, {
+		msg:    "long path",
+		target: "/" + strings.Repeat("a", threshold),
+		match:  true,
+	}, {
+		msg:    "long query",
+		target: "/search?q=" + strings.Repeat("a", threshold),
+		match:  true,
+	}, {
+		msg:    "long raw target",
+		target: "/" + strings.Repeat("%2F", threshold/2),
+		raw:    true,
+		match:  true,
+	}, {
+		msg:    "normal raw target",
+		target: "/api/orders?page=2",
+		raw:    true,
+	}} {
+		t.Run(tt.msg, func(t *testing.T) {
+			req, err := http.NewRequest("GET", "https://www.example.org"+tt.target, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if tt.raw {
+				req.RequestURI = tt.target
+			}
+
+			if m := p.Match(req); m != tt.match {
+				t.Errorf("expected match: %v, got: %v", tt.match, m)
+			}
+		})
+	}
+}
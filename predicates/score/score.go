@@ -0,0 +1,464 @@
+/*
+Package score implements the ScoreAbove predicate, that matches requests
+whose score, computed from a small expression over the request attributes,
+is above a threshold.
+
+The expression combines numbers and the following functions with the +, -,
+* and / operators and parentheses:
+
+	header('Name')              1 if the header is present, 0 otherwise
+	headerValue('Name', 'v')    1 if the header has the value v, 0 otherwise
+	query('name')               1 if the query parameter is present, 0 otherwise
+	pathDepth()                 the number of the non-empty path segments
+	method('GET', 0.2, ...)     the weight of the request method, 0 when not listed
+	min(a, b), max(a, b)        the smaller and the larger of the two values
+
+The strings can be quoted with single or double quotes. The result of the
+expression is clamped to the [0, 1] interval, and division by zero evaluates
+to 0. The expression cannot contain loops or variables, and its length is
+limited, so its evaluation is always cheap.
+
+Examples:
+
+	// matches when both headers are present, or when X-Client is present
+	// in a POST request
+	ScoreAbove("0.5 * header('X-Client') + 0.3 * header('X-Trace') + method('POST', 0.3)", 0.7)
+
+	// matches deep paths
+	ScoreAbove("min(pathDepth() / 5, 1)", 0.5)
+*/
+package score
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/zalando/skipper/predicates"
+	"github.com/zalando/skipper/routing"
+)
+
+const maxExpressionLength = 1024
+
+type spec struct{}
+
+type predicate struct {
+	expr      evaluator
+	threshold float64
+}
+
+type evaluator func(*http.Request) float64
+
+type tokenType int
+
+const (
+	tokenEOF tokenType = iota
+	tokenNumber
+	tokenString
+	tokenIdent
+	tokenOperator
+	tokenOpen
+	tokenClose
+	tokenComma
+)
+
+type token struct {
+	typ   tokenType
+	value string
+	pos   int
+}
+
+type parser struct {
+	tokens []token
+	pos    int
+}
+
+// New creates a new ScoreAbove predicate specification.
+func New() routing.PredicateSpec { return &spec{} }
+
+func (*spec) Name() string { return predicates.ScoreAboveName }
+
+// Create a predicate instance, that matches when the score of the request
+// is above the threshold. The first argument is the expression, and the
+// second one is the threshold.
+func (*spec) Create(args []interface{}) (routing.Predicate, error) {
+	if len(args) != 2 {
+		return nil, predicates.ErrInvalidPredicateParameters
+	}
+
+	expr, ok := args[0].(string)
+	if !ok {
+		return nil, predicates.ErrInvalidPredicateParameters
+	}
+
+	threshold, ok := args[1].(float64)
+	if !ok || threshold < 0 || threshold > 1 {
+		return nil, predicates.ErrInvalidPredicateParameters
+	}
+
+	e, err := parse(expr)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", predicates.ErrInvalidPredicateParameters, err)
+	}
+
+	return &predicate{expr: e, threshold: threshold}, nil
+}
+
+func (p *predicate) Match(r *http.Request) bool {
+	return score(p.expr, r) > p.threshold
+}
+
+func score(e evaluator, r *http.Request) float64 {
+	s := e(r)
+	switch {
+	case s != s, s < 0:
+		// NaN or negative
+		return 0
+	case s > 1:
+		return 1
+	default:
+		return s
+	}
+}
+
+func isDigit(c byte) bool { return c >= '0' && c <= '9' }
+
+func isIdentChar(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z'
+}
+
+func tokenize(expr string) ([]token, error) {
+	var tokens []token
+	for i := 0; i < len(expr); {
+		c := expr[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n':
+			i++
+		case isDigit(c) || c == '.':
+			start := i
+			for i < len(expr) && (isDigit(expr[i]) || expr[i] == '.') {
+				i++
+			}
+
+			tokens = append(tokens, token{typ: tokenNumber, value: expr[start:i], pos: start})
+		case c == '\'' || c == '"':
+			end := strings.IndexByte(expr[i+1:], c)
+			if end < 0 {
+				return nil, fmt.Errorf("unterminated string at %d", i)
+			}
+
+			tokens = append(tokens, token{typ: tokenString, value: expr[i+1 : i+1+end], pos: i})
+			i += end + 2
+		case isIdentChar(c):
+			start := i
+			for i < len(expr) && isIdentChar(expr[i]) {
+				i++
+			}
+
+			tokens = append(tokens, token{typ: tokenIdent, value: expr[start:i], pos: start})
+		case strings.IndexByte("+-*/", c) >= 0:
+			tokens = append(tokens, token{typ: tokenOperator, value: string(c), pos: i})
+			i++
+		case c == '(':
+			tokens = append(tokens, token{typ: tokenOpen, value: "(", pos: i})
+			i++
+		case c == ')':
+			tokens = append(tokens, token{typ: tokenClose, value: ")", pos: i})
+			i++
+		case c == ',':
+			tokens = append(tokens, token{typ: tokenComma, value: ",", pos: i})
+			i++
+		default:
+			return nil, fmt.Errorf("unexpected character %q at %d", c, i)
+		}
+	}
+
+	return append(tokens, token{typ: tokenEOF, pos: len(expr)}), nil
+}
+
+func parse(expr string) (evaluator, error) {
+	if len(expr) > maxExpressionLength {
+		return nil, fmt.Errorf("expression longer than %d characters", maxExpressionLength)
+	}
+
+	tokens, err := tokenize(expr)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &parser{tokens: tokens}
+	e, err := p.parseExpression()
+	if err != nil {
+		return nil, err
+	}
+
+	if t := p.peek(); t.typ != tokenEOF {
+		return nil, fmt.Errorf("unexpected %q at %d", t.value, t.pos)
+	}
+
+	return e, nil
+}
+
+func (p *parser) peek() token { return p.tokens[p.pos] }
+
+func (p *parser) next() token {
+	t := p.tokens[p.pos]
+	if t.typ != tokenEOF {
+		p.pos++
+	}
+
+	return t
+}
+
+func (p *parser) expect(typ tokenType, value string) error {
+	if t := p.next(); t.typ != typ {
+		return fmt.Errorf("expected %q at %d", value, t.pos)
+	}
+
+	return nil
+}
+
+// expression = term { ("+" | "-") term }
+func (p *parser) parseExpression() (evaluator, error) {
+	left, err := p.parseTerm()
+	if err != nil {
+		return nil, err
+	}
+
+	for t := p.peek(); t.typ == tokenOperator && (t.value == "+" || t.value == "-"); t = p.peek() {
+		p.next()
+		right, err := p.parseTerm()
+		if err != nil {
+			return nil, err
+		}
+
+		l := left
+		if t.value == "+" {
+			left = func(r *http.Request) float64 { return l(r) + right(r) }
+		} else {
+			left = func(r *http.Request) float64 { return l(r) - right(r) }
+		}
+	}
+
+	return left, nil
+}
+
+// term = factor { ("*" | "/") factor }
+func (p *parser) parseTerm() (evaluator, error) {
+	left, err := p.parseFactor()
+	if err != nil {
+		return nil, err
+	}
+
+	for t := p.peek(); t.typ == tokenOperator && (t.value == "*" || t.value == "/"); t = p.peek() {
+		p.next()
+		right, err := p.parseFactor()
+		if err != nil {
+			return nil, err
+		}
+
+		l := left
+		if t.value == "*" {
+			left = func(r *http.Request) float64 { return l(r) * right(r) }
+		} else {
+			left = func(r *http.Request) float64 {
+				d := right(r)
+				if d == 0 {
+					return 0
+				}
+
+				return l(r) / d
+			}
+		}
+	}
+
+	return left, nil
+}
+
+// factor = number | "-" factor | "(" expression ")" | function
+func (p *parser) parseFactor() (evaluator, error) {
+	t := p.next()
+	switch t.typ {
+	case tokenNumber:
+		v, err := strconv.ParseFloat(t.value, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid number %q at %d", t.value, t.pos)
+		}
+
+		return func(*http.Request) float64 { return v }, nil
+	case tokenOperator:
+		if t.value != "-" {
+			return nil, fmt.Errorf("unexpected %q at %d", t.value, t.pos)
+		}
+
+		f, err := p.parseFactor()
+		if err != nil {
+			return nil, err
+		}
+
+		return func(r *http.Request) float64 { return -f(r) }, nil
+	case tokenOpen:
+		e, err := p.parseExpression()
+		if err != nil {
+			return nil, err
+		}
+
+		if err := p.expect(tokenClose, ")"); err != nil {
+			return nil, err
+		}
+
+		return e, nil
+	case tokenIdent:
+		return p.parseFunction(t)
+	case tokenEOF:
+		return nil, fmt.Errorf("unexpected end of expression")
+	default:
+		return nil, fmt.Errorf("unexpected %q at %d", t.value, t.pos)
+	}
+}
+
+// function = identifier "(" [ argument { "," argument } ] ")"
+func (p *parser) parseFunction(name token) (evaluator, error) {
+	if err := p.expect(tokenOpen, "("); err != nil {
+		return nil, err
+	}
+
+	var (
+		strs []string
+		nums []evaluator
+		args []tokenType
+	)
+
+	if p.peek().typ != tokenClose {
+		for {
+			if t := p.peek(); t.typ == tokenString {
+				p.next()
+				strs = append(strs, t.value)
+				args = append(args, tokenString)
+			} else {
+				e, err := p.parseExpression()
+				if err != nil {
+					return nil, err
+				}
+
+				nums = append(nums, e)
+				args = append(args, tokenNumber)
+			}
+
+			if p.peek().typ != tokenComma {
+				break
+			}
+
+			p.next()
+		}
+	}
+
+	if err := p.expect(tokenClose, ")"); err != nil {
+		return nil, err
+	}
+
+	signature := func(expected ...tokenType) error {
+		if len(args) != len(expected) {
+			return fmt.Errorf("invalid number of arguments of %s at %d", name.value, name.pos)
+		}
+
+		for i := range expected {
+			if args[i] != expected[i] {
+				return fmt.Errorf("invalid argument of %s at %d", name.value, name.pos)
+			}
+		}
+
+		return nil
+	}
+
+	switch name.value {
+	case "header":
+		if err := signature(tokenString); err != nil {
+			return nil, err
+		}
+
+		h := strs[0]
+		return func(r *http.Request) float64 { return boolScore(r.Header.Get(h) != "") }, nil
+	case "headerValue":
+		if err := signature(tokenString, tokenString); err != nil {
+			return nil, err
+		}
+
+		h, v := strs[0], strs[1]
+		return func(r *http.Request) float64 { return boolScore(r.Header.Get(h) == v) }, nil
+	case "query":
+		if err := signature(tokenString); err != nil {
+			return nil, err
+		}
+
+		q := strs[0]
+		return func(r *http.Request) float64 { return boolScore(r.URL.Query().Has(q)) }, nil
+	case "pathDepth":
+		if err := signature(); err != nil {
+			return nil, err
+		}
+
+		return pathDepth, nil
+	case "method":
+		return methodWeights(name, args, strs, nums)
+	case "min", "max":
+		if err := signature(tokenNumber, tokenNumber); err != nil {
+			return nil, err
+		}
+
+		a, b, isMin := nums[0], nums[1], name.value == "min"
+		return func(r *http.Request) float64 {
+			va, vb := a(r), b(r)
+			if va < vb == isMin {
+				return va
+			}
+
+			return vb
+		}, nil
+	default:
+		return nil, fmt.Errorf("unknown function %s at %d", name.value, name.pos)
+	}
+}
+
+func methodWeights(name token, args []tokenType, strs []string, nums []evaluator) (evaluator, error) {
+	if len(args) == 0 || len(args)%2 != 0 {
+		return nil, fmt.Errorf("invalid number of arguments of %s at %d", name.value, name.pos)
+	}
+
+	weights := make(map[string]evaluator)
+	for i := 0; i < len(args); i += 2 {
+		if args[i] != tokenString || args[i+1] != tokenNumber {
+			return nil, fmt.Errorf("invalid argument of %s at %d", name.value, name.pos)
+		}
+
+		weights[strings.ToUpper(strs[i/2])] = nums[i/2]
+	}
+
+	return func(r *http.Request) float64 {
+		if w, ok := weights[r.Method]; ok {
+			return w(r)
+		}
+
+		return 0
+	}, nil
+}
+
+func boolScore(b bool) float64 {
+	if b {
+		return 1
+	}
+
+	return 0
+}
+
+func pathDepth(r *http.Request) float64 {
+	var depth int
+	for _, s := range strings.Split(r.URL.Path, "/") {
+		if s != "" {
+			depth++
+		}
+	}
+
+	return float64(depth)
+}
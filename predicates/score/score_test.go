@@ -0,0 +1,253 @@
+package score
+
+import (
+	"math"
+	"net/http"
+	"testing"
+
+	"github.com/zalando/skipper/predicates"
+)
+
+func TestName(t *testing.T) {
+	if New().Name() != predicates.ScoreAboveName {
+		t.Errorf("invalid name: %s", New().Name())
+	}
+}
+
+func TestCreate(t *testing.T) {
+	for _, tt := range []struct {
+		msg  string
+		args []interface{}
+		err  bool
+	}{{
+		msg: "no args",
+		err: true,
+	}, {
+		msg:  "missing threshold",
+		args: []interface{}{"1"},
+		err:  true,
+	}, {
+		msg:  "invalid threshold",
+		args: []interface{}{"1", "0.5"},
+		err:  true,
+	}, {
+		msg:  "threshold out of range",
+		args: []interface{}{"1", 1.5},
+		err:  true,
+	}, {
+		msg:  "expression not a string",
+		args: []interface{}{1.0, 0.5},
+		err:  true,
+	}, {
+		msg:  "empty expression",
+		args: []interface{}{"", 0.5},
+		err:  true,
+	}, {
+		msg:  "unknown function",
+		args: []interface{}{"exec('ls')", 0.5},
+		err:  true,
+	}, {
+		msg:  "invalid character",
+		args: []interface{}{"1 % 2", 0.5},
+		err:  true,
+	}, {
+		msg:  "unterminated string",
+		args: []interface{}{"header('X-Foo)", 0.5},
+		err:  true,
+	}, {
+		msg:  "unbalanced parentheses",
+		args: []interface{}{"(1 + 2", 0.5},
+		err:  true,
+	}, {
+		msg:  "trailing tokens",
+		args: []interface{}{"1 2", 0.5},
+		err:  true,
+	}, {
+		msg:  "invalid number",
+		args: []interface{}{"1.2.3", 0.5},
+		err:  true,
+	}, {
+		msg:  "invalid header argument",
+		args: []interface{}{"header(42)", 0.5},
+		err:  true,
+	}, {
+		msg:  "invalid header value arguments",
+		args: []interface{}{"headerValue('X-Foo')", 0.5},
+		err:  true,
+	}, {
+		msg:  "invalid path depth arguments",
+		args: []interface{}{"pathDepth('/')", 0.5},
+		err:  true,
+	}, {
+		msg:  "invalid method arguments",
+		args: []interface{}{"method('GET')", 0.5},
+		err:  true,
+	}, {
+		msg:  "invalid method argument order",
+		args: []interface{}{"method(0.5, 'GET')", 0.5},
+		err:  true,
+	}, {
+		msg:  "invalid min arguments",
+		args: []interface{}{"min(1)", 0.5},
+		err:  true,
+	}, {
+		msg:  "too long",
+		args: []interface{}{"1" + string(make([]byte, maxExpressionLength)), 0.5},
+		err:  true,
+	}, {
+		msg:  "number",
+		args: []interface{}{"0.5", 0.5},
+	}, {
+		msg:  "combined",
+		args: []interface{}{`0.5 * header("X-Foo") + 0.5 * (query('q') - -headerValue('X-Bar', 'baz')) / 2 + method('get', 0.1, 'POST', 0.2) + min(pathDepth() / 5, max(0, 1))`, 0.5},
+	}} {
+		t.Run(tt.msg, func(t *testing.T) {
+			_, err := New().Create(tt.args)
+			if tt.err && err == nil {
+				t.Error("failed to fail")
+			} else if !tt.err && err != nil {
+				t.Error(err)
+			}
+		})
+	}
+}
+
+func TestScore(t *testing.T) {
+	for _, tt := range []struct {
+		msg      string
+		expr     string
+		method   string
+		url      string
+		header   http.Header
+		expected float64
+	}{{
+		msg:      "constant",
+		expr:     "0.25",
+		expected: 0.25,
+	}, {
+		msg:      "header present",
+		expr:     "header('X-Foo')",
+		header:   http.Header{"X-Foo": []string{"bar"}},
+		expected: 1,
+	}, {
+		msg:      "header missing",
+		expr:     "header('X-Foo')",
+		expected: 0,
+	}, {
+		msg:      "header value",
+		expr:     "headerValue('X-Foo', 'bar') + 0.5 * headerValue('X-Foo', 'baz')",
+		header:   http.Header{"X-Foo": []string{"bar"}},
+		expected: 1,
+	}, {
+		msg:      "query",
+		expr:     "0.5 * query('foo') + 0.25 * query('bar')",
+		url:      "/?foo",
+		expected: 0.5,
+	}, {
+		msg:      "path depth",
+		expr:     "pathDepth() / 4",
+		url:      "/foo/bar//baz",
+		expected: 0.75,
+	}, {
+		msg:      "method weights",
+		expr:     "method('GET', 0.1, 'post', 0.6)",
+		method:   "POST",
+		expected: 0.6,
+	}, {
+		msg:      "method not listed",
+		expr:     "method('GET', 0.1, 'POST', 0.6)",
+		method:   "DELETE",
+		expected: 0,
+	}, {
+		msg:      "precedence and parentheses",
+		expr:     "0.1 + 0.2 * 2 - (0.3 - 0.1) / 2",
+		expected: 0.4,
+	}, {
+		msg:      "min and max",
+		expr:     "min(pathDepth() / 2, 1) * max(0.5, header('X-Foo'))",
+		url:      "/a/b/c/d",
+		expected: 0.5,
+	}, {
+		msg:      "clamped above",
+		expr:     "pathDepth()",
+		url:      "/a/b/c",
+		expected: 1,
+	}, {
+		msg:      "clamped below",
+		expr:     "-header('X-Foo') - 1",
+		expected: 0,
+	}, {
+		msg:      "division by zero",
+		expr:     "1 / header('X-Foo')",
+		expected: 0,
+	}, {
+		msg:  "combined signals",
+		expr: "0.5 * header('X-Client') + 0.3 * header('X-Trace') + method('POST', 0.3)",
+		header: http.Header{
+			"X-Client": []string{"app"},
+			"X-Trace":  []string{"abc"},
+		},
+		method:   "POST",
+		expected: 1,
+	}} {
+		t.Run(tt.msg, func(t *testing.T) {
+			e, err := parse(tt.expr)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			method := tt.method
+			if method == "" {
+				method = "GET"
+			}
+
+			req, err := http.NewRequest(method, "https://www.example.org"+tt.url, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			for k, v := range tt.header {
+				req.Header[k] = v
+			}
+
+			if s := score(e, req); math.Abs(s-tt.expected) > 1e-9 {
+				t.Errorf("expected score: %v, got: %v", tt.expected, s)
+			}
+		})
+	}
+}
+
+func TestMatch(t *testing.T) {
+	p, err := New().Create([]interface{}{"0.5 * header('X-Client') + 0.3 * header('X-Trace') + method('POST', 0.3)", 0.7})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tt := range []struct {
+		msg      string
+		method   string
+		header   []string
+		expected bool
+	}{
+		{"no signals", "GET", nil, false},
+		{"one header", "GET", []string{"X-Client"}, false},
+		{"one header and method", "POST", []string{"X-Client"}, true},
+		{"both headers", "GET", []string{"X-Client", "X-Trace"}, true},
+		{"other header and method", "POST", []string{"X-Trace"}, false},
+	} {
+		t.Run(tt.msg, func(t *testing.T) {
+			req, err := http.NewRequest(tt.method, "https://www.example.org", nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			for _, h := range tt.header {
+				req.Header.Set(h, "foo")
+			}
+
+			if m := p.Match(req); m != tt.expected {
+				t.Errorf("expected match: %v, got: %v", tt.expected, m)
+			}
+		})
+	}
+}
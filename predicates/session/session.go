@@ -0,0 +1,138 @@
+/*
+Package session implements a predicate to match routes based on the number
+of requests made within a user session, e.g. for treating the first few
+requests of new sessions differently during a progressive rollout.
+
+The request counts are tracked in memory, per skipper instance, in a store
+of bounded size. When the store is full, the least recently seen sessions
+are evicted, and their counting starts again from zero.
+
+Examples:
+
+	// the first 5 requests of every session go to the onboarding service
+	onboarding: SessionRequestCountBelow("cookie:SID", 5) -> "https://onboarding.example.org";
+	main: * -> "https://www.example.org";
+*/
+package session
+
+import (
+	"container/list"
+	"net/http"
+	"sync"
+
+	"github.com/zalando/skipper/net"
+	"github.com/zalando/skipper/predicates"
+	"github.com/zalando/skipper/routing"
+)
+
+const defaultMaxSessions = 100000
+
+type sessionEntry struct {
+	key   string
+	count int
+}
+
+// Spec is the SessionRequestCountBelow predicate specification. The
+// predicates created by the same specification share the session store.
+type Spec struct {
+	mu          sync.Mutex
+	maxSessions int
+	entries     map[string]*list.Element
+	order       *list.List
+}
+
+type predicate struct {
+	spec      *Spec
+	attribute net.RequestSelector
+	limit     int
+}
+
+// New creates a SessionRequestCountBelow predicate specification, that
+// tracks the request counts of at most maxSessions sessions. When
+// maxSessions is not positive, 100000 sessions are tracked.
+func New(maxSessions int) *Spec {
+	if maxSessions <= 0 {
+		maxSessions = defaultMaxSessions
+	}
+
+	return &Spec{
+		maxSessions: maxSessions,
+		entries:     make(map[string]*list.Element),
+		order:       list.New(),
+	}
+}
+
+func (*Spec) Name() string { return predicates.SessionRequestCountBelowName }
+
+// Create a predicate instance with two arguments: the session attribute,
+// either "cookie:<name>" or "header:<name>", and the positive request
+// count limit.
+func (s *Spec) Create(args []interface{}) (routing.Predicate, error) {
+	if len(args) != 2 {
+		return nil, predicates.ErrInvalidPredicateParameters
+	}
+
+	a, ok := args[0].(string)
+	if !ok {
+		return nil, predicates.ErrInvalidPredicateParameters
+	}
+
+	attr, err := net.ParseRequestSelector(a, "cookie:", "header:")
+	if err != nil {
+		return nil, err
+	}
+
+	var limit int
+	switch v := args[1].(type) {
+	case int:
+		limit = v
+	case float64:
+		limit = int(v)
+		if float64(limit) != v {
+			return nil, predicates.ErrInvalidPredicateParameters
+		}
+	default:
+		return nil, predicates.ErrInvalidPredicateParameters
+	}
+
+	if limit <= 0 {
+		return nil, predicates.ErrInvalidPredicateParameters
+	}
+
+	return &predicate{spec: s, attribute: attr, limit: limit}, nil
+}
+
+// increment counts a request of the session, and returns the count
+// including the current request.
+func (s *Spec) increment(key string) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if e, ok := s.entries[key]; ok {
+		s.order.MoveToBack(e)
+		se := e.Value.(*sessionEntry)
+		se.count++
+		return se.count
+	}
+
+	for s.order.Len() >= s.maxSessions {
+		e := s.order.Front()
+		s.order.Remove(e)
+		delete(s.entries, e.Value.(*sessionEntry).key)
+	}
+
+	s.entries[key] = s.order.PushBack(&sessionEntry{key: key, count: 1})
+	return 1
+}
+
+// Match counts the request for the session, and matches while the count,
+// including the current request, does not exceed the limit. Requests
+// without a session don't match, and they are not counted.
+func (p *predicate) Match(r *http.Request) bool {
+	v := p.attribute.Value(r)
+	if v == "" {
+		return false
+	}
+
+	return p.spec.increment(p.attribute.String()+"\x00"+v) <= p.limit
+}
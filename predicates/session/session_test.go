@@ -0,0 +1,133 @@
+package session
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestCreate(t *testing.T) {
+	s := New(0)
+	for _, tt := range []struct {
+		msg  string
+		args []interface{}
+		err  bool
+	}{{
+		msg: "no args",
+		err: true,
+	}, {
+		msg:  "missing limit",
+		args: []interface{}{"cookie:SID"},
+		err:  true,
+	}, {
+		msg:  "invalid attribute",
+		args: []interface{}{"query:SID", 5.0},
+		err:  true,
+	}, {
+		msg:  "missing name",
+		args: []interface{}{"cookie:", 5.0},
+		err:  true,
+	}, {
+		msg:  "zero limit",
+		args: []interface{}{"cookie:SID", 0.0},
+		err:  true,
+	}, {
+		msg:  "fractional limit",
+		args: []interface{}{"cookie:SID", 2.5},
+		err:  true,
+	}, {
+		msg:  "cookie",
+		args: []interface{}{"cookie:SID", 5.0},
+	}, {
+		msg:  "header",
+		args: []interface{}{"header:X-Session-Id", 5},
+	}} {
+		t.Run(tt.msg, func(t *testing.T) {
+			_, err := s.Create(tt.args)
+			if tt.err && err == nil {
+				t.Error("failed to fail")
+			} else if !tt.err && err != nil {
+				t.Error(err)
+			}
+		})
+	}
+}
+
+func sessionRequest(t *testing.T, sid string) *http.Request {
+	r, err := http.NewRequest("GET", "https://www.example.org", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if sid != "" {
+		r.AddCookie(&http.Cookie{Name: "SID", Value: sid})
+	}
+
+	return r
+}
+
+func TestMatch(t *testing.T) {
+	p, err := New(0).Create([]interface{}{"cookie:SID", 3.0})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i, expected := range []bool{true, true, true, false, false} {
+		if m := p.Match(sessionRequest(t, "foo")); m != expected {
+			t.Errorf("%d: expected match: %v, got: %v", i, expected, m)
+		}
+	}
+
+	if !p.Match(sessionRequest(t, "bar")) {
+		t.Error("failed to match new session")
+	}
+
+	if p.Match(sessionRequest(t, "")) {
+		t.Error("unexpected match without session")
+	}
+}
+
+func TestSharedStore(t *testing.T) {
+	s := New(0)
+	p1, err := s.Create([]interface{}{"cookie:SID", 2.0})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	p2, err := s.Create([]interface{}{"cookie:SID", 2.0})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// e.g. after a route update, the count continues
+	p1.Match(sessionRequest(t, "foo"))
+	p1.Match(sessionRequest(t, "foo"))
+	if p2.Match(sessionRequest(t, "foo")) {
+		t.Error("expected the count to be shared")
+	}
+}
+
+func TestEviction(t *testing.T) {
+	p, err := New(2).Create([]interface{}{"cookie:SID", 1.0})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	p.Match(sessionRequest(t, "foo"))
+	p.Match(sessionRequest(t, "bar"))
+
+	// seen recently, not evicted
+	if p.Match(sessionRequest(t, "foo")) {
+		t.Error("unexpected match for counted session")
+	}
+
+	p.Match(sessionRequest(t, "baz"))
+
+	// the least recently seen session is evicted
+	if !p.Match(sessionRequest(t, "bar")) {
+		t.Error("expected the evicted session to start again")
+	}
+
+	if n := len(p.(*predicate).spec.entries); n > 2 {
+		t.Errorf("expected at most 2 sessions, got: %d", n)
+	}
+}
@@ -0,0 +1,49 @@
+package source
+
+import (
+	"net"
+	"net/http"
+
+	"github.com/zalando/skipper/predicates"
+	"github.com/zalando/skipper/routing"
+)
+
+type unixSocketSpec struct{}
+
+type unixSocketPredicate struct{}
+
+// NewUnixSocket creates a predicate specification, whose instances match
+// the requests received over a connection accepted on a Unix domain socket
+// listener.
+//
+// The listener of the connection is detected from the local address stored
+// by the HTTP server in the request context.
+//
+// Example:
+//
+//	admin: UnixSocket() && PathSubtree("/admin") -> "http://admin.internal";
+func NewUnixSocket() routing.PredicateSpec { return unixSocketSpec{} }
+
+func (unixSocketSpec) Name() string { return predicates.UnixSocketName }
+
+func (unixSocketSpec) Create(args []interface{}) (routing.Predicate, error) {
+	if len(args) != 0 {
+		return nil, predicates.ErrInvalidPredicateParameters
+	}
+
+	return unixSocketPredicate{}, nil
+}
+
+func (unixSocketPredicate) Match(r *http.Request) bool {
+	addr, ok := r.Context().Value(http.LocalAddrContextKey).(net.Addr)
+	if !ok {
+		return false
+	}
+
+	switch addr.Network() {
+	case "unix", "unixpacket":
+		return true
+	default:
+		return false
+	}
+}
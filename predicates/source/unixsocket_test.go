@@ -0,0 +1,99 @@
+package source
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"path/filepath"
+	"strconv"
+	"testing"
+
+	"github.com/zalando/skipper/predicates"
+)
+
+func TestUnixSocketCreate(t *testing.T) {
+	s := NewUnixSocket()
+	if s.Name() != predicates.UnixSocketName {
+		t.Errorf("invalid name: %s", s.Name())
+	}
+
+	if _, err := s.Create(nil); err != nil {
+		t.Error(err)
+	}
+
+	if _, err := s.Create([]interface{}{"foo"}); err == nil {
+		t.Error("failed to fail")
+	}
+}
+
+func serveUnixSocketMatch(t *testing.T, network, address string) string {
+	t.Helper()
+
+	l, err := net.Listen(network, address)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	p, err := NewUnixSocket().Create(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	srv := &http.Server{Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Match", strconv.FormatBool(p.Match(r)))
+	})}
+
+	go srv.Serve(l)
+	t.Cleanup(func() { srv.Close() })
+	return l.Addr().String()
+}
+
+func TestUnixSocket(t *testing.T) {
+	tcpAddress := serveUnixSocketMatch(t, "tcp", "127.0.0.1:0")
+	unixAddress := serveUnixSocketMatch(t, "unix", filepath.Join(t.TempDir(), "skipper.sock"))
+
+	for _, tt := range []struct {
+		msg      string
+		client   *http.Client
+		url      string
+		expected string
+	}{{
+		msg:      "tcp",
+		client:   http.DefaultClient,
+		url:      "http://" + tcpAddress,
+		expected: "false",
+	}, {
+		msg: "unix",
+		client: &http.Client{Transport: &http.Transport{
+			DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+				var d net.Dialer
+				return d.DialContext(ctx, "unix", unixAddress)
+			},
+		}},
+		url:      "http://unix",
+		expected: "true",
+	}} {
+		t.Run(tt.msg, func(t *testing.T) {
+			rsp, err := tt.client.Get(tt.url)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			defer rsp.Body.Close()
+			if got := rsp.Header.Get("X-Match"); got != tt.expected {
+				t.Errorf("expected match: %s, got: %s", tt.expected, got)
+			}
+		})
+	}
+
+	t.Run("no local address", func(t *testing.T) {
+		req, err := http.NewRequest("GET", "http://www.example.org", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if (unixSocketPredicate{}).Match(req) {
+			t.Error("unexpected match")
+		}
+	})
+}
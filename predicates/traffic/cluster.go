@@ -0,0 +1,131 @@
+package traffic
+
+import (
+	"math/rand"
+	"net/http"
+
+	"github.com/cespare/xxhash/v2"
+
+	"github.com/zalando/skipper/net"
+	"github.com/zalando/skipper/predicates"
+	"github.com/zalando/skipper/routing"
+)
+
+type (
+	clusterSegmentSpec      struct{}
+	clusterSegmentPredicate struct {
+		min, max float64
+		key      net.RequestSelector
+		cohort   string
+	}
+)
+
+// NewClusterSegment creates a new cluster segment predicate specification
+func NewClusterSegment() routing.WeightedPredicateSpec {
+	return &clusterSegmentSpec{}
+}
+
+func (*clusterSegmentSpec) Name() string {
+	return predicates.ClusterSegmentName
+}
+
+// Create new predicate instance with two number arguments _min_ and _max_
+// from an interval [0, 1] (from zero included to one included) and _min_ <= _max_,
+// and a string argument, the hash key of the request, in the form of
+// header:<name>, cookie:<name> or query:<name>.
+//
+// Let _h_ be the hash of the value of the hash key, mapped to [0, 1). This
+// predicate matches if _h_ belongs to an interval from [_min_, _max_). The
+// hash doesn't depend on the instance, therefore all the instances of a
+// Skipper cluster take the same decision for the same key, without
+// coordination. When the request has no value for the key, the
+// one-per-request random value of TrafficSegment is used instead.
+//
+// The optional fourth argument labels the cohort of the requests matching
+// the route, like with TrafficSegment.
+//
+// This predicate has weight of -1 and therefore does not affect route weight.
+//
+// Example of routes sending 10% of the users to a canary, consistently
+// across the cluster:
+//
+//	canary: Path("/") && ClusterSegment(0.0, 0.1, "header:X-User-Id", "canary") -> "https://canary.example.org";
+//	stable: Path("/") -> "https://stable.example.org";
+func (*clusterSegmentSpec) Create(args []any) (routing.Predicate, error) {
+	if len(args) != 3 && len(args) != 4 {
+		return nil, predicates.ErrInvalidPredicateParameters
+	}
+
+	p, ok := &clusterSegmentPredicate{}, false
+
+	var err error
+	if p.min, err = fractionArg(args[0]); err != nil {
+		return nil, err
+	}
+
+	if p.max, err = fractionArg(args[1]); err != nil {
+		return nil, err
+	}
+
+	if p.min > p.max {
+		return nil, predicates.ErrInvalidPredicateParameters
+	}
+
+	key, ok := args[2].(string)
+	if !ok {
+		return nil, predicates.ErrInvalidPredicateParameters
+	}
+
+	if p.key, err = net.ParseRequestSelector(key, "header:", "cookie:", "query:"); err != nil {
+		return nil, predicates.ErrInvalidPredicateParameters
+	}
+
+	if len(args) == 4 {
+		if p.cohort, ok = args[3].(string); !ok || p.cohort == "" {
+			return nil, predicates.ErrInvalidPredicateParameters
+		}
+	}
+
+	return p, nil
+}
+
+// Weight returns -1.
+// By returning -1 this predicate does not affect route weight.
+func (*clusterSegmentSpec) Weight() int {
+	return -1
+}
+
+// value maps the hash key of the request to [0, 1), or returns the
+// per-request random value, when the request has no value for the key
+func (p *clusterSegmentPredicate) value(req *http.Request) float64 {
+	k := p.key.Value(req)
+	if k == "" {
+		return routing.FromContext(req.Context(), randomValue, rand.Float64)
+	}
+
+	// use the top 53 bits to get a uniform float64 from [0, 1)
+	return float64(xxhash.Sum64String(k)>>11) / (1 << 53)
+}
+
+func (p *clusterSegmentPredicate) Match(req *http.Request) bool {
+	v := p.value(req)
+	return p.min <= v && v < p.max
+}
+
+// TrafficSegment returns the segment metadata of the predicate for the
+// request, see routing.TrafficSegmentPredicate. The random value of the
+// segment is the hash of the key.
+func (p *clusterSegmentPredicate) TrafficSegment(req *http.Request) routing.TrafficSegment {
+	return routing.TrafficSegment{
+		Min:    p.min,
+		Max:    p.max,
+		Cohort: p.cohort,
+		Random: p.value(req),
+	}
+}
+
+// SegmentInterval returns the interval of the predicate, see
+// routing.SegmentIntervalPredicate.
+func (p *clusterSegmentPredicate) SegmentInterval() (float64, float64) {
+	return p.min, p.max
+}
@@ -0,0 +1,100 @@
+package traffic_test
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/zalando/skipper/eskip"
+	"github.com/zalando/skipper/predicates/traffic"
+	"github.com/zalando/skipper/routing"
+)
+
+func TestClusterSegmentInvalidCreateArguments(t *testing.T) {
+	spec := traffic.NewClusterSegment()
+
+	for _, def := range []string{
+		`ClusterSegment()`,
+		`ClusterSegment(0, 1)`,
+		`ClusterSegment(0, 1, "")`,
+		`ClusterSegment(0, 1, "X-User-Id")`,
+		`ClusterSegment(0, 1, "header:")`,
+		`ClusterSegment(0, 1, "path:user")`,
+		`ClusterSegment(0, 1, 42)`,
+		`ClusterSegment(1, 0, "header:X-User-Id")`,
+		`ClusterSegment(0, 1.1, "header:X-User-Id")`,
+		`ClusterSegment("0", 1, "header:X-User-Id")`,
+		`ClusterSegment(0, 1, "header:X-User-Id", "")`,
+		`ClusterSegment(0, 1, "header:X-User-Id", "canary", "foo")`,
+	} {
+		t.Run(def, func(t *testing.T) {
+			pp := eskip.MustParsePredicates(def)
+			require.Len(t, pp, 1)
+
+			_, err := spec.Create(pp[0].Args)
+			assert.Error(t, err)
+		})
+	}
+}
+
+func createClusterSegment(t *testing.T, args ...any) routing.Predicate {
+	p, err := traffic.NewClusterSegment().Create(args)
+	require.NoError(t, err)
+	return p
+}
+
+func userRequest(t *testing.T, user string) *http.Request {
+	req, err := http.NewRequest("GET", "https://www.example.org/?user="+user, nil)
+	require.NoError(t, err)
+
+	req.Header.Set("X-User-Id", user)
+	req.AddCookie(&http.Cookie{Name: "user", Value: user})
+	return req
+}
+
+func TestClusterSegmentConsistentAcrossInstances(t *testing.T) {
+	for _, key := range []string{"header:x-user-id", "cookie:user", "query:user"} {
+		t.Run(key, func(t *testing.T) {
+			// the predicates of different instances
+			first := createClusterSegment(t, 0.0, 0.5, key)
+			second := createClusterSegment(t, 0.0, 0.5, key)
+			other := createClusterSegment(t, 0.5, 1.0, key)
+
+			for i := 0; i < 100; i++ {
+				user := fmt.Sprintf("user-%d", i)
+				m := first.Match(userRequest(t, user))
+				assert.Equal(t, m, second.Match(userRequest(t, user)), "inconsistent match for %s", user)
+				assert.NotEqual(t, m, other.Match(userRequest(t, user)), "adjacent segments must not overlap")
+			}
+		})
+	}
+}
+
+func TestClusterSegmentDistribution(t *testing.T) {
+	p := createClusterSegment(t, 0.2, 0.5, "header:X-User-Id")
+
+	const N = 10000
+	var n int
+	for i := 0; i < N; i++ {
+		if p.Match(userRequest(t, fmt.Sprintf("user-%d", i))) {
+			n++
+		}
+	}
+
+	assert.InDelta(t, 0.3, float64(n)/N, 0.02)
+}
+
+func TestClusterSegmentWithoutKey(t *testing.T) {
+	p := createClusterSegment(t, 0.0, 0.5, "header:X-User-Id", "canary")
+
+	assert.True(t, p.Match(requestWithR(0.2)))
+	assert.False(t, p.Match(requestWithR(0.7)))
+
+	s, ok := (&routing.Route{Predicates: []routing.Predicate{p}}).TrafficSegment(requestWithR(0.3))
+	require.True(t, ok)
+	assert.Equal(t, 0.3, s.Random)
+	assert.Equal(t, "canary", s.Cohort)
+}
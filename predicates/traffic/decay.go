@@ -0,0 +1,184 @@
+package traffic
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/zalando/skipper/predicates"
+	"github.com/zalando/skipper/routing"
+)
+
+// after this many half-lives the decaying fraction is considered zero
+const maxHalfLives = 20
+
+type (
+	// DecaySpec is the specification of the TrafficDecay predicate.
+	DecaySpec struct {
+		mu          sync.Mutex
+		activations map[string]time.Time
+		now         func() time.Time
+	}
+
+	decayPostProcessor struct {
+		spec *DecaySpec
+	}
+
+	decayPredicate struct {
+		start      float64
+		halfLife   time.Duration
+		explicit   bool
+		activation time.Time
+		now        func() time.Time
+	}
+)
+
+// NewDecay creates a new traffic decay predicate specification.
+func NewDecay() *DecaySpec {
+	return &DecaySpec{
+		activations: make(map[string]time.Time),
+		now:         time.Now,
+	}
+}
+
+func (*DecaySpec) Name() string {
+	return predicates.TrafficDecayName
+}
+
+// Create new predicate instance with a _startFraction_ argument from an
+// interval [0, 1] and a _halfLife_ duration argument, e.g. "1h".
+//
+// The matched fraction of the traffic starts with _startFraction_ at the
+// activation time and it decays exponentially with the given half-life,
+// until it is considered zero after 20 half-lives. The predicate matches
+// when the one-per-request uniform random value, the same as used by the
+// TrafficSegment predicate, is below the current fraction.
+//
+// The activation time is the time when the route was created first with
+// a predicate of the same arguments, so that it does not restart on route
+// updates. This requires the PostProcessor of the spec to be registered in
+// the routing, otherwise the activation time is the creation time of the
+// predicate instance. It can be also set explicitly with the optional third
+// argument, an RFC3339 date or a Unix timestamp in seconds.
+//
+// This predicate has weight of -1 and therefore does not affect route weight.
+//
+// Example of a canary route winding down from 20% with a half-life of 1 hour:
+//
+//	canary: Path("/test") && TrafficDecay(0.2, "1h") -> "https://canary.example.org";
+//	stable: Path("/test") -> "https://stable.example.org";
+func (s *DecaySpec) Create(args []any) (routing.Predicate, error) {
+	if len(args) != 2 && len(args) != 3 {
+		return nil, predicates.ErrInvalidPredicateParameters
+	}
+
+	p, ok := &decayPredicate{now: s.now}, false
+	if p.start, ok = args[0].(float64); !ok || p.start < 0 || p.start > 1 {
+		return nil, predicates.ErrInvalidPredicateParameters
+	}
+
+	hl, ok := args[1].(string)
+	if !ok {
+		return nil, predicates.ErrInvalidPredicateParameters
+	}
+
+	var err error
+	if p.halfLife, err = time.ParseDuration(hl); err != nil || p.halfLife <= 0 {
+		return nil, predicates.ErrInvalidPredicateParameters
+	}
+
+	if len(args) == 3 {
+		switch a := args[2].(type) {
+		case float64:
+			p.activation = time.Unix(int64(a), 0)
+		case string:
+			if p.activation, err = time.Parse(time.RFC3339, a); err != nil {
+				return nil, predicates.ErrInvalidPredicateParameters
+			}
+		default:
+			return nil, predicates.ErrInvalidPredicateParameters
+		}
+
+		p.explicit = true
+		return p, nil
+	}
+
+	p.activation = s.now()
+	return p, nil
+}
+
+// Weight returns -1.
+// By returning -1 this predicate does not affect route weight.
+func (*DecaySpec) Weight() int {
+	return -1
+}
+
+// PostProcessor returns the routing.PostProcessor keeping the activation
+// time of the TrafficDecay predicates of a route across the route updates.
+func (s *DecaySpec) PostProcessor() routing.PostProcessor {
+	return decayPostProcessor{spec: s}
+}
+
+func (p decayPostProcessor) Do(routes []*routing.Route) []*routing.Route {
+	s := p.spec
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	inUse := make(map[string]struct{})
+	for _, r := range routes {
+		for _, rp := range r.Predicates {
+			dp, ok := rp.(*decayPredicate)
+			if !ok || dp.explicit {
+				continue
+			}
+
+			key := fmt.Sprint(r.Id, "/", dp.start, "/", dp.halfLife)
+			if activation, ok := s.activations[key]; ok {
+				dp.activation = activation
+			} else {
+				s.activations[key] = dp.activation
+			}
+
+			inUse[key] = struct{}{}
+		}
+	}
+
+	for key := range s.activations {
+		if _, ok := inUse[key]; !ok {
+			delete(s.activations, key)
+		}
+	}
+
+	return routes
+}
+
+func (p *decayPredicate) fraction() float64 {
+	elapsed := p.now().Sub(p.activation)
+	if elapsed <= 0 {
+		return p.start
+	}
+
+	halfLives := float64(elapsed) / float64(p.halfLife)
+	if halfLives >= maxHalfLives {
+		return 0
+	}
+
+	return p.start * math.Exp2(-halfLives)
+}
+
+func (p *decayPredicate) Match(req *http.Request) bool {
+	r := routing.FromContext(req.Context(), randomValue, rand.Float64)
+	return r < p.fraction()
+}
+
+// TrafficSegment returns the current segment [0, fraction) of the predicate
+// for the request, see routing.TrafficSegmentPredicate.
+func (p *decayPredicate) TrafficSegment(req *http.Request) routing.TrafficSegment {
+	return routing.TrafficSegment{
+		Max:    p.fraction(),
+		Random: routing.FromContext(req.Context(), randomValue, rand.Float64),
+	}
+}
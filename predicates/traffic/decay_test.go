@@ -0,0 +1,153 @@
+package traffic_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/zalando/skipper/eskip"
+	"github.com/zalando/skipper/predicates/traffic"
+	"github.com/zalando/skipper/routing"
+)
+
+func TestTrafficDecayInvalidCreateArguments(t *testing.T) {
+	spec := traffic.NewDecay()
+
+	for _, def := range []string{
+		`TrafficDecay()`,
+		`TrafficDecay(0.5)`,
+		`TrafficDecay(1.1, "1h")`,
+		`TrafficDecay("0.5", "1h")`,
+		`TrafficDecay(0.5, 3600)`,
+		`TrafficDecay(0.5, "foo")`,
+		`TrafficDecay(0.5, "0s")`,
+		`TrafficDecay(0.5, "-1h")`,
+		`TrafficDecay(0.5, "1h", "yesterday")`,
+		`TrafficDecay(0.5, "1h", 0, 1)`,
+	} {
+		t.Run(def, func(t *testing.T) {
+			pp := eskip.MustParsePredicates(def)
+			require.Len(t, pp, 1)
+
+			_, err := spec.Create(pp[0].Args)
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestTrafficDecay(t *testing.T) {
+	activation := time.Date(2023, 6, 1, 12, 0, 0, 0, time.UTC)
+	now := activation
+
+	spec := traffic.NewDecay()
+	traffic.SetDecayNow(spec, func() time.Time { return now })
+
+	create := func(def string) routing.Predicate {
+		pp := eskip.MustParsePredicates(def)
+		require.Len(t, pp, 1)
+
+		p, err := spec.Create(pp[0].Args)
+		require.NoError(t, err)
+		return p
+	}
+
+	p := create(`TrafficDecay(0.4, "1h")`)
+
+	for _, tt := range []struct {
+		elapsed time.Duration
+		r       float64
+		match   bool
+	}{
+		{elapsed: 0, r: 0.39, match: true},
+		{elapsed: 0, r: 0.4, match: false},
+		{elapsed: time.Hour, r: 0.19, match: true},
+		{elapsed: time.Hour, r: 0.2, match: false},
+		{elapsed: 2 * time.Hour, r: 0.09, match: true},
+		{elapsed: 2 * time.Hour, r: 0.11, match: false},
+		{elapsed: 19 * time.Hour, r: 0, match: true},
+		{elapsed: 20 * time.Hour, r: 0, match: false},
+	} {
+		now = activation.Add(tt.elapsed)
+		assert.Equal(t, tt.match, p.Match(requestWithR(tt.r)), "elapsed: %v, r: %v", tt.elapsed, tt.r)
+	}
+
+	// without the post-processor, the activation time is the creation time
+	now = activation.Add(time.Hour)
+	p = create(`TrafficDecay(0.4, "1h")`)
+	assert.True(t, p.Match(requestWithR(0.3)))
+
+	// explicit activation time
+	p = create(`TrafficDecay(0.4, "1h", "2023-06-01T11:00:00Z")`)
+	assert.False(t, p.Match(requestWithR(0.3)))
+	assert.True(t, p.Match(requestWithR(0.09)))
+
+	p = create(`TrafficDecay(0.4, "1h", 1685624400)`)
+	assert.True(t, p.Match(requestWithR(0.3)))
+}
+
+func TestTrafficDecayPostProcessor(t *testing.T) {
+	activation := time.Date(2023, 6, 1, 12, 0, 0, 0, time.UTC)
+	now := activation
+
+	spec := traffic.NewDecay()
+	traffic.SetDecayNow(spec, func() time.Time { return now })
+	pp := spec.PostProcessor()
+
+	route := func(id, def string) *routing.Route {
+		preds := eskip.MustParsePredicates(def)
+		require.Len(t, preds, 1)
+
+		p, err := spec.Create(preds[0].Args)
+		require.NoError(t, err)
+		return &routing.Route{Route: eskip.Route{Id: id}, Predicates: []routing.Predicate{p}}
+	}
+
+	matches := func(r *routing.Route, rv float64) bool {
+		return r.Predicates[0].Match(requestWithR(rv))
+	}
+
+	pp.Do([]*routing.Route{route("canary", `TrafficDecay(0.4, "1h")`)})
+
+	// the activation time is kept when the route is recreated with the same arguments
+	now = activation.Add(time.Hour)
+	canary := route("canary", `TrafficDecay(0.4, "1h")`)
+	other := route("other", `TrafficDecay(0.4, "1h")`)
+	pp.Do([]*routing.Route{canary, other})
+	assert.False(t, matches(canary, 0.3))
+
+	// another route with the same arguments has its own activation time
+	assert.True(t, matches(other, 0.3))
+
+	// other arguments start a new decay
+	canary = route("canary", `TrafficDecay(0.4, "2h")`)
+	pp.Do([]*routing.Route{canary, other})
+	assert.True(t, matches(canary, 0.3))
+
+	// a deleted route starts a new decay when it is created again
+	pp.Do([]*routing.Route{other})
+	now = activation.Add(2 * time.Hour)
+	canary = route("canary", `TrafficDecay(0.4, "2h")`)
+	pp.Do([]*routing.Route{canary, other})
+	assert.True(t, matches(canary, 0.3))
+	assert.False(t, matches(other, 0.3))
+
+	// the explicit activation time is not overridden
+	explicit := route("canary", `TrafficDecay(0.4, "1h", "2023-06-01T11:00:00Z")`)
+	pp.Do([]*routing.Route{explicit})
+	assert.False(t, matches(explicit, 0.3))
+	assert.True(t, matches(explicit, 0.04))
+}
+
+func TestTrafficDecayMetadata(t *testing.T) {
+	pp := eskip.MustParsePredicates(`TrafficDecay(0.5, "1h")`)
+	p, err := traffic.NewDecay().Create(pp[0].Args)
+	require.NoError(t, err)
+
+	req := requestWithR(0.3)
+	s, ok := (&routing.Route{Predicates: []routing.Predicate{p}}).TrafficSegment(req)
+	require.True(t, ok)
+	assert.Equal(t, 0.3, s.Random)
+	assert.InDelta(t, 0.5, s.Max, 0.001)
+}
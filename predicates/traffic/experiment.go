@@ -0,0 +1,113 @@
+package traffic
+
+import (
+	"math/rand"
+	"net/http"
+	"regexp"
+
+	"github.com/zalando/skipper/predicates"
+	"github.com/zalando/skipper/routing"
+)
+
+type (
+	experimentSpec      struct{}
+	experimentPredicate struct {
+		name, variant string
+		weight        float64
+		min, max      float64
+	}
+)
+
+var experimentNameRx = regexp.MustCompile(`^[A-Za-z0-9_.-]+$`)
+
+// NewExperiment creates a new experiment predicate specification
+func NewExperiment() routing.WeightedPredicateSpec {
+	return &experimentSpec{}
+}
+
+func (*experimentSpec) Name() string {
+	return predicates.ExperimentName
+}
+
+// Create new predicate instance with two string arguments, the name of
+// the experiment and the name of the variant, consisting of letters,
+// digits, '_', '.' and '-', and an optional positive relative weight of
+// the variant, defaulting to 1.
+//
+// The routing partitions the one-per-request uniform random value, shared
+// with the TrafficSegment predicate, between the variants of the same
+// experiment, proportionally to their weights, in the order of the variant
+// names. The variant is used as the cohort of the matching requests. See
+// routing.ExperimentPredicate and routing.TrafficSegment.
+//
+// This predicate has weight of -1 and therefore does not affect route weight.
+//
+// Example of routes splitting traffic in 50%+25%+25% proportion:
+//
+//	a: Path("/test") && Experiment("checkout", "a", 2) -> "https://a.example.org";
+//	b: Path("/test") && Experiment("checkout", "b") -> "https://b.example.org";
+//	c: Path("/test") && Experiment("checkout", "c") -> "https://c.example.org";
+func (*experimentSpec) Create(args []any) (routing.Predicate, error) {
+	if len(args) < 2 || len(args) > 3 {
+		return nil, predicates.ErrInvalidPredicateParameters
+	}
+
+	name, ok := args[0].(string)
+	if !ok || !experimentNameRx.MatchString(name) {
+		return nil, predicates.ErrInvalidPredicateParameters
+	}
+
+	variant, ok := args[1].(string)
+	if !ok || !experimentNameRx.MatchString(variant) {
+		return nil, predicates.ErrInvalidPredicateParameters
+	}
+
+	weight := 1.0
+	if len(args) == 3 {
+		if weight, ok = args[2].(float64); !ok || weight <= 0 {
+			return nil, predicates.ErrInvalidPredicateParameters
+		}
+	}
+
+	return &experimentPredicate{name: name, variant: variant, weight: weight}, nil
+}
+
+// Weight returns -1.
+// By returning -1 this predicate does not affect route weight.
+func (*experimentSpec) Weight() int {
+	return -1
+}
+
+func (p *experimentPredicate) Match(req *http.Request) bool {
+	r := routing.FromContext(req.Context(), randomValue, rand.Float64)
+	return p.min <= r && r < p.max
+}
+
+// Experiment returns the name of the experiment, the variant and its
+// weight, see routing.ExperimentPredicate.
+func (p *experimentPredicate) Experiment() (string, string, float64) {
+	return p.name, p.variant, p.weight
+}
+
+// SetSegment sets the slice of the random value assigned to the variant,
+// see routing.ExperimentPredicate.
+func (p *experimentPredicate) SetSegment(min, max float64) {
+	p.min, p.max = min, max
+}
+
+// TrafficSegment returns the segment metadata of the predicate for the
+// request, see routing.TrafficSegmentPredicate.
+func (p *experimentPredicate) TrafficSegment(req *http.Request) routing.TrafficSegment {
+	return routing.TrafficSegment{
+		Min:    p.min,
+		Max:    p.max,
+		Cohort: p.variant,
+		Random: routing.FromContext(req.Context(), randomValue, rand.Float64),
+	}
+}
+
+// SegmentInterval returns the interval of the predicate, see
+// routing.SegmentIntervalPredicate.
+func (p *experimentPredicate) SegmentInterval() (float64, float64) {
+	return p.min, p.max
+}
@@ -0,0 +1,165 @@
+package traffic_test
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/zalando/skipper/eskip"
+	"github.com/zalando/skipper/filters/builtin"
+	"github.com/zalando/skipper/logging/loggingtest"
+	"github.com/zalando/skipper/predicates"
+	"github.com/zalando/skipper/predicates/traffic"
+	"github.com/zalando/skipper/proxy/proxytest"
+	"github.com/zalando/skipper/routing"
+	"github.com/zalando/skipper/routing/testdataclient"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExperimentInvalidCreateArguments(t *testing.T) {
+	spec := traffic.NewExperiment()
+
+	for _, def := range []string{
+		`Experiment()`,
+		`Experiment("checkout")`,
+		`Experiment("checkout", 1)`,
+		`Experiment(1, "a")`,
+		`Experiment("", "a")`,
+		`Experiment("checkout", "")`,
+		`Experiment("checkout", "a b")`,
+		`Experiment("check/out", "a")`,
+		`Experiment("checkout", "a", 0)`,
+		`Experiment("checkout", "a", "1")`,
+		`Experiment("checkout", "a", 1, 2)`,
+	} {
+		t.Run(def, func(t *testing.T) {
+			pp := eskip.MustParsePredicates(def)
+			require.Len(t, pp, 1)
+
+			_, err := spec.Create(pp[0].Args)
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestExperimentSpec(t *testing.T) {
+	spec := traffic.NewExperiment()
+
+	assert.Equal(t, predicates.ExperimentName, spec.Name())
+	assert.Equal(t, -1, spec.Weight())
+}
+
+func routingWithExperiments(t *testing.T, doc string) *routing.Routing {
+	dc, err := testdataclient.NewDoc(doc)
+	require.NoError(t, err)
+	t.Cleanup(dc.Close)
+
+	l := loggingtest.New()
+	t.Cleanup(l.Close)
+
+	rt := routing.New(routing.Options{
+		DataClients: []routing.DataClient{dc},
+		Predicates:  []routing.PredicateSpec{traffic.NewExperiment(), traffic.NewSegment()},
+		Log:         l,
+	})
+	t.Cleanup(rt.Close)
+
+	require.NoError(t, l.WaitFor("route settings applied", time.Second))
+	return rt
+}
+
+func TestExperimentPartition(t *testing.T) {
+	rt := routingWithExperiments(t, `
+		a: Path("/checkout") && Experiment("checkout", "a", 2) -> <shunt>;
+		b: Path("/checkout") && Experiment("checkout", "b") -> <shunt>;
+		c: Path("/checkout") && Experiment("checkout", "c") -> <shunt>;
+		aOther: Path("/other") && Experiment("checkout", "a", 2) -> <shunt>;
+		x: Path("/search") && Experiment("search", "x") -> <shunt>;
+		y: Path("/search") && Experiment("search", "y") -> <shunt>;
+	`)
+
+	for _, tt := range []struct {
+		path     string
+		r        float64
+		expected string
+		segment  routing.TrafficSegment
+	}{
+		{"/checkout", 0, "a", routing.TrafficSegment{Min: 0, Max: 0.5, Cohort: "a"}},
+		{"/checkout", 0.49, "a", routing.TrafficSegment{Min: 0, Max: 0.5, Cohort: "a"}},
+		{"/checkout", 0.5, "b", routing.TrafficSegment{Min: 0.5, Max: 0.75, Cohort: "b"}},
+		{"/checkout", 0.8, "c", routing.TrafficSegment{Min: 0.75, Max: 1, Cohort: "c"}},
+		{"/checkout", 0.999, "c", routing.TrafficSegment{Min: 0.75, Max: 1, Cohort: "c"}},
+		{"/other", 0.25, "aOther", routing.TrafficSegment{Min: 0, Max: 0.5, Cohort: "a"}},
+		{"/other", 0.75, "", routing.TrafficSegment{}},
+		{"/search", 0.3, "x", routing.TrafficSegment{Min: 0, Max: 0.5, Cohort: "x"}},
+		{"/search", 0.7, "y", routing.TrafficSegment{Min: 0.5, Max: 1, Cohort: "y"}},
+	} {
+		req, err := http.NewRequest("GET", "https://www.example.org"+tt.path, nil)
+		require.NoError(t, err)
+
+		req = req.WithContext(routing.NewContext(req.Context()))
+		r := tt.r
+		_ = routing.FromContext(req.Context(), traffic.ExportRandomValue, func() float64 { return r })
+
+		route, _ := rt.Route(req)
+		if tt.expected == "" {
+			assert.Nil(t, route, "%s %v", tt.path, tt.r)
+			continue
+		}
+
+		require.NotNil(t, route, "%s %v", tt.path, tt.r)
+		assert.Equal(t, tt.expected, route.Id, "%s %v", tt.path, tt.r)
+
+		segment, ok := route.TrafficSegment(req)
+		require.True(t, ok)
+
+		tt.segment.RouteId = tt.expected
+		assert.InDelta(t, tt.segment.Min, segment.Min, 1e-9)
+		assert.InDelta(t, tt.segment.Max, segment.Max, 1e-9)
+		assert.Equal(t, tt.segment.Cohort, segment.Cohort)
+		assert.Equal(t, tt.segment.RouteId, segment.RouteId)
+	}
+}
+
+func TestExperimentUnpartitionedDoesNotMatch(t *testing.T) {
+	pp := eskip.MustParsePredicates(`Experiment("checkout", "a")`)
+	require.Len(t, pp, 1)
+
+	p, err := traffic.NewExperiment().Create(pp[0].Args)
+	require.NoError(t, err)
+
+	assert.False(t, p.Match(requestWithR(0)))
+	assert.False(t, p.Match(requestWithR(0.5)))
+}
+
+func TestExperimentSplit(t *testing.T) {
+	p := proxytest.Config{
+		RoutingOptions: routing.Options{
+			FilterRegistry: builtin.MakeRegistry(),
+			Predicates: []routing.PredicateSpec{
+				traffic.NewExperiment(),
+			},
+		},
+		Routes: eskip.MustParse(`
+			a: Path("/test") && Experiment("test", "a", 2) -> status(200) -> <shunt>;
+			b: Path("/test") && Experiment("test", "b") -> status(201) -> <shunt>;
+			c: Path("/test") && Experiment("test", "c") -> status(202) -> <shunt>;
+		`),
+	}.Create()
+	defer p.Close()
+
+	const (
+		N     = 1_000
+		delta = 0.05 * N
+	)
+
+	codes := getN(t, p.Client(), p.URL+"/test", N)
+
+	t.Logf("Response codes: %v", codes)
+
+	assert.InDelta(t, N*0.5, codes[200], delta)
+	assert.InDelta(t, N*0.25, codes[201], delta)
+	assert.InDelta(t, N*0.25, codes[202], delta)
+}
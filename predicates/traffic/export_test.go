@@ -1,3 +1,13 @@
 package traffic
 
+import "time"
+
 var ExportRandomValue = randomValue
+
+func ExportNamespacedRandomValue(namespace string) contextKey {
+	return contextKey{namespace: namespace}
+}
+
+func SetDecayNow(spec *DecaySpec, now func() time.Time) {
+	spec.now = now
+}
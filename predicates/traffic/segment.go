@@ -1,8 +1,12 @@
 package traffic
 
 import (
+	"fmt"
 	"math/rand"
 	"net/http"
+	"os"
+	"regexp"
+	"strconv"
 
 	"github.com/zalando/skipper/predicates"
 	"github.com/zalando/skipper/routing"
@@ -10,13 +14,23 @@ import (
 
 type (
 	segmentSpec      struct{}
-	segmentPredicate struct{ min, max float64 }
+	segmentPredicate struct {
+		min, max float64
+		cohort   string
+		random   contextKey
+	}
 )
 
-type contextKey struct{}
+// contextKey identifies the random value of the request in the routing
+// context. The predicates using the same namespace share the random value.
+type contextKey struct {
+	namespace string
+}
 
 var randomValue contextKey
 
+var envReference = regexp.MustCompile(`^\$\{([A-Za-z_][A-Za-z0-9_]*)\}$`)
+
 // NewSegment creates a new traffic segment predicate specification
 func NewSegment() routing.WeightedPredicateSpec {
 	return &segmentSpec{}
@@ -37,24 +51,42 @@ func (*segmentSpec) Name() string {
 //
 // This predicate has weight of -1 and therefore does not affect route weight.
 //
+// The optional third argument labels the cohort of the requests matching the
+// route, e.g. "canary". See routing.TrafficSegment.
+//
+// The optional fourth argument names the namespace of the random value _r_.
+// The predicates with different namespaces use independent random values for
+// the same request, e.g. to decorrelate the segments of different
+// experiments. The cohort may be empty when the namespace is set.
+//
+// The optional fifth argument is a salt, deriving a separate namespace from
+// the namespace of the predicate, which may be empty. The predicates with
+// the same namespace and salt share the random value, e.g. the routes of the
+// same split, while different salts draw independent values, so that stacked
+// splits within the same namespace don't correlate.
+//
+// The _min_ and _max_ arguments can also reference an environment variable,
+// e.g. "${CANARY_FRACTION}", resolved once when the predicate is created.
+//
 // Example of routes splitting traffic in 50%+30%+20% proportion:
 //
 //	r50: Path("/test") && TrafficSegment(0.0, 0.5) -> <shunt>;
 //	r30: Path("/test") && TrafficSegment(0.5, 0.8) -> <shunt>;
-//	r20: Path("/test") && TrafficSegment(0.8, 1.0) -> <shunt>;
+//	r20: Path("/test") && TrafficSegment(0.8, 1.0, "canary") -> <shunt>;
 func (*segmentSpec) Create(args []any) (routing.Predicate, error) {
-	if len(args) != 2 {
+	if len(args) < 2 || len(args) > 5 {
 		return nil, predicates.ErrInvalidPredicateParameters
 	}
 
 	p, ok := &segmentPredicate{}, false
 
-	if p.min, ok = args[0].(float64); !ok || p.min < 0 || p.min > 1 {
-		return nil, predicates.ErrInvalidPredicateParameters
+	var err error
+	if p.min, err = fractionArg(args[0]); err != nil {
+		return nil, err
 	}
 
-	if p.max, ok = args[1].(float64); !ok || p.max < 0 || p.max > 1 {
-		return nil, predicates.ErrInvalidPredicateParameters
+	if p.max, err = fractionArg(args[1]); err != nil {
+		return nil, err
 	}
 
 	// min == max defines a never-matching interval, e.g. "owl interval" [0,0)
@@ -62,16 +94,93 @@ func (*segmentSpec) Create(args []any) (routing.Predicate, error) {
 		return nil, predicates.ErrInvalidPredicateParameters
 	}
 
+	if len(args) >= 3 {
+		if p.cohort, ok = args[2].(string); !ok || p.cohort == "" && len(args) == 3 {
+			return nil, predicates.ErrInvalidPredicateParameters
+		}
+	}
+
+	if len(args) >= 4 {
+		if p.random.namespace, ok = args[3].(string); !ok || p.random.namespace == "" && len(args) == 4 {
+			return nil, predicates.ErrInvalidPredicateParameters
+		}
+	}
+
+	if len(args) == 5 {
+		salt, ok := args[4].(string)
+		if !ok || salt == "" {
+			return nil, predicates.ErrInvalidPredicateParameters
+		}
+
+		p.random.namespace += "\x00" + salt
+	}
+
 	return p, nil
 }
 
+// fractionArg returns a fraction from an interval [0, 1], defined either as
+// a number or as a reference to an environment variable.
+func fractionArg(a any) (float64, error) {
+	var f float64
+	switch v := a.(type) {
+	case float64:
+		f = v
+	case string:
+		m := envReference.FindStringSubmatch(v)
+		if m == nil {
+			return 0, predicates.ErrInvalidPredicateParameters
+		}
+
+		s, ok := os.LookupEnv(m[1])
+		if !ok {
+			return 0, fmt.Errorf("environment variable %s is not set: %w", m[1], predicates.ErrInvalidPredicateParameters)
+		}
+
+		var err error
+		if f, err = strconv.ParseFloat(s, 64); err != nil {
+			return 0, fmt.Errorf("environment variable %s is not a number: %w", m[1], predicates.ErrInvalidPredicateParameters)
+		}
+	default:
+		return 0, predicates.ErrInvalidPredicateParameters
+	}
+
+	if f < 0 || f > 1 {
+		return 0, predicates.ErrInvalidPredicateParameters
+	}
+
+	return f, nil
+}
+
 // Weight returns -1.
 // By returning -1 this predicate does not affect route weight.
 func (*segmentSpec) Weight() int {
 	return -1
 }
 
+// value returns the random value of the request in the namespace of the
+// predicate.
+func (p *segmentPredicate) value(req *http.Request) float64 {
+	return routing.FromContext(req.Context(), p.random, rand.Float64)
+}
+
 func (p *segmentPredicate) Match(req *http.Request) bool {
-	r := routing.FromContext(req.Context(), randomValue, rand.Float64)
+	r := p.value(req)
 	return p.min <= r && r < p.max
 }
+
+// TrafficSegment returns the segment metadata of the predicate for the
+// request, see routing.TrafficSegmentPredicate.
+func (p *segmentPredicate) TrafficSegment(req *http.Request) routing.TrafficSegment {
+	return routing.TrafficSegment{
+		Min:    p.min,
+		Max:    p.max,
+		Cohort: p.cohort,
+		Random: p.value(req),
+	}
+}
+
+// SegmentInterval returns the interval of the predicate, see
+// routing.SegmentIntervalPredicate.
+func (p *segmentPredicate) SegmentInterval() (float64, float64) {
+	return p.min, p.max
+}
@@ -32,6 +32,13 @@ func TestTrafficSegmentInvalidCreateArguments(t *testing.T) {
 		`TrafficSegment(1, 2)`,
 		`TrafficSegment(0, "1")`,
 		`TrafficSegment("0", 1)`,
+		`TrafficSegment(0, 1, 2)`,
+		`TrafficSegment(0, 1, "")`,
+		`TrafficSegment(0, 1, "canary", "")`,
+		`TrafficSegment(0, 1, "canary", 1)`,
+		`TrafficSegment(0, 1, "canary", "", "")`,
+		`TrafficSegment(0, 1, "canary", "", 1)`,
+		`TrafficSegment(0, 1, "canary", "experiment-a", "foo", "bar")`,
 	} {
 		t.Run(def, func(t *testing.T) {
 			pp := eskip.MustParsePredicates(def)
@@ -43,6 +50,224 @@ func TestTrafficSegmentInvalidCreateArguments(t *testing.T) {
 	}
 }
 
+func TestTrafficSegmentMetadata(t *testing.T) {
+	spec := traffic.NewSegment()
+	create := func(def string) routing.Predicate {
+		pp := eskip.MustParsePredicates(def)
+		require.Len(t, pp, 1)
+
+		p, err := spec.Create(pp[0].Args)
+		require.NoError(t, err)
+		return p
+	}
+
+	req := requestWithR(0.7)
+
+	_, ok := (&routing.Route{}).TrafficSegment(req)
+	assert.False(t, ok)
+
+	r := &routing.Route{Predicates: []routing.Predicate{create(`TrafficSegment(0.1, 0.5)`)}}
+	s, ok := r.TrafficSegment(req)
+	assert.True(t, ok)
+	assert.Equal(t, routing.TrafficSegment{Min: 0.1, Max: 0.5, Random: 0.7}, s)
+
+	r = &routing.Route{Predicates: []routing.Predicate{create(`TrafficSegment(0.5, 1, "canary")`)}}
+	s, ok = r.TrafficSegment(req)
+	assert.True(t, ok)
+	assert.Equal(t, routing.TrafficSegment{Min: 0.5, Max: 1, Cohort: "canary", Random: 0.7}, s)
+}
+
+func TestTrafficSegmentNamespace(t *testing.T) {
+	spec := traffic.NewSegment()
+	create := func(def string) routing.Predicate {
+		pp := eskip.MustParsePredicates(def)
+		require.Len(t, pp, 1)
+
+		p, err := spec.Create(pp[0].Args)
+		require.NoError(t, err)
+		return p
+	}
+
+	req := requestWithR(0.2)
+	_ = routing.FromContext(req.Context(), traffic.ExportNamespacedRandomValue("experiment-a"), func() float64 { return 0.7 })
+
+	def := create(`TrafficSegment(0, 0.5)`)
+	a := create(`TrafficSegment(0, 0.5, "", "experiment-a")`)
+	b := create(`TrafficSegment(0, 0.5, "canary", "experiment-b")`)
+
+	assert.True(t, def.Match(req))
+	assert.False(t, a.Match(req))
+
+	s, ok := (&routing.Route{Predicates: []routing.Predicate{a}}).TrafficSegment(req)
+	require.True(t, ok)
+	assert.Equal(t, routing.TrafficSegment{Min: 0, Max: 0.5, Random: 0.7}, s)
+
+	// the draw of a new namespace is memoized for the request
+	s, ok = (&routing.Route{Predicates: []routing.Predicate{b}}).TrafficSegment(req)
+	require.True(t, ok)
+	for i := 0; i < 10; i++ {
+		assert.Equal(t, s.Random < 0.5, b.Match(req))
+	}
+
+	// the namespaces draw independently
+	const N = 10000
+	var both, onlyDefault int
+	for i := 0; i < N; i++ {
+		req := &http.Request{}
+		req = req.WithContext(routing.NewContext(req.Context()))
+		m, n := def.Match(req), b.Match(req)
+		if m && n {
+			both++
+		}
+
+		if m && !n {
+			onlyDefault++
+		}
+	}
+
+	assert.InDelta(t, 0.25, float64(both)/N, 0.02)
+	assert.InDelta(t, 0.25, float64(onlyDefault)/N, 0.02)
+}
+
+func TestTrafficSegmentSalt(t *testing.T) {
+	spec := traffic.NewSegment()
+	create := func(def string) routing.Predicate {
+		pp := eskip.MustParsePredicates(def)
+		require.Len(t, pp, 1)
+
+		p, err := spec.Create(pp[0].Args)
+		require.NoError(t, err)
+		return p
+	}
+
+	def := create(`TrafficSegment(0, 0.5)`)
+	a := create(`TrafficSegment(0, 0.5, "", "", "split-a")`)
+	aUpper := create(`TrafficSegment(0.5, 1, "canary", "", "split-a")`)
+	b := create(`TrafficSegment(0, 0.5, "canary", "", "split-b")`)
+
+	// the salted value is consistent for the request, and it is shared by
+	// the routes of the same split
+	req := requestWithR(0.3)
+	s, ok := (&routing.Route{Predicates: []routing.Predicate{a}}).TrafficSegment(req)
+	require.True(t, ok)
+	assert.NotEqual(t, 0.3, s.Random)
+	for i := 0; i < 10; i++ {
+		assert.Equal(t, s.Random < 0.5, a.Match(req))
+		assert.NotEqual(t, a.Match(req), aUpper.Match(req))
+	}
+
+	// the salted splits draw independently from each other and from the
+	// unsalted one
+	const N = 10000
+	var aCount, ab, defA int
+	for i := 0; i < N; i++ {
+		req := &http.Request{}
+		req = req.WithContext(routing.NewContext(req.Context()))
+		m, n, d := a.Match(req), b.Match(req), def.Match(req)
+		if m {
+			aCount++
+		}
+
+		if m && n {
+			ab++
+		}
+
+		if m && d {
+			defA++
+		}
+	}
+
+	assert.InDelta(t, 0.5, float64(aCount)/N, 0.02)
+	assert.InDelta(t, 0.25, float64(ab)/N, 0.02)
+	assert.InDelta(t, 0.25, float64(defA)/N, 0.02)
+}
+
+func TestTrafficSegmentNamespaceAndSalt(t *testing.T) {
+	spec := traffic.NewSegment()
+	create := func(def string) routing.Predicate {
+		pp := eskip.MustParsePredicates(def)
+		require.Len(t, pp, 1)
+
+		p, err := spec.Create(pp[0].Args)
+		require.NoError(t, err)
+		return p
+	}
+
+	ns := create(`TrafficSegment(0, 0.5, "", "experiment")`)
+	salt := create(`TrafficSegment(0, 0.5, "", "", "split")`)
+	nsSalt := create(`TrafficSegment(0, 0.5, "", "experiment", "split")`)
+	nsSaltUpper := create(`TrafficSegment(0.5, 1, "canary", "experiment", "split")`)
+
+	// the routes with the same namespace and salt share the value
+	req := requestWithR(0.3)
+	for i := 0; i < 10; i++ {
+		assert.NotEqual(t, nsSalt.Match(req), nsSaltUpper.Match(req))
+	}
+
+	// the salt derives a namespace independent of both the namespace
+	// without the salt, and the salt without the namespace
+	const N = 10000
+	var withNs, withSalt int
+	for i := 0; i < N; i++ {
+		req := &http.Request{}
+		req = req.WithContext(routing.NewContext(req.Context()))
+		m, n, s := nsSalt.Match(req), ns.Match(req), salt.Match(req)
+		if m && n {
+			withNs++
+		}
+
+		if m && s {
+			withSalt++
+		}
+	}
+
+	assert.InDelta(t, 0.25, float64(withNs)/N, 0.02)
+	assert.InDelta(t, 0.25, float64(withSalt)/N, 0.02)
+}
+
+func TestTrafficSegmentEnvironmentVariables(t *testing.T) {
+	t.Setenv("TEST_CANARY_FRACTION", "0.9")
+	t.Setenv("TEST_INVALID_FRACTION", "1.5")
+	t.Setenv("TEST_NOT_A_NUMBER", "foo")
+
+	spec := traffic.NewSegment()
+	create := func(def string) (routing.Predicate, error) {
+		pp := eskip.MustParsePredicates(def)
+		require.Len(t, pp, 1)
+
+		return spec.Create(pp[0].Args)
+	}
+
+	p, err := create(`TrafficSegment("${TEST_CANARY_FRACTION}", 1.0, "canary")`)
+	require.NoError(t, err)
+
+	r := &routing.Route{Predicates: []routing.Predicate{p}}
+	s, ok := r.TrafficSegment(requestWithR(0.95))
+	assert.True(t, ok)
+	assert.Equal(t, routing.TrafficSegment{Min: 0.9, Max: 1, Cohort: "canary", Random: 0.95}, s)
+
+	p, err = create(`TrafficSegment(0.0, "${TEST_CANARY_FRACTION}")`)
+	require.NoError(t, err)
+	assert.True(t, p.Match(requestWithR(0.5)))
+	assert.False(t, p.Match(requestWithR(0.95)))
+
+	for _, def := range []string{
+		`TrafficSegment(0.0, "${TEST_MISSING_FRACTION}")`,
+		`TrafficSegment(0.0, "${TEST_INVALID_FRACTION}")`,
+		`TrafficSegment(0.0, "${TEST_NOT_A_NUMBER}")`,
+		`TrafficSegment(0.0, "$TEST_CANARY_FRACTION")`,
+		`TrafficSegment("${TEST_CANARY_FRACTION}", 0.5)`,
+	} {
+		t.Run(def, func(t *testing.T) {
+			_, err := create(def)
+			assert.ErrorIs(t, err, predicates.ErrInvalidPredicateParameters)
+		})
+	}
+
+	_, err = create(`TrafficSegment(0.0, "${TEST_MISSING_FRACTION}")`)
+	assert.ErrorContains(t, err, "TEST_MISSING_FRACTION is not set")
+}
+
 func requestWithR(r float64) *http.Request {
 	req := &http.Request{}
 	req = req.WithContext(routing.NewContext(req.Context()))
@@ -0,0 +1,126 @@
+package traffic
+
+import (
+	"math/rand"
+	"net/http"
+
+	"golang.org/x/net/http/httpguts"
+
+	"github.com/zalando/skipper/predicates"
+	"github.com/zalando/skipper/routing"
+)
+
+type (
+	segmentExceptSpec      struct{}
+	segmentExceptPredicate struct {
+		min, max float64
+		header   string
+		excluded map[string]bool
+	}
+)
+
+// NewSegmentExcept creates a new traffic segment predicate specification,
+// that excludes the requests by a header.
+func NewSegmentExcept() routing.WeightedPredicateSpec {
+	return &segmentExceptSpec{}
+}
+
+func (*segmentExceptSpec) Name() string {
+	return predicates.SegmentExceptName
+}
+
+// Create new predicate instance with the _min_ and _max_ arguments like
+// TrafficSegment, followed by a header name and one or more excluded header
+// values.
+//
+// The predicate never matches the requests with any of the excluded values
+// in the header, e.g. to keep the VIP traffic out of a canary, without
+// rolling the random value for them. Otherwise it matches like
+// TrafficSegment, and it shares the random value of the request with the
+// TrafficSegment predicates without a namespace.
+//
+// This predicate has weight of -1 and therefore does not affect route weight.
+//
+// Example of a canary route receiving 10% of the traffic, except for the
+// VIP customers:
+//
+//	stable: Path("/") -> "https://stable.example.org";
+//	canary: Path("/") && SegmentExcept(0.9, 1.0, "X-Customer-Tier", "vip", "partner") -> "https://canary.example.org";
+func (*segmentExceptSpec) Create(args []any) (routing.Predicate, error) {
+	if len(args) < 4 {
+		return nil, predicates.ErrInvalidPredicateParameters
+	}
+
+	p := &segmentExceptPredicate{excluded: make(map[string]bool)}
+
+	var err error
+	if p.min, err = fractionArg(args[0]); err != nil {
+		return nil, err
+	}
+
+	if p.max, err = fractionArg(args[1]); err != nil {
+		return nil, err
+	}
+
+	if p.min > p.max {
+		return nil, predicates.ErrInvalidPredicateParameters
+	}
+
+	var ok bool
+	if p.header, ok = args[2].(string); !ok || !httpguts.ValidHeaderFieldName(p.header) {
+		return nil, predicates.ErrInvalidPredicateParameters
+	}
+
+	p.header = http.CanonicalHeaderKey(p.header)
+	for _, a := range args[3:] {
+		v, ok := a.(string)
+		if !ok {
+			return nil, predicates.ErrInvalidPredicateParameters
+		}
+
+		p.excluded[v] = true
+	}
+
+	return p, nil
+}
+
+// Weight returns -1.
+// By returning -1 this predicate does not affect route weight.
+func (*segmentExceptSpec) Weight() int {
+	return -1
+}
+
+func (p *segmentExceptPredicate) isExcluded(req *http.Request) bool {
+	for _, v := range req.Header[p.header] {
+		if p.excluded[v] {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (p *segmentExceptPredicate) Match(req *http.Request) bool {
+	if p.isExcluded(req) {
+		return false
+	}
+
+	r := routing.FromContext(req.Context(), randomValue, rand.Float64)
+	return p.min <= r && r < p.max
+}
+
+// TrafficSegment returns the segment metadata of the predicate for the
+// request, see routing.TrafficSegmentPredicate.
+func (p *segmentExceptPredicate) TrafficSegment(req *http.Request) routing.TrafficSegment {
+	return routing.TrafficSegment{
+		Min:    p.min,
+		Max:    p.max,
+		Random: routing.FromContext(req.Context(), randomValue, rand.Float64),
+	}
+}
+
+// SegmentInterval returns the interval of the predicate, see
+// routing.SegmentIntervalPredicate.
+func (p *segmentExceptPredicate) SegmentInterval() (float64, float64) {
+	return p.min, p.max
+}
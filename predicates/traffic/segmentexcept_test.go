@@ -0,0 +1,101 @@
+package traffic_test
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/zalando/skipper/eskip"
+	"github.com/zalando/skipper/predicates/traffic"
+	"github.com/zalando/skipper/routing"
+)
+
+func createSegmentExcept(t *testing.T, def string) (routing.Predicate, error) {
+	pp := eskip.MustParsePredicates(def)
+	require.Len(t, pp, 1)
+	return traffic.NewSegmentExcept().Create(pp[0].Args)
+}
+
+func TestSegmentExceptInvalidCreateArguments(t *testing.T) {
+	for _, def := range []string{
+		`SegmentExcept()`,
+		`SegmentExcept(0, 1)`,
+		`SegmentExcept(0, 1, "X-Tier")`,
+		`SegmentExcept(1, 0, "X-Tier", "vip")`,
+		`SegmentExcept(0, 1.1, "X-Tier", "vip")`,
+		`SegmentExcept("0", 1, "X-Tier", "vip")`,
+		`SegmentExcept(0, 1, 1, "vip")`,
+		`SegmentExcept(0, 1, "X Tier", "vip")`,
+		`SegmentExcept(0, 1, "X-Tier", "vip", 1)`,
+	} {
+		t.Run(def, func(t *testing.T) {
+			_, err := createSegmentExcept(t, def)
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestSegmentExcept(t *testing.T) {
+	p, err := createSegmentExcept(t, `SegmentExcept(0.5, 1, "x-tier", "vip", "partner")`)
+	require.NoError(t, err)
+
+	for _, test := range []struct {
+		title  string
+		r      float64
+		tier   []string
+		expect bool
+	}{{
+		title:  "in segment",
+		r:      0.7,
+		expect: true,
+	}, {
+		title: "out of segment",
+		r:     0.3,
+	}, {
+		title:  "other header value",
+		r:      0.7,
+		tier:   []string{"standard"},
+		expect: true,
+	}, {
+		title: "excluded",
+		r:     0.7,
+		tier:  []string{"vip"},
+	}, {
+		title: "excluded by the second value",
+		r:     0.7,
+		tier:  []string{"partner"},
+	}, {
+		title: "excluded by one of multiple headers",
+		r:     0.7,
+		tier:  []string{"standard", "vip"},
+	}} {
+		t.Run(test.title, func(t *testing.T) {
+			req := requestWithR(test.r)
+			req.Header = http.Header{"X-Tier": test.tier}
+			assert.Equal(t, test.expect, p.Match(req))
+		})
+	}
+}
+
+func TestSegmentExceptSkipsTheRoll(t *testing.T) {
+	p, err := createSegmentExcept(t, `SegmentExcept(0, 1, "X-Tier", "vip")`)
+	require.NoError(t, err)
+
+	req := &http.Request{Header: http.Header{"X-Tier": []string{"vip"}}}
+	req = req.WithContext(routing.NewContext(req.Context()))
+	assert.False(t, p.Match(req))
+
+	// the random value was not rolled by the excluded request
+	r := routing.FromContext(req.Context(), traffic.ExportRandomValue, func() float64 { return 0.42 })
+	assert.Equal(t, 0.42, r)
+}
+
+func TestSegmentExceptMetadata(t *testing.T) {
+	p, err := createSegmentExcept(t, `SegmentExcept(0.1, 0.5, "X-Tier", "vip")`)
+	require.NoError(t, err)
+
+	s := p.(routing.TrafficSegmentPredicate).TrafficSegment(requestWithR(0.3))
+	assert.Equal(t, routing.TrafficSegment{Min: 0.1, Max: 0.5, Random: 0.3}, s)
+}
@@ -0,0 +1,118 @@
+package traffic
+
+import (
+	"math/rand"
+	"net/http"
+
+	"github.com/cespare/xxhash/v2"
+
+	"github.com/zalando/skipper/predicates"
+	"github.com/zalando/skipper/routing"
+)
+
+type (
+	sessionPathSegmentSpec      struct{}
+	sessionPathSegmentPredicate struct {
+		cookie   string
+		min, max float64
+	}
+)
+
+// NewSessionPathSegment creates a new session path segment predicate specification
+func NewSessionPathSegment() routing.WeightedPredicateSpec {
+	return &sessionPathSegmentSpec{}
+}
+
+func (*sessionPathSegmentSpec) Name() string {
+	return predicates.SessionPathSegmentName
+}
+
+// Create new predicate instance with a string argument, the name of the
+// session cookie, and two number arguments _min_ and _max_ from an interval
+// [0, 1] (from zero included to one included) and _min_ <= _max_.
+//
+// Let _h_ be the hash of the session cookie value combined with the request
+// path, mapped to [0, 1). This predicate matches if _h_ belongs to an
+// interval from [_min_, _max_). The same session always falls into the same
+// interval for the same path, but it may fall into different intervals for
+// different paths. When the request has no session cookie, the
+// one-per-request random value of TrafficSegment is used instead.
+//
+// This predicate has weight of -1 and therefore does not affect route weight.
+//
+// Example of routes sending 10% of the sessions to a canary, per resource:
+//
+//	canary: PathSubtree("/") && SessionPathSegment("session", 0.0, 0.1) -> "https://canary.example.org";
+//	stable: PathSubtree("/") -> "https://stable.example.org";
+func (*sessionPathSegmentSpec) Create(args []any) (routing.Predicate, error) {
+	if len(args) != 3 {
+		return nil, predicates.ErrInvalidPredicateParameters
+	}
+
+	p, ok := &sessionPathSegmentPredicate{}, false
+
+	if p.cookie, ok = args[0].(string); !ok || p.cookie == "" {
+		return nil, predicates.ErrInvalidPredicateParameters
+	}
+
+	if p.min, ok = args[1].(float64); !ok || p.min < 0 || p.min > 1 {
+		return nil, predicates.ErrInvalidPredicateParameters
+	}
+
+	if p.max, ok = args[2].(float64); !ok || p.max < 0 || p.max > 1 {
+		return nil, predicates.ErrInvalidPredicateParameters
+	}
+
+	if p.min > p.max {
+		return nil, predicates.ErrInvalidPredicateParameters
+	}
+
+	return p, nil
+}
+
+// Weight returns -1.
+// By returning -1 this predicate does not affect route weight.
+func (*sessionPathSegmentSpec) Weight() int {
+	return -1
+}
+
+// value maps the session cookie and the request path to [0, 1), or returns
+// the per-request random value, when the session cookie is missing
+func (p *sessionPathSegmentPredicate) value(req *http.Request) float64 {
+	c, err := req.Cookie(p.cookie)
+	if err != nil || c.Value == "" {
+		return routing.FromContext(req.Context(), randomValue, rand.Float64)
+	}
+
+	h := xxhash.New()
+	h.WriteString(c.Value)
+
+	// separate the session from the path, so that they can't be shifted
+	h.WriteString("\x00")
+	h.WriteString(req.URL.Path)
+
+	// use the top 53 bits to get a uniform float64 from [0, 1)
+	return float64(h.Sum64()>>11) / (1 << 53)
+}
+
+func (p *sessionPathSegmentPredicate) Match(req *http.Request) bool {
+	v := p.value(req)
+	return p.min <= v && v < p.max
+}
+
+// TrafficSegment returns the segment metadata of the predicate for the
+// request, see routing.TrafficSegmentPredicate. The random value of the
+// segment is the session path hash.
+func (p *sessionPathSegmentPredicate) TrafficSegment(req *http.Request) routing.TrafficSegment {
+	return routing.TrafficSegment{
+		Min:    p.min,
+		Max:    p.max,
+		Random: p.value(req),
+	}
+}
+
+// SegmentInterval returns the interval of the predicate, see
+// routing.SegmentIntervalPredicate.
+func (p *sessionPathSegmentPredicate) SegmentInterval() (float64, float64) {
+	return p.min, p.max
+}
@@ -0,0 +1,110 @@
+package traffic_test
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/zalando/skipper/eskip"
+	"github.com/zalando/skipper/predicates/traffic"
+	"github.com/zalando/skipper/routing"
+)
+
+func TestSessionPathSegmentInvalidCreateArguments(t *testing.T) {
+	spec := traffic.NewSessionPathSegment()
+
+	for _, def := range []string{
+		`SessionPathSegment()`,
+		`SessionPathSegment(0, 1)`,
+		`SessionPathSegment("session", 0)`,
+		`SessionPathSegment("", 0, 1)`,
+		`SessionPathSegment(1, 0, 1)`,
+		`SessionPathSegment("session", 1, 0)`,
+		`SessionPathSegment("session", 0, 1.1)`,
+		`SessionPathSegment("session", "0", 1)`,
+		`SessionPathSegment("session", 0, 1, 2)`,
+	} {
+		t.Run(def, func(t *testing.T) {
+			pp := eskip.MustParsePredicates(def)
+			require.Len(t, pp, 1)
+
+			_, err := spec.Create(pp[0].Args)
+			assert.Error(t, err)
+		})
+	}
+}
+
+func createSessionPathSegment(t *testing.T, min, max float64) routing.Predicate {
+	p, err := traffic.NewSessionPathSegment().Create([]any{"session", min, max})
+	require.NoError(t, err)
+	return p
+}
+
+func sessionRequest(t *testing.T, session, path string) *http.Request {
+	req, err := http.NewRequest("GET", "https://www.example.org"+path, nil)
+	require.NoError(t, err)
+
+	req.AddCookie(&http.Cookie{Name: "session", Value: session})
+	return req
+}
+
+func TestSessionPathSegmentStable(t *testing.T) {
+	first := createSessionPathSegment(t, 0, 0.5)
+	second := createSessionPathSegment(t, 0.5, 1)
+
+	for i := 0; i < 100; i++ {
+		session := fmt.Sprintf("session-%d", i)
+		m := first.Match(sessionRequest(t, session, "/articles"))
+		assert.NotEqual(t, m, second.Match(sessionRequest(t, session, "/articles")), "adjacent segments must not overlap")
+
+		for j := 0; j < 10; j++ {
+			assert.Equal(t, m, first.Match(sessionRequest(t, session, "/articles")), "unstable match for %s", session)
+		}
+	}
+}
+
+func TestSessionPathSegmentVariesAcrossPaths(t *testing.T) {
+	p := createSessionPathSegment(t, 0, 0.5)
+
+	const N = 1000
+	var n int
+	for i := 0; i < N; i++ {
+		if p.Match(sessionRequest(t, "session-1", fmt.Sprintf("/items/%d", i))) {
+			n++
+		}
+	}
+
+	assert.InDelta(t, 0.5, float64(n)/N, 0.05)
+}
+
+func TestSessionPathSegmentDistribution(t *testing.T) {
+	p := createSessionPathSegment(t, 0.2, 0.5)
+
+	const N = 10000
+	var n int
+	for i := 0; i < N; i++ {
+		if p.Match(sessionRequest(t, fmt.Sprintf("session-%d", i), "/articles")) {
+			n++
+		}
+	}
+
+	assert.InDelta(t, 0.3, float64(n)/N, 0.02)
+}
+
+func TestSessionPathSegmentWithoutSession(t *testing.T) {
+	p := createSessionPathSegment(t, 0, 0.5)
+
+	assert.True(t, p.Match(requestWithR(0.2)))
+	assert.False(t, p.Match(requestWithR(0.7)))
+
+	req := requestWithR(0.2)
+	req.Header = http.Header{"Cookie": []string{"other=foo"}}
+	assert.True(t, p.Match(req))
+
+	s, ok := (&routing.Route{Predicates: []routing.Predicate{p}}).TrafficSegment(requestWithR(0.3))
+	require.True(t, ok)
+	assert.Equal(t, 0.3, s.Random)
+}
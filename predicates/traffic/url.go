@@ -0,0 +1,100 @@
+package traffic
+
+import (
+	"net/http"
+
+	"github.com/cespare/xxhash/v2"
+
+	"github.com/zalando/skipper/predicates"
+	"github.com/zalando/skipper/routing"
+)
+
+type (
+	urlSegmentSpec      struct{}
+	urlSegmentPredicate struct {
+		min, max float64
+	}
+)
+
+// NewURLSegment creates a new URL segment predicate specification
+func NewURLSegment() routing.WeightedPredicateSpec {
+	return &urlSegmentSpec{}
+}
+
+func (*urlSegmentSpec) Name() string {
+	return predicates.URLSegmentName
+}
+
+// Create new predicate instance with two number arguments _min_ and _max_
+// from an interval [0, 1] (from zero included to one included) and _min_ <= _max_.
+//
+// Let _h_ be the hash of the request host and request URI, mapped to [0, 1).
+// This predicate matches if _h_ belongs to an interval from [_min_, _max_).
+// Unlike TrafficSegment, the same URL always falls into the same interval,
+// also across restarts, e.g. for cache affinity.
+//
+// This predicate has weight of -1 and therefore does not affect route weight.
+//
+// Example of routes sending 10% of the distinct URLs to a canary:
+//
+//	canary: PathSubtree("/") && URLSegment(0.0, 0.1) -> "https://canary.example.org";
+//	stable: PathSubtree("/") -> "https://stable.example.org";
+func (*urlSegmentSpec) Create(args []any) (routing.Predicate, error) {
+	if len(args) != 2 {
+		return nil, predicates.ErrInvalidPredicateParameters
+	}
+
+	p, ok := &urlSegmentPredicate{}, false
+
+	if p.min, ok = args[0].(float64); !ok || p.min < 0 || p.min > 1 {
+		return nil, predicates.ErrInvalidPredicateParameters
+	}
+
+	if p.max, ok = args[1].(float64); !ok || p.max < 0 || p.max > 1 {
+		return nil, predicates.ErrInvalidPredicateParameters
+	}
+
+	if p.min > p.max {
+		return nil, predicates.ErrInvalidPredicateParameters
+	}
+
+	return p, nil
+}
+
+// Weight returns -1.
+// By returning -1 this predicate does not affect route weight.
+func (*urlSegmentSpec) Weight() int {
+	return -1
+}
+
+// urlHash maps the request host and request URI to [0, 1)
+func urlHash(req *http.Request) float64 {
+	h := xxhash.New()
+	h.WriteString(req.Host)
+	h.WriteString(req.URL.RequestURI())
+
+	// use the top 53 bits to get a uniform float64 from [0, 1)
+	return float64(h.Sum64()>>11) / (1 << 53)
+}
+
+func (p *urlSegmentPredicate) Match(req *http.Request) bool {
+	h := urlHash(req)
+	return p.min <= h && h < p.max
+}
+
+// TrafficSegment returns the segment metadata of the predicate for the
+// request, see routing.TrafficSegmentPredicate. The random value of the
+// segment is the URL hash.
+func (p *urlSegmentPredicate) TrafficSegment(req *http.Request) routing.TrafficSegment {
+	return routing.TrafficSegment{
+		Min:    p.min,
+		Max:    p.max,
+		Random: urlHash(req),
+	}
+}
+
+// SegmentInterval returns the interval of the predicate, see
+// routing.SegmentIntervalPredicate.
+func (p *urlSegmentPredicate) SegmentInterval() (float64, float64) {
+	return p.min, p.max
+}
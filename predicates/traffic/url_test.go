@@ -0,0 +1,100 @@
+package traffic_test
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/zalando/skipper/eskip"
+	"github.com/zalando/skipper/predicates/traffic"
+	"github.com/zalando/skipper/routing"
+)
+
+func TestURLSegmentInvalidCreateArguments(t *testing.T) {
+	spec := traffic.NewURLSegment()
+
+	for _, def := range []string{
+		`URLSegment()`,
+		`URLSegment(1)`,
+		`URLSegment(1, 0)`,
+		`URLSegment(0, 1.1)`,
+		`URLSegment(0, "1")`,
+		`URLSegment("0", 1)`,
+		`URLSegment(0, 1, 2)`,
+	} {
+		t.Run(def, func(t *testing.T) {
+			pp := eskip.MustParsePredicates(def)
+			require.Len(t, pp, 1)
+
+			_, err := spec.Create(pp[0].Args)
+			assert.Error(t, err)
+		})
+	}
+}
+
+func createURLSegment(t *testing.T, min, max float64) routing.Predicate {
+	p, err := traffic.NewURLSegment().Create([]any{min, max})
+	require.NoError(t, err)
+	return p
+}
+
+func TestURLSegmentStable(t *testing.T) {
+	first := createURLSegment(t, 0, 0.5)
+	second := createURLSegment(t, 0.5, 1)
+
+	for i := 0; i < 100; i++ {
+		url := fmt.Sprintf("https://www.example.org/articles/%d?page=2", i)
+
+		req, err := http.NewRequest("GET", url, nil)
+		require.NoError(t, err)
+
+		m := first.Match(req)
+		assert.NotEqual(t, m, second.Match(req), "adjacent segments must not overlap")
+
+		for j := 0; j < 10; j++ {
+			req, err := http.NewRequest("GET", url, nil)
+			require.NoError(t, err)
+			assert.Equal(t, m, first.Match(req), "unstable match for %s", url)
+		}
+	}
+}
+
+func TestURLSegmentHashDoesNotChange(t *testing.T) {
+	// the hash must be stable across restarts and releases, the values
+	// were computed once and changing them breaks the cache affinity
+	for _, tt := range []struct {
+		url      string
+		expected float64
+	}{
+		{"https://www.example.org/", 0.687585348910496},
+		{"https://www.example.org/foo?bar=baz", 0.15645681558395252},
+		{"https://api.example.org/foo", 0.16828152136930452},
+	} {
+		req, err := http.NewRequest("GET", tt.url, nil)
+		require.NoError(t, err)
+
+		s, ok := (&routing.Route{Predicates: []routing.Predicate{createURLSegment(t, 0, 1)}}).TrafficSegment(req)
+		require.True(t, ok)
+		assert.Equal(t, tt.expected, s.Random, tt.url)
+	}
+}
+
+func TestURLSegmentDistribution(t *testing.T) {
+	p := createURLSegment(t, 0.2, 0.5)
+
+	const N = 10000
+	var n int
+	for i := 0; i < N; i++ {
+		req, err := http.NewRequest("GET", fmt.Sprintf("https://www.example.org/items/%d", i), nil)
+		require.NoError(t, err)
+
+		if p.Match(req) {
+			n++
+		}
+	}
+
+	assert.InDelta(t, 0.3, float64(n)/N, 0.02)
+}
@@ -0,0 +1,146 @@
+/*
+Package useragent implements a predicate to match routes based on the
+device class of the client, parsed from the User-Agent header.
+
+The classification is coarse, and it is based on substrings of the
+User-Agent, checked in the following order:
+
+  - bot: the known crawlers, monitoring agents and HTTP libraries, and the
+    requests without a User-Agent
+  - tablet: e.g. iPad, Kindle, or Android without the Mobile token
+  - mobile: e.g. iPhone, or Android with the Mobile token
+  - desktop: everything else
+
+Examples:
+
+	// routes the crawlers to the prerendered pages
+	bots: DeviceClass("bot") -> "https://prerender.example.org";
+
+	// routes the phones and the tablets to the mobile site
+	mobile: DeviceClass("mobile", "tablet") -> "https://m.example.org";
+*/
+package useragent
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/zalando/skipper/predicates"
+	"github.com/zalando/skipper/routing"
+)
+
+const (
+	mobile  = "mobile"
+	desktop = "desktop"
+	tablet  = "tablet"
+	bot     = "bot"
+)
+
+// the lowercase substrings identifying the bots, crawlers and other
+// automated clients
+var botTokens = []string{
+	"bot",
+	"crawl",
+	"spider",
+	"slurp",
+	"facebookexternalhit",
+	"mediapartners-google",
+	"bingpreview",
+	"headlesschrome",
+	"lighthouse",
+	"pingdom",
+	"curl/",
+	"wget/",
+	"python-requests",
+	"python-urllib",
+	"go-http-client",
+	"okhttp",
+	"java/",
+	"apache-httpclient",
+	"libwww-perl",
+}
+
+var tabletTokens = []string{
+	"ipad",
+	"tablet",
+	"kindle",
+	"silk/",
+	"playbook",
+}
+
+var mobileTokens = []string{
+	"mobi",
+	"iphone",
+	"ipod",
+	"android",
+	"windows phone",
+	"blackberry",
+	"opera mini",
+}
+
+type spec struct{}
+
+type predicate struct {
+	classes map[string]bool
+}
+
+// New creates a DeviceClass predicate specification.
+func New() routing.PredicateSpec { return &spec{} }
+
+func (*spec) Name() string { return predicates.DeviceClassName }
+
+// Create a predicate instance with one or more arguments, the device
+// classes to match: "mobile", "desktop", "tablet" or "bot".
+func (*spec) Create(args []interface{}) (routing.Predicate, error) {
+	if len(args) == 0 {
+		return nil, predicates.ErrInvalidPredicateParameters
+	}
+
+	classes := make(map[string]bool)
+	for _, a := range args {
+		c, ok := a.(string)
+		if !ok {
+			return nil, predicates.ErrInvalidPredicateParameters
+		}
+
+		switch c {
+		case mobile, desktop, tablet, bot:
+			classes[c] = true
+		default:
+			return nil, predicates.ErrInvalidPredicateParameters
+		}
+	}
+
+	return &predicate{classes: classes}, nil
+}
+
+func containsAny(s string, tokens []string) bool {
+	for _, t := range tokens {
+		if strings.Contains(s, t) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// deviceClass returns the device class of a User-Agent.
+func deviceClass(userAgent string) string {
+	ua := strings.ToLower(userAgent)
+	switch {
+	case strings.TrimSpace(ua) == "" || containsAny(ua, botTokens):
+		return bot
+	case containsAny(ua, tabletTokens):
+		return tablet
+	case strings.Contains(ua, "android") && !strings.Contains(ua, "mobile"):
+		return tablet
+	case containsAny(ua, mobileTokens):
+		return mobile
+	default:
+		return desktop
+	}
+}
+
+func (p *predicate) Match(req *http.Request) bool {
+	return p.classes[deviceClass(req.Header.Get("User-Agent"))]
+}
@@ -0,0 +1,161 @@
+package useragent
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestCreate(t *testing.T) {
+	for _, tt := range []struct {
+		msg  string
+		args []interface{}
+		err  bool
+	}{{
+		msg: "no args",
+		err: true,
+	}, {
+		msg:  "not a string",
+		args: []interface{}{42.0},
+		err:  true,
+	}, {
+		msg:  "unknown class",
+		args: []interface{}{"phone"},
+		err:  true,
+	}, {
+		msg:  "unknown class after valid",
+		args: []interface{}{"mobile", "tv"},
+		err:  true,
+	}, {
+		msg:  "single class",
+		args: []interface{}{"bot"},
+	}, {
+		msg:  "all classes",
+		args: []interface{}{"mobile", "desktop", "tablet", "bot"},
+	}} {
+		t.Run(tt.msg, func(t *testing.T) {
+			_, err := New().Create(tt.args)
+			if tt.err && err == nil {
+				t.Error("failed to fail")
+			} else if !tt.err && err != nil {
+				t.Error(err)
+			}
+		})
+	}
+}
+
+func TestDeviceClass(t *testing.T) {
+	for _, tt := range []struct {
+		userAgent string
+		class     string
+	}{{
+		userAgent: "",
+		class:     bot,
+	}, {
+		userAgent: "Mozilla/5.0 (compatible; Googlebot/2.1; +http://www.google.com/bot.html)",
+		class:     bot,
+	}, {
+		userAgent: "Mozilla/5.0 (Linux; Android 6.0.1; Nexus 5X Build/MMB29P) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/119.0.6045.199 Mobile Safari/537.36 (compatible; Googlebot/2.1; +http://www.google.com/bot.html)",
+		class:     bot,
+	}, {
+		userAgent: "Mozilla/5.0 (compatible; bingbot/2.0; +http://www.bing.com/bingbot.htm)",
+		class:     bot,
+	}, {
+		userAgent: "Mozilla/5.0 (compatible; Yahoo! Slurp; http://help.yahoo.com/help/us/ysearch/slurp)",
+		class:     bot,
+	}, {
+		userAgent: "facebookexternalhit/1.1 (+http://www.facebook.com/externalhit_uatext.php)",
+		class:     bot,
+	}, {
+		userAgent: "Mozilla/5.0 (X11; Linux x86_64) AppleWebKit/537.36 (KHTML, like Gecko) HeadlessChrome/119.0.0.0 Safari/537.36",
+		class:     bot,
+	}, {
+		userAgent: "curl/8.4.0",
+		class:     bot,
+	}, {
+		userAgent: "python-requests/2.31.0",
+		class:     bot,
+	}, {
+		userAgent: "Go-http-client/1.1",
+		class:     bot,
+	}, {
+		userAgent: "Mozilla/5.0 (iPad; CPU OS 17_1 like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/17.1 Mobile/15E148 Safari/604.1",
+		class:     tablet,
+	}, {
+		userAgent: "Mozilla/5.0 (Linux; Android 13; SM-X700) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/119.0.0.0 Safari/537.36",
+		class:     tablet,
+	}, {
+		userAgent: "Mozilla/5.0 (Linux; Android 9; KFTRWI) AppleWebKit/537.36 (KHTML, like Gecko) Silk/118.3.1 like Chrome/118.0.5993.111 Safari/537.36",
+		class:     tablet,
+	}, {
+		userAgent: "Mozilla/5.0 (iPhone; CPU iPhone OS 17_1 like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/17.1 Mobile/15E148 Safari/604.1",
+		class:     mobile,
+	}, {
+		userAgent: "Mozilla/5.0 (Linux; Android 14; Pixel 8) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/119.0.0.0 Mobile Safari/537.36",
+		class:     mobile,
+	}, {
+		userAgent: "Mozilla/5.0 (Android 14; Mobile; rv:120.0) Gecko/120.0 Firefox/120.0",
+		class:     mobile,
+	}, {
+		userAgent: "Opera/9.80 (J2ME/MIDP; Opera Mini/9.80 (S60; SymbOS; Opera Mobi/23.348; U; en) Presto/2.5.25 Version/10.54",
+		class:     mobile,
+	}, {
+		userAgent: "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/119.0.0.0 Safari/537.36",
+		class:     desktop,
+	}, {
+		userAgent: "Mozilla/5.0 (Macintosh; Intel Mac OS X 10_15_7) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/17.1 Safari/605.1.15",
+		class:     desktop,
+	}, {
+		userAgent: "Mozilla/5.0 (X11; Ubuntu; Linux x86_64; rv:120.0) Gecko/20100101 Firefox/120.0",
+		class:     desktop,
+	}} {
+		t.Run(tt.userAgent, func(t *testing.T) {
+			if c := deviceClass(tt.userAgent); c != tt.class {
+				t.Errorf("expected class: %s, got: %s", tt.class, c)
+			}
+		})
+	}
+}
+
+func TestMatch(t *testing.T) {
+	p, err := New().Create([]interface{}{"mobile", "tablet"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tt := range []struct {
+		msg       string
+		userAgent string
+		match     bool
+	}{{
+		msg:       "mobile",
+		userAgent: "Mozilla/5.0 (iPhone; CPU iPhone OS 17_1 like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/17.1 Mobile/15E148 Safari/604.1",
+		match:     true,
+	}, {
+		msg:       "tablet",
+		userAgent: "Mozilla/5.0 (iPad; CPU OS 17_1 like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/17.1 Mobile/15E148 Safari/604.1",
+		match:     true,
+	}, {
+		msg:       "desktop",
+		userAgent: "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/119.0.0.0 Safari/537.36",
+	}, {
+		msg:       "mobile bot",
+		userAgent: "Mozilla/5.0 (iPhone; CPU iPhone OS 14_7_1 like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/14.1.2 Mobile/15E148 Safari/604.1 (compatible; AdsBot-Google-Mobile; +http://www.google.com/mobile/adsbot.html)",
+	}, {
+		msg: "no user agent",
+	}} {
+		t.Run(tt.msg, func(t *testing.T) {
+			req, err := http.NewRequest("GET", "https://www.example.org", nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if tt.userAgent != "" {
+				req.Header.Set("User-Agent", tt.userAgent)
+			}
+
+			if m := p.Match(req); m != tt.match {
+				t.Errorf("expected match: %v, got: %v", tt.match, m)
+			}
+		})
+	}
+}
@@ -290,11 +290,17 @@ func (c *context) Split() (filters.FilterContext, error) {
 	serverSpan := opentracing.SpanFromContext(originalRequest.Context())
 	cr = cr.WithContext(opentracing.ContextWithSpan(cr.Context(), serverSpan))
 	cr = cr.WithContext(routing.NewContext(cr.Context()))
+
+	// the shadow request shares the correlation id with the original request
+	cid := routing.FromContext(originalRequest.Context(), filters.CorrelationIdKey, newCorrelationId)
+	routing.FromContext(cr.Context(), filters.CorrelationIdKey, func() *filters.CorrelationId { return cid })
 	originalRequest.Body = body
 	cc.request = cr
 	return cc, nil
 }
 
+func newCorrelationId() *filters.CorrelationId { return &filters.CorrelationId{} }
+
 func (c *context) Loopback() {
 	err := c.proxy.do(c)
 	if c.response != nil && c.response.Body != nil {
@@ -20,6 +20,9 @@ type (
 
 	// filter to return all state bag values as response headers for assertions
 	returnState struct{}
+
+	// filter to request loopback via the state bag
+	requestLoopback struct{}
 )
 
 func (f *returnPathParam) Name() string                                       { return "returnParam" }
@@ -52,6 +55,14 @@ func (c *returnState) Response(ctx filters.FilterContext) {
 	}
 }
 
+func (l *requestLoopback) Name() string                                       { return "requestLoopback" }
+func (l *requestLoopback) CreateFilter([]interface{}) (filters.Filter, error) { return l, nil }
+func (l *requestLoopback) Response(filters.FilterContext)                     {}
+
+func (l *requestLoopback) Request(ctx filters.FilterContext) {
+	ctx.StateBag()[filters.LoopbackKey] = true
+}
+
 func testLoopback(
 	t *testing.T,
 	routes string,
@@ -96,6 +107,7 @@ func testLoopback(
 	fr.Register(&returnPathParam{})
 	fr.Register(&setState{})
 	fr.Register(&returnState{})
+	fr.Register(&requestLoopback{})
 
 	p, err := newTestProxyWithFiltersAndParams(fr, routes, params, nil)
 	if err != nil {
@@ -397,3 +409,23 @@ func TestLoopbackStatebag(t *testing.T) {
 		"X-State-Bag":        []string{"foo=bar"},
 	})
 }
+
+func TestLoopbackRequestedByFilter(t *testing.T) {
+	routes := `
+		entry: *
+			-> appendResponseHeader("X-Entry-Route-Done", "true")
+			-> setRequestHeader("X-Loop-Route", "1")
+			-> requestLoopback()
+			-> "$backend";
+
+		loopRoute1: Header("X-Loop-Route", "1")
+			-> appendResponseHeader("X-Loop-Route-Done", "1")
+			-> "$backend";
+	`
+
+	testLoopback(t, routes, Params{}, http.StatusOK, http.Header{
+		"X-Entry-Route-Done": []string{"true"},
+		"X-Loop-Route-Done":  []string{"1"},
+		"X-Backend-Done":     []string{"true"},
+	})
+}
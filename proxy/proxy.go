@@ -8,6 +8,7 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"math/rand"
 	"net"
 	"net/http"
 	"net/http/httptrace"
@@ -36,6 +37,7 @@ import (
 	"github.com/zalando/skipper/loadbalancer"
 	"github.com/zalando/skipper/logging"
 	"github.com/zalando/skipper/metrics"
+	snet "github.com/zalando/skipper/net"
 	"github.com/zalando/skipper/proxy/fastcgi"
 	"github.com/zalando/skipper/ratelimit"
 	"github.com/zalando/skipper/rfc"
@@ -106,6 +108,14 @@ const (
 	PatchPath
 )
 
+// DefaultAccessLogCohort is the cohort of the requests, in terms of the
+// AccessLogCohortSampleRates, whose routes have no cohort label.
+const DefaultAccessLogCohort = "default"
+
+// FailFastSaturatedMetricKey is the counter of the requests rejected
+// because of a saturated backend connection pool, see Params.FailFastCohorts.
+const FailFastSaturatedMetricKey = "proxy.failfast.saturated"
+
 // Options are deprecated alias for Flags.
 type Options Flags
 
@@ -167,6 +177,17 @@ type Params struct {
 	// When set, no access log is printed.
 	AccessLogDisabled bool
 
+	// AccessLogCohortSampleRates maps the cohort labels of the TrafficSegment
+	// predicates to access log sampling rates from [0, 1]. The requests of
+	// routes without a cohort label belong to the DefaultAccessLogCohort.
+	// Requests of cohorts not listed in the map are always logged.
+	//
+	// For requests matching a TrafficSegment predicate, the sampling decision
+	// is based on the one-per-request random value of the predicate, scaled
+	// to the segment interval, and therefore it is consistent within a
+	// request, including loopbacks.
+	AccessLogCohortSampleRates map[string]float64
+
 	// DualStack sets if the proxy TCP connections to the backend should be dual stack
 	DualStack bool
 
@@ -190,6 +211,19 @@ type Params struct {
 	// MaxIdleConns limits the number of idle connections to all backends, 0 means no limit
 	MaxIdleConns int
 
+	// MaxConnsPerHost is the same as net/http.Transport.MaxConnsPerHost,
+	// it limits the number of connections to a backend host, 0 means no
+	// limit. When the limit is reached, the backend requests wait for a
+	// free connection.
+	MaxConnsPerHost int
+
+	// FailFastCohorts lists the cohort labels of the TrafficSegment
+	// predicates, e.g. "canary", whose requests fail fast with 503 instead
+	// of waiting for a free connection, when the connection pool of the
+	// backend host is saturated, see MaxConnsPerHost. The fast fails are
+	// counted with the FailFastSaturatedMetricKey counter.
+	FailFastCohorts []string
+
 	// DisableHTTPKeepalives forces backend to always create a new connection
 	DisableHTTPKeepalives bool
 
@@ -320,10 +354,14 @@ type Proxy struct {
 	experimentalUpgrade      bool
 	experimentalUpgradeAudit bool
 	accessLogDisabled        bool
+	accessLogSampleRates     map[string]float64
+	connectionPools          *connectionPools
+	failFastCohorts          map[string]bool
 	maxLoops                 int
 	defaultHTTPStatus        int
 	routing                  *routing.Routing
 	roundTripper             http.RoundTripper
+	proxyProtocolTripper     http.RoundTripper
 	priorityRoutes           []PriorityRoute
 	flags                    Flags
 	metrics                  metrics.Metrics
@@ -641,6 +679,7 @@ func WithParams(p Params) *Proxy {
 		ExpectContinueTimeout: p.ExpectContinueTimeout,
 		MaxIdleConns:          p.MaxIdleConns,
 		MaxIdleConnsPerHost:   p.IdleConnectionsPerHost,
+		MaxConnsPerHost:       p.MaxConnsPerHost,
 		IdleConnTimeout:       p.CloseIdleConnsPeriod,
 		DisableKeepAlives:     p.DisableHTTPKeepalives,
 		Proxy:                 proxyFromContext,
@@ -698,9 +737,15 @@ func WithParams(p Params) *Proxy {
 
 	hostname := os.Getenv("HOSTNAME")
 
+	failFastCohorts := make(map[string]bool)
+	for _, c := range p.FailFastCohorts {
+		failFastCohorts[c] = true
+	}
+
 	return &Proxy{
 		routing:                  p.Routing,
 		roundTripper:             p.CustomHttpRoundTripperWrap(tr),
+		proxyProtocolTripper:     p.CustomHttpRoundTripperWrap(newProxyProtocolTransport(tr)),
 		priorityRoutes:           p.PriorityRoutes,
 		flags:                    p.Flags,
 		metrics:                  m,
@@ -716,6 +761,9 @@ func WithParams(p Params) *Proxy {
 		defaultHTTPStatus:        defaultHTTPStatus,
 		tracing:                  newProxyTracing(p.OpenTracing),
 		accessLogDisabled:        p.AccessLogDisabled,
+		accessLogSampleRates:     p.AccessLogCohortSampleRates,
+		connectionPools:          newConnectionPools(p.MaxConnsPerHost),
+		failFastCohorts:          failFastCohorts,
 		upgradeAuditLogOut:       os.Stdout,
 		upgradeAuditLogErr:       os.Stderr,
 		clientTLS:                tr.TLSClientConfig,
@@ -826,6 +874,10 @@ func (p *Proxy) makeBackendRequest(ctx *context, requestContext stdlibcontext.Co
 		return res, nil
 	}
 
+	if res, ok := p.failFastSaturated(ctx, req); ok {
+		return res, nil
+	}
+
 	if endpoint != nil {
 		endpoint.Metrics.IncInflightRequest()
 		defer endpoint.Metrics.DecInflightRequest()
@@ -869,7 +921,23 @@ func (p *Proxy) makeBackendRequest(ctx *context, requestContext stdlibcontext.Co
 	ctx.proxySpan.LogKV("http_roundtrip", StartEvent)
 	req = injectClientTrace(req, ctx.proxySpan)
 
+	if version, ok := bag[filters.ProxyProtocolKey].(string); ok && req.URL.Scheme != "fastcgi" {
+		if req, err = withProxyProtocol(version, ctx.Request(), req); err != nil {
+			return nil, &proxyError{err: err}
+		}
+
+		roundTripper = p.proxyProtocolTripper
+	}
+
+	release := p.connectionPools.acquire(req.URL.Host)
 	response, err := roundTripper.RoundTrip(req)
+	if release != nil {
+		if err != nil || response.Body == nil {
+			release()
+		} else {
+			response.Body = &releaseBody{ReadCloser: response.Body, release: release}
+		}
+	}
 
 	ctx.proxySpan.LogKV("http_roundtrip", EndEvent)
 	if err != nil {
@@ -966,6 +1034,26 @@ func (p *Proxy) rejectBackend(ctx *context, req *http.Request) (*http.Response,
 	return nil, false
 }
 
+// failFastSaturated rejects the requests of the FailFastCohorts, when the
+// connection pool of the backend host is saturated.
+func (p *Proxy) failFastSaturated(ctx *context, req *http.Request) (*http.Response, bool) {
+	if len(p.failFastCohorts) == 0 {
+		return nil, false
+	}
+
+	segment, ok := ctx.StateBag()[filters.TrafficSegmentKey].(routing.TrafficSegment)
+	if !ok || !p.failFastCohorts[segment.Cohort] || !p.connectionPools.saturated(req.URL.Host) {
+		return nil, false
+	}
+
+	p.metrics.IncCounter(FailFastSaturatedMetricKey)
+	return &http.Response{
+		StatusCode: http.StatusServiceUnavailable,
+		Header:     http.Header{"Content-Length": []string{"0"}},
+		Body:       io.NopCloser(&bytes.Buffer{}),
+	}, true
+}
+
 func (p *Proxy) checkBreaker(c *context) (func(bool), bool) {
 	if p.breakers == nil {
 		return nil, true
@@ -1076,6 +1164,13 @@ func (p *Proxy) do(ctx *context) (err error) {
 	}
 
 	ctx.applyRoute(route, params, p.flags.PreserveHost())
+	if segment, ok := route.TrafficSegment(ctx.request); ok {
+		ctx.stateBag[filters.TrafficSegmentKey] = segment
+	}
+
+	if len(route.Metadata) > 0 {
+		ctx.stateBag[filters.RouteMetadataKey] = route.Metadata
+	}
 
 	processedFilters := p.applyFiltersToRequest(ctx.route.Filters, ctx)
 
@@ -1090,7 +1185,7 @@ func (p *Proxy) do(ctx *context) (err error) {
 			}
 		}
 		ctx.ensureDefaultResponse()
-	} else if ctx.route.BackendType == eskip.LoopBackend {
+	} else if ctx.route.BackendType == eskip.LoopBackend || loopbackRequested(ctx) {
 		loopCTX := ctx.clone()
 		if err := p.do(loopCTX); err != nil {
 			// in case of error we have to copy the response in this recursion unwinding
@@ -1221,6 +1316,7 @@ func (p *Proxy) serveResponse(ctx *context) {
 	}
 
 	p.tracing.setTag(ctx.initialSpan, HTTPStatusCodeTag, uint16(ctx.response.StatusCode))
+	ctx.route.ObserveResponse(ctx.response.StatusCode)
 
 	ctx.responseWriter.WriteHeader(ctx.response.StatusCode)
 	ctx.responseWriter.Flush()
@@ -1283,6 +1379,10 @@ func (p *Proxy) errorResponse(ctx *context, err error) {
 		return
 	}
 
+	if ctx.route != nil {
+		ctx.route.ObserveResponse(ctx.response.StatusCode)
+	}
+
 	msgPrefix := "error while proxying"
 	logFunc := p.log.Errorf
 	if ctx.response.StatusCode == 499 {
@@ -1378,6 +1478,38 @@ func shouldLog(statusCode int, filter *al.AccessLogFilter) bool {
 	return match == filter.Enable
 }
 
+// sampleAccessLog decides whether the access log entry of the request should
+// be logged, based on the sampling rate configured for its cohort.
+func (p *Proxy) sampleAccessLog(ctx *context) bool {
+	if len(p.accessLogSampleRates) == 0 {
+		return true
+	}
+
+	cohort := DefaultAccessLogCohort
+	segment, hasSegment := ctx.stateBag[filters.TrafficSegmentKey].(routing.TrafficSegment)
+	if hasSegment && segment.Cohort != "" {
+		cohort = segment.Cohort
+	}
+
+	sampleRate, ok := p.accessLogSampleRates[cohort]
+	if !ok {
+		return true
+	}
+
+	if !hasSegment {
+		return rand.Float64() < sampleRate
+	}
+
+	// scale the random value to the segment interval, so that the sampling
+	// is uniform within the cohort
+	r := segment.Random
+	if segment.Max > segment.Min {
+		r = (r - segment.Min) / (segment.Max - segment.Min)
+	}
+
+	return r < sampleRate
+}
+
 // http.Handler implementation
 func (p *Proxy) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	lw := logging.NewLoggingWriter(w)
@@ -1411,7 +1543,7 @@ func (p *Proxy) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 
 		statusCode := lw.GetCode()
 
-		if shouldLog(statusCode, accessLogEnabled) {
+		if shouldLog(statusCode, accessLogEnabled) && p.sampleAccessLog(ctx) {
 			authUser, _ := ctx.stateBag[filterslog.AuthUserKey].(string)
 			entry := &logging.AccessEntry{
 				Request:      r,
@@ -1442,6 +1574,7 @@ func (p *Proxy) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		setTag(span, HTTPRemoteIPTag, stripPort(r.RemoteAddr))
 	p.setCommonSpanInfo(r.URL, r, span)
 	r = r.WithContext(ot.ContextWithSpan(r.Context(), span))
+	r = snet.ServeConnRequest(r)
 	r = r.WithContext(routing.NewContext(r.Context()))
 
 	ctx = newContext(lw, r, p)
@@ -1478,6 +1611,13 @@ func (p *Proxy) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+// RoundTripper returns the round tripper used to send the requests to the
+// backends, to let the filters send requests with the same transport
+// settings.
+func (p *Proxy) RoundTripper() http.RoundTripper {
+	return p.roundTripper
+}
+
 // Close causes the proxy to stop closing idle
 // connections and, currently, has no other effect.
 // It's primary purpose is to support testing.
@@ -1586,6 +1726,15 @@ type errorHandlerFilter interface {
 	HandleErrorResponse() bool
 }
 
+// loopbackRequested tells whether a filter requested processing the request
+// as with a loopback backend. The flag is cleared, so that it applies only to
+// the current route.
+func loopbackRequested(ctx *context) bool {
+	loopback, _ := ctx.stateBag[filters.LoopbackKey].(bool)
+	delete(ctx.stateBag, filters.LoopbackKey)
+	return loopback
+}
+
 func (p *Proxy) applyFiltersOnError(ctx *context, filters []*routing.RouteFilter) {
 	filtersStart := time.Now()
 	filterTracing := p.tracing.startFilterTracing("response_filters", ctx)
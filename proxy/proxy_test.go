@@ -34,6 +34,7 @@ import (
 	"github.com/zalando/skipper/routing/testdataclient"
 
 	teePredicate "github.com/zalando/skipper/predicates/tee"
+	"github.com/zalando/skipper/predicates/traffic"
 )
 
 const (
@@ -150,7 +151,7 @@ func newTestProxyWithFiltersAndParams(fr filters.Registry, doc string, params Pa
 		DataClients:    []routing.DataClient{dc},
 		PostProcessors: []routing.PostProcessor{loadbalancer.NewAlgorithmProvider()},
 		Log:            tl,
-		Predicates:     []routing.PredicateSpec{teePredicate.New()},
+		Predicates:     []routing.PredicateSpec{teePredicate.New(), traffic.NewSegment()},
 	}
 	if len(preprocs) > 0 {
 		opts.PreProcessors = preprocs
@@ -1990,6 +1991,64 @@ func TestEnableAccessLogWithFilter(t *testing.T) {
 	}
 }
 
+func TestAccessLogCohortSampleRates(t *testing.T) {
+	const doc = `
+		canary: Path("/hello") && TrafficSegment(0, 0.2, "canary") -> status(201) -> <shunt>;
+		test: Path("/hello") && TrafficSegment(0.2, 0.4, "test") -> status(202) -> <shunt>;
+		stable: Path("/hello") && TrafficSegment(0.4, 1) -> status(200) -> <shunt>;
+	`
+
+	var buf bytes.Buffer
+	logging.Init(logging.Options{AccessLogOutput: &buf})
+
+	tp, err := newTestProxyWithParams(doc, Params{
+		AccessLogCohortSampleRates: map[string]float64{
+			"canary":               1,
+			"test":                 0.5,
+			DefaultAccessLogCohort: 0,
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer tp.close()
+
+	for i := 0; i < 100; i++ {
+		buf.Reset()
+
+		r, err := http.NewRequest("GET", "https://www.example.org/hello", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		// the random value is memoized in the routing context of the request
+		r = r.WithContext(routing.NewContext(r.Context()))
+		route, _ := tp.routing.Route(r)
+		segment, ok := route.TrafficSegment(r)
+		if !ok {
+			t.Fatal("route without traffic segment", route.Id)
+		}
+
+		rnd := segment.Random
+
+		w := httptest.NewRecorder()
+		tp.proxy.ServeHTTP(w, r)
+
+		var shouldLog bool
+		switch {
+		case rnd < 0.2:
+			shouldLog = true
+		case rnd < 0.4:
+			shouldLog = (rnd-0.2)/0.2 < 0.5
+		}
+
+		if logged := buf.Len() > 0; logged != shouldLog {
+			t.Errorf("random value: %v, status: %d, expected logged: %v, got: %v", rnd, w.Code, shouldLog, logged)
+		}
+	}
+}
+
 func TestAccessLogOnFailedRequest(t *testing.T) {
 	buf := NewLockedBuffer()
 	logging.Init(logging.Options{
@@ -0,0 +1,150 @@
+package proxy
+
+import (
+	stdlibcontext "context"
+	"encoding/binary"
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"strconv"
+)
+
+const (
+	proxyProtocolV1 = "v1"
+	proxyProtocolV2 = "v2"
+)
+
+type proxyProtocolContextKey struct{}
+
+var proxyProtocolV2Signature = []byte("\r\n\r\n\x00\r\nQUIT\n")
+
+// proxyProtocolHeader creates the PROXY protocol header of the given
+// version, carrying the address of the client and the address that the
+// client connected to. When the addresses are not known, or they are not
+// of the same family, the header tells the backend that the addresses are
+// unknown.
+func proxyProtocolHeader(version string, src, dst netip.AddrPort) ([]byte, error) {
+	valid := src.IsValid() && dst.IsValid() && src.Addr().Is4() == dst.Addr().Is4()
+	switch version {
+	case proxyProtocolV1:
+		if !valid {
+			return []byte("PROXY UNKNOWN\r\n"), nil
+		}
+
+		family := "TCP4"
+		if !src.Addr().Is4() {
+			family = "TCP6"
+		}
+
+		return []byte(fmt.Sprintf(
+			"PROXY %s %s %s %d %d\r\n",
+			family,
+			src.Addr(),
+			dst.Addr(),
+			src.Port(),
+			dst.Port(),
+		)), nil
+	case proxyProtocolV2:
+		h := append([]byte(nil), proxyProtocolV2Signature...)
+
+		// version 2, PROXY command
+		h = append(h, 0x21)
+		if !valid {
+			// unspecified family, no addresses
+			return append(h, 0x00, 0x00, 0x00), nil
+		}
+
+		var family byte
+		var addrs []byte
+		if src.Addr().Is4() {
+			// TCP over IPv4
+			family = 0x11
+			s, d := src.Addr().As4(), dst.Addr().As4()
+			addrs = append(s[:], d[:]...)
+		} else {
+			// TCP over IPv6
+			family = 0x21
+			s, d := src.Addr().As16(), dst.Addr().As16()
+			addrs = append(s[:], d[:]...)
+		}
+
+		addrs = binary.BigEndian.AppendUint16(addrs, src.Port())
+		addrs = binary.BigEndian.AppendUint16(addrs, dst.Port())
+
+		h = append(h, family)
+		h = binary.BigEndian.AppendUint16(h, uint16(len(addrs)))
+		return append(h, addrs...), nil
+	default:
+		return nil, fmt.Errorf("unsupported PROXY protocol version: %s", version)
+	}
+}
+
+func parseAddrPort(s string) netip.AddrPort {
+	host, port, err := net.SplitHostPort(s)
+	if err != nil {
+		return netip.AddrPort{}
+	}
+
+	addr, err := netip.ParseAddr(host)
+	if err != nil {
+		return netip.AddrPort{}
+	}
+
+	p, err := strconv.ParseUint(port, 10, 16)
+	if err != nil {
+		return netip.AddrPort{}
+	}
+
+	return netip.AddrPortFrom(addr.Unmap(), uint16(p))
+}
+
+// withProxyProtocol stores the PROXY protocol header in the context of the
+// outgoing request, based on the incoming request.
+func withProxyProtocol(version string, incoming, outgoing *http.Request) (*http.Request, error) {
+	src := parseAddrPort(incoming.RemoteAddr)
+
+	var dst netip.AddrPort
+	if addr, ok := incoming.Context().Value(http.LocalAddrContextKey).(net.Addr); ok {
+		dst = parseAddrPort(addr.String())
+	}
+
+	h, err := proxyProtocolHeader(version, src, dst)
+	if err != nil {
+		return nil, err
+	}
+
+	return outgoing.WithContext(stdlibcontext.WithValue(outgoing.Context(), proxyProtocolContextKey{}, h)), nil
+}
+
+// newProxyProtocolTransport creates a transport that sends the PROXY
+// protocol header, when it is found in the request context, right after
+// the connection is established. The connections are tied to the incoming
+// connection of the client, therefore they are not reused.
+func newProxyProtocolTransport(tr *http.Transport) *http.Transport {
+	ptr := tr.Clone()
+	ptr.DisableKeepAlives = true
+
+	dial := tr.DialContext
+	ptr.DialContext = func(ctx stdlibcontext.Context, network, addr string) (net.Conn, error) {
+		conn, err := dial(ctx, network, addr)
+		if err != nil {
+			return nil, err
+		}
+
+		if h, ok := ctx.Value(proxyProtocolContextKey{}).([]byte); ok {
+			if _, err := conn.Write(h); err != nil {
+				conn.Close()
+				return nil, &proxyError{
+					err:           fmt.Errorf("failed to send PROXY protocol header: %w", err),
+					code:          -1,
+					dialingFailed: true,
+				}
+			}
+		}
+
+		return conn, nil
+	}
+
+	return ptr
+}
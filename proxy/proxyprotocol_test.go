@@ -0,0 +1,278 @@
+package proxy
+
+import (
+	"bufio"
+	"bytes"
+	stdlibcontext "context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"strings"
+	"testing"
+)
+
+type proxyProtocolConn struct {
+	net.Conn
+	reader *bufio.Reader
+	header string
+}
+
+type proxyProtocolListener struct {
+	net.Listener
+}
+
+type proxyProtocolConnKey struct{}
+
+func (c *proxyProtocolConn) Read(p []byte) (int, error) { return c.reader.Read(p) }
+
+// parses the PROXY protocol header and returns it in the v1 text format
+func parseProxyProtocolHeader(r *bufio.Reader) (string, error) {
+	sig, err := r.Peek(len(proxyProtocolV2Signature))
+	if err != nil {
+		return "", err
+	}
+
+	if !bytes.Equal(sig, proxyProtocolV2Signature) {
+		if !bytes.HasPrefix(sig, []byte("PROXY ")) {
+			return "", nil
+		}
+
+		line, err := r.ReadString('\n')
+		if err != nil || !strings.HasPrefix(line, "PROXY ") || !strings.HasSuffix(line, "\r\n") {
+			return "", fmt.Errorf("invalid v1 header: %q", line)
+		}
+
+		return strings.TrimSuffix(line, "\r\n"), nil
+	}
+
+	h := make([]byte, 16)
+	if _, err := io.ReadFull(r, h); err != nil {
+		return "", err
+	}
+
+	if h[12] != 0x21 {
+		return "", errors.New("invalid v2 version or command")
+	}
+
+	addrs := make([]byte, binary.BigEndian.Uint16(h[14:]))
+	if _, err := io.ReadFull(r, addrs); err != nil {
+		return "", err
+	}
+
+	var src, dst netip.Addr
+	switch h[13] {
+	case 0x00:
+		return "PROXY UNKNOWN", nil
+	case 0x11:
+		src, _ = netip.AddrFromSlice(addrs[0:4])
+		dst, _ = netip.AddrFromSlice(addrs[4:8])
+		addrs = addrs[8:]
+	case 0x21:
+		src, _ = netip.AddrFromSlice(addrs[0:16])
+		dst, _ = netip.AddrFromSlice(addrs[16:32])
+		addrs = addrs[32:]
+	default:
+		return "", errors.New("invalid v2 family")
+	}
+
+	family := "TCP4"
+	if h[13] == 0x21 {
+		family = "TCP6"
+	}
+
+	return fmt.Sprintf(
+		"PROXY %s %s %s %d %d",
+		family,
+		src,
+		dst,
+		binary.BigEndian.Uint16(addrs[0:]),
+		binary.BigEndian.Uint16(addrs[2:]),
+	), nil
+}
+
+func (l proxyProtocolListener) Accept() (net.Conn, error) {
+	c, err := l.Listener.Accept()
+	if err != nil {
+		return nil, err
+	}
+
+	r := bufio.NewReader(c)
+	h, err := parseProxyProtocolHeader(r)
+	if err != nil {
+		h = "invalid: " + err.Error()
+	}
+
+	return &proxyProtocolConn{Conn: c, reader: r, header: h}, nil
+}
+
+func TestProxyProtocolHeader(t *testing.T) {
+	src4 := netip.MustParseAddrPort("192.0.2.1:56324")
+	dst4 := netip.MustParseAddrPort("198.51.100.1:443")
+	src6 := netip.MustParseAddrPort("[2001:db8::1]:56324")
+	dst6 := netip.MustParseAddrPort("[2001:db8::2]:443")
+
+	for _, tt := range []struct {
+		msg      string
+		version  string
+		src, dst netip.AddrPort
+		expected string
+		err      bool
+	}{{
+		msg:      "v1 ipv4",
+		version:  "v1",
+		src:      src4,
+		dst:      dst4,
+		expected: "PROXY TCP4 192.0.2.1 198.51.100.1 56324 443",
+	}, {
+		msg:      "v1 ipv6",
+		version:  "v1",
+		src:      src6,
+		dst:      dst6,
+		expected: "PROXY TCP6 2001:db8::1 2001:db8::2 56324 443",
+	}, {
+		msg:      "v1 unknown destination",
+		version:  "v1",
+		src:      src4,
+		expected: "PROXY UNKNOWN",
+	}, {
+		msg:      "v1 mixed families",
+		version:  "v1",
+		src:      src4,
+		dst:      dst6,
+		expected: "PROXY UNKNOWN",
+	}, {
+		msg:      "v2 ipv4",
+		version:  "v2",
+		src:      src4,
+		dst:      dst4,
+		expected: "PROXY TCP4 192.0.2.1 198.51.100.1 56324 443",
+	}, {
+		msg:      "v2 ipv6",
+		version:  "v2",
+		src:      src6,
+		dst:      dst6,
+		expected: "PROXY TCP6 2001:db8::1 2001:db8::2 56324 443",
+	}, {
+		msg:      "v2 unknown",
+		version:  "v2",
+		expected: "PROXY UNKNOWN",
+	}, {
+		msg:     "unsupported version",
+		version: "v3",
+		err:     true,
+	}} {
+		t.Run(tt.msg, func(t *testing.T) {
+			h, err := proxyProtocolHeader(tt.version, tt.src, tt.dst)
+			if tt.err {
+				if err == nil {
+					t.Error("failed to fail")
+				}
+
+				return
+			}
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			parsed, err := parseProxyProtocolHeader(bufio.NewReader(bytes.NewReader(h)))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if parsed != tt.expected {
+				t.Errorf("expected header: %q, got: %q", tt.expected, parsed)
+			}
+		})
+	}
+}
+
+func TestSendProxyProtocol(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	backend := &httptest.Server{
+		Listener: proxyProtocolListener{l},
+		Config: &http.Server{
+			Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				h, _ := r.Context().Value(proxyProtocolConnKey{}).(string)
+				w.Header().Set("X-Proxy-Protocol", h)
+			}),
+			ConnContext: func(ctx stdlibcontext.Context, c net.Conn) stdlibcontext.Context {
+				if pc, ok := c.(*proxyProtocolConn); ok {
+					return stdlibcontext.WithValue(ctx, proxyProtocolConnKey{}, pc.header)
+				}
+
+				return ctx
+			},
+		},
+	}
+
+	backend.Start()
+	defer backend.Close()
+
+	for _, version := range []string{"v1", "v2"} {
+		t.Run(version, func(t *testing.T) {
+			doc := fmt.Sprintf(`
+				withHeader: Path("/proxy-protocol") -> sendProxyProtocol("%s") -> "%s";
+				withoutHeader: Path("/plain") -> "%s";
+			`, version, backend.URL, backend.URL)
+
+			tp, err := newTestProxy(doc, FlagsNone)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			defer tp.close()
+
+			ps := httptest.NewServer(tp.proxy)
+			defer ps.Close()
+
+			var clientAddr string
+			client := &http.Client{Transport: &http.Transport{
+				DialContext: func(ctx stdlibcontext.Context, network, addr string) (net.Conn, error) {
+					var d net.Dialer
+					c, err := d.DialContext(ctx, network, addr)
+					if err == nil {
+						clientAddr = c.LocalAddr().String()
+					}
+
+					return c, err
+				},
+			}}
+
+			defer client.CloseIdleConnections()
+
+			rsp, err := client.Get(ps.URL + "/proxy-protocol")
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			rsp.Body.Close()
+
+			src := netip.MustParseAddrPort(clientAddr)
+			dst := netip.MustParseAddrPort(ps.Listener.Addr().String())
+			expected := fmt.Sprintf("PROXY TCP4 %s %s %d %d", src.Addr(), dst.Addr(), src.Port(), dst.Port())
+			if h := rsp.Header.Get("X-Proxy-Protocol"); h != expected {
+				t.Errorf("expected header: %q, got: %q", expected, h)
+			}
+
+			rsp, err = client.Get(ps.URL + "/plain")
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			rsp.Body.Close()
+			if h := rsp.Header.Get("X-Proxy-Protocol"); h != "" {
+				t.Errorf("unexpected PROXY protocol header: %q", h)
+			}
+		})
+	}
+}
@@ -0,0 +1,64 @@
+package proxy
+
+import (
+	"io"
+	"sync"
+	"sync/atomic"
+)
+
+// connectionPools tracks the number of in-flight backend requests per
+// host, to tell when the connection pool of a host, limited by
+// Params.MaxConnsPerHost, is saturated, and new requests would wait for
+// a free connection.
+type connectionPools struct {
+	maxConnsPerHost int64
+	inflight        sync.Map // host -> *int64
+}
+
+func newConnectionPools(maxConnsPerHost int) *connectionPools {
+	return &connectionPools{maxConnsPerHost: int64(maxConnsPerHost)}
+}
+
+func (c *connectionPools) counter(host string) *int64 {
+	if n, ok := c.inflight.Load(host); ok {
+		return n.(*int64)
+	}
+
+	n, _ := c.inflight.LoadOrStore(host, new(int64))
+	return n.(*int64)
+}
+
+// acquire registers an in-flight request to the host and returns the
+// function to call when the request is done. It returns nil, when the
+// connections are not limited.
+func (c *connectionPools) acquire(host string) func() {
+	if c.maxConnsPerHost <= 0 {
+		return nil
+	}
+
+	n := c.counter(host)
+	atomic.AddInt64(n, 1)
+	return func() { atomic.AddInt64(n, -1) }
+}
+
+// saturated tells whether all the connections to the host are in use
+func (c *connectionPools) saturated(host string) bool {
+	if c.maxConnsPerHost <= 0 {
+		return false
+	}
+
+	return atomic.LoadInt64(c.counter(host)) >= c.maxConnsPerHost
+}
+
+// releaseBody releases the in-flight request of a connection pool, when
+// the response body is closed
+type releaseBody struct {
+	io.ReadCloser
+	once    sync.Once
+	release func()
+}
+
+func (b *releaseBody) Close() error {
+	b.once.Do(b.release)
+	return b.ReadCloser.Close()
+}
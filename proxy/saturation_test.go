@@ -0,0 +1,92 @@
+package proxy
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/zalando/skipper/metrics"
+	"github.com/zalando/skipper/metrics/metricstest"
+)
+
+func TestFailFastSaturatedCohorts(t *testing.T) {
+	dm := metrics.Default
+	t.Cleanup(func() { metrics.Default = dm })
+
+	m := &metricstest.MockMetrics{}
+	metrics.Default = m
+
+	release := make(chan struct{})
+	received := make(chan struct{}, 1)
+	backend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/block" {
+			received <- struct{}{}
+			<-release
+		}
+	}))
+	defer backend.Close()
+
+	tp, err := newTestProxyWithParams(fmt.Sprintf(`
+		block: Path("/block") -> "%[1]s";
+		canary: Path("/canary") && TrafficSegment(0, 1, "canary") -> "%[1]s";
+		stable: Path("/stable") && TrafficSegment(0, 1) -> "%[1]s";
+	`, backend.URL), Params{
+		MaxConnsPerHost: 1,
+		FailFastCohorts: []string{"canary"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer tp.close()
+
+	ps := httptest.NewServer(tp.proxy)
+	defer ps.Close()
+
+	get := func(path string) int {
+		rsp, err := http.Get(ps.URL + path)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		rsp.Body.Close()
+		return rsp.StatusCode
+	}
+
+	if s := get("/canary"); s != http.StatusOK {
+		t.Fatalf("expected status %d before saturation, got: %d", http.StatusOK, s)
+	}
+
+	// saturate the pool of the backend host
+	done := make(chan int)
+	go func() { done <- get("/block") }()
+	<-received
+
+	if s := get("/canary"); s != http.StatusServiceUnavailable {
+		t.Errorf("expected status %d for the canary, got: %d", http.StatusServiceUnavailable, s)
+	}
+
+	// the stable requests wait for the free connection
+	stable := make(chan int)
+	go func() { stable <- get("/stable") }()
+
+	close(release)
+	if s := <-done; s != http.StatusOK {
+		t.Errorf("expected status %d for the blocking request, got: %d", http.StatusOK, s)
+	}
+
+	if s := <-stable; s != http.StatusOK {
+		t.Errorf("expected status %d for stable, got: %d", http.StatusOK, s)
+	}
+
+	if s := get("/canary"); s != http.StatusOK {
+		t.Errorf("expected status %d after saturation, got: %d", http.StatusOK, s)
+	}
+
+	m.WithCounters(func(counters map[string]int64) {
+		if n := counters[FailFastSaturatedMetricKey]; n != 1 {
+			t.Errorf("expected 1 fast fail, got: %d", n)
+		}
+	})
+}
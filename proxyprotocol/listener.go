@@ -0,0 +1,290 @@
+/*
+Package proxyprotocol implements a listener that accepts the PROXY protocol
+header, version 1 or 2, sent by an L4 load balancer at the beginning of the
+connections, and exposes the address of the original client as the remote
+address of the connections.
+
+See: https://www.haproxy.org/download/2.8/doc/proxy-protocol.txt
+
+Every accepted connection must start with a valid PROXY protocol header,
+otherwise the connection is closed. The header is read on the first use of
+the connection, and not during Accept, so that slow clients don't block
+accepting other connections.
+*/
+package proxyprotocol
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/netip"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// DefaultHeaderTimeout is used when the HeaderTimeout is not set.
+	DefaultHeaderTimeout = 10 * time.Second
+
+	// the maximum length of a v1 header, including CRLF
+	maxV1HeaderLength = 107
+)
+
+var (
+	v2Signature = []byte("\r\n\r\n\x00\r\nQUIT\n")
+
+	// ErrInvalidHeader is returned when reading from a connection that
+	// started with a missing or malformed PROXY protocol header.
+	ErrInvalidHeader = errors.New("invalid PROXY protocol header")
+)
+
+// Options are used to initialize the PROXY protocol listener.
+type Options struct {
+
+	// HeaderTimeout sets the time limit for receiving the PROXY protocol
+	// header. Defaults to DefaultHeaderTimeout.
+	HeaderTimeout time.Duration
+}
+
+type listener struct {
+	net.Listener
+	options Options
+}
+
+type conn struct {
+	net.Conn
+	options    Options
+	reader     *bufio.Reader
+	once       sync.Once
+	err        error
+	remoteAddr net.Addr
+	localAddr  net.Addr
+}
+
+// NewListener wraps a listener, typically a TCP listener, and returns a
+// listener whose connections accept the PROXY protocol header.
+func NewListener(l net.Listener, o Options) net.Listener {
+	if o.HeaderTimeout <= 0 {
+		o.HeaderTimeout = DefaultHeaderTimeout
+	}
+
+	return &listener{Listener: l, options: o}
+}
+
+func (l *listener) Accept() (net.Conn, error) {
+	c, err := l.Listener.Accept()
+	if err != nil {
+		return nil, err
+	}
+
+	return &conn{
+		Conn:       c,
+		options:    l.options,
+		reader:     bufio.NewReaderSize(c, maxV1HeaderLength),
+		remoteAddr: c.RemoteAddr(),
+		localAddr:  c.LocalAddr(),
+	}, nil
+}
+
+func (c *conn) readHeader() {
+	c.once.Do(func() {
+		if err := c.SetReadDeadline(time.Now().Add(c.options.HeaderTimeout)); err != nil {
+			c.err = err
+			return
+		}
+
+		src, dst, err := readHeader(c.reader)
+		if err != nil {
+			c.err = fmt.Errorf("%w: %v", ErrInvalidHeader, err)
+			c.Conn.Close()
+			return
+		}
+
+		if src.IsValid() && dst.IsValid() {
+			c.remoteAddr = net.TCPAddrFromAddrPort(src)
+			c.localAddr = net.TCPAddrFromAddrPort(dst)
+		}
+
+		c.err = c.SetReadDeadline(time.Time{})
+	})
+}
+
+// Read reads the PROXY protocol header on the first call, and the data
+// following it.
+func (c *conn) Read(b []byte) (int, error) {
+	c.readHeader()
+	if c.err != nil {
+		return 0, c.err
+	}
+
+	return c.reader.Read(b)
+}
+
+// RemoteAddr returns the address of the client, as received in the PROXY
+// protocol header. When the header carries no address, e.g. in case of
+// health checks, it returns the address of the peer.
+func (c *conn) RemoteAddr() net.Addr {
+	c.readHeader()
+	return c.remoteAddr
+}
+
+// LocalAddr returns the address that the client connected to, as received
+// in the PROXY protocol header. When the header carries no address, it
+// returns the local address of the connection.
+func (c *conn) LocalAddr() net.Addr {
+	c.readHeader()
+	return c.localAddr
+}
+
+// readHeader reads the PROXY protocol header, and returns the source and the
+// destination address. The addresses are not valid, when the header doesn't
+// carry them.
+func readHeader(r *bufio.Reader) (src, dst netip.AddrPort, err error) {
+	prefix, err := r.Peek(len(v2Signature))
+	if err != nil {
+		return
+	}
+
+	switch {
+	case bytes.Equal(prefix, v2Signature):
+		return readV2(r)
+	case bytes.HasPrefix(prefix, []byte("PROXY ")):
+		return readV1(r)
+	default:
+		err = errors.New("missing header")
+		return
+	}
+}
+
+func readV1(r *bufio.Reader) (src, dst netip.AddrPort, err error) {
+	line, err := r.ReadSlice('\n')
+	if err == bufio.ErrBufferFull {
+		err = errors.New("v1 header too long")
+		return
+	} else if err != nil {
+		return
+	}
+
+	if !bytes.HasSuffix(line, []byte("\r\n")) {
+		err = errors.New("v1 header not terminated with CRLF")
+		return
+	}
+
+	fields := strings.Split(string(line[:len(line)-2]), " ")
+	if len(fields) >= 2 && fields[1] == "UNKNOWN" {
+		return
+	}
+
+	if len(fields) != 6 {
+		err = fmt.Errorf("invalid v1 header: %q", line)
+		return
+	}
+
+	var is4 bool
+	switch fields[1] {
+	case "TCP4":
+		is4 = true
+	case "TCP6":
+	default:
+		err = fmt.Errorf("invalid v1 protocol: %s", fields[1])
+		return
+	}
+
+	if src, err = parseV1Address(fields[2], fields[4], is4); err != nil {
+		return
+	}
+
+	dst, err = parseV1Address(fields[3], fields[5], is4)
+	return
+}
+
+func parseV1Address(addr, port string, is4 bool) (netip.AddrPort, error) {
+	a, err := netip.ParseAddr(addr)
+	if err != nil {
+		return netip.AddrPort{}, err
+	}
+
+	if a.Is4() != is4 {
+		return netip.AddrPort{}, fmt.Errorf("invalid v1 address family: %s", addr)
+	}
+
+	// leading zeros are not allowed
+	if len(port) > 1 && port[0] == '0' {
+		return netip.AddrPort{}, fmt.Errorf("invalid v1 port: %s", port)
+	}
+
+	p, err := strconv.ParseUint(port, 10, 16)
+	if err != nil {
+		return netip.AddrPort{}, fmt.Errorf("invalid v1 port: %s", port)
+	}
+
+	return netip.AddrPortFrom(a, uint16(p)), nil
+}
+
+func readV2(r *bufio.Reader) (src, dst netip.AddrPort, err error) {
+	var h [16]byte
+	if _, err = io.ReadFull(r, h[:]); err != nil {
+		return
+	}
+
+	if h[12]>>4 != 2 {
+		err = fmt.Errorf("invalid v2 version: %d", h[12]>>4)
+		return
+	}
+
+	command, family := h[12]&0x0f, h[13]>>4
+	body := make([]byte, binary.BigEndian.Uint16(h[14:]))
+	if _, err = io.ReadFull(r, body); err != nil {
+		return
+	}
+
+	switch command {
+	case 0:
+		// LOCAL, e.g. health checks of the load balancer
+		return
+	case 1:
+		// PROXY
+	default:
+		err = fmt.Errorf("invalid v2 command: %d", command)
+		return
+	}
+
+	switch family {
+	case 1:
+		// IPv4
+		if len(body) < 12 {
+			err = errors.New("v2 IPv4 addresses too short")
+			return
+		}
+
+		src, dst = v2Addresses(body, 4)
+	case 2:
+		// IPv6
+		if len(body) < 36 {
+			err = errors.New("v2 IPv6 addresses too short")
+			return
+		}
+
+		src, dst = v2Addresses(body, 16)
+	default:
+		// unspecified or unix, the addresses are ignored
+	}
+
+	return
+}
+
+// v2Addresses returns the source and the destination address from the
+// address block, where the address length is 4 or 16 bytes.
+func v2Addresses(body []byte, n int) (src, dst netip.AddrPort) {
+	s, _ := netip.AddrFromSlice(body[:n])
+	d, _ := netip.AddrFromSlice(body[n : 2*n])
+	sp := binary.BigEndian.Uint16(body[2*n:])
+	dp := binary.BigEndian.Uint16(body[2*n+2:])
+	return netip.AddrPortFrom(s, sp), netip.AddrPortFrom(d, dp)
+}
@@ -0,0 +1,229 @@
+package proxyprotocol
+
+import (
+	"bufio"
+	"encoding/binary"
+	"io"
+	"net"
+	"net/http"
+	"net/netip"
+	"testing"
+	"time"
+)
+
+func v2Header(command, family byte, addrs []byte) []byte {
+	h := append([]byte(nil), v2Signature...)
+	h = append(h, 0x20|command, family)
+	h = binary.BigEndian.AppendUint16(h, uint16(len(addrs)))
+	return append(h, addrs...)
+}
+
+func v2IPv4Addresses() []byte {
+	addrs := []byte{192, 0, 2, 1, 198, 51, 100, 1}
+	addrs = binary.BigEndian.AppendUint16(addrs, 12345)
+	return binary.BigEndian.AppendUint16(addrs, 443)
+}
+
+func v2IPv6Addresses() []byte {
+	src := netip.MustParseAddr("2001:db8::1").As16()
+	dst := netip.MustParseAddr("2001:db8::2").As16()
+	addrs := append(src[:], dst[:]...)
+	addrs = binary.BigEndian.AppendUint16(addrs, 12345)
+	return binary.BigEndian.AppendUint16(addrs, 443)
+}
+
+func startServer(t *testing.T) net.Addr {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	srv := &http.Server{
+		Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("X-Remote-Addr", r.RemoteAddr)
+			if addr, ok := r.Context().Value(http.LocalAddrContextKey).(net.Addr); ok {
+				w.Header().Set("X-Local-Addr", addr.String())
+			}
+		}),
+	}
+
+	go srv.Serve(NewListener(l, Options{HeaderTimeout: time.Second}))
+	t.Cleanup(func() { srv.Close() })
+	return l.Addr()
+}
+
+// request sends the header followed by an HTTP request on a new connection,
+// and returns the response, or nil when the connection was closed.
+func request(t *testing.T, addr net.Addr, header []byte) *http.Response {
+	c, err := net.Dial("tcp", addr.String())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer c.Close()
+	if err := c.SetDeadline(time.Now().Add(3 * time.Second)); err != nil {
+		t.Fatal(err)
+	}
+
+	req := append(header, []byte("GET / HTTP/1.1\r\nHost: www.example.org\r\nConnection: close\r\n\r\n")...)
+	if _, err := c.Write(req); err != nil {
+		t.Fatal(err)
+	}
+
+	rsp, err := http.ReadResponse(bufio.NewReader(c), nil)
+	if err == io.EOF || err == io.ErrUnexpectedEOF {
+		return nil
+	} else if err != nil {
+		if nerr, ok := err.(net.Error); ok && !nerr.Timeout() {
+			return nil
+		}
+
+		t.Fatal(err)
+	}
+
+	rsp.Body.Close()
+	return rsp
+}
+
+func TestListener(t *testing.T) {
+	addr := startServer(t)
+	for _, tt := range []struct {
+		msg    string
+		header []byte
+		remote string
+		local  string
+	}{{
+		msg:    "v1 TCP4",
+		header: []byte("PROXY TCP4 192.0.2.1 198.51.100.1 12345 443\r\n"),
+		remote: "192.0.2.1:12345",
+		local:  "198.51.100.1:443",
+	}, {
+		msg:    "v1 TCP6",
+		header: []byte("PROXY TCP6 2001:db8::1 2001:db8::2 12345 443\r\n"),
+		remote: "[2001:db8::1]:12345",
+		local:  "[2001:db8::2]:443",
+	}, {
+		msg:    "v1 UNKNOWN",
+		header: []byte("PROXY UNKNOWN\r\n"),
+	}, {
+		msg:    "v2 IPv4",
+		header: v2Header(1, 0x11, v2IPv4Addresses()),
+		remote: "192.0.2.1:12345",
+		local:  "198.51.100.1:443",
+	}, {
+		msg:    "v2 IPv6",
+		header: v2Header(1, 0x21, v2IPv6Addresses()),
+		remote: "[2001:db8::1]:12345",
+		local:  "[2001:db8::2]:443",
+	}, {
+		msg:    "v2 IPv4 with TLVs",
+		header: v2Header(1, 0x11, append(v2IPv4Addresses(), 0x04, 0x00, 0x01, 0x00)),
+		remote: "192.0.2.1:12345",
+		local:  "198.51.100.1:443",
+	}, {
+		msg:    "v2 LOCAL",
+		header: v2Header(0, 0x00, nil),
+	}, {
+		msg:    "v2 unspecified",
+		header: v2Header(1, 0x00, nil),
+	}} {
+		t.Run(tt.msg, func(t *testing.T) {
+			rsp := request(t, addr, tt.header)
+			if rsp == nil {
+				t.Fatal("connection closed")
+			}
+
+			if rsp.StatusCode != http.StatusOK {
+				t.Fatalf("unexpected status: %d", rsp.StatusCode)
+			}
+
+			remote, local := tt.remote, tt.local
+			if remote == "" {
+				// the addresses of the connection are kept
+				if h, _, _ := net.SplitHostPort(rsp.Header.Get("X-Remote-Addr")); h != "127.0.0.1" {
+					t.Errorf("unexpected remote address: %s", rsp.Header.Get("X-Remote-Addr"))
+				}
+
+				remote, local = rsp.Header.Get("X-Remote-Addr"), addr.String()
+			}
+
+			if got := rsp.Header.Get("X-Remote-Addr"); got != remote {
+				t.Errorf("expected remote address %s, got: %s", remote, got)
+			}
+
+			if got := rsp.Header.Get("X-Local-Addr"); got != local {
+				t.Errorf("expected local address %s, got: %s", local, got)
+			}
+		})
+	}
+}
+
+func TestListenerRejectsInvalidHeaders(t *testing.T) {
+	addr := startServer(t)
+	for _, tt := range []struct {
+		msg    string
+		header []byte
+	}{{
+		msg: "missing header",
+	}, {
+		msg:    "v1 invalid protocol",
+		header: []byte("PROXY UDP4 192.0.2.1 198.51.100.1 12345 443\r\n"),
+	}, {
+		msg:    "v1 missing fields",
+		header: []byte("PROXY TCP4 192.0.2.1 198.51.100.1 12345\r\n"),
+	}, {
+		msg:    "v1 mixed address families",
+		header: []byte("PROXY TCP4 192.0.2.1 2001:db8::2 12345 443\r\n"),
+	}, {
+		msg:    "v1 invalid address",
+		header: []byte("PROXY TCP4 192.0.2 198.51.100.1 12345 443\r\n"),
+	}, {
+		msg:    "v1 invalid port",
+		header: []byte("PROXY TCP4 192.0.2.1 198.51.100.1 123456 443\r\n"),
+	}, {
+		msg:    "v1 port with leading zero",
+		header: []byte("PROXY TCP4 192.0.2.1 198.51.100.1 012345 443\r\n"),
+	}, {
+		msg:    "v1 missing CR",
+		header: []byte("PROXY TCP4 192.0.2.1 198.51.100.1 12345 443\n"),
+	}, {
+		msg:    "v1 too long",
+		header: append([]byte("PROXY TCP4 "), make([]byte, 128)...),
+	}, {
+		msg:    "v2 invalid version",
+		header: append(append([]byte(nil), v2Signature...), 0x11, 0x11, 0x00, 0x00),
+	}, {
+		msg:    "v2 invalid command",
+		header: v2Header(2, 0x11, v2IPv4Addresses()),
+	}, {
+		msg:    "v2 addresses too short",
+		header: v2Header(1, 0x21, v2IPv4Addresses()),
+	}} {
+		t.Run(tt.msg, func(t *testing.T) {
+			if rsp := request(t, addr, tt.header); rsp != nil {
+				t.Errorf("expected closed connection, got status: %d", rsp.StatusCode)
+			}
+		})
+	}
+}
+
+func TestListenerHeaderTimeout(t *testing.T) {
+	addr := startServer(t)
+	c, err := net.Dial("tcp", addr.String())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer c.Close()
+	if err := c.SetReadDeadline(time.Now().Add(3 * time.Second)); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := c.Write([]byte("PROXY TCP4")); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := c.Read(make([]byte, 1)); err != io.EOF {
+		t.Errorf("expected closed connection, got: %v", err)
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"net"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/zalando/skipper/logging"
@@ -75,9 +76,38 @@ type Options struct {
 	// Log is used to log unexpected, non-fatal errors. It defaults to logging.DefaultLog.
 	Log logging.Logger
 
+	// QueueDepth, when set, is updated with the number of the pending connections in the
+	// queue. The same QueueDepth can be shared by multiple listeners, in which case it
+	// reports the sum of their queues.
+	QueueDepth *QueueDepth
+
 	testQueueChangeHook chan struct{}
 }
 
+// QueueDepth reports the current number of the pending connections in the queue of the
+// listeners using it. It is safe to read it concurrently, and reading it is cheap.
+type QueueDepth struct {
+	value atomic.Int64
+}
+
+// Value returns the current number of the pending connections. It returns 0 when the
+// QueueDepth is nil.
+func (d *QueueDepth) Value() int {
+	if d == nil {
+		return 0
+	}
+
+	return int(d.value.Load())
+}
+
+func (d *QueueDepth) add(delta int) {
+	if d == nil || delta == 0 {
+		return
+	}
+
+	d.value.Add(int64(delta))
+}
+
 type listener struct {
 	options           Options
 	maxConcurrency    int
@@ -281,6 +311,7 @@ func (l *listener) listenInternal() {
 		acceptInternal chan<- *connection
 		internalError  chan<- error
 		nextTimeout    <-chan time.Time
+		reportedDepth  int
 	)
 
 	queue = newRing(l.maxQueueSize)
@@ -314,6 +345,9 @@ func (l *listener) listenInternal() {
 			l.options.Metrics.UpdateGauge(queuedConnectionsKey, float64(queue.size))
 		}
 
+		l.options.QueueDepth.add(queue.size - reportedDepth)
+		reportedDepth = queue.size
+
 		select {
 		case conn := <-l.acceptExternal:
 			cc := &connection{
@@ -358,6 +392,7 @@ func (l *listener) listenInternal() {
 			}
 		case <-l.quit:
 			queue.rangeOver(func(c net.Conn) { c.(*connection).external.Close() })
+			l.options.QueueDepth.add(-reportedDepth)
 
 			// Closing the real listener in a separate goroutine is based on inspecting the
 			// stdlib. It's fair to just log the errors.
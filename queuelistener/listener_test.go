@@ -1042,6 +1042,45 @@ func TestMonitoring(t *testing.T) {
 		})
 	})
 
+	t.Run("reports the queue depth of the listeners sharing it", func(t *testing.T) {
+		d := &QueueDepth{}
+		var listeners []net.Listener
+		for i := 0; i < 2; i++ {
+			l, err := listenWith(&testListener{}, Options{
+				QueueDepth:     d,
+				MaxConcurrency: 3,
+				MaxQueueSize:   3,
+			})
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			accepted := goAcceptN(t, l, 3)
+			defer closeAll(<-accepted)
+			listeners = append(listeners, l)
+		}
+
+		if err := waitFor(func() bool { return d.Value() == 6 }); err != nil {
+			t.Fatalf("failed to report the queue depth: %d", d.Value())
+		}
+
+		for _, l := range listeners {
+			l.Close()
+		}
+
+		if err := waitFor(func() bool { return d.Value() == 0 }); err != nil {
+			t.Fatalf("failed to reset the queue depth: %d", d.Value())
+		}
+	})
+
+	t.Run("nil queue depth reports zero", func(t *testing.T) {
+		var d *QueueDepth
+		if d.Value() != 0 {
+			t.Error("unexpected queue depth")
+		}
+	})
+
 	t.Run("multiple calls to close are tolerated", func(t *testing.T) {
 		l, err := Listen(Options{Network: "tcp", Address: ":0"})
 		if err != nil {
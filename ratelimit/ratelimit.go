@@ -25,6 +25,14 @@ const (
 	// long a client should wait before making a new request
 	RetryAfterHeader = "Retry-After"
 
+	// LimitHeader, RemainingHeader and ResetHeader are the names of the
+	// headers defined by the IETF draft "RateLimit header fields for HTTP",
+	// indicating the quota, the remaining quota in the current time window
+	// and the seconds until the quota resets
+	LimitHeader     = "RateLimit-Limit"
+	RemainingHeader = "RateLimit-Remaining"
+	ResetHeader     = "RateLimit-Reset"
+
 	// Deprecated, use filters.RatelimitName instead
 	ServiceRatelimitName = filters.RatelimitName
 
@@ -400,6 +408,35 @@ func (l *Ratelimit) Resize(s string, i int) {
 	l.impl.Resize(s, i)
 }
 
+// remainingLimiter is implemented by the limiters that count the calls per
+// key within the time window.
+type remainingLimiter interface {
+	Remaining(string) int
+}
+
+// Remaining returns the number of the calls left for s in the current time
+// window. When the limiter implementation doesn't count the calls per key,
+// e.g. in case of the client ratelimit, it returns a lower estimate: 0 when
+// the next call is not allowed, and 1 otherwise.
+func (l *Ratelimit) Remaining(s string) int {
+	if l == nil {
+		return 0
+	}
+
+	switch impl := l.impl.(type) {
+	case remainingLimiter:
+		return impl.Remaining(s)
+	case *circularbuffer.CircularBuffer:
+		return impl.Cap() - impl.Len()
+	}
+
+	if l.impl.RetryAfter(s) > 0 {
+		return 0
+	}
+
+	return 1
+}
+
 type voidRatelimit struct{}
 
 func (voidRatelimit) Allow(context.Context, string) bool { return true }
@@ -426,6 +463,7 @@ func (zeroRatelimit) Oldest(string) time.Time            { return time.Time{} }
 func (zeroRatelimit) RetryAfter(string) int              { return zeroRetry }
 func (zeroRatelimit) Delta(string) time.Duration         { return zeroDelta }
 func (zeroRatelimit) Resize(string, int)                 {}
+func (zeroRatelimit) Remaining(string) int               { return 0 }
 
 func newRatelimit(s Settings, sw Swarmer, redisRing *net.RedisRingClient) *Ratelimit {
 	var impl limiter
@@ -464,6 +502,16 @@ func Headers(maxHits int, timeWindow time.Duration, retryAfter int) http.Header
 	}
 }
 
+// RateLimitHeaders returns the headers defined by the IETF draft "RateLimit
+// header fields for HTTP".
+func RateLimitHeaders(limit, remaining, reset int) http.Header {
+	h := make(http.Header)
+	h.Set(LimitHeader, strconv.Itoa(limit))
+	h.Set(RemainingHeader, strconv.Itoa(remaining))
+	h.Set(ResetHeader, strconv.Itoa(reset))
+	return h
+}
+
 func getHashedKey(clearText string) string {
 	h := sha256.Sum256([]byte(clearText))
 	return hex.EncodeToString(h[:])
@@ -112,6 +112,38 @@ func TestLocalRatelimit(t *testing.T) {
 	})
 }
 
+func TestRemaining(t *testing.T) {
+	for _, tt := range []struct {
+		msg      string
+		settings Settings
+		want     []int
+	}{{
+		msg:      "service",
+		settings: Settings{Type: ServiceRatelimit, MaxHits: 3, TimeWindow: time.Minute},
+		want:     []int{2, 1, 0, 0},
+	}, {
+		msg:      "client estimate",
+		settings: Settings{Type: ClientRatelimit, MaxHits: 3, TimeWindow: time.Minute, CleanInterval: time.Minute},
+		want:     []int{1, 1, 0, 0},
+	}, {
+		msg:      "zero",
+		settings: Settings{Type: ServiceRatelimit, TimeWindow: time.Minute},
+		want:     []int{0},
+	}} {
+		t.Run(tt.msg, func(t *testing.T) {
+			rl := newRatelimit(tt.settings, nil, nil)
+			defer rl.Close()
+
+			for i, want := range tt.want {
+				rl.Allow(context.Background(), "foo")
+				if got := rl.Remaining("foo"); got != want {
+					t.Errorf("call %d: expected %d remaining, got: %d", i, want, got)
+				}
+			}
+		})
+	}
+}
+
 func TestDisableRatelimit(t *testing.T) {
 	s := Settings{
 		Type:          DisableRatelimit,
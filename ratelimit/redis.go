@@ -264,6 +264,28 @@ func (c *clusterLimitRedis) Oldest(clearText string) time.Time {
 	return t
 }
 
+// Remaining returns the number of the calls left for the key in the current
+// time window.
+//
+// Performance considerations:
+//
+// It will use ZCARD, relying on that Allow() removed the items older than
+// the time window.
+func (c *clusterLimitRedis) Remaining(clearText string) int {
+	key := c.prefixKey(getHashedKey(clearText))
+	count, err := c.ringClient.ZCard(context.Background(), key)
+	if err != nil {
+		log.Errorf("Failed to get from redis the number of the calls in the time window: %v", err)
+		return 0
+	}
+
+	if count >= c.maxHits {
+		return 0
+	}
+
+	return int(c.maxHits - count)
+}
+
 // Resize is noop to implement the limiter interface
 func (*clusterLimitRedis) Resize(string, int) {}
 
@@ -388,6 +388,30 @@ func Test_clusterLimitRedis_RetryAfter(t *testing.T) {
 	}
 }
 
+func Test_clusterLimitRedis_Remaining(t *testing.T) {
+	redisAddr, done := redistest.NewTestRedis(t)
+	defer done()
+
+	settings := Settings{
+		Type:       ClusterClientRatelimit,
+		Lookuper:   NewHeaderLookuper("X-Test"),
+		MaxHits:    3,
+		TimeWindow: 10 * time.Second,
+		Group:      "C",
+	}
+
+	ringClient := net.NewRedisRingClient(&net.RedisOptions{Addrs: []string{redisAddr}})
+	defer ringClient.Close()
+	c := newClusterRateLimiterRedis(settings, ringClient, settings.Group)
+
+	for _, want := range []int{2, 1, 0, 0} {
+		_ = c.Allow(context.Background(), "clientC")
+		if got := c.Remaining("clientC"); got != want {
+			t.Errorf("clusterLimitRedis.Remaining() = %v, want %v", got, want)
+		}
+	}
+}
+
 func TestFailOpenOnRedisError(t *testing.T) {
 	dm := metrics.Default
 	defer func() { metrics.Default = dm }()
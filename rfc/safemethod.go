@@ -0,0 +1,14 @@
+package rfc
+
+import "net/http"
+
+// IsSafeMethod tells whether the request method is safe, as defined by
+// RFC 7231, section 4.2.1: GET, HEAD, OPTIONS or TRACE.
+func IsSafeMethod(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodTrace:
+		return true
+	default:
+		return false
+	}
+}
@@ -0,0 +1,22 @@
+package rfc
+
+import "testing"
+
+func TestIsSafeMethod(t *testing.T) {
+	for method, safe := range map[string]bool{
+		"GET":     true,
+		"HEAD":    true,
+		"OPTIONS": true,
+		"TRACE":   true,
+		"POST":    false,
+		"PUT":     false,
+		"PATCH":   false,
+		"DELETE":  false,
+		"CONNECT": false,
+		"get":     false,
+	} {
+		if got := IsSafeMethod(method); got != safe {
+			t.Errorf("%s: expected %v, got %v", method, safe, got)
+		}
+	}
+}
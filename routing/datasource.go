@@ -21,7 +21,15 @@ const (
 	incomingUpdate
 )
 
-var errInvalidWeightParams = errors.New("invalid argument for the Weight predicate")
+var (
+	errInvalidWeightParams = errors.New("invalid argument for the Weight predicate")
+	errInvalidGlobalParams = errors.New("invalid argument for the Global predicate")
+	errInvalidRouteWeight  = errors.New("invalid argument for the RouteWeight predicate")
+	errMultipleRouteWeight = errors.New("multiple RouteWeight predicates")
+	errGlobalWithTreePath  = errors.New("the Global predicate cannot be combined with Path or PathSubtree")
+)
+
+const defaultPredicateMetricsMaxRoutes = 100
 
 func (it incomingType) String() string {
 	switch it {
@@ -163,6 +171,15 @@ func mergeDefs(defsByClient map[DataClient]routeDefs) []*eskip.Route {
 //
 // The active set of routes from last successful update are used until the
 // next successful update.
+//
+// The incoming updates are received also while the merged route
+// definitions are waiting to be consumed, so that the slow processing of
+// the routing table doesn't stall the data clients. Only the latest merged
+// route definitions are kept waiting, and they are overwritten by the
+// next update, because the stale ones would be replaced right after
+// processing anyway. Until every data client delivered its initial route
+// definitions, the updates are not coalesced, so that each initial load
+// results in its own routing table, as expected by the first load signal.
 func receiveRouteDefs(o Options, quit <-chan struct{}) <-chan []*eskip.Route {
 	in := make(chan *incomingData)
 	out := make(chan []*eskip.Route)
@@ -173,20 +190,40 @@ func receiveRouteDefs(o Options, quit <-chan struct{}) <-chan []*eskip.Route {
 	}
 
 	go func() {
+		var (
+			latest  []*eskip.Route
+			pending bool
+			dropped int
+		)
+
 		for {
-			var incoming *incomingData
-			select {
-			case incoming = <-in:
-			case <-quit:
-				return
+			var outRelay chan<- []*eskip.Route
+			inRelay := in
+			if pending {
+				outRelay = out
+				if len(defsByClient) < len(o.DataClients) {
+					inRelay = nil
+				}
 			}
 
-			incoming.log(o.Log, o.SuppressLogs)
-			c := incoming.client
-			defsByClient[c] = applyIncoming(defsByClient[c], incoming)
-
 			select {
-			case out <- mergeDefs(defsByClient):
+			case incoming := <-inRelay:
+				incoming.log(o.Log, o.SuppressLogs)
+				c := incoming.client
+				defsByClient[c] = applyIncoming(defsByClient[c], incoming)
+
+				if pending {
+					dropped++
+				}
+
+				latest, pending = mergeDefs(defsByClient), true
+			case outRelay <- latest:
+				if dropped > 0 {
+					o.Log.Infof("route settings, dropped intermediate updates: %d", dropped)
+					dropped = 0
+				}
+
+				latest, pending = nil, false
 			case <-quit:
 				return
 			}
@@ -365,16 +402,20 @@ func parseWeightPredicateArgs(args []interface{}) (int, error) {
 }
 
 // initialize predicate instances from their spec with the concrete arguments
-func processPredicates(cpm map[string]PredicateSpec, defs []*eskip.Predicate) ([]Predicate, int, error) {
+func processPredicates(cpm map[string]PredicateSpec, defs []*eskip.Predicate) ([]Predicate, int, *int, bool, error) {
 	cps := make([]Predicate, 0, len(defs))
-	var weight int
+	var (
+		weight         int
+		weightOverride *int
+		global         bool
+	)
 	for _, def := range defs {
 		if def.Name == predicates.WeightName {
 			var w int
 			var err error
 
 			if w, err = parseWeightPredicateArgs(def.Args); err != nil {
-				return nil, 0, err
+				return nil, 0, nil, false, err
 			}
 
 			weight += w
@@ -382,18 +423,51 @@ func processPredicates(cpm map[string]PredicateSpec, defs []*eskip.Predicate) ([
 			continue
 		}
 
+		if def.Name == predicates.RouteWeightName {
+			if weightOverride != nil {
+				return nil, 0, nil, false, errMultipleRouteWeight
+			}
+
+			w, err := parseWeightPredicateArgs(def.Args)
+			if err != nil {
+				return nil, 0, nil, false, errInvalidRouteWeight
+			}
+
+			weightOverride = &w
+
+			continue
+		}
+
+		if def.Name == predicates.GlobalName {
+			if len(def.Args) != 0 {
+				return nil, 0, nil, false, errInvalidGlobalParams
+			}
+
+			global = true
+
+			continue
+		}
+
 		if isTreePredicate(def.Name) {
 			continue
 		}
 
 		spec, ok := cpm[def.Name]
 		if !ok {
-			return nil, 0, fmt.Errorf("predicate %q not found", def.Name)
+			return nil, 0, nil, false, fmt.Errorf("predicate %q not found", def.Name)
+		}
+
+		args := def.Args
+		if ss, ok := spec.(ArgsSchemaPredicateSpec); ok {
+			var err error
+			if args, err = ss.ArgsSchema().Parse(args); err != nil {
+				return nil, 0, nil, false, fmt.Errorf("failed to create predicate %q: %w", spec.Name(), err)
+			}
 		}
 
-		cp, err := spec.Create(def.Args)
+		cp, err := spec.Create(args)
 		if err != nil {
-			return nil, 0, fmt.Errorf("failed to create predicate %q: %w", spec.Name(), err)
+			return nil, 0, nil, false, fmt.Errorf("failed to create predicate %q: %w", spec.Name(), err)
 		}
 
 		if ws, ok := spec.(WeightedPredicateSpec); ok {
@@ -403,7 +477,7 @@ func processPredicates(cpm map[string]PredicateSpec, defs []*eskip.Predicate) ([
 		cps = append(cps, cp)
 	}
 
-	return cps, weight, nil
+	return cps, weight, weightOverride, global, nil
 }
 
 // returns the subtree path if it is a valid definition
@@ -481,16 +555,37 @@ func processRouteDef(cpm map[string]PredicateSpec, fr filters.Registry, def *esk
 		return nil, err
 	}
 
-	cps, weight, err := processPredicates(cpm, def.Predicates)
+	cps, weight, weightOverride, global, err := processPredicates(cpm, def.Predicates)
 	if err != nil {
 		return nil, err
 	}
 
-	r := &Route{Route: *def, Scheme: scheme, Host: host, Predicates: cps, Filters: fs, weight: weight}
+	r := &Route{
+		Route:          *def,
+		Scheme:         scheme,
+		Host:           host,
+		Predicates:     cps,
+		Filters:        fs,
+		weight:         weight,
+		weightOverride: weightOverride,
+		global:         global,
+		predicateNames: customPredicateNames(def.Predicates),
+	}
 	if err := processTreePredicates(r, def.Predicates); err != nil {
 		return nil, err
 	}
 
+	if r.global && (r.path != "" || r.pathSubtree != "") {
+		return nil, errGlobalWithTreePath
+	}
+
+	for _, p := range r.Predicates {
+		if o, ok := p.(ResponseObserverPredicate); ok {
+			o.SetRouteId(r.Id)
+			r.responseObservers = append(r.responseObservers, o)
+		}
+	}
+
 	return r, nil
 }
 
@@ -506,7 +601,13 @@ func mapPredicates(cps []PredicateSpec) map[string]PredicateSpec {
 
 // processes a set of route definitions for the routing table
 func processRouteDefs(o Options, fr filters.Registry, defs []*eskip.Route) (routes []*Route, invalidDefs []*eskip.Route) {
-	cpm := mapPredicates(o.Predicates)
+	var cpm map[string]PredicateSpec
+	if o.predicateRegistry != nil {
+		cpm = o.predicateRegistry.snapshot()
+	} else {
+		cpm = mapPredicates(o.Predicates)
+	}
+
 	for _, def := range defs {
 		route, err := processRouteDef(cpm, fr, def)
 		if err == nil {
@@ -516,9 +617,153 @@ func processRouteDefs(o Options, fr filters.Registry, defs []*eskip.Route) (rout
 			o.Log.Errorf("failed to process route %s: %v", def.Id, err)
 		}
 	}
+
+	partitionExperiments(o.Log, routes)
+	if o.PredicateMetrics != nil {
+		setPredicateMetrics(o, routes)
+	}
+
+	if o.RouteExpiryMetrics != nil {
+		setExpiryMetrics(o.RouteExpiryMetrics, routes)
+	}
+
 	return
 }
 
+type experimentVariant struct {
+	name       string
+	weight     float64
+	predicates []ExperimentPredicate
+}
+
+// partitionExperiments assigns the slices of the random value to the
+// variants of the experiments, see ExperimentPredicate.
+func partitionExperiments(log logging.Logger, routes []*Route) {
+	experiments := make(map[string]map[string]*experimentVariant)
+	for _, r := range routes {
+		for _, p := range r.Predicates {
+			ep, ok := p.(ExperimentPredicate)
+			if !ok {
+				continue
+			}
+
+			name, variant, weight := ep.Experiment()
+			variants, ok := experiments[name]
+			if !ok {
+				variants = make(map[string]*experimentVariant)
+				experiments[name] = variants
+			}
+
+			v, ok := variants[variant]
+			if !ok {
+				v = &experimentVariant{name: variant, weight: weight}
+				variants[variant] = v
+			} else if weight > v.weight {
+				log.Warnf("conflicting weights of variant %s in experiment %s, using %v", variant, name, weight)
+				v.weight = weight
+			} else if weight < v.weight {
+				log.Warnf("conflicting weights of variant %s in experiment %s, using %v", variant, name, v.weight)
+			}
+
+			v.predicates = append(v.predicates, ep)
+		}
+	}
+
+	for _, variants := range experiments {
+		var (
+			sorted []*experimentVariant
+			total  float64
+		)
+
+		for _, v := range variants {
+			sorted = append(sorted, v)
+			total += v.weight
+		}
+
+		sort.Slice(sorted, func(i, j int) bool { return sorted[i].name < sorted[j].name })
+
+		var sum float64
+		for i, v := range sorted {
+			lower := sum / total
+			sum += v.weight
+			upper := sum / total
+
+			// avoid a gap at the end of the interval due to rounding
+			if i == len(sorted)-1 {
+				upper = 1
+			}
+
+			for _, p := range v.predicates {
+				p.SetSegment(lower, upper)
+			}
+		}
+	}
+}
+
+// the names of the predicates instantiated by processPredicates
+func customPredicateNames(defs []*eskip.Predicate) []string {
+	var names []string
+	for _, def := range defs {
+		switch {
+		case def.Name == predicates.WeightName,
+			def.Name == predicates.RouteWeightName,
+			def.Name == predicates.GlobalName,
+			isTreePredicate(def.Name):
+			continue
+		}
+
+		names = append(names, def.Name)
+	}
+
+	return names
+}
+
+// setPredicateMetrics assigns the counter keys to the custom predicates of
+// the routes. To limit the cardinality of the metrics, the route id is used
+// in the keys only for the first PredicateMetricsMaxRoutes routes with
+// custom predicates, in the order of the route ids.
+func setPredicateMetrics(o Options, routes []*Route) {
+	maxRoutes := o.PredicateMetricsMaxRoutes
+	if maxRoutes <= 0 {
+		maxRoutes = defaultPredicateMetricsMaxRoutes
+	}
+
+	var counted []*Route
+	for _, r := range routes {
+		if len(r.predicateNames) > 0 {
+			counted = append(counted, r)
+		}
+	}
+
+	sort.Slice(counted, func(i, j int) bool { return counted[i].Id < counted[j].Id })
+	for i, r := range counted {
+		var suffix string
+		if i < maxRoutes {
+			suffix = "." + r.Id
+		}
+
+		r.predicateMetrics = o.PredicateMetrics
+		r.predicateKeys = make([]predicateMetricKeys, len(r.predicateNames))
+		for j, name := range r.predicateNames {
+			p := "routing.predicate." + name + suffix
+			r.predicateKeys[j] = predicateMetricKeys{match: p + ".match", noMatch: p + ".nomatch"}
+		}
+	}
+}
+
+// setExpiryMetrics passes the function counting the expiry of the route to
+// its expiring predicates.
+func setExpiryMetrics(m PredicateMetrics, routes []*Route) {
+	for _, r := range routes {
+		key := "routing.route.expired." + r.Id
+		for _, p := range r.Predicates {
+			if ep, ok := p.(ExpiringPredicate); ok {
+				ep.OnExpiry(func() { m.IncCounter(key) })
+			}
+		}
+	}
+}
+
 type routeTable struct {
 	m             *matcher
 	once          sync.Once
@@ -561,6 +806,10 @@ func receiveRouteMatcher(o Options, out chan<- *routeTable, quit <-chan struct{}
 				defs = o.PreProcessors[i].Do(defs)
 			}
 
+			if o.SegmentGapBackend != "" {
+				defs = fillSegmentGaps(o.SegmentGapBackend, defs)
+			}
+
 			routes, invalidRoutes := processRouteDefs(o, o.FilterRegistry, defs)
 
 			for i := range o.PostProcessors {
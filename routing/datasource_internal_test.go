@@ -0,0 +1,105 @@
+package routing
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/zalando/skipper/eskip"
+	"github.com/zalando/skipper/logging/loggingtest"
+)
+
+// rapidClient emits the given number of updates of the same route as fast
+// as it is polled.
+type rapidClient struct {
+	mu      sync.Mutex
+	updates int
+	emitted int
+	done    chan struct{}
+}
+
+func (c *rapidClient) route() *eskip.Route {
+	return &eskip.Route{Id: "route", Backend: fmt.Sprintf("https://v%d.example.org", c.emitted)}
+}
+
+func (c *rapidClient) LoadAll() ([]*eskip.Route, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return []*eskip.Route{c.route()}, nil
+}
+
+func (c *rapidClient) LoadUpdate() ([]*eskip.Route, []string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.emitted == c.updates {
+		return nil, nil, nil
+	}
+
+	c.emitted++
+	if c.emitted == c.updates {
+		close(c.done)
+	}
+
+	return []*eskip.Route{c.route()}, nil, nil
+}
+
+func TestReceiveRouteDefsWithBackpressure(t *testing.T) {
+	l := loggingtest.New()
+	defer l.Close()
+
+	const updates = 100
+	c := &rapidClient{updates: updates, done: make(chan struct{})}
+
+	quit := make(chan struct{})
+	defer close(quit)
+
+	out := receiveRouteDefs(Options{
+		DataClients:  []DataClient{c},
+		PollTimeout:  time.Microsecond,
+		SuppressLogs: true,
+		Log:          l,
+	}, quit)
+
+	// the client is not stalled by the consumer not receiving the updates
+	select {
+	case <-c.done:
+	case <-time.After(3 * time.Second):
+		t.Fatal("the data client was stalled")
+	}
+
+	var (
+		received int
+		last     string
+	)
+
+	for {
+		select {
+		case defs := <-out:
+			received++
+			if len(defs) != 1 {
+				t.Fatalf("unexpected route definitions: %v", defs)
+			}
+
+			last = defs[0].Backend
+			continue
+		case <-time.After(120 * time.Millisecond):
+		}
+
+		break
+	}
+
+	// only the latest update is kept waiting, and the last one may arrive
+	// after the first one was received
+	if received > 2 {
+		t.Errorf("too many buffered updates: %d", received)
+	}
+
+	if expected := fmt.Sprintf("https://v%d.example.org", updates); last != expected {
+		t.Errorf("the last received route definitions are not the latest: %s, expected: %s", last, expected)
+	}
+
+	if err := l.WaitFor("route settings, dropped intermediate updates", 120*time.Millisecond); err != nil {
+		t.Error("failed to log the dropped updates")
+	}
+}
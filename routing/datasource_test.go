@@ -1,10 +1,13 @@
 package routing_test
 
 import (
+	"net"
 	"net/http"
+	"reflect"
 	"testing"
 	"time"
 
+	"github.com/zalando/skipper/eskip"
 	"github.com/zalando/skipper/filters"
 	"github.com/zalando/skipper/filters/builtin"
 	"github.com/zalando/skipper/logging"
@@ -184,7 +187,7 @@ func TestProcessRouteDefWeight(t *testing.T) {
 
 			r := defs[0]
 
-			_, weight, err := routing.ExportProcessPredicates(cpm, r.Predicates)
+			_, weight, _, _, err := routing.ExportProcessPredicates(cpm, r.Predicates)
 			if err != nil {
 				t.Error(ti.route, err)
 
@@ -200,6 +203,71 @@ func TestProcessRouteDefWeight(t *testing.T) {
 	}
 }
 
+func TestProcessPredicatesArgsSchema(t *testing.T) {
+	_, network, _ := net.ParseCIDR("10.0.0.0/8")
+	for _, ti := range []struct {
+		route string
+		args  []interface{}
+		err   string
+	}{{
+		route: `Typed(0.5, "1m") -> <shunt>`,
+		args:  []interface{}{0.5, time.Minute},
+	}, {
+		route: `Typed(0.5, "1m", "10.0.0.0/8") -> <shunt>`,
+		args:  []interface{}{0.5, time.Minute, network},
+	}, {
+		route: `Typed(0.5) -> <shunt>`,
+		err:   `failed to create predicate "Typed": expected at least 2 arguments, got 1`,
+	}, {
+		route: `Typed(0.5, "1m", "10.0.0.0/8", "foo") -> <shunt>`,
+		err:   `failed to create predicate "Typed": expected at most 3 arguments, got 4`,
+	}, {
+		route: `Typed(0.5, "one minute") -> <shunt>`,
+		err: `failed to create predicate "Typed": invalid argument 2 (duration): ` +
+			`invalid duration "one minute": time: invalid duration "one minute"`,
+	}, {
+		route: `Typed(0.5, "1m", "10.0.0.0") -> <shunt>`,
+		err: `failed to create predicate "Typed": invalid argument 3 (cidr): ` +
+			`invalid CIDR "10.0.0.0": invalid CIDR address: 10.0.0.0`,
+	}, {
+		route: `Raw(0.5, "1m") -> <shunt>`,
+		args:  []interface{}{0.5, "1m"},
+	}} {
+		t.Run(ti.route, func(t *testing.T) {
+			var args []interface{}
+			record := func(a []interface{}) { args = a }
+			cpm := map[string]routing.PredicateSpec{
+				"Typed": schemaPredicateSpec{
+					rawPredicateSpec: rawPredicateSpec{name: "Typed", record: record},
+					schema: eskip.ArgsSchema{
+						Required: []eskip.ArgType{eskip.ArgNumber, eskip.ArgDuration},
+						Optional: []eskip.ArgType{eskip.ArgCIDR},
+					},
+				},
+				"Raw": rawPredicateSpec{name: "Raw", record: record},
+			}
+
+			r := eskip.MustParse(ti.route)[0]
+			_, _, _, _, err := routing.ExportProcessPredicates(cpm, r.Predicates)
+			if ti.err != "" {
+				if err == nil || err.Error() != ti.err {
+					t.Errorf("expected error '%s'. Got: '%v'", ti.err, err)
+				}
+
+				return
+			}
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if !reflect.DeepEqual(args, ti.args) {
+				t.Errorf("expected args %v. Got: %v", ti.args, args)
+			}
+		})
+	}
+}
+
 func TestLogging(t *testing.T) {
 	if testing.Short() {
 		t.Skip()
@@ -319,3 +387,23 @@ func (w weightedPredicateSpec) Create([]interface{}) (routing.Predicate, error)
 func (w weightedPredicateSpec) Weight() int {
 	return w.weight
 }
+
+// rawPredicateSpec records the arguments passed to Create.
+type rawPredicateSpec struct {
+	name   string
+	record func([]interface{})
+}
+
+func (s rawPredicateSpec) Name() string { return s.name }
+
+func (s rawPredicateSpec) Create(args []interface{}) (routing.Predicate, error) {
+	s.record(args)
+	return weightedPredicate{}, nil
+}
+
+type schemaPredicateSpec struct {
+	rawPredicateSpec
+	schema eskip.ArgsSchema
+}
+
+func (s schemaPredicateSpec) ArgsSchema() eskip.ArgsSchema { return s.schema }
@@ -0,0 +1,129 @@
+package routing
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/zalando/skipper/eskip"
+	"github.com/zalando/skipper/logging/loggingtest"
+	"github.com/zalando/skipper/routing/testdataclient"
+)
+
+func TestGlobalArgs(t *testing.T) {
+	for _, tt := range []struct {
+		msg  string
+		doc  string
+		fail bool
+	}{{
+		msg: "no args",
+		doc: `* && Global() -> <shunt>`,
+	}, {
+		msg:  "too many args",
+		doc:  `* && Global("foo") -> <shunt>`,
+		fail: true,
+	}, {
+		msg:  "combined with Path",
+		doc:  `Path("/foo") && Global() -> <shunt>`,
+		fail: true,
+	}, {
+		msg:  "combined with PathSubtree",
+		doc:  `PathSubtree("/foo") && Global() -> <shunt>`,
+		fail: true,
+	}, {
+		msg: "combined with PathRegexp",
+		doc: `PathRegexp("^/foo") && Global() -> <shunt>`,
+	}} {
+		t.Run(tt.msg, func(t *testing.T) {
+			r := eskip.MustParse(tt.doc)
+			_, err := processRouteDef(nil, nil, r[0])
+			if tt.fail && err == nil {
+				t.Error("failed to fail")
+			} else if !tt.fail && err != nil {
+				t.Error(err)
+			}
+		})
+	}
+}
+
+func TestGlobalRoutesMatchFirst(t *testing.T) {
+	const routes = `
+		foo: Path("/foo") -> <shunt>;
+		fooHeavy: Path("/foo") && Header("X-Test", "foo") && Weight(100) -> <shunt>;
+		bar: PathSubtree("/bar") -> <shunt>;
+		root: * -> <shunt>;
+		killSwitch: Global() && Header("X-Kill-Switch", "true") -> <shunt>;
+		killSwitchFoo: Global() && PathRegexp("^/foo") && Header("X-Kill-Switch", "true") -> <shunt>;
+	`
+
+	dc, err := testdataclient.NewDoc(routes)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer dc.Close()
+
+	l := loggingtest.New()
+	defer l.Close()
+
+	rt := New(Options{
+		DataClients: []DataClient{dc},
+		Log:         l,
+	})
+	defer rt.Close()
+
+	if err := l.WaitFor("route settings applied", 120*time.Millisecond); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tt := range []struct {
+		path       string
+		header     http.Header
+		expectedID string
+	}{{
+		path:       "/foo",
+		expectedID: "foo",
+	}, {
+		path:       "/foo",
+		header:     http.Header{"X-Test": []string{"foo"}},
+		expectedID: "fooHeavy",
+	}, {
+		path:       "/bar/baz",
+		expectedID: "bar",
+	}, {
+		path:       "/baz",
+		expectedID: "root",
+	}, {
+		path:       "/foo",
+		header:     http.Header{"X-Test": []string{"foo"}, "X-Kill-Switch": []string{"true"}},
+		expectedID: "killSwitchFoo",
+	}, {
+		path:       "/bar/baz",
+		header:     http.Header{"X-Kill-Switch": []string{"true"}},
+		expectedID: "killSwitch",
+	}, {
+		path:       "/baz",
+		header:     http.Header{"X-Kill-Switch": []string{"true"}},
+		expectedID: "killSwitch",
+	}} {
+		t.Run(tt.path+" "+tt.expectedID, func(t *testing.T) {
+			req, err := http.NewRequest("GET", "https://www.example.org"+tt.path, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			for k, v := range tt.header {
+				req.Header[k] = v
+			}
+
+			r, _ := rt.Route(req)
+			if r == nil {
+				t.Fatal("route not found")
+			}
+
+			if r.Id != tt.expectedID {
+				t.Errorf("routing failed; matched route: %s, expected: %s", r.Id, tt.expectedID)
+			}
+		})
+	}
+}
@@ -0,0 +1,110 @@
+package routing
+
+import (
+	"encoding/json"
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/zalando/skipper/eskip"
+)
+
+// LBRouteStats contains the state of the endpoints of a load balanced
+// route.
+type LBRouteStats struct {
+	Id        string            `json:"id"`
+	Algorithm string            `json:"algorithm"`
+	Endpoints []LBEndpointStats `json:"endpoints"`
+}
+
+// LBEndpointStats contains the state of an endpoint of a load balanced
+// route. FadingIn is set while the endpoint is within the fade-in duration
+// of the route after it was detected. The selections are counted since the
+// endpoint was created by the last routing update.
+type LBEndpointStats struct {
+	Scheme           string     `json:"scheme"`
+	Host             string     `json:"host"`
+	FadingIn         bool       `json:"fadingIn"`
+	Detected         *time.Time `json:"detected,omitempty"`
+	InflightRequests int        `json:"inflightRequests"`
+	Selections       int64      `json:"selections"`
+}
+
+func lbEndpointStats(r *Route, e LBEndpoint, now time.Time) LBEndpointStats {
+	s := LBEndpointStats{
+		Scheme: e.Scheme,
+		Host:   e.Host,
+	}
+
+	if !e.Detected.IsZero() {
+		d := e.Detected
+		s.Detected = &d
+	}
+
+	s.FadingIn = s.Detected != nil && now.Sub(e.Detected) < r.LBFadeInDuration
+
+	if e.Metrics != nil {
+		s.InflightRequests = e.Metrics.GetInflightRequests()
+		s.Selections = e.Metrics.GetSelections()
+	}
+
+	return s
+}
+
+// lbStats returns the state of the load balanced routes of the routing
+// table, in the order of the route ids. The routing table is not changed
+// after it was created, and the counters are read atomically, so reading
+// the stats doesn't block the selection of the endpoints.
+func (rt *routeTable) lbStats(now time.Time) []LBRouteStats {
+	valid := make(map[*eskip.Route]bool, len(rt.validRoutes))
+	for _, r := range rt.validRoutes {
+		valid[r] = true
+	}
+
+	stats := []LBRouteStats{}
+	for _, r := range rt.routes {
+		if r.BackendType != eskip.LBBackend || r.LBAlgorithm == nil || !valid[&r.Route] {
+			continue
+		}
+
+		s := LBRouteStats{
+			Id:        r.Id,
+			Algorithm: r.Route.LBAlgorithm,
+			Endpoints: make([]LBEndpointStats, len(r.LBEndpoints)),
+		}
+
+		for i, e := range r.LBEndpoints {
+			s.Endpoints[i] = lbEndpointStats(r, e, now)
+		}
+
+		stats = append(stats, s)
+	}
+
+	sort.Slice(stats, func(i, j int) bool { return stats[i].Id < stats[j].Id })
+	return stats
+}
+
+// LBStatsHandler returns the handler rendering the state of the endpoints
+// of the load balanced routes of the current routing table as JSON, see
+// LBRouteStats. Methods other than GET are responded with 405.
+func (r *Routing) LBStatsHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		r.routeTable.Load().(*routeTable).serveLBStats(w, req)
+	})
+}
+
+func (rt *routeTable) serveLBStats(w http.ResponseWriter, req *http.Request) {
+	if req.Method != "GET" {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(rt.lbStats(time.Now())); err != nil {
+		http.Error(
+			w,
+			http.StatusText(http.StatusInternalServerError),
+			http.StatusInternalServerError,
+		)
+	}
+}
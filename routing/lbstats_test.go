@@ -0,0 +1,165 @@
+package routing_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/zalando/skipper/loadbalancer"
+	"github.com/zalando/skipper/logging/loggingtest"
+	"github.com/zalando/skipper/routing"
+	"github.com/zalando/skipper/routing/testdataclient"
+)
+
+// fadeInPostProcessor sets the fade-in of the fadein route.
+type fadeInPostProcessor struct{}
+
+func (fadeInPostProcessor) Do(routes []*routing.Route) []*routing.Route {
+	for _, r := range routes {
+		if r.Id == "fadein" {
+			// the second endpoint is fading in
+			r.LBFadeInDuration = time.Hour
+			r.LBEndpoints[1].Detected = time.Now()
+		}
+	}
+
+	return routes
+}
+
+func getLBStats(t *testing.T, rt *routing.Routing) []routing.LBRouteStats {
+	req := httptest.NewRequest("GET", "/lbstats", nil)
+	w := httptest.NewRecorder()
+	rt.LBStatsHandler().ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("unexpected status code: %d", w.Code)
+	}
+
+	if ct := w.Header().Get("Content-Type"); ct != "application/json" {
+		t.Fatalf("unexpected content type: %s", ct)
+	}
+
+	var stats []routing.LBRouteStats
+	if err := json.Unmarshal(w.Body.Bytes(), &stats); err != nil {
+		t.Fatal(err)
+	}
+
+	return stats
+}
+
+func TestLBStats(t *testing.T) {
+	dc, err := testdataclient.NewDoc(`
+		rr: Path("/rr") -> <roundRobin, "http://10.0.0.1:8080", "http://10.0.0.2:8080", "http://10.0.0.3:8080">;
+		fadein: Path("/fadein") -> <random, "http://10.0.1.1:8080", "http://10.0.1.2:8443">;
+		network: Path("/network") -> "https://www.example.org";
+	`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer dc.Close()
+
+	l := loggingtest.New()
+	defer l.Close()
+
+	rt := routing.New(routing.Options{
+		DataClients:    []routing.DataClient{dc},
+		PostProcessors: []routing.PostProcessor{loadbalancer.NewAlgorithmProvider(), fadeInPostProcessor{}},
+		Log:            l,
+	})
+	defer rt.Close()
+
+	if err := l.WaitFor("route settings applied", 120*time.Millisecond); err != nil {
+		t.Fatal(err)
+	}
+
+	req, err := http.NewRequest("GET", "https://www.example.org/rr", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	r, _ := rt.Route(req)
+	if r == nil {
+		t.Fatal("failed to match the route")
+	}
+
+	for i := 0; i < 3; i++ {
+		r.LBEndpoints[0].Metrics.IncInflightRequest()
+	}
+
+	r.LBEndpoints[0].Metrics.DecInflightRequest()
+	r.LBEndpoints[1].Metrics.IncInflightRequest()
+	r.LBEndpoints[1].Metrics.DecInflightRequest()
+
+	stats := getLBStats(t, rt)
+	if len(stats) != 2 {
+		t.Fatalf("unexpected number of LB routes: %d", len(stats))
+	}
+
+	fadein, rr := stats[0], stats[1]
+	if fadein.Id != "fadein" || fadein.Algorithm != "random" || rr.Id != "rr" || rr.Algorithm != "roundRobin" {
+		t.Fatalf("unexpected routes: %+v", stats)
+	}
+
+	expected := []routing.LBEndpointStats{{
+		Scheme:           "http",
+		Host:             "10.0.0.1:8080",
+		InflightRequests: 2,
+		Selections:       3,
+	}, {
+		Scheme:     "http",
+		Host:       "10.0.0.2:8080",
+		Selections: 1,
+	}, {
+		Scheme: "http",
+		Host:   "10.0.0.3:8080",
+	}}
+
+	if len(rr.Endpoints) != len(expected) {
+		t.Fatalf("unexpected endpoints: %+v", rr.Endpoints)
+	}
+
+	for i, e := range rr.Endpoints {
+		if e != expected[i] {
+			t.Errorf("unexpected endpoint: %+v, expected: %+v", e, expected[i])
+		}
+	}
+
+	if len(fadein.Endpoints) != 2 ||
+		fadein.Endpoints[0].Host != "10.0.1.1:8080" ||
+		fadein.Endpoints[1].Scheme != "http" ||
+		fadein.Endpoints[1].Host != "10.0.1.2:8443" {
+		t.Fatalf("unexpected endpoints: %+v", fadein.Endpoints)
+	}
+
+	if fadein.Endpoints[0].FadingIn || fadein.Endpoints[0].Detected != nil {
+		t.Errorf("unexpected fade-in of the first endpoint: %+v", fadein.Endpoints[0])
+	}
+
+	if !fadein.Endpoints[1].FadingIn || fadein.Endpoints[1].Detected == nil {
+		t.Errorf("unexpected fade-in of the second endpoint: %+v", fadein.Endpoints[1])
+	}
+}
+
+func TestLBStatsMethod(t *testing.T) {
+	dc := testdataclient.New(nil)
+	defer dc.Close()
+
+	rt := routing.New(routing.Options{DataClients: []routing.DataClient{dc}})
+	defer rt.Close()
+
+	<-rt.FirstLoad()
+
+	for _, m := range []string{"POST", "HEAD", "DELETE"} {
+		w := httptest.NewRecorder()
+		rt.LBStatsHandler().ServeHTTP(w, httptest.NewRequest(m, "/lbstats", nil))
+		if w.Code != http.StatusMethodNotAllowed {
+			t.Fatalf("unexpected status code of %s: %d", m, w.Code)
+		}
+	}
+
+	if stats := getLBStats(t, rt); len(stats) != 0 {
+		t.Fatalf("unexpected stats: %+v", stats)
+	}
+}
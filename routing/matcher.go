@@ -34,17 +34,26 @@ type leafMatcher struct {
 	exactPath            string
 	method               string
 	weight               int
+	weightOverride       *int
 	hostRxs              []*regexp.Regexp
 	pathRxs              []*regexp.Regexp
 	headersExact         map[string]string
 	headersRegexp        map[string][]*regexp.Regexp
 	predicates           []Predicate
+	segmentMin           float64
+	hasSegment           bool
+	predicateKeys        []predicateMetricKeys
+	predicateMetrics     PredicateMetrics
 	route                *Route
 }
 
 type leafMatchers []*leafMatcher
 
 func leafWeight(l *leafMatcher) int {
+	if l.weightOverride != nil {
+		return *l.weightOverride
+	}
+
 	w := l.weight
 
 	if l.method != "" {
@@ -65,12 +74,54 @@ func (ls leafMatchers) Len() int           { return len(ls) }
 func (ls leafMatchers) Swap(i, j int)      { ls[i], ls[j] = ls[j], ls[i] }
 func (ls leafMatchers) Less(i, j int) bool { return leafWeight(ls[i]) > leafWeight(ls[j]) }
 
+// sortLeaves orders the leaves by their weight. Among the leaves of equal
+// weight, the ones with a traffic segment are ordered by the lower bound of
+// the segment, so that overlapping intervals resolve to the lower one, while
+// the leaves without a segment keep their position.
+func sortLeaves(ls leafMatchers) {
+	sort.Stable(ls)
+	for i := 0; i < len(ls); {
+		j, w := i+1, leafWeight(ls[i])
+		for j < len(ls) && leafWeight(ls[j]) == w {
+			j++
+		}
+
+		sortSegments(ls[i:j])
+		i = j
+	}
+}
+
+// sortSegments orders the leaves with a traffic segment by the lower bound
+// of the segment, placing them into the positions taken by them before.
+func sortSegments(ls leafMatchers) {
+	var (
+		positions []int
+		segments  leafMatchers
+	)
+
+	for i, l := range ls {
+		if l.hasSegment {
+			positions = append(positions, i)
+			segments = append(segments, l)
+		}
+	}
+
+	sort.SliceStable(segments, func(i, j int) bool {
+		return segments[i].segmentMin < segments[j].segmentMin
+	})
+
+	for i, p := range positions {
+		ls[p] = segments[i]
+	}
+}
+
 type pathMatcher struct {
 	leaves leafMatchers
 }
 
 // root structure representing the routing tree.
 type matcher struct {
+	globalLeaves    leafMatchers
 	paths           *pathmux.Tree
 	rootLeaves      leafMatchers
 	matchingOptions MatchingOptions
@@ -233,18 +284,44 @@ func newLeaf(r *Route, rxs map[string]*regexp.Regexp) (*leafMatcher, error) {
 		allHeaderRxs[k] = headerRxs
 	}
 
-	return &leafMatcher{
+	l := &leafMatcher{
 		wildcardParamNames:   extractWildcardParamNames(r),
 		hasFreeWildcardParam: hasFreeWildcardParam(r),
 
-		weight:        r.weight,
-		method:        r.Method,
-		hostRxs:       hostRxs,
-		pathRxs:       pathRxs,
-		headersExact:  canonicalizeHeaders(r.Headers),
-		headersRegexp: canonicalizeHeaderRegexps(allHeaderRxs),
-		predicates:    r.Predicates,
-		route:         r}, nil
+		weight:         r.weight,
+		weightOverride: r.weightOverride,
+		method:         r.Method,
+		hostRxs:        hostRxs,
+		pathRxs:        pathRxs,
+		headersExact:   canonicalizeHeaders(r.Headers),
+		headersRegexp:  canonicalizeHeaderRegexps(allHeaderRxs),
+		predicates:     r.Predicates,
+		route:          r,
+	}
+
+	// post-processors may change the predicates of the route, in which
+	// case the keys don't apply
+	if r.predicateMetrics != nil && len(r.predicateKeys) == len(r.Predicates) {
+		l.predicateKeys = r.predicateKeys
+		l.predicateMetrics = r.predicateMetrics
+	}
+
+	// the segment of the route is the one of the first segment predicate,
+	// see Route.TrafficSegment
+	for _, p := range r.Predicates {
+		if _, ok := p.(TrafficSegmentPredicate); !ok {
+			continue
+		}
+
+		if sp, ok := p.(SegmentIntervalPredicate); ok {
+			l.segmentMin, _ = sp.SegmentInterval()
+			l.hasSegment = true
+		}
+
+		break
+	}
+
+	return l, nil
 }
 
 func trimTrailingSlash(path string) string {
@@ -262,7 +339,7 @@ func addTreeMatchers(pathTree *pathmux.Tree, matchers map[string]*pathMatcher) [
 	for p, m := range matchers {
 
 		// sort leaves during construction time, based on their priority
-		sort.Stable(m.leaves)
+		sortLeaves(m.leaves)
 
 		if err := pathTree.Add(p, m); err != nil {
 			errors = append(errors, &definitionError{Index: -1, Original: err})
@@ -310,8 +387,9 @@ func addSubtreeLeafsToPath(pms map[string]*pathMatcher, path string, l *leafMatc
 // definition matches first.
 func newMatcher(rs []*Route, o MatchingOptions) (*matcher, []*definitionError) {
 	var (
-		errors     []*definitionError
-		rootLeaves leafMatchers
+		errors       []*definitionError
+		globalLeaves leafMatchers
+		rootLeaves   leafMatchers
 	)
 
 	pathMatchers := make(map[string]*pathMatcher)
@@ -324,6 +402,11 @@ func newMatcher(rs []*Route, o MatchingOptions) (*matcher, []*definitionError) {
 			continue
 		}
 
+		if r.global {
+			globalLeaves = append(globalLeaves, l)
+			continue
+		}
+
 		path, err := normalizePath(r)
 		if err != nil {
 			errors = append(errors, &definitionError{r.Id, i, err})
@@ -349,10 +432,11 @@ func newMatcher(rs []*Route, o MatchingOptions) (*matcher, []*definitionError) {
 	pathTree := &pathmux.Tree{}
 	errors = append(errors, addTreeMatchers(pathTree, pathMatchers)...)
 
-	// sort root leaves during construction time, based on their priority
-	sort.Stable(rootLeaves)
+	// sort global and root leaves during construction time, based on their priority
+	sortLeaves(globalLeaves)
+	sortLeaves(rootLeaves)
 
-	return &matcher{pathTree, rootLeaves, o}, errors
+	return &matcher{globalLeaves, pathTree, rootLeaves, o}, errors
 }
 
 // matches a path in the path trie structure.
@@ -442,6 +526,20 @@ func matchPredicates(cps []Predicate, req *http.Request) bool {
 	return true
 }
 
+// like matchPredicates, but counting the result of each evaluated predicate
+func matchPredicatesCounted(cps []Predicate, keys []predicateMetricKeys, m PredicateMetrics, req *http.Request) bool {
+	for i, cp := range cps {
+		if !cp.Match(req) {
+			m.IncCounter(keys[i].noMatch)
+			return false
+		}
+
+		m.IncCounter(keys[i].match)
+	}
+
+	return true
+}
+
 // matches a request to the conditions in a leaf matcher
 func matchLeaf(l *leafMatcher, req *http.Request, path, exactPath string) bool {
 	if l.exactPath != "" && l.exactPath != path {
@@ -464,7 +562,11 @@ func matchLeaf(l *leafMatcher, req *http.Request, path, exactPath string) bool {
 		return false
 	}
 
-	if !matchPredicates(l.predicates, req) {
+	if l.predicateMetrics != nil {
+		if !matchPredicatesCounted(l.predicates, l.predicateKeys, l.predicateMetrics, req) {
+			return false
+		}
+	} else if !matchPredicates(l.predicates, req) {
 		return false
 	}
 
@@ -493,9 +595,15 @@ func (m *matcher) match(r *http.Request) (*Route, map[string]string) {
 	if m.matchingOptions.ignoreTrailingSlash() {
 		path = trimTrailingSlash(path)
 	}
+
+	// global routes take precedence over all other routes, regardless of the path
+	if l := matchLeaves(m.globalLeaves, r, path, exact); l != nil {
+		return l.route, nil
+	}
+
 	lrm := &leafRequestMatcher{r: r, path: path, exactPath: exact}
 
-	// first match fixed and wildcard paths
+	// then match fixed and wildcard paths
 	params, l := matchPathTree(m.paths, path, lrm)
 
 	if l != nil {
@@ -7,6 +7,7 @@ import (
 	"net/http"
 	"net/url"
 	"regexp"
+	"strconv"
 	"testing"
 
 	"github.com/zalando/skipper/eskip"
@@ -1309,3 +1310,141 @@ func BenchmarkConstructionMass(b *testing.B) {
 		}
 	}
 }
+
+// intervalSegmentSpec creates TrafficSegment predicates with a fixed
+// interval, matching the random value taken from the X-Random header.
+type intervalSegmentSpec struct{}
+
+type intervalSegmentPredicate struct {
+	min, max float64
+}
+
+func (intervalSegmentSpec) Name() string { return "TrafficSegment" }
+
+func (intervalSegmentSpec) Create(args []interface{}) (Predicate, error) {
+	min, _ := args[0].(float64)
+	max, _ := args[1].(float64)
+	return &intervalSegmentPredicate{min: min, max: max}, nil
+}
+
+func (p *intervalSegmentPredicate) random(req *http.Request) float64 {
+	r, _ := strconv.ParseFloat(req.Header.Get("X-Random"), 64)
+	return r
+}
+
+func (p *intervalSegmentPredicate) Match(req *http.Request) bool {
+	r := p.random(req)
+	return p.min <= r && r < p.max
+}
+
+func (p *intervalSegmentPredicate) TrafficSegment(req *http.Request) TrafficSegment {
+	return TrafficSegment{Min: p.min, Max: p.max, Random: p.random(req)}
+}
+
+func (p *intervalSegmentPredicate) SegmentInterval() (float64, float64) {
+	return p.min, p.max
+}
+
+func TestMatchSegmentsOrderedByLowerBound(t *testing.T) {
+	for _, tt := range []struct {
+		title  string
+		routes string
+	}{{
+		title: "lower interval first",
+		routes: `
+			fallback: Path("/") -> "https://fallback.example.org";
+			lower: Path("/") && TrafficSegment(0, 0.6) -> "https://lower.example.org";
+			upper: Path("/") && TrafficSegment(0.4, 1) -> "https://upper.example.org";
+		`,
+	}, {
+		title: "upper interval first",
+		routes: `
+			upper: Path("/") && TrafficSegment(0.4, 1) -> "https://upper.example.org";
+			fallback: Path("/") -> "https://fallback.example.org";
+			lower: Path("/") && TrafficSegment(0, 0.6) -> "https://lower.example.org";
+		`,
+	}} {
+		t.Run(tt.title, func(t *testing.T) {
+			defs, err := eskip.Parse(tt.routes)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			routes, _ := processRouteDefs(Options{
+				Predicates: []PredicateSpec{&truePredicate{}, intervalSegmentSpec{}},
+			}, nil, defs)
+
+			m, err := newTestMatcher(routes)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			for _, rt := range []struct {
+				random string
+				id     string
+			}{
+				{"0.2", "lower"},
+				{"0.4", "lower"},
+				{"0.5", "lower"},
+				{"0.59", "lower"},
+				{"0.6", "upper"},
+				{"0.9", "upper"},
+				{"1", "fallback"},
+			} {
+				req, err := http.NewRequest("GET", "https://www.example.org/", nil)
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				req.Header.Set("X-Random", rt.random)
+				r, _ := m.match(req)
+				if r == nil || r.Id != rt.id {
+					t.Errorf("expected route %s for %s, got: %v", rt.id, rt.random, r)
+				}
+			}
+		})
+	}
+}
+
+func TestMatchSegmentsKeepOtherRoutesInPlace(t *testing.T) {
+	defs, err := eskip.Parse(`
+		upper: Path("/") && TrafficSegment(0.4, 1) -> "https://upper.example.org";
+		other: Path("/") && True() -> "https://other.example.org";
+		lower: Path("/") && TrafficSegment(0, 0.6) -> "https://lower.example.org";
+	`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	routes, _ := processRouteDefs(Options{
+		Predicates: []PredicateSpec{&truePredicate{}, intervalSegmentSpec{}},
+	}, nil, defs)
+
+	m, err := newTestMatcher(routes)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the segments swap their positions, while the route of equal weight
+	// without a segment stays between them
+	for _, rt := range []struct {
+		random string
+		id     string
+	}{
+		{"0.2", "lower"},
+		{"0.5", "lower"},
+		{"0.6", "other"},
+		{"0.9", "other"},
+	} {
+		req, err := http.NewRequest("GET", "https://www.example.org/", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		req.Header.Set("X-Random", rt.random)
+		r, _ := m.match(req)
+		if r == nil || r.Id != rt.id {
+			t.Errorf("expected route %s for %s, got: %v", rt.id, rt.random, r)
+		}
+	}
+}
@@ -0,0 +1,128 @@
+package routing
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/zalando/skipper/logging/loggingtest"
+	"github.com/zalando/skipper/metrics/metricstest"
+	"github.com/zalando/skipper/routing/testdataclient"
+)
+
+type queryFlagSpec struct{}
+
+type queryFlagPredicate string
+
+func (queryFlagSpec) Name() string { return "QueryFlag" }
+
+func (queryFlagSpec) Create(args []interface{}) (Predicate, error) {
+	return queryFlagPredicate(args[0].(string)), nil
+}
+
+func (p queryFlagPredicate) Match(r *http.Request) bool {
+	return r.URL.Query().Has(string(p))
+}
+
+func TestPredicateMetrics(t *testing.T) {
+	const routes = `
+		foo: Path("/foo") && QueryFlag("foo") && QueryFlag("bar") -> <shunt>;
+		baz: Path("/baz") && QueryFlag("baz") -> <shunt>;
+		qux: Path("/qux") && QueryFlag("qux") -> <shunt>;
+		fallback: * -> <shunt>;
+	`
+
+	dc, err := testdataclient.NewDoc(routes)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer dc.Close()
+
+	l := loggingtest.New()
+	defer l.Close()
+
+	m := &metricstest.MockMetrics{}
+	rt := New(Options{
+		DataClients:               []DataClient{dc},
+		Predicates:                []PredicateSpec{queryFlagSpec{}},
+		PredicateMetrics:          m,
+		PredicateMetricsMaxRoutes: 2,
+		Log:                       l,
+	})
+	defer rt.Close()
+
+	if err := l.WaitFor("route settings applied", 120*time.Millisecond); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, u := range []string{
+		"/foo?foo&bar",
+		"/foo?foo",
+		"/foo",
+		"/baz?baz",
+		"/qux",
+		"/qux?qux",
+		"/other",
+	} {
+		req, err := http.NewRequest("GET", "https://www.example.org"+u, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		rt.Route(req)
+	}
+
+	// the route ids are used in the keys in the order of the ids
+	expected := map[string]int64{
+		"routing.predicate.QueryFlag.baz.match":   1,
+		"routing.predicate.QueryFlag.foo.match":   3,
+		"routing.predicate.QueryFlag.foo.nomatch": 2,
+		"routing.predicate.QueryFlag.match":       1,
+		"routing.predicate.QueryFlag.nomatch":     1,
+	}
+
+	m.WithCounters(func(counters map[string]int64) {
+		if len(counters) != len(expected) {
+			t.Errorf("unexpected counters: %v", counters)
+		}
+
+		for k, v := range expected {
+			if counters[k] != v {
+				t.Errorf("unexpected value of %s: %d, expected: %d", k, counters[k], v)
+			}
+		}
+	})
+}
+
+func TestPredicateMetricsDisabled(t *testing.T) {
+	dc, err := testdataclient.NewDoc(`foo: QueryFlag("foo") -> <shunt>`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer dc.Close()
+
+	l := loggingtest.New()
+	defer l.Close()
+
+	rt := New(Options{
+		DataClients: []DataClient{dc},
+		Predicates:  []PredicateSpec{queryFlagSpec{}},
+		Log:         l,
+	})
+	defer rt.Close()
+
+	if err := l.WaitFor("route settings applied", 120*time.Millisecond); err != nil {
+		t.Fatal(err)
+	}
+
+	req, err := http.NewRequest("GET", "https://www.example.org/?foo", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if r, _ := rt.Route(req); r == nil || r.predicateMetrics != nil || r.predicateKeys != nil {
+		t.Error("unexpected route or predicate metrics")
+	}
+}
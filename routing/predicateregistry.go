@@ -0,0 +1,78 @@
+package routing
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/zalando/skipper/predicates"
+)
+
+// ErrPredicateExists is returned by Routing.RegisterPredicate, when a
+// predicate with the same name is already registered, or the name is
+// reserved by the routing, e.g. Path or Weight.
+var ErrPredicateExists = errors.New("predicate already exists")
+
+// predicateRegistry holds the custom predicate specifications, initialized
+// from Options.Predicates, and extended by Routing.RegisterPredicate. The
+// routing uses a snapshot of it for every new routing table.
+type predicateRegistry struct {
+	mu    sync.RWMutex
+	specs map[string]PredicateSpec
+}
+
+func newPredicateRegistry(specs []PredicateSpec) *predicateRegistry {
+	return &predicateRegistry{specs: mapPredicates(specs)}
+}
+
+func isReservedPredicate(name string) bool {
+	switch name {
+	case predicates.WeightName, predicates.RouteWeightName, predicates.GlobalName:
+		return true
+	default:
+		return isTreePredicate(name)
+	}
+}
+
+func (r *predicateRegistry) register(spec PredicateSpec) error {
+	name := spec.Name()
+	if isReservedPredicate(name) {
+		return fmt.Errorf("%w: %s is reserved", ErrPredicateExists, name)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.specs[name]; ok {
+		return fmt.Errorf("%w: %s", ErrPredicateExists, name)
+	}
+
+	r.specs[name] = spec
+	return nil
+}
+
+func (r *predicateRegistry) snapshot() map[string]PredicateSpec {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	cpm := make(map[string]PredicateSpec, len(r.specs))
+	for name, spec := range r.specs {
+		cpm[name] = spec
+	}
+
+	return cpm
+}
+
+// RegisterPredicate registers an additional custom predicate specification,
+// e.g. of a plugin, after the routing was created. The predicate can be
+// used by the routes from the next routing table, created on the next
+// update received from the data clients. It returns ErrPredicateExists,
+// when a predicate with the same name was already registered.
+func (r *Routing) RegisterPredicate(spec PredicateSpec) error {
+	if err := r.predicates.register(spec); err != nil {
+		return err
+	}
+
+	r.log.Infof("predicate %s registered", spec.Name())
+	return nil
+}
@@ -0,0 +1,27 @@
+package routing
+
+import "sync/atomic"
+
+// RouteCount holds the number of the valid routes in the live routing
+// table. It is updated by the routing every time it applies a new table,
+// when set in Options.RouteCount, and it can be shared with the
+// predicates, e.g. RouteCountAbove(), reading it in constant time.
+type RouteCount struct {
+	count int64
+}
+
+// Value returns the current number of the valid routes. It returns 0 for
+// a nil RouteCount.
+func (c *RouteCount) Value() int {
+	if c == nil {
+		return 0
+	}
+
+	return int(atomic.LoadInt64(&c.count))
+}
+
+func (c *RouteCount) set(n int) {
+	if c != nil {
+		atomic.StoreInt64(&c.count, int64(n))
+	}
+}
@@ -0,0 +1,60 @@
+package routing
+
+import (
+	"testing"
+	"time"
+
+	"github.com/zalando/skipper/logging/loggingtest"
+	"github.com/zalando/skipper/routing/testdataclient"
+)
+
+func TestRouteCount(t *testing.T) {
+	var nilCount *RouteCount
+	if v := nilCount.Value(); v != 0 {
+		t.Fatalf("unexpected count of nil: %d", v)
+	}
+
+	dc, err := testdataclient.NewDoc(`
+		r1: Path("/a") -> "https://a.example.org";
+		r2: Path("/b") -> "https://b.example.org";
+		r3: Path("/c") -> "https://c.example.org";
+	`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer dc.Close()
+
+	l := loggingtest.New()
+	defer l.Close()
+
+	count := &RouteCount{}
+	rt := New(Options{
+		DataClients:     []DataClient{dc},
+		RouteCount:      count,
+		Log:             l,
+		PollTimeout:     12 * time.Millisecond,
+		SignalFirstLoad: true,
+	})
+	defer rt.Close()
+
+	<-rt.FirstLoad()
+	if v := count.Value(); v != 3 {
+		t.Fatalf("unexpected count: %d", v)
+	}
+
+	l.Reset()
+
+	// the invalid routes are not counted
+	if err := dc.UpdateDoc(`r4: Path("/d") -> noSuchFilter() -> "https://d.example.org"`, []string{"r1", "r2"}); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := l.WaitFor("route settings applied", 120*time.Millisecond); err != nil {
+		t.Fatal(err)
+	}
+
+	if v := count.Value(); v != 1 {
+		t.Errorf("unexpected count after the update: %d", v)
+	}
+}
@@ -0,0 +1,112 @@
+package routing
+
+import (
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/zalando/skipper/logging/loggingtest"
+	"github.com/zalando/skipper/metrics/metricstest"
+	"github.com/zalando/skipper/routing/testdataclient"
+)
+
+// expiresSpec creates predicates expiring when the test calls expire.
+type expiresSpec struct {
+	mu      sync.Mutex
+	expired bool
+}
+
+type expiresPredicate struct {
+	spec     *expiresSpec
+	once     sync.Once
+	onExpiry func()
+}
+
+func (*expiresSpec) Name() string { return "Expires" }
+
+func (s *expiresSpec) Create([]interface{}) (Predicate, error) {
+	return &expiresPredicate{spec: s}, nil
+}
+
+func (s *expiresSpec) expire() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.expired = true
+}
+
+func (p *expiresPredicate) Expiry() time.Time { return time.Time{} }
+
+func (p *expiresPredicate) OnExpiry(f func()) { p.onExpiry = f }
+
+func (p *expiresPredicate) Match(*http.Request) bool {
+	p.spec.mu.Lock()
+	expired := p.spec.expired
+	p.spec.mu.Unlock()
+	if !expired {
+		return true
+	}
+
+	p.once.Do(p.onExpiry)
+	return false
+}
+
+func TestRouteExpiryMetrics(t *testing.T) {
+	dc, err := testdataclient.NewDoc(`
+		canary: Path("/") && Expires() -> "https://canary.example.org";
+		stable: Path("/") -> "https://stable.example.org";
+	`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer dc.Close()
+
+	l := loggingtest.New()
+	defer l.Close()
+
+	spec := &expiresSpec{}
+	m := &metricstest.MockMetrics{}
+	rt := New(Options{
+		DataClients:        []DataClient{dc},
+		Predicates:         []PredicateSpec{spec},
+		RouteExpiryMetrics: m,
+		Log:                l,
+	})
+	defer rt.Close()
+
+	if err := l.WaitFor("route settings applied", 120*time.Millisecond); err != nil {
+		t.Fatal(err)
+	}
+
+	route := func() string {
+		req, err := http.NewRequest("GET", "https://www.example.org/", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		r, _ := rt.Route(req)
+		if r == nil {
+			t.Fatal("failed to match a route")
+		}
+
+		return r.Id
+	}
+
+	if id := route(); id != "canary" {
+		t.Fatalf("unexpected route before the expiry: %s", id)
+	}
+
+	spec.expire()
+	for i := 0; i < 3; i++ {
+		if id := route(); id != "stable" {
+			t.Fatalf("unexpected route after the expiry: %s", id)
+		}
+	}
+
+	m.WithCounters(func(counters map[string]int64) {
+		if len(counters) != 1 || counters["routing.route.expired.canary"] != 1 {
+			t.Errorf("unexpected counters: %v", counters)
+		}
+	})
+}
@@ -0,0 +1,126 @@
+package routing
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/zalando/skipper/eskip"
+	"github.com/zalando/skipper/logging/loggingtest"
+	"github.com/zalando/skipper/routing/testdataclient"
+)
+
+func TestRouteWeightArgs(t *testing.T) {
+	for _, tt := range []struct {
+		msg  string
+		doc  string
+		fail bool
+	}{{
+		msg:  "no args",
+		doc:  `* && RouteWeight() -> <shunt>`,
+		fail: true,
+	}, {
+		msg:  "invalid arg",
+		doc:  `* && RouteWeight("foo") -> <shunt>`,
+		fail: true,
+	}, {
+		msg:  "too many args",
+		doc:  `* && RouteWeight(1, 2) -> <shunt>`,
+		fail: true,
+	}, {
+		msg:  "multiple",
+		doc:  `* && RouteWeight(1) && RouteWeight(2) -> <shunt>`,
+		fail: true,
+	}, {
+		msg: "weight",
+		doc: `* && RouteWeight(10) -> <shunt>`,
+	}, {
+		msg: "combined with Weight",
+		doc: `* && RouteWeight(10) && Weight(5) -> <shunt>`,
+	}} {
+		t.Run(tt.msg, func(t *testing.T) {
+			r := eskip.MustParse(tt.doc)
+			_, err := processRouteDef(nil, nil, r[0])
+			if tt.fail && err == nil {
+				t.Error("failed to fail")
+			} else if !tt.fail && err != nil {
+				t.Error(err)
+			}
+		})
+	}
+}
+
+type negativeWeightSpec struct{}
+
+func (negativeWeightSpec) Name() string                            { return "NegativeWeight" }
+func (negativeWeightSpec) Create([]interface{}) (Predicate, error) { return negativeWeightSpec{}, nil }
+func (negativeWeightSpec) Weight() int                             { return -1 }
+func (negativeWeightSpec) Match(*http.Request) bool                { return true }
+
+func TestRouteWeightOverridesPredicateWeight(t *testing.T) {
+	const routes = `
+		heavy: Path("/foo") && Header("X-Test", "foo") && Header("X-Other", "bar") && Weight(10) -> <shunt>;
+		canary: Path("/foo") && Header("X-Canary", "true") && NegativeWeight() && RouteWeight(100) -> <shunt>;
+		stable: Path("/foo") && Header("X-Test", "foo") -> <shunt>;
+		demoted: Path("/foo") && Header("X-Test", "foo") && Header("X-Stable", "true") && RouteWeight(0) -> <shunt>;
+	`
+
+	dc, err := testdataclient.NewDoc(routes)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer dc.Close()
+
+	l := loggingtest.New()
+	defer l.Close()
+
+	rt := New(Options{
+		DataClients: []DataClient{dc},
+		Predicates:  []PredicateSpec{negativeWeightSpec{}},
+		Log:         l,
+	})
+	defer rt.Close()
+
+	if err := l.WaitFor("route settings applied", 120*time.Millisecond); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tt := range []struct {
+		msg        string
+		header     http.Header
+		expectedID string
+	}{{
+		msg:        "predicate weights",
+		header:     http.Header{"X-Test": []string{"foo"}, "X-Other": []string{"bar"}},
+		expectedID: "heavy",
+	}, {
+		msg:        "explicit weight wins over the sum of the predicate weights",
+		header:     http.Header{"X-Test": []string{"foo"}, "X-Other": []string{"bar"}, "X-Canary": []string{"true"}},
+		expectedID: "canary",
+	}, {
+		msg:        "explicit weight lowers the priority",
+		header:     http.Header{"X-Test": []string{"foo"}, "X-Stable": []string{"true"}},
+		expectedID: "stable",
+	}} {
+		t.Run(tt.msg, func(t *testing.T) {
+			req, err := http.NewRequest("GET", "https://www.example.org/foo", nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			for k, v := range tt.header {
+				req.Header[k] = v
+			}
+
+			r, _ := rt.Route(req)
+			if r == nil {
+				t.Fatal("route not found")
+			}
+
+			if r.Id != tt.expectedID {
+				t.Errorf("routing failed; matched route: %s, expected: %s", r.Id, tt.expectedID)
+			}
+		})
+	}
+}
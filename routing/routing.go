@@ -79,6 +79,106 @@ type WeightedPredicateSpec interface {
 	Weight() int
 }
 
+// ArgsSchemaPredicateSpec is an optional interface of the predicate specs
+// that declare the types of their arguments. The routing checks and converts
+// the arguments of the route definition according to the schema, e.g. a
+// duration string into time.Duration, before passing them to Create, and
+// rejects the route with a descriptive error, when they don't match. The
+// specs without a schema receive the raw arguments.
+type ArgsSchemaPredicateSpec interface {
+	PredicateSpec
+
+	// ArgsSchema returns the types of the arguments.
+	ArgsSchema() eskip.ArgsSchema
+}
+
+// TrafficSegment contains the traffic segment metadata of a request, as
+// assigned by a predicate implementing TrafficSegmentPredicate.
+type TrafficSegment struct {
+	// Min and Max define the interval of the segment: [Min, Max).
+	Min, Max float64
+
+	// Cohort is the optional label of the segment, e.g. "canary".
+	Cohort string
+
+	// Random is the one-per-request random value from [0, 1), that
+	// was used to match the segment.
+	Random float64
+
+	// RouteId is the id of the route containing the segment predicate.
+	RouteId string
+}
+
+// ExperimentPredicate is implemented by predicates that assign the matching
+// requests to a named variant of an experiment, e.g. Experiment(). When the
+// routing table is built, the routing partitions the [0, 1) interval of the
+// per-request random value between the variants of each experiment, in the
+// order of the variant names, proportionally to their weights, and passes
+// the slice of each variant to the predicates with SetSegment.
+type ExperimentPredicate interface {
+	Predicate
+
+	// Experiment returns the name of the experiment, the name of the
+	// variant and the relative weight of the variant.
+	Experiment() (name, variant string, weight float64)
+
+	// SetSegment sets the slice [min, max) of the random value assigned
+	// to the variant.
+	SetSegment(min, max float64)
+}
+
+// TrafficSegmentPredicate is implemented by predicates that assign the
+// matching requests to a traffic segment, e.g. TrafficSegment().
+type TrafficSegmentPredicate interface {
+	Predicate
+
+	// Returns the traffic segment metadata for the request.
+	TrafficSegment(*http.Request) TrafficSegment
+}
+
+// SegmentIntervalPredicate is implemented by the traffic segment predicates
+// whose interval doesn't depend on the request, e.g. TrafficSegment(). The
+// routing uses the lower bound of the interval to order the routes of equal
+// weight, so that the intervals are evaluated in a consistent order.
+type SegmentIntervalPredicate interface {
+	TrafficSegmentPredicate
+
+	// SegmentInterval returns the interval [min, max) of the segment.
+	SegmentInterval() (min, max float64)
+}
+
+// ExpiringPredicate is implemented by predicates that stop matching after
+// an expiry time, e.g. Until(). When the routing table is built, the
+// routing passes the function counting the expiry of the route with
+// OnExpiry, see Options.RouteExpiryMetrics.
+type ExpiringPredicate interface {
+	Predicate
+
+	// Expiry returns the time, from which the predicate doesn't match.
+	Expiry() time.Time
+
+	// OnExpiry sets the function to be called once, when the predicate
+	// evaluates the first request after the expiry.
+	OnExpiry(func())
+}
+
+// ResponseObserverPredicate is implemented by predicates that match based
+// on the recent responses of their route, e.g. ErrorRateBelow(). When the
+// routing table is built, the routing passes the id of the route with
+// SetRouteId, so that the predicates can keep their state across the
+// routing updates, and the proxy reports the status code of every
+// response of the route, see Route.ObserveResponse.
+type ResponseObserverPredicate interface {
+	Predicate
+
+	// SetRouteId sets the id of the route of the predicate.
+	SetRouteId(string)
+
+	// ObserveResponse is called with the status code of every response
+	// of the route.
+	ObserveResponse(statusCode int)
+}
+
 // Options for initialization for routing.
 type Options struct {
 
@@ -132,6 +232,60 @@ type Options struct {
 	// SignalFirstLoad enables signaling on the first load
 	// of the routing configuration during the startup.
 	SignalFirstLoad bool
+
+	// PredicateMetrics, when set, enables counting the matches and the
+	// no-matches of the custom predicates, e.g. Cookie or TrafficSegment,
+	// under the routing.predicate.<predicate>.<route id>.match and
+	// routing.predicate.<predicate>.<route id>.nomatch keys. Only the
+	// evaluated predicates are counted, which means that the predicates
+	// following a no-match are not.
+	PredicateMetrics PredicateMetrics
+
+	// PredicateMetricsMaxRoutes limits the number of routes whose
+	// predicates are counted with the route id in the key, in the order
+	// of the route ids. The predicates of the further routes are counted
+	// only by the predicate name, under the
+	// routing.predicate.<predicate>.match and
+	// routing.predicate.<predicate>.nomatch keys. Defaults to 100.
+	PredicateMetricsMaxRoutes int
+
+	// RouteExpiryMetrics, when set, enables counting the expiry of the
+	// routes with an ExpiringPredicate, e.g. Until(), under the
+	// routing.route.expired.<route id> key. The expiry is counted once,
+	// when the route receives the first request after its expiry time.
+	RouteExpiryMetrics PredicateMetrics
+
+	// RouteCount, when set, is updated with the number of the valid
+	// routes of the live routing table, e.g. for the RouteCountAbove()
+	// predicate.
+	RouteCount *RouteCount
+
+	// SegmentGapBackend, when set, enables generating the routes for the
+	// intervals of the traffic splits not covered by the TrafficSegment
+	// predicates, e.g. the remaining [0.9, 1) of the routes with
+	// TrafficSegment(0, 0.5) and TrafficSegment(0.5, 0.9), that otherwise
+	// don't match. The routes of a split are the ones that differ only in
+	// their TrafficSegment predicate. The generated routes forward the
+	// requests to this backend address. Their ids start with
+	// SegmentGapRouteIdPrefix, and their metadata contains
+	// SegmentGapMetadataKey, so that they can be told apart from the
+	// declared routes. When the declared routes cover the whole interval,
+	// no route is generated.
+	SegmentGapBackend string
+
+	// set by the routing, containing Predicates and the predicates
+	// registered at runtime
+	predicateRegistry *predicateRegistry
+}
+
+// PredicateMetrics is used to count the matches of the predicates, see
+// Options.PredicateMetrics. It is implemented by metrics.Metrics.
+type PredicateMetrics interface {
+	IncCounter(key string)
+}
+
+type predicateMetricKeys struct {
+	match, noMatch string
 }
 
 // RouteFilter contains extensions to generic filter
@@ -148,11 +302,14 @@ type RouteFilter struct {
 // LBMetrics contains metrics used by LB algorithms
 type LBMetrics struct {
 	inflightRequests int64
+	selections       int64
 }
 
 // IncInflightRequest increments the number of outstanding requests from the proxy to a given backend.
+// It also counts the selection of the backend.
 func (m *LBMetrics) IncInflightRequest() {
 	atomic.AddInt64(&m.inflightRequests, 1)
+	atomic.AddInt64(&m.selections, 1)
 }
 
 // DecInflightRequest decrements the number of outstanding requests from the proxy to a given backend.
@@ -165,6 +322,11 @@ func (m *LBMetrics) GetInflightRequests() int {
 	return int(atomic.LoadInt64(&m.inflightRequests))
 }
 
+// GetSelections returns the number of the requests sent by the proxy to a given backend.
+func (m *LBMetrics) GetSelections() int64 {
+	return atomic.LoadInt64(&m.selections)
+}
+
 // LBEndpoint represents the scheme and the host of load balanced
 // backends.
 type LBEndpoint struct {
@@ -209,6 +371,25 @@ type Route struct {
 	// weight used internally, received from the Weight() predicates.
 	weight int
 
+	// weightOverride is set by the RouteWeight() predicate, and it
+	// replaces the weight calculated from the predicates of the route.
+	weightOverride *int
+
+	// global is set by the Global() predicate, and it means that the
+	// route is evaluated before all other routes, regardless of the path.
+	global bool
+
+	// names of the custom predicates, in the order of Predicates
+	predicateNames []string
+
+	// the predicates observing the responses of the route
+	responseObservers []ResponseObserverPredicate
+
+	// predicateMetrics and predicateKeys are set when the predicate
+	// metrics are enabled, see Options.PredicateMetrics
+	predicateMetrics PredicateMetrics
+	predicateKeys    []predicateMetricKeys
+
 	// path predicate matching a subtree
 	path string
 
@@ -246,6 +427,30 @@ type Route struct {
 	LBFadeInExponent float64
 }
 
+// TrafficSegment returns the traffic segment metadata of the request,
+// provided by the first predicate of the route that implements the
+// TrafficSegmentPredicate interface, if any.
+func (r *Route) TrafficSegment(req *http.Request) (TrafficSegment, bool) {
+	for _, p := range r.Predicates {
+		if sp, ok := p.(TrafficSegmentPredicate); ok {
+			s := sp.TrafficSegment(req)
+			s.RouteId = r.Id
+			return s, true
+		}
+	}
+
+	return TrafficSegment{}, false
+}
+
+// ObserveResponse reports the status code of a response of the route to
+// the predicates of the route that implement the ResponseObserverPredicate
+// interface.
+func (r *Route) ObserveResponse(statusCode int) {
+	for _, o := range r.responseObservers {
+		o.ObserveResponse(statusCode)
+	}
+}
+
 // PostProcessor is an interface for custom post-processors applying changes
 // to the routes after they were created from their data representation and
 // before they were passed to the proxy.
@@ -267,6 +472,7 @@ type Routing struct {
 	firstLoad         chan struct{}
 	firstLoadSignaled bool
 	quit              chan struct{}
+	predicates        *predicateRegistry
 }
 
 // New initializes a routing instance, and starts listening for route
@@ -277,6 +483,8 @@ func New(o Options) *Routing {
 	}
 
 	r := &Routing{log: o.Log, firstLoad: make(chan struct{}), quit: make(chan struct{})}
+	r.predicates = newPredicateRegistry(o.Predicates)
+	o.predicateRegistry = r.predicates
 	if !o.SignalFirstLoad {
 		close(r.firstLoad)
 		r.firstLoadSignaled = true
@@ -362,6 +570,7 @@ func (r *Routing) startReceivingUpdates(o Options) {
 			select {
 			case rt := <-c:
 				r.routeTable.Store(rt)
+				o.RouteCount.set(len(rt.validRoutes))
 				if !r.firstLoadSignaled {
 					dc--
 					if dc == 0 {
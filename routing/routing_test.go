@@ -333,15 +333,23 @@ func TestMergesUpdatesFromMultipleSources(t *testing.T) {
 		t.Error(err)
 	}
 
-	tr.log.Reset()
-
-	dc1.Update([]*eskip.Route{{Id: "route1", Path: "/some-changed-path", Backend: "https://www.example.org"}}, nil)
-	dc2.Update([]*eskip.Route{{Id: "route2", Path: "/some-other-changed", Backend: "https://www.example.org"}}, nil)
-	dc3.Update(nil, []string{"route3"})
-
-	if err := tr.waitForNRouteSettings(3); err != nil {
-		t.Error(err)
-		return
+	// the updates are applied one by one, because the pending ones may be
+	// coalesced
+	for _, update := range []func(){
+		func() {
+			dc1.Update([]*eskip.Route{{Id: "route1", Path: "/some-changed-path", Backend: "https://www.example.org"}}, nil)
+		},
+		func() {
+			dc2.Update([]*eskip.Route{{Id: "route2", Path: "/some-other-changed", Backend: "https://www.example.org"}}, nil)
+		},
+		func() { dc3.Update(nil, []string{"route3"}) },
+	} {
+		tr.log.Reset()
+		update()
+		if err := tr.waitForRouteSetting(); err != nil {
+			t.Error(err)
+			return
+		}
 	}
 
 	if _, err := tr.checkGetRequest("https://www.example.com/some-changed-path"); err != nil {
@@ -457,6 +465,62 @@ func TestProcessesPredicates(t *testing.T) {
 	}
 }
 
+func TestRegisterPredicate(t *testing.T) {
+	dc, err := testdataclient.NewDoc(`
+        route1: CustomPredicate("custom1") -> "https://route1.example.org";
+        catchAll: * -> "https://route.example.org"`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer dc.Close()
+
+	tr, err := newTestRouting(dc)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer tr.close()
+
+	req, err := http.NewRequest("GET", "https://www.example.com", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	req.Header.Set(predicateHeader, "custom1")
+	if r, err := tr.checkRequest(req); err != nil || r.Backend != "https://route.example.org" {
+		t.Fatal("unexpected match before registering the predicate")
+	}
+
+	if err := tr.routing.RegisterPredicate(&predicate{}); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, spec := range []routing.PredicateSpec{
+		&predicate{},
+		weightedPredicateSpec{name: "Path"},
+		weightedPredicateSpec{name: "Weight"},
+	} {
+		if err := tr.routing.RegisterPredicate(spec); !errors.Is(err, routing.ErrPredicateExists) {
+			t.Errorf("failed to reject %s, got: %v", spec.Name(), err)
+		}
+	}
+
+	// the predicate is applied with the next routing table
+	if r, err := tr.checkRequest(req); err != nil || r.Backend != "https://route.example.org" {
+		t.Fatal("unexpected match before the next routing table")
+	}
+
+	tr.log.Reset()
+	dc.Update([]*eskip.Route{{Id: "route2", Path: "/some-other", Backend: "https://other.example.org"}}, nil)
+	if err := tr.waitForRouteSetting(); err != nil {
+		t.Fatal(err)
+	}
+
+	if r, err := tr.checkRequest(req); err != nil || r.Backend != "https://route1.example.org" {
+		t.Error("failed to match the registered predicate")
+	}
+}
+
 // TestNonMatchedStaticRoute for bug #116: non-matched static route suppress wild-carded route
 func TestNonMatchedStaticRoute(t *testing.T) {
 	dc, err := testdataclient.NewDoc(`
@@ -0,0 +1,250 @@
+package routing
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/zalando/skipper/eskip"
+	"github.com/zalando/skipper/predicates"
+)
+
+const (
+	// SegmentGapRouteIdPrefix is the prefix of the ids of the routes
+	// generated for the uncovered intervals of the traffic segments, see
+	// Options.SegmentGapBackend.
+	SegmentGapRouteIdPrefix = "segmentgap__"
+
+	// SegmentGapMetadataKey is the key of the route metadata labeling
+	// the generated routes of the uncovered traffic segments.
+	SegmentGapMetadataKey = "synthesized"
+
+	segmentGapMetadataValue = "segmentGap"
+
+	// the gaps below this size are considered rounding errors
+	segmentGapTolerance = 1e-9
+)
+
+type segmentInterval struct {
+	min, max float64
+}
+
+// segmentRandom identifies the random value of a TrafficSegment predicate,
+// by its namespace and salt.
+type segmentRandom struct {
+	namespace, salt string
+}
+
+// segmentGroup contains the routes that differ only in their
+// TrafficSegment predicate.
+type segmentGroup struct {
+	// the id and the predicates of the first route, in the order of the
+	// ids, except for the TrafficSegment
+	firstId    string
+	predicates []*eskip.Predicate
+
+	random     segmentRandom
+	intervals  []segmentInterval
+	unresolved bool
+}
+
+type segmentKind int
+
+const (
+	noSegment segmentKind = iota
+	fixedSegment
+	// the interval references environment variables
+	unresolvedSegment
+	multipleSegments
+)
+
+// trafficSegment returns the kind, the interval and the random value of the
+// TrafficSegment predicate of a canonical route, and the rest of its
+// predicates.
+func trafficSegment(r *eskip.Route) (segmentKind, segmentInterval, segmentRandom, []*eskip.Predicate) {
+	var (
+		segment *eskip.Predicate
+		rest    []*eskip.Predicate
+	)
+
+	for _, p := range r.Predicates {
+		if p.Name != predicates.TrafficSegmentName {
+			rest = append(rest, p)
+			continue
+		}
+
+		if segment != nil {
+			return multipleSegments, segmentInterval{}, segmentRandom{}, nil
+		}
+
+		segment = p
+	}
+
+	if segment == nil {
+		return noSegment, segmentInterval{}, segmentRandom{}, rest
+	}
+
+	var random segmentRandom
+	if len(segment.Args) >= 4 {
+		random.namespace, _ = segment.Args[3].(string)
+	}
+
+	if len(segment.Args) == 5 {
+		random.salt, _ = segment.Args[4].(string)
+	}
+
+	if len(segment.Args) < 2 {
+		return unresolvedSegment, segmentInterval{}, random, rest
+	}
+
+	min, minOk := segment.Args[0].(float64)
+	max, maxOk := segment.Args[1].(float64)
+	if !minOk || !maxOk {
+		return unresolvedSegment, segmentInterval{}, random, rest
+	}
+
+	return fixedSegment, segmentInterval{min: min, max: max}, random, rest
+}
+
+func predicatesKey(ps []*eskip.Predicate) string {
+	s := make([]string, len(ps))
+	for i, p := range ps {
+		s[i] = p.String()
+	}
+
+	sort.Strings(s)
+	return strings.Join(s, " && ")
+}
+
+// gaps returns the uncovered intervals of [0, 1).
+func (g *segmentGroup) gaps() []segmentInterval {
+	sort.Slice(g.intervals, func(i, j int) bool { return g.intervals[i].min < g.intervals[j].min })
+
+	var (
+		gaps []segmentInterval
+		end  float64
+	)
+
+	for _, i := range g.intervals {
+		if i.max <= i.min {
+			continue
+		}
+
+		if i.min-end > segmentGapTolerance {
+			gaps = append(gaps, segmentInterval{min: end, max: i.min})
+		}
+
+		if i.max > end {
+			end = i.max
+		}
+	}
+
+	if 1-end > segmentGapTolerance {
+		gaps = append(gaps, segmentInterval{min: end, max: 1})
+	}
+
+	return gaps
+}
+
+func (g *segmentGroup) gapRoutes(backend string) []*eskip.Route {
+	var routes []*eskip.Route
+	for i, gap := range g.gaps() {
+		args := []interface{}{gap.min, gap.max}
+		switch {
+		case g.random.salt != "":
+			args = append(args, "", g.random.namespace, g.random.salt)
+		case g.random.namespace != "":
+			args = append(args, "", g.random.namespace)
+		}
+
+		ps := make([]*eskip.Predicate, len(g.predicates), len(g.predicates)+1)
+		copy(ps, g.predicates)
+		ps = append(ps, &eskip.Predicate{Name: predicates.TrafficSegmentName, Args: args})
+
+		routes = append(routes, &eskip.Route{
+			Id:          SegmentGapRouteIdPrefix + g.firstId + "__" + strconv.Itoa(i),
+			Predicates:  ps,
+			BackendType: eskip.NetworkBackend,
+			Backend:     backend,
+			Metadata:    map[string]string{SegmentGapMetadataKey: segmentGapMetadataValue},
+		})
+	}
+
+	return routes
+}
+
+// fillSegmentGaps generates the routes to the backend for the intervals of
+// the random value not covered by the TrafficSegment predicates of the
+// routes that differ only in their TrafficSegment predicate. A group is
+// covered fully, when there is also a route with the same predicates but
+// without the TrafficSegment. The groups with intervals referencing
+// environment variables are ignored, and so are the routes with multiple
+// TrafficSegment predicates.
+func fillSegmentGaps(backend string, defs []*eskip.Route) []*eskip.Route {
+	var (
+		groups  = make(map[string]*segmentGroup)
+		covered = make(map[string]bool)
+		ids     = make(map[string]bool)
+	)
+
+	for _, def := range defs {
+		ids[def.Id] = true
+
+		kind, interval, random, rest := trafficSegment(eskip.Canonical(def))
+		pk := predicatesKey(rest)
+		switch kind {
+		case multipleSegments:
+			continue
+		case noSegment:
+			covered[pk] = true
+			continue
+		}
+
+		key := random.namespace + "\x00" + random.salt + "\x00" + pk
+		g, ok := groups[key]
+		if !ok {
+			g = &segmentGroup{random: random}
+			groups[key] = g
+		}
+
+		if !ok || def.Id < g.firstId {
+			g.firstId, g.predicates = def.Id, rest
+		}
+
+		if kind == unresolvedSegment {
+			g.unresolved = true
+			continue
+		}
+
+		g.intervals = append(g.intervals, interval)
+	}
+
+	keys := make([]string, 0, len(groups))
+	for key := range groups {
+		keys = append(keys, key)
+	}
+
+	sort.Strings(keys)
+
+	var generated []*eskip.Route
+	for _, key := range keys {
+		g := groups[key]
+		if g.unresolved || covered[predicatesKey(g.predicates)] {
+			continue
+		}
+
+		for _, r := range g.gapRoutes(backend) {
+			if !ids[r.Id] {
+				generated = append(generated, r)
+			}
+		}
+	}
+
+	if len(generated) == 0 {
+		return defs
+	}
+
+	result := make([]*eskip.Route, 0, len(defs)+len(generated))
+	result = append(result, defs...)
+	return append(result, generated...)
+}
@@ -0,0 +1,209 @@
+package routing
+
+import (
+	"net/http"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/zalando/skipper/eskip"
+	"github.com/zalando/skipper/logging/loggingtest"
+	"github.com/zalando/skipper/routing/testdataclient"
+)
+
+// headerSegmentSpec creates TrafficSegment predicates matching the random
+// value taken from the X-Random header.
+type headerSegmentSpec struct{}
+
+type headerSegmentPredicate struct {
+	min, max float64
+}
+
+func (headerSegmentSpec) Name() string { return "TrafficSegment" }
+
+func (headerSegmentSpec) Create(args []interface{}) (Predicate, error) {
+	min, _ := args[0].(float64)
+	max, _ := args[1].(float64)
+	return &headerSegmentPredicate{min: min, max: max}, nil
+}
+
+func (p *headerSegmentPredicate) Match(req *http.Request) bool {
+	r, err := strconv.ParseFloat(req.Header.Get("X-Random"), 64)
+	return err == nil && p.min <= r && r < p.max
+}
+
+func TestFillSegmentGaps(t *testing.T) {
+	for _, test := range []struct {
+		title     string
+		routes    string
+		generated string
+	}{{
+		title:  "no segments",
+		routes: `r: Path("/") -> "https://www.example.org";`,
+	}, {
+		title: "full coverage",
+		routes: `
+			r0: Path("/") && TrafficSegment(0, 0.5) -> "https://a.example.org";
+			r1: Path("/") && TrafficSegment(0.5, 1) -> "https://b.example.org";
+		`,
+	}, {
+		title: "gap at the end",
+		routes: `
+			r0: Path("/") && TrafficSegment(0, 0.5) -> "https://a.example.org";
+			r1: Path("/") && TrafficSegment(0.5, 0.9, "canary") -> "https://b.example.org";
+		`,
+		generated: `segmentgap__r0__0: Path("/") && TrafficSegment(0.9, 1) -> "https://default.example.org";`,
+	}, {
+		title: "gaps at the start and in the middle",
+		routes: `
+			r1: Path("/") && TrafficSegment(0.6, 1) -> "https://b.example.org";
+			r0: Path("/") && TrafficSegment(0.2, 0.5) -> "https://a.example.org";
+		`,
+		generated: `
+			segmentgap__r0__0: Path("/") && TrafficSegment(0, 0.2) -> "https://default.example.org";
+			segmentgap__r0__1: Path("/") && TrafficSegment(0.5, 0.6) -> "https://default.example.org";
+		`,
+	}, {
+		title: "overlapping segments",
+		routes: `
+			r0: Path("/") && TrafficSegment(0, 0.6) -> "https://a.example.org";
+			r1: Path("/") && TrafficSegment(0.4, 0.8) -> "https://b.example.org";
+		`,
+		generated: `segmentgap__r0__0: Path("/") && TrafficSegment(0.8, 1) -> "https://default.example.org";`,
+	}, {
+		title: "rounding errors are ignored",
+		routes: `
+			r0: Path("/") && TrafficSegment(0, 0.3) -> "https://a.example.org";
+			r1: Path("/") && TrafficSegment(0.3000000000001, 1) -> "https://b.example.org";
+		`,
+	}, {
+		title: "covered by a route without segment",
+		routes: `
+			r0: Path("/") && TrafficSegment(0, 0.5) -> "https://a.example.org";
+			r1: Path("/") -> "https://b.example.org";
+		`,
+	}, {
+		title: "separate groups by predicates",
+		routes: `
+			r0: Path("/") && TrafficSegment(0, 0.5) -> "https://a.example.org";
+			r1: Path("/") && TrafficSegment(0.5, 1) -> "https://b.example.org";
+			r2: Path("/api") && Method("GET") && TrafficSegment(0, 0.5) -> "https://a.example.org";
+			r3: Method("GET") && Path("/api") && TrafficSegment(0.5, 0.7) -> "https://b.example.org";
+		`,
+		generated: `segmentgap__r2__0: Method("GET") && Path("/api") && TrafficSegment(0.7, 1) -> "https://default.example.org";`,
+	}, {
+		title: "separate groups by namespace",
+		routes: `
+			r0: Path("/") && TrafficSegment(0, 1) -> "https://a.example.org";
+			r1: Path("/") && TrafficSegment(0, 0.5, "", "experiment") -> "https://b.example.org";
+		`,
+		generated: `segmentgap__r1__0: Path("/") && TrafficSegment(0.5, 1, "", "experiment") -> "https://default.example.org";`,
+	}, {
+		title: "separate groups by salt",
+		routes: `
+			r0: Path("/") && TrafficSegment(0, 1) -> "https://a.example.org";
+			r1: Path("/") && TrafficSegment(0, 0.5, "", "", "split-a") -> "https://b.example.org";
+			r2: Path("/") && TrafficSegment(0.5, 0.8, "canary", "", "split-a") -> "https://c.example.org";
+		`,
+		generated: `segmentgap__r1__0: Path("/") && TrafficSegment(0.8, 1, "", "", "split-a") -> "https://default.example.org";`,
+	}, {
+		title: "interval from environment variable",
+		routes: `
+			r0: Path("/") && TrafficSegment(0, "${FRACTION}") -> "https://a.example.org";
+			r1: Path("/") && TrafficSegment(0.5, 0.9) -> "https://b.example.org";
+		`,
+	}, {
+		title: "multiple segments",
+		routes: `
+			r0: Path("/") && TrafficSegment(0, 0.5) && TrafficSegment(0, 1, "", "other") -> "https://a.example.org";
+		`,
+	}} {
+		t.Run(test.title, func(t *testing.T) {
+			defs, err := eskip.Parse(test.routes)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			expected, err := eskip.Parse(test.generated)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			result := fillSegmentGaps("https://default.example.org", defs)
+			if len(result) != len(defs)+len(expected) {
+				t.Fatalf("unexpected number of routes: %d, expected: %d", len(result), len(defs)+len(expected))
+			}
+
+			for i, r := range result[len(defs):] {
+				if r.Metadata[SegmentGapMetadataKey] != "segmentGap" {
+					t.Errorf("generated route %s is not labeled", r.Id)
+				}
+
+				r.Metadata = nil
+				if !eskip.Eq(r, expected[i]) {
+					t.Errorf("unexpected route: %s, expected: %s", r.String(), expected[i].String())
+				}
+			}
+		})
+	}
+}
+
+func TestSegmentGapRoutes(t *testing.T) {
+	dc, err := testdataclient.NewDoc(`
+		r0: Path("/") && TrafficSegment(0, 0.5) -> "https://a.example.org";
+		r1: Path("/") && TrafficSegment(0.5, 0.9) -> "https://b.example.org";
+	`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer dc.Close()
+
+	l := loggingtest.New()
+	defer l.Close()
+
+	rt := New(Options{
+		DataClients:       []DataClient{dc},
+		Predicates:        []PredicateSpec{headerSegmentSpec{}},
+		SegmentGapBackend: "https://default.example.org",
+		Log:               l,
+	})
+	defer rt.Close()
+
+	if err := l.WaitFor("route settings applied", 120*time.Millisecond); err != nil {
+		t.Fatal(err)
+	}
+
+	route := func(random string) *Route {
+		req, err := http.NewRequest("GET", "https://www.example.org/", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		req.Header.Set("X-Random", random)
+		r, _ := rt.Route(req)
+		return r
+	}
+
+	r := route("0.95")
+	if r == nil || r.Id != "segmentgap__r0__0" || r.Backend != "https://default.example.org" {
+		t.Fatalf("failed to route to the generated route: %v", r)
+	}
+
+	if r := route("0.7"); r == nil || r.Id != "r1" {
+		t.Fatalf("unexpected route: %v", r)
+	}
+
+	l.Reset()
+	if err := dc.UpdateDoc(`r1: Path("/") && TrafficSegment(0.5, 1) -> "https://b.example.org";`, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := l.WaitFor("route settings applied", 120*time.Millisecond); err != nil {
+		t.Fatal(err)
+	}
+
+	if r := route("0.95"); r == nil || r.Id != "r1" {
+		t.Fatalf("unexpected route after full coverage: %v", r)
+	}
+}
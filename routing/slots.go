@@ -0,0 +1,125 @@
+package routing
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"sort"
+	"sync"
+)
+
+// ErrUnknownSlot is returned when a deployment slot is not registered.
+var ErrUnknownSlot = errors.New("unknown deployment slot")
+
+// SlotRegistry contains the known deployment slots, e.g. "blue" and
+// "green", and the cohorts that are currently rolled back to one of them.
+// The rollbackTo filter reads the registry, and the rollbacks are engaged
+// and released with the admin API, see SlotRegistry.ServeHTTP.
+type SlotRegistry struct {
+	mu        sync.RWMutex
+	slots     map[string]bool
+	rollbacks map[string]string
+}
+
+// SlotRollback is a rolled back cohort, as listed by the admin API.
+type SlotRollback struct {
+	Cohort string `json:"cohort"`
+	Slot   string `json:"slot"`
+}
+
+// NewSlotRegistry creates a registry with the given deployment slots.
+func NewSlotRegistry(slots ...string) *SlotRegistry {
+	r := &SlotRegistry{
+		slots:     make(map[string]bool),
+		rollbacks: make(map[string]string),
+	}
+
+	for _, s := range slots {
+		r.slots[s] = true
+	}
+
+	return r
+}
+
+// Known tells whether the deployment slot is registered.
+func (r *SlotRegistry) Known(slot string) bool {
+	return r.slots[slot]
+}
+
+// Engage rolls back the traffic of the cohort to the deployment slot.
+func (r *SlotRegistry) Engage(cohort, slot string) error {
+	if !r.Known(slot) {
+		return ErrUnknownSlot
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.rollbacks[cohort] = slot
+	return nil
+}
+
+// Release ends the rollback of the cohort.
+func (r *SlotRegistry) Release(cohort string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.rollbacks, cohort)
+}
+
+// Rollback returns the deployment slot that the cohort is rolled back to,
+// if any.
+func (r *SlotRegistry) Rollback(cohort string) (string, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	s, ok := r.rollbacks[cohort]
+	return s, ok
+}
+
+// Rollbacks returns the current rollbacks, ordered by the cohort.
+func (r *SlotRegistry) Rollbacks() []SlotRollback {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	rb := make([]SlotRollback, 0, len(r.rollbacks))
+	for c, s := range r.rollbacks {
+		rb = append(rb, SlotRollback{Cohort: c, Slot: s})
+	}
+
+	sort.Slice(rb, func(i, j int) bool { return rb[i].Cohort < rb[j].Cohort })
+	return rb
+}
+
+// ServeHTTP implements the admin API of the rollbacks. GET lists the
+// current rollbacks as JSON, POST with the cohort and slot query parameters
+// engages a rollback, and DELETE with the cohort query parameter releases
+// it. Other methods are responded with 405.
+func (r *SlotRegistry) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	q := req.URL.Query()
+	cohort := q.Get("cohort")
+	switch req.Method {
+	case "GET":
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(r.Rollbacks())
+	case "POST":
+		if cohort == "" {
+			http.Error(w, "missing cohort", http.StatusBadRequest)
+			return
+		}
+
+		if err := r.Engage(cohort, q.Get("slot")); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+	case "DELETE":
+		if cohort == "" {
+			http.Error(w, "missing cohort", http.StatusBadRequest)
+			return
+		}
+
+		r.Release(cohort)
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
@@ -0,0 +1,70 @@
+package routing_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/zalando/skipper/routing"
+)
+
+func TestSlotRegistry(t *testing.T) {
+	slots := routing.NewSlotRegistry("blue", "green")
+	if !slots.Known("blue") || slots.Known("red") {
+		t.Error("invalid known slots")
+	}
+
+	if err := slots.Engage("canary", "red"); err != routing.ErrUnknownSlot {
+		t.Errorf("expected unknown slot error, got: %v", err)
+	}
+
+	if err := slots.Engage("canary", "blue"); err != nil {
+		t.Fatal(err)
+	}
+
+	if s, ok := slots.Rollback("canary"); !ok || s != "blue" {
+		t.Errorf("expected rollback to blue, got: %s, %v", s, ok)
+	}
+
+	slots.Release("canary")
+	if _, ok := slots.Rollback("canary"); ok {
+		t.Error("unexpected rollback after release")
+	}
+}
+
+func TestSlotRegistryAdminAPI(t *testing.T) {
+	slots := routing.NewSlotRegistry("blue", "green")
+	serve := func(method, url string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		slots.ServeHTTP(w, httptest.NewRequest(method, url, nil))
+		return w
+	}
+
+	for _, tt := range []struct {
+		method, url string
+		status      int
+	}{
+		{"POST", "/rollbacks?slot=blue", http.StatusBadRequest},
+		{"POST", "/rollbacks?cohort=canary&slot=red", http.StatusBadRequest},
+		{"POST", "/rollbacks?cohort=canary&slot=blue", http.StatusNoContent},
+		{"POST", "/rollbacks?cohort=beta&slot=green", http.StatusNoContent},
+		{"DELETE", "/rollbacks?cohort=beta", http.StatusNoContent},
+		{"PUT", "/rollbacks", http.StatusMethodNotAllowed},
+		{"HEAD", "/rollbacks", http.StatusMethodNotAllowed},
+	} {
+		if w := serve(tt.method, tt.url); w.Code != tt.status {
+			t.Errorf("%s %s: expected status %d, got: %d", tt.method, tt.url, tt.status, w.Code)
+		}
+	}
+
+	w := serve("GET", "/rollbacks")
+	var rb []routing.SlotRollback
+	if err := json.Unmarshal(w.Body.Bytes(), &rb); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(rb) != 1 || rb[0] != (routing.SlotRollback{Cohort: "canary", Slot: "blue"}) {
+		t.Errorf("unexpected rollbacks: %v", rb)
+	}
+}
@@ -519,7 +519,8 @@ func (r *Registry) deleteUnused(inUse map[queueId]struct{}) {
 	}
 }
 
-// Returns routing.PreProcessor that ensures single lifo filter instance per route
+// Returns routing.PreProcessor that ensures single lifo and single fifo or
+// queueConcurrency filter instance per route
 //
 // Registry can not implement routing.PreProcessor directly due to unfortunate method name clash with routing.PostProcessor
 func (r *Registry) PreProcessor() routing.PreProcessor {
@@ -534,18 +535,19 @@ func (registryPreProcessor) Do(routes []*eskip.Route) []*eskip.Route {
 		fifoCount := 0
 		for _, f := range r.Filters {
 			switch f.Name {
-			case filters.FifoName:
+			case filters.FifoName, filters.QueueConcurrencyName:
 				fifoCount++
 			case filters.LifoName:
 				lifoCount++
 			}
 		}
-		// remove all but last fifo instances
+		// remove all but last fifo or queueConcurrency instances, as they
+		// share the fifo queue of the route
 		if fifoCount > 1 {
 			old := r.Filters
 			r.Filters = make([]*eskip.Filter, 0, len(old)-fifoCount+1)
 			for _, f := range old {
-				if fifoCount > 1 && f.Name == filters.FifoName {
+				if fifoCount > 1 && (f.Name == filters.FifoName || f.Name == filters.QueueConcurrencyName) {
 					log.Debugf("Removing non-last %v from %s", f, r.Id)
 					fifoCount--
 				} else {
@@ -690,6 +690,16 @@ func TestRegistryPreProcessor(t *testing.T) {
 			input:  `* -> fifo(2, 2, "3s") -> fifo(20, 2, "3s") -> setPath("/foo") -> <shunt>`,
 			expect: `* -> fifo(20, 2, "3s") -> setPath("/foo") -> <shunt>`,
 		},
+		{
+			name:   "fifo and queueConcurrency",
+			input:  `* -> queueConcurrency(2, 2, "3s") -> fifo(20, 2, "3s") -> setPath("/foo") -> <shunt>`,
+			expect: `* -> fifo(20, 2, "3s") -> setPath("/foo") -> <shunt>`,
+		},
+		{
+			name:   "queueConcurrency and fifo",
+			input:  `* -> fifo(20, 2, "3s") -> setPath("/foo") -> queueConcurrency(2, 2, "3s") -> <shunt>`,
+			expect: `* -> setPath("/foo") -> queueConcurrency(2, 2, "3s") -> <shunt>`,
+		},
 		{
 			name:   "three lifos",
 			input:  `* -> lifo(777) -> setPath("/foo") -> lifo(999) -> lifo() -> setPath("/bar") -> <shunt>`,
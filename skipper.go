@@ -20,11 +20,14 @@ import (
 	stdlog "log"
 
 	ot "github.com/opentracing/opentracing-go"
+	"github.com/oschwald/maxminddb-golang"
 	"github.com/prometheus/client_golang/prometheus"
 	log "github.com/sirupsen/logrus"
 
 	"github.com/zalando/skipper/circuit"
+	"github.com/zalando/skipper/dataclients/consul"
 	"github.com/zalando/skipper/dataclients/kubernetes"
+	"github.com/zalando/skipper/dataclients/objectstore"
 	"github.com/zalando/skipper/dataclients/routestring"
 	"github.com/zalando/skipper/eskip"
 	"github.com/zalando/skipper/eskipfile"
@@ -43,20 +46,34 @@ import (
 	"github.com/zalando/skipper/logging"
 	"github.com/zalando/skipper/metrics"
 	skpnet "github.com/zalando/skipper/net"
+	"github.com/zalando/skipper/predicates/asn"
 	pauth "github.com/zalando/skipper/predicates/auth"
+	"github.com/zalando/skipper/predicates/bloom"
+	"github.com/zalando/skipper/predicates/burst"
+	"github.com/zalando/skipper/predicates/connection"
 	"github.com/zalando/skipper/predicates/content"
 	"github.com/zalando/skipper/predicates/cookie"
 	"github.com/zalando/skipper/predicates/cron"
+	"github.com/zalando/skipper/predicates/dependency"
+	"github.com/zalando/skipper/predicates/errorrate"
 	"github.com/zalando/skipper/predicates/forwarded"
+	"github.com/zalando/skipper/predicates/header"
+	"github.com/zalando/skipper/predicates/healthcheck"
 	"github.com/zalando/skipper/predicates/host"
 	"github.com/zalando/skipper/predicates/interval"
 	"github.com/zalando/skipper/predicates/methods"
+	"github.com/zalando/skipper/predicates/prefixset"
 	"github.com/zalando/skipper/predicates/primitive"
 	"github.com/zalando/skipper/predicates/query"
+	"github.com/zalando/skipper/predicates/requesttarget"
+	"github.com/zalando/skipper/predicates/score"
+	"github.com/zalando/skipper/predicates/session"
 	"github.com/zalando/skipper/predicates/source"
 	"github.com/zalando/skipper/predicates/tee"
 	"github.com/zalando/skipper/predicates/traffic"
+	"github.com/zalando/skipper/predicates/useragent"
 	"github.com/zalando/skipper/proxy"
+	"github.com/zalando/skipper/proxyprotocol"
 	"github.com/zalando/skipper/queuelistener"
 	"github.com/zalando/skipper/ratelimit"
 	"github.com/zalando/skipper/routing"
@@ -101,6 +118,17 @@ type Options struct {
 	// Insecure network address skipper should listen on when TLS is enabled
 	InsecureAddress string
 
+	// UnixSocketAddress is the path of an additional Unix domain socket
+	// that skipper listens on, serving plain HTTP. The requests received
+	// on it can be matched with the UnixSocket() predicate.
+	UnixSocketAddress string
+
+	// AcceptProxyProtocol enables accepting the PROXY protocol header,
+	// version 1 or 2, on the connections of the main and the insecure
+	// listener, to get the real client address behind L4 load balancers.
+	// When enabled, the connections without a valid header are rejected.
+	AcceptProxyProtocol bool
+
 	// EnableTCPQueue enables controlling the
 	// concurrently processed requests at the TCP listener.
 	EnableTCPQueue bool
@@ -290,6 +318,23 @@ type Options struct {
 	// InlineRoutes can define routes as eskip text.
 	InlineRoutes string
 
+	// ConsulAddress is the address of the Consul HTTP API. When set,
+	// routes are generated from the services of the Consul catalog.
+	ConsulAddress string
+
+	// ConsulServiceTag is the tag of the Consul services to generate
+	// routes for, defaults to "skipper".
+	ConsulServiceTag string
+
+	// RoutesObjectURL is the URL of an object in eskip format, in an S3
+	// or GCS bucket, e.g. s3://my-bucket/routes.eskip. When set, the
+	// routes are polled from the object.
+	RoutesObjectURL string
+
+	// RoutesObjectEndpoint overrides the address of the object store API
+	// of RoutesObjectURL, e.g. of an S3 compatible store.
+	RoutesObjectEndpoint string
+
 	// Polling timeout of the routing data sources.
 	SourcePollTimeout time.Duration
 
@@ -375,6 +420,21 @@ type Options struct {
 	// limit.
 	MaxIdleConnsBackend int
 
+	// MaxConnsPerHostBackend sets MaxConnsPerHost, which limits the
+	// number of connections to a backend host, 0 means no limit.
+	MaxConnsPerHostBackend int
+
+	// FailFastCohorts lists the TrafficSegment cohorts, whose requests
+	// fail fast when the connections to the backend host are saturated.
+	// See proxy.Params.FailFastCohorts.
+	FailFastCohorts []string
+
+	// DeploymentSlots lists the known deployment slots, that the cohorts
+	// can be rolled back to with the rollbackTo filter. When set, the
+	// support listener serves the admin API of the rollbacks under
+	// /rollbacks. See routing.SlotRegistry.
+	DeploymentSlots []string
+
 	// DisableHTTPKeepalives sets DisableKeepAlives, which forces
 	// a backend to always create a new connection.
 	DisableHTTPKeepalives bool
@@ -413,6 +473,11 @@ type Options struct {
 	// instead of full details of the updated/deleted routes.
 	SuppressRouteUpdateLogs bool
 
+	// SegmentGapBackend, when set, enables generating the routes to this
+	// backend address for the intervals of the traffic splits not covered
+	// by the TrafficSegment predicates. See routing.Options.
+	SegmentGapBackend string
+
 	// Dev mode. Currently this flag disables prioritization of the
 	// consumer side over the feeding side during the routing updates to
 	// populate the updated routes faster.
@@ -569,6 +634,10 @@ type Options struct {
 	// Disables the access log.
 	AccessLogDisabled bool
 
+	// AccessLogCohortSampleRates sets the access log sampling rates per
+	// traffic cohort. See proxy.Params.AccessLogCohortSampleRates.
+	AccessLogCohortSampleRates map[string]float64
+
 	// Enables logs in JSON format
 	AccessLogJSONEnabled bool
 
@@ -637,6 +706,14 @@ type Options struct {
 	// EnableRouteLIFOMetrics enables metrics for the individual route LIFO queues, if any.
 	EnableRouteLIFOMetrics bool
 
+	// EnablePredicateMetrics enables counting the matches and no-matches of
+	// the custom predicates, per predicate name and route id.
+	EnablePredicateMetrics bool
+
+	// PredicateMetricsMaxRoutes limits the number of routes whose predicate
+	// metrics contain the route id. Default: 100.
+	PredicateMetricsMaxRoutes int
+
 	// OpenTracing enables opentracing
 	OpenTracing []string
 
@@ -717,6 +794,10 @@ type Options struct {
 	// header, in this case you want to set this to true.
 	ReverseSourcePredicate bool
 
+	// ClientASNDatabase is the path of a MaxMind ASN database, e.g.
+	// GeoLite2-ASN.mmdb. When set, the ClientASN predicate is enabled.
+	ClientASNDatabase string
+
 	// EnableOAuth2GrantFlow, enables OAuth2 Grant Flow filter
 	EnableOAuth2GrantFlow bool
 
@@ -801,6 +882,36 @@ type Options struct {
 	// CompressEncodings, if not empty replace default compression encodings
 	CompressEncodings []string
 
+	// EnableErrorEnrich enables the errorEnrich filter, that exposes the
+	// routing details of the cohort traffic in the error responses. It is
+	// meant only for test environments.
+	EnableErrorEnrich bool
+
+	// TeeKafkaBrokers, when set, configures the default producer of the
+	// teeToKafka filter. The filter is registered only, when the brokers
+	// or the TeeKafkaProducers are set.
+	TeeKafkaBrokers []string
+
+	// TeeKafkaProducers defines additional named producers for the
+	// teeToKafka filter.
+	TeeKafkaProducers map[string]teefilters.KafkaProducer
+
+	// TeeKafkaMaxBodySize caps the size of the request body copied by
+	// the teeToKafka filter, defaults to 64KB.
+	TeeKafkaMaxBodySize int64
+
+	// CohortWALFile, when set, configures the default sink of the
+	// cohortWAL filter, appending the records to the file.
+	CohortWALFile string
+
+	// CohortWALSinks defines additional named sinks for the cohortWAL
+	// filter.
+	CohortWALSinks map[string]builtin.CohortWALSink
+
+	// CohortWALBufferSize is the number of the records buffered per sink
+	// of the cohortWAL filter, defaults to 1024.
+	CohortWALBufferSize int
+
 	// OIDCSecretsFile path to the file containing key to encrypt OpenID token
 	OIDCSecretsFile string
 
@@ -887,6 +998,14 @@ type Options struct {
 	LuaSources []string
 
 	testOptions
+
+	// queueDepth is shared by the TCP queue listeners and the
+	// QueuePositionBelow predicates
+	queueDepth *queuelistener.QueueDepth
+
+	// routeCount is shared by the routing and the RouteCountAbove
+	// predicates
+	routeCount *routing.RouteCount
 }
 
 type serverErrorLogWriter struct{}
@@ -953,6 +1072,32 @@ func createDataClients(o Options, cr *certregistry.CertRegistry) ([]routing.Data
 		clients = append(clients, ir)
 	}
 
+	if o.ConsulAddress != "" {
+		consulClient, err := consul.New(consul.Options{
+			Address: o.ConsulAddress,
+			Tag:     o.ConsulServiceTag,
+			Timeout: o.SourcePollTimeout,
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		clients = append(clients, consulClient)
+	}
+
+	if o.RoutesObjectURL != "" {
+		objectClient, err := objectstore.New(objectstore.Options{
+			URL:      o.RoutesObjectURL,
+			Endpoint: o.RoutesObjectEndpoint,
+			Timeout:  o.SourcePollTimeout,
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		clients = append(clients, objectClient)
+	}
+
 	if len(o.EtcdUrls) > 0 {
 		etcdClient, err := etcd.New(etcd.Options{
 			Endpoints:  o.EtcdUrls,
@@ -1075,6 +1220,11 @@ func (o *Options) filterRegistry() filters.Registry {
 		}
 	}
 
+	// looks up the filters of its chains in the final registry
+	if _, ok := disabledFilters[filters.TransformByContentTypeName]; !ok {
+		registry.Register(builtin.NewTransformByContentType(registry))
+	}
+
 	for _, f := range o.CustomFilters {
 		if _, ok := disabledFilters[f.Name()]; !ok {
 			registry.Register(f)
@@ -1129,6 +1279,43 @@ func (o *Options) tlsConfig(cr *certregistry.CertRegistry) (*tls.Config, error)
 }
 
 func listen(o *Options, address string, mtr metrics.Metrics) (net.Listener, error) {
+	l, err := listenTCP(o, address, mtr)
+	if err != nil {
+		return nil, err
+	}
+
+	return withProxyProtocol(o, l), nil
+}
+
+// listenUnix listens on the Unix domain socket. The socket file left behind
+// by an earlier process that didn't exit cleanly is removed, while other
+// kinds of files at the path are kept.
+func listenUnix(path string) (net.Listener, error) {
+	if fi, err := os.Lstat(path); err == nil && fi.Mode()&os.ModeSocket != 0 {
+		if err := os.Remove(path); err != nil {
+			return nil, err
+		}
+	}
+
+	return net.Listen("unix", path)
+}
+
+// withProxyProtocol wraps the listener to accept the PROXY protocol header,
+// when enabled.
+func withProxyProtocol(o *Options, l net.Listener) net.Listener {
+	if !o.AcceptProxyProtocol {
+		return l
+	}
+
+	timeout := o.ReadHeaderTimeoutServer
+	if timeout <= 0 {
+		timeout = o.ReadTimeoutServer
+	}
+
+	return proxyprotocol.NewListener(l, proxyprotocol.Options{HeaderTimeout: timeout})
+}
+
+func listenTCP(o *Options, address string, mtr metrics.Metrics) (net.Listener, error) {
 	if address == "" {
 		address = ":http"
 	}
@@ -1182,6 +1369,7 @@ func listen(o *Options, address string, mtr metrics.Metrics) (net.Listener, erro
 		ConnectionBytes:  o.ExpectedBytesPerRequest,
 		QueueTimeout:     qto,
 		Metrics:          mtr,
+		QueueDepth:       o.queueDepth,
 	})
 }
 
@@ -1208,6 +1396,7 @@ func listenAndServeQuit(
 		IdleTimeout:       o.IdleTimeoutServer,
 		MaxHeaderBytes:    o.MaxHeaderBytes,
 		ErrorLog:          newServerErrorLog(),
+		ConnContext:       skpnet.ConnContext,
 	}
 
 	if o.EnableConnMetricsServer {
@@ -1244,6 +1433,21 @@ func listenAndServeQuit(
 
 	log.Infof("proxy listener on %v", o.Address)
 
+	if o.UnixSocketAddress != "" {
+		l, err := listenUnix(o.UnixSocketAddress)
+		if err != nil {
+			return fmt.Errorf("failed to start unix socket listener on %s: %w", o.UnixSocketAddress, err)
+		}
+
+		log.Infof("unix socket listener on %v", o.UnixSocketAddress)
+
+		go func() {
+			if err := srv.Serve(l); err != http.ErrServerClosed {
+				log.Errorf("Unix socket listener serve failed: %v", err)
+			}
+		}()
+	}
+
 	if srv.TLSConfig != nil {
 		if o.InsecureAddress != "" {
 			log.Infof("insecure listener on %v", o.InsecureAddress)
@@ -1260,7 +1464,22 @@ func listenAndServeQuit(
 			}()
 		}
 
-		if err := srv.ListenAndServeTLS("", ""); err != http.ErrServerClosed {
+		if o.AcceptProxyProtocol {
+			address := o.Address
+			if address == "" {
+				address = ":https"
+			}
+
+			l, err := net.Listen("tcp", address)
+			if err != nil {
+				return err
+			}
+
+			if err := srv.ServeTLS(withProxyProtocol(o, l), "", ""); err != http.ErrServerClosed {
+				log.Errorf("ServeTLS failed: %v", err)
+				return err
+			}
+		} else if err := srv.ListenAndServeTLS("", ""); err != http.ErrServerClosed {
 			log.Errorf("ListenAndServeTLS failed: %v", err)
 			return err
 		}
@@ -1527,6 +1746,8 @@ func run(o Options, sig chan os.Signal, idleConnsCH chan struct{}) error {
 		block.NewBlock(o.MaxMatcherBufferSize),
 		block.NewBlockHex(o.MaxMatcherBufferSize),
 		auth.NewBearerInjector(sp),
+		auth.NewSignResponse(sp),
+		auth.NewVerifyEd25519(sp),
 		auth.NewJwtValidationWithOptions(tio),
 		auth.TokenintrospectionWithOptions(auth.NewOAuthTokenintrospectionAnyClaims, tio),
 		auth.TokenintrospectionWithOptions(auth.NewOAuthTokenintrospectionAllClaims, tio),
@@ -1677,6 +1898,7 @@ func run(o Options, sig chan os.Signal, idleConnsCH chan struct{}) error {
 		provider := ratelimitfilters.NewRatelimitProvider(ratelimitRegistry)
 		o.CustomFilters = append(o.CustomFilters,
 			ratelimitfilters.NewFailClosed(),
+			ratelimitfilters.NewRatelimitHeaders(),
 			ratelimitfilters.NewClientRatelimit(provider),
 			ratelimitfilters.NewLocalRatelimit(provider),
 			ratelimitfilters.NewRatelimit(provider),
@@ -1691,6 +1913,22 @@ func run(o Options, sig chan os.Signal, idleConnsCH chan struct{}) error {
 		}
 	}
 
+	if redisOptions != nil {
+		// share the webhook delivery IDs between the skipper instances
+		dedupRing := skpnet.NewRedisRingClient(redisOptions)
+		defer dedupRing.Close()
+
+		o.CustomFilters = append(o.CustomFilters, builtin.NewWebhookDedupWithOptions(builtin.WebhookDedupOptions{
+			Store: builtin.NewRedisDedupStore(dedupRing),
+		}))
+	}
+
+	var slots *routing.SlotRegistry
+	if len(o.DeploymentSlots) > 0 {
+		slots = routing.NewSlotRegistry(o.DeploymentSlots...)
+		o.CustomFilters = append(o.CustomFilters, builtin.NewRollbackTo(slots))
+	}
+
 	if o.TLSMinVersion == 0 {
 		o.TLSMinVersion = tls.VersionTLS12
 	}
@@ -1744,6 +1982,77 @@ func run(o Options, sig chan os.Signal, idleConnsCH chan struct{}) error {
 		o.CustomFilters = append(o.CustomFilters, compress)
 	}
 
+	if o.EnableErrorEnrich {
+		o.CustomFilters = append(o.CustomFilters, builtin.NewErrorEnrich())
+	}
+
+	kafkaProducers := make(map[string]teefilters.KafkaProducer)
+	for name, p := range o.TeeKafkaProducers {
+		kafkaProducers[name] = p
+	}
+
+	if len(o.TeeKafkaBrokers) > 0 {
+		p, err := teefilters.NewKafkaProducer(o.TeeKafkaBrokers, mtr)
+		if err != nil {
+			log.Errorf("Failed to create kafka producer: %v.", err)
+			return err
+		}
+		defer p.Close()
+
+		kafkaProducers[teefilters.DefaultKafkaProducer] = p
+	}
+
+	if len(kafkaProducers) > 0 {
+		o.CustomFilters = append(o.CustomFilters, teefilters.NewTeeToKafka(teefilters.KafkaOptions{
+			Producers:   kafkaProducers,
+			MaxBodySize: o.TeeKafkaMaxBodySize,
+		}))
+	}
+
+	cohortWALSinks := make(map[string]builtin.CohortWALSink)
+	for name, s := range o.CohortWALSinks {
+		cohortWALSinks[name] = s
+	}
+
+	if o.CohortWALFile != "" {
+		s, err := builtin.NewFileCohortWALSink(o.CohortWALFile)
+		if err != nil {
+			log.Errorf("Failed to open cohort WAL file: %v.", err)
+			return err
+		}
+		defer s.Close()
+
+		cohortWALSinks[builtin.DefaultCohortWALSink] = s
+	}
+
+	cohortWAL := builtin.NewCohortWAL(builtin.CohortWALOptions{
+		Sinks:      cohortWALSinks,
+		BufferSize: o.CohortWALBufferSize,
+		Metrics:    mtr,
+	})
+	defer cohortWAL.Close()
+	o.CustomFilters = append(o.CustomFilters, cohortWAL)
+
+	replaySpec := teefilters.NewReplaySynthesize(teefilters.ReplayOptions{Metrics: mtr})
+	defer replaySpec.Close()
+	o.CustomFilters = append(o.CustomFilters, replaySpec)
+
+	autoKillSpec := builtin.NewAutoKill()
+	o.CustomFilters = append(o.CustomFilters, autoKillSpec)
+
+	cohortApdexSpec := builtin.NewCohortApdex()
+	o.CustomFilters = append(o.CustomFilters, cohortApdexSpec)
+
+	canarySeqSpec := builtin.NewCanarySeq()
+	o.CustomFilters = append(o.CustomFilters, canarySeqSpec)
+
+	wasmSpec := builtin.NewWasmResponse()
+	defer wasmSpec.Close()
+	o.CustomFilters = append(o.CustomFilters, wasmSpec)
+
+	failoverReconcileSpec := builtin.NewFailoverReconcile()
+	o.CustomFilters = append(o.CustomFilters, failoverReconcileSpec)
+
 	lua, err := script.NewLuaScriptWithOptions(script.LuaOptions{
 		Modules: o.LuaModules,
 		Sources: o.LuaSources,
@@ -1772,33 +2081,90 @@ func run(o Options, sig chan os.Signal, idleConnsCH chan struct{}) error {
 		updateBuffer = 0
 	}
 
+	if o.ClientASNDatabase != "" {
+		asnDB, err := maxminddb.Open(o.ClientASNDatabase)
+		if err != nil {
+			return fmt.Errorf("failed to open client ASN database: %w", err)
+		}
+		defer asnDB.Close()
+
+		o.CustomPredicates = append(o.CustomPredicates, asn.New(asnDB))
+	}
+
+	bloomSpec := bloom.New(0)
+	defer bloomSpec.Close()
+
+	decaySpec := traffic.NewDecay()
+
+	o.queueDepth = &queuelistener.QueueDepth{}
+	o.routeCount = &routing.RouteCount{}
+
+	var breakerRegistry *circuit.Registry
+	if o.EnableBreakers || len(o.BreakerSettings) > 0 {
+		breakerRegistry = circuit.NewRegistry(o.BreakerSettings...)
+	}
+
 	// include bundled custom predicates
 	o.CustomPredicates = append(o.CustomPredicates,
 		source.New(),
 		source.NewFromLast(),
 		source.NewClientIP(),
+		source.NewUnixSocket(),
 		interval.NewBetween(),
 		interval.NewBefore(),
 		interval.NewAfter(),
+		interval.NewUntil(),
 		cron.New(),
 		cookie.New(),
+		session.New(0),
+		burst.New(0),
+		dependency.New(breakerRegistry),
+		errorrate.New(),
 		query.New(),
 		traffic.New(),
 		traffic.NewSegment(),
+		traffic.NewSegmentExcept(),
+		traffic.NewExperiment(),
+		decaySpec,
+		traffic.NewURLSegment(),
+		traffic.NewSessionPathSegment(),
+		traffic.NewClusterSegment(),
+		score.New(),
+		healthcheck.New(),
+		prefixset.New(),
+		bloomSpec,
 		primitive.NewTrue(),
 		primitive.NewFalse(),
 		primitive.NewShutdown(),
+		primitive.NewQueuePositionBelow(o.queueDepth),
+		primitive.NewRouteCountAbove(o.routeCount),
 		pauth.NewJWTPayloadAllKV(),
 		pauth.NewJWTPayloadAnyKV(),
 		pauth.NewJWTPayloadAllKVRegexp(),
 		pauth.NewJWTPayloadAnyKVRegexp(),
+		pauth.NewJWTExpiringWithin(),
 		pauth.NewHeaderSHA256(),
+		header.NewAbsent(),
+		header.NewEntropyAbove(),
+		header.NewCacheControl(),
+		header.NewHasTrailer(),
+		header.NewConditional(),
+		requesttarget.New(),
+		connection.NewReused(),
+		useragent.New(),
 		methods.New(),
+		methods.NewSafe(),
+		methods.NewUnsafe(),
 		tee.New(),
 		forwarded.NewForwardedHost(),
 		forwarded.NewForwardedProto(),
 		host.NewAny(),
+		host.NewSNI(),
+		host.NewSNIRegexp(),
+		host.NewClientCertSAN(),
 		content.NewContentLengthBetween(),
+		content.NewDecompressedSizeBelow(),
+		content.NewDecompressedSizeAbove(),
 	)
 
 	// provide default value for wrapper if not defined
@@ -1830,8 +2196,22 @@ func run(o Options, sig chan os.Signal, idleConnsCH chan struct{}) error {
 			builtin.NewRouteCreationMetrics(mtr),
 			fadein.NewPostProcessor(),
 			admissionControlSpec.PostProcessor(),
+			replaySpec.PostProcessor(),
+			autoKillSpec.PostProcessor(),
+			cohortApdexSpec.PostProcessor(),
+			canarySeqSpec.PostProcessor(),
+			decaySpec.PostProcessor(),
+			bloomSpec.PostProcessor(),
 		},
-		SignalFirstLoad: o.WaitFirstRouteLoad,
+		SignalFirstLoad:           o.WaitFirstRouteLoad,
+		PredicateMetricsMaxRoutes: o.PredicateMetricsMaxRoutes,
+		RouteExpiryMetrics:        mtr,
+		RouteCount:                o.routeCount,
+		SegmentGapBackend:         o.SegmentGapBackend,
+	}
+
+	if o.EnablePredicateMetrics {
+		ro.PredicateMetrics = mtr
 	}
 
 	if lbInstance != nil {
@@ -1893,15 +2273,15 @@ func run(o Options, sig chan os.Signal, idleConnsCH chan struct{}) error {
 		DualStack:                  o.DualStackBackend,
 		TLSHandshakeTimeout:        o.TLSHandshakeTimeoutBackend,
 		MaxIdleConns:               o.MaxIdleConnsBackend,
+		MaxConnsPerHost:            o.MaxConnsPerHostBackend,
+		FailFastCohorts:            o.FailFastCohorts,
 		DisableHTTPKeepalives:      o.DisableHTTPKeepalives,
 		AccessLogDisabled:          o.AccessLogDisabled,
+		AccessLogCohortSampleRates: o.AccessLogCohortSampleRates,
 		ClientTLS:                  o.ClientTLS,
 		CustomHttpRoundTripperWrap: o.CustomHttpRoundTripperWrap,
 		RateLimiters:               ratelimitRegistry,
-	}
-
-	if o.EnableBreakers || len(o.BreakerSettings) > 0 {
-		proxyParams.CircuitBreakers = circuit.NewRegistry(o.BreakerSettings...)
+		CircuitBreakers:            breakerRegistry,
 	}
 
 	if o.DebugListener != "" {
@@ -1924,6 +2304,10 @@ func run(o Options, sig chan os.Signal, idleConnsCH chan struct{}) error {
 		mux := http.NewServeMux()
 		mux.Handle("/routes", routing)
 		mux.Handle("/routes/", routing)
+		mux.Handle("/lbstats", routing.LBStatsHandler())
+		if slots != nil {
+			mux.Handle("/rollbacks", slots)
+		}
 
 		metricsHandler := metrics.NewHandler(mtrOpts, mtr)
 		mux.Handle("/metrics", metricsHandler)
@@ -1955,6 +2339,9 @@ func run(o Options, sig chan os.Signal, idleConnsCH chan struct{}) error {
 	proxy := proxy.WithParams(proxyParams)
 	defer proxy.Close()
 
+	// the failed canary requests are replayed with the backend transport
+	failoverReconcileSpec.SetTransport(proxy.RoundTripper())
+
 	for _, startupCheckURL := range o.StatusChecks {
 		for {
 			/* #nosec */
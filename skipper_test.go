@@ -10,6 +10,7 @@ import (
 	stdlibhttptest "net/http/httptest"
 	"net/url"
 	"os"
+	"path/filepath"
 	"syscall"
 	"testing"
 	"time"
@@ -164,6 +165,39 @@ func TestOptionsTLSConfig(t *testing.T) {
 	require.Equal(t, []tls.Certificate{cert, cert2}, c.Certificates)
 }
 
+func TestListenUnixStaleSocket(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "skipper.sock")
+
+	// a socket file left behind by an unclean exit
+	stale, err := net.Listen("unix", path)
+	require.NoError(t, err)
+	stale.(*net.UnixListener).SetUnlinkOnClose(false)
+	require.NoError(t, stale.Close())
+	require.FileExists(t, path)
+
+	l, err := listenUnix(path)
+	require.NoError(t, err)
+	defer l.Close()
+
+	go http.Serve(l, http.HandlerFunc(func(http.ResponseWriter, *http.Request) {}))
+
+	conn, err := net.Dial("unix", path)
+	require.NoError(t, err)
+	conn.Close()
+}
+
+func TestListenUnixKeepsOtherFiles(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "skipper.sock")
+	require.NoError(t, os.WriteFile(path, []byte("data"), 0o644))
+
+	_, err := listenUnix(path)
+	require.Error(t, err)
+
+	b, err := os.ReadFile(path)
+	require.NoError(t, err)
+	require.Equal(t, "data", string(b))
+}
+
 func TestOptionsTLSConfigInvalidPaths(t *testing.T) {
 	cr := certregistry.NewCertRegistry()
 